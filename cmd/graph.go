@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/graph"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// 可视化配置
+	vizFormat string
+	vizOutput string
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "📊 图工作流工具",
+	Long: `📊 图工作流工具
+
+处理通过 Graph.MarshalJSON 序列化（或手写）的图定义文件。`,
+}
+
+// 可视化命令
+var graphVizCmd = &cobra.Command{
+	Use:   "viz [graph.json]",
+	Short: "将图定义导出为 Mermaid 或 DOT 图表",
+	Long: `将图定义导出为 Mermaid 或 DOT 图表
+
+渲染所有节点、入口点以及每条边的类型：条件边和默认边以虚线表示并标注条件，
+设置了优先级的边在标签中显示优先级。
+
+图定义中引用的函数和条件无需注册，仅用于可视化。
+
+支持的格式:
+  • mermaid - Mermaid 流程图，可嵌入 Markdown
+  • dot     - Graphviz DOT，可通过 dot -Tsvg 渲染
+
+未指定 --format 时根据输出文件扩展名选择（.dot/.gv 为 dot），默认 mermaid。`,
+	Example: `  # 输出 Mermaid 流程图
+  langchaingo-cn graph viz workflow.json
+
+  # 导出 DOT 并渲染为 SVG
+  langchaingo-cn graph viz workflow.json -o workflow.dot
+  dot -Tsvg workflow.dot -o workflow.svg`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g, err := loadGraphForViz(args[0])
+		if err != nil {
+			log.Fatal("❌ 加载图定义失败: ", err)
+		}
+
+		format := vizFormat
+		if format == "" {
+			switch strings.ToLower(filepath.Ext(vizOutput)) {
+			case ".dot", ".gv":
+				format = "dot"
+			default:
+				format = "mermaid"
+			}
+		}
+
+		var diagram string
+		switch format {
+		case "mermaid":
+			diagram = g.ExportMermaid()
+		case "dot":
+			diagram = g.ExportDOT()
+		default:
+			log.Fatalf("❌ 不支持的格式: %s (可选 mermaid, dot)", format)
+		}
+
+		if vizOutput == "" {
+			fmt.Print(diagram)
+			return
+		}
+		if err := os.WriteFile(vizOutput, []byte(diagram), 0o644); err != nil {
+			log.Fatal("❌ 写入文件失败: ", err)
+		}
+		fmt.Printf("✅ 已导出 %s 图表: %s\n", format, vizOutput)
+	},
+}
+
+func init() {
+	graphVizCmd.Flags().StringVarP(&vizFormat, "format", "f", "", "输出格式 (mermaid, dot)")
+	graphVizCmd.Flags().StringVarP(&vizOutput, "output", "o", "", "输出文件 (默认输出到标准输出)")
+
+	graphCmd.AddCommand(graphVizCmd)
+}
+
+// loadGraphForViz 读取图定义，以占位函数绑定其中引用的函数和条件
+func loadGraphForViz(path string) (*graph.Graph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition graph.GraphDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("解析图定义失败: %w", err)
+	}
+
+	registry := graph.NewFunctionRegistry()
+	registerPlaceholders(registry, definition.Nodes)
+	return graph.NewGraph(definition.ID).
+		WithDefinition(&definition).
+		WithFunctions(registry).
+		BuildE()
+}
+
+// registerPlaceholders 为节点（含子图和并行子节点）引用的函数和循环条件注册占位实现
+func registerPlaceholders(registry *graph.FunctionRegistry, nodes []*graph.NodeDefinition) {
+	placeholder := func(ctx context.Context, state *graph.State) (*graph.State, error) {
+		return state, nil
+	}
+	router := func(ctx context.Context, state *graph.State) (string, error) {
+		return "", nil
+	}
+	condition := func(ctx context.Context, state *graph.State) (bool, error) {
+		return false, nil
+	}
+
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		name := node.Function
+		if name == "" {
+			name = node.ID
+		}
+		registry.Register(name, placeholder)
+		registry.RegisterRouter(name, router)
+		if node.Condition != "" {
+			if _, ok := graph.LookupCondition(node.Condition); !ok {
+				graph.RegisterCondition(node.Condition, condition)
+			}
+		}
+
+		registerPlaceholders(registry, node.Children)
+		if node.SubGraph != nil {
+			registerPlaceholders(registry, node.SubGraph.Nodes)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/spf13/cobra"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/prompts"
+)
+
+var (
+	// 模板配置
+	playTemplate string
+	playVars     []string
+
+	// 模型配置
+	playModels      []string
+	playProvider    string
+	playTemperature float64
+	playMaxTokens   int
+	playTimeout     time.Duration
+
+	// 费用配置（元/百万token）
+	playPriceIn  float64
+	playPriceOut float64
+
+	// 交互模式
+	playInteractive bool
+)
+
+var playCmd = &cobra.Command{
+	Use:   "play",
+	Short: "🎮 提示词调试场",
+	Long: `🎮 提示词调试场
+
+渲染提示词模板（Go text/template 语法，如 {{.name}}），发送给指定模型，
+流式输出结果并统计耗时、token 用量与费用。
+
+指定多个 --model 时会依次运行并输出对比汇总；使用 --interactive 可在运行结束后
+输入新的模型名快速重新运行。
+
+模型提供商默认根据模型名推断:
+  • deepseek-*          → deepseek
+  • moonshot-* / kimi-* → kimi
+  • qwen-*              → qwen
+  • glm-* / charglm-*   → zhipu
+  • ernie-*             → ernie
+  • abab* / minimax-*   → minimax
+  • doubao-* / ep-*     → doubao
+  • yi-*                → yi
+  • baichuan*           → baichuan
+  • gpt-* / o1-* / o3-* → openai
+  • claude-*            → anthropic
+无法推断时请通过 --llm 指定。`,
+	Example: `  # 渲染模板并发送给 DeepSeek
+  langchaingo-cn play --template prompt.tmpl --var name=张三 --model deepseek-chat
+
+  # 对比多个模型
+  langchaingo-cn play -t prompt.tmpl --var topic=量子计算 --model deepseek-chat --model qwen-plus
+
+  # 计算费用（元/百万token）
+  langchaingo-cn play -t prompt.tmpl --model deepseek-chat --price-in 2 --price-out 8
+
+  # 运行结束后交互式切换模型
+  langchaingo-cn play -t prompt.tmpl --model glm-4 -i`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		prompt, err := renderPlayTemplate(playTemplate, playVars)
+		if err != nil {
+			log.Fatal("❌ 渲染模板失败: ", err)
+		}
+
+		if verbose {
+			fmt.Println("📝 提示词:")
+			fmt.Println(prompt)
+			fmt.Println()
+		}
+
+		var results []playResult
+		for _, model := range playModels {
+			results = append(results, runPlay(model, prompt))
+		}
+
+		if playInteractive {
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				fmt.Print("\n🔁 输入模型名重新运行（直接回车退出）: ")
+				line, err := reader.ReadString('\n')
+				model := strings.TrimSpace(line)
+				if model == "" {
+					break
+				}
+				results = append(results, runPlay(model, prompt))
+				if err != nil {
+					break
+				}
+			}
+		}
+
+		if len(results) > 1 {
+			printPlaySummary(results)
+		}
+	},
+}
+
+func init() {
+	playCmd.Flags().StringVarP(&playTemplate, "template", "t", "", "提示词模板文件")
+	playCmd.Flags().StringArrayVar(&playVars, "var", nil, "模板变量 name=value，可重复指定")
+	playCmd.Flags().StringArrayVarP(&playModels, "model", "m", nil, "模型名称，可重复指定以对比多个模型")
+	playCmd.Flags().StringVar(&playProvider, "llm", "", "模型提供商 (deepseek, kimi, qwen, zhipu, siliconflow, ernie, minimax, doubao, yi, baichuan, openai, anthropic, ollama)，默认根据模型名推断")
+	playCmd.Flags().Float64Var(&playTemperature, "temperature", -1, "采样温度 (默认 -1 表示使用模型默认值)")
+	playCmd.Flags().IntVar(&playMaxTokens, "max-tokens", 0, "最大输出token数 (默认 0 表示不限制)")
+	playCmd.Flags().DurationVar(&playTimeout, "timeout", 2*time.Minute, "单次请求超时时间")
+	playCmd.Flags().Float64Var(&playPriceIn, "price-in", 0, "输入价格（元/百万token），用于计算费用")
+	playCmd.Flags().Float64Var(&playPriceOut, "price-out", 0, "输出价格（元/百万token），用于计算费用")
+	playCmd.Flags().BoolVarP(&playInteractive, "interactive", "i", false, "运行结束后交互式切换模型重新运行")
+	playCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "详细输出")
+
+	playCmd.MarkFlagRequired("template")
+	playCmd.MarkFlagRequired("model")
+}
+
+// playResult 单次运行的统计结果
+type playResult struct {
+	Model            string
+	Err              error
+	Duration         time.Duration
+	FirstToken       time.Duration
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Cost             float64
+}
+
+// renderPlayTemplate 读取模板文件并使用变量渲染
+func renderPlayTemplate(path string, vars []string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	values := make(map[string]any, len(vars))
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return "", fmt.Errorf("变量格式错误 (应为 name=value): %s", v)
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+
+	return prompts.RenderTemplate(string(content), prompts.TemplateFormatGoTemplate, values)
+}
+
+// inferProvider 根据模型名推断模型提供商
+func inferProvider(model string) (llmscn.LLMType, error) {
+	name := strings.ToLower(model)
+	prefixes := []struct {
+		prefix   string
+		provider llmscn.LLMType
+	}{
+		{"deepseek", llmscn.DeepSeekLLM},
+		{"moonshot", llmscn.KimiLLM},
+		{"kimi", llmscn.KimiLLM},
+		{"qwen", llmscn.QwenLLM},
+		{"glm", llmscn.ZhipuLLM},
+		{"charglm", llmscn.ZhipuLLM},
+		{"ernie", llmscn.ErnieLLM},
+		{"abab", llmscn.MiniMaxLLM},
+		{"minimax", llmscn.MiniMaxLLM},
+		{"doubao", llmscn.DoubaoLLM},
+		{"ep-", llmscn.DoubaoLLM},
+		{"yi-", llmscn.YiLLM},
+		{"baichuan", llmscn.BaichuanLLM},
+		{"gpt", llmscn.OpenAILLM},
+		{"o1", llmscn.OpenAILLM},
+		{"o3", llmscn.OpenAILLM},
+		{"claude", llmscn.AnthropicLLM},
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.provider, nil
+		}
+	}
+	return "", fmt.Errorf("无法根据模型名 %s 推断提供商，请使用 --llm 指定", model)
+}
+
+// runPlay 使用指定模型运行提示词，流式输出并返回统计结果
+func runPlay(model, prompt string) playResult {
+	result := playResult{Model: model}
+
+	provider := llmscn.LLMType(playProvider)
+	if provider == "" {
+		var err error
+		if provider, err = inferProvider(model); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			result.Err = err
+			return result
+		}
+	}
+
+	fmt.Printf("🤖 %s (%s)\n", model, provider)
+	fmt.Println(strings.Repeat("─", 50))
+
+	llm, err := llmscn.CreateLLM(provider, map[string]interface{}{"model": model})
+	if err != nil {
+		fmt.Printf("❌ 创建模型失败: %v\n", err)
+		result.Err = err
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), playTimeout)
+	defer cancel()
+
+	start := time.Now()
+	options := []llms.CallOption{
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			if result.FirstToken == 0 && len(chunk) > 0 {
+				result.FirstToken = time.Since(start)
+			}
+			fmt.Print(string(chunk))
+			return nil
+		}),
+	}
+	if playTemperature >= 0 {
+		options = append(options, llms.WithTemperature(playTemperature))
+	}
+	if playMaxTokens > 0 {
+		options = append(options, llms.WithMaxTokens(playMaxTokens))
+	}
+
+	resp, err := llm.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	}, options...)
+	result.Duration = time.Since(start)
+	fmt.Println()
+	fmt.Println(strings.Repeat("─", 50))
+	if err != nil {
+		fmt.Printf("❌ 调用失败: %v\n", err)
+		result.Err = err
+		return result
+	}
+
+	if len(resp.Choices) > 0 {
+		info := resp.Choices[0].GenerationInfo
+		result.PromptTokens = playIntInfo(info, "PromptTokens")
+		result.CompletionTokens = playIntInfo(info, "CompletionTokens")
+		result.TotalTokens = playIntInfo(info, "TotalTokens")
+		if result.TotalTokens == 0 {
+			result.TotalTokens = result.PromptTokens + result.CompletionTokens
+		}
+	}
+	result.Cost = (float64(result.PromptTokens)*playPriceIn + float64(result.CompletionTokens)*playPriceOut) / 1e6
+
+	fmt.Printf("⏱️  耗时: %s (首字: %s)\n", result.Duration.Round(time.Millisecond), result.FirstToken.Round(time.Millisecond))
+	fmt.Printf("🔢 Token: 输入 %d / 输出 %d / 合计 %d\n", result.PromptTokens, result.CompletionTokens, result.TotalTokens)
+	if playPriceIn > 0 || playPriceOut > 0 {
+		fmt.Printf("💰 费用: ¥%.6f\n", result.Cost)
+	}
+	return result
+}
+
+// printPlaySummary 输出多次运行的对比汇总
+func printPlaySummary(results []playResult) {
+	fmt.Println("\n📊 对比汇总:")
+	fmt.Printf("%-24s %-10s %-10s %-8s %-8s %-8s %s\n", "模型", "耗时", "首字", "输入", "输出", "合计", "费用")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("%-24s ❌ %v\n", r.Model, r.Err)
+			continue
+		}
+		fmt.Printf("%-24s %-10s %-10s %-8d %-8d %-8d ¥%.6f\n",
+			r.Model, r.Duration.Round(time.Millisecond), r.FirstToken.Round(time.Millisecond),
+			r.PromptTokens, r.CompletionTokens, r.TotalTokens, r.Cost)
+	}
+}
+
+// playIntInfo 从 GenerationInfo 中读取整数字段
+func playIntInfo(info map[string]any, key string) int {
+	switch v := info[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
@@ -43,4 +43,7 @@ func init() {
 
 	// Add subcommands
 	rootCmd.AddCommand(configGenCmd)
+	rootCmd.AddCommand(stateCmd)
+	rootCmd.AddCommand(playCmd)
+	rootCmd.AddCommand(graphCmd)
 }
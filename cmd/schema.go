@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -42,6 +43,13 @@ var (
 	// Executor配置
 	maxIterations           int
 	returnIntermediateSteps bool
+
+	// 代码生成配置
+	codegenPackage string
+	codegenFunc    string
+
+	// 环境变量清单配置
+	envCheck bool
 )
 
 var configGenCmd = &cobra.Command{
@@ -59,19 +67,33 @@ var configGenCmd = &cobra.Command{
   • qwen        - 通义千问模型
   • zhipu       - 智谱AI GLM模型
   • siliconflow - 硅基流动平台模型
+  • ernie       - 百度文心一言模型
+  • minimax     - MiniMax abab模型
+  • doubao      - 字节跳动豆包模型（火山方舟）
+  • yi          - 零一万物Yi模型
+  • baichuan    - 百川智能模型
   • anthropic   - Anthropic Claude模型
   • ollama      - 本地Ollama模型`,
-	Example: `  # 生成DeepSeek聊天配置
+	Example: `  # 通过交互式向导生成配置
+  langchaingo-cn config-gen wizard
+
+  # 生成DeepSeek聊天配置
   langchaingo-cn config-gen preset deepseek-chat -o deepseek.json
 
   # 生成自定义LLM配置
   langchaingo-cn config-gen llm --llm deepseek --model deepseek-chat -o my_llm.json
 
+  # 生成YAML格式的配置（按输出文件扩展名选择格式）
+  langchaingo-cn config-gen preset deepseek-chat -o deepseek.yaml
+
   # 生成Chain配置
   langchaingo-cn config-gen chain --llm kimi --model moonshot-v1-8k --memory conversation_buffer
 
   # 生成Agent配置
-  langchaingo-cn config-gen agent --llm openai --model gpt-4 --agent-type zero_shot_react`,
+  langchaingo-cn config-gen agent --llm openai --model gpt-4 --agent-type zero_shot_react
+
+  # 生成配置依赖的环境变量清单 .env.example
+  langchaingo-cn config-gen env config.json`,
 }
 
 // LLM命令
@@ -266,7 +288,7 @@ var presetCmd = &cobra.Command{
 	Short:     "生成预设配置文件",
 	Long:      "使用预定义的配置模板快速生成常用配置文件",
 	Args:      cobra.ExactArgs(1),
-	ValidArgs: []string{"deepseek-chat", "kimi-chat", "openai-chat", "qwen-chat", "zhipu-chat", "siliconflow-chat", "deepseek-executor", "zhipu-executor", "siliconflow-executor"},
+	ValidArgs: []string{"deepseek-chat", "kimi-chat", "openai-chat", "qwen-chat", "zhipu-chat", "siliconflow-chat", "ernie-chat", "doubao-chat", "deepseek-executor", "zhipu-executor", "siliconflow-executor"},
 	Example: `  # 生成DeepSeek聊天配置
   config-gen preset deepseek-chat -o deepseek.json
 
@@ -303,6 +325,8 @@ var listCmd = &cobra.Command{
 		fmt.Println("  • qwen-chat           - 通义千问聊天配置")
 		fmt.Println("  • zhipu-chat          - 智谱AI聊天配置")
 		fmt.Println("  • siliconflow-chat    - 硅基流动聊天配置")
+		fmt.Println("  • ernie-chat          - 文心一言聊天配置")
+		fmt.Println("  • doubao-chat         - 豆包聊天配置")
 		fmt.Println("  • deepseek-executor   - DeepSeek执行器配置")
 		fmt.Println("  • zhipu-executor      - 智谱AI执行器配置")
 		fmt.Println("  • siliconflow-executor - 硅基流动执行器配置")
@@ -314,6 +338,11 @@ var listCmd = &cobra.Command{
 		fmt.Println("  • qwen        - 通义千问模型")
 		fmt.Println("  • zhipu       - 智谱AI GLM模型")
 		fmt.Println("  • siliconflow - 硅基流动平台模型")
+		fmt.Println("  • ernie       - 百度文心一言模型")
+		fmt.Println("  • minimax     - MiniMax abab模型")
+		fmt.Println("  • doubao      - 字节跳动豆包模型（火山方舟）")
+		fmt.Println("  • yi          - 零一万物Yi模型")
+		fmt.Println("  • baichuan    - 百川智能模型")
 		fmt.Println("  • anthropic   - Anthropic Claude模型")
 		fmt.Println("  • ollama      - 本地Ollama模型")
 
@@ -366,6 +395,118 @@ var validateCmd = &cobra.Command{
 	},
 }
 
+// Codegen命令
+var codegenCmd = &cobra.Command{
+	Use:   "codegen [config-file]",
+	Short: "根据配置文件生成Go构造代码",
+	Long: `根据配置文件生成强类型的Go构造代码
+
+生成的代码按照与配置加载相同的方式创建LLM、Memory、Prompt、Chain、Agent和Executor，
+便于从配置驱动平滑过渡到编译期检查的代码。
+
+配置中的 ${VAR} 引用会转换为运行时读取环境变量，明文API密钥不会写入生成的代码。
+同时支持完整配置和 executor 命令生成的使用风格配置。
+
+示例:
+  # 生成 app_gen.go
+  xin config-gen codegen config.json -o app_gen.go
+
+  # 指定包名和构造函数名
+  xin config-gen codegen config.json -o app_gen.go --package app --func NewAssistant`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := schema.LoadCodegenConfigFromFile(args[0])
+		if err != nil {
+			log.Fatal("❌ 加载配置失败: ", err)
+		}
+
+		code, err := schema.GenerateGoCode(config, schema.CodegenOptions{
+			PackageName: codegenPackage,
+			FuncName:    codegenFunc,
+		})
+		if err != nil {
+			log.Fatal("❌ 生成代码失败: ", err)
+		}
+
+		// -o 默认值为 config.json，代码生成时改用 app_gen.go
+		if !cmd.Flags().Changed("output") {
+			outputFile = "app_gen.go"
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			log.Fatal("❌ 创建输出目录失败: ", err)
+		}
+		path := filepath.Join(outputDir, outputFile)
+		if err := os.WriteFile(path, code, 0644); err != nil {
+			log.Fatal("❌ 写入文件失败: ", err)
+		}
+
+		fmt.Printf("✅ Go代码已生成: %s\n", path)
+	},
+}
+
+// Env命令
+var envCmd = &cobra.Command{
+	Use:   "env [config-file]",
+	Short: "提取配置依赖的环境变量并生成 .env.example",
+	Long: `扫描配置文件中的 ${VAR} 引用，以及未配置 api_key 的LLM/Embedding组件隐式读取的API密钥环境变量，
+列出每个变量的用途和当前是否已设置，并生成 .env.example 文件。
+
+使用 --check 时仅检查环境变量，存在未设置的变量时以非零状态退出，适合在CI或部署前执行，
+避免服务启动后才出现难以排查的服务商认证失败。
+
+示例:
+  # 生成 .env.example
+  xin config-gen env config.json
+
+  # 在CI中检查环境变量
+  xin config-gen env config.json --check`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := schema.LoadCodegenConfigFromFile(args[0])
+		if err != nil {
+			log.Fatal("❌ 加载配置失败: ", err)
+		}
+
+		vars := schema.RequiredEnv(config)
+		if len(vars) == 0 {
+			fmt.Println("📭 配置未依赖任何环境变量")
+			return
+		}
+
+		fmt.Printf("%-28s %-6s %s\n", "变量", "状态", "用于")
+		for _, v := range vars {
+			status := "✅"
+			if !v.Set {
+				status = "❌"
+			}
+			fmt.Printf("%-28s %-6s %s\n", v.Name, status, strings.Join(v.Paths, ", "))
+		}
+
+		if envCheck {
+			if missing := schema.MissingEnv(vars); len(missing) > 0 {
+				fmt.Printf("\n❌ %d 个环境变量未设置\n", len(missing))
+				os.Exit(1)
+			}
+			fmt.Println("\n✅ 所有环境变量均已设置")
+			return
+		}
+
+		// -o 默认值为 config.json，生成清单时改用 .env.example
+		if !cmd.Flags().Changed("output") {
+			outputFile = ".env.example"
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			log.Fatal("❌ 创建输出目录失败: ", err)
+		}
+		path := filepath.Join(outputDir, outputFile)
+		if err := os.WriteFile(path, []byte(schema.EnvExample(vars)), 0644); err != nil {
+			log.Fatal("❌ 写入文件失败: ", err)
+		}
+
+		fmt.Printf("\n✅ 环境变量清单已生成: %s\n", path)
+	},
+}
+
 func init() {
 	// 全局标志
 	configGenCmd.PersistentFlags().StringVarP(&outputDir, "dir", "d", ".", "输出目录")
@@ -373,7 +514,7 @@ func init() {
 	configGenCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "详细输出")
 
 	// LLM命令标志
-	llmCmd.Flags().StringVar(&llmType, "llm", "", "LLM类型 (deepseek|kimi|openai|qwen|anthropic|ollama) [必需]")
+	llmCmd.Flags().StringVar(&llmType, "llm", "", "LLM类型 (deepseek|kimi|openai|qwen|ernie|minimax|doubao|yi|baichuan|anthropic|ollama) [必需]")
 	llmCmd.Flags().StringVar(&model, "model", "", "模型名称 [必需]")
 	llmCmd.Flags().Float64Var(&temperature, "temperature", 0, "温度参数 (0.0-2.0)")
 	llmCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "最大token数")
@@ -422,7 +563,29 @@ func init() {
 	// Validate命令标志
 	validateCmd.Flags().BoolVarP(&enableAPITest, "api-test", "t", false, "启用真实API调用测试")
 
+	// Codegen命令标志
+	codegenCmd.Flags().StringVar(&codegenPackage, "package", "main", "生成代码的包名")
+	codegenCmd.Flags().StringVar(&codegenFunc, "func", "NewApp", "生成的构造函数名")
+
+	// Env命令标志
+	envCmd.Flags().BoolVar(&envCheck, "check", false, "仅检查环境变量，缺失时以非零状态退出")
+
+	// 类型标志与配置文件参数的补全
+	for _, c := range []*cobra.Command{llmCmd, chainCmd, agentCmd, executorCmd} {
+		c.RegisterFlagCompletionFunc("llm", completeTypes("llm"))
+	}
+	for _, c := range []*cobra.Command{chainCmd, agentCmd, executorCmd} {
+		c.RegisterFlagCompletionFunc("memory", completeTypes("memory"))
+	}
+	chainCmd.RegisterFlagCompletionFunc("chain-type", completeTypes("chain"))
+	agentCmd.RegisterFlagCompletionFunc("agent-type", completeTypes("agent"))
+	executorCmd.RegisterFlagCompletionFunc("agent-type", completeTypes("agent"))
+	for _, c := range []*cobra.Command{validateCmd, codegenCmd, envCmd} {
+		c.ValidArgsFunction = completeConfigFile
+	}
+
 	// 添加子命令
+	configGenCmd.AddCommand(wizardCmd)
 	configGenCmd.AddCommand(llmCmd)
 	configGenCmd.AddCommand(chainCmd)
 	configGenCmd.AddCommand(agentCmd)
@@ -430,6 +593,8 @@ func init() {
 	configGenCmd.AddCommand(presetCmd)
 	configGenCmd.AddCommand(listCmd)
 	configGenCmd.AddCommand(validateCmd)
+	configGenCmd.AddCommand(codegenCmd)
+	configGenCmd.AddCommand(envCmd)
 }
 
 // 辅助函数
@@ -447,6 +612,34 @@ func getAgentType() string {
 	return agentType
 }
 
+// completeTypes 返回补全组件类型的函数，候选项附带说明
+func completeTypes(component string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	descriptions := map[string]map[string]string{
+		"llm":    llmDescriptions,
+		"agent":  agentDescriptions,
+		"memory": memoryDescriptions,
+	}[component]
+
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var candidates []string
+		for _, t := range schema.SupportedTypes(component) {
+			if description := descriptions[t]; description != "" {
+				t += "\t" + description
+			}
+			candidates = append(candidates, t)
+		}
+		return candidates, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeConfigFile 补全JSON或YAML配置文件
+func completeConfigFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return []string{"json", "yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
 func generatePreset(generator *schema.ConfigGenerator, preset, output string) error {
 	switch strings.ToLower(preset) {
 	case "deepseek-chat":
@@ -461,6 +654,10 @@ func generatePreset(generator *schema.ConfigGenerator, preset, output string) er
 		return generator.GenerateZhipuChatConfig(output)
 	case "siliconflow-chat":
 		return generator.GenerateSiliconFlowChatConfig(output)
+	case "ernie-chat":
+		return generator.GenerateErnieChatConfig(output)
+	case "doubao-chat":
+		return generator.GenerateDoubaoChatConfig(output)
 	case "deepseek-executor":
 		return generator.GenerateExecutorWithDeepSeek(output)
 	case "zhipu-executor":
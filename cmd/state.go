@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sjzsdu/langchaingo-cn/graph"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// 状态存储配置
+	stateBackend   string
+	stateDir       string
+	stateRedisURL  string
+	stateKeyPrefix string
+
+	// 删除配置
+	removeCheckpoints bool
+
+	// 恢复配置
+	checkpointIndex int
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "💾 图执行状态管理工具",
+	Long: `💾 图执行状态管理工具
+
+管理Graph执行过程中持久化的状态与检查点，支持查看、删除以及从检查点恢复。
+
+支持的存储后端:
+  • file  - 基于文件的状态存储 (FileStateManager)
+  • redis - 基于Redis的状态存储 (RedisStateManager)
+
+存储后端需实现 graph.StateLister 接口（StateManager + ListStates）。`,
+	Example: `  # 列出所有状态
+  langchaingo-cn state ls --dir ./states
+
+  # 查看状态详情及其检查点
+  langchaingo-cn state show session-001
+
+  # 删除状态及其所有检查点
+  langchaingo-cn state rm session-001 --checkpoints
+
+  # 从最新检查点恢复状态
+  langchaingo-cn state restore session-001
+
+  # 列出Redis中的状态
+  langchaingo-cn state ls --backend redis --redis-url redis://localhost:6379/0`,
+}
+
+// 列出状态命令
+var stateListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "列出所有状态",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := openStateStore()
+		ctx := context.Background()
+
+		stateIDs, checkpoints := listStateIDs(store)
+		if len(stateIDs) == 0 && len(checkpoints) == 0 {
+			fmt.Println("📭 没有找到任何状态")
+			return
+		}
+
+		fmt.Printf("%-36s %-20s %-8s %-8s %s\n", "ID", "当前节点", "消息数", "检查点", "更新于")
+		for _, id := range stateIDs {
+			state, err := store.Load(ctx, id)
+			if err != nil {
+				fmt.Printf("%-36s ⚠️  加载失败: %v\n", id, err)
+				continue
+			}
+			fmt.Printf("%-36s %-20s %-8d %-8d %s\n",
+				state.ID, displayNode(state.CurrentNode), len(state.Messages),
+				len(checkpoints[id]), formatAge(state.UpdatedAt))
+		}
+
+		// 仅剩检查点的状态（原始状态已被删除），仍可通过 restore 恢复
+		for _, id := range orphanCheckpointStates(stateIDs, checkpoints) {
+			cps := checkpoints[id]
+			fmt.Printf("%-36s %-20s %-8s %-8d %s\n",
+				id, "📌 仅检查点", "-", len(cps), formatAge(cps[len(cps)-1].Timestamp))
+		}
+	},
+}
+
+// 查看状态命令
+var stateShowCmd = &cobra.Command{
+	Use:   "show [state-id]",
+	Short: "查看状态详情",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := openStateStore()
+		ctx := context.Background()
+
+		state, err := store.Load(ctx, args[0])
+		if err != nil {
+			log.Fatal("❌ 加载状态失败: ", err)
+		}
+
+		fmt.Printf("🆔 ID:       %s\n", state.ID)
+		fmt.Printf("📍 当前节点: %s\n", displayNode(state.CurrentNode))
+		fmt.Printf("💬 消息数:   %d\n", len(state.Messages))
+		fmt.Printf("📦 变量数:   %d\n", len(state.Variables))
+		fmt.Printf("🪜 执行步数: %d\n", len(state.History))
+		fmt.Printf("🕐 创建于:   %s\n", state.CreatedAt.Format(time.RFC3339))
+		fmt.Printf("🕑 更新于:   %s (%s)\n", state.UpdatedAt.Format(time.RFC3339), formatAge(state.UpdatedAt))
+
+		if verbose && len(state.Variables) > 0 {
+			fmt.Println("\n📦 变量:")
+			keys := make([]string, 0, len(state.Variables))
+			for k := range state.Variables {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Printf("  • %s = %v\n", k, state.Variables[k])
+			}
+		}
+
+		_, checkpoints := listStateIDs(store)
+		if cps := checkpoints[state.ID]; len(cps) > 0 {
+			fmt.Println("\n📌 检查点:")
+			for i, cp := range cps {
+				fmt.Printf("  [%d] %s (%s)\n", i, cp.ID, formatAge(cp.Timestamp))
+			}
+		}
+	},
+}
+
+// 删除状态命令
+var stateRemoveCmd = &cobra.Command{
+	Use:     "rm [state-id...]",
+	Aliases: []string{"delete"},
+	Short:   "删除状态",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := openStateStore()
+		ctx := context.Background()
+
+		var checkpoints map[string][]graph.CheckpointInfo
+		if removeCheckpoints {
+			_, checkpoints = listStateIDs(store)
+		}
+
+		for _, id := range args {
+			if _, err := store.Load(ctx, id); err != nil {
+				log.Fatal("❌ 状态不存在: ", err)
+			}
+
+			// 先删除检查点，避免中途失败时留下没有原始状态的检查点
+			for _, cp := range checkpoints[id] {
+				if err := store.Delete(ctx, cp.ID); err != nil {
+					log.Fatal("❌ 删除检查点失败: ", err)
+				}
+			}
+			if err := store.Delete(ctx, id); err != nil {
+				log.Fatal("❌ 删除状态失败: ", err)
+			}
+
+			fmt.Printf("🗑️  已删除状态: %s", id)
+			if n := len(checkpoints[id]); n > 0 {
+				fmt.Printf(" (含 %d 个检查点)", n)
+			}
+			fmt.Println()
+		}
+	},
+}
+
+// 恢复状态命令
+var stateRestoreCmd = &cobra.Command{
+	Use:   "restore [state-id]",
+	Short: "从检查点恢复状态",
+	Long: `从检查点恢复状态
+
+默认使用最新的检查点，可以通过 --index 指定检查点序号（见 state show 的输出）。
+恢复后的状态会以原始状态ID覆盖保存。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := openStateStore()
+		ctx := context.Background()
+		stateID := args[0]
+
+		_, checkpoints := listStateIDs(store)
+		cps := checkpoints[stateID]
+		if len(cps) == 0 {
+			log.Fatalf("❌ 状态 %s 没有可用的检查点", stateID)
+		}
+
+		index := checkpointIndex
+		if index == -1 {
+			index = len(cps) - 1
+		}
+		if index < 0 || index >= len(cps) {
+			log.Fatalf("❌ 检查点序号无效: %d (共 %d 个)", index, len(cps))
+		}
+
+		state, err := store.Load(ctx, cps[index].ID)
+		if err != nil {
+			log.Fatal("❌ 加载检查点失败: ", err)
+		}
+
+		state.ID = stateID
+		if err := store.Save(ctx, state); err != nil {
+			log.Fatal("❌ 保存恢复的状态失败: ", err)
+		}
+
+		fmt.Printf("♻️  已从检查点 [%d] %s 恢复状态: %s\n", index, cps[index].ID, stateID)
+	},
+}
+
+func init() {
+	// 全局标志
+	stateCmd.PersistentFlags().StringVar(&stateBackend, "backend", "file", "状态存储后端 (file, redis)")
+	stateCmd.PersistentFlags().StringVarP(&stateDir, "dir", "d", "./states", "状态文件目录")
+	stateCmd.PersistentFlags().StringVar(&stateRedisURL, "redis-url", "redis://localhost:6379/0", "Redis连接URL")
+	stateCmd.PersistentFlags().StringVar(&stateKeyPrefix, "key-prefix", "langchaingo:state:", "Redis键前缀")
+	stateCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "详细输出")
+
+	// 删除命令标志
+	stateRemoveCmd.Flags().BoolVar(&removeCheckpoints, "checkpoints", false, "同时删除该状态的所有检查点")
+
+	// 恢复命令标志
+	stateRestoreCmd.Flags().IntVar(&checkpointIndex, "index", -1, "检查点序号 (默认 -1 表示最新)")
+
+	// 添加子命令
+	stateCmd.AddCommand(stateListCmd)
+	stateCmd.AddCommand(stateShowCmd)
+	stateCmd.AddCommand(stateRemoveCmd)
+	stateCmd.AddCommand(stateRestoreCmd)
+}
+
+// openStateStore 根据标志打开状态存储后端
+func openStateStore() graph.StateLister {
+	switch stateBackend {
+	case "file":
+		info, err := os.Stat(stateDir)
+		if err != nil {
+			log.Fatal("❌ 状态目录不可用: ", err)
+		}
+		if !info.IsDir() {
+			log.Fatalf("❌ 状态路径不是目录: %s", stateDir)
+		}
+
+		store, err := graph.NewFileStateManager(stateDir)
+		if err != nil {
+			log.Fatal("❌ 打开状态目录失败: ", err)
+		}
+		return store
+	case "redis":
+		opts, err := redis.ParseURL(stateRedisURL)
+		if err != nil {
+			log.Fatal("❌ Redis连接URL无效: ", err)
+		}
+		client := redis.NewClient(opts)
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			log.Fatal("❌ 连接Redis失败: ", err)
+		}
+		// 读取时自动识别压缩格式，无需指定是否压缩
+		return graph.NewRedisStateManager(client, stateKeyPrefix, 0)
+	default:
+		log.Fatalf("❌ 不支持的状态存储后端: %s", stateBackend)
+		return nil
+	}
+}
+
+// listStateIDs 列出普通状态ID，并按原始状态分组返回检查点（按时间排序）
+func listStateIDs(store graph.StateLister) ([]string, map[string][]graph.CheckpointInfo) {
+	ids, err := store.ListStates()
+	if err != nil {
+		log.Fatal("❌ 读取状态列表失败: ", err)
+	}
+
+	var stateIDs []string
+	checkpoints := make(map[string][]graph.CheckpointInfo)
+	for _, id := range ids {
+		if stateID, createdAt, ok := graph.ParseCheckpointID(id); ok {
+			checkpoints[stateID] = append(checkpoints[stateID], graph.CheckpointInfo{
+				ID:        id,
+				StateID:   stateID,
+				Timestamp: createdAt,
+			})
+			continue
+		}
+		stateIDs = append(stateIDs, id)
+	}
+
+	sort.Strings(stateIDs)
+	for _, cps := range checkpoints {
+		sort.Slice(cps, func(i, j int) bool { return cps[i].Timestamp.Before(cps[j].Timestamp) })
+	}
+
+	return stateIDs, checkpoints
+}
+
+// orphanCheckpointStates 返回只剩检查点、原始状态已不存在的状态ID
+func orphanCheckpointStates(stateIDs []string, checkpoints map[string][]graph.CheckpointInfo) []string {
+	existing := make(map[string]bool, len(stateIDs))
+	for _, id := range stateIDs {
+		existing[id] = true
+	}
+
+	var orphans []string
+	for id := range checkpoints {
+		if !existing[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	sort.Strings(orphans)
+
+	return orphans
+}
+
+// displayNode 返回用于显示的节点名称
+func displayNode(node string) string {
+	if node == "" {
+		return "-"
+	}
+	return node
+}
+
+// formatAge 将时间格式化为距今的时长
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return strconv.Itoa(int(age.Seconds())) + "秒前"
+	case age < time.Hour:
+		return strconv.Itoa(int(age.Minutes())) + "分钟前"
+	case age < 24*time.Hour:
+		return strconv.Itoa(int(age.Hours())) + "小时前"
+	default:
+		return strconv.Itoa(int(age.Hours()/24)) + "天前"
+	}
+}
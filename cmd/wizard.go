@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/schema"
+	"github.com/spf13/cobra"
+)
+
+// wizardNoAgent 不使用智能体，生成对话链配置
+const wizardNoAgent = "none"
+
+var (
+	errWizardInputClosed = errors.New("输入已结束")
+	errWizardAborted     = errors.New("已取消")
+)
+
+// wizardDefaultModels 各LLM类型的默认模型，与预设配置保持一致
+var wizardDefaultModels = map[string]string{
+	"deepseek":    "deepseek-chat",
+	"kimi":        "moonshot-v1-8k",
+	"openai":      "gpt-4",
+	"qwen":        "qwen-plus",
+	"zhipu":       "glm-4",
+	"siliconflow": "Qwen/Qwen2.5-72B-Instruct",
+	"ernie":       "ernie-4.0-8k",
+	"minimax":     "abab6.5s-chat",
+	"doubao":      "doubao-1-5-pro-32k-250115",
+	"yi":          "yi-lightning",
+	"baichuan":    "Baichuan4-Turbo",
+	"anthropic":   "claude-3-5-sonnet-latest",
+	"ollama":      "llama3",
+}
+
+// 选项说明
+var (
+	llmDescriptions = map[string]string{
+		"deepseek":    "DeepSeek模型",
+		"kimi":        "Kimi月之暗面模型",
+		"openai":      "OpenAI GPT模型",
+		"qwen":        "通义千问模型",
+		"zhipu":       "智谱AI GLM模型",
+		"siliconflow": "硅基流动平台模型",
+		"ernie":       "百度文心一言模型",
+		"minimax":     "MiniMax abab模型",
+		"doubao":      "字节跳动豆包模型（火山方舟）",
+		"yi":          "零一万物Yi模型",
+		"baichuan":    "百川智能模型",
+		"anthropic":   "Anthropic Claude模型",
+		"ollama":      "本地Ollama模型",
+	}
+	agentDescriptions = map[string]string{
+		wizardNoAgent:          "不使用智能体，生成对话链",
+		"zero_shot_react":      "零样本ReAct智能体",
+		"conversational_react": "对话ReAct智能体",
+	}
+	memoryDescriptions = map[string]string{
+		"conversation_buffer":       "会话缓冲记忆",
+		"conversation_token_buffer": "按token数截断的会话记忆",
+		"simple":                    "简单记忆",
+	}
+)
+
+// Wizard命令
+var wizardCmd = &cobra.Command{
+	Use:   "wizard",
+	Short: "通过交互式向导生成配置文件",
+	Long: `通过问答逐步选择LLM服务商、模型、记忆、智能体类型和工具，无需记忆各子命令的参数
+
+向导会:
+1. 列出支持的LLM服务商，并给出常用模型作为默认值
+2. 检查API密钥环境变量，可选发送一次真实请求验证密钥和模型
+3. 选择智能体类型，不使用智能体时生成对话链配置
+4. 为对话链或对话智能体选择记忆类型
+5. 为智能体添加OpenAPI工具，添加前会加载规范进行校验
+6. 写入配置文件并校验生成的配置
+
+生成的配置通过 ${VAR} 引用API密钥，不会写入明文密钥。
+
+示例:
+  # 启动向导
+  xin config-gen wizard
+
+  # 指定默认的输出位置
+  xin config-gen wizard -d configs -o assistant.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := newWizard(cmd.InOrStdin(), cmd.OutOrStdout())
+		if err := w.run(); err != nil {
+			log.Fatal("❌ 配置向导失败: ", err)
+		}
+	},
+}
+
+// wizard 交互式配置向导
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// newWizard 创建配置向导，从 in 读取回答，向 out 输出提示
+func newWizard(in io.Reader, out io.Writer) *wizard {
+	return &wizard{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// run 依次询问各项配置并生成配置文件
+func (w *wizard) run() error {
+	fmt.Fprintln(w.out, "🧙 LangChainGo-CN 配置向导（直接回车使用方括号中的默认值）")
+
+	// 步骤1: LLM服务商与模型
+	llm := schema.LLMTemplate{}
+	var err error
+	if llm.Type, err = w.choose("LLM服务商", schema.SupportedTypes("llm"), llmDescriptions, "deepseek"); err != nil {
+		return err
+	}
+	if llm.Model, err = w.askRequired("模型名称", wizardDefaultModels[llm.Type]); err != nil {
+		return err
+	}
+	if llm.BaseURL, err = w.ask("自定义API基础URL（可选）", ""); err != nil {
+		return err
+	}
+
+	// 步骤2: API密钥
+	if err := w.checkAPIKey(llm); err != nil {
+		return err
+	}
+
+	// 步骤3: 智能体类型
+	agentChoices := append([]string{wizardNoAgent}, schema.SupportedTypes("agent")...)
+	agent, err := w.choose("智能体类型", agentChoices, agentDescriptions, wizardNoAgent)
+	if err != nil {
+		return err
+	}
+
+	// 步骤4: 记忆，零样本智能体不使用记忆
+	memory := ""
+	if agent == wizardNoAgent || agent == "conversational_react" {
+		if memory, err = w.choose("记忆类型", schema.SupportedTypes("memory"), memoryDescriptions, "conversation_buffer"); err != nil {
+			return err
+		}
+	}
+
+	// 步骤5: 智能体参数与工具
+	steps := 5
+	var tools []*schema.OpenAPIToolsConfig
+	if agent != wizardNoAgent {
+		if steps, err = w.askInt("最大步数", steps); err != nil {
+			return err
+		}
+		if tools, err = w.askTools(); err != nil {
+			return err
+		}
+	}
+
+	// 步骤6: 写入并校验
+	path, err := w.askOutput()
+	if err != nil {
+		return err
+	}
+	generator := schema.NewConfigGenerator(filepath.Dir(path))
+	if agent == wizardNoAgent {
+		llm.Temperature = 0.7
+		err = generator.GenerateChainConfig(schema.ChainTemplate{
+			Type:        "conversation",
+			LLMTemplate: llm,
+			MemoryType:  memory,
+		}, filepath.Base(path))
+	} else {
+		llm.Temperature = 0.3
+		err = generator.GenerateAgentConfig(schema.AgentTemplate{
+			Type:         agent,
+			LLMTemplate:  llm,
+			MemoryType:   memory,
+			MaxSteps:     steps,
+			OpenAPITools: tools,
+		}, filepath.Base(path))
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := schema.LoadConfigFromFile(path)
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		return fmt.Errorf("生成的配置校验失败: %w", err)
+	}
+
+	fmt.Fprintln(w.out, "\n💡 下一步:")
+	if env := schema.LLMAPIKeyEnv(llm.Type); env != "" {
+		fmt.Fprintf(w.out, "   1. 🔑 设置环境变量 %s\n", env)
+	} else {
+		fmt.Fprintln(w.out, "   1. 🔑 确认本地服务已启动")
+	}
+	fmt.Fprintf(w.out, "   2. 🔍 运行 config-gen validate %s --api-test 测试配置\n", path)
+	fmt.Fprintln(w.out, "   3. 📝 使用 schema.CreateApplicationFromFile() 加载配置")
+	return nil
+}
+
+// checkAPIKey 检查API密钥环境变量，并可选发送一次真实请求验证密钥和模型
+func (w *wizard) checkAPIKey(llm schema.LLMTemplate) error {
+	env := schema.LLMAPIKeyEnv(llm.Type)
+	if env != "" && os.Getenv(env) == "" {
+		fmt.Fprintf(w.out, "⚠️  环境变量 %s 未设置，配置将通过 ${%s} 引用，运行前请先设置\n", env, env)
+		return nil
+	}
+	if env != "" {
+		fmt.Fprintf(w.out, "✅ 已检测到环境变量 %s\n", env)
+	}
+
+	test, err := w.confirm("发送一次真实请求验证API密钥和模型（会产生少量调用费用）", true)
+	if err != nil || !test {
+		return err
+	}
+
+	model, err := schema.NewLLMFactory().Create(&schema.LLMConfig{
+		Type:    llm.Type,
+		Model:   llm.Model,
+		BaseURL: llm.BaseURL,
+	})
+	if err != nil {
+		fmt.Fprintf(w.out, "❌ 创建LLM失败: %v\n", err)
+	} else if testLLMAPICall(llm.Type, model, verbose) {
+		return nil
+	}
+
+	proceed, err := w.confirm("验证未通过，是否仍然生成配置", false)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		return errWizardAborted
+	}
+	return nil
+}
+
+// askTools 逐个添加OpenAPI工具，加载规范成功后才会加入配置
+func (w *wizard) askTools() ([]*schema.OpenAPIToolsConfig, error) {
+	var result []*schema.OpenAPIToolsConfig
+	for {
+		spec, err := w.ask("OpenAPI规范文件路径或URL（留空结束添加工具）", "")
+		if err != nil {
+			return nil, err
+		}
+		if spec == "" {
+			break
+		}
+
+		config := &schema.OpenAPIToolsConfig{Spec: spec}
+		tools, err := config.Tools()
+		if err != nil {
+			fmt.Fprintf(w.out, "⚠️  无法加载OpenAPI规范: %v\n", err)
+			continue
+		}
+		fmt.Fprintf(w.out, "✅ 已加载 %d 个工具\n", len(tools))
+		for _, tool := range tools {
+			fmt.Fprintf(w.out, "   • %s\n", tool.Name())
+		}
+		result = append(result, config)
+	}
+
+	if len(result) == 0 {
+		fmt.Fprintln(w.out, "ℹ️  智能体未配置工具，可以稍后在 agents.main_agent.tools_from_openapi 中添加")
+	}
+	return result, nil
+}
+
+// askOutput 询问输出文件路径，文件已存在时确认是否覆盖
+func (w *wizard) askOutput() (string, error) {
+	for {
+		path, err := w.askRequired("输出文件", filepath.Join(outputDir, outputFile))
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path, nil
+		}
+
+		overwrite, err := w.confirm(fmt.Sprintf("%s 已存在，是否覆盖", path), false)
+		if err != nil {
+			return "", err
+		}
+		if overwrite {
+			return path, nil
+		}
+	}
+}
+
+// ask 输出提示并读取一行回答，回答为空时返回默认值
+func (w *wizard) ask(prompt, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "? %s [%s]: ", prompt, def)
+	} else {
+		fmt.Fprintf(w.out, "? %s: ", prompt)
+	}
+
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Fprintln(w.out)
+		if err == io.EOF {
+			return "", errWizardInputClosed
+		}
+		return "", err
+	}
+
+	answer := strings.TrimSpace(line)
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askRequired 询问必填项，回答为空且没有默认值时重新询问
+func (w *wizard) askRequired(prompt, def string) (string, error) {
+	for {
+		answer, err := w.ask(prompt, def)
+		if err != nil || answer != "" {
+			return answer, err
+		}
+		fmt.Fprintf(w.out, "⚠️  %s不能为空\n", prompt)
+	}
+}
+
+// askInt 询问正整数
+func (w *wizard) askInt(prompt string, def int) (int, error) {
+	for {
+		answer, err := w.ask(prompt, strconv.Itoa(def))
+		if err != nil {
+			return 0, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n > 0 {
+			return n, nil
+		}
+		fmt.Fprintf(w.out, "⚠️  请输入正整数: %s\n", answer)
+	}
+}
+
+// choose 列出选项供选择，可以输入序号或选项名称
+func (w *wizard) choose(prompt string, options []string, descriptions map[string]string, def string) (string, error) {
+	fmt.Fprintf(w.out, "\n%s:\n", prompt)
+	for i, option := range options {
+		fmt.Fprintf(w.out, "  %d) %-26s %s\n", i+1, option, descriptions[option])
+	}
+
+	for {
+		answer, err := w.ask("请选择", def)
+		if err != nil {
+			return "", err
+		}
+		if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(options) {
+			return options[i-1], nil
+		}
+		for _, option := range options {
+			if strings.EqualFold(answer, option) {
+				return option, nil
+			}
+		}
+		fmt.Fprintf(w.out, "⚠️  无效的选择: %s\n", answer)
+	}
+}
+
+// confirm 询问是否确认
+func (w *wizard) confirm(prompt string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+
+	for {
+		answer, err := w.ask(fmt.Sprintf("%s (%s)", prompt, hint), "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes", "是":
+			return true, nil
+		case "n", "no", "否":
+			return false, nil
+		}
+		fmt.Fprintf(w.out, "⚠️  请输入 y 或 n: %s\n", answer)
+	}
+}
@@ -9,6 +9,7 @@ replace github.com/sjzsdu/langchaingo-cn => ../..
 require github.com/sjzsdu/langchaingo-cn v0.0.0-00010101000000-000000000000
 
 require (
+	github.com/coder/websocket v1.8.12 // indirect
 	github.com/dlclark/regexp2 v1.11.5 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/pkoukk/tiktoken-go v0.1.7 // indirect
@@ -7,6 +7,7 @@ import (
 	"github.com/sjzsdu/langchaingo-cn/llms/kimi"
 	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
 	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/sjzsdu/langchaingo-cn/llms/spark"
 	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
 )
 
@@ -69,6 +70,20 @@ func main() {
 
 	fmt.Println()
 
+	// 讯飞星火模型
+	fmt.Println("🔥 讯飞星火 (Spark) 支持的模型:")
+	sparkLLM, err := spark.New(spark.WithAPIPassword("dummy-key"))
+	if err == nil {
+		models := sparkLLM.GetModels()
+		for i, model := range models {
+			fmt.Printf("  %d. %s\n", i+1, model)
+		}
+	} else {
+		fmt.Printf("  初始化失败: %v\n", err)
+	}
+
+	fmt.Println()
+
 	// 硅基流动模型
 	fmt.Println("⚡ 硅基流动 (SiliconFlow) 支持的模型:")
 	fmt.Println("  文本生成模型:")
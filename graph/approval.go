@@ -0,0 +1,376 @@
+// Package graph - Webhook approval implementation
+// 包 graph - Webhook 审批实现
+package graph
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ================================
+// Webhook Approval Webhook 审批
+// ================================
+
+// Approval signature headers. The signature is the hex HMAC-SHA256 of "<timestamp>.<body>".
+// 审批签名请求头，签名为 "<timestamp>.<body>" 的 HMAC-SHA256 十六进制值。
+const (
+	ApprovalSignatureHeader = "X-Graph-Signature"
+	ApprovalTimestampHeader = "X-Graph-Timestamp"
+)
+
+var (
+	// ErrApprovalRejected is returned by an approval node when the request is rejected.
+	// ErrApprovalRejected 表示审批被拒绝。
+	ErrApprovalRejected = errors.New("approval rejected")
+
+	// ErrApprovalTimeout is returned by an approval node when no decision arrives in time.
+	// ErrApprovalTimeout 表示在超时前未收到审批结果。
+	ErrApprovalTimeout = errors.New("approval timed out")
+)
+
+// WebhookApprovalConfig configures a WebhookApprover.
+// WebhookApprovalConfig 配置 WebhookApprover。
+type WebhookApprovalConfig struct {
+	// URL receives the signed approval request as a JSON POST.
+	// URL 以 JSON POST 方式接收签名的审批请求。
+	URL string
+
+	// Secret signs outgoing requests and verifies incoming callbacks.
+	// Secret 用于签名发出的请求并校验回调。
+	Secret string
+
+	// CallbackURL is included in the request so the approval system knows where to reply,
+	// e.g. the public URL of the approver's HTTP handler.
+	// CallbackURL 随请求发送，告知审批系统回调地址，例如审批处理器的公网地址。
+	CallbackURL string
+
+	// Timeout bounds how long a node waits for a decision; 0 waits until the execution is cancelled.
+	// Note that the graph's own timeout (5 minutes by default) also applies.
+	// Timeout 节点等待审批结果的最长时间，0 表示一直等待到执行被取消。
+	// 注意图本身的超时（默认5分钟）同样生效。
+	Timeout time.Duration
+
+	// MaxSkew is the accepted clock difference for callback timestamps; defaults to 5 minutes.
+	// MaxSkew 回调时间戳允许的时钟偏差，默认5分钟。
+	MaxSkew time.Duration
+
+	// Variables lists the state variables included in the request for the reviewer.
+	// Variables 列出随请求发送给审批人的状态变量。
+	Variables []string
+
+	// HTTPClient sends the webhook; defaults to a client with a 10 second timeout.
+	// HTTPClient 发送 webhook 使用的客户端，默认超时10秒。
+	HTTPClient *http.Client
+}
+
+// ApprovalRequest is the payload posted to the webhook when execution pauses.
+// ApprovalRequest 是执行暂停时发送到 webhook 的内容。
+type ApprovalRequest struct {
+	ID          string                 `json:"id"`
+	NodeID      string                 `json:"node_id"`
+	StateID     string                 `json:"state_id"`
+	Message     string                 `json:"message"`
+	Variables   map[string]interface{} `json:"variables,omitempty"`
+	ToolCall    *ToolCallRequest       `json:"tool_call,omitempty"`
+	CallbackURL string                 `json:"callback_url,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+}
+
+// ApprovalDecision is the callback body that resumes or cancels the paused execution.
+// ApprovalDecision 是恢复或取消暂停执行的回调内容。
+type ApprovalDecision struct {
+	ID       string `json:"id"`
+	Approved bool   `json:"approved"`
+	Approver string `json:"approver,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// WebhookApprover pauses executions at approval nodes, notifies an external system via a
+// signed webhook, and resumes or cancels them when the system calls back. It implements
+// http.Handler for the callback endpoint. Pending approvals live in memory, so the callback
+// must reach the same process that runs the execution.
+// WebhookApprover 在审批节点暂停执行，通过签名的 webhook 通知外部系统，
+// 并在外部系统回调时恢复或取消执行。它实现 http.Handler 作为回调接口。
+// 待审批请求保存在内存中，回调必须到达运行该执行的进程。
+type WebhookApprover struct {
+	config  WebhookApprovalConfig
+	mu      sync.Mutex
+	pending map[string]chan ApprovalDecision
+}
+
+// NewWebhookApprover creates a new webhook approver.
+// NewWebhookApprover 创建一个新的 webhook 审批器。
+func NewWebhookApprover(config WebhookApprovalConfig) *WebhookApprover {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.MaxSkew <= 0 {
+		config.MaxSkew = 5 * time.Minute
+	}
+	return &WebhookApprover{
+		config:  config,
+		pending: make(map[string]chan ApprovalDecision),
+	}
+}
+
+// Node returns an approval node. On approval it records the decision in the
+// "<id>_approved", "<id>_approver" and "<id>_comment" variables and continues;
+// on rejection or timeout it fails with ErrApprovalRejected or ErrApprovalTimeout.
+// Node 返回审批节点。审批通过时将结果写入 "<id>_approved"、"<id>_approver"、
+// "<id>_comment" 变量并继续执行；被拒绝或超时时以 ErrApprovalRejected 或 ErrApprovalTimeout 失败。
+func (a *WebhookApprover) Node(id, message string) *Node {
+	return NewNode(id).
+		WithType(NodeTypeFunction).
+		WithDescription(message).
+		WithOutput(id+"_approved", "bool", "Whether the request was approved").
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			decision, err := a.Request(ctx, id, message, state)
+			if err != nil {
+				return nil, err
+			}
+			state.SetVariable(id+"_approved", true)
+			state.SetVariable(id+"_approver", decision.Approver)
+			state.SetVariable(id+"_comment", decision.Comment)
+			return state, nil
+		}).
+		Build()
+}
+
+// Request posts an approval request and blocks until it is approved, rejected,
+// times out or ctx is cancelled.
+// Request 发送审批请求并阻塞，直到审批通过、被拒绝、超时或 ctx 被取消。
+func (a *WebhookApprover) Request(ctx context.Context, nodeID, message string, state *State) (*ApprovalDecision, error) {
+	id, err := newApprovalID()
+	if err != nil {
+		return nil, err
+	}
+
+	request := ApprovalRequest{
+		ID:          id,
+		NodeID:      nodeID,
+		StateID:     state.ID,
+		Message:     message,
+		CallbackURL: a.config.CallbackURL,
+		CreatedAt:   time.Now(),
+	}
+	for _, key := range a.config.Variables {
+		if value, ok := state.GetVariable(key); ok {
+			if request.Variables == nil {
+				request.Variables = make(map[string]interface{})
+			}
+			request.Variables[key] = value
+		}
+	}
+	return a.await(ctx, request)
+}
+
+// ApproveToolCall implements ToolApprover. The request carries the pending tool call,
+// and a rejection is returned as a decision rather than an error so that the denial
+// can be fed back to the model.
+// ApproveToolCall 实现 ToolApprover 接口。请求中携带待执行的工具调用，
+// 拒绝时返回审批结果而非错误，以便将拒绝信息反馈给模型。
+func (a *WebhookApprover) ApproveToolCall(ctx context.Context, call ToolCallRequest) (*ApprovalDecision, error) {
+	id, err := newApprovalID()
+	if err != nil {
+		return nil, err
+	}
+
+	decision, err := a.await(ctx, ApprovalRequest{
+		ID:          id,
+		NodeID:      call.NodeID,
+		Message:     fmt.Sprintf("Allow tool %s to run?", call.Tool),
+		ToolCall:    &call,
+		CallbackURL: a.config.CallbackURL,
+		CreatedAt:   time.Now(),
+	})
+	if errors.Is(err, ErrApprovalRejected) {
+		return decision, nil
+	}
+	return decision, err
+}
+
+// await posts the request and blocks until a decision arrives, the request times out
+// or ctx is cancelled.
+func (a *WebhookApprover) await(ctx context.Context, request ApprovalRequest) (*ApprovalDecision, error) {
+	id := request.ID
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+		expiresAt := request.CreatedAt.Add(a.config.Timeout)
+		request.ExpiresAt = &expiresAt
+	}
+
+	// Register before notifying so that a fast callback is not lost
+	decisions := make(chan ApprovalDecision, 1)
+	a.mu.Lock()
+	a.pending[id] = decisions
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.pending, id)
+		a.mu.Unlock()
+	}()
+
+	if err := a.notify(ctx, request); err != nil {
+		return nil, err
+	}
+
+	select {
+	case decision := <-decisions:
+		if !decision.Approved {
+			if decision.Comment != "" {
+				return &decision, fmt.Errorf("%w by %s: %s", ErrApprovalRejected, decision.Approver, decision.Comment)
+			}
+			return &decision, fmt.Errorf("%w by %s", ErrApprovalRejected, decision.Approver)
+		}
+		return &decision, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: request %s", ErrApprovalTimeout, id)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Pending returns the IDs of approval requests waiting for a decision.
+// Pending 返回等待审批结果的请求ID。
+func (a *WebhookApprover) Pending() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ids := make([]string, 0, len(a.pending))
+	for id := range a.pending {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Decide delivers a decision to a pending request without going through HTTP.
+// Decide 直接向待审批请求投递审批结果，无需经过 HTTP。
+func (a *WebhookApprover) Decide(decision ApprovalDecision) error {
+	a.mu.Lock()
+	decisions, ok := a.pending[decision.ID]
+	if ok {
+		delete(a.pending, decision.ID)
+	}
+	a.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("approval request not found: %s", decision.ID)
+	}
+	decisions <- decision
+	return nil
+}
+
+// ServeHTTP receives signed approve/reject callbacks.
+// ServeHTTP 接收签名的审批通过或拒绝回调。
+func (a *WebhookApprover) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if err := a.verify(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var decision ApprovalDecision
+	if err := json.Unmarshal(body, &decision); err != nil {
+		http.Error(w, "invalid decision: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.Decide(decision); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SignApprovalPayload returns the signature headers for body, for use by approval
+// systems replying to the callback and by tests.
+// SignApprovalPayload 返回 body 的签名请求头，供审批系统回调及测试使用。
+func SignApprovalPayload(secret string, body []byte, timestamp time.Time) http.Header {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	header := http.Header{}
+	header.Set(ApprovalTimestampHeader, ts)
+	header.Set(ApprovalSignatureHeader, "sha256="+approvalSignature(secret, ts, body))
+	return header
+}
+
+// notify posts the signed request to the webhook URL.
+func (a *WebhookApprover) notify(ctx context.Context, request ApprovalRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create approval request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range SignApprovalPayload(a.config.Secret, body, time.Now()) {
+		req.Header[key] = values
+	}
+
+	resp, err := a.config.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send approval request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("approval webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// verify checks the callback signature and timestamp.
+func (a *WebhookApprover) verify(header http.Header, body []byte) error {
+	ts := header.Get(ApprovalTimestampHeader)
+	seconds, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid %s header", ApprovalTimestampHeader)
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > a.config.MaxSkew || skew < -a.config.MaxSkew {
+		return fmt.Errorf("callback timestamp outside the accepted window")
+	}
+	expected := "sha256=" + approvalSignature(a.config.Secret, ts, body)
+	if !hmac.Equal([]byte(header.Get(ApprovalSignatureHeader)), []byte(expected)) {
+		return fmt.Errorf("invalid callback signature")
+	}
+	return nil
+}
+
+// approvalSignature computes the hex HMAC-SHA256 of "<timestamp>.<body>".
+func approvalSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newApprovalID returns a random approval request ID.
+func newApprovalID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate approval ID: %w", err)
+	}
+	return "apr_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,187 @@
+// Package graph - Batching node implementation
+// 包 graph - 批处理节点实现
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ================================
+// Batch Node 批处理节点
+// ================================
+
+// BatchConfig configures when a BatchNode flushes its buffered items.
+// A batch is flushed when any of the configured triggers fires.
+// BatchConfig 配置 BatchNode 何时输出缓冲的数据项，任一触发条件满足即输出一批。
+type BatchConfig struct {
+	// Size flushes once this many items are buffered; 0 disables the size trigger.
+	// Size 缓冲的数据项达到该数量时输出，0 表示不按数量触发。
+	Size int
+
+	// Window flushes once the oldest buffered item has waited this long; 0 disables it.
+	// The window is checked whenever the node executes.
+	// Window 最早缓冲的数据项等待超过该时长时输出，0 表示不按时间触发。
+	// 时间窗口在节点每次执行时检查。
+	Window time.Duration
+
+	// InputKey is the variable holding the item to buffer. A []interface{} value
+	// adds each element; a missing variable adds nothing.
+	// InputKey 保存待缓冲数据项的变量。值为 []interface{} 时逐个添加，变量不存在时不添加。
+	InputKey string
+
+	// OutputKey receives the flushed batch as []interface{}. It is removed from the
+	// state when the execution did not flush, so downstream edges can test for it.
+	// OutputKey 接收输出的批次（[]interface{}）。未输出时该变量会被移除，便于下游边判断。
+	OutputKey string
+
+	// FlushKey, when set, flushes all buffered items if the variable is true,
+	// e.g. on the last loop iteration.
+	// FlushKey 若设置，该变量为 true 时输出全部缓冲数据项，例如循环的最后一次迭代。
+	FlushKey string
+}
+
+// BatchNode buffers items across executions or loop iterations and releases them
+// in batches, so an expensive downstream node (batch embedding, batch LLM call)
+// runs once per batch instead of once per item. The buffer lives in the BatchNode,
+// not in the state, and is shared by every execution of the graph.
+// BatchNode 在多次执行或循环迭代之间缓冲数据项并按批输出，
+// 使下游的高开销节点（批量向量化、批量LLM调用）每批只执行一次。
+// 缓冲区保存在 BatchNode 中而非状态中，由图的所有执行共享。
+type BatchNode struct {
+	id      string
+	config  BatchConfig
+	mu      sync.Mutex
+	pending []interface{}
+	firstAt time.Time
+}
+
+// NewBatchNode creates a new batching node.
+// NewBatchNode 创建一个新的批处理节点。
+func NewBatchNode(id string, config BatchConfig) *BatchNode {
+	return &BatchNode{
+		id:     id,
+		config: config,
+	}
+}
+
+// Node returns the graph node that buffers the input item and emits a batch
+// into OutputKey when a trigger fires.
+// Node 返回图节点：缓冲输入数据项，在触发条件满足时将批次写入 OutputKey。
+func (b *BatchNode) Node() *Node {
+	return NewNode(b.id).
+		WithType(NodeTypeFunction).
+		WithInput(b.config.InputKey, "interface{}", false).
+		WithOutput(b.config.OutputKey, "[]interface{}", "Flushed batch of items").
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			var items []interface{}
+			if value, ok := state.GetVariable(b.config.InputKey); ok {
+				if list, isList := value.([]interface{}); isList {
+					items = list
+				} else {
+					items = []interface{}{value}
+				}
+			}
+
+			force := false
+			if b.config.FlushKey != "" {
+				value, _ := state.GetVariable(b.config.FlushKey)
+				force, _ = value.(bool)
+			}
+
+			b.emit(state, b.add(items, force))
+			return state, nil
+		}).
+		Build()
+}
+
+// FlushNode returns a node that emits whatever is still buffered into OutputKey.
+// Place it before the end node so that a partial final batch is not lost.
+// FlushNode 返回一个将剩余缓冲数据项写入 OutputKey 的节点。
+// 将其放在结束节点之前，避免最后一个不完整的批次丢失。
+func (b *BatchNode) FlushNode(id string) *Node {
+	return NewNode(id).
+		WithType(NodeTypeFunction).
+		WithOutput(b.config.OutputKey, "[]interface{}", "Remaining buffered items").
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			b.emit(state, b.Flush())
+			return state, nil
+		}).
+		Build()
+}
+
+// Ready returns an edge condition that holds when the last execution emitted a batch.
+// Ready 返回一个边条件，当最近一次执行输出了批次时成立。
+func (b *BatchNode) Ready() EdgeCondition {
+	return func(ctx context.Context, state *State) (bool, error) {
+		value, ok := state.GetVariable(b.config.OutputKey)
+		if !ok {
+			return false, nil
+		}
+		batch, _ := value.([]interface{})
+		return len(batch) > 0, nil
+	}
+}
+
+// Add buffers items and returns the batch to process if a trigger fired, or nil.
+// Add 缓冲数据项，若触发条件满足则返回待处理的批次，否则返回 nil。
+func (b *BatchNode) Add(items ...interface{}) []interface{} {
+	return b.add(items, false)
+}
+
+// Flush returns and clears all buffered items.
+// Flush 返回并清空所有缓冲的数据项。
+func (b *BatchNode) Flush() []interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
+}
+
+// Pending returns the number of buffered items.
+// Pending 返回缓冲中的数据项数量。
+func (b *BatchNode) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// add buffers items and flushes when forced or a trigger fires.
+func (b *BatchNode) add(items []interface{}, force bool) []interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) == 0 && len(items) > 0 {
+		b.firstAt = time.Now()
+	}
+	b.pending = append(b.pending, items...)
+
+	switch {
+	case len(b.pending) == 0:
+		return nil
+	case force,
+		b.config.Size > 0 && len(b.pending) >= b.config.Size,
+		b.config.Window > 0 && time.Since(b.firstAt) >= b.config.Window:
+		return b.flushLocked()
+	}
+	return nil
+}
+
+func (b *BatchNode) flushLocked() []interface{} {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	batch := b.pending
+	b.pending = nil
+	b.firstAt = time.Time{}
+	return batch
+}
+
+// emit writes batch into OutputKey, or removes the variable when there is none.
+func (b *BatchNode) emit(state *State, batch []interface{}) {
+	if len(batch) == 0 {
+		delete(state.Variables, b.config.OutputKey)
+		return
+	}
+	state.SetVariable(b.config.OutputKey, batch)
+}
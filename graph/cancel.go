@@ -0,0 +1,78 @@
+// Package graph - Cooperative cancellation
+// 包 graph - 协作式取消
+package graph
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// ================================
+// Cooperative Cancellation 协作式取消
+// ================================
+
+// cancellationWatch records when the context of an execution was cancelled, so that the
+// work nodes keep doing afterwards can be measured.
+type cancellationWatch struct {
+	// canceledAt is the cancellation time in Unix nanoseconds, 0 while not cancelled.
+	canceledAt atomic.Int64
+
+	// stop unregisters the watch once the execution has finished.
+	stop func() bool
+}
+
+// watchCancellation starts recording the cancellation of the execution's context. The
+// returned function stops the watch; cancellations after it are not counted.
+// watchCancellation 开始记录执行上下文的取消时间，返回的函数用于停止记录，此后的取消不再计入。
+func (execCtx *ExecutionContext) watchCancellation() func() {
+	watch := &cancellationWatch{}
+	watch.stop = context.AfterFunc(execCtx.Context, func() {
+		watch.canceledAt.Store(time.Now().UnixNano())
+	})
+	execCtx.cancellation = watch
+	return func() { watch.stop() }
+}
+
+// CanceledAt returns when the execution's context was cancelled or timed out, or the zero
+// time if it was not.
+// CanceledAt 返回执行上下文被取消或超时的时间，未取消时返回零值。
+func (execCtx *ExecutionContext) CanceledAt() time.Time {
+	if execCtx.cancellation == nil {
+		return time.Time{}
+	}
+	nanos := execCtx.cancellation.canceledAt.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// recordWastedWork adds the part of a node run that happened after the cancellation to
+// WastedAfterCancel. Nodes that honor their context return promptly and waste little;
+// a large value points at nodes, tools or providers that ignore cancellation.
+// recordWastedWork 将节点在取消之后仍在运行的时间计入 WastedAfterCancel。遵循 context 的节点会及时返回，
+// 浪费很少；数值较大说明有节点、工具或服务商忽略了取消。
+func (execCtx *ExecutionContext) recordWastedWork(start, end time.Time) {
+	canceledAt := execCtx.CanceledAt()
+	if canceledAt.IsZero() || !canceledAt.Before(end) {
+		return
+	}
+	if canceledAt.After(start) {
+		start = canceledAt
+	}
+	execCtx.WastedAfterCancel += end.Sub(start)
+}
+
+// recordCancellation adds a cancelled execution to the stats.
+// recordCancellation 将被取消的执行计入统计。
+func (r *Runnable) recordCancellation(execCtx *ExecutionContext) {
+	if execCtx.CanceledAt().IsZero() {
+		return
+	}
+
+	r.executionStats.lock.Lock()
+	defer r.executionStats.lock.Unlock()
+	r.executionStats.CanceledExecutions++
+	r.executionStats.WastedAfterCancel += execCtx.WastedAfterCancel
+}
@@ -0,0 +1,113 @@
+// Package graph - Checkpointed execution
+// 包 graph - 检查点执行
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// ================================
+// Checkpointed Execution 检查点执行
+// ================================
+
+// CheckpointResumeNodeKey is the metadata key under which a checkpoint records the node to
+// resume from, or "END" once the execution has finished.
+// CheckpointResumeNodeKey 是检查点中记录恢复节点的元数据键，执行完成后为 "END"。
+const CheckpointResumeNodeKey = "checkpoint_resume_node"
+
+// checkpointing saves the state of an execution at step boundaries.
+type checkpointing struct {
+	manager *CheckpointManager
+	every   int
+}
+
+// WithCheckpointEvery sets how many nodes run between two checkpoints of InvokeWithCheckpointing
+// and ResumeFromCheckpoint; defaults to 1, a checkpoint after every node.
+// WithCheckpointEvery 设置 InvokeWithCheckpointing 和 ResumeFromCheckpoint 每执行多少个节点保存一次检查点，
+// 默认为1，即每个节点之后都保存。
+func WithCheckpointEvery(steps int) ExecutionOption {
+	return func(ctx *ExecutionContext) {
+		if ctx.checkpoint != nil {
+			ctx.checkpoint.every = steps
+		}
+	}
+}
+
+// InvokeWithCheckpointing executes the graph and saves a checkpoint of the state through the
+// manager before the first node, every few nodes (see WithCheckpointEvery) and once the
+// execution has finished. After a crash or timeout, ResumeFromCheckpoint continues from the
+// node after the last checkpoint. Failing to save a checkpoint does not stop the execution;
+// it is reported as a warning.
+// InvokeWithCheckpointing 执行图，在第一个节点之前、每执行若干个节点（见 WithCheckpointEvery）以及执行完成时通过管理器保存状态检查点。
+// 崩溃或超时后，ResumeFromCheckpoint 从最后一个检查点之后的节点继续执行。检查点保存失败不会中止执行，而是记录为警告。
+func (r *Runnable) InvokeWithCheckpointing(ctx context.Context, state *State, manager *CheckpointManager, options ...ExecutionOption) (*State, error) {
+	if manager == nil {
+		return nil, fmt.Errorf("checkpoint manager cannot be nil")
+	}
+	opts := append([]ExecutionOption{withCheckpointing(manager)}, options...)
+	result, _, err := r.invoke(ctx, state, opts...)
+	return result, err
+}
+
+// ResumeFromCheckpoint continues an execution started with InvokeWithCheckpointing from its latest
+// checkpoint. Nodes completed before the checkpoint are not run again, but the node that was
+// running when the execution stopped is, so nodes with side effects should be idempotent. The
+// resumed execution keeps checkpointing; resuming a finished execution returns its final state.
+// ResumeFromCheckpoint 从最近的检查点继续执行通过 InvokeWithCheckpointing 启动的执行。检查点之前已完成的节点不会重新执行，
+// 但执行停止时正在运行的节点会重新执行，有副作用的节点应当是幂等的。恢复后的执行继续保存检查点；
+// 恢复已完成的执行时直接返回其最终状态。
+func (r *Runnable) ResumeFromCheckpoint(ctx context.Context, manager *CheckpointManager, stateID string, options ...ExecutionOption) (*State, error) {
+	if manager == nil {
+		return nil, fmt.Errorf("checkpoint manager cannot be nil")
+	}
+	state, err := manager.LatestCheckpoint(ctx, stateID)
+	if err != nil {
+		return nil, err
+	}
+	resumeNode, _ := state.GetMetadata(CheckpointResumeNodeKey)
+	nodeID, _ := resumeNode.(string)
+	if nodeID == "" {
+		return nil, fmt.Errorf("checkpoint of state %s does not record a node to resume from", stateID)
+	}
+	delete(state.Metadata, CheckpointResumeNodeKey)
+	if nodeID == "END" {
+		return state, nil
+	}
+
+	opts := append([]ExecutionOption{withCheckpointing(manager)}, options...)
+	opts = append(opts, func(execCtx *ExecutionContext) {
+		execCtx.startNode = nodeID
+	})
+	result, _, err := r.invoke(ctx, state, opts...)
+	return result, err
+}
+
+// withCheckpointing enables checkpoints for an execution.
+func withCheckpointing(manager *CheckpointManager) ExecutionOption {
+	return func(ctx *ExecutionContext) {
+		ctx.checkpoint = &checkpointing{manager: manager, every: 1}
+	}
+}
+
+// save checkpoints the state at a step boundary when the execution starts, the interval is
+// reached or the execution has finished. A resumed execution already has its start checkpoint.
+func (c *checkpointing) save(execCtx *ExecutionContext, state *State, nextNode string) {
+	finished := nextNode == "END" || nextNode == ""
+	every := c.every
+	if every <= 0 {
+		every = 1
+	}
+	if !finished && (execCtx.StepCount%every != 0 || execCtx.StepCount == 0 && execCtx.startNode != "") {
+		return
+	}
+	if finished {
+		nextNode = "END"
+	}
+
+	snapshot := state.Clone()
+	snapshot.SetMetadata(CheckpointResumeNodeKey, nextNode)
+	if err := c.manager.CreateCheckpoint(execCtx.Context, snapshot); err != nil {
+		execCtx.Warnings = append(execCtx.Warnings, fmt.Sprintf("checkpoint before node %s failed: %v", nextNode, err))
+	}
+}
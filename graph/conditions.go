@@ -0,0 +1,149 @@
+// Package graph - Shared condition and value library
+// 包 graph - 共享条件与常量库
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ================================
+// Condition Library 条件库
+// ================================
+
+// conditionLibrary holds the named conditions and values shared by all graphs.
+// conditionLibrary 保存所有图共享的命名条件和常量。
+var conditionLibrary = struct {
+	sync.RWMutex
+	conditions map[string]EdgeCondition
+	values     map[string]interface{}
+}{
+	conditions: make(map[string]EdgeCondition),
+	values:     make(map[string]interface{}),
+}
+
+// RegisterCondition makes a condition available by name to every graph, so edges can refer to it
+// with WithNamedCondition instead of repeating the closure. Like database/sql.Register it is meant
+// to be called from init functions and panics if the name is empty, the condition is nil or the
+// name is already registered.
+// RegisterCondition 以名称注册条件，供所有图使用，边可以通过 WithNamedCondition 引用而无需重复编写闭包。
+// 与 database/sql.Register 一样应在 init 函数中调用，名称为空、条件为 nil 或名称重复时 panic。
+func RegisterCondition(name string, condition EdgeCondition) {
+	if name == "" {
+		panic("graph: RegisterCondition name is empty")
+	}
+	if condition == nil {
+		panic("graph: RegisterCondition condition is nil for " + name)
+	}
+
+	conditionLibrary.Lock()
+	defer conditionLibrary.Unlock()
+	if _, exists := conditionLibrary.conditions[name]; exists {
+		panic("graph: RegisterCondition called twice for " + name)
+	}
+	conditionLibrary.conditions[name] = condition
+}
+
+// RegisterExprCondition registers an expression as a named condition. The expression is compiled
+// once and evaluated against ExprVariables, so it can use registered values. It panics under the
+// same rules as RegisterCondition and if the expression does not compile.
+// RegisterExprCondition 将表达式注册为命名条件。表达式只编译一次，基于 ExprVariables 求值，
+// 因此可以使用已注册的常量。panic 规则与 RegisterCondition 相同，表达式无法编译时同样 panic。
+func RegisterExprCondition(name, expression string) {
+	expr, err := CompileExpr(expression)
+	if err != nil {
+		panic(fmt.Sprintf("graph: RegisterExprCondition %s: %v", name, err))
+	}
+	RegisterCondition(name, func(ctx context.Context, state *State) (bool, error) {
+		return expr.EvalBool(ExprVariables(state))
+	})
+}
+
+// LookupCondition returns the condition registered under name.
+// LookupCondition 返回以 name 注册的条件。
+func LookupCondition(name string) (EdgeCondition, bool) {
+	conditionLibrary.RLock()
+	defer conditionLibrary.RUnlock()
+	condition, ok := conditionLibrary.conditions[name]
+	return condition, ok
+}
+
+// RegisteredConditions returns the names of all registered conditions, sorted.
+// RegisteredConditions 返回所有已注册条件的名称，按字母排序。
+func RegisteredConditions() []string {
+	conditionLibrary.RLock()
+	defer conditionLibrary.RUnlock()
+	names := make([]string, 0, len(conditionLibrary.conditions))
+	for name := range conditionLibrary.conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NamedCondition returns a condition that calls the condition registered under name, for places
+// that take an EdgeCondition such as loops. The name is resolved on every call, and an unknown
+// name is reported as an error.
+// NamedCondition 返回调用以 name 注册的条件的函数，用于循环等接收 EdgeCondition 的场景。
+// 每次调用时解析名称，名称未注册时返回错误。
+func NamedCondition(name string) EdgeCondition {
+	return func(ctx context.Context, state *State) (bool, error) {
+		condition, ok := LookupCondition(name)
+		if !ok {
+			return false, fmt.Errorf("unknown condition: %s", name)
+		}
+		return condition(ctx, state)
+	}
+}
+
+// ================================
+// Shared Values 共享常量
+// ================================
+
+// RegisterValue registers a named value, such as a threshold or a list of VIP tiers, that
+// conditions read with LookupValue and expressions see as a variable. Registering a name
+// again replaces the value.
+// RegisterValue 注册命名常量（例如阈值或 VIP 等级列表），条件通过 LookupValue 读取，表达式中可作为变量使用。
+// 重复注册同一名称会替换原值。
+func RegisterValue(name string, value interface{}) {
+	if name == "" {
+		panic("graph: RegisterValue name is empty")
+	}
+
+	conditionLibrary.Lock()
+	defer conditionLibrary.Unlock()
+	conditionLibrary.values[name] = value
+}
+
+// LookupValue returns the value registered under name.
+// LookupValue 返回以 name 注册的常量。
+func LookupValue(name string) (interface{}, bool) {
+	conditionLibrary.RLock()
+	defer conditionLibrary.RUnlock()
+	value, ok := conditionLibrary.values[name]
+	return value, ok
+}
+
+// ExprVariables returns the variables expressions are evaluated against: the registered values
+// overlaid with the state variables, so a state variable shadows a value of the same name. The
+// state variables and metadata are also reachable as variables.<name> and metadata.<name>
+// unless a variable or value already uses those names.
+// ExprVariables 返回表达式求值使用的变量：已注册的常量叠加状态变量，同名时状态变量优先。
+// 状态变量和元数据还可以通过 variables.<名称> 和 metadata.<名称> 访问，除非已有同名的变量或常量。
+func ExprVariables(state *State) map[string]interface{} {
+	conditionLibrary.RLock()
+	defer conditionLibrary.RUnlock()
+
+	vars := make(map[string]interface{}, len(conditionLibrary.values)+len(state.Variables)+2)
+	vars["variables"] = state.Variables
+	vars["metadata"] = state.Metadata
+	for name, value := range conditionLibrary.values {
+		vars[name] = value
+	}
+	for name, value := range state.Variables {
+		vars[name] = value
+	}
+	return vars
+}
@@ -0,0 +1,162 @@
+// Package graph - Shared counter stores for middleware
+// 包 graph - 中间件共享计数存储
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ================================
+// Counter Store 计数存储
+// ================================
+
+// CounterStore keeps rate limiter and circuit breaker state outside the process,
+// so that replicas running the same graphs share limits and breaker states.
+// Implementations must apply each operation atomically.
+// CounterStore 将限流器和断路器状态保存在进程之外，
+// 使运行相同图的多个副本共享限流和断路状态。实现必须保证每个操作的原子性。
+type CounterStore interface {
+	// AllowRate refills the token bucket identified by key and tries to take one token.
+	// AllowRate 补充 key 对应的令牌桶并尝试取出一个令牌。
+	AllowRate(ctx context.Context, key string, rate float64, burstSize int) (bool, error)
+
+	// AllowCircuit reports whether the circuit identified by key lets a request through,
+	// moving an open circuit to half-open once resetTimeout has passed.
+	// AllowCircuit 判断 key 对应的断路器是否放行请求，
+	// 断开状态超过 resetTimeout 后转为半开状态。
+	AllowCircuit(ctx context.Context, key string, resetTimeout time.Duration) (bool, error)
+
+	// RecordCircuit records the result of a request and returns the new circuit state.
+	// RecordCircuit 记录请求结果并返回新的断路器状态。
+	RecordCircuit(ctx context.Context, key string, failed bool, failureThreshold int) (CircuitState, error)
+}
+
+// ================================
+// Redis Counter Store Redis计数存储
+// ================================
+
+// RedisCounterStore is a CounterStore backed by Redis.
+// Timestamps are taken from the local clock, so replicas should be time-synchronized.
+// RedisCounterStore 是基于Redis的 CounterStore。
+// 时间戳取自本地时钟，因此各副本之间需要保持时间同步。
+type RedisCounterStore struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+var _ CounterStore = (*RedisCounterStore)(nil)
+
+// NewRedisCounterStore creates a new Redis-backed counter store.
+// NewRedisCounterStore 创建一个新的基于Redis的计数存储。
+func NewRedisCounterStore(client redis.UniversalClient, keyPrefix string) *RedisCounterStore {
+	return &RedisCounterStore{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+// rateLimitScript implements a token bucket stored in a hash with fields tokens and ts (ms).
+var rateLimitScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return allowed
+`)
+
+// circuitAllowScript mirrors CircuitBreakerMiddleware.allowRequest on a hash with
+// fields state, failures and last_failure (ms).
+var circuitAllowScript = redis.NewScript(`
+local state = tonumber(redis.call('HGET', KEYS[1], 'state') or '0')
+if state == 0 or state == 2 then
+	return 1
+end
+local last = tonumber(redis.call('HGET', KEYS[1], 'last_failure') or '0')
+if tonumber(ARGV[1]) - last > tonumber(ARGV[2]) then
+	redis.call('HSET', KEYS[1], 'state', '2')
+	return 1
+end
+return 0
+`)
+
+// circuitRecordScript mirrors CircuitBreakerMiddleware.recordResult.
+var circuitRecordScript = redis.NewScript(`
+if ARGV[1] == '1' then
+	local failures = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+	redis.call('HSET', KEYS[1], 'last_failure', ARGV[2])
+	if failures >= tonumber(ARGV[3]) then
+		redis.call('HSET', KEYS[1], 'state', '1')
+	end
+else
+	redis.call('HSET', KEYS[1], 'failures', '0', 'state', '0')
+end
+return tonumber(redis.call('HGET', KEYS[1], 'state') or '0')
+`)
+
+// AllowRate implements the CounterStore interface.
+// AllowRate 实现 CounterStore 接口。
+func (s *RedisCounterStore) AllowRate(ctx context.Context, key string, rate float64, burstSize int) (bool, error) {
+	// Keep the bucket until it would be full again, plus a margin.
+	ttl := time.Minute
+	if rate > 0 {
+		ttl = time.Duration(float64(burstSize)/rate*float64(time.Second)) + time.Second
+	}
+
+	allowed, err := rateLimitScript.Run(ctx, s.client, []string{s.key("ratelimit", key)},
+		strconv.FormatFloat(rate, 'f', -1, 64), burstSize, time.Now().UnixMilli(), ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return allowed == 1, nil
+}
+
+// AllowCircuit implements the CounterStore interface.
+// AllowCircuit 实现 CounterStore 接口。
+func (s *RedisCounterStore) AllowCircuit(ctx context.Context, key string, resetTimeout time.Duration) (bool, error) {
+	allowed, err := circuitAllowScript.Run(ctx, s.client, []string{s.key("circuit", key)},
+		time.Now().UnixMilli(), resetTimeout.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to check circuit breaker: %w", err)
+	}
+	return allowed == 1, nil
+}
+
+// RecordCircuit implements the CounterStore interface.
+// RecordCircuit 实现 CounterStore 接口。
+func (s *RedisCounterStore) RecordCircuit(ctx context.Context, key string, failed bool, failureThreshold int) (CircuitState, error) {
+	flag := "0"
+	if failed {
+		flag = "1"
+	}
+	state, err := circuitRecordScript.Run(ctx, s.client, []string{s.key("circuit", key)},
+		flag, time.Now().UnixMilli(), failureThreshold).Int()
+	if err != nil {
+		return CircuitStateClosed, fmt.Errorf("failed to record circuit breaker result: %w", err)
+	}
+	return CircuitState(state), nil
+}
+
+// key builds the Redis key for a counter.
+func (s *RedisCounterStore) key(kind, key string) string {
+	return s.keyPrefix + kind + ":" + key
+}
@@ -0,0 +1,448 @@
+// Package dashboard provides an embeddable web dashboard for graph executions.
+// It renders execution stats, recent traces, node metrics and circuit breaker
+// states, and streams in-flight executions over Server-Sent Events.
+// 包 dashboard 提供可嵌入的图执行Web仪表盘，展示执行统计、最近的追踪、
+// 节点指标和断路器状态，并通过SSE实时推送进行中的执行。
+package dashboard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/graph"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// ================================
+// Records 记录
+// ================================
+
+// Execution describes a tracked graph execution, in flight or finished.
+// Execution 描述一次被跟踪的图执行（进行中或已完成）。
+type Execution struct {
+	// ID is the execution ID.
+	ID string `json:"id"`
+
+	// Graph is the name the graph was registered under.
+	Graph string `json:"graph"`
+
+	// StartTime is when the execution started.
+	StartTime time.Time `json:"start_time"`
+
+	// Duration is how long the execution took; zero while in flight.
+	Duration time.Duration `json:"duration"`
+
+	// CurrentNode is the node being executed, if the dashboard middleware is installed.
+	CurrentNode string `json:"current_node,omitempty"`
+
+	// Done reports whether the execution has finished.
+	Done bool `json:"done"`
+
+	// Success reports whether the execution succeeded.
+	Success bool `json:"success"`
+
+	// Error is the execution error, if any.
+	Error string `json:"error,omitempty"`
+
+	// Path is the ordered list of executed node IDs.
+	Path []string `json:"path,omitempty"`
+
+	// Usage is the token and cost usage of the execution.
+	Usage graph.Usage `json:"usage"`
+
+	// Trace is the execution trace.
+	Trace []graph.TraceEntry `json:"trace,omitempty"`
+}
+
+// Event is pushed to SSE subscribers whenever a tracked execution changes.
+// Event 在被跟踪的执行发生变化时推送给SSE订阅者。
+type Event struct {
+	// Type is one of execution_start, node_start, node_end and execution_end.
+	Type string `json:"type"`
+
+	// ExecutionID is the ID of the execution.
+	ExecutionID string `json:"execution_id"`
+
+	// Graph is the name of the graph.
+	Graph string `json:"graph"`
+
+	// NodeID is the node for node events.
+	NodeID string `json:"node_id,omitempty"`
+
+	// Error is set when a node or execution failed.
+	Error string `json:"error,omitempty"`
+
+	// Timestamp is when the event happened.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ================================
+// Dashboard 仪表盘
+// ================================
+
+// Dashboard is an http.Handler serving the dashboard page and its JSON/SSE API.
+// Mount it with http.StripPrefix to serve it under a sub-path.
+// Dashboard 是提供仪表盘页面及其JSON/SSE接口的 http.Handler。
+// 使用 http.StripPrefix 可将其挂载到子路径下。
+type Dashboard struct {
+	mu        sync.RWMutex
+	graphs    map[string]*graph.Runnable
+	metrics   map[string]*graph.MetricsMiddleware
+	breakers  map[string]*graph.CircuitBreakerMiddleware
+	inflight  map[string]*Execution
+	recent    []*Execution
+	maxTraces int
+
+	subMu       sync.Mutex
+	subscribers map[chan Event]struct{}
+
+	mux *http.ServeMux
+}
+
+// Option configures a Dashboard.
+// Option 配置 Dashboard。
+type Option func(*Dashboard)
+
+// WithMaxTraces sets how many finished executions are kept (default 100).
+// WithMaxTraces 设置保留的已完成执行数量（默认100）。
+func WithMaxTraces(n int) Option {
+	return func(d *Dashboard) {
+		d.maxTraces = n
+	}
+}
+
+// New creates a new dashboard.
+// New 创建一个新的仪表盘。
+func New(opts ...Option) *Dashboard {
+	d := &Dashboard{
+		graphs:      make(map[string]*graph.Runnable),
+		metrics:     make(map[string]*graph.MetricsMiddleware),
+		breakers:    make(map[string]*graph.CircuitBreakerMiddleware),
+		inflight:    make(map[string]*Execution),
+		maxTraces:   100,
+		subscribers: make(map[chan Event]struct{}),
+		mux:         http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	d.mux.HandleFunc("GET /{$}", d.handleIndex)
+	d.mux.HandleFunc("GET /api/stats", d.handleStats)
+	d.mux.HandleFunc("GET /api/traces", d.handleTraces)
+	d.mux.HandleFunc("GET /api/traces/{id}", d.handleTrace)
+	d.mux.HandleFunc("GET /api/events", d.handleEvents)
+	return d
+}
+
+// RegisterGraph adds a compiled graph whose execution stats are shown.
+// RegisterGraph 注册一个已编译的图，展示其执行统计。
+func (d *Dashboard) RegisterGraph(name string, runnable *graph.Runnable) *Dashboard {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.graphs[name] = runnable
+	return d
+}
+
+// RegisterMetrics adds a metrics middleware whose node metrics are shown.
+// RegisterMetrics 注册一个指标中间件，展示其节点指标。
+func (d *Dashboard) RegisterMetrics(name string, metrics *graph.MetricsMiddleware) *Dashboard {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.metrics[name] = metrics
+	return d
+}
+
+// RegisterCircuitBreaker adds a circuit breaker whose state is shown.
+// RegisterCircuitBreaker 注册一个断路器，展示其状态。
+func (d *Dashboard) RegisterCircuitBreaker(name string, breaker *graph.CircuitBreakerMiddleware) *Dashboard {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakers[name] = breaker
+	return d
+}
+
+// Invoke runs a registered graph with InvokeDetailed and tracks it as an
+// in-flight execution, then keeps its trace among the recent executions.
+// Invoke 使用 InvokeDetailed 执行已注册的图，并将其作为进行中的执行跟踪，
+// 完成后将其追踪保存到最近执行列表中。
+func (d *Dashboard) Invoke(ctx context.Context, name string, state *graph.State, options ...graph.ExecutionOption) (*graph.Result, error) {
+	d.mu.RLock()
+	runnable, ok := d.graphs[name]
+	d.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("graph %s is not registered", name)
+	}
+
+	exec := &Execution{
+		ID:        fmt.Sprintf("exec_%d", time.Now().UnixNano()),
+		Graph:     name,
+		StartTime: time.Now(),
+	}
+	d.mu.Lock()
+	d.inflight[exec.ID] = exec
+	d.mu.Unlock()
+	d.publish(Event{Type: "execution_start", ExecutionID: exec.ID, Graph: name, Timestamp: exec.StartTime})
+
+	ctx = context.WithValue(ctx, executionContextKey{}, exec)
+	options = append([]graph.ExecutionOption{graph.WithExecutionID(exec.ID)}, options...)
+	result, err := runnable.InvokeDetailed(ctx, state, options...)
+
+	d.finish(exec, result, err)
+	return result, err
+}
+
+// Middleware returns a graph middleware that reports node progress of executions
+// started through Invoke. Install it with GraphBuilder.WithMiddleware.
+// Middleware 返回一个图中间件，用于报告通过 Invoke 启动的执行的节点进度。
+// 使用 GraphBuilder.WithMiddleware 安装。
+func (d *Dashboard) Middleware() graph.Middleware {
+	return &progressMiddleware{dashboard: d}
+}
+
+// finish moves an execution from in-flight to the recent list.
+func (d *Dashboard) finish(exec *Execution, result *graph.Result, err error) {
+	d.mu.Lock()
+	exec.Done = true
+	exec.Duration = time.Since(exec.StartTime)
+	exec.CurrentNode = ""
+	exec.Success = err == nil
+	if err != nil {
+		exec.Error = err.Error()
+	}
+	if result != nil {
+		exec.Path = result.Path
+		exec.Usage = result.Usage
+		exec.Trace = result.Trace
+	}
+	delete(d.inflight, exec.ID)
+	d.recent = append(d.recent, exec)
+	if d.maxTraces > 0 && len(d.recent) > d.maxTraces {
+		d.recent = d.recent[len(d.recent)-d.maxTraces:]
+	}
+	d.mu.Unlock()
+
+	d.publish(Event{Type: "execution_end", ExecutionID: exec.ID, Graph: exec.Graph, Error: exec.Error, Timestamp: time.Now()})
+}
+
+// ================================
+// Live Updates 实时更新
+// ================================
+
+// executionContextKey is the context key for the execution tracked by Invoke.
+type executionContextKey struct{}
+
+// progressMiddleware publishes node start and end events.
+type progressMiddleware struct {
+	dashboard *Dashboard
+}
+
+// Process implements the graph.Middleware interface.
+func (m *progressMiddleware) Process(ctx context.Context, next func(ctx context.Context, state *graph.State) (*graph.State, error), state *graph.State) (*graph.State, error) {
+	exec, ok := ctx.Value(executionContextKey{}).(*Execution)
+	if !ok {
+		return next(ctx, state)
+	}
+
+	nodeID := graph.NodeIDFromContext(ctx)
+	m.dashboard.mu.Lock()
+	exec.CurrentNode = nodeID
+	m.dashboard.mu.Unlock()
+	m.dashboard.publish(Event{Type: "node_start", ExecutionID: exec.ID, Graph: exec.Graph, NodeID: nodeID, Timestamp: time.Now()})
+
+	result, err := next(ctx, state)
+
+	event := Event{Type: "node_end", ExecutionID: exec.ID, Graph: exec.Graph, NodeID: nodeID, Timestamp: time.Now()}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	m.dashboard.publish(event)
+	return result, err
+}
+
+// subscribe registers an SSE subscriber.
+func (d *Dashboard) subscribe() chan Event {
+	ch := make(chan Event, 64)
+	d.subMu.Lock()
+	d.subscribers[ch] = struct{}{}
+	d.subMu.Unlock()
+	return ch
+}
+
+// unsubscribe removes an SSE subscriber.
+func (d *Dashboard) unsubscribe(ch chan Event) {
+	d.subMu.Lock()
+	delete(d.subscribers, ch)
+	d.subMu.Unlock()
+}
+
+// publish sends an event to all subscribers, dropping it for slow ones.
+func (d *Dashboard) publish(event Event) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	for ch := range d.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// ================================
+// HTTP Handlers HTTP处理器
+// ================================
+
+// ServeHTTP implements the http.Handler interface.
+// ServeHTTP 实现 http.Handler 接口。
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mux.ServeHTTP(w, r)
+}
+
+// Stats is the payload of /api/stats.
+// Stats 是 /api/stats 接口的返回内容。
+type Stats struct {
+	Graphs          map[string]*graph.ExecutionStats         `json:"graphs"`
+	Metrics         map[string]map[string]*graph.NodeMetrics `json:"metrics"`
+	LabeledMetrics  map[string][]graph.LabeledMetrics        `json:"labeled_metrics,omitempty"`
+	CircuitBreakers map[string]string                        `json:"circuit_breakers"`
+	InFlight        []*Execution                             `json:"in_flight"`
+}
+
+// Stats returns a snapshot of everything the dashboard shows except traces.
+// Stats 返回仪表盘展示内容（追踪除外）的快照。
+func (d *Dashboard) Stats() *Stats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := &Stats{
+		Graphs:          make(map[string]*graph.ExecutionStats),
+		Metrics:         make(map[string]map[string]*graph.NodeMetrics),
+		CircuitBreakers: make(map[string]string),
+		InFlight:        make([]*Execution, 0, len(d.inflight)),
+	}
+	for name, runnable := range d.graphs {
+		stats.Graphs[name] = runnable.GetExecutionStats()
+	}
+	for name, metrics := range d.metrics {
+		stats.Metrics[name] = metrics.GetMetrics()
+		if labeled := metrics.GetLabeledMetrics(); len(labeled) > 0 {
+			if stats.LabeledMetrics == nil {
+				stats.LabeledMetrics = make(map[string][]graph.LabeledMetrics)
+			}
+			stats.LabeledMetrics[name] = labeled
+		}
+	}
+	for name, breaker := range d.breakers {
+		stats.CircuitBreakers[name] = circuitStateName(breaker.GetState())
+	}
+	for _, exec := range d.inflight {
+		copied := *exec
+		stats.InFlight = append(stats.InFlight, &copied)
+	}
+	sort.Slice(stats.InFlight, func(i, j int) bool {
+		return stats.InFlight[i].StartTime.Before(stats.InFlight[j].StartTime)
+	})
+	return stats
+}
+
+// Traces returns the recent finished executions, newest first.
+// Traces 返回最近完成的执行，最新的在前。
+func (d *Dashboard) Traces() []*Execution {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	traces := make([]*Execution, 0, len(d.recent))
+	for i := len(d.recent) - 1; i >= 0; i-- {
+		traces = append(traces, d.recent[i])
+	}
+	return traces
+}
+
+func (d *Dashboard) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexHTML)
+}
+
+func (d *Dashboard) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, d.Stats())
+}
+
+func (d *Dashboard) handleTraces(w http.ResponseWriter, r *http.Request) {
+	// The list omits trace entries to keep the payload small
+	summaries := make([]Execution, 0)
+	for _, exec := range d.Traces() {
+		summary := *exec
+		summary.Trace = nil
+		summaries = append(summaries, summary)
+	}
+	writeJSON(w, summaries)
+}
+
+func (d *Dashboard) handleTrace(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	for _, exec := range d.Traces() {
+		if exec.ID == id {
+			writeJSON(w, exec)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (d *Dashboard) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before responding so no event is missed once the client sees the stream
+	ch := d.subscribe()
+	defer d.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func circuitStateName(state graph.CircuitState) string {
+	switch state {
+	case graph.CircuitStateOpen:
+		return "open"
+	case graph.CircuitStateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
@@ -0,0 +1,92 @@
+package dashboard_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/graph"
+	"github.com/sjzsdu/langchaingo-cn/graph/dashboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	board := dashboard.New()
+	metrics := graph.NewMetricsMiddleware()
+	breaker := graph.NewCircuitBreakerMiddleware(3, time.Minute)
+
+	g := graph.NewGraph("echo").
+		WithMiddleware(board.Middleware(), metrics, breaker).
+		AddNodes(graph.VariableSetterNode("set", "answer", 42)).
+		Connect("set", "END").
+		SetEntryPoint("set").
+		Build()
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	board.RegisterGraph("echo", runnable).
+		RegisterMetrics("echo", metrics).
+		RegisterCircuitBreaker("llm", breaker)
+
+	server := httptest.NewServer(http.StripPrefix("/debug/graph", board))
+	defer server.Close()
+
+	// Subscribe to live events before running
+	resp, err := http.Get(server.URL + "/debug/graph/api/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	result, err := board.Invoke(context.Background(), "echo", graph.NewState("s1"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"set"}, result.Path)
+
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(types) < 4 && scanner.Scan() {
+		if event, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			types = append(types, event)
+		}
+	}
+	assert.Equal(t, []string{"execution_start", "node_start", "node_end", "execution_end"}, types)
+
+	var stats dashboard.Stats
+	getJSON(t, server.URL+"/debug/graph/api/stats", &stats)
+	assert.Equal(t, int64(1), stats.Graphs["echo"].SuccessfulExecutions)
+	assert.Equal(t, int64(1), stats.Metrics["echo"]["set"].ExecutionCount)
+	assert.Equal(t, "closed", stats.CircuitBreakers["llm"])
+	assert.Empty(t, stats.InFlight)
+
+	var traces []dashboard.Execution
+	getJSON(t, server.URL+"/debug/graph/api/traces", &traces)
+	require.Len(t, traces, 1)
+	assert.True(t, traces[0].Success)
+	assert.Empty(t, traces[0].Trace)
+
+	var trace dashboard.Execution
+	getJSON(t, server.URL+"/debug/graph/api/traces/"+traces[0].ID, &trace)
+	assert.NotEmpty(t, trace.Trace)
+
+	page, err := http.Get(server.URL + "/debug/graph/")
+	require.NoError(t, err)
+	page.Body.Close()
+	assert.Equal(t, http.StatusOK, page.StatusCode)
+
+	_, err = board.Invoke(context.Background(), "missing", graph.NewState("s2"))
+	assert.Error(t, err)
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
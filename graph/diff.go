@@ -0,0 +1,96 @@
+// Package graph - State diffs
+// 包 graph - 状态差异
+package graph
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ================================
+// State Diff 状态差异
+// ================================
+
+// StateDiff describes the changes a node made to the state.
+// StateDiff 描述节点对状态所做的修改。
+type StateDiff struct {
+	// Variables contains the variables that were added or changed, with their new values.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+
+	// RemovedVariables lists the variables that were deleted.
+	RemovedVariables []string `json:"removed_variables,omitempty"`
+
+	// Metadata contains the metadata entries that were added or changed.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// RemovedMetadata lists the metadata entries that were deleted.
+	RemovedMetadata []string `json:"removed_metadata,omitempty"`
+
+	// Messages contains the appended messages, or all messages when MessagesReset is set.
+	Messages []llms.MessageContent `json:"messages,omitempty"`
+
+	// MessagesReset indicates that earlier messages were changed or removed.
+	MessagesReset bool `json:"messages_reset,omitempty"`
+}
+
+// IsEmpty reports whether the diff contains no changes.
+// IsEmpty 判断差异是否为空。
+func (d *StateDiff) IsEmpty() bool {
+	return len(d.Variables) == 0 && len(d.RemovedVariables) == 0 &&
+		len(d.Metadata) == 0 && len(d.RemovedMetadata) == 0 &&
+		len(d.Messages) == 0 && !d.MessagesReset
+}
+
+// DiffStates returns the changes from before to after. Values are compared deeply, so values
+// mutated in place after before was cloned are reported as unchanged.
+// DiffStates 返回从 before 到 after 的修改。值按深度比较，因此克隆 before 之后被原地修改的值视为未变化。
+func DiffStates(before, after *State) *StateDiff {
+	if before == nil {
+		before = &State{}
+	}
+	if after == nil {
+		after = &State{}
+	}
+
+	diff := &StateDiff{}
+	diff.Variables, diff.RemovedVariables = diffMaps(before.Variables, after.Variables)
+	diff.Metadata, diff.RemovedMetadata = diffMaps(before.Metadata, after.Metadata)
+
+	prefix := len(before.Messages)
+	if prefix <= len(after.Messages) && (prefix == 0 || reflect.DeepEqual(before.Messages, after.Messages[:prefix])) {
+		if len(after.Messages) > prefix {
+			diff.Messages = append([]llms.MessageContent(nil), after.Messages[prefix:]...)
+		}
+	} else {
+		diff.Messages = append([]llms.MessageContent(nil), after.Messages...)
+		diff.MessagesReset = true
+	}
+
+	return diff
+}
+
+// diffMaps returns the changed entries and the sorted removed keys.
+func diffMaps(before, after map[string]interface{}) (map[string]interface{}, []string) {
+	var changed map[string]interface{}
+	for k, v := range after {
+		if old, exists := before[k]; exists && reflect.DeepEqual(old, v) {
+			continue
+		}
+		if changed == nil {
+			changed = make(map[string]interface{})
+		}
+		changed[k] = v
+	}
+
+	var removed []string
+	for k := range before {
+		if _, exists := after[k]; !exists {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+
+	return changed, removed
+}
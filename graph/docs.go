@@ -0,0 +1,268 @@
+// Package graph - Documentation generator
+// 包 graph - 文档生成器
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ================================
+// Documentation 文档生成
+// ================================
+
+// GenerateDocs renders Markdown documentation for a graph: an overview, the input schema,
+// a Mermaid diagram, every node (description, inputs/outputs, timeouts, tags) and every edge
+// with its condition. Condition functions cannot be rendered, so conditions are taken from the
+// edge's "condition" or "expression" metadata, falling back to the edge name.
+// GenerateDocs 为图生成 Markdown 文档：概览、输入参数、Mermaid 流程图、
+// 每个节点（描述、输入输出、超时、标签）以及每条边及其条件。
+// 条件函数无法渲染，条件取自边的 "condition" 或 "expression" 元数据，否则使用边的名称。
+func GenerateDocs(g *Graph) string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	edges := g.router.edgeRefs()
+
+	var b strings.Builder
+	title := g.Name
+	if title == "" {
+		title = g.ID
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if g.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", g.Description)
+	}
+
+	b.WriteString("| Property | Value |\n| --- | --- |\n")
+	fmt.Fprintf(&b, "| ID | `%s` |\n", g.ID)
+	if g.Version != "" {
+		fmt.Fprintf(&b, "| Version | %s |\n", markdownCell(g.Version))
+	}
+	fmt.Fprintf(&b, "| Entry point | %s |\n", codeOrDash(g.entryPoint))
+	fmt.Fprintf(&b, "| Nodes | %d |\n", len(g.nodes))
+	fmt.Fprintf(&b, "| Edges | %d |\n", len(edges))
+	fmt.Fprintf(&b, "| Timeout | %s |\n", formatDocDuration(g.Config.Timeout))
+	b.WriteString("\n")
+
+	if len(g.InputSchema) > 0 {
+		b.WriteString("## Inputs\n\n")
+		writeParameterTable(&b, g.InputSchema)
+	}
+
+	b.WriteString("## Diagram\n\n```mermaid\n")
+	b.WriteString(mermaidDiagram(g, edges))
+	b.WriteString("```\n\n")
+
+	b.WriteString("## Nodes\n\n")
+	for _, node := range sortedNodes(g.nodes) {
+		writeNodeDocs(&b, node)
+	}
+
+	b.WriteString("## Edges\n\n")
+	if len(edges) == 0 {
+		b.WriteString("_No edges._\n")
+		return b.String()
+	}
+	b.WriteString("| From | To | Type | Condition | Priority | Description |\n| --- | --- | --- | --- | --- | --- |\n")
+	for _, edge := range edges {
+		priority := "-"
+		if edge.Priority != 0 {
+			priority = fmt.Sprint(edge.Priority)
+		}
+		fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s | %s |\n",
+			edge.From, edge.To, edge.Type, markdownCell(edgeCondition(edge)), priority, markdownCell(edge.Description))
+	}
+	return b.String()
+}
+
+// writeNodeDocs renders the section of a single node.
+func writeNodeDocs(b *strings.Builder, node *Node) {
+	if node.Name != "" && node.Name != node.ID {
+		fmt.Fprintf(b, "### %s (`%s`)\n\n", node.Name, node.ID)
+	} else {
+		fmt.Fprintf(b, "### `%s`\n\n", node.ID)
+	}
+	if node.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", node.Description)
+	}
+
+	b.WriteString("| Property | Value |\n| --- | --- |\n")
+	fmt.Fprintf(b, "| Type | %s |\n", node.Type)
+	fmt.Fprintf(b, "| Timeout | %s |\n", formatDocDuration(node.Config.Timeout))
+	if node.Config.Retries > 0 {
+		fmt.Fprintf(b, "| Retries | %d (delay %s) |\n", node.Config.Retries, formatDocDuration(node.Config.RetryDelay))
+	}
+	if node.Config.FailureMode != "" {
+		fmt.Fprintf(b, "| Failure mode | %s |\n", node.Config.FailureMode)
+	}
+	if node.Version != "" {
+		fmt.Fprintf(b, "| Version | %s |\n", markdownCell(node.Version))
+	}
+	if len(node.Tags) > 0 {
+		fmt.Fprintf(b, "| Tags | %s |\n", markdownCell(strings.Join(node.Tags, ", ")))
+	}
+	b.WriteString("\n")
+
+	if len(node.Inputs) > 0 {
+		b.WriteString("**Inputs**\n\n")
+		writeParameterTable(b, node.Inputs)
+	}
+	if len(node.Outputs) > 0 {
+		b.WriteString("**Outputs**\n\n")
+		writeParameterTable(b, node.Outputs)
+	}
+}
+
+// writeParameterTable renders parameter definitions as a Markdown table.
+func writeParameterTable(b *strings.Builder, params []ParameterDef) {
+	b.WriteString("| Name | Type | Required | Default | Description |\n| --- | --- | --- | --- | --- |\n")
+	for _, param := range params {
+		required := "no"
+		if param.Required {
+			required = "yes"
+		}
+		def := "-"
+		if param.Default != nil {
+			def = fmt.Sprintf("`%v`", param.Default)
+		}
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s |\n",
+			param.Name, markdownCell(param.Type), required, def, markdownCell(param.Description))
+	}
+	b.WriteString("\n")
+}
+
+// mermaidDiagram renders the graph as a Mermaid flowchart.
+func mermaidDiagram(g *Graph, edges []*Edge) string {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	b.WriteString("    START([Start])\n")
+	for _, node := range sortedNodes(g.nodes) {
+		label := mermaidLabel(node.ID)
+		if node.Name != "" && node.Name != node.ID {
+			label = mermaidLabel(node.Name)
+		}
+		id := mermaidID(node.ID)
+		switch node.Type {
+		case NodeTypeCondition:
+			fmt.Fprintf(&b, "    %s{%s}\n", id, label)
+		case NodeTypeSubGraph:
+			fmt.Fprintf(&b, "    %s[[%s]]\n", id, label)
+		case NodeTypeParallel, NodeTypeLoop:
+			fmt.Fprintf(&b, "    %s[/%s/]\n", id, label)
+		case NodeTypeHumanInput:
+			fmt.Fprintf(&b, "    %s[/%s\\]\n", id, label)
+		default:
+			fmt.Fprintf(&b, "    %s[%s]\n", id, label)
+		}
+	}
+	b.WriteString("    END([End])\n")
+	if g.entryPoint != "" {
+		fmt.Fprintf(&b, "    START --> %s\n", mermaidID(g.entryPoint))
+	}
+
+	for _, edge := range edges {
+		arrow := "-->"
+		if isConditionalEdge(edge) {
+			arrow = "-.->"
+		}
+		label := edgeLabel(edge)
+		if label != "" {
+			fmt.Fprintf(&b, "    %s %s|%s| %s\n", mermaidID(edge.From), arrow, mermaidLabel(label), mermaidID(edge.To))
+		} else {
+			fmt.Fprintf(&b, "    %s %s %s\n", mermaidID(edge.From), arrow, mermaidID(edge.To))
+		}
+	}
+	return b.String()
+}
+
+// edgeRefs returns the edges in definition order without copying them.
+func (er *EdgeRouter) edgeRefs() []*Edge {
+	er.lock.RLock()
+	defer er.lock.RUnlock()
+	refs := make([]*Edge, len(er.edges))
+	for i := range er.edges {
+		refs[i] = &er.edges[i]
+	}
+	return refs
+}
+
+// edgeCondition describes the condition of an edge, if any.
+func edgeCondition(edge *Edge) string {
+	for _, key := range []string{"condition", "expression"} {
+		if value, ok := edge.Metadata[key]; ok {
+			return fmt.Sprint(value)
+		}
+	}
+	if edge.ConditionName != "" {
+		return edge.ConditionName
+	}
+	if edge.Expression != "" {
+		return edge.Expression
+	}
+	if edge.Condition != nil || edge.Type == EdgeTypeConditional {
+		if edge.Name != "" {
+			return edge.Name
+		}
+		return "conditional"
+	}
+	return ""
+}
+
+// sortedNodes returns the nodes ordered by ID.
+func sortedNodes(nodes map[string]*Node) []*Node {
+	result := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, node)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// mermaidID returns an identifier safe for Mermaid; START and END are reserved for the terminals.
+func mermaidID(id string) string {
+	if id == "END" {
+		return "END"
+	}
+	var b strings.Builder
+	b.WriteString("n_")
+	for _, r := range id {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			fmt.Fprintf(&b, "_%x", r)
+		}
+	}
+	return b.String()
+}
+
+// mermaidLabel quotes a label, escaping characters Mermaid would interpret.
+func mermaidLabel(label string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "|", "#124;", "\n", " ").Replace(label) + `"`
+}
+
+// markdownCell escapes a value for use inside a Markdown table cell.
+func markdownCell(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.NewReplacer("|", `\|`, "\n", "<br>").Replace(value)
+}
+
+// codeOrDash formats an identifier as code, or a dash when empty.
+func codeOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return "`" + value + "`"
+}
+
+// formatDocDuration formats a timeout for documentation.
+func formatDocDuration(d time.Duration) string {
+	if d <= 0 {
+		return "none"
+	}
+	return d.String()
+}
@@ -0,0 +1,234 @@
+// Package graph - Graceful draining
+// 包 graph - 优雅排空
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ================================
+// Draining 排空
+// ================================
+
+// ErrRunnableDraining is returned when invoking a runnable after Drain was called.
+// ErrRunnableDraining 表示在调用 Drain 之后调用可运行实例。
+var ErrRunnableDraining = errors.New("runnable is draining")
+
+// ErrExecutionDrained is returned by executions that Drain checkpointed and stopped.
+// ErrExecutionDrained 表示执行已被 Drain 保存检查点并停止。
+var ErrExecutionDrained = errors.New("execution was checkpointed and stopped by drain")
+
+// DrainResumeNodeKey is the metadata key under which a drained state records the node
+// to resume from; the node had not completed when the state was persisted.
+// DrainResumeNodeKey 是排空时保存的状态中记录恢复节点的元数据键，该节点在保存时尚未完成。
+const DrainResumeNodeKey = "drain_resume_node"
+
+// DrainReport describes the outcome of Drain.
+// DrainReport 描述 Drain 的结果。
+type DrainReport struct {
+	// InFlight is the number of executions running when Drain was called.
+	InFlight int `json:"in_flight"`
+
+	// Completed is the number of those executions that finished before the deadline.
+	Completed int `json:"completed"`
+
+	// Checkpointed lists the executions that were still running at the deadline.
+	Checkpointed []DrainedExecution `json:"checkpointed,omitempty"`
+}
+
+// DrainedExecution describes an execution stopped by Drain.
+// DrainedExecution 描述被 Drain 停止的执行。
+type DrainedExecution struct {
+	// ExecutionID is the ID of the stopped execution.
+	ExecutionID string `json:"execution_id"`
+
+	// StateID is the ID under which the state was saved.
+	StateID string `json:"state_id"`
+
+	// ResumeNode is the first node that has not completed.
+	ResumeNode string `json:"resume_node"`
+
+	// Steps is the number of nodes completed before the checkpoint.
+	Steps int `json:"steps"`
+
+	// Error is set when the state could not be persisted.
+	Error error `json:"-"`
+}
+
+// Persisted reports whether the state of the execution was saved.
+// Persisted 判断执行的状态是否已保存。
+func (d DrainedExecution) Persisted() bool {
+	return d.Error == nil
+}
+
+// Drain stops accepting new invocations and waits for in-flight executions until ctx is
+// done. Executions still running at that point are stopped, and the state they had after
+// their last completed node is saved through the graph's state manager, with the
+// node to resume from recorded under DrainResumeNodeKey. The interrupted node runs again on
+// resume, so nodes with side effects should be idempotent. The stopped invocations return
+// ErrExecutionDrained. Drain returns an error joining the failures to persist a state;
+// the runnable keeps rejecting invocations afterwards.
+// Drain 停止接受新的调用，并在 ctx 结束前等待进行中的执行完成。届时仍在运行的执行会被停止，
+// 其最后一个已完成节点之后的状态通过图的状态管理器保存，恢复节点记录在 DrainResumeNodeKey 下。
+// 恢复时被中断的节点会重新执行，有副作用的节点应当是幂等的。被停止的调用返回 ErrExecutionDrained。
+// 保存状态失败时 Drain 返回合并后的错误；此后可运行实例继续拒绝新的调用。
+func (r *Runnable) Drain(ctx context.Context) (*DrainReport, error) {
+	r.inflightLock.Lock()
+	r.draining = true
+	running := make([]*inflightExecution, 0, len(r.inflight))
+	for execution := range r.inflight {
+		running = append(running, execution)
+	}
+	r.inflightLock.Unlock()
+
+	report := &DrainReport{InFlight: len(running)}
+
+	done := make(chan struct{})
+	go func() {
+		r.inflightWait.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		report.Completed = len(running)
+		return report, nil
+	case <-ctx.Done():
+	}
+
+	// The deadline has passed; persisting must not be canceled with it
+	saveCtx := context.WithoutCancel(ctx)
+	var errs []error
+	for _, execution := range running {
+		snapshot, drained := execution.drain()
+		if !drained {
+			report.Completed++
+			continue
+		}
+
+		result := DrainedExecution{
+			ExecutionID: execution.execCtx.ExecutionID,
+			StateID:     snapshot.state.ID,
+			ResumeNode:  snapshot.nextNode,
+			Steps:       snapshot.steps,
+		}
+		if r.graph.stateManager == nil {
+			result.Error = errors.New("no state manager configured")
+		} else {
+			snapshot.state.SetMetadata(DrainResumeNodeKey, snapshot.nextNode)
+			result.Error = r.graph.stateManager.Save(saveCtx, snapshot.state)
+		}
+		if result.Error != nil {
+			errs = append(errs, fmt.Errorf("failed to persist execution %s: %w", result.ExecutionID, result.Error))
+		}
+		report.Checkpointed = append(report.Checkpointed, result)
+
+		// Stop the execution once its state is safe
+		execution.execCtx.Cancel()
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// inflightExecution tracks the last step boundary of a running execution for Drain.
+type inflightExecution struct {
+	execCtx *ExecutionContext
+
+	// snapshotState keeps a copy of the state at each boundary; nodes may mutate the live
+	// state in place. Only needed when a state manager can persist it.
+	snapshotState bool
+
+	lock     sync.Mutex
+	snapshot drainSnapshot
+	finished bool
+	drained  bool
+}
+
+// drainSnapshot is the state of an execution after its last completed node.
+type drainSnapshot struct {
+	state    *State
+	nextNode string
+	steps    int
+}
+
+// progress records that the execution reached a step boundary, or fails with
+// ErrExecutionDrained when Drain has taken the execution over.
+func (e *inflightExecution) progress(state *State, nextNode string, steps int) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.drained {
+		return ErrExecutionDrained
+	}
+	if nextNode == "END" || nextNode == "" {
+		e.finished = true
+		return nil
+	}
+	if e.snapshotState {
+		state = state.Clone()
+	}
+	e.snapshot = drainSnapshot{state: state, nextNode: nextNode, steps: steps}
+	return nil
+}
+
+// drain takes the execution over unless it already finished, returning its last snapshot.
+func (e *inflightExecution) drain() (drainSnapshot, bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.finished || e.snapshot.state == nil {
+		return drainSnapshot{}, false
+	}
+	e.drained = true
+	return e.snapshot, true
+}
+
+// isDrained reports whether Drain took the execution over.
+func (e *inflightExecution) isDrained() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.drained
+}
+
+// beginExecution registers an execution with the runnable, or fails while draining.
+func (r *Runnable) beginExecution(execCtx *ExecutionContext) (*inflightExecution, error) {
+	r.inflightLock.Lock()
+	defer r.inflightLock.Unlock()
+
+	if r.draining {
+		return nil, ErrRunnableDraining
+	}
+	if r.inflight == nil {
+		r.inflight = make(map[*inflightExecution]struct{})
+	}
+
+	execution := &inflightExecution{
+		execCtx:       execCtx,
+		snapshotState: r.graph.stateManager != nil,
+	}
+	r.inflight[execution] = struct{}{}
+	r.inflightWait.Add(1)
+	return execution, nil
+}
+
+// endExecution unregisters an execution.
+func (r *Runnable) endExecution(execution *inflightExecution) {
+	r.inflightLock.Lock()
+	delete(r.inflight, execution)
+	r.inflightLock.Unlock()
+	r.inflightWait.Done()
+}
+
+// progress records a step boundary of the execution for Drain and checkpointing.
+func (execCtx *ExecutionContext) progress(state *State, nextNode string) error {
+	if execCtx.checkpoint != nil {
+		execCtx.checkpoint.save(execCtx, state, nextNode)
+	}
+	if execCtx.inflight == nil {
+		return nil
+	}
+	return execCtx.inflight.progress(state, nextNode, execCtx.StepCount)
+}
@@ -4,7 +4,9 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -52,6 +54,13 @@ type Edge struct {
 	// Condition is the condition function for conditional edges.
 	Condition EdgeCondition `json:"-"`
 
+	// ConditionName refers to a condition registered with RegisterCondition; it is used when Condition is nil.
+	ConditionName string `json:"condition,omitempty"`
+
+	// Expression is a boolean expression over the state (see Expr and ExprVariables), used when
+	// Condition is nil and ConditionName is empty.
+	Expression string `json:"expression,omitempty"`
+
 	// Priority is the priority of this edge (higher values have higher priority).
 	Priority int `json:"priority,omitempty"`
 
@@ -70,10 +79,26 @@ type Edge struct {
 	// Enabled indicates if this edge is currently enabled.
 	Enabled bool `json:"enabled"`
 
+	// Mappings are data mappings applied to the state when this edge is traversed.
+	Mappings []DataMapping `json:"mappings,omitempty"`
+
 	// lock protects concurrent access to the edge.
 	lock sync.RWMutex
 }
 
+// DataMapping describes how state data is carried across an edge.
+// Paths are "vars.<key>" for variables and "meta.<key>" for metadata; a bare key means a variable.
+// An empty To drops the From key instead of copying it.
+// DataMapping 描述跨边传递状态数据的方式。
+// 路径格式为 "vars.<key>"（变量）或 "meta.<key>"（元数据），不带前缀时视为变量；To 为空时删除 From 对应的键。
+type DataMapping struct {
+	// From is the source path.
+	From string `json:"from"`
+
+	// To is the destination path; empty means drop.
+	To string `json:"to,omitempty"`
+}
+
 // EdgeCondition represents a condition function for conditional edges.
 // EdgeCondition 表示条件边的条件函数。
 type EdgeCondition func(ctx context.Context, state *State) (bool, error)
@@ -128,6 +153,25 @@ func (eb *EdgeBuilder) WithCondition(condition EdgeCondition) *EdgeBuilder {
 	return eb
 }
 
+// WithNamedCondition makes the edge conditional on the condition registered under name.
+// WithNamedCondition 使边以 name 注册的条件为条件。
+func (eb *EdgeBuilder) WithNamedCondition(name string) *EdgeBuilder {
+	eb.edge.ConditionName = name
+	eb.edge.Type = EdgeTypeConditional
+	return eb
+}
+
+// WithExpression makes the edge conditional on a boolean expression over the state, such as
+// "variables.category == 'math'". Invalid expressions are reported by Validate, and an
+// expression referring to a variable that is not set does not match.
+// WithExpression 使边以基于状态的布尔表达式为条件，例如 "variables.category == 'math'"。
+// 无效的表达式由 Validate 报告，引用未设置变量的表达式视为不满足。
+func (eb *EdgeBuilder) WithExpression(expression string) *EdgeBuilder {
+	eb.edge.Expression = expression
+	eb.edge.Type = EdgeTypeConditional
+	return eb
+}
+
 // WithPriority sets the priority of the edge.
 // WithPriority 设置边的优先级。
 func (eb *EdgeBuilder) WithPriority(priority int) *EdgeBuilder {
@@ -173,6 +217,22 @@ func (eb *EdgeBuilder) WithEnabled(enabled bool) *EdgeBuilder {
 	return eb
 }
 
+// WithMapping copies the value at from to to when the edge is traversed.
+// WithMapping 在遍历边时将 from 处的值复制到 to。
+func (eb *EdgeBuilder) WithMapping(from, to string) *EdgeBuilder {
+	eb.edge.Mappings = append(eb.edge.Mappings, DataMapping{From: from, To: to})
+	return eb
+}
+
+// WithDrop removes the given keys from the state when the edge is traversed.
+// WithDrop 在遍历边时从状态中删除给定的键。
+func (eb *EdgeBuilder) WithDrop(paths ...string) *EdgeBuilder {
+	for _, path := range paths {
+		eb.edge.Mappings = append(eb.edge.Mappings, DataMapping{From: path})
+	}
+	return eb
+}
+
 // AsDefault marks this edge as a default fallback edge.
 // AsDefault 将此边标记为默认回退边。
 func (eb *EdgeBuilder) AsDefault() *EdgeBuilder {
@@ -205,10 +265,29 @@ func (e *Edge) CanTraverse(ctx context.Context, state *State) (bool, error) {
 	case EdgeTypeNormal, EdgeTypePriority:
 		return true, nil
 	case EdgeTypeConditional:
-		if e.Condition == nil {
-			return false, fmt.Errorf("conditional edge %s has no condition function", e.ID)
+		if e.Condition != nil {
+			return e.Condition(ctx, state)
 		}
-		return e.Condition(ctx, state)
+		if e.ConditionName != "" {
+			condition, ok := LookupCondition(e.ConditionName)
+			if !ok {
+				return false, fmt.Errorf("edge %s references unknown condition %s", e.ID, e.ConditionName)
+			}
+			return condition(ctx, state)
+		}
+		if e.Expression != "" {
+			expr, err := compileCachedExpr(e.Expression)
+			if err != nil {
+				return false, fmt.Errorf("edge %s has an invalid expression: %w", e.ID, err)
+			}
+			matched, err := expr.EvalBool(ExprVariables(state))
+			if errors.Is(err, ErrUndefinedVariable) {
+				// Variables that are not set yet do not match
+				return false, nil
+			}
+			return matched, err
+		}
+		return false, fmt.Errorf("conditional edge %s has no condition function", e.ID)
 	case EdgeTypeDefault:
 		return true, nil
 	default:
@@ -316,9 +395,19 @@ func (er *EdgeRouter) GetEdgesTo(nodeID string) []Edge {
 // GetNextNode determines the next node to execute from a given node.
 // GetNextNode 确定从给定节点执行的下一个节点。
 func (er *EdgeRouter) GetNextNode(ctx context.Context, currentNodeID string, state *State) (string, error) {
+	edge, err := er.GetNextEdge(ctx, currentNodeID, state)
+	if err != nil {
+		return "", err
+	}
+	return edge.To, nil
+}
+
+// GetNextEdge determines the edge to traverse from a given node.
+// GetNextEdge 确定从给定节点要遍历的边。
+func (er *EdgeRouter) GetNextEdge(ctx context.Context, currentNodeID string, state *State) (*Edge, error) {
 	edges := er.GetEdgesFrom(currentNodeID)
 	if len(edges) == 0 {
-		return "", fmt.Errorf("no edges found from node %s", currentNodeID)
+		return nil, fmt.Errorf("no edges found from node %s", currentNodeID)
 	}
 
 	// Check if there's a specific next node set in metadata (for condition nodes)
@@ -329,10 +418,10 @@ func (er *EdgeRouter) GetNextNode(ctx context.Context, currentNodeID string, sta
 				if edge.To == nextNodeStr {
 					canTraverse, err := edge.CanTraverse(ctx, state)
 					if err != nil {
-						return "", err
+						return nil, err
 					}
 					if canTraverse {
-						return nextNodeStr, nil
+						return &edge, nil
 					}
 				}
 			}
@@ -351,7 +440,7 @@ func (er *EdgeRouter) GetNextNode(ctx context.Context, currentNodeID string, sta
 	for _, edge := range edges {
 		score, err := edge.GetScore(ctx, state)
 		if err != nil {
-			return "", fmt.Errorf("error scoring edge %s: %w", edge.ID, err)
+			return nil, fmt.Errorf("error scoring edge %s: %w", edge.ID, err)
 		}
 
 		if score > 0 {
@@ -369,13 +458,13 @@ func (er *EdgeRouter) GetNextNode(ctx context.Context, currentNodeID string, sta
 		if defaultEdge != nil {
 			canTraverse, err := defaultEdge.CanTraverse(ctx, state)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			if canTraverse {
-				return defaultEdge.To, nil
+				return defaultEdge, nil
 			}
 		}
-		return "", fmt.Errorf("no traversable edges found from node %s", currentNodeID)
+		return nil, fmt.Errorf("no traversable edges found from node %s", currentNodeID)
 	}
 
 	// Sort candidates by score (highest first)
@@ -388,7 +477,41 @@ func (er *EdgeRouter) GetNextNode(ctx context.Context, currentNodeID string, sta
 	}
 
 	// Return the highest scoring edge
-	return candidates[0].edge.To, nil
+	return &candidates[0].edge, nil
+}
+
+// ================================
+// Data Mapping 数据映射
+// ================================
+
+// ApplyMappings applies the edge's data mappings to the state in declaration order.
+// ApplyMappings 按声明顺序将边的数据映射应用到状态。
+func (e *Edge) ApplyMappings(state *State) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	for _, mapping := range e.Mappings {
+		fromStore, fromKey := resolveMappingPath(state, mapping.From)
+		if mapping.To == "" {
+			delete(fromStore, fromKey)
+			continue
+		}
+
+		value, exists := fromStore[fromKey]
+		if !exists {
+			continue
+		}
+		toStore, toKey := resolveMappingPath(state, mapping.To)
+		toStore[toKey] = value
+	}
+}
+
+// resolveMappingPath returns the state map and key addressed by a mapping path.
+func resolveMappingPath(state *State, path string) (map[string]interface{}, string) {
+	if key, ok := strings.CutPrefix(path, "meta."); ok {
+		return state.Metadata, key
+	}
+	return state.Variables, strings.TrimPrefix(path, "vars.")
 }
 
 // ================================
@@ -408,7 +531,23 @@ func (e *Edge) Validate() error {
 		return fmt.Errorf("edge %s must have a destination node", e.ID)
 	}
 	if e.Type == EdgeTypeConditional && e.Condition == nil {
-		return fmt.Errorf("conditional edge %s must have a condition function", e.ID)
+		switch {
+		case e.ConditionName != "":
+			if _, ok := LookupCondition(e.ConditionName); !ok {
+				return fmt.Errorf("edge %s references unknown condition %s", e.ID, e.ConditionName)
+			}
+		case e.Expression != "":
+			if _, err := compileCachedExpr(e.Expression); err != nil {
+				return fmt.Errorf("edge %s has an invalid expression: %w", e.ID, err)
+			}
+		default:
+			return fmt.Errorf("conditional edge %s must have a condition function", e.ID)
+		}
+	}
+	for _, mapping := range e.Mappings {
+		if mapping.From == "" {
+			return fmt.Errorf("edge %s has a data mapping without a source", e.ID)
+		}
 	}
 	return nil
 }
@@ -435,18 +574,20 @@ func (e *Edge) Clone() *Edge {
 	defer e.lock.RUnlock()
 
 	clone := &Edge{
-		ID:          e.ID,
-		Name:        e.Name,
-		Type:        e.Type,
-		From:        e.From,
-		To:          e.To,
-		Condition:   e.Condition,
-		Priority:    e.Priority,
-		Weight:      e.Weight,
-		Metadata:    make(map[string]interface{}),
-		Description: e.Description,
-		Tags:        make([]string, len(e.Tags)),
-		Enabled:     e.Enabled,
+		ID:            e.ID,
+		Name:          e.Name,
+		Type:          e.Type,
+		From:          e.From,
+		To:            e.To,
+		Condition:     e.Condition,
+		ConditionName: e.ConditionName,
+		Expression:    e.Expression,
+		Priority:      e.Priority,
+		Weight:        e.Weight,
+		Metadata:      make(map[string]interface{}),
+		Description:   e.Description,
+		Tags:          make([]string, len(e.Tags)),
+		Enabled:       e.Enabled,
 	}
 
 	// Deep copy metadata
@@ -456,6 +597,11 @@ func (e *Edge) Clone() *Edge {
 
 	copy(clone.Tags, e.Tags)
 
+	if len(e.Mappings) > 0 {
+		clone.Mappings = make([]DataMapping, len(e.Mappings))
+		copy(clone.Mappings, e.Mappings)
+	}
+
 	return clone
 }
 
@@ -533,4 +679,43 @@ func NeverEdge(id, from, to string) *Edge {
 			return false, nil
 		}).
 		Build()
-}
\ No newline at end of file
+}
+
+// IfBudgetAbove returns a condition that holds while more than tokens remain in the
+// budget published by BudgetMiddleware. It also holds when no token budget applies.
+// IfBudgetAbove 返回一个条件：当 BudgetMiddleware 发布的剩余token多于 tokens 时成立，
+// 未设置token预算时同样成立。
+func IfBudgetAbove(tokens int) EdgeCondition {
+	return func(ctx context.Context, state *State) (bool, error) {
+		budget, ok := RemainingBudget(state)
+		if !ok || budget.RemainingTokens() < 0 {
+			return true, nil
+		}
+		return budget.RemainingTokens() > tokens, nil
+	}
+}
+
+// IfBudgetBelow returns a condition that holds when at most tokens remain in the budget.
+// It is the complement of IfBudgetAbove and is typically used for the cheaper branch.
+// IfBudgetBelow 返回一个条件：剩余token不超过 tokens 时成立，
+// 与 IfBudgetAbove 互补，通常用于更便宜的分支。
+func IfBudgetBelow(tokens int) EdgeCondition {
+	above := IfBudgetAbove(tokens)
+	return func(ctx context.Context, state *State) (bool, error) {
+		ok, err := above(ctx, state)
+		return !ok, err
+	}
+}
+
+// IfCostBudgetAbove returns a condition that holds while more than cost remains in the budget.
+// It also holds when no cost budget applies.
+// IfCostBudgetAbove 返回一个条件：剩余成本多于 cost 时成立，未设置成本预算时同样成立。
+func IfCostBudgetAbove(cost float64) EdgeCondition {
+	return func(ctx context.Context, state *State) (bool, error) {
+		budget, ok := RemainingBudget(state)
+		if !ok || budget.RemainingCost() < 0 {
+			return true, nil
+		}
+		return budget.RemainingCost() > cost, nil
+	}
+}
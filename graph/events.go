@@ -0,0 +1,514 @@
+// Package graph - Event bus between concurrent branches
+// 包 graph - 并发分支间的事件总线
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ================================
+// Events 事件
+// ================================
+
+// AllTopics subscribes to every topic.
+// AllTopics 订阅所有主题。
+const AllTopics = "*"
+
+// Event is a message published on an EventBus.
+// Event 是发布到 EventBus 上的消息。
+type Event struct {
+	// Seq is the position of the event in the execution's event log. It is assigned when the
+	// event is committed: immediately outside branches, at the join point for branch events.
+	Seq int `json:"seq"`
+
+	// Topic is the topic the event was published on.
+	Topic string `json:"topic"`
+
+	// Payload is the event data.
+	Payload interface{} `json:"payload,omitempty"`
+
+	// NodeID is the node that published the event.
+	NodeID string `json:"node_id,omitempty"`
+
+	// Branch is the path of the branch that published the event, e.g. "search/web".
+	Branch string `json:"branch,omitempty"`
+
+	// Timestamp is when the event was published.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventBus lets the concurrent branches of one execution coordinate, e.g. to share discovered
+// facts or to stop searching once an answer is found. Events are delivered to subscribers as
+// they are published; branch events are committed to the log at the join point in branch
+// order, so the log and the trace do not depend on scheduling.
+// EventBus 让同一次执行中的并发分支相互协调，例如共享发现的事实，或在找到答案后停止搜索。
+// 事件发布后立即投递给订阅者；分支中的事件在汇合点按分支顺序提交到日志，
+// 因此日志和追踪不受调度顺序影响。
+type EventBus struct {
+	subscribers map[*Subscription]struct{}
+	log         []Event
+	traced      int
+	closed      bool
+	lock        sync.Mutex
+}
+
+// NewEventBus creates an empty event bus.
+// NewEventBus 创建一个空的事件总线。
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Publish publishes payload on topic. Publishing on a nil bus is a no-op.
+// Publish 在 topic 上发布 payload。在 nil 总线上发布不做任何处理。
+func (b *EventBus) Publish(ctx context.Context, topic string, payload interface{}) {
+	if b == nil {
+		return
+	}
+
+	event := Event{
+		Topic:     topic,
+		Payload:   payload,
+		NodeID:    NodeIDFromContext(ctx),
+		Timestamp: time.Now(),
+	}
+	scope := branchFromContext(ctx)
+	if scope != nil {
+		event.Branch = scope.path
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if scope == nil {
+		event = b.commitLocked(event)[0]
+	}
+	for sub := range b.subscribers {
+		if sub.topic == AllTopics || sub.topic == topic {
+			sub.deliver(event)
+		}
+	}
+
+	// Branch events wait for the join point to be committed
+	if scope != nil {
+		scope.record(event)
+	}
+}
+
+// Subscribe receives the events published on topic from now on; use AllTopics for every topic.
+// Subscriptions made inside a branch are closed once no sibling branch is left to publish,
+// the others when the execution ends or Close is called.
+// Subscribe 接收此后在 topic 上发布的事件，AllTopics 表示所有主题。在分支内创建的订阅
+// 在没有其他兄弟分支可以发布时关闭，其余订阅在执行结束或调用 Close 时关闭。
+func (b *EventBus) Subscribe(ctx context.Context, topic string) *Subscription {
+	sub := &Subscription{topic: topic, bus: b, notify: make(chan struct{}, 1)}
+	if b == nil {
+		sub.closed = true
+		return sub
+	}
+
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		sub.closed = true
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	b.lock.Unlock()
+
+	if scope := branchFromContext(ctx); scope != nil {
+		scope.fork.own(sub)
+	}
+	return sub
+}
+
+// History returns the committed events in log order.
+// History 按日志顺序返回已提交的事件。
+func (b *EventBus) History() []Event {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]Event(nil), b.log...)
+}
+
+// commitLocked appends events to the log and assigns their sequence numbers.
+func (b *EventBus) commitLocked(events ...Event) []Event {
+	for i := range events {
+		events[i].Seq = len(b.log) + 1
+		b.log = append(b.log, events[i])
+	}
+	return events
+}
+
+// commit appends events to the log and returns them with their sequence numbers.
+func (b *EventBus) commit(events []Event) []Event {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.commitLocked(events...)
+}
+
+// untraced returns the committed events not yet recorded in the trace.
+func (b *EventBus) untraced() []Event {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	events := b.log[b.traced:]
+	b.traced = len(b.log)
+	return events
+}
+
+// unsubscribe removes sub from the bus.
+func (b *EventBus) unsubscribe(sub *Subscription) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.subscribers, sub)
+}
+
+// close ends every subscription; events already delivered can still be read.
+func (b *EventBus) close() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	subs := make([]*Subscription, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		subs = append(subs, sub)
+	}
+	b.subscribers = make(map[*Subscription]struct{})
+	b.closed = true
+	b.lock.Unlock()
+
+	for _, sub := range subs {
+		sub.finish()
+	}
+}
+
+// Subscription receives the events of one topic.
+// Subscription 接收一个主题的事件。
+type Subscription struct {
+	topic  string
+	bus    *EventBus
+	queue  []Event
+	notify chan struct{}
+	closed bool
+	lock   sync.Mutex
+}
+
+// Next blocks until an event arrives. It returns false once the subscription is closed and
+// drained, or when ctx is done.
+// Next 阻塞直到收到事件。订阅关闭且事件读完后，或 ctx 结束时返回 false。
+func (s *Subscription) Next(ctx context.Context) (Event, bool) {
+	for {
+		if event, ok, closed := s.pop(); ok || closed {
+			return event, ok
+		}
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return Event{}, false
+		}
+	}
+}
+
+// TryNext returns the next pending event without blocking.
+// TryNext 不阻塞地返回下一个待处理事件。
+func (s *Subscription) TryNext() (Event, bool) {
+	event, ok, _ := s.pop()
+	return event, ok
+}
+
+// Close stops the subscription and discards pending events.
+// Close 停止订阅并丢弃待处理的事件。
+func (s *Subscription) Close() {
+	s.finish()
+	s.lock.Lock()
+	s.queue = nil
+	s.lock.Unlock()
+}
+
+// pop removes the next event, reporting whether the subscription is closed and empty.
+func (s *Subscription) pop() (Event, bool, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.queue) > 0 {
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		return event, true, false
+	}
+	return Event{}, false, s.closed
+}
+
+// deliver queues an event without blocking the publisher.
+func (s *Subscription) deliver(event Event) {
+	s.lock.Lock()
+	if !s.closed {
+		s.queue = append(s.queue, event)
+	}
+	s.lock.Unlock()
+	s.wake()
+}
+
+// finish closes the subscription, keeping pending events readable.
+func (s *Subscription) finish() {
+	s.bus.unsubscribe(s)
+	s.lock.Lock()
+	s.closed = true
+	s.lock.Unlock()
+	s.wake()
+}
+
+// wake signals a waiting Next.
+func (s *Subscription) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Topic is a typed event topic.
+// Topic 是带类型的事件主题。
+//
+//	var Answer = graph.Topic[string]("answer")
+//	Answer.Publish(ctx, state.Events, "42")
+type Topic[T any] string
+
+// Publish publishes payload on the topic.
+// Publish 在该主题上发布 payload。
+func (t Topic[T]) Publish(ctx context.Context, bus *EventBus, payload T) {
+	bus.Publish(ctx, string(t), payload)
+}
+
+// Subscribe subscribes to the topic.
+// Subscribe 订阅该主题。
+func (t Topic[T]) Subscribe(ctx context.Context, bus *EventBus) *TypedSubscription[T] {
+	return &TypedSubscription[T]{Subscription: bus.Subscribe(ctx, string(t))}
+}
+
+// Payloads returns the payloads of the events published on the topic, in order.
+// Payloads 按顺序返回在该主题上发布的事件的 payload。
+func (t Topic[T]) Payloads(events []Event) []T {
+	var payloads []T
+	for _, event := range events {
+		if payload, ok := event.Payload.(T); ok && event.Topic == string(t) {
+			payloads = append(payloads, payload)
+		}
+	}
+	return payloads
+}
+
+// TypedSubscription receives the payloads of a typed topic.
+// TypedSubscription 接收带类型主题的 payload。
+type TypedSubscription[T any] struct {
+	*Subscription
+}
+
+// Next blocks until a payload arrives, skipping payloads of other types.
+// Next 阻塞直到收到 payload，跳过其他类型的 payload。
+func (s *TypedSubscription[T]) Next(ctx context.Context) (T, bool) {
+	for {
+		event, ok := s.Subscription.Next(ctx)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		if payload, ok := event.Payload.(T); ok {
+			return payload, true
+		}
+	}
+}
+
+// ================================
+// Branches 分支
+// ================================
+
+// Branch is a function run concurrently with its siblings by RunBranches.
+// Branch 是由 RunBranches 与兄弟分支并发执行的函数。
+type Branch struct {
+	// Name identifies the branch in events and errors.
+	Name string
+
+	// Function runs the branch on its own clone of the state.
+	Function NodeFunction
+}
+
+// NewBranch creates a branch.
+// NewBranch 创建一个分支。
+func NewBranch(name string, fn NodeFunction) Branch {
+	return Branch{Name: name, Function: fn}
+}
+
+// branchContextKey is the context key for the branch running in a context.
+type branchContextKey struct{}
+
+// branchScope is one running branch of a fork.
+type branchScope struct {
+	fork   *branchFork
+	index  int
+	path   string
+	events []Event
+}
+
+// record keeps events published by the branch until the join point.
+func (s *branchScope) record(events ...Event) {
+	s.fork.lock.Lock()
+	defer s.fork.lock.Unlock()
+	s.events = append(s.events, events...)
+}
+
+// branchFork tracks the branches started by one RunBranches call.
+type branchFork struct {
+	branches      []*branchScope
+	cancels       []context.CancelFunc
+	finished      []bool
+	stopped       []bool
+	running       int
+	subscriptions []*Subscription
+	quiet         bool
+	lock          sync.Mutex
+}
+
+// own closes sub together with the other subscriptions of the fork.
+func (f *branchFork) own(sub *Subscription) {
+	f.lock.Lock()
+	quiet := f.quiet
+	if !quiet {
+		f.subscriptions = append(f.subscriptions, sub)
+	}
+	f.lock.Unlock()
+
+	if quiet {
+		sub.finish()
+	}
+}
+
+// done marks a branch finished. Once at most one branch is left, nothing else can publish to
+// it, so the subscriptions of the fork are closed to let blocked readers drain and return.
+func (f *branchFork) done(index int) {
+	f.lock.Lock()
+	f.finished[index] = true
+	f.running--
+	var subs []*Subscription
+	if f.running <= 1 && !f.quiet {
+		f.quiet = true
+		subs = f.subscriptions
+		f.subscriptions = nil
+	}
+	f.lock.Unlock()
+
+	for _, sub := range subs {
+		sub.finish()
+	}
+}
+
+// branchFromContext returns the branch running in ctx.
+func branchFromContext(ctx context.Context) *branchScope {
+	scope, _ := ctx.Value(branchContextKey{}).(*branchScope)
+	return scope
+}
+
+// RunBranches runs branches concurrently, each on its own clone of state sharing state.Events,
+// and waits for all of them. It returns the branch states in branch order and the events
+// published by the branches, committed in branch order so the result is deterministic.
+// Branches stopped by CancelSiblings are not reported as failures.
+// RunBranches 并发执行各分支，每个分支使用各自的状态副本并共享 state.Events，等待全部完成后
+// 按分支顺序返回分支状态，以及按分支顺序提交的分支事件，结果与调度顺序无关。
+// 被 CancelSiblings 停止的分支不会作为失败返回。
+func RunBranches(ctx context.Context, state *State, branches ...Branch) ([]*State, []Event, error) {
+	if len(branches) == 0 {
+		return nil, nil, nil
+	}
+	if state.Events == nil {
+		state.Events = NewEventBus()
+	}
+
+	parent := branchFromContext(ctx)
+	fork := &branchFork{
+		branches: make([]*branchScope, len(branches)),
+		cancels:  make([]context.CancelFunc, len(branches)),
+		finished: make([]bool, len(branches)),
+		stopped:  make([]bool, len(branches)),
+		running:  len(branches),
+	}
+
+	// Prepare every context before starting, so CancelSiblings sees all branches
+	contexts := make([]context.Context, len(branches))
+	for i, branch := range branches {
+		if branch.Function == nil {
+			return nil, nil, fmt.Errorf("branch %s has no function", branch.Name)
+		}
+		path := branch.Name
+		if parent != nil {
+			path = parent.path + "/" + branch.Name
+		}
+		fork.branches[i] = &branchScope{fork: fork, index: i, path: path}
+		contexts[i], fork.cancels[i] = context.WithCancel(context.WithValue(ctx, branchContextKey{}, fork.branches[i]))
+	}
+
+	results := make([]*State, len(branches))
+	errs := make([]error, len(branches))
+	var wg sync.WaitGroup
+	for i, branch := range branches {
+		wg.Add(1)
+		go func(i int, branch Branch) {
+			defer wg.Done()
+			defer fork.done(i)
+			results[i], errs[i] = branch.Function(contexts[i], state.Clone())
+		}(i, branch)
+	}
+	wg.Wait()
+	for _, cancel := range fork.cancels {
+		cancel()
+	}
+
+	// Commit in branch order; nested forks hand their events to the enclosing branch
+	var events []Event
+	for _, scope := range fork.branches {
+		events = append(events, scope.events...)
+	}
+	if parent != nil {
+		parent.record(events...)
+	} else {
+		events = state.Events.commit(events)
+	}
+
+	var failures []error
+	for i, err := range errs {
+		if err == nil || (fork.stopped[i] && errors.Is(err, context.Canceled)) {
+			continue
+		}
+		failures = append(failures, fmt.Errorf("branch %s: %w", branches[i].Name, err))
+	}
+	return results, events, errors.Join(failures...)
+}
+
+// CancelSiblings cancels the other running branches of the branch in ctx, e.g. once it found
+// an answer. It reports whether ctx belongs to a branch.
+// CancelSiblings 取消 ctx 所属分支的其他运行中兄弟分支，例如在找到答案之后。
+// 返回 ctx 是否属于某个分支。
+func CancelSiblings(ctx context.Context) bool {
+	scope := branchFromContext(ctx)
+	if scope == nil {
+		return false
+	}
+
+	fork := scope.fork
+	fork.lock.Lock()
+	defer fork.lock.Unlock()
+	for i, cancel := range fork.cancels {
+		if i != scope.index && !fork.finished[i] {
+			fork.stopped[i] = true
+			cancel()
+		}
+	}
+	return true
+}
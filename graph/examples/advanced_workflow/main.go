@@ -209,11 +209,12 @@ func createAdvancedWorkflowGraph() *graph.Graph {
 			userMessage := state.Messages[len(state.Messages)-1]
 			userText := getTextFromMessage(userMessage)
 			
-			// Simple math parsing (for demo purposes)
-			// 简单数学解析（演示目的）
+			// Evaluate the expression deterministically instead of asking the LLM
+			// 使用确定性的表达式求值，而不是让LLM计算
+			expression := strings.TrimSpace(strings.TrimPrefix(userText, "Calculate"))
 			var response string
-			if strings.Contains(userText, "15 + 27") || strings.Contains(userText, "15+27") {
-				response = "15 + 27 = 42"
+			if result, err := graph.EvalExpr(expression, state.Variables); err == nil {
+				response = fmt.Sprintf("%s = %v", expression, result)
 			} else {
 				response = "I can help with basic math operations. Please provide a clear mathematical expression."
 			}
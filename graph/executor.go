@@ -4,9 +4,13 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ================================
@@ -19,9 +23,51 @@ type Runnable struct {
 	// graph is the underlying graph instance.
 	graph *Graph
 
+	// plan is the static execution plan of a linear graph, set by CompileOptimized.
+	plan *staticPlan
+
 	// executionStats tracks execution statistics.
 	executionStats *ExecutionStats
 
+	// sla evaluates the SLA of the graph over a sliding window.
+	sla *slaTracker
+
+	// webhooks receive a summary of every finished execution.
+	webhooks resultWebhooks
+
+	// traces retains the traces of recent executions.
+	traces *traceBuffer
+
+	// resources holds the shared resources registered by setup nodes.
+	resources *Resources
+
+	// ready is set once the setup nodes have run and the runnable is not closed.
+	ready atomic.Bool
+
+	// closed is set by Close.
+	closed bool
+
+	// setupLock serializes Setup and Close.
+	setupLock sync.Mutex
+
+	// nodeSlots holds the semaphores of nodes with MaxConcurrent set, shared by all executions.
+	nodeSlots map[string]chan struct{}
+
+	// nodeSlotsLock protects nodeSlots.
+	nodeSlotsLock sync.Mutex
+
+	// inflight tracks the running executions for Drain.
+	inflight map[*inflightExecution]struct{}
+
+	// inflightWait counts the running executions.
+	inflightWait sync.WaitGroup
+
+	// draining is set by Drain; new invocations are rejected.
+	draining bool
+
+	// inflightLock protects inflight and draining.
+	inflightLock sync.Mutex
+
 	// lock protects concurrent access.
 	lock sync.RWMutex
 }
@@ -50,6 +96,15 @@ type ExecutionStats struct {
 	// NodeExecutionTime tracks total execution time for each node.
 	NodeExecutionTime map[string]time.Duration `json:"node_execution_time"`
 
+	// CanceledExecutions is the number of executions whose context was cancelled or timed out.
+	CanceledExecutions int64 `json:"canceled_executions"`
+
+	// WastedAfterCancel is the total time nodes kept running after their execution was cancelled.
+	WastedAfterCancel time.Duration `json:"wasted_after_cancel"`
+
+	// SLA reports the SLA values over the current window, when the graph declares an SLA.
+	SLA *SLAStatus `json:"sla,omitempty"`
+
 	// lock protects concurrent access to stats.
 	lock sync.RWMutex
 }
@@ -83,6 +138,39 @@ type ExecutionContext struct {
 
 	// Trace contains execution trace information.
 	Trace []TraceEntry
+
+	// Path is the ordered list of executed node IDs.
+	Path []string
+
+	// NodeDurations tracks the time spent in each node during this execution.
+	NodeDurations map[string]time.Duration
+
+	// Warnings contains non-fatal issues encountered during this execution.
+	Warnings []string
+
+	// WastedAfterCancel is the time nodes kept running after the execution was cancelled.
+	WastedAfterCancel time.Duration
+
+	// Locale selects the language of errors returned by this execution.
+	Locale Locale
+
+	// usage collects token and cost usage reported by nodes.
+	usage *usageCollector
+
+	// inflight tracks the step boundaries of the execution for Drain.
+	inflight *inflightExecution
+
+	// startNode is the node the execution starts from instead of the entry point.
+	startNode string
+
+	// checkpoint saves the state at step boundaries for ResumeFromCheckpoint.
+	checkpoint *checkpointing
+
+	// cancellation records when the execution was cancelled.
+	cancellation *cancellationWatch
+
+	// tracer emits the OpenTelemetry spans of the nodes; nil when the execution is not traced.
+	tracer trace.Tracer
 }
 
 // TraceEntry represents a single trace entry.
@@ -157,6 +245,60 @@ func (r *Runnable) Invoke(ctx context.Context, state *State) (*State, error) {
 // InvokeWithOptions executes the graph with the given state and options.
 // InvokeWithOptions 使用给定的状态和选项执行图。
 func (r *Runnable) InvokeWithOptions(ctx context.Context, state *State, options ...ExecutionOption) (*State, error) {
+	result, _, err := r.invoke(ctx, state, options...)
+	return result, err
+}
+
+// InvokeDetailed executes the graph and returns a structured result with the
+// final state, trace, taken path, per-node durations, usage and warnings.
+// Tracing is enabled by default; pass WithTracing(false) to disable it.
+// InvokeDetailed 执行图并返回结构化结果，包含最终状态、追踪、执行路径、
+// 各节点耗时、用量和警告。默认启用追踪，可通过 WithTracing(false) 关闭。
+func (r *Runnable) InvokeDetailed(ctx context.Context, state *State, options ...ExecutionOption) (*Result, error) {
+	opts := append([]ExecutionOption{WithTracing(true)}, options...)
+	finalState, execCtx, err := r.invoke(ctx, state, opts...)
+
+	result := &Result{
+		State:             finalState,
+		Success:           err == nil,
+		Error:             err,
+		Duration:          time.Since(execCtx.StartTime),
+		NodesExecuted:     execCtx.StepCount,
+		ExecutionID:       execCtx.ExecutionID,
+		Path:              execCtx.Path,
+		NodeDurations:     execCtx.NodeDurations,
+		Trace:             execCtx.Trace,
+		Warnings:          execCtx.Warnings,
+		WastedAfterCancel: execCtx.WastedAfterCancel,
+	}
+	result.Usage, result.NodeUsage = execCtx.usage.snapshot()
+	result.Pending, _ = PendingInput(err)
+
+	// Surface graph validation warnings alongside runtime warnings
+	for _, warning := range r.graph.Validate().Warnings {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("validation: [%s] %s", warning.Code, warning.Message))
+	}
+
+	return result, err
+}
+
+// InvokeFrom executes the graph starting from the given node instead of the entry point,
+// so that the failed tail of a workflow can be re-run after its data was fixed. The node
+// must exist and the state must carry the node's required inputs; the graph's input schema
+// is not checked, since the state is expected to come from an earlier execution.
+// InvokeFrom 从指定节点而不是入口点开始执行图，便于在修正数据后只重新执行工作流失败的后半部分。
+// 节点必须存在，且状态中必须包含该节点的必填输入；状态通常来自之前的执行，因此不校验图的输入参数定义。
+func (r *Runnable) InvokeFrom(ctx context.Context, state *State, nodeID string, options ...ExecutionOption) (*State, error) {
+	opts := append(options[:len(options):len(options)], func(execCtx *ExecutionContext) {
+		execCtx.startNode = nodeID
+	})
+	result, _, err := r.invoke(ctx, state, opts...)
+	return result, err
+}
+
+// invoke runs the graph and returns the final state together with its execution context.
+// invoke 执行图，并返回最终状态及其执行上下文。
+func (r *Runnable) invoke(ctx context.Context, state *State, options ...ExecutionOption) (*State, *ExecutionContext, error) {
 	// Create execution context
 	execCtx := &ExecutionContext{
 		ExecutionID:   fmt.Sprintf("exec_%d", time.Now().UnixNano()),
@@ -167,6 +309,11 @@ func (r *Runnable) InvokeWithOptions(ctx context.Context, state *State, options
 		Context:       ctx,
 		StepCount:     0,
 		Trace:         make([]TraceEntry, 0),
+		Path:          make([]string, 0),
+		NodeDurations: make(map[string]time.Duration),
+		Warnings:      make([]string, 0),
+		Locale:        r.graph.Config.Locale,
+		usage:         newUsageCollector(),
 	}
 
 	// Apply options
@@ -174,43 +321,128 @@ func (r *Runnable) InvokeWithOptions(ctx context.Context, state *State, options
 		option(execCtx)
 	}
 
+	// Reject new work once the runnable is draining
+	r.inflightLock.Lock()
+	draining := r.draining
+	r.inflightLock.Unlock()
+	if draining {
+		return state, execCtx, ErrRunnableDraining
+	}
+
+	// Run the setup nodes on first use
+	if !r.ready.Load() {
+		if err := r.Setup(ctx); err != nil {
+			return state, execCtx, err
+		}
+	}
+
 	// Create context with timeout
+	runCtx := context.WithValue(ctx, usageCollectorContextKey{}, execCtx.usage)
+	runCtx = context.WithValue(runCtx, resourcesContextKey{}, r.resources)
+	runCtx = context.WithValue(runCtx, parallelLimitContextKey{}, r.graph.Config.MaxConcurrency)
 	if execCtx.Timeout > 0 {
-		execCtx.Context, execCtx.Cancel = context.WithTimeout(ctx, execCtx.Timeout)
+		execCtx.Context, execCtx.Cancel = context.WithTimeout(runCtx, execCtx.Timeout)
 	} else {
-		execCtx.Context, execCtx.Cancel = context.WithCancel(ctx)
+		execCtx.Context, execCtx.Cancel = context.WithCancel(runCtx)
 	}
 	defer execCtx.Cancel()
+	stopWatch := execCtx.watchCancellation()
+	defer stopWatch()
+
+	// Track the execution so that Drain can wait for or checkpoint it
+	inflight, err := r.beginExecution(execCtx)
+	if err != nil {
+		return state, execCtx, err
+	}
+	defer r.endExecution(inflight)
+	execCtx.inflight = inflight
+
+	// Reject inputs that do not match the declared schema before running any node
+	if err := r.validateStart(execCtx, state); err != nil {
+		return state, execCtx, err
+	}
 
 	// Record execution start
 	r.recordExecutionStart(execCtx)
 
 	// Execute the graph
+	var span trace.Span
+	execCtx.Context, span = r.startExecutionSpan(execCtx)
 	result, err := r.executeGraph(execCtx, state)
+	stopWatch()
+
+	// Executions taken over by Drain report it whatever the interrupted node returned
+	if inflight.isDrained() && !errors.Is(err, ErrExecutionDrained) {
+		result = nil
+		err = errors.Join(ErrExecutionDrained, err)
+	}
 
 	// Record execution end
 	r.recordExecutionEnd(execCtx, err)
+	endExecutionSpan(execCtx, span, err)
 
-	return result, err
+	// Failed executions report the state they were started with
+	if result != nil {
+		r.recordTrace(execCtx, result, err)
+		r.notifyResultWebhooks(execCtx, result, err)
+	} else {
+		r.recordTrace(execCtx, state, err)
+		r.notifyResultWebhooks(execCtx, state, err)
+	}
+
+	return result, execCtx, err
 }
 
-// executeGraph executes the graph starting from the entry point.
-// executeGraph 从入口点开始执行图。
+// validateStart checks the state against the graph's input schema, or against the inputs
+// of the start node when the execution does not start from the entry point.
+// validateStart 按图的输入参数定义校验状态；从指定节点开始执行时改为校验该节点的输入。
+func (r *Runnable) validateStart(execCtx *ExecutionContext, state *State) error {
+	if execCtx.startNode == "" {
+		return r.graph.validateInput(state, execCtx.Locale)
+	}
+
+	node, exists := r.graph.GetNode(execCtx.startNode)
+	if !exists {
+		return newGraphError(execCtx.Locale, ErrCodeNodeNotFound, execCtx.startNode, nil,
+			fmt.Sprintf("start node %s not found", execCtx.startNode),
+			fmt.Sprintf("起始节点 %s 不存在", execCtx.startNode))
+	}
+	if state == nil {
+		return newGraphError(execCtx.Locale, ErrCodeInvalidInput, node.ID, nil,
+			fmt.Sprintf("%s for node %s: state is nil", ErrInvalidInput, node.ID),
+			fmt.Sprintf("节点 %s 的输入无效: 状态为空", node.ID))
+	}
+	return r.graph.validateNodeInput(node, state, execCtx.Locale)
+}
+
+// executeGraph executes the graph starting from the entry point, or from the start node.
+// executeGraph 从入口点或指定的起始节点开始执行图。
 func (r *Runnable) executeGraph(execCtx *ExecutionContext, state *State) (*State, error) {
+	// Work on a copy with an event bus of its own, closed when the execution ends
+	state = state.Clone()
+	state.Events = NewEventBus()
+	defer state.Events.close()
+
 	currentNodeID := r.graph.entryPoint
-	currentState := state.Clone()
+	if execCtx.startNode != "" {
+		currentNodeID = execCtx.startNode
+	}
 
-	for {
-		// Check context cancellation
-		select {
-		case <-execCtx.Context.Done():
-			return nil, execCtx.Context.Err()
-		default:
+	if r.plan != nil {
+		if steps := r.plan.from(currentNodeID); steps != nil {
+			return r.executePlan(execCtx, steps, state)
 		}
+	}
+
+	currentState := state
+	if err := execCtx.progress(currentState, currentNodeID); err != nil {
+		return nil, err
+	}
 
-		// Check max steps
-		if execCtx.MaxSteps > 0 && execCtx.StepCount >= execCtx.MaxSteps {
-			return nil, fmt.Errorf("maximum execution steps (%d) exceeded", execCtx.MaxSteps)
+	for {
+		// Check context cancellation and max steps
+		if err := r.checkContinue(execCtx); err != nil {
+			return nil, err
 		}
 
 		// Check if we've reached the end
@@ -221,76 +453,235 @@ func (r *Runnable) executeGraph(execCtx *ExecutionContext, state *State) (*State
 		// Get the current node
 		node, exists := r.graph.GetNode(currentNodeID)
 		if !exists {
-			return nil, fmt.Errorf("node %s not found", currentNodeID)
+			return nil, newGraphError(execCtx.Locale, ErrCodeNodeNotFound, currentNodeID, nil,
+				fmt.Sprintf("node %s not found", currentNodeID),
+				fmt.Sprintf("节点 %s 不存在", currentNodeID))
 		}
 
-		// Trace node execution start
-		if execCtx.EnableTracing {
-			r.addTraceEntry(execCtx, currentNodeID, "node_start", "Starting node execution", nil)
+		// Pause until Resume brings the human response
+		if awaitsHumanInput(node, currentState) {
+			return currentState, r.pauseForHumanInput(execCtx, node, currentState)
 		}
 
-		// Execute the node
-		nodeStartTime := time.Now()
-		newState, err := r.executeNode(execCtx, node, currentState)
-		nodeExecutionTime := time.Since(nodeStartTime)
+		newState, err := r.runStep(execCtx, node, currentState)
+		if err != nil {
+			return nil, err
+		}
+		currentState = newState
 
-		// Update node execution stats
-		r.updateNodeStats(node.ID, nodeExecutionTime, err == nil)
+		// A node of a loop body ended the iteration early
+		if loopIterationEnded(execCtx.Context, currentState) {
+			return currentState, nil
+		}
 
+		// Determine next node
+		nextEdge, err := r.graph.router.GetNextEdge(execCtx.Context, currentNodeID, currentState)
 		if err != nil {
-			// Trace error
-			if execCtx.EnableTracing {
-				r.addTraceEntry(execCtx, currentNodeID, "node_error", "Node execution failed", map[string]interface{}{
-					"error": err.Error(),
-				})
-			}
+			return nil, newGraphError(execCtx.Locale, ErrCodeRoutingFailed, currentNodeID, err,
+				fmt.Sprintf("failed to determine next node from %s", currentNodeID),
+				fmt.Sprintf("无法确定节点 %s 的下一个节点", currentNodeID))
+		}
 
-			// Handle error based on node failure mode
-			switch node.Config.FailureMode {
-			case FailureModeStop:
-				return nil, fmt.Errorf("node %s failed: %w", currentNodeID, err)
-			case FailureModeContinue, FailureModeSkip:
-				// Continue with current state
-				newState = currentState
-			default:
-				return nil, fmt.Errorf("node %s failed: %w", currentNodeID, err)
-			}
+		r.traverse(execCtx, nextEdge, currentState)
+		currentNodeID = nextEdge.To
+		if err := execCtx.progress(currentState, currentNodeID); err != nil {
+			return nil, err
 		}
+	}
 
-		// Trace node execution end
-		if execCtx.EnableTracing {
-			r.addTraceEntry(execCtx, currentNodeID, "node_end", "Node execution completed", map[string]interface{}{
-				"duration_ms": nodeExecutionTime.Milliseconds(),
-				"success":     err == nil,
-			})
+	return currentState, nil
+}
+
+// executePlan executes a linear graph along its precomputed static plan,
+// skipping router scoring and node lookups.
+// executePlan 按预先计算的静态计划执行线性图，跳过路由评分和节点查找。
+func (r *Runnable) executePlan(execCtx *ExecutionContext, steps []planStep, state *State) (*State, error) {
+	currentState := state
+
+	for _, step := range steps {
+		if err := execCtx.progress(currentState, step.node.ID); err != nil {
+			return nil, err
+		}
+		if err := r.checkContinue(execCtx); err != nil {
+			return nil, err
+		}
+		if awaitsHumanInput(step.node, currentState) {
+			return currentState, r.pauseForHumanInput(execCtx, step.node, currentState)
 		}
 
-		// Update state
+		newState, err := r.runStep(execCtx, step.node, currentState)
+		if err != nil {
+			return nil, err
+		}
 		currentState = newState
-		execCtx.StepCount++
+		if loopIterationEnded(execCtx.Context, currentState) {
+			return currentState, nil
+		}
 
-		// Determine next node
-		nextNodeID, err := r.graph.router.GetNextNode(execCtx.Context, currentNodeID, currentState)
-		if err != nil {
-			return nil, fmt.Errorf("failed to determine next node from %s: %w", currentNodeID, err)
+		r.traverse(execCtx, step.edge, currentState)
+	}
+
+	if err := execCtx.progress(currentState, "END"); err != nil {
+		return nil, err
+	}
+	return currentState, nil
+}
+
+// checkContinue reports whether the execution may run another step.
+// checkContinue 检查执行是否可以继续下一步。
+func (r *Runnable) checkContinue(execCtx *ExecutionContext) error {
+	select {
+	case <-execCtx.Context.Done():
+		// The cause is described in each locale when rendered
+		err := execCtx.Context.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return newGraphError(execCtx.Locale, ErrCodeTimeout, "", err, "", "")
 		}
+		return newGraphError(execCtx.Locale, ErrCodeCanceled, "", err, "", "")
+	default:
+	}
+
+	if execCtx.MaxSteps > 0 && execCtx.StepCount >= execCtx.MaxSteps {
+		return newGraphError(execCtx.Locale, ErrCodeMaxStepsExceeded, "", nil,
+			fmt.Sprintf("maximum execution steps (%d) exceeded", execCtx.MaxSteps),
+			fmt.Sprintf("超过最大执行步数 (%d)", execCtx.MaxSteps))
+	}
+	return nil
+}
 
-		// Trace routing decision
+// runStep executes one node, applying its failure mode and recording stats, path and trace.
+// runStep 执行单个节点，处理其失败模式并记录统计、路径和跟踪信息。
+func (r *Runnable) runStep(execCtx *ExecutionContext, node *Node, currentState *State) (*State, error) {
+	// Trace node execution start
+	if execCtx.EnableTracing {
+		r.addTraceEntry(execCtx, node.ID, "node_start", "Starting node execution", nil)
+	}
+
+	// Keep the state before the node for the diff of the intermediate result
+	emitter, streaming := streamEmitter(execCtx.Context)
+	var before *State
+	if streaming {
+		before = currentState.Clone()
+	}
+
+	// Execute the node
+	nodeStartTime := time.Now()
+	newState, err := r.executeNode(execCtx, node, currentState)
+	nodeExecutionTime := time.Since(nodeStartTime)
+	execCtx.recordWastedWork(nodeStartTime, nodeStartTime.Add(nodeExecutionTime))
+
+	// Update node execution stats
+	r.updateNodeStats(node.ID, nodeExecutionTime, err == nil)
+	execCtx.Path = append(execCtx.Path, node.ID)
+	execCtx.NodeDurations[node.ID] += nodeExecutionTime
+
+	if err != nil {
+		// Trace error
 		if execCtx.EnableTracing {
-			r.addTraceEntry(execCtx, currentNodeID, "routing", "Routing to next node", map[string]interface{}{
-				"next_node": nextNodeID,
+			r.addTraceEntry(execCtx, node.ID, "node_error", "Node execution failed", map[string]interface{}{
+				"error": err.Error(),
 			})
 		}
 
-		currentNodeID = nextNodeID
+		// Handle error based on node failure mode
+		switch node.Config.FailureMode {
+		case FailureModeContinue, FailureModeSkip:
+			// Continue with current state
+			execCtx.Warnings = append(execCtx.Warnings, fmt.Sprintf("node %s failed and was skipped: %v", node.ID, err))
+			newState = currentState
+		default:
+			return nil, newGraphError(execCtx.Locale, ErrCodeNodeFailed, node.ID, err,
+				fmt.Sprintf("node %s failed", node.ID),
+				fmt.Sprintf("节点 %s 执行失败", node.ID))
+		}
 	}
 
-	return currentState, nil
+	// Nodes in continue/skip mode swallow their errors but record them in history
+	if err == nil && newState != nil && len(newState.History) > 0 {
+		if step := newState.History[len(newState.History)-1]; step.NodeID == node.ID && !step.Success {
+			execCtx.Warnings = append(execCtx.Warnings, fmt.Sprintf("node %s failed and was skipped: %s", node.ID, step.Error))
+		}
+	}
+
+	// Nodes returning a fresh state keep publishing on the execution's bus
+	if newState != nil && newState.Events == nil {
+		newState.Events = currentState.Events
+	}
+
+	// Events are traced in log order, after the branches of the node have joined
+	if execCtx.EnableTracing && newState != nil {
+		for _, event := range newState.Events.untraced() {
+			r.addTraceEntry(execCtx, event.NodeID, "event", "Event published", map[string]interface{}{
+				"seq":     event.Seq,
+				"topic":   event.Topic,
+				"branch":  event.Branch,
+				"payload": event.Payload,
+			})
+		}
+	}
+
+	// Trace node execution end
+	if execCtx.EnableTracing {
+		r.addTraceEntry(execCtx, node.ID, "node_end", "Node execution completed", map[string]interface{}{
+			"duration_ms": nodeExecutionTime.Milliseconds(),
+			"success":     err == nil,
+		})
+	}
+
+	// Keep the working state small before the next node sees it
+	if policy := r.graph.Config.Summarization; policy != nil && newState != nil {
+		if _, err := policy.Apply(execCtx.Context, newState); err != nil {
+			execCtx.Warnings = append(execCtx.Warnings, fmt.Sprintf("summarization after node %s failed: %v", node.ID, err))
+		}
+	}
+
+	execCtx.StepCount++
+
+	// Publish the result of the node when running under Stream
+	if streaming && newState != nil {
+		metadata := map[string]interface{}{
+			"step":        execCtx.StepCount,
+			"duration_ms": nodeExecutionTime.Milliseconds(),
+		}
+		if err != nil {
+			metadata["error"] = err.Error()
+		}
+		emitter(&StreamResult{
+			Type:     StreamResultTypeIntermediate,
+			State:    newState.Clone(),
+			NodeID:   node.ID,
+			Diff:     DiffStates(before, newState),
+			Metadata: metadata,
+		})
+	}
+
+	return newState, nil
+}
+
+// traverse applies the data mappings of the chosen edge and traces the routing decision.
+// traverse 应用所选边的数据映射并记录路由决策。
+func (r *Runnable) traverse(execCtx *ExecutionContext, edge *Edge, state *State) {
+	// Carry data across the edge
+	edge.ApplyMappings(state)
+
+	// Trace routing decision
+	if execCtx.EnableTracing {
+		r.addTraceEntry(execCtx, edge.From, "routing", "Routing to next node", map[string]interface{}{
+			"next_node": edge.To,
+		})
+	}
 }
 
 // executeNode executes a single node with middleware support.
 // executeNode 执行单个节点，支持中间件。
 func (r *Runnable) executeNode(execCtx *ExecutionContext, node *Node, state *State) (*State, error) {
+	// Wait for a free slot of a node with limited concurrency
+	release, err := r.acquireNodeSlot(execCtx.Context, node)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Create final execution function
 	finalFunc := func(ctx context.Context, state *State) (*State, error) {
 		return node.Execute(ctx, state)
@@ -305,7 +696,114 @@ func (r *Runnable) executeNode(execCtx *ExecutionContext, node *Node, state *Sta
 		}
 	}
 
-	return finalFunc(execCtx.Context, state)
+	// Trace the node run, middleware included
+	ctx, span := startNodeSpan(context.WithValue(execCtx.Context, nodeIDContextKey{}, node.ID), execCtx, node)
+	start := time.Now()
+	result, err := finalFunc(ctx, state)
+	endNodeSpan(span, time.Since(start), err)
+	return result, err
+}
+
+// acquireNodeSlot blocks until the node may run under its MaxConcurrent limit, which is
+// shared by all in-flight executions of the runnable. The returned function releases the slot.
+// acquireNodeSlot 阻塞直到节点在其 MaxConcurrent 限制内可以执行，该限制由可运行实例的
+// 所有进行中的执行共享。返回的函数用于释放占用的名额。
+func (r *Runnable) acquireNodeSlot(ctx context.Context, node *Node) (func(), error) {
+	if node.Config.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+
+	r.nodeSlotsLock.Lock()
+	if r.nodeSlots == nil {
+		r.nodeSlots = make(map[string]chan struct{})
+	}
+	slots, exists := r.nodeSlots[node.ID]
+	if !exists {
+		slots = make(chan struct{}, node.Config.MaxConcurrent)
+		r.nodeSlots[node.ID] = slots
+	}
+	r.nodeSlotsLock.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a concurrency slot of node %s: %w", node.ID, ctx.Err())
+	}
+}
+
+// nodeIDContextKey is the context key for the ID of the executing node.
+type nodeIDContextKey struct{}
+
+// NodeIDFromContext returns the ID of the node currently being executed.
+// NodeIDFromContext 返回当前正在执行的节点ID。
+func NodeIDFromContext(ctx context.Context) string {
+	nodeID, _ := ctx.Value(nodeIDContextKey{}).(string)
+	return nodeID
+}
+
+// ================================
+// Usage Reporting 用量报告
+// ================================
+
+// usageCollectorContextKey is the context key for the usage collector of an execution.
+type usageCollectorContextKey struct{}
+
+// usageCollector aggregates usage reported by nodes during one execution.
+type usageCollector struct {
+	total   Usage
+	perNode map[string]Usage
+	lock    sync.Mutex
+}
+
+// newUsageCollector creates an empty usage collector.
+func newUsageCollector() *usageCollector {
+	return &usageCollector{perNode: make(map[string]Usage)}
+}
+
+// add records usage for a node.
+func (uc *usageCollector) add(nodeID string, usage Usage) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	uc.total.Add(usage)
+	nodeUsage := uc.perNode[nodeID]
+	nodeUsage.Add(usage)
+	uc.perNode[nodeID] = nodeUsage
+}
+
+// snapshot returns a copy of the collected usage.
+func (uc *usageCollector) snapshot() (Usage, map[string]Usage) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	perNode := make(map[string]Usage, len(uc.perNode))
+	for k, v := range uc.perNode {
+		perNode[k] = v
+	}
+	return uc.total, perNode
+}
+
+// RecordUsage reports token and cost usage for the node executing in ctx.
+// The usage is aggregated into the Result returned by InvokeDetailed.
+// RecordUsage 为 ctx 中正在执行的节点报告token与成本用量，用量会汇总到 InvokeDetailed 的结果中。
+func RecordUsage(ctx context.Context, usage Usage) {
+	collector, ok := ctx.Value(usageCollectorContextKey{}).(*usageCollector)
+	if !ok {
+		return
+	}
+	collector.add(NodeIDFromContext(ctx), usage)
+}
+
+// UsageFromContext returns the total usage reported so far by the execution in ctx.
+// UsageFromContext 返回 ctx 所属执行到目前为止报告的总用量。
+func UsageFromContext(ctx context.Context) Usage {
+	collector, ok := ctx.Value(usageCollectorContextKey{}).(*usageCollector)
+	if !ok {
+		return Usage{}
+	}
+	total, _ := collector.snapshot()
+	return total
 }
 
 // ================================
@@ -335,9 +833,16 @@ func (r *Runnable) InvokeParallel(ctx context.Context, states []*State, options
 		go func(index int, inputState *State) {
 			defer wg.Done()
 
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
+			// Acquire semaphore; states still waiting when ctx is cancelled are not started
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				mu.Lock()
+				results[index] = &Result{State: inputState, Error: ctx.Err()}
+				mu.Unlock()
+				return
+			}
 
 			startTime := time.Now()
 			finalState, err := r.InvokeWithOptions(ctx, inputState, options...)
@@ -364,31 +869,51 @@ func (r *Runnable) InvokeParallel(ctx context.Context, states []*State, options
 // Streaming Execution 流式执行
 // ================================
 
-// Stream executes the graph and streams intermediate results.
-// Stream 执行图并流式传输中间结果。
+// Stream executes the graph and streams its results: an intermediate result after every
+// node with the node ID, a snapshot of the state and the changes the node made, draft
+// chunks published with EmitDraft, and finally the final state or the error.
+// Stream 执行图并流式传输结果：每个节点执行后发送包含节点ID、状态快照及该节点所做修改的中间结果，
+// 以及通过 EmitDraft 发布的草稿分片，最后发送最终状态或错误。
 func (r *Runnable) Stream(ctx context.Context, state *State, options ...ExecutionOption) (<-chan *StreamResult, error) {
 	resultChan := make(chan *StreamResult, 100)
 
 	go func() {
 		defer close(resultChan)
 
+		// Let nodes publish draft events to this stream
+		emitter := func(result *StreamResult) {
+			select {
+			case resultChan <- result:
+			case <-ctx.Done():
+			}
+		}
+		streamCtx := context.WithValue(ctx, streamEmitterContextKey{}, emitter)
+
 		// Create execution context with tracing enabled
 		opts := append(options, WithTracing(true))
-		finalState, err := r.InvokeWithOptions(ctx, state, opts...)
+		finalState, err := r.InvokeWithOptions(streamCtx, state, opts...)
 
+		// The consumer may be gone after a cancellation; only block on it while ctx is live
+		finish := func(result *StreamResult) {
+			select {
+			case resultChan <- result:
+			default:
+				emitter(result)
+			}
+		}
 		if err != nil {
-			resultChan <- &StreamResult{
+			finish(&StreamResult{
 				Type:  StreamResultTypeError,
 				Error: err,
-			}
+			})
 			return
 		}
 
 		// Send final result
-		resultChan <- &StreamResult{
+		finish(&StreamResult{
 			Type:  StreamResultTypeFinal,
 			State: finalState,
-		}
+		})
 	}()
 
 	return resultChan, nil
@@ -406,6 +931,9 @@ type StreamResult struct {
 	// NodeID is the ID of the node that produced this result.
 	NodeID string
 
+	// Diff contains the changes the node made to the state (for intermediate results).
+	Diff *StateDiff
+
 	// Error contains any error that occurred.
 	Error error
 
@@ -418,14 +946,62 @@ type StreamResult struct {
 type StreamResultType string
 
 const (
-	// StreamResultTypeIntermediate represents an intermediate result.
+	// StreamResultTypeIntermediate represents the result of a node, emitted after every node.
 	StreamResultTypeIntermediate StreamResultType = "intermediate"
 	// StreamResultTypeFinal represents the final result.
 	StreamResultTypeFinal StreamResultType = "final"
 	// StreamResultTypeError represents an error result.
 	StreamResultTypeError StreamResultType = "error"
+	// StreamResultTypeDraft represents a partial output chunk aggregated into a state draft.
+	StreamResultTypeDraft StreamResultType = "draft"
 )
 
+// streamEmitterContextKey is the context key for the stream emitter installed by Stream.
+type streamEmitterContextKey struct{}
+
+// EmitDraft appends chunk to the state draft for key and, when running under Stream,
+// publishes a draft event carrying the chunk and the accumulated text.
+// EmitDraft 将分片追加到状态草稿中，在 Stream 下执行时发布包含分片和累积文本的草稿事件。
+func EmitDraft(ctx context.Context, state *State, key, chunk string) {
+	draft := state.AppendDraft(key, chunk)
+
+	emitter, ok := streamEmitter(ctx)
+	if !ok {
+		return
+	}
+	emitter(&StreamResult{
+		Type:   StreamResultTypeDraft,
+		State:  state,
+		NodeID: NodeIDFromContext(ctx),
+		Metadata: map[string]interface{}{
+			"key":   key,
+			"chunk": chunk,
+			"draft": draft,
+		},
+	})
+}
+
+// streamEmitter returns the emitter installed by Stream, if any.
+func streamEmitter(ctx context.Context) (func(*StreamResult), bool) {
+	emitter, ok := ctx.Value(streamEmitterContextKey{}).(func(*StreamResult))
+	return emitter, ok
+}
+
+// DraftStreamingFunc returns a streaming callback, suitable for llms.WithStreamingFunc,
+// that aggregates chunks into the state draft for key. Call State.FinalizeDraft when generation completes.
+// DraftStreamingFunc 返回可用于 llms.WithStreamingFunc 的流式回调，将分片聚合到状态草稿中；
+// 生成完成后调用 State.FinalizeDraft。
+func DraftStreamingFunc(state *State, key string) func(ctx context.Context, chunk []byte) error {
+	return func(ctx context.Context, chunk []byte) error {
+		// Stop the provider from reading the rest of the stream once the execution is cancelled
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		EmitDraft(ctx, state, key, string(chunk))
+		return nil
+	}
+}
+
 // ================================
 // Statistics and Monitoring 统计和监控
 // ================================
@@ -433,12 +1009,15 @@ const (
 // recordExecutionStart records the start of an execution.
 // recordExecutionStart 记录执行的开始。
 func (r *Runnable) recordExecutionStart(execCtx *ExecutionContext) {
+	// Concurrent first invocations must not both initialize the stats
+	r.lock.Lock()
 	if r.executionStats == nil {
 		r.executionStats = &ExecutionStats{
 			NodeExecutionCount: make(map[string]int64),
 			NodeExecutionTime:  make(map[string]time.Duration),
 		}
 	}
+	r.lock.Unlock()
 
 	r.executionStats.lock.Lock()
 	defer r.executionStats.lock.Unlock()
@@ -450,11 +1029,30 @@ func (r *Runnable) recordExecutionStart(execCtx *ExecutionContext) {
 // recordExecutionEnd records the end of an execution.
 // recordExecutionEnd 记录执行的结束。
 func (r *Runnable) recordExecutionEnd(execCtx *ExecutionContext, err error) {
+	duration := time.Since(execCtx.StartTime)
+
+	// An execution paused for human input has not failed
+	if errors.Is(err, ErrExecutionInterrupted) {
+		err = nil
+	}
+	r.updateExecutionStats(duration, err)
+	r.recordCancellation(execCtx)
+
+	// Listeners run outside the locks so that they may query the runnable
+	events, listeners := r.sla.record(duration, err)
+	for _, event := range events {
+		for _, listener := range listeners {
+			listener(event)
+		}
+	}
+}
+
+// updateExecutionStats updates the totals with a finished execution.
+// updateExecutionStats 使用已结束的执行更新汇总统计。
+func (r *Runnable) updateExecutionStats(duration time.Duration, err error) {
 	r.executionStats.lock.Lock()
 	defer r.executionStats.lock.Unlock()
 
-	duration := time.Since(execCtx.StartTime)
-
 	if err == nil {
 		r.executionStats.SuccessfulExecutions++
 	} else {
@@ -513,6 +1111,8 @@ func (r *Runnable) GetExecutionStats() *ExecutionStats {
 		FailedExecutions:     r.executionStats.FailedExecutions,
 		AverageExecutionTime: r.executionStats.AverageExecutionTime,
 		LastExecutionTime:    r.executionStats.LastExecutionTime,
+		CanceledExecutions:   r.executionStats.CanceledExecutions,
+		WastedAfterCancel:    r.executionStats.WastedAfterCancel,
 		NodeExecutionCount:   make(map[string]int64),
 		NodeExecutionTime:    make(map[string]time.Duration),
 	}
@@ -525,6 +1125,8 @@ func (r *Runnable) GetExecutionStats() *ExecutionStats {
 		stats.NodeExecutionTime[k] = v
 	}
 
+	stats.SLA = r.SLAStatus()
+
 	return stats
 }
 
@@ -538,6 +1140,7 @@ func (r *Runnable) ResetStats() {
 		NodeExecutionCount: make(map[string]int64),
 		NodeExecutionTime:  make(map[string]time.Duration),
 	}
+	r.sla.reset()
 }
 
 // ================================
@@ -550,10 +1153,13 @@ func (r *Runnable) GetGraph() *Graph {
 	return r.graph
 }
 
-// GetExecutionTrace returns the execution trace from the last execution.
-// GetExecutionTrace 返回最后一次执行的执行追踪。
+// GetExecutionTrace returns the trace entries of the most recent execution retained in
+// memory. Use GetTrace to look up a specific execution.
+// GetExecutionTrace 返回内存中保留的最近一次执行的追踪条目，查询指定执行请使用 GetTrace。
 func (r *Runnable) GetExecutionTrace() []TraceEntry {
-	// This would require storing the last execution context
-	// For now, return an empty slice
-	return make([]TraceEntry, 0)
+	traces := r.traces.recent()
+	if len(traces) == 0 {
+		return make([]TraceEntry, 0)
+	}
+	return append([]TraceEntry{}, traces[0].Entries...)
 }
\ No newline at end of file
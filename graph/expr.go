@@ -0,0 +1,817 @@
+// Package graph - Safe expression evaluation
+// 包 graph - 安全表达式求值
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ================================
+// Expression 表达式
+// ================================
+
+// maxExprDepth limits nesting so that hostile input cannot exhaust the stack.
+// maxExprDepth 限制嵌套深度，防止恶意输入耗尽调用栈。
+const maxExprDepth = 64
+
+// Expr is a compiled arithmetic/logic expression over state variables.
+// Expressions only see the variables passed to Eval and a fixed set of
+// math functions; they cannot call into arbitrary Go code.
+//
+// Supported syntax:
+//   - literals: numbers, "strings", 'strings', true, false, null
+//   - variables: name, nested.path (maps are traversed by key)
+//   - arithmetic: + - * / % ** (string + string concatenates)
+//   - comparison: == != < <= > >=
+//   - logic: && || ! and the ternary cond ? a : b
+//   - functions: abs, ceil, floor, round(x[, digits]), sqrt, pow, min, max, len, int
+//
+// Expr 是基于状态变量的已编译算术/逻辑表达式。
+// 表达式只能访问传入 Eval 的变量和固定的数学函数，无法调用任意Go代码。
+type Expr struct {
+	source string
+	root   exprNode
+}
+
+// CompileExpr parses an expression for repeated evaluation.
+// CompileExpr 解析表达式以便重复求值。
+func CompileExpr(source string) (*Expr, error) {
+	tokens, err := tokenizeExpr(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseTernary()
+	if err == nil && p.peek().kind != exprTokenEOF {
+		err = fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+
+	return &Expr{source: source, root: root}, nil
+}
+
+// ErrUndefinedVariable is returned when an expression refers to a variable that is not set.
+// ErrUndefinedVariable 表示表达式引用了未设置的变量。
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// exprCache holds the expressions compiled by compileCachedExpr, keyed by source.
+var exprCache sync.Map
+
+// compileCachedExpr compiles source once and reuses the result, for expressions stored in
+// edges that are evaluated on every traversal.
+func compileCachedExpr(source string) (*Expr, error) {
+	if cached, ok := exprCache.Load(source); ok {
+		return cached.(*Expr), nil
+	}
+	expr, err := CompileExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	exprCache.Store(source, expr)
+	return expr, nil
+}
+
+// EvalExpr compiles and evaluates an expression in one step.
+// EvalExpr 一步完成表达式的编译与求值。
+func EvalExpr(source string, vars map[string]interface{}) (interface{}, error) {
+	expr, err := CompileExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	return expr.Eval(vars)
+}
+
+// String returns the expression source.
+// String 返回表达式源码。
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against vars. Numeric results are float64.
+// Eval 使用 vars 对表达式求值，数值结果为 float64。
+func (e *Expr) Eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := e.root.eval(vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %w", e.source, err)
+	}
+	return value, nil
+}
+
+// EvalBool evaluates the expression and requires a boolean result.
+// EvalBool 对表达式求值并要求结果为布尔值。
+func (e *Expr) EvalBool(vars map[string]interface{}) (bool, error) {
+	value, err := e.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q evaluated to %T, expected bool", e.source, value)
+	}
+	return b, nil
+}
+
+// ExprNode creates a node that evaluates expression over the state variables
+// (and registered values, see ExprVariables) and stores the result in the variable outputKey. Compilation errors are
+// reported when the node executes.
+// ExprNode 创建一个节点，基于状态变量（及已注册常量，见 ExprVariables）计算表达式并将结果写入变量 outputKey。
+// 编译错误会在节点执行时返回。
+func ExprNode(id, expression, outputKey string) *Node {
+	expr, compileErr := CompileExpr(expression)
+	return NewNode(id).
+		WithType(NodeTypeFunction).
+		WithDescription("Evaluates " + expression).
+		WithOutput(outputKey, "interface{}", "Result of "+expression).
+		WithMetadata("expression", expression).
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			if compileErr != nil {
+				return nil, compileErr
+			}
+			value, err := expr.Eval(ExprVariables(state))
+			if err != nil {
+				return nil, err
+			}
+			state.SetVariable(outputKey, value)
+			return state, nil
+		}).
+		Build()
+}
+
+// ================================
+// Tokenizer 词法分析
+// ================================
+
+type exprTokenKind int
+
+const (
+	exprTokenEOF exprTokenKind = iota
+	exprTokenNumber
+	exprTokenString
+	exprTokenIdent
+	exprTokenOp
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+// exprOperators lists operators, longest first so that "**" wins over "*".
+var exprOperators = []string{
+	"**", "==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "(", ")", ",", "?", ":", ".",
+}
+
+func tokenizeExpr(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == '_') {
+				i++
+			}
+			// Exponent such as 1e-3
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					for i = j; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+					}
+				}
+			}
+			text := string(runes[start:i])
+			num, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, start)
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenNumber, text: text, num: num, pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					continue
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: exprTokenString, text: sb.String(), pos: start})
+
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, exprToken{kind: exprTokenOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+	return append(tokens, exprToken{kind: exprTokenEOF, pos: len(runes)}), nil
+}
+
+// ================================
+// Parser 语法分析
+// ================================
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	depth  int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != exprTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the given operators.
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != exprTokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		tok := p.peek()
+		if tok.kind == exprTokenEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at position %d, got %q", op, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *exprParser) parseTernary() (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExprDepth {
+		return nil, fmt.Errorf("expression nested deeper than %d levels", maxExprDepth)
+	}
+
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &exprTernary{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// exprPrecedence lists binary operators from lowest to highest precedence.
+var exprPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(exprPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(exprPrecedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.accept("-", "+", "!"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxExprDepth {
+			return nil, fmt.Errorf("expression nested deeper than %d levels", maxExprDepth)
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprUnary{op: op, operand: operand}, nil
+	}
+	return p.parsePower()
+}
+
+func (p *exprParser) parsePower() (exprNode, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("**"); !ok {
+		return base, nil
+	}
+	// Right-associative: 2 ** 3 ** 2 == 2 ** 9
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &exprBinary{op: "**", left: base, right: exponent}, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case exprTokenNumber:
+		return &exprLiteral{value: tok.num}, nil
+	case exprTokenString:
+		return &exprLiteral{value: tok.text}, nil
+	case exprTokenIdent:
+		switch tok.text {
+		case "true":
+			return &exprLiteral{value: true}, nil
+		case "false":
+			return &exprLiteral{value: false}, nil
+		case "null", "nil":
+			return &exprLiteral{value: nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(tok)
+		}
+		path := []string{tok.text}
+		for {
+			if _, ok := p.accept("."); !ok {
+				break
+			}
+			field := p.next()
+			if field.kind != exprTokenIdent {
+				return nil, fmt.Errorf("expected field name at position %d", field.pos)
+			}
+			path = append(path, field.text)
+		}
+		return &exprVariable{path: path}, nil
+	case exprTokenOp:
+		if tok.text == "(" {
+			inner, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+
+	var args []exprNode
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments for %s: %d", name.text, len(args))
+	}
+	return &exprCall{name: name.text, fn: fn.call, args: args}, nil
+}
+
+// ================================
+// Evaluation 求值
+// ================================
+
+type exprNode interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type exprLiteral struct {
+	value interface{}
+}
+
+func (n *exprLiteral) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type exprVariable struct {
+	path []string
+}
+
+func (n *exprVariable) eval(vars map[string]interface{}) (interface{}, error) {
+	// A variable named with dots takes precedence over traversal.
+	if value, ok := vars[strings.Join(n.path, ".")]; ok {
+		return normalizeExprValue(value), nil
+	}
+
+	value, ok := vars[n.path[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUndefinedVariable, n.path[0])
+	}
+	for i, key := range n.path[1:] {
+		m, isMap := value.(map[string]interface{})
+		if !isMap {
+			return nil, fmt.Errorf("%s is %T, not a map", strings.Join(n.path[:i+1], "."), value)
+		}
+		if value, ok = m[key]; !ok {
+			return nil, fmt.Errorf("%w %q", ErrUndefinedVariable, strings.Join(n.path[:i+2], "."))
+		}
+	}
+	return normalizeExprValue(value), nil
+}
+
+type exprUnary struct {
+	op      string
+	operand exprNode
+}
+
+func (n *exprUnary) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! expects bool, got %T", value)
+		}
+		return !b, nil
+	}
+	num, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("operator %s expects number, got %T", n.op, value)
+	}
+	if n.op == "-" {
+		return -num, nil
+	}
+	return num, nil
+}
+
+type exprBinary struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *exprBinary) eval(vars map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit logic operators.
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects bool, got %T", n.op, left)
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+		right, err := n.right.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects bool, got %T", n.op, right)
+		}
+		return rb, nil
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return exprEqual(left, right), nil
+	case "!=":
+		return !exprEqual(left, right), nil
+	}
+
+	if ls, ok := left.(string); ok {
+		rs, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("operator %s cannot mix string and %T", n.op, right)
+		}
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+		return nil, fmt.Errorf("operator %s is not supported for strings", n.op)
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s expects numbers, got %T and %T", n.op, left, right)
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	case "**":
+		return math.Pow(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+type exprTernary struct {
+	cond, then, otherwise exprNode
+}
+
+func (n *exprTernary) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.cond.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	cond, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("ternary condition expects bool, got %T", value)
+	}
+	if cond {
+		return n.then.eval(vars)
+	}
+	return n.otherwise.eval(vars)
+}
+
+type exprCall struct {
+	name string
+	fn   func(args []interface{}) (interface{}, error)
+	args []exprNode
+}
+
+func (n *exprCall) eval(vars map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	result, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return result, nil
+}
+
+// exprFunction describes a built-in function; maxArgs < 0 means variadic.
+type exprFunction struct {
+	minArgs, maxArgs int
+	call             func(args []interface{}) (interface{}, error)
+}
+
+// exprFunctions is the whitelist of functions callable from expressions.
+var exprFunctions = map[string]exprFunction{
+	"abs":   {1, 1, exprMath1(math.Abs)},
+	"ceil":  {1, 1, exprMath1(math.Ceil)},
+	"floor": {1, 1, exprMath1(math.Floor)},
+	"sqrt":  {1, 1, exprMath1(math.Sqrt)},
+	"int":   {1, 1, exprMath1(math.Trunc)},
+	"pow": {2, 2, func(args []interface{}) (interface{}, error) {
+		nums, err := exprNumbers(args)
+		if err != nil {
+			return nil, err
+		}
+		return math.Pow(nums[0], nums[1]), nil
+	}},
+	"round": {1, 2, func(args []interface{}) (interface{}, error) {
+		nums, err := exprNumbers(args)
+		if err != nil {
+			return nil, err
+		}
+		if len(nums) == 1 {
+			return math.Round(nums[0]), nil
+		}
+		scale := math.Pow(10, nums[1])
+		return math.Round(nums[0]*scale) / scale, nil
+	}},
+	"min": {1, -1, func(args []interface{}) (interface{}, error) {
+		nums, err := exprNumbers(args)
+		if err != nil {
+			return nil, err
+		}
+		result := nums[0]
+		for _, n := range nums[1:] {
+			result = math.Min(result, n)
+		}
+		return result, nil
+	}},
+	"max": {1, -1, func(args []interface{}) (interface{}, error) {
+		nums, err := exprNumbers(args)
+		if err != nil {
+			return nil, err
+		}
+		result := nums[0]
+		for _, n := range nums[1:] {
+			result = math.Max(result, n)
+		}
+		return result, nil
+	}},
+	"len": {1, 1, func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v))), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case []string:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, fmt.Errorf("unsupported argument type %T", args[0])
+	}},
+}
+
+func exprMath1(fn func(float64) float64) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		nums, err := exprNumbers(args)
+		if err != nil {
+			return nil, err
+		}
+		return fn(nums[0]), nil
+	}
+}
+
+func exprNumbers(args []interface{}) ([]float64, error) {
+	nums := make([]float64, len(args))
+	for i, arg := range args {
+		num, ok := arg.(float64)
+		if !ok {
+			return nil, fmt.Errorf("argument %d expects number, got %T", i+1, arg)
+		}
+		nums[i] = num
+	}
+	return nums, nil
+}
+
+func exprEqual(left, right interface{}) bool {
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case float64, string, bool:
+		return l == right
+	}
+	return false
+}
+
+// normalizeExprValue converts Go numeric types found in state variables to float64.
+// normalizeExprValue 将状态变量中的Go数值类型统一转换为 float64。
+func normalizeExprValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case interface{ Float64() (float64, error) }:
+		// json.Number
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
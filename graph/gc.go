@@ -0,0 +1,341 @@
+// Package graph - State garbage collection
+// 包 graph - 状态垃圾回收
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ================================
+// State GC 状态垃圾回收
+// ================================
+
+// StateGraphIDKey is the state metadata key used to group states by graph for StateGCPolicy.MaxPerGraph.
+// StateGraphIDKey 是 StateGCPolicy.MaxPerGraph 按图分组状态时使用的元数据键。
+const StateGraphIDKey = "graph_id"
+
+// StateAccessTracker is implemented by state managers that record when a state was last accessed.
+// Managers without it fall back to State.UpdatedAt.
+// StateAccessTracker 由记录状态最近访问时间的状态管理器实现，未实现时使用 State.UpdatedAt。
+type StateAccessTracker interface {
+	LastAccessed(id string) (time.Time, bool)
+}
+
+// StateGCPolicy decides which states and checkpoints are reclaimed. Zero fields are not enforced.
+// StateGCPolicy 决定回收哪些状态和检查点，为零的字段不生效。
+type StateGCPolicy struct {
+	// TTL removes states created longer ago than this.
+	// TTL 移除创建时间早于该时长的状态。
+	TTL time.Duration `json:"ttl,omitempty"`
+
+	// MaxIdle removes states not accessed for this long.
+	// MaxIdle 移除超过该时长未被访问的状态。
+	MaxIdle time.Duration `json:"max_idle,omitempty"`
+
+	// MaxPerGraph keeps only the most recently updated states of each graph,
+	// grouped by the StateGraphIDKey metadata.
+	// MaxPerGraph 每个图只保留最近更新的若干状态，按 StateGraphIDKey 元数据分组。
+	MaxPerGraph int `json:"max_per_graph,omitempty"`
+
+	// CheckpointTTL removes checkpoints older than this; defaults to TTL.
+	// CheckpointTTL 移除早于该时长的检查点，默认与 TTL 相同。
+	CheckpointTTL time.Duration `json:"checkpoint_ttl,omitempty"`
+
+	// MaxCheckpoints keeps only the newest checkpoints of each state.
+	// MaxCheckpoints 每个状态只保留最新的若干检查点。
+	MaxCheckpoints int `json:"max_checkpoints,omitempty"`
+
+	// Interval is how often the background service runs; defaults to 1 minute.
+	// Interval 后台服务的运行间隔，默认1分钟。
+	Interval time.Duration `json:"interval,omitempty"`
+}
+
+// StateGCStats contains metrics about reclaimed entries.
+// StateGCStats 包含回收条目的统计信息。
+type StateGCStats struct {
+	Runs                 int64         `json:"runs"`
+	Scanned              int64         `json:"scanned"`
+	ReclaimedStates      int64         `json:"reclaimed_states"`
+	ReclaimedCheckpoints int64         `json:"reclaimed_checkpoints"`
+	Errors               int64         `json:"errors"`
+	LastRun              time.Time     `json:"last_run"`
+	LastDuration         time.Duration `json:"last_duration"`
+	LastError            string        `json:"last_error,omitempty"`
+}
+
+// StateGCResult describes a single collection run.
+// StateGCResult 描述单次回收的结果。
+type StateGCResult struct {
+	Scanned              int `json:"scanned"`
+	ReclaimedStates      int `json:"reclaimed_states"`
+	ReclaimedCheckpoints int `json:"reclaimed_checkpoints"`
+}
+
+// StateGC periodically deletes expired states and checkpoints from a state manager.
+// StateGC 定期从状态管理器中删除过期的状态和检查点。
+type StateGC struct {
+	manager StateLister
+	policy  StateGCPolicy
+
+	// stats holds the accumulated metrics.
+	stats StateGCStats
+
+	// lock protects stats and the running service.
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewStateGC creates a garbage collector for the given state manager.
+// NewStateGC 为指定的状态管理器创建垃圾回收器。
+func NewStateGC(manager StateLister, policy StateGCPolicy) *StateGC {
+	if policy.Interval <= 0 {
+		policy.Interval = time.Minute
+	}
+	if policy.CheckpointTTL <= 0 {
+		policy.CheckpointTTL = policy.TTL
+	}
+	return &StateGC{
+		manager: manager,
+		policy:  policy,
+	}
+}
+
+// Start runs the collector in the background until Stop is called or ctx is done.
+// Start 在后台运行回收器，直到调用 Stop 或 ctx 结束。
+func (gc *StateGC) Start(ctx context.Context) {
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+	if gc.cancel != nil {
+		return
+	}
+
+	ctx, gc.cancel = context.WithCancel(ctx)
+	gc.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(gc.policy.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				gc.RunOnce(ctx) // Errors are recorded in the stats
+			}
+		}
+	}(gc.done)
+}
+
+// Stop stops the background collector and waits for a running collection to finish.
+// Stop 停止后台回收器，并等待正在进行的回收结束。
+func (gc *StateGC) Stop() {
+	gc.lock.Lock()
+	cancel, done := gc.cancel, gc.done
+	gc.cancel, gc.done = nil, nil
+	gc.lock.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Stats returns the accumulated metrics.
+// Stats 返回累计的统计信息。
+func (gc *StateGC) Stats() StateGCStats {
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+	return gc.stats
+}
+
+// gcEntry is a state considered for collection.
+type gcEntry struct {
+	id         string
+	graphID    string
+	createdAt  time.Time
+	updatedAt  time.Time
+	accessedAt time.Time
+}
+
+// RunOnce performs a single collection. Individual delete failures do not stop the run;
+// they are joined into the returned error.
+// RunOnce 执行一次回收。单个删除失败不会中断回收，错误会合并后返回。
+func (gc *StateGC) RunOnce(ctx context.Context) (StateGCResult, error) {
+	start := time.Now()
+	result, errs := gc.collect(ctx, start)
+	err := errors.Join(errs...)
+
+	gc.lock.Lock()
+	gc.stats.Runs++
+	gc.stats.Scanned += int64(result.Scanned)
+	gc.stats.ReclaimedStates += int64(result.ReclaimedStates)
+	gc.stats.ReclaimedCheckpoints += int64(result.ReclaimedCheckpoints)
+	gc.stats.Errors += int64(len(errs))
+	gc.stats.LastRun = start
+	gc.stats.LastDuration = time.Since(start)
+	gc.stats.LastError = ""
+	if err != nil {
+		gc.stats.LastError = err.Error()
+	}
+	gc.lock.Unlock()
+
+	return result, err
+}
+
+// collect finds and deletes expired entries.
+func (gc *StateGC) collect(ctx context.Context, now time.Time) (StateGCResult, []error) {
+	var result StateGCResult
+	var errs []error
+
+	ids, err := gc.manager.ListStates()
+	if err != nil {
+		return result, []error{fmt.Errorf("failed to list states: %w", err)}
+	}
+	result.Scanned = len(ids)
+
+	// Split plain states from checkpoints
+	var states []gcEntry
+	checkpoints := make(map[string][]CheckpointInfo)
+	tracker, _ := gc.manager.(StateAccessTracker)
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return result, append(errs, ctx.Err())
+		}
+		if stateID, createdAt, ok := ParseCheckpointID(id); ok {
+			checkpoints[stateID] = append(checkpoints[stateID], CheckpointInfo{ID: id, StateID: stateID, Timestamp: createdAt})
+			continue
+		}
+
+		// Read the access time before Load, which may update it
+		var accessedAt time.Time
+		if tracker != nil {
+			accessedAt, _ = tracker.LastAccessed(id)
+		}
+		state, err := gc.manager.Load(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load state %s: %w", id, err))
+			continue
+		}
+		entry := gcEntry{id: id, createdAt: state.CreatedAt, updatedAt: state.UpdatedAt, accessedAt: accessedAt}
+		if entry.accessedAt.IsZero() {
+			entry.accessedAt = state.UpdatedAt
+		}
+		if graphID, ok := state.GetMetadata(StateGraphIDKey); ok {
+			entry.graphID = fmt.Sprint(graphID)
+		}
+		states = append(states, entry)
+	}
+
+	reclaimed := make(map[string]bool)
+	deleteState := func(id string) {
+		if err := gc.manager.Delete(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete state %s: %w", id, err))
+			return
+		}
+		reclaimed[id] = true
+		result.ReclaimedStates++
+	}
+
+	// Expired and idle states
+	var live []gcEntry
+	for _, entry := range states {
+		switch {
+		case gc.policy.TTL > 0 && !entry.createdAt.IsZero() && now.Sub(entry.createdAt) > gc.policy.TTL,
+			gc.policy.MaxIdle > 0 && !entry.accessedAt.IsZero() && now.Sub(entry.accessedAt) > gc.policy.MaxIdle:
+			deleteState(entry.id)
+		default:
+			live = append(live, entry)
+		}
+	}
+
+	// Count limit per graph, keeping the most recently updated states
+	if gc.policy.MaxPerGraph > 0 {
+		byGraph := make(map[string][]gcEntry)
+		for _, entry := range live {
+			byGraph[entry.graphID] = append(byGraph[entry.graphID], entry)
+		}
+		for _, entries := range byGraph {
+			if len(entries) <= gc.policy.MaxPerGraph {
+				continue
+			}
+			sort.Slice(entries, func(i, j int) bool { return entries[i].updatedAt.After(entries[j].updatedAt) })
+			for _, entry := range entries[gc.policy.MaxPerGraph:] {
+				deleteState(entry.id)
+			}
+		}
+	}
+
+	// Checkpoints of reclaimed states, expired checkpoints and checkpoints over the limit
+	for stateID, cps := range checkpoints {
+		sort.Slice(cps, func(i, j int) bool { return cps[i].Timestamp.After(cps[j].Timestamp) })
+		for i, cp := range cps {
+			if !reclaimed[stateID] &&
+				(gc.policy.CheckpointTTL <= 0 || now.Sub(cp.Timestamp) <= gc.policy.CheckpointTTL) &&
+				(gc.policy.MaxCheckpoints <= 0 || i < gc.policy.MaxCheckpoints) {
+				continue
+			}
+			if err := gc.manager.Delete(ctx, cp.ID); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete checkpoint %s: %w", cp.ID, err))
+				continue
+			}
+			result.ReclaimedCheckpoints++
+		}
+	}
+
+	return result, errs
+}
+
+// LastAccessed implements the StateAccessTracker interface.
+// LastAccessed 实现 StateAccessTracker 接口。
+func (msm *MemoryStateManager) LastAccessed(id string) (time.Time, bool) {
+	msm.lock.RLock()
+	defer msm.lock.RUnlock()
+	accessedAt, ok := msm.cleanup[id]
+	return accessedAt, ok
+}
+
+// LastAccessed implements the StateAccessTracker interface using the file modification time.
+// LastAccessed 使用文件修改时间实现 StateAccessTracker 接口。
+func (fsm *FileStateManager) LastAccessed(id string) (time.Time, bool) {
+	info, err := os.Stat(fsm.getFilename(id))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+// ListStates returns the union of the state IDs of both managers that support listing.
+// ListStates 返回两个支持枚举的状态管理器中状态ID的并集。
+func (csm *CompositeStateManager) ListStates() ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	listed := false
+	for _, manager := range []StateManager{csm.primary, csm.secondary} {
+		lister, ok := manager.(StateLister)
+		if !ok {
+			continue
+		}
+		listed = true
+		managerIDs, err := lister.ListStates()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range managerIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if !listed {
+		return nil, fmt.Errorf("no underlying state manager supports listing")
+	}
+	return ids, nil
+}
@@ -3,10 +3,12 @@
 package graph
 
 import (
+	"errors"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ================================
@@ -31,6 +33,9 @@ type Graph struct {
 	// Config contains configuration for this graph.
 	Config GraphConfig `json:"config"`
 
+	// InputSchema declares the variables expected in the initial state.
+	InputSchema []ParameterDef `json:"input_schema,omitempty"`
+
 	// nodes stores all nodes in the graph.
 	nodes map[string]*Node
 
@@ -43,9 +48,15 @@ type Graph struct {
 	// middleware contains graph-level middleware.
 	middleware []Middleware
 
+	// setupNodes run once per runnable before its first invocation.
+	setupNodes []*Node
+
 	// stateManager handles state persistence.
 	stateManager StateManager
 
+	// tracerProvider emits OpenTelemetry spans of executions; nil disables tracing.
+	tracerProvider trace.TracerProvider
+
 	// lock protects concurrent access to the graph.
 	lock sync.RWMutex
 }
@@ -58,6 +69,15 @@ type Graph struct {
 // GraphBuilder 提供了构建图的流畅接口。
 type GraphBuilder struct {
 	graph *Graph
+
+	// errs collects misconfigurations detected while building; returned by BuildE.
+	errs []error
+
+	// definition holds the nodes and edges added by WithDefinition until the graph is built.
+	definition *GraphDefinition
+
+	// functions binds the function names of the definition.
+	functions *FunctionRegistry
 }
 
 // NewGraph creates a new graph builder.
@@ -142,6 +162,13 @@ func (gb *GraphBuilder) WithStateManager(manager StateManager) *GraphBuilder {
 	return gb
 }
 
+// WithValidationProfile sets the default validation profile for the graph.
+// WithValidationProfile 设置图的默认验证配置。
+func (gb *GraphBuilder) WithValidationProfile(profile ValidationProfile) *GraphBuilder {
+	gb.graph.Config.ValidationProfile = profile
+	return gb
+}
+
 // WithMiddleware adds middleware to the graph.
 // WithMiddleware 为图添加中间件。
 func (gb *GraphBuilder) WithMiddleware(middleware ...Middleware) *GraphBuilder {
@@ -162,9 +189,7 @@ func (gb *GraphBuilder) WithMetadata(key string, value interface{}) *GraphBuilde
 // AddNode adds a node to the graph.
 // AddNode 向图添加节点。
 func (gb *GraphBuilder) AddNode(node *Node) *GraphBuilder {
-	if node != nil {
-		gb.graph.nodes[node.ID] = node
-	}
+	gb.addNode(node)
 	return gb
 }
 
@@ -172,9 +197,7 @@ func (gb *GraphBuilder) AddNode(node *Node) *GraphBuilder {
 // AddNodes 向图添加多个节点。
 func (gb *GraphBuilder) AddNodes(nodes ...*Node) *GraphBuilder {
 	for _, node := range nodes {
-		if node != nil {
-			gb.graph.nodes[node.ID] = node
-		}
+		gb.addNode(node)
 	}
 	return gb
 }
@@ -182,9 +205,7 @@ func (gb *GraphBuilder) AddNodes(nodes ...*Node) *GraphBuilder {
 // AddEdge adds an edge to the graph.
 // AddEdge 向图添加边。
 func (gb *GraphBuilder) AddEdge(edge *Edge) *GraphBuilder {
-	if edge != nil {
-		gb.graph.router.AddEdge(*edge)
-	}
+	gb.addEdge(edge)
 	return gb
 }
 
@@ -192,9 +213,7 @@ func (gb *GraphBuilder) AddEdge(edge *Edge) *GraphBuilder {
 // AddEdges 向图添加多个边。
 func (gb *GraphBuilder) AddEdges(edges ...*Edge) *GraphBuilder {
 	for _, edge := range edges {
-		if edge != nil {
-			gb.graph.router.AddEdge(*edge)
-		}
+		gb.addEdge(edge)
 	}
 	return gb
 }
@@ -202,8 +221,7 @@ func (gb *GraphBuilder) AddEdges(edges ...*Edge) *GraphBuilder {
 // Connect creates a simple edge between two nodes.
 // Connect 在两个节点之间创建简单的边。
 func (gb *GraphBuilder) Connect(from, to string) *GraphBuilder {
-	edge := NewEdge(fmt.Sprintf("%s_to_%s", from, to), from, to).Build()
-	gb.graph.router.AddEdge(*edge)
+	gb.addEdge(NewEdge(fmt.Sprintf("%s_to_%s", from, to), from, to).Build())
 	return gb
 }
 
@@ -213,10 +231,64 @@ func (gb *GraphBuilder) ConnectWithCondition(from, to string, condition EdgeCond
 	edge := NewEdge(fmt.Sprintf("%s_to_%s_conditional", from, to), from, to).
 		WithCondition(condition).
 		Build()
-	gb.graph.router.AddEdge(*edge)
+	gb.addEdge(edge)
 	return gb
 }
 
+// ConnectWithNamedCondition creates an edge between two nodes conditional on a registered condition.
+// ConnectWithNamedCondition 在两个节点之间创建以已注册条件为条件的边。
+func (gb *GraphBuilder) ConnectWithNamedCondition(from, to, name string) *GraphBuilder {
+	edge := NewEdge(fmt.Sprintf("%s_to_%s_%s", from, to, name), from, to).
+		WithNamedCondition(name).
+		Build()
+	gb.addEdge(edge)
+	return gb
+}
+
+// ConnectWithExpression creates an edge between two nodes conditional on a boolean expression over the state.
+// ConnectWithExpression 在两个节点之间创建以基于状态的布尔表达式为条件的边。
+func (gb *GraphBuilder) ConnectWithExpression(from, to, expression string) *GraphBuilder {
+	edge := NewEdge(fmt.Sprintf("%s_to_%s_expression", from, to), from, to).
+		WithExpression(expression).
+		Build()
+	gb.addEdge(edge)
+	return gb
+}
+
+// addNode registers a node, recording nil, invalid and duplicate nodes as builder errors.
+// Duplicates keep the last definition so that Build behaves as before.
+func (gb *GraphBuilder) addNode(node *Node) {
+	if node == nil {
+		gb.errs = append(gb.errs, fmt.Errorf("node cannot be nil"))
+		return
+	}
+	if err := node.Validate(); err != nil {
+		gb.errs = append(gb.errs, fmt.Errorf("invalid node: %w", err))
+	}
+	if _, exists := gb.graph.nodes[node.ID]; exists {
+		gb.errs = append(gb.errs, fmt.Errorf("duplicate node ID: %s", node.ID))
+	}
+	gb.graph.nodes[node.ID] = node
+}
+
+// addEdge registers an edge, recording nil, invalid and duplicate edges as builder errors.
+func (gb *GraphBuilder) addEdge(edge *Edge) {
+	if edge == nil {
+		gb.errs = append(gb.errs, fmt.Errorf("edge cannot be nil"))
+		return
+	}
+	if err := edge.Validate(); err != nil {
+		gb.errs = append(gb.errs, fmt.Errorf("invalid edge: %w", err))
+	}
+	for _, existing := range gb.graph.router.edges {
+		if existing.ID == edge.ID {
+			gb.errs = append(gb.errs, fmt.Errorf("duplicate edge ID: %s", edge.ID))
+			break
+		}
+	}
+	gb.graph.router.AddEdge(*edge)
+}
+
 // SetEntryPoint sets the entry point of the graph.
 // SetEntryPoint 设置图的入口点。
 func (gb *GraphBuilder) SetEntryPoint(nodeID string) *GraphBuilder {
@@ -225,11 +297,42 @@ func (gb *GraphBuilder) SetEntryPoint(nodeID string) *GraphBuilder {
 }
 
 // Build creates the graph instance.
-// Build 创建图实例。
+// Misconfigurations are not reported; use BuildE to surface them.
+// Build 创建图实例。不报告配置错误，如需获取错误请使用 BuildE。
 func (gb *GraphBuilder) Build() *Graph {
+	gb.applyDefinition()
 	return gb.graph
 }
 
+// BuildE creates the graph instance and returns all errors collected while building,
+// including edges that reference unknown nodes and a missing entry point.
+// BuildE 创建图实例，并返回构建过程中收集的所有错误，
+// 包括引用未知节点的边以及缺失的入口点。
+func (gb *GraphBuilder) BuildE() (*Graph, error) {
+	gb.applyDefinition()
+	errs := append([]error(nil), gb.errs...)
+
+	for _, edge := range gb.graph.router.edges {
+		if _, exists := gb.graph.nodes[edge.From]; !exists {
+			errs = append(errs, fmt.Errorf("edge %s references unknown source node: %s", edge.ID, edge.From))
+		}
+		if _, exists := gb.graph.nodes[edge.To]; !exists && edge.To != "END" {
+			errs = append(errs, fmt.Errorf("edge %s references unknown destination node: %s", edge.ID, edge.To))
+		}
+	}
+
+	if gb.graph.entryPoint == "" {
+		errs = append(errs, fmt.Errorf("entry point is not set"))
+	} else if _, exists := gb.graph.nodes[gb.graph.entryPoint]; !exists {
+		errs = append(errs, fmt.Errorf("entry point node not found: %s", gb.graph.entryPoint))
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return gb.graph, nil
+}
+
 // ================================
 // Graph Operations 图操作
 // ================================
@@ -379,6 +482,20 @@ func (g *Graph) GetEntryPoint() string {
 // Validate validates the entire graph.
 // Validate 验证整个图。
 func (g *Graph) Validate() *ValidationResult {
+	return g.ValidateWithProfile(g.Config.ValidationProfile)
+}
+
+// profileStrictCodes lists the warning codes promoted to errors under the prod profile.
+var profileStrictCodes = map[string]bool{
+	"UNREACHABLE_NODE":     true,
+	"MISSING_DEFAULT_EDGE": true,
+	"MISSING_NODE_TIMEOUT": true,
+}
+
+// ValidateWithProfile validates the graph using the given validation profile.
+// An empty profile behaves like ValidationProfileDev.
+// ValidateWithProfile 使用给定的验证配置验证图，空配置等同于 ValidationProfileDev。
+func (g *Graph) ValidateWithProfile(profile ValidationProfile) *ValidationResult {
 	result := &ValidationResult{
 		Valid:    true,
 		Errors:   make([]ValidationError, 0),
@@ -461,9 +578,64 @@ func (g *Graph) Validate() *ValidationResult {
 		}
 	}
 
+	// Check for conditional branches without a fallback edge
+	for nodeID := range g.nodes {
+		hasConditional, hasFallback := false, false
+		for _, edge := range g.router.GetEdgesFrom(nodeID) {
+			switch edge.Type {
+			case EdgeTypeConditional:
+				hasConditional = true
+			case EdgeTypeDefault, EdgeTypeNormal:
+				hasFallback = true
+			}
+		}
+		if hasConditional && !hasFallback {
+			result.Warnings = append(result.Warnings, ValidationWarning{
+				Code:    "MISSING_DEFAULT_EDGE",
+				Message: fmt.Sprintf("Node %s has conditional edges but no default edge", nodeID),
+				NodeID:  nodeID,
+			})
+		}
+	}
+
+	// Check for LLM nodes without a timeout
+	for nodeID, node := range g.nodes {
+		if node.HasTag(NodeTagLLM) && node.Config.Timeout <= 0 {
+			result.Warnings = append(result.Warnings, ValidationWarning{
+				Code:    "MISSING_NODE_TIMEOUT",
+				Message: fmt.Sprintf("LLM node %s has no timeout configured", nodeID),
+				NodeID:  nodeID,
+			})
+		}
+	}
+
+	if profile == ValidationProfileProd {
+		applyStrictProfile(result)
+	}
+
 	return result
 }
 
+// applyStrictProfile promotes hygiene warnings to errors.
+// applyStrictProfile 将卫生类警告提升为错误。
+func applyStrictProfile(result *ValidationResult) {
+	warnings := make([]ValidationWarning, 0, len(result.Warnings))
+	for _, warning := range result.Warnings {
+		if !profileStrictCodes[warning.Code] {
+			warnings = append(warnings, warning)
+			continue
+		}
+		result.Errors = append(result.Errors, ValidationError{
+			Code:    warning.Code,
+			Message: warning.Message,
+			NodeID:  warning.NodeID,
+			Details: warning.Details,
+		})
+		result.Valid = false
+	}
+	result.Warnings = warnings
+}
+
 // getReachableNodes returns a set of nodes reachable from the entry point.
 // getReachableNodes 返回从入口点可达的节点集合。
 func (g *Graph) getReachableNodes() map[string]bool {
@@ -501,24 +673,126 @@ func (g *Graph) getReachableNodes() map[string]bool {
 // Graph Compilation 图编译
 // ================================
 
+// CompileOptions contains options applied when compiling a graph.
+// CompileOptions 包含编译图时使用的选项。
+type CompileOptions struct {
+	// ValidationProfile overrides the graph's configured validation profile.
+	ValidationProfile ValidationProfile
+}
+
+// CompileOption configures graph compilation.
+// CompileOption 配置图的编译。
+type CompileOption func(*CompileOptions)
+
+// WithValidationProfile selects the validation profile used during compilation.
+// WithValidationProfile 选择编译时使用的验证配置。
+func WithValidationProfile(profile ValidationProfile) CompileOption {
+	return func(opts *CompileOptions) {
+		opts.ValidationProfile = profile
+	}
+}
+
 // Compile compiles the graph into a runnable instance.
 // Compile 将图编译为可运行的实例。
-func (g *Graph) Compile() (*Runnable, error) {
+func (g *Graph) Compile(opts ...CompileOption) (*Runnable, error) {
+	options := &CompileOptions{
+		ValidationProfile: g.Config.ValidationProfile,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	// Validate the graph
-	validation := g.Validate()
+	validation := g.ValidateWithProfile(options.ValidationProfile)
 	if !validation.Valid {
-		var errorMessages []string
-		for _, err := range validation.Errors {
-			errorMessages = append(errorMessages, err.Error())
-		}
-		return nil, fmt.Errorf("graph validation failed: %s", strings.Join(errorMessages, "; "))
+		return nil, validationFailedError(g.Config.Locale, validation.Errors)
 	}
 
 	return &Runnable{
-		graph: g,
+		graph:     g,
+		resources: newResources(),
+		sla:       newSLATracker(g.ID, g.Config.SLA),
+		traces:    newTraceBuffer(DefaultTraceRetention),
 	}, nil
 }
 
+// CompileOptimized compiles the graph like Compile and, when the graph is purely linear,
+// precomputes a static execution plan that bypasses router scoring and node lookups.
+// Non-linear graphs fall back to the general executor. The plan is a snapshot: nodes and
+// edges added to the graph after compilation are not picked up.
+// CompileOptimized 与 Compile 相同地编译图，当图为纯线性时预先计算静态执行计划，
+// 跳过路由评分和节点查找；非线性图回退到通用执行器。计划是编译时的快照，
+// 编译后对图的节点和边的修改不会生效。
+func (g *Graph) CompileOptimized(opts ...CompileOption) (*Runnable, error) {
+	runnable, err := g.Compile(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	runnable.plan = g.linearPlan()
+	return runnable, nil
+}
+
+// IsOptimized reports whether the runnable executes along a static plan.
+// IsOptimized 判断可运行实例是否按静态计划执行。
+func (r *Runnable) IsOptimized() bool {
+	return r.plan != nil
+}
+
+// staticPlan is the precomputed execution order of a linear graph.
+type staticPlan struct {
+	steps []planStep
+}
+
+// planStep is a node together with the edge leaving it.
+type planStep struct {
+	node *Node
+	edge *Edge
+}
+
+// from returns the steps of the plan starting at the given node, or nil when the node is not on the plan.
+func (p *staticPlan) from(nodeID string) []planStep {
+	for i, step := range p.steps {
+		if step.node.ID == nodeID {
+			return p.steps[i:]
+		}
+	}
+	return nil
+}
+
+// linearPlan returns the static plan of the graph, or nil when the graph is not linear.
+// A graph is linear when every node on the path from the entry point has exactly one
+// enabled, unconditional outgoing edge, no node is a condition node, and the path ends at END.
+func (g *Graph) linearPlan() *staticPlan {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	plan := &staticPlan{}
+	visited := make(map[string]bool)
+
+	for current := g.entryPoint; current != "END"; {
+		node, exists := g.nodes[current]
+		if !exists || visited[current] || node.Type == NodeTypeCondition {
+			return nil
+		}
+		visited[current] = true
+
+		edges := g.router.GetEdgesFrom(current)
+		if len(edges) != 1 {
+			return nil
+		}
+		edge := edges[0].Clone()
+		if !edge.Enabled || edge.Condition != nil || edge.Type == EdgeTypeConditional {
+			return nil
+		}
+
+		plan.steps = append(plan.steps, planStep{node: node, edge: edge})
+		current = edge.To
+	}
+
+	return plan
+}
+
 // ================================
 // Utility Functions 工具函数
 // ================================
@@ -535,6 +809,7 @@ func (g *Graph) Clone() *Graph {
 		Description:  g.Description,
 		Version:      g.Version,
 		Config:       g.Config,
+		InputSchema:  append([]ParameterDef(nil), g.InputSchema...),
 		nodes:        make(map[string]*Node),
 		router:       NewEdgeRouter(),
 		entryPoint:   g.entryPoint,
@@ -554,6 +829,10 @@ func (g *Graph) Clone() *Graph {
 
 	copy(clone.middleware, g.middleware)
 
+	for _, node := range g.setupNodes {
+		clone.setupNodes = append(clone.setupNodes, node.Clone())
+	}
+
 	// Deep copy config metadata
 	if g.Config.Metadata != nil {
 		clone.Config.Metadata = make(map[string]interface{})
@@ -3,14 +3,37 @@
 package graph_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/sjzsdu/langchaingo-cn/graph"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/chains"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+	"github.com/tmc/langchaingo/tools"
 )
 
 // TestState tests the State functionality
@@ -360,6 +383,64 @@ func TestMiddleware(t *testing.T) {
 	assert.Equal(t, int64(0), nodeMetrics.ErrorCount)
 }
 
+// TestMetricsLabels tests labeled metrics with cardinality limits
+// TestMetricsLabels 测试带标签的指标及基数限制
+func TestMetricsLabels(t *testing.T) {
+	metrics := graph.NewMetricsMiddleware().
+		WithVariableLabel("tenant", "tenant").
+		WithLabel("model", func(state *graph.State) string {
+			if model, ok := state.GetVariable("model"); ok {
+				return model.(string)
+			}
+			return "none"
+		}).
+		WithMaxLabelValues(2)
+
+	g, err := graph.NewGraph("labeled").
+		AddNode(graph.NewNode("answer").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			state.SetVariable("model", "glm-4")
+			if state.ID == "fail" {
+				return nil, errors.New("provider error")
+			}
+			return state, nil
+		}).WithMiddleware(metrics).Build()).
+		Connect("answer", "END").
+		SetEntryPoint("answer").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	for _, run := range []struct{ id, tenant string }{{"1", "acme"}, {"2", "globex"}, {"3", "acme"}, {"4", "initech"}, {"fail", "acme"}} {
+		state := graph.NewState(run.id)
+		state.SetVariable("tenant", run.tenant)
+		_, _ = runnable.Invoke(context.Background(), state)
+	}
+
+	series := metrics.GetLabeledMetrics()
+	byTenant := make(map[string]*graph.NodeMetrics)
+	for _, s := range series {
+		assert.Equal(t, "answer", s.NodeID)
+		assert.Equal(t, "glm-4", s.Labels["model"])
+		byTenant[s.Labels["tenant"]] = s.Metrics
+	}
+	require.Len(t, byTenant, 3)
+	assert.Equal(t, int64(3), byTenant["acme"].ExecutionCount)
+	assert.Equal(t, int64(1), byTenant["acme"].ErrorCount)
+	assert.Equal(t, int64(1), byTenant["globex"].ExecutionCount)
+	// The third tenant exceeds the cardinality limit
+	assert.Equal(t, int64(1), byTenant[graph.OtherLabelValue].ExecutionCount)
+
+	// Node-level metrics are unaffected by labels
+	nodeMetrics, exists := metrics.GetNodeMetrics("answer")
+	require.True(t, exists)
+	assert.Equal(t, int64(5), nodeMetrics.ExecutionCount)
+
+	metrics.ResetMetrics()
+	assert.Empty(t, metrics.GetLabeledMetrics())
+	assert.Empty(t, graph.NewMetricsMiddleware().GetLabeledMetrics())
+}
+
 // TestStateManager tests state management functionality
 // TestStateManager 测试状态管理功能
 func TestStateManager(t *testing.T) {
@@ -398,6 +479,68 @@ func TestStateManager(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// TestRedisStateManager tests the Redis state manager against miniredis
+// TestRedisStateManager 使用 miniredis 测试Redis状态管理器
+func TestRedisStateManager(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	manager := graph.NewRedisStateManager(client, "app*:state:", time.Minute)
+	other := graph.NewRedisStateManager(client, "other:", 0)
+
+	state := graph.NewState("run-1")
+	state.SetVariable("query", "你好")
+	state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hello"))
+	require.NoError(t, manager.Save(ctx, state))
+	require.NoError(t, manager.Save(ctx, graph.NewState("run-2")))
+	require.NoError(t, other.Save(ctx, graph.NewState("run-3")))
+	assert.True(t, mr.Exists("app*:state:run-1"))
+
+	loaded, err := manager.Load(ctx, "run-1")
+	require.NoError(t, err)
+	value, _ := loaded.GetVariable("query")
+	assert.Equal(t, "你好", value)
+	assert.Len(t, loaded.Messages, 1)
+
+	// Keys of other prefixes are not listed
+	ids, err := manager.ListStates()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run-1", "run-2"}, ids)
+
+	_, err = other.Load(ctx, "run-1")
+	assert.EqualError(t, err, "state run-1 not found")
+
+	// Compressed and uncompressed states can be read either way
+	manager.WithCompression(true)
+	require.NoError(t, manager.Save(ctx, state))
+	raw, err := mr.Get("app*:state:run-1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1f, 0x8b}, []byte(raw[:2]))
+	loaded, err = manager.Load(ctx, "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, state.ID, loaded.ID)
+	loaded, err = manager.Load(ctx, "run-2")
+	require.NoError(t, err)
+	assert.Equal(t, "run-2", loaded.ID)
+
+	// States expire after the TTL, a zero TTL keeps them
+	mr.FastForward(2 * time.Minute)
+	_, err = manager.Load(ctx, "run-1")
+	assert.EqualError(t, err, "state run-1 not found")
+	_, err = other.Load(ctx, "run-3")
+	assert.NoError(t, err)
+
+	require.NoError(t, other.Delete(ctx, "run-3"))
+	ids, err = other.ListStates()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	assert.Error(t, manager.Save(ctx, nil))
+	_, err = manager.Load(ctx, "")
+	assert.Error(t, err)
+}
+
 // TestConditionalRouting tests conditional routing in graphs
 // TestConditionalRouting 测试图中的条件路由
 func TestConditionalRouting(t *testing.T) {
@@ -515,4 +658,3060 @@ func BenchmarkGraphExecution(b *testing.B) {
 			b.Fatalf("Failed to execute graph: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// TestParseCheckpointID tests parsing checkpoint state IDs
+// TestParseCheckpointID 测试检查点状态ID的解析
+func TestParseCheckpointID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		stateID string
+		nanos   int64
+		ok      bool
+	}{
+		{name: "valid", id: "session_checkpoint_1700000000000000000", stateID: "session", nanos: 1700000000000000000, ok: true},
+		{name: "state ID containing separator", id: "a_checkpoint_b_checkpoint_42", stateID: "a_checkpoint_b", nanos: 42, ok: true},
+		{name: "non-numeric suffix", id: "session_checkpoint_latest", ok: false},
+		{name: "empty prefix", id: "_checkpoint_42", ok: false},
+		{name: "plain state ID", id: "session", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateID, createdAt, ok := graph.ParseCheckpointID(tt.id)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.stateID, stateID)
+			if tt.ok {
+				assert.Equal(t, tt.nanos, createdAt.UnixNano())
+			}
+		})
+	}
+}
+
+// TestCheckpointIDRoundTrip tests that checkpoint IDs created by CheckpointManager can be parsed
+// TestCheckpointIDRoundTrip 测试 CheckpointManager 创建的检查点ID可以被解析
+func TestCheckpointIDRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cm := graph.NewCheckpointManager(graph.NewMemoryStateManager(10), time.Minute, 5)
+
+	state := graph.NewState("round_trip")
+	require.NoError(t, cm.CreateCheckpoint(ctx, state))
+
+	checkpoints := cm.GetCheckpoints("round_trip")
+	require.Len(t, checkpoints, 1)
+
+	stateID, createdAt, ok := graph.ParseCheckpointID(checkpoints[0].ID)
+	assert.True(t, ok)
+	assert.Equal(t, "round_trip", stateID)
+	assert.WithinDuration(t, checkpoints[0].Timestamp, createdAt, time.Second)
+}
+
+// TestValidationProfiles tests dev and prod validation strictness
+// TestValidationProfiles 测试 dev 与 prod 验证严格程度
+func TestValidationProfiles(t *testing.T) {
+	noop := func(ctx context.Context, state *graph.State) (*graph.State, error) {
+		return state, nil
+	}
+
+	g := graph.NewGraph("profile_graph").
+		AddNodes(
+			graph.NewNode("llm").WithFunction(noop).WithTags(graph.NodeTagLLM).Build(),
+			graph.NewNode("orphan").WithFunction(noop).Build(),
+			graph.NewNode("end").WithType(graph.NodeTypeEnd).Build(),
+		).
+		Connect("llm", "end").
+		SetEntryPoint("llm").
+		Build()
+
+	// Dev profile only warns
+	// dev 配置只产生警告
+	dev := g.ValidateWithProfile(graph.ValidationProfileDev)
+	assert.True(t, dev.Valid)
+	codes := make([]string, 0, len(dev.Warnings))
+	for _, w := range dev.Warnings {
+		codes = append(codes, w.Code)
+	}
+	assert.Contains(t, codes, "UNREACHABLE_NODE")
+	assert.Contains(t, codes, "MISSING_NODE_TIMEOUT")
+
+	_, err := g.Compile()
+	assert.NoError(t, err)
+
+	// Prod profile turns hygiene warnings into errors
+	// prod 配置将卫生类警告变为错误
+	_, err = g.Compile(graph.WithValidationProfile(graph.ValidationProfileProd))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "UNREACHABLE_NODE")
+	assert.Contains(t, err.Error(), "MISSING_NODE_TIMEOUT")
+
+	prod := g.ValidateWithProfile(graph.ValidationProfileProd)
+	assert.False(t, prod.Valid)
+	for _, w := range prod.Warnings {
+		assert.NotEqual(t, "UNREACHABLE_NODE", w.Code)
+	}
+}
+
+// TestStreamDraftAggregation tests streaming chunks aggregated into a state draft
+// TestStreamDraftAggregation 测试流式分片聚合到状态草稿
+func TestStreamDraftAggregation(t *testing.T) {
+	writer := graph.NewNode("writer").
+		WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			stream := graph.DraftStreamingFunc(state, "answer")
+			for _, chunk := range []string{"你", "好", "!"} {
+				if err := stream(ctx, []byte(chunk)); err != nil {
+					return nil, err
+				}
+			}
+			state.FinalizeDraft("answer")
+			return state, nil
+		}).
+		Build()
+
+	g := graph.NewGraph("draft_graph").
+		AddNodes(writer, graph.NewNode("END").WithType(graph.NodeTypeEnd).Build()).
+		AddEdge(graph.AlwaysEdge("writer_end", "writer", "END")).
+		SetEntryPoint("writer").
+		Build()
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	results, err := runnable.Stream(context.Background(), graph.NewState("draft_state"))
+	require.NoError(t, err)
+
+	var drafts []string
+	var final *graph.State
+	for result := range results {
+		switch result.Type {
+		case graph.StreamResultTypeDraft:
+			assert.Equal(t, "writer", result.NodeID)
+			drafts = append(drafts, result.Metadata["draft"].(string))
+		case graph.StreamResultTypeFinal:
+			final = result.State
+		case graph.StreamResultTypeError:
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+	}
+
+	assert.Equal(t, []string{"你", "你好", "你好!"}, drafts)
+	require.NotNil(t, final)
+	answer, exists := final.GetVariable("answer")
+	assert.True(t, exists)
+	assert.Equal(t, "你好!", answer)
+	_, exists = final.GetDraft("answer")
+	assert.False(t, exists)
+}
+
+// TestStreamIntermediateResults tests the per-node results of Stream
+// TestStreamIntermediateResults 测试 Stream 在每个节点后发送的结果
+func TestStreamIntermediateResults(t *testing.T) {
+	g, err := graph.NewGraph("steps").
+		AddNode(graph.NewNode("fetch").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			state.SetVariable("records", []string{"b", "a"})
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "fetched"))
+			return state, nil
+		}).Build()).
+		AddNode(graph.NewNode("rank").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			state.SetVariable("records", []string{"a", "b"})
+			delete(state.Variables, "cursor")
+			state.SetMetadata("ranked", true)
+			return state, nil
+		}).Build()).
+		Connect("fetch", "rank").
+		Connect("rank", "END").
+		SetEntryPoint("fetch").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	state := graph.NewState("stream")
+	state.SetVariable("cursor", 10)
+	results, err := runnable.Stream(context.Background(), state)
+	require.NoError(t, err)
+
+	var steps []*graph.StreamResult
+	var final *graph.State
+	for result := range results {
+		switch result.Type {
+		case graph.StreamResultTypeIntermediate:
+			steps = append(steps, result)
+		case graph.StreamResultTypeFinal:
+			final = result.State
+		case graph.StreamResultTypeError:
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+	}
+	require.NotNil(t, final)
+	require.Len(t, steps, 2)
+
+	fetch := steps[0]
+	assert.Equal(t, "fetch", fetch.NodeID)
+	assert.Equal(t, 1, fetch.Metadata["step"])
+	assert.Equal(t, map[string]interface{}{"records": []string{"b", "a"}}, fetch.Diff.Variables)
+	require.Len(t, fetch.Diff.Messages, 1)
+	assert.False(t, fetch.Diff.MessagesReset)
+	// The snapshot is not affected by later nodes
+	records, _ := fetch.State.GetVariable("records")
+	assert.Equal(t, []string{"b", "a"}, records)
+	_, hasCursor := fetch.State.GetVariable("cursor")
+	assert.True(t, hasCursor)
+
+	rank := steps[1]
+	assert.Equal(t, "rank", rank.NodeID)
+	assert.Equal(t, map[string]interface{}{"records": []string{"a", "b"}}, rank.Diff.Variables)
+	assert.Equal(t, []string{"cursor"}, rank.Diff.RemovedVariables)
+	assert.Equal(t, true, rank.Diff.Metadata["ranked"])
+	assert.Empty(t, rank.Diff.Messages)
+
+	// A state compared with itself has no changes
+	assert.True(t, graph.DiffStates(final, final).IsEmpty())
+}
+
+// TestInvokeDetailed tests the structured execution result
+// TestInvokeDetailed 测试结构化执行结果
+func TestInvokeDetailed(t *testing.T) {
+	llmNode := graph.NewNode("llm").
+		WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			graph.RecordUsage(ctx, graph.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, Cost: 0.01})
+			return state, nil
+		}).
+		Build()
+
+	flaky := graph.NewNode("flaky").
+		WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			return nil, assert.AnError
+		}).
+		WithFailureMode(graph.FailureModeSkip).
+		Build()
+
+	g := graph.NewGraph("detailed_graph").
+		AddNodes(llmNode, flaky, graph.NewNode("END").WithType(graph.NodeTypeEnd).Build()).
+		AddEdges(graph.AlwaysEdge("e1", "llm", "flaky"), graph.AlwaysEdge("e2", "flaky", "END")).
+		SetEntryPoint("llm").
+		Build()
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	result, err := runnable.InvokeDetailed(context.Background(), graph.NewState("detailed"))
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"llm", "flaky"}, result.Path)
+	assert.Equal(t, 2, result.NodesExecuted)
+	assert.Contains(t, result.NodeDurations, "llm")
+	assert.Equal(t, 15, result.Usage.TotalTokens)
+	assert.Equal(t, 10, result.NodeUsage["llm"].PromptTokens)
+	assert.NotEmpty(t, result.Trace)
+	require.NotEmpty(t, result.Warnings)
+	assert.Contains(t, result.Warnings[0], "flaky")
+}
+
+func TestEdgeDataMapping(t *testing.T) {
+	answer := graph.NewNode("answer").
+		WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			state.SetVariable("answer", "42")
+			state.SetVariable("scratch", "tmp")
+			return state, nil
+		}).
+		Build()
+
+	var received interface{}
+	consume := graph.NewNode("consume").
+		WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			received, _ = state.GetVariable("input")
+			_, hasScratch := state.GetVariable("scratch")
+			assert.False(t, hasScratch)
+			return state, nil
+		}).
+		Build()
+
+	edge := graph.NewEdge("answer_to_consume", "answer", "consume").
+		WithMapping("vars.answer", "vars.input").
+		WithMapping("answer", "meta.source_answer").
+		WithDrop("vars.scratch").
+		Build()
+	require.NoError(t, edge.Validate())
+
+	g := graph.NewGraph("mapping_graph").
+		AddNodes(answer, consume, graph.NewNode("END").WithType(graph.NodeTypeEnd).Build()).
+		AddEdges(edge, graph.AlwaysEdge("e2", "consume", "END")).
+		SetEntryPoint("answer").
+		Build()
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	result, err := runnable.Invoke(context.Background(), graph.NewState("mapping"))
+	require.NoError(t, err)
+	assert.Equal(t, "42", received)
+
+	source, exists := result.GetMetadata("source_answer")
+	assert.True(t, exists)
+	assert.Equal(t, "42", source)
+
+	assert.Error(t, graph.NewEdge("bad", "a", "b").WithDrop("").Build().Validate())
+}
+
+func TestGraphBuilderBuildE(t *testing.T) {
+	fn := func(ctx context.Context, state *graph.State) (*graph.State, error) { return state, nil }
+
+	g, err := graph.NewGraph("valid").
+		AddNodes(graph.NewNode("a").WithFunction(fn).Build()).
+		Connect("a", "END").
+		SetEntryPoint("a").
+		BuildE()
+	require.NoError(t, err)
+	assert.NotNil(t, g)
+
+	_, err = graph.NewGraph("invalid").
+		AddNode(nil).
+		AddNode(graph.NewNode("a").WithFunction(fn).Build()).
+		AddNode(graph.NewNode("a").WithFunction(fn).Build()).
+		AddNode(graph.NewNode("b").Build()).
+		Connect("a", "missing").
+		Connect("a", "missing").
+		SetEntryPoint("start").
+		BuildE()
+	require.Error(t, err)
+	for _, want := range []string{
+		"node cannot be nil",
+		"duplicate node ID: a",
+		"function node b must have a function",
+		"duplicate edge ID: a_to_missing",
+		"unknown destination node: missing",
+		"entry point node not found: start",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+// echoTranslator is a fake model that tags the text with the requested language.
+type echoTranslator struct{}
+
+func (echoTranslator) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	prompt := messages[0].Parts[0].(llms.TextContent).Text
+	header, text, _ := strings.Cut(prompt, "\n\n")
+	language := strings.TrimSuffix(strings.TrimPrefix(header, "Translate the following text into "), ". Output only the translation.")
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:        "[" + language + "] " + text,
+		GenerationInfo: map[string]interface{}{"PromptTokens": 3, "CompletionTokens": 2, "TotalTokens": 5},
+	}}}, nil
+}
+
+func (t echoTranslator) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, t, prompt, options...)
+}
+
+func TestLanguageNormalization(t *testing.T) {
+	assert.Equal(t, "zh", graph.DetectLanguage("你好，请介绍一下 LangChain"))
+	assert.Equal(t, "en", graph.DetectLanguage("hello world"))
+	assert.Equal(t, "ja", graph.DetectLanguage("こんにちは"))
+	assert.Equal(t, "", graph.DetectLanguage("123"))
+
+	config := graph.LanguageNormalizerConfig{PivotLanguage: "en", InboundModel: echoTranslator{}}
+	answer := graph.NewNode("answer").
+		WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			input, _ := state.GetVariable("input")
+			state.SetVariable("output", "answer to "+input.(string))
+			return state, nil
+		}).
+		Build()
+
+	g := graph.NewGraph("language_graph").
+		AddNodes(graph.NormalizeLanguageNode("normalize", config), answer, graph.RestoreLanguageNode("restore", config)).
+		Connect("normalize", "answer").
+		Connect("answer", "restore").
+		Connect("restore", "END").
+		SetEntryPoint("normalize").
+		Build()
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	state := graph.NewState("language")
+	state.SetVariable("input", "你好")
+	result, err := runnable.InvokeDetailed(context.Background(), state)
+	require.NoError(t, err)
+
+	output, _ := result.State.GetVariable("output")
+	assert.Equal(t, "[Simplified Chinese] answer to [English] 你好", output)
+	language, _ := result.State.GetVariable(graph.DetectedLanguageVariable)
+	assert.Equal(t, "zh", language)
+	assert.Equal(t, 5, result.NodeUsage["normalize"].TotalTokens)
+	assert.Equal(t, 5, result.NodeUsage["restore"].TotalTokens)
+	assert.Equal(t, 10, result.Usage.TotalTokens)
+}
+
+func TestBudgetRouting(t *testing.T) {
+	spend := func(tokens int) graph.NodeFunction {
+		return func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			graph.RecordUsage(ctx, graph.Usage{TotalTokens: tokens})
+			state.SetVariable("last", graph.NodeIDFromContext(ctx))
+			return state, nil
+		}
+	}
+
+	build := func(maxTokens int) *graph.Runnable {
+		g := graph.NewGraph("budget_graph").
+			WithMiddleware(graph.NewBudgetMiddleware(maxTokens, 0)).
+			AddNodes(
+				graph.NewNode("plan").WithFunction(spend(80)).Build(),
+				graph.NewNode("expensive").WithFunction(spend(10)).Build(),
+				graph.NewNode("cheap").WithFunction(spend(1)).Build(),
+			).
+			AddEdges(
+				graph.NewEdge("to_expensive", "plan", "expensive").WithCondition(graph.IfBudgetAbove(50)).Build(),
+				graph.NewEdge("to_cheap", "plan", "cheap").WithCondition(graph.IfBudgetBelow(50)).Build(),
+			).
+			Connect("expensive", "END").
+			Connect("cheap", "END").
+			SetEntryPoint("plan").
+			Build()
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+
+	result, err := build(100).Invoke(context.Background(), graph.NewState("low_budget"))
+	require.NoError(t, err)
+	last, _ := result.GetVariable("last")
+	assert.Equal(t, "cheap", last)
+	budget, ok := graph.RemainingBudget(result)
+	require.True(t, ok)
+	assert.Equal(t, 19, budget.RemainingTokens())
+
+	result, err = build(1000).Invoke(context.Background(), graph.NewState("high_budget"))
+	require.NoError(t, err)
+	last, _ = result.GetVariable("last")
+	assert.Equal(t, "expensive", last)
+
+	_, err = build(80).Invoke(context.Background(), graph.NewState("exhausted"))
+	assert.ErrorIs(t, err, graph.ErrBudgetExceeded)
+}
+
+// linearGraph builds a graph of n chained nodes that each increment a counter.
+func linearGraph(n int) *graph.Graph {
+	builder := graph.NewGraph("linear_graph")
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("n%d", i)
+		builder.AddNode(graph.NewNode(id).
+			WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				count, _ := state.GetVariable("count")
+				c, _ := count.(int)
+				state.SetVariable("count", c+1)
+				return state, nil
+			}).
+			Build())
+		if i > 0 {
+			builder.Connect(fmt.Sprintf("n%d", i-1), id)
+		}
+	}
+	return builder.Connect(fmt.Sprintf("n%d", n-1), "END").SetEntryPoint("n0").Build()
+}
+
+func TestCompileOptimized(t *testing.T) {
+	runnable, err := linearGraph(5).CompileOptimized()
+	require.NoError(t, err)
+	assert.True(t, runnable.IsOptimized())
+
+	result, err := runnable.InvokeDetailed(context.Background(), graph.NewState("optimized"))
+	require.NoError(t, err)
+	count, _ := result.State.GetVariable("count")
+	assert.Equal(t, 5, count)
+	assert.Equal(t, []string{"n0", "n1", "n2", "n3", "n4"}, result.Path)
+
+	// Graphs with conditional routing fall back to the general executor
+	g := graph.NewGraph("branching").
+		AddNodes(linearGraph(1).GetNodes()["n0"]).
+		ConnectWithCondition("n0", "END", func(ctx context.Context, state *graph.State) (bool, error) { return true, nil }).
+		SetEntryPoint("n0").
+		Build()
+	runnable, err = g.CompileOptimized()
+	require.NoError(t, err)
+	assert.False(t, runnable.IsOptimized())
+}
+
+func BenchmarkInvokeLinear(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		compile func(g *graph.Graph) (*graph.Runnable, error)
+	}{
+		{"general", func(g *graph.Graph) (*graph.Runnable, error) { return g.Compile() }},
+		{"optimized", func(g *graph.Graph) (*graph.Runnable, error) { return g.CompileOptimized() }},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			runnable, err := bm.compile(linearGraph(20))
+			require.NoError(b, err)
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := runnable.Invoke(ctx, graph.NewState("bench")); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestSharedCounters tests rate limit and circuit breaker state shared through Redis
+// TestSharedCounters 测试通过Redis共享的限流与断路器状态
+func TestSharedCounters(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	store := graph.NewRedisCounterStore(client, "test:")
+	ctx := context.Background()
+	next := func(ctx context.Context, state *graph.State) (*graph.State, error) { return state, nil }
+
+	// Two replicas share one bucket of two tokens
+	replicaA := graph.NewRateLimitMiddleware(0.001, 2).WithStore(store, "llm")
+	replicaB := graph.NewRateLimitMiddleware(0.001, 2).WithStore(store, "llm")
+	_, err := replicaA.Process(ctx, next, graph.NewState("a"))
+	require.NoError(t, err)
+	_, err = replicaB.Process(ctx, next, graph.NewState("b"))
+	require.NoError(t, err)
+	_, err = replicaA.Process(ctx, next, graph.NewState("a"))
+	assert.Error(t, err)
+
+	// A failure seen by one replica opens the breaker for the other
+	failing := func(ctx context.Context, state *graph.State) (*graph.State, error) { return nil, fmt.Errorf("boom") }
+	breakerA := graph.NewCircuitBreakerMiddleware(1, time.Hour).WithStore(store, "llm")
+	breakerB := graph.NewCircuitBreakerMiddleware(1, time.Hour).WithStore(store, "llm")
+	_, err = breakerA.Process(ctx, failing, graph.NewState("a"))
+	require.Error(t, err)
+	assert.Equal(t, graph.CircuitStateOpen, breakerA.GetState())
+	_, err = breakerB.Process(ctx, next, graph.NewState("b"))
+	assert.EqualError(t, err, "circuit breaker is open")
+
+	// Falls back to the local bucket when the store is unreachable
+	mr.Close()
+	local := graph.NewRateLimitMiddleware(0.001, 1).WithStore(store, "llm")
+	_, err = local.Process(ctx, next, graph.NewState("c"))
+	require.NoError(t, err)
+	_, err = local.Process(ctx, next, graph.NewState("c"))
+	assert.Error(t, err)
+}
+
+func TestExprNode(t *testing.T) {
+	vars := map[string]interface{}{
+		"price":    19.5,
+		"quantity": 3,
+		"order":    map[string]interface{}{"discount": 0.1, "vip": true},
+		"name":     "张三",
+	}
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"15 + 27", 42.0},
+		{"2 + 3 * 4 - 10 / 5", 12.0},
+		{"(2 + 3) * 4 % 7", 6.0},
+		{"2 ** 3 ** 2", 512.0},
+		{"-2 ** 2", -4.0},
+		{"round(price * quantity * (1 - order.discount), 2)", 52.65},
+		{"max(1, quantity, 2) + min(4, abs(-5))", 7.0},
+		{"order.vip && quantity >= 3 ? 'gold' : 'normal'", "gold"},
+		{"!order.vip || len(name) == 2", true},
+		{`"hello " + name`, "hello 张三"},
+	}
+	for _, tt := range tests {
+		got, err := graph.EvalExpr(tt.expr, vars)
+		require.NoError(t, err, tt.expr)
+		if f, ok := tt.want.(float64); ok {
+			assert.InDelta(t, f, got, 1e-9, tt.expr)
+		} else {
+			assert.Equal(t, tt.want, got, tt.expr)
+		}
+	}
+
+	// Undefined variables are errors rather than null
+	_, err := graph.EvalExpr("missing == null", vars)
+	assert.ErrorContains(t, err, `undefined variable "missing"`)
+
+	for _, bad := range []string{"1 +", "os.Exit(1)", "exec('ls')", "(1", "1 / 0", "'a' - 1", "1 ? 2 : 3", strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100)} {
+		_, err = graph.EvalExpr(bad, vars)
+		assert.Error(t, err, bad)
+	}
+
+	node := graph.ExprNode("total", "price * quantity", "total")
+	state := graph.NewState("expr")
+	state.SetVariable("price", 2.5)
+	state.SetVariable("quantity", 4)
+	result, err := node.Execute(context.Background(), state)
+	require.NoError(t, err)
+	total, _ := result.GetVariable("total")
+	assert.Equal(t, 10.0, total)
+
+	_, err = graph.ExprNode("broken", "price *", "total").Execute(context.Background(), graph.NewState("expr"))
+	assert.ErrorContains(t, err, "invalid expression")
+}
+
+func TestBatchNode(t *testing.T) {
+	ctx := context.Background()
+	batcher := graph.NewBatchNode("batch", graph.BatchConfig{
+		Size:      3,
+		InputKey:  "doc",
+		OutputKey: "docs",
+		FlushKey:  "last",
+	})
+	node := batcher.Node()
+	ready := batcher.Ready()
+
+	run := func(doc interface{}, last bool) *graph.State {
+		state := graph.NewState("batch")
+		state.SetVariable("doc", doc)
+		state.SetVariable("last", last)
+		result, err := node.Execute(ctx, state)
+		require.NoError(t, err)
+		return result
+	}
+
+	// Items accumulate until the batch is full
+	for _, doc := range []string{"a", "b"} {
+		state := run(doc, false)
+		ok, err := ready(ctx, state)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	state := run("c", false)
+	ok, _ := ready(ctx, state)
+	assert.True(t, ok)
+	docs, _ := state.GetVariable("docs")
+	assert.Equal(t, []interface{}{"a", "b", "c"}, docs)
+	assert.Equal(t, 0, batcher.Pending())
+
+	// The flush key releases a partial batch on the last iteration
+	run("d", false)
+	state = run([]interface{}{"e"}, true)
+	docs, _ = state.GetVariable("docs")
+	assert.Equal(t, []interface{}{"d", "e"}, docs)
+
+	// The flush node emits leftovers before the graph ends
+	run("f", false)
+	state, err := batcher.FlushNode("flush").Execute(ctx, graph.NewState("batch"))
+	require.NoError(t, err)
+	docs, _ = state.GetVariable("docs")
+	assert.Equal(t, []interface{}{"f"}, docs)
+
+	// Time window
+	windowed := graph.NewBatchNode("window", graph.BatchConfig{Window: 20 * time.Millisecond})
+	assert.Nil(t, windowed.Add(1))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, []interface{}{1, 2}, windowed.Add(2))
+}
+
+func TestGraphInputSchema(t *testing.T) {
+	g, err := graph.NewGraph("shared").
+		WithInputSchema(
+			graph.ParameterDef{Name: "query", Type: graph.ParameterTypeString, Required: true, Description: "user question"},
+			graph.ParameterDef{Name: "top_k", Type: graph.ParameterTypeInteger, Default: 5},
+		).
+		AddNodes(graph.VariableSetterNode("set", "done", true)).
+		Connect("set", "END").
+		SetEntryPoint("set").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// Defaults are filled in for missing optional inputs
+	state := graph.NewState("ok")
+	state.SetVariable("query", "hello")
+	result, err := runnable.Invoke(context.Background(), state)
+	require.NoError(t, err)
+	topK, _ := result.GetVariable("top_k")
+	assert.Equal(t, 5, topK)
+
+	// Missing and mistyped inputs are reported together
+	state = graph.NewState("bad")
+	state.SetVariable("top_k", 2.5)
+	_, err = runnable.Invoke(context.Background(), state)
+	require.ErrorIs(t, err, graph.ErrInvalidInput)
+	assert.Contains(t, err.Error(), `missing required variable "query"`)
+	assert.Contains(t, err.Error(), `variable "top_k" must be integer, got float64`)
+	_, executed := state.GetVariable("done")
+	assert.False(t, executed)
+
+	// The schema is part of the exported definition
+	data, err := json.Marshal(g)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"input_schema":[{"name":"query","type":"string","required":true`)
+
+	_, err = graph.NewGraph("invalid").
+		WithInputSchema(graph.ParameterDef{Name: "n", Type: "decimal"}).
+		AddNodes(graph.VariableSetterNode("set", "done", true)).
+		SetEntryPoint("set").
+		BuildE()
+	assert.ErrorContains(t, err, "unsupported type: decimal")
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	failing := graph.NewNode("llm").
+		WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			return nil, fmt.Errorf("API returned unexpected status code: 429: rate limited")
+		}).
+		Build()
+	g := graph.NewGraph("localized").
+		AddNodes(failing).
+		Connect("llm", "END").
+		SetEntryPoint("llm").
+		Build()
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// English stays the default and keeps the original message
+	_, err = runnable.Invoke(context.Background(), graph.NewState("en"))
+	require.Error(t, err)
+	assert.Equal(t, "node llm failed: API returned unexpected status code: 429: rate limited", err.Error())
+	assert.Equal(t, graph.ErrCodeNodeFailed, graph.ErrorCode(err))
+
+	_, err = runnable.InvokeWithOptions(context.Background(), graph.NewState("zh"), graph.WithLocale("zh"))
+	require.Error(t, err)
+	assert.Equal(t, "节点 llm 执行失败: 服务商限流，请稍后重试 (HTTP 429): API returned unexpected status code: 429: rate limited", err.Error())
+
+	var graphErr *graph.GraphError
+	require.ErrorAs(t, err, &graphErr)
+	assert.Equal(t, "llm", graphErr.NodeID)
+	assert.Equal(t, graph.ErrCodeProviderRateLimit, graph.ErrorCode(graphErr.Cause))
+	assert.True(t, strings.HasPrefix(graph.RenderError(err, graph.LocaleBilingual), "[NODE_FAILED] 节点 llm 执行失败"))
+	assert.Contains(t, graph.RenderError(err, graph.LocaleBilingual), " | node llm failed")
+
+	// Validation errors honour the graph's default locale
+	_, err = graph.NewGraph("broken").WithLocale(graph.LocaleChinese).Build().Compile()
+	require.Error(t, err)
+	assert.Equal(t, "图验证失败: [NO_ENTRY_POINT] 图未设置入口节点", err.Error())
+	assert.Equal(t, graph.ErrCodeValidationFailed, graph.ErrorCode(err))
+
+	// Context errors keep matching the standard sentinels
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = runnable.InvokeWithOptions(ctx, graph.NewState("canceled"), graph.WithLocale(graph.LocaleChinese))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "执行已取消: context canceled", err.Error())
+}
+
+// TestWebhookApproval tests pausing at an approval node until a signed callback arrives
+// TestWebhookApproval 测试在审批节点暂停直到收到签名回调
+func TestWebhookApproval(t *testing.T) {
+	const secret = "s3cret"
+
+	var approver *graph.WebhookApprover
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		approver.ServeHTTP(w, r)
+	}))
+	defer callback.Close()
+
+	// The external approval system checks the signature and replies asynchronously
+	reply := func(decision graph.ApprovalDecision) int {
+		body, _ := json.Marshal(decision)
+		req, _ := http.NewRequest(http.MethodPost, callback.URL, bytes.NewReader(body))
+		for key, values := range graph.SignApprovalPayload(secret, body, time.Now()) {
+			req.Header[key] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := graph.SignApprovalPayload(secret, body, time.Now()).Get(graph.ApprovalSignatureHeader)
+		assert.Equal(t, expected, r.Header.Get(graph.ApprovalSignatureHeader))
+
+		var request graph.ApprovalRequest
+		require.NoError(t, json.Unmarshal(body, &request))
+		assert.Equal(t, "review", request.NodeID)
+		assert.Equal(t, "refund $500", request.Variables["summary"])
+		assert.Equal(t, callback.URL, request.CallbackURL)
+		w.WriteHeader(http.StatusAccepted)
+
+		go reply(graph.ApprovalDecision{ID: request.ID, Approved: request.StateID == "approved", Approver: "alice", Comment: "ok"})
+	}))
+	defer webhook.Close()
+
+	approver = graph.NewWebhookApprover(graph.WebhookApprovalConfig{
+		URL:         webhook.URL,
+		Secret:      secret,
+		CallbackURL: callback.URL,
+		Timeout:     5 * time.Second,
+		Variables:   []string{"summary"},
+	})
+	runnable, err := graph.NewGraph("approval").
+		AddNodes(approver.Node("review", "Approve the refund?"), graph.VariableSetterNode("refund", "refunded", true)).
+		Connect("review", "refund").
+		Connect("refund", "END").
+		SetEntryPoint("review").
+		Build().
+		Compile()
+	require.NoError(t, err)
+
+	state := graph.NewState("approved")
+	state.SetVariable("summary", "refund $500")
+	result, err := runnable.Invoke(context.Background(), state)
+	require.NoError(t, err)
+	approved, _ := result.GetVariable("review_approved")
+	approverName, _ := result.GetVariable("review_approver")
+	refunded, _ := result.GetVariable("refunded")
+	assert.Equal(t, true, approved)
+	assert.Equal(t, "alice", approverName)
+	assert.Equal(t, true, refunded)
+	assert.Empty(t, approver.Pending())
+
+	// Rejection cancels the execution
+	state = graph.NewState("rejected")
+	state.SetVariable("summary", "refund $500")
+	_, err = runnable.Invoke(context.Background(), state)
+	require.ErrorIs(t, err, graph.ErrApprovalRejected)
+	_, executed := state.GetVariable("refunded")
+	assert.False(t, executed)
+
+	// Unsigned or unknown callbacks are refused
+	resp, err := http.Post(callback.URL, "application/json", strings.NewReader(`{"id":"x","approved":true}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, http.StatusNotFound, reply(graph.ApprovalDecision{ID: "unknown", Approved: true}))
+}
+
+// TestStateGC tests reclaiming expired states and checkpoints
+// TestStateGC 测试回收过期的状态和检查点
+func TestStateGC(t *testing.T) {
+	ctx := context.Background()
+	manager := graph.NewMemoryStateManager(100)
+	now := time.Now()
+
+	save := func(id, graphID string, age time.Duration) {
+		state := graph.NewState(id)
+		// SetMetadata touches UpdatedAt, so backdate the timestamps afterwards
+		state.SetMetadata(graph.StateGraphIDKey, graphID)
+		state.CreatedAt = now.Add(-age)
+		state.UpdatedAt = now.Add(-age)
+		require.NoError(t, manager.Save(ctx, state))
+	}
+	save("expired", "chat", 2*time.Hour)
+	save("chat-1", "chat", 3*time.Minute)
+	save("chat-2", "chat", 2*time.Minute)
+	save("chat-3", "chat", time.Minute)
+	save("other", "search", time.Minute)
+
+	checkpoints := graph.NewCheckpointManager(manager, time.Minute, 10)
+	for _, id := range []string{"expired", "chat-3", "chat-3", "chat-3"} {
+		state, err := manager.Load(ctx, id)
+		require.NoError(t, err)
+		require.NoError(t, checkpoints.CreateCheckpoint(ctx, state))
+	}
+
+	gc := graph.NewStateGC(manager, graph.StateGCPolicy{TTL: time.Hour, MaxPerGraph: 2, MaxCheckpoints: 2})
+	result, err := gc.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 9, result.Scanned)
+	assert.Equal(t, 2, result.ReclaimedStates)
+	assert.Equal(t, 2, result.ReclaimedCheckpoints)
+
+	ids, err := manager.ListStates()
+	require.NoError(t, err)
+	var remaining []string
+	for _, id := range ids {
+		if stateID, _, ok := graph.ParseCheckpointID(id); ok {
+			assert.Equal(t, "chat-3", stateID)
+			continue
+		}
+		remaining = append(remaining, id)
+	}
+	assert.ElementsMatch(t, []string{"chat-2", "chat-3", "other"}, remaining)
+	assert.Len(t, ids, 5)
+
+	// Idle states are reclaimed by last access time
+	gc = graph.NewStateGC(manager, graph.StateGCPolicy{MaxIdle: time.Millisecond, Interval: 10 * time.Millisecond})
+	gc.Start(ctx)
+	require.Eventually(t, func() bool {
+		ids, _ := manager.ListStates()
+		return len(ids) == 0
+	}, time.Second, 10*time.Millisecond)
+	gc.Stop()
+
+	stats := gc.Stats()
+	assert.GreaterOrEqual(t, stats.Runs, int64(1))
+	assert.Equal(t, int64(3), stats.ReclaimedStates)
+	assert.Equal(t, int64(2), stats.ReclaimedCheckpoints)
+}
+
+// TestGenerateDocs tests rendering Markdown documentation from a graph definition
+// TestGenerateDocs 测试根据图定义生成 Markdown 文档
+func TestGenerateDocs(t *testing.T) {
+	noop := func(ctx context.Context, state *graph.State) (*graph.State, error) { return state, nil }
+	g, err := graph.NewGraph("support").
+		WithName("Support Flow").
+		WithDescription("Routes customer questions.").
+		WithVersion("1.2").
+		WithInputSchema(graph.ParameterDef{Name: "question", Type: graph.ParameterTypeString, Required: true, Description: "customer question"}).
+		AddNodes(
+			graph.NewNode("classify").WithName("Classify").WithDescription("Detects the intent.").
+				WithFunction(noop).WithTimeout(10*time.Second).WithTags("llm", "routing").
+				WithInput("question", "string", true).WithOutput("intent", "string", "detected intent").Build(),
+			graph.NewNode("refund").WithFunction(noop).WithRetries(2, time.Second).Build(),
+			graph.NewNode("answer").WithFunction(noop).Build(),
+		).
+		AddEdges(
+			graph.NewEdge("to_refund", "classify", "refund").
+				WithCondition(func(ctx context.Context, state *graph.State) (bool, error) { return true, nil }).
+				WithMetadata("condition", `intent == "refund"`).WithPriority(10).Build(),
+			graph.NewEdge("to_answer", "classify", "answer").AsDefault().WithDescription("Everything else").Build(),
+		).
+		Connect("refund", "END").
+		Connect("answer", "END").
+		SetEntryPoint("classify").
+		BuildE()
+	require.NoError(t, err)
+
+	docs := graph.GenerateDocs(g)
+	assert.True(t, strings.HasPrefix(docs, "# Support Flow\n\nRoutes customer questions.\n"))
+	assert.Contains(t, docs, "| Entry point | `classify` |")
+	assert.Contains(t, docs, "## Inputs\n\n| Name | Type | Required | Default | Description |")
+	assert.Contains(t, docs, "| `question` | string | yes | - | customer question |")
+	assert.Contains(t, docs, "### Classify (`classify`)\n\nDetects the intent.\n")
+	assert.Contains(t, docs, "| Timeout | 10s |")
+	assert.Contains(t, docs, "| Tags | llm, routing |")
+	assert.Contains(t, docs, "**Outputs**")
+	assert.Contains(t, docs, "| Retries | 2 (delay 1s) |")
+	assert.Contains(t, docs, "| `classify` | `refund` | conditional | intent == \"refund\" | 10 | - |")
+	assert.Contains(t, docs, "| `classify` | `answer` | default | - | -1 | Everything else |")
+
+	// Embedded Mermaid diagram
+	assert.Contains(t, docs, "```mermaid\nflowchart TD\n")
+	assert.Contains(t, docs, "    START --> n_classify\n")
+	assert.Contains(t, docs, `    n_classify -.->|"intent == #quot;refund#quot; (priority 10)"| n_refund`)
+	assert.Contains(t, docs, `    n_classify -.->|"default"| n_answer`)
+	assert.Contains(t, docs, "    n_refund --> END\n")
+}
+
+// TestGraphVisualization tests exporting a graph as Mermaid and DOT diagrams
+// TestGraphVisualization 测试将图导出为 Mermaid 和 DOT 图表
+func TestGraphVisualization(t *testing.T) {
+	noop := func(ctx context.Context, state *graph.State) (*graph.State, error) { return state, nil }
+	g, err := graph.NewGraph("triage").
+		WithName("Triage \"v2\"").
+		AddNodes(
+			graph.NewNode("classify").WithName("Classify").WithFunction(noop).Build(),
+			graph.NewNode("urgent").WithFunction(noop).Build(),
+			graph.NewNode("review").WithHumanInput("Approve?", "decision").Build(),
+			graph.NewNode("answer").WithFunction(noop).Build(),
+		).
+		AddEdges(
+			graph.NewEdge("to_urgent", "classify", "urgent").
+				WithExpression("variables.level > 3").WithPriority(5).Build(),
+			graph.NewEdge("to_review", "classify", "review").WithPriority(2).Build(),
+			graph.NewEdge("to_answer", "classify", "answer").AsDefault().Build(),
+		).
+		Connect("urgent", "END").
+		Connect("review", "END").
+		Connect("answer", "END").
+		SetEntryPoint("classify").
+		BuildE()
+	require.NoError(t, err)
+
+	mermaid := g.ExportMermaid()
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart TD\n    START([Start])\n"))
+	assert.Contains(t, mermaid, `    n_classify["Classify"]`)
+	assert.Contains(t, mermaid, `    n_review[/"review"\]`)
+	assert.Contains(t, mermaid, "    START --> n_classify\n")
+	assert.Contains(t, mermaid, `    n_classify -.->|"variables.level > 3 (priority 5)"| n_urgent`)
+	assert.Contains(t, mermaid, `    n_classify -->|"priority 2"| n_review`)
+	assert.Contains(t, mermaid, `    n_classify -.->|"default"| n_answer`)
+	assert.Contains(t, mermaid, "    n_answer --> END\n")
+
+	dot := g.ExportDOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph \"triage\" {\n"))
+	assert.True(t, strings.HasSuffix(dot, "}\n"))
+	assert.Contains(t, dot, `    label="Triage \"v2\"";`)
+	assert.Contains(t, dot, `    "classify" [label="Classify"];`)
+	assert.Contains(t, dot, `    "review" [label="review", shape=trapezium, style=solid];`)
+	assert.Contains(t, dot, `    "__start__" -> "classify";`)
+	assert.Contains(t, dot, `    "classify" -> "urgent" [label="variables.level > 3 (priority 5)", style=dashed];`)
+	assert.Contains(t, dot, `    "classify" -> "review" [label="priority 2"];`)
+	assert.Contains(t, dot, `    "classify" -> "answer" [label="default", style=dashed];`)
+	assert.Contains(t, dot, `    "answer" -> "END";`)
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestSetupNodes(t *testing.T) {
+	var setups, closed atomic.Int32
+	failFirst := true
+	g, err := graph.NewGraph("setup").
+		WithSetupNode(graph.NewNode("flaky").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			if failFirst {
+				failFirst = false
+				return nil, errors.New("index not ready")
+			}
+			return state, nil
+		}).Build()).
+		WithSetupNode(graph.NewNode("pool").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			setups.Add(1)
+			resources, ok := graph.ResourcesFromContext(ctx)
+			require.True(t, ok)
+			resources.Set("pool", closerFunc(func() error { closed.Add(1); return nil }))
+			resources.Set("index", map[string]string{"go": "golang"})
+			return state, nil
+		}).Build()).
+		AddNode(graph.NewNode("lookup").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			index, ok := graph.ResourceFromContext(ctx, "index")
+			if !ok {
+				return nil, errors.New("index missing")
+			}
+			state.SetVariable("result", index.(map[string]string)["go"])
+			return state, nil
+		}).Build()).
+		Connect("lookup", "END").
+		SetEntryPoint("lookup").
+		BuildE()
+	require.NoError(t, err)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// A failed setup is retried by the next call
+	assert.ErrorContains(t, runnable.Setup(context.Background()), "setup node flaky failed")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := runnable.Invoke(context.Background(), graph.NewState("run"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, "golang", result.Variables["result"])
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), setups.Load())
+
+	require.NoError(t, runnable.Close())
+	require.NoError(t, runnable.Close())
+	assert.Equal(t, int32(1), closed.Load())
+
+	_, err = runnable.Invoke(context.Background(), graph.NewState("run"))
+	assert.ErrorIs(t, err, graph.ErrRunnableClosed)
+}
+
+func TestNodeMaxConcurrent(t *testing.T) {
+	var running, peak, cheap atomic.Int32
+	g, err := graph.NewGraph("throttled").
+		AddNode(graph.NewNode("cheap").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			cheap.Add(1)
+			return state, nil
+		}).Build()).
+		AddNode(graph.NewNode("gpu").WithMaxConcurrent(2).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				previous := peak.Load()
+				if current <= previous || peak.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return state, nil
+		}).Build()).
+		Connect("cheap", "gpu").
+		Connect("gpu", "END").
+		SetEntryPoint("cheap").
+		BuildE()
+	require.NoError(t, err)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	states := make([]*graph.State, 8)
+	for i := range states {
+		states[i] = graph.NewState(fmt.Sprintf("run-%d", i))
+	}
+	results, err := runnable.InvokeParallel(context.Background(), states)
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.NoError(t, result.Error)
+	}
+	assert.Equal(t, int32(2), peak.Load())
+	assert.Equal(t, int32(8), cheap.Load())
+
+	t.Run("waiting respects cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		blocked := make(chan struct{})
+		release := make(chan struct{})
+		g, err := graph.NewGraph("single").
+			AddNode(graph.NewNode("slow").WithMaxConcurrent(1).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				if state.ID == "holder" {
+					close(blocked)
+					<-release
+				}
+				return state, nil
+			}).Build()).
+			Connect("slow", "END").
+			SetEntryPoint("slow").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = runnable.Invoke(context.Background(), graph.NewState("holder"))
+		}()
+		<-blocked
+
+		_, err = runnable.Invoke(ctx, graph.NewState("waiter"))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		close(release)
+		<-done
+	})
+}
+
+func TestInvokeFrom(t *testing.T) {
+	var calls []string
+	record := func(id string) graph.NodeFunction {
+		return func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			calls = append(calls, id)
+			state.SetVariable(id, true)
+			return state, nil
+		}
+	}
+	g, err := graph.NewGraph("tail").
+		WithInputSchema(graph.ParameterDef{Name: "query", Type: graph.ParameterTypeString, Required: true}).
+		AddNode(graph.NewNode("fetch").WithFunction(record("fetch")).Build()).
+		AddNode(graph.NewNode("transform").WithInput("records", graph.ParameterTypeArray, true).WithFunction(record("transform")).Build()).
+		AddNode(graph.NewNode("store").WithFunction(record("store")).Build()).
+		Connect("fetch", "transform").
+		Connect("transform", "store").
+		Connect("store", "END").
+		SetEntryPoint("fetch").
+		BuildE()
+	require.NoError(t, err)
+
+	compilers := map[string]func() (*graph.Runnable, error){
+		"router":    func() (*graph.Runnable, error) { return g.Compile() },
+		"optimized": func() (*graph.Runnable, error) { return g.CompileOptimized() },
+	}
+	for name, compile := range compilers {
+		t.Run(name, func(t *testing.T) {
+			runnable, err := compile()
+			require.NoError(t, err)
+
+			// The graph's input schema is not required when re-running the tail
+			calls = nil
+			state := graph.NewState("retry")
+			state.SetVariable("records", []string{"fixed"})
+			result, err := runnable.InvokeFrom(context.Background(), state, "transform")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"transform", "store"}, calls)
+			_, fetched := result.GetVariable("fetch")
+			assert.False(t, fetched)
+
+			calls = nil
+			_, err = runnable.InvokeFrom(context.Background(), graph.NewState("missing"), "transform")
+			require.Error(t, err)
+			assert.ErrorIs(t, err, graph.ErrInvalidInput)
+			assert.Contains(t, err.Error(), "records")
+			assert.Empty(t, calls)
+
+			_, err = runnable.InvokeFrom(context.Background(), state, "unknown")
+			var graphErr *graph.GraphError
+			require.ErrorAs(t, err, &graphErr)
+			assert.Equal(t, graph.ErrCodeNodeNotFound, graphErr.Code)
+			assert.Empty(t, calls)
+		})
+	}
+}
+
+func TestDrain(t *testing.T) {
+	newRunnable := func(manager graph.StateManager, started chan<- struct{}, release <-chan struct{}) *graph.Runnable {
+		builder := graph.NewGraph("drain").
+			AddNode(graph.NewNode("first").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				state.SetVariable("first", "done")
+				return state, nil
+			}).Build()).
+			AddNode(graph.NewNode("slow").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				state.SetVariable("slow", "partial")
+				started <- struct{}{}
+				select {
+				case <-release:
+					return state, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}).Build()).
+			Connect("first", "slow").
+			Connect("slow", "END").
+			SetEntryPoint("first")
+		if manager != nil {
+			builder.WithStateManager(manager)
+		}
+		g, err := builder.BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+
+	t.Run("in-flight executions finish before the deadline", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		runnable := newRunnable(nil, started, release)
+
+		errs := make(chan error)
+		go func() {
+			_, err := runnable.Invoke(context.Background(), graph.NewState("run"))
+			errs <- err
+		}()
+		<-started
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+		}()
+		report, err := runnable.Drain(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.InFlight)
+		assert.Equal(t, 1, report.Completed)
+		assert.Empty(t, report.Checkpointed)
+		assert.NoError(t, <-errs)
+
+		_, err = runnable.Invoke(context.Background(), graph.NewState("late"))
+		assert.ErrorIs(t, err, graph.ErrRunnableDraining)
+	})
+
+	t.Run("running executions are checkpointed at the deadline", func(t *testing.T) {
+		manager := graph.NewMemoryStateManager(10)
+		started := make(chan struct{})
+		runnable := newRunnable(manager, started, nil)
+
+		errs := make(chan error)
+		go func() {
+			_, err := runnable.Invoke(context.Background(), graph.NewState("run"))
+			errs <- err
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		report, err := runnable.Drain(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.InFlight)
+		assert.Equal(t, 0, report.Completed)
+		require.Len(t, report.Checkpointed, 1)
+		drained := report.Checkpointed[0]
+		assert.True(t, drained.Persisted())
+		assert.Equal(t, "run", drained.StateID)
+		assert.Equal(t, "slow", drained.ResumeNode)
+		assert.Equal(t, 1, drained.Steps)
+		assert.ErrorIs(t, <-errs, graph.ErrExecutionDrained)
+
+		// The saved state is the one after the last completed node
+		saved, err := manager.Load(context.Background(), "run")
+		require.NoError(t, err)
+		assert.Equal(t, "done", saved.Variables["first"])
+		assert.NotContains(t, saved.Variables, "slow")
+		assert.Equal(t, "slow", saved.Metadata[graph.DrainResumeNodeKey])
+	})
+
+	t.Run("without a state manager the loss is reported", func(t *testing.T) {
+		started := make(chan struct{})
+		runnable := newRunnable(nil, started, nil)
+
+		errs := make(chan error)
+		go func() {
+			_, err := runnable.Invoke(context.Background(), graph.NewState("run"))
+			errs <- err
+		}()
+		<-started
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		report, err := runnable.Drain(ctx)
+		assert.ErrorContains(t, err, "no state manager configured")
+		require.Len(t, report.Checkpointed, 1)
+		assert.False(t, report.Checkpointed[0].Persisted())
+		assert.ErrorIs(t, <-errs, graph.ErrExecutionDrained)
+	})
+}
+
+// summarizer is a fake model that reports how many transcript lines it summarized.
+type summarizer struct{ calls atomic.Int32 }
+
+func (s *summarizer) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	s.calls.Add(1)
+	_, transcript, _ := strings.Cut(messages[0].Parts[0].(llms.TextContent).Text, "\n\n")
+	lines := strings.Count(strings.TrimSpace(transcript), "\n") + 1
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:        fmt.Sprintf("%d lines", lines),
+		GenerationInfo: map[string]interface{}{"TotalTokens": 7},
+	}}}, nil
+}
+
+func (s *summarizer) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, s, prompt, options...)
+}
+
+func TestSummarization(t *testing.T) {
+	model := &summarizer{}
+	policy := graph.NewSummarizationPolicy(model, 40).WithKeepRecent(2)
+
+	t.Run("compact messages outside graphs", func(t *testing.T) {
+		messages := []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeSystem, "你是一个助手"),
+			llms.TextParts(llms.ChatMessageTypeHuman, "第一个问题，关于Go语言的并发模型"),
+			llms.TextParts(llms.ChatMessageTypeAI, "Go uses goroutines and channels for concurrency"),
+			{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{ID: "1", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: `{}`}}}},
+			{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "1", Name: "search", Content: "结果"}}},
+			llms.TextParts(llms.ChatMessageTypeHuman, "谢谢"),
+		}
+
+		compacted, summarized, err := policy.Compact(context.Background(), messages)
+		require.NoError(t, err)
+		require.True(t, summarized)
+		require.Len(t, compacted, 5)
+		assert.Equal(t, messages[0], compacted[0])
+		assert.Equal(t, graph.SummaryPrefix+"2 lines", compacted[1].Parts[0].(llms.TextContent).Text)
+		// The tool result stays with its call
+		assert.Equal(t, messages[3:], compacted[2:])
+		assert.Len(t, messages, 6)
+
+		short, summarized, err := policy.Compact(context.Background(), messages[:2])
+		require.NoError(t, err)
+		assert.False(t, summarized)
+		assert.Equal(t, messages[:2], short)
+	})
+
+	t.Run("summarize after nodes and archive the full history", func(t *testing.T) {
+		archive := graph.NewMemoryStateManager(10)
+		turn := func(id string) *graph.Node {
+			return graph.NewNode(id).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "请详细解释一下"+id+"阶段需要完成的所有工作"))
+				state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "这个阶段需要完成很多工作，包括设计、实现和测试"))
+				return state, nil
+			}).Build()
+		}
+
+		g, err := graph.NewGraph("summarize").
+			AddNodes(turn("first"), turn("second"), turn("third")).
+			Connect("first", "second").
+			Connect("second", "third").
+			Connect("third", "END").
+			SetEntryPoint("first").
+			WithSummarization(graph.NewSummarizationPolicy(model, 60).WithKeepRecent(2).WithArchive(archive)).
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		result, err := runnable.InvokeDetailed(context.Background(), graph.NewState("session"))
+		require.NoError(t, err)
+
+		messages := result.State.Messages
+		require.Len(t, messages, 3)
+		assert.Equal(t, graph.SummaryPrefix+"3 lines", messages[0].Parts[0].(llms.TextContent).Text)
+		assert.Equal(t, 2, result.State.Metadata[graph.SummarizationCountMetadata])
+		assert.Equal(t, 14, result.NodeUsage[graph.SummarizationUsageNode].TotalTokens)
+
+		archives := result.State.Metadata[graph.SummarizationArchivesMetadata].([]string)
+		require.Len(t, archives, 2)
+		full, err := archive.Load(context.Background(), archives[1])
+		require.NoError(t, err)
+		assert.Len(t, full.Messages, 5)
+
+		_, err = graph.NewGraph("invalid").WithSummarization(graph.NewSummarizationPolicy(nil, 10)).BuildE()
+		assert.ErrorContains(t, err, "requires a model")
+	})
+}
+
+func TestEventBus(t *testing.T) {
+	answerTopic := graph.Topic[string]("answer")
+	factTopic := graph.Topic[string]("fact")
+
+	var joined []graph.Event
+	g, err := graph.NewGraph("events").
+		AddNode(graph.NewNode("search").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			answers := answerTopic.Subscribe(ctx, state.Events)
+			defer answers.Close()
+
+			branches, events, err := graph.RunBranches(ctx, state,
+				graph.NewBranch("slow", func(ctx context.Context, state *graph.State) (*graph.State, error) {
+					<-ctx.Done()
+					return state, ctx.Err()
+				}),
+				graph.NewBranch("facts", func(ctx context.Context, state *graph.State) (*graph.State, error) {
+					factTopic.Publish(ctx, state.Events, "sky is blue")
+					return state, nil
+				}),
+				graph.NewBranch("answer", func(ctx context.Context, state *graph.State) (*graph.State, error) {
+					answerTopic.Publish(ctx, state.Events, "42")
+					state.SetVariable("answer", "42")
+					graph.CancelSiblings(ctx)
+					return state, nil
+				}),
+			)
+			if err != nil {
+				return nil, err
+			}
+			joined = events
+
+			answer, ok := answers.TryNext()
+			if !ok {
+				return nil, errors.New("answer not delivered")
+			}
+			state.Events.Publish(ctx, "joined", answer)
+			return branches[2], nil
+		}).Build()).
+		Connect("search", "END").
+		SetEntryPoint("search").
+		BuildE()
+	require.NoError(t, err)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		result, err := runnable.InvokeDetailed(context.Background(), graph.NewState("q"))
+		require.NoError(t, err)
+		value, _ := result.State.GetVariable("answer")
+		assert.Equal(t, "42", value)
+
+		// Branch events are committed in branch order whatever finished first
+		require.Len(t, joined, 2)
+		assert.Equal(t, []string{"sky is blue"}, factTopic.Payloads(joined))
+		assert.Equal(t, "facts", joined[0].Branch)
+		assert.Equal(t, "answer", joined[1].Branch)
+		assert.Equal(t, "search", joined[1].NodeID)
+
+		var traced []string
+		for _, entry := range result.Trace {
+			if entry.Event == "event" {
+				traced = append(traced, fmt.Sprintf("%v:%v", entry.Data["seq"], entry.Data["topic"]))
+			}
+		}
+		assert.Equal(t, []string{"1:fact", "2:answer", "3:joined"}, traced)
+		assert.Len(t, result.State.Events.History(), 3)
+	}
+
+	t.Run("blocked readers drain at the join", func(t *testing.T) {
+		state := graph.NewState("drain")
+		subscribed := make(chan struct{})
+		var received []string
+		_, events, err := graph.RunBranches(context.Background(), state,
+			graph.NewBranch("reader", func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				facts := factTopic.Subscribe(ctx, state.Events)
+				close(subscribed)
+				for {
+					fact, ok := facts.Next(ctx)
+					if !ok {
+						return state, nil
+					}
+					received = append(received, fact)
+				}
+			}),
+			graph.NewBranch("writer", func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				<-subscribed
+				factTopic.Publish(ctx, state.Events, "a")
+				factTopic.Publish(ctx, state.Events, "b")
+				return state, nil
+			}),
+			graph.NewBranch("broken", func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				return nil, errors.New("boom")
+			}),
+		)
+		assert.ErrorContains(t, err, "branch broken: boom")
+		assert.Equal(t, []string{"a", "b"}, received)
+		assert.Equal(t, []string{"a", "b"}, factTopic.Payloads(events))
+		assert.Equal(t, []int{1, 2}, []int{events[0].Seq, events[1].Seq})
+	})
+
+	assert.False(t, graph.CancelSiblings(context.Background()))
+}
+
+// promptRecorder records the prompts it receives and replies with a numbered answer.
+type promptRecorder struct {
+	prompts []string
+}
+
+func (m *promptRecorder) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.prompts = append(m.prompts, messages[len(messages)-1].Parts[0].(llms.TextContent).Text)
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: fmt.Sprintf("reply %d", len(m.prompts))}}}, nil
+}
+
+func (m *promptRecorder) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// calculatorAgent calls the calculator once and answers with its observation.
+type calculatorAgent struct {
+	tools []tools.Tool
+}
+
+func (a calculatorAgent) Plan(ctx context.Context, steps []schema.AgentStep, inputs map[string]string) ([]schema.AgentAction, *schema.AgentFinish, error) {
+	if len(steps) == 0 {
+		return []schema.AgentAction{{Tool: "calculator", ToolInput: inputs["input"]}}, nil, nil
+	}
+	return nil, &schema.AgentFinish{ReturnValues: map[string]any{"output": "answer: " + steps[len(steps)-1].Observation}}, nil
+}
+
+func (a calculatorAgent) GetInputKeys() []string  { return []string{"input"} }
+func (a calculatorAgent) GetOutputKeys() []string { return []string{"output"} }
+func (a calculatorAgent) GetTools() []tools.Tool  { return a.tools }
+
+// calculatorTool evaluates expressions with graph.EvalExpr.
+type calculatorTool struct{}
+
+func (calculatorTool) Name() string        { return "calculator" }
+func (calculatorTool) Description() string { return "evaluates arithmetic" }
+func (calculatorTool) Call(ctx context.Context, input string) (string, error) {
+	value, err := graph.EvalExpr(input, nil)
+	return fmt.Sprint(value), err
+}
+
+func TestMigration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("chain", func(t *testing.T) {
+		model := &promptRecorder{}
+		g, err := graph.FromChain(chains.NewConversation(model, memory.NewConversationBuffer()))
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		state := graph.NewState("chat")
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hello"))
+		result, err := runnable.Invoke(ctx, state)
+		require.NoError(t, err)
+		text, _ := result.GetVariable("text")
+		assert.Equal(t, "reply 1", text)
+		assert.Equal(t, llms.ChatMessageTypeAI, result.Messages[len(result.Messages)-1].Role)
+
+		// The memory node feeds the first turn into the second prompt
+		state = graph.NewState("chat")
+		state.SetVariable("input", "again")
+		_, err = runnable.Invoke(ctx, state)
+		require.NoError(t, err)
+		assert.Contains(t, model.prompts[1], "Human: hello\nAI: reply 1")
+		assert.Contains(t, model.prompts[1], "again")
+		assert.Len(t, g.GetNodesByTag(graph.NodeTagLLM), 1)
+
+		_, err = runnable.Invoke(ctx, graph.NewState("empty"))
+		assert.ErrorContains(t, err, "missing chain inputs: input")
+	})
+
+	t.Run("executor", func(t *testing.T) {
+		executor := agents.NewExecutor(calculatorAgent{tools: []tools.Tool{calculatorTool{}}}, agents.WithReturnIntermediateSteps())
+		expected, err := chains.Call(ctx, executor, map[string]any{"input": "6 * 7"})
+		require.NoError(t, err)
+
+		g, err := graph.FromExecutor(executor)
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		state := graph.NewState("agent")
+		state.SetVariable("input", "6 * 7")
+		result, err := runnable.InvokeDetailed(ctx, state)
+		require.NoError(t, err)
+		output, _ := result.State.GetVariable("output")
+		assert.Equal(t, expected["output"], output)
+		assert.Equal(t, "answer: 42", output)
+		assert.Equal(t, []string{"load_memory", "plan", "tools", "plan", "save_memory"}, result.Path)
+		steps, _ := result.State.GetVariable("intermediateSteps")
+		assert.Len(t, steps, 1)
+
+		// The iteration limit is enforced as in Executor.Call
+		executor.MaxIterations = 1
+		_, err = runnable.Invoke(ctx, state)
+		assert.ErrorIs(t, err, agents.ErrNotFinished)
+	})
+}
+
+func TestToolApproval(t *testing.T) {
+	ctx := context.Background()
+
+	var calls []graph.ToolCallRequest
+	approved := false
+	policy := &graph.ToolApprovalPolicy{
+		Tools: []string{"CALC*"},
+		Approver: graph.ToolApproverFunc(func(ctx context.Context, call graph.ToolCallRequest) (*graph.ApprovalDecision, error) {
+			calls = append(calls, call)
+			return &graph.ApprovalDecision{Approved: approved, Comment: "not today"}, nil
+		}),
+	}
+	assert.True(t, policy.RequiresApproval("calculator"))
+	assert.False(t, policy.RequiresApproval("search"))
+
+	// A denial is fed back to the agent as the observation
+	executor := agents.NewExecutor(calculatorAgent{tools: policy.WrapAll([]tools.Tool{calculatorTool{}})})
+	g, err := graph.FromExecutor(executor)
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	state := graph.NewState("agent")
+	state.SetVariable("input", "6 * 7")
+	result, err := runnable.Invoke(ctx, state)
+	require.NoError(t, err)
+	output, _ := result.GetVariable("output")
+	assert.Contains(t, output, "denied by a human reviewer")
+	assert.Contains(t, output, "not today")
+	require.Len(t, calls, 1)
+	assert.Equal(t, graph.ToolCallRequest{Tool: "calculator", Input: "6 * 7", NodeID: "tools"}, calls[0])
+
+	// Approved calls run the tool
+	approved = true
+	answer, err := chains.Run(ctx, executor, "6 * 7")
+	require.NoError(t, err)
+	assert.Equal(t, "answer: 42", answer)
+
+	t.Run("webhook", func(t *testing.T) {
+		var approver *graph.WebhookApprover
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request graph.ApprovalRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			w.WriteHeader(http.StatusAccepted)
+			go approver.Decide(graph.ApprovalDecision{ID: request.ID, Approved: request.ToolCall.Input == "1 + 1"})
+		}))
+		defer webhook.Close()
+		approver = graph.NewWebhookApprover(graph.WebhookApprovalConfig{URL: webhook.URL, Timeout: 5 * time.Second})
+
+		gated := (&graph.ToolApprovalPolicy{Tools: []string{"*"}, Approver: approver}).Wrap(calculatorTool{})
+		observation, err := gated.Call(ctx, "1 + 1")
+		require.NoError(t, err)
+		assert.Equal(t, "2", observation)
+
+		observation, err = gated.Call(ctx, "2 + 2")
+		require.NoError(t, err)
+		assert.Contains(t, observation, "denied")
+	})
+}
+
+// TestSLAViolations tests SLA evaluation over the sliding window of executions
+// TestSLAViolations 测试基于滑动窗口的 SLA 评估
+func TestSLAViolations(t *testing.T) {
+	g, err := graph.NewGraph("checkout").
+		WithSLA(graph.SLA{MaxErrorRate: 0.25, MaxP95Duration: time.Hour, MinSamples: 4}).
+		AddNode(graph.NewNode("pay").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			if fail, _ := state.GetVariable("fail"); fail == true {
+				return nil, fmt.Errorf("payment declined")
+			}
+			return state, nil
+		}).Build()).
+		Connect("pay", "END").
+		SetEntryPoint("pay").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	var events []graph.SLAViolation
+	runnable.OnSLAViolation(func(violation graph.SLAViolation) {
+		events = append(events, violation)
+	})
+	run := func(fail bool) {
+		state := graph.NewState("run")
+		state.SetVariable("fail", fail)
+		_, _ = runnable.Invoke(context.Background(), state)
+	}
+
+	// Not evaluated before MinSamples executions
+	run(true)
+	run(true)
+	run(true)
+	assert.Empty(t, events)
+	run(false)
+	require.Len(t, events, 1)
+
+	// One event when the target starts being violated, none while it lasts
+	run(true)
+	require.Len(t, events, 1)
+	assert.Equal(t, graph.SLAMetricErrorRate, events[0].Metric)
+	assert.Equal(t, "checkout", events[0].GraphID)
+	assert.Equal(t, 0.75, events[0].Actual)
+	assert.Equal(t, 4, events[0].Samples)
+	assert.False(t, events[0].Resolved)
+
+	status := runnable.GetExecutionStats().SLA
+	require.NotNil(t, status)
+	assert.Equal(t, []graph.SLAMetric{graph.SLAMetricErrorRate}, status.Violated)
+	assert.Equal(t, 5, status.Samples)
+	assert.Equal(t, int64(1), status.Violations)
+
+	// A resolution event once the error rate drops below the target
+	for i := 0; i < 15; i++ {
+		run(false)
+	}
+	require.Len(t, events, 2)
+	assert.True(t, events[1].Resolved)
+	assert.Empty(t, runnable.SLAStatus().Violated)
+
+	runnable.ResetStats()
+	assert.Zero(t, runnable.SLAStatus().Samples)
+
+	// The p95 duration is checked independently
+	slow, err := graph.NewGraph("slow").
+		WithSLA(graph.SLA{MaxP95Duration: time.Millisecond, MinSamples: 1}).
+		AddNode(graph.NewNode("sleep").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			time.Sleep(5 * time.Millisecond)
+			return state, nil
+		}).Build()).
+		Connect("sleep", "END").
+		SetEntryPoint("sleep").
+		BuildE()
+	require.NoError(t, err)
+	slowRunnable, err := slow.Compile()
+	require.NoError(t, err)
+	var slowEvents []graph.SLAViolation
+	slowRunnable.OnSLAViolation(func(violation graph.SLAViolation) {
+		slowEvents = append(slowEvents, violation)
+	})
+	_, err = slowRunnable.Invoke(context.Background(), graph.NewState("run"))
+	require.NoError(t, err)
+	require.Len(t, slowEvents, 1)
+	assert.Equal(t, graph.SLAMetricP95Duration, slowEvents[0].Metric)
+	assert.Equal(t, 1.0, slowEvents[0].Target)
+	assert.Greater(t, slowEvents[0].Actual, 5.0)
+
+	// Graphs without an SLA report no status
+	plain, err := graph.NewGraph("plain").
+		AddNode(graph.NewNode("sleep").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) { return state, nil }).Build()).
+		Connect("sleep", "END").
+		SetEntryPoint("sleep").
+		BuildE()
+	require.NoError(t, err)
+	plainRunnable, err := plain.Compile()
+	require.NoError(t, err)
+	_, err = plainRunnable.Invoke(context.Background(), graph.NewState("run"))
+	require.NoError(t, err)
+	assert.Nil(t, plainRunnable.SLAStatus())
+	assert.Nil(t, plainRunnable.GetExecutionStats().SLA)
+}
+
+// TestParallelNode tests fan-out/fan-in of parallel nodes
+// TestParallelNode 测试并行节点的分发与合并
+func TestParallelNode(t *testing.T) {
+	var running, peak int32
+	child := func(id string, value interface{}, err error) *graph.Node {
+		return graph.NewNode(id).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			if err != nil {
+				return nil, err
+			}
+			state.SetVariable(id, value)
+			state.SetVariable("shared", id)
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, id))
+			return state, nil
+		}).Build()
+	}
+	run := func(t *testing.T, maxConcurrency int, node *graph.Node) (*graph.State, error) {
+		g, err := graph.NewGraph("fanout").
+			WithMaxConcurrency(maxConcurrency).
+			AddNode(node).
+			Connect(node.ID, "END").
+			SetEntryPoint(node.ID).
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		state := graph.NewState("run")
+		state.SetVariable("query", "q")
+		return runnable.Invoke(context.Background(), state)
+	}
+
+	t.Run("overwrite", func(t *testing.T) {
+		atomic.StoreInt32(&peak, 0)
+		result, err := run(t, 2, graph.NewNode("search").
+			WithParallel(child("web", 1, nil), child("docs", 2, nil), child("wiki", 3, nil)).
+			Build())
+		require.NoError(t, err)
+		assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+
+		for id, value := range map[string]int{"web": 1, "docs": 2, "wiki": 3} {
+			got, _ := result.GetVariable(id)
+			assert.Equal(t, value, got)
+		}
+		// Later children win, messages are appended in child order
+		shared, _ := result.GetVariable("shared")
+		assert.Equal(t, "wiki", shared)
+		require.Len(t, result.Messages, 3)
+		assert.Equal(t, llms.TextContent{Text: "docs"}, result.Messages[1].Parts[0])
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+		result, err := run(t, 0, graph.NewNode("search").
+			WithParallel(child("web", 1, nil), child("docs", 2, nil)).
+			WithMergeStrategy(graph.MergeStrategyNamespace).
+			Build())
+		require.NoError(t, err)
+		web, _ := result.GetVariable("web")
+		assert.Equal(t, map[string]interface{}{"web": 1, "shared": "web"}, web)
+		_, exists := result.GetVariable("shared")
+		assert.False(t, exists)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := run(t, 0, graph.NewNode("search").
+			WithParallel(child("web", 1, nil), child("docs", 2, nil)).
+			WithMergeStrategy(graph.MergeStrategyError).
+			Build())
+		assert.ErrorContains(t, err, "both changed variable shared")
+	})
+
+	t.Run("stop on failure", func(t *testing.T) {
+		_, err := run(t, 0, graph.NewNode("search").
+			WithParallel(child("web", 1, nil), child("docs", nil, fmt.Errorf("index offline"))).
+			Build())
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "child docs: index offline")
+		assert.NotContains(t, err.Error(), "child web")
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		result, err := run(t, 0, graph.NewNode("search").
+			WithParallel(child("web", 1, nil), child("docs", nil, fmt.Errorf("index offline"))).
+			WithFailureMode(graph.FailureModeContinue).
+			Build())
+		require.NoError(t, err)
+		web, _ := result.GetVariable("web")
+		assert.Equal(t, 1, web)
+		errs, _ := result.GetMetadata(graph.ParallelErrorsMetadata)
+		assert.Equal(t, map[string]interface{}{"docs": "index offline"}, errs)
+	})
+
+	t.Run("custom merge", func(t *testing.T) {
+		result, err := run(t, 0, graph.NewNode("search").
+			WithParallel(child("web", 1, nil), child("docs", 2, nil)).
+			WithMergeFunc(func(base *graph.State, results []*graph.State) (*graph.State, error) {
+				base.SetVariable("count", len(results))
+				return base, nil
+			}).
+			Build())
+		require.NoError(t, err)
+		count, _ := result.GetVariable("count")
+		assert.Equal(t, 2, count)
+	})
+
+	assert.Error(t, graph.NewNode("empty").WithType(graph.NodeTypeParallel).Build().Validate())
+}
+
+func TestLoopNode(t *testing.T) {
+	run := func(t *testing.T, node *graph.Node) (*graph.State, error) {
+		g, err := graph.NewGraph("loop").
+			AddNode(node).
+			Connect(node.ID, "END").
+			SetEntryPoint(node.ID).
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		state := graph.NewState("run")
+		state.SetVariable("count", 0)
+		return runnable.Invoke(context.Background(), state)
+	}
+	below := func(limit int) graph.LoopCondition {
+		return func(ctx context.Context, state *graph.State) (bool, error) {
+			count, _ := state.GetVariable("count")
+			return count.(int) < limit, nil
+		}
+	}
+	increment := func(ctx context.Context, state *graph.State) (*graph.State, error) {
+		count, _ := state.GetVariable("count")
+		state.SetVariable("count", count.(int)+1)
+		return state, nil
+	}
+	loopSteps := func(state *graph.State, id string) []graph.ExecutionStep {
+		var steps []graph.ExecutionStep
+		for _, step := range state.History {
+			if step.NodeID == id && step.Iteration > 0 {
+				steps = append(steps, step)
+			}
+		}
+		return steps
+	}
+
+	t.Run("condition", func(t *testing.T) {
+		result, err := run(t, graph.NewNode("count").WithLoop(below(3), increment).Build())
+		require.NoError(t, err)
+		count, _ := result.GetVariable("count")
+		assert.Equal(t, 3, count)
+		iterations, _ := result.GetMetadata(graph.LoopIterationMetadata)
+		assert.Equal(t, 3, iterations)
+
+		// One snapshot of the variables per iteration
+		steps := loopSteps(result, "count")
+		require.Len(t, steps, 3)
+		for i, step := range steps {
+			assert.Equal(t, i+1, step.Iteration)
+			assert.Equal(t, i+1, step.Output["count"])
+		}
+	})
+
+	t.Run("max iterations", func(t *testing.T) {
+		_, err := run(t, graph.NewNode("count").
+			WithLoop(below(10), increment).
+			WithMaxIterations(4).
+			Build())
+		assert.ErrorContains(t, err, "maximum of 4 iterations")
+	})
+
+	t.Run("break", func(t *testing.T) {
+		result, err := run(t, graph.NewNode("count").
+			WithLoop(nil, func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				state, _ = increment(ctx, state)
+				if iteration, _ := state.GetMetadata(graph.LoopIterationMetadata); iteration == 2 {
+					graph.BreakLoop(state)
+				}
+				return state, nil
+			}).
+			Build())
+		require.NoError(t, err)
+		count, _ := result.GetVariable("count")
+		assert.Equal(t, 2, count)
+		_, exists := result.GetMetadata(graph.LoopControlMetadata)
+		assert.False(t, exists)
+	})
+
+	t.Run("sub-graph continue", func(t *testing.T) {
+		var skipped int
+		body, err := graph.NewGraph("body").
+			AddNode(graph.NewNode("inc").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				state, _ = increment(ctx, state)
+				if count, _ := state.GetVariable("count"); count.(int)%2 == 1 {
+					graph.ContinueLoop(state)
+				}
+				return state, nil
+			}).Build()).
+			AddNode(graph.NewNode("even").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				skipped++
+				return state, nil
+			}).Build()).
+			Connect("inc", "even").
+			Connect("even", "END").
+			SetEntryPoint("inc").
+			BuildE()
+		require.NoError(t, err)
+
+		result, err := run(t, graph.NewNode("count").WithLoopSubGraph(below(4), body).Build())
+		require.NoError(t, err)
+		count, _ := result.GetVariable("count")
+		assert.Equal(t, 4, count)
+		// Odd iterations end after the first node
+		assert.Equal(t, 2, skipped)
+		assert.Len(t, loopSteps(result, "count"), 4)
+	})
+
+	assert.Error(t, graph.NewNode("empty").WithType(graph.NodeTypeLoop).Build().Validate())
+}
+
+// chunkedModel is a fake model that streams its reply in chunks when asked to.
+type chunkedModel struct {
+	chunks   []string
+	toolCall *llms.ToolCall
+	received [][]llms.MessageContent
+}
+
+func (m *chunkedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.received = append(m.received, messages)
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.StreamingFunc != nil {
+		for _, chunk := range m.chunks {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	choice := &llms.ContentChoice{
+		Content:        strings.Join(m.chunks, ""),
+		GenerationInfo: map[string]interface{}{"PromptTokens": 4, "CompletionTokens": 2, "TotalTokens": 6},
+	}
+	if m.toolCall != nil {
+		choice.ToolCalls = []llms.ToolCall{*m.toolCall}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}, nil
+}
+
+func (m *chunkedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestLLMNode(t *testing.T) {
+	compile := func(t *testing.T, node *graph.Node) *graph.Runnable {
+		g, err := graph.NewGraph("chat").
+			AddNode(node).
+			Connect(node.ID, "END").
+			SetEntryPoint(node.ID).
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+	newState := func() *graph.State {
+		state := graph.NewState("run")
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "你好"))
+		return state
+	}
+
+	t.Run("invoke", func(t *testing.T) {
+		model := &chunkedModel{chunks: []string{"你", "好！"}}
+		node := graph.LLMNode("assistant", model,
+			graph.WithLLMSystemPrompt("你是助手"),
+			graph.WithLLMOutputKey("answer"),
+			graph.WithLLMCostFunc(func(u graph.Usage) float64 { return float64(u.TotalTokens) / 1000 }))
+		assert.True(t, node.HasTag(graph.NodeTagLLM))
+		assert.Equal(t, graph.DefaultLLMNodeTimeout, node.Config.Timeout)
+
+		result, err := compile(t, node).InvokeDetailed(context.Background(), newState())
+		require.NoError(t, err)
+
+		// The system prompt is sent but not stored
+		require.Len(t, model.received, 1)
+		assert.Equal(t, llms.ChatMessageTypeSystem, model.received[0][0].Role)
+		require.Len(t, result.State.Messages, 2)
+		assert.Equal(t, llms.ChatMessageTypeAI, result.State.Messages[1].Role)
+		assert.Equal(t, llms.TextContent{Text: "你好！"}, result.State.Messages[1].Parts[0])
+
+		answer, _ := result.State.GetVariable("answer")
+		assert.Equal(t, "你好！", answer)
+		assert.Equal(t, 6, result.NodeUsage["assistant"].TotalTokens)
+		assert.InDelta(t, 0.006, result.Usage.Cost, 1e-9)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		model := &chunkedModel{chunks: []string{"你", "好", "！"}}
+		results, err := compile(t, graph.LLMNode("assistant", model)).Stream(context.Background(), newState())
+		require.NoError(t, err)
+
+		var drafts []string
+		var final *graph.State
+		for result := range results {
+			switch result.Type {
+			case graph.StreamResultTypeDraft:
+				assert.Equal(t, "assistant", result.NodeID)
+				drafts = append(drafts, result.Metadata["draft"].(string))
+			case graph.StreamResultTypeFinal:
+				final = result.State
+			case graph.StreamResultTypeError:
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+		}
+		assert.Equal(t, []string{"你", "你好", "你好！"}, drafts)
+		require.NotNil(t, final)
+		output, _ := final.GetVariable(graph.DefaultLLMOutputKey)
+		assert.Equal(t, "你好！", output)
+		_, exists := final.GetDraft(graph.DefaultLLMOutputKey)
+		assert.False(t, exists)
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		call := llms.ToolCall{ID: "1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: `{"q":"天气"}`}}
+		model := &chunkedModel{toolCall: &call}
+		state, err := compile(t, graph.LLMNode("assistant", model, graph.WithLLMStreaming(false))).
+			Invoke(context.Background(), newState())
+		require.NoError(t, err)
+		require.Len(t, state.Messages, 2)
+		assert.Equal(t, []llms.ContentPart{call}, state.Messages[1].Parts)
+	})
+
+	t.Run("no messages", func(t *testing.T) {
+		_, err := compile(t, graph.LLMNode("assistant", &chunkedModel{})).
+			Invoke(context.Background(), graph.NewState("empty"))
+		assert.ErrorContains(t, err, "no messages")
+	})
+}
+
+func TestNamedConditions(t *testing.T) {
+	graph.RegisterValue("test_vip_threshold", 1000)
+	graph.RegisterExprCondition("test_is_vip", "spend >= test_vip_threshold")
+	graph.RegisterCondition("test_is_regular", func(ctx context.Context, state *graph.State) (bool, error) {
+		isVIP, _ := graph.LookupCondition("test_is_vip")
+		vip, err := isVIP(ctx, state)
+		return !vip, err
+	})
+
+	tier := func(id string) *graph.Node {
+		return graph.NewNode(id).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			state.SetVariable("tier", id)
+			return state, nil
+		}).Build()
+	}
+	g, err := graph.NewGraph("named").
+		AddNode(graph.NewNode("start").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			return state, nil
+		}).Build()).
+		AddNodes(tier("vip"), tier("regular")).
+		ConnectWithNamedCondition("start", "vip", "test_is_vip").
+		ConnectWithNamedCondition("start", "regular", "test_is_regular").
+		Connect("vip", "END").
+		Connect("regular", "END").
+		SetEntryPoint("start").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	for spend, expected := range map[int]string{1500: "vip", 200: "regular"} {
+		state := graph.NewState("run")
+		state.SetVariable("spend", spend)
+		result, err := runnable.Invoke(context.Background(), state)
+		require.NoError(t, err)
+		value, _ := result.GetVariable("tier")
+		assert.Equal(t, expected, value)
+	}
+
+	t.Run("state variables shadow values", func(t *testing.T) {
+		state := graph.NewState("run")
+		state.SetVariable("spend", 500)
+		state.SetVariable("test_vip_threshold", 100)
+		vip, err := graph.NamedCondition("test_is_vip")(context.Background(), state)
+		require.NoError(t, err)
+		assert.True(t, vip)
+	})
+
+	t.Run("exported by name", func(t *testing.T) {
+		edge := graph.NewEdge("e", "start", "vip").WithNamedCondition("test_is_vip").Build()
+		data, err := json.Marshal(edge)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"condition":"test_is_vip"`)
+		assert.Equal(t, "test_is_vip", edge.Clone().ConditionName)
+		assert.Contains(t, graph.RegisteredConditions(), "test_is_vip")
+	})
+
+	t.Run("unknown and duplicate names", func(t *testing.T) {
+		_, err := graph.NewGraph("unknown").
+			AddNodes(tier("vip"), tier("regular")).
+			ConnectWithNamedCondition("vip", "regular", "test_missing").
+			SetEntryPoint("vip").
+			BuildE()
+		assert.ErrorContains(t, err, "unknown condition test_missing")
+
+		_, err = graph.NamedCondition("test_missing")(context.Background(), graph.NewState("run"))
+		assert.ErrorContains(t, err, "unknown condition")
+
+		assert.Panics(t, func() { graph.RegisterExprCondition("test_is_vip", "true") })
+		assert.Panics(t, func() { graph.RegisterExprCondition("test_bad", "spend >=") })
+	})
+}
+
+func TestGraphJSON(t *testing.T) {
+	graph.RegisterCondition("test_json_is_math", func(ctx context.Context, state *graph.State) (bool, error) {
+		category, _ := state.GetVariable("category")
+		return category == "math", nil
+	})
+	graph.RegisterExprCondition("test_json_below_three", "count < 3")
+
+	increment := func(ctx context.Context, state *graph.State) (*graph.State, error) {
+		count, _ := state.GetVariable("count")
+		state.SetVariable("count", count.(int)+1)
+		return state, nil
+	}
+	answer := func(ctx context.Context, state *graph.State) (*graph.State, error) {
+		state.SetVariable("answer", "general")
+		return state, nil
+	}
+	registry := graph.NewFunctionRegistry().
+		Register("count", increment).
+		Register("general", answer)
+
+	loop := graph.NewNode("count").WithNamedLoop("test_json_below_three", increment).Build()
+	original, err := graph.NewGraph("router").
+		WithName("Router").
+		AddNodes(
+			graph.ExprNode("classify", "question == '1+1' ? 'math' : 'other'", "category"),
+			loop,
+			graph.NewNode("general").WithFunction(answer).WithTimeout(time.Second).Build(),
+		).
+		ConnectWithNamedCondition("classify", "count", "test_json_is_math").
+		AddEdge(graph.NewEdge("fallback", "classify", "general").AsDefault().Build()).
+		Connect("count", "END").
+		Connect("general", "END").
+		SetEntryPoint("classify").
+		BuildE()
+	require.NoError(t, err)
+
+	data, err := json.Marshal(original)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"condition":"test_json_is_math"`)
+	assert.Contains(t, string(data), `"entry_point":"classify"`)
+
+	loaded, err := graph.LoadFromJSON(data, registry)
+	require.NoError(t, err)
+	assert.Equal(t, "Router", loaded.Name)
+	reencoded, err := json.Marshal(loaded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(reencoded))
+
+	for question, check := range map[string]func(*graph.State){
+		"1+1": func(state *graph.State) {
+			count, _ := state.GetVariable("count")
+			assert.Equal(t, 3, count)
+		},
+		"hello": func(state *graph.State) {
+			value, _ := state.GetVariable("answer")
+			assert.Equal(t, "general", value)
+		},
+	} {
+		runnable, err := loaded.Compile()
+		require.NoError(t, err)
+		state := graph.NewState("run")
+		state.SetVariable("question", question)
+		state.SetVariable("count", 0)
+		result, err := runnable.Invoke(context.Background(), state)
+		require.NoError(t, err)
+		check(result)
+	}
+
+	t.Run("hand-written definition", func(t *testing.T) {
+		loaded, err := graph.LoadFromJSON([]byte(`{
+			"id": "doubler",
+			"entry_point": "double",
+			"nodes": [{"id": "double", "expression": "x * 2", "output_key": "y"}],
+			"edges": [{"from": "double", "to": "END"}]
+		}`), nil)
+		require.NoError(t, err)
+		edges := loaded.GetEdgesFrom("double")
+		require.Len(t, edges, 1)
+		assert.True(t, edges[0].Enabled)
+		assert.Equal(t, "double_to_END", edges[0].ID)
+
+		runnable, err := loaded.Compile()
+		require.NoError(t, err)
+		state := graph.NewState("run")
+		state.SetVariable("x", 21)
+		result, err := runnable.Invoke(context.Background(), state)
+		require.NoError(t, err)
+		y, _ := result.GetVariable("y")
+		assert.Equal(t, float64(42), y)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := graph.LoadFromJSON(data, graph.NewFunctionRegistry())
+		assert.ErrorContains(t, err, "no function registered as general")
+
+		closure, err := graph.NewGraph("closure").
+			AddNode(graph.NewNode("a").WithFunction(answer).Build()).
+			ConnectWithCondition("a", "END", func(ctx context.Context, state *graph.State) (bool, error) { return true, nil }).
+			SetEntryPoint("a").
+			BuildE()
+		require.NoError(t, err)
+		_, err = json.Marshal(closure)
+		assert.ErrorContains(t, err, "register it with RegisterCondition")
+	})
+}
+
+func TestEdgeExpressions(t *testing.T) {
+	answer := func(id string) *graph.Node {
+		return graph.NewNode(id).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			state.SetVariable("handled_by", id)
+			return state, nil
+		}).Build()
+	}
+	g, err := graph.NewGraph("router").
+		AddNodes(answer("classify"), answer("math"), answer("urgent"), answer("chat")).
+		ConnectWithExpression("classify", "math", "variables.category == 'math'").
+		AddEdge(graph.NewEdge("urgent", "classify", "urgent").WithExpression("metadata.priority >= 5").Build()).
+		AddEdge(graph.NewEdge("fallback", "classify", "chat").AsDefault().Build()).
+		Connect("math", "END").
+		Connect("urgent", "END").
+		Connect("chat", "END").
+		SetEntryPoint("classify").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	run := func(category string, priority int) string {
+		state := graph.NewState("run")
+		state.SetVariable("category", category)
+		state.SetMetadata("priority", priority)
+		result, err := runnable.Invoke(context.Background(), state)
+		require.NoError(t, err)
+		handledBy, _ := result.GetVariable("handled_by")
+		return handledBy.(string)
+	}
+	assert.Equal(t, "math", run("math", 0))
+	assert.Equal(t, "urgent", run("other", 9))
+	assert.Equal(t, "chat", run("other", 0))
+
+	t.Run("declared in JSON", func(t *testing.T) {
+		data, err := json.Marshal(g)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"expression":"variables.category == 'math'"`)
+
+		loaded, err := graph.LoadFromJSON(data, graph.NewFunctionRegistry().
+			Register("classify", answer("classify").Function).
+			Register("math", answer("math").Function).
+			Register("urgent", answer("urgent").Function).
+			Register("chat", answer("chat").Function))
+		require.NoError(t, err)
+		runnable, err := loaded.Compile()
+		require.NoError(t, err)
+		state := graph.NewState("run")
+		state.SetVariable("category", "math")
+		result, err := runnable.Invoke(context.Background(), state)
+		require.NoError(t, err)
+		handledBy, _ := result.GetVariable("handled_by")
+		assert.Equal(t, "math", handledBy)
+	})
+
+	t.Run("validated when built", func(t *testing.T) {
+		_, err := graph.NewGraph("invalid").
+			AddNodes(answer("a"), answer("b")).
+			ConnectWithExpression("a", "b", "variables.category ==").
+			SetEntryPoint("a").
+			BuildE()
+		assert.ErrorContains(t, err, "invalid expression")
+
+		_, err = graph.LoadFromJSON([]byte(`{
+			"id": "invalid",
+			"entry_point": "a",
+			"nodes": [{"id": "a", "expression": "1", "output_key": "x"}],
+			"edges": [{"from": "a", "to": "END", "expression": "(x > 1"}]
+		}`), nil)
+		assert.ErrorContains(t, err, "invalid expression")
+	})
+
+	t.Run("non-bool result", func(t *testing.T) {
+		edge := graph.NewEdge("e", "a", "b").WithExpression("variables.category").Build()
+		state := graph.NewState("run")
+		state.SetVariable("category", "math")
+		_, err := edge.CanTraverse(context.Background(), state)
+		assert.ErrorContains(t, err, "expected bool")
+	})
+}
+
+// TestResultWebhooks tests posting signed execution summaries when executions finish
+// TestResultWebhooks 测试执行结束时发送签名的执行摘要
+func TestResultWebhooks(t *testing.T) {
+	const secret = "s3cret"
+
+	var (
+		mu        sync.Mutex
+		summaries []graph.ExecutionSummary
+		attempts  int32
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery fails to exercise the retry
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(graph.ApprovalTimestampHeader), 10, 64)
+		expected := graph.SignApprovalPayload(secret, body, time.Unix(ts, 0)).Get(graph.ApprovalSignatureHeader)
+		assert.Equal(t, expected, r.Header.Get(graph.ApprovalSignatureHeader))
+
+		var summary graph.ExecutionSummary
+		require.NoError(t, json.Unmarshal(body, &summary))
+		mu.Lock()
+		summaries = append(summaries, summary)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	g, err := graph.NewGraph("webhooks").
+		AddNode(graph.NewNode("answer").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			if fail, _ := state.GetVariable("fail"); fail == true {
+				return nil, errors.New("boom")
+			}
+			graph.RecordUsage(ctx, graph.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, Cost: 0.01})
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "hi"))
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, "hello"))
+			state.SetVariable("answer", "hello")
+			state.SetVariable("secret", "hidden")
+			return state, nil
+		}).Build()).
+		Connect("answer", "END").
+		SetEntryPoint("answer").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	runnable.WithResultWebhook(graph.ResultWebhookConfig{
+		URL:        webhook.URL,
+		Secret:     secret,
+		Variables:  []string{"answer"},
+		RetryDelay: time.Millisecond,
+	})
+
+	_, err = runnable.Invoke(context.Background(), graph.NewState("ok"))
+	require.NoError(t, err)
+	require.NoError(t, runnable.FlushResultWebhooks(context.Background()))
+
+	failing := graph.NewState("failed")
+	failing.SetVariable("fail", true)
+	_, err = runnable.Invoke(context.Background(), failing)
+	require.Error(t, err)
+	require.NoError(t, runnable.FlushResultWebhooks(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, summaries, 2)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&attempts))
+
+	ok := summaries[0]
+	assert.Equal(t, "webhooks", ok.GraphID)
+	assert.Equal(t, "ok", ok.StateID)
+	assert.Equal(t, graph.ExecutionStatusSuccess, ok.Status)
+	assert.Equal(t, []graph.SummaryMessage{{Role: "ai", Text: "hello"}}, ok.Messages)
+	assert.Equal(t, map[string]interface{}{"answer": "hello"}, ok.Variables)
+	assert.Equal(t, 15, ok.Usage.TotalTokens)
+	assert.InDelta(t, 0.01, ok.Usage.Cost, 1e-9)
+	assert.Equal(t, []string{"answer"}, ok.Path)
+
+	failed := summaries[1]
+	assert.Equal(t, "failed", failed.StateID)
+	assert.Equal(t, graph.ExecutionStatusFailure, failed.Status)
+	assert.Contains(t, failed.Error, "boom")
+
+	t.Run("reports undeliverable summaries", func(t *testing.T) {
+		rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer rejecting.Close()
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		var deliveryErr error
+		runnable.WithResultWebhook(graph.ResultWebhookConfig{
+			URL: rejecting.URL,
+			OnError: func(summary *graph.ExecutionSummary, err error) {
+				deliveryErr = err
+			},
+		})
+		_, err = runnable.Invoke(context.Background(), graph.NewState("run"))
+		require.NoError(t, err)
+		require.NoError(t, runnable.FlushResultWebhooks(context.Background()))
+		// Client errors are not retried
+		assert.ErrorContains(t, deliveryErr, "failed after 1 attempts")
+		assert.ErrorContains(t, deliveryErr, "status 400")
+	})
+}
+
+// TestCheckpointResume tests resuming a checkpointed execution after a failure
+// TestCheckpointResume 测试失败后从检查点恢复执行
+func TestCheckpointResume(t *testing.T) {
+	ctx := context.Background()
+	runs := make(map[string]int)
+	crash := true
+	step := func(id string) *graph.Node {
+		return graph.NewNode(id).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			runs[id]++
+			if id == "charge" && crash {
+				return nil, errors.New("process crashed")
+			}
+			state.SetVariable(id, true)
+			return state, nil
+		}).Build()
+	}
+	g, err := graph.NewGraph("checkpointed").
+		AddNodes(step("fetch"), step("charge"), step("notify")).
+		Connect("fetch", "charge").
+		Connect("charge", "notify").
+		Connect("notify", "END").
+		SetEntryPoint("fetch").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	stateManager := graph.NewMemoryStateManager(100)
+	_, err = runnable.InvokeWithCheckpointing(ctx, graph.NewState("order"), graph.NewCheckpointManager(stateManager, time.Minute, 10))
+	require.ErrorContains(t, err, "process crashed")
+
+	// A fresh manager, as after a restart, finds the checkpoints through the state manager
+	crash = false
+	manager := graph.NewCheckpointManager(stateManager, time.Minute, 10)
+	result, err := runnable.ResumeFromCheckpoint(ctx, manager, "order")
+	require.NoError(t, err)
+	assert.Equal(t, "order", result.ID)
+	assert.Equal(t, map[string]int{"fetch": 1, "charge": 2, "notify": 1}, runs)
+	for _, id := range []string{"fetch", "charge", "notify"} {
+		value, _ := result.GetVariable(id)
+		assert.Equal(t, true, value)
+	}
+	_, recorded := result.GetMetadata(graph.CheckpointResumeNodeKey)
+	assert.False(t, recorded)
+
+	// Resuming a finished execution returns its final state without running nodes
+	again, err := runnable.ResumeFromCheckpoint(ctx, manager, "order")
+	require.NoError(t, err)
+	notified, _ := again.GetVariable("notify")
+	assert.Equal(t, true, notified)
+	assert.Equal(t, 1, runs["notify"])
+
+	t.Run("checkpoint interval", func(t *testing.T) {
+		manager := graph.NewCheckpointManager(graph.NewMemoryStateManager(100), time.Minute, 10)
+		_, err := runnable.InvokeWithCheckpointing(ctx, graph.NewState("interval"), manager, graph.WithCheckpointEvery(2))
+		require.NoError(t, err)
+		// Start, after the second node and at the end
+		assert.Len(t, manager.GetCheckpoints("interval"), 3)
+	})
+
+	t.Run("no checkpoint", func(t *testing.T) {
+		_, err := runnable.ResumeFromCheckpoint(ctx, manager, "unknown")
+		assert.ErrorContains(t, err, "no checkpoints found")
+	})
+}
+
+// endlessStreamModel streams chunks until its streaming callback fails, ignoring ctx itself
+// like a provider client that only notices cancellation through the callback.
+type endlessStreamModel struct {
+	stopped chan error
+}
+
+func (m *endlessStreamModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	for {
+		if err := opts.StreamingFunc(ctx, []byte("token ")); err != nil {
+			m.stopped <- err
+			return nil, err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (m *endlessStreamModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// TestCooperativeCancellation tests that cancelling the caller's context stops provider
+// streams and parallel branches promptly, and that work done after it is measured
+// TestCooperativeCancellation 测试调用方取消 context 后及时停止服务商流式请求与并行分支，并统计取消后浪费的工作
+func TestCooperativeCancellation(t *testing.T) {
+	compile := func(t *testing.T, node *graph.Node) *graph.Runnable {
+		g, err := graph.NewGraph("cancel").
+			AddNode(node).
+			Connect(node.ID, "END").
+			SetEntryPoint(node.ID).
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+
+	t.Run("provider stream", func(t *testing.T) {
+		model := &endlessStreamModel{stopped: make(chan error, 1)}
+		runnable := compile(t, graph.LLMNode("assistant", model, graph.WithLLMStreaming(true)))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		state := graph.NewState("run")
+		state.AddMessage(llms.TextParts(llms.ChatMessageTypeHuman, "讲个长故事"))
+		_, err := runnable.Invoke(ctx, state)
+		require.Error(t, err)
+
+		select {
+		case err := <-model.stopped:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("the provider stream was not stopped")
+		}
+	})
+
+	t.Run("parallel branches", func(t *testing.T) {
+		var stopped atomic.Int32
+		branch := func(id string) *graph.Node {
+			return graph.NewNode(id).WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				<-ctx.Done()
+				stopped.Add(1)
+				return nil, ctx.Err()
+			}).Build()
+		}
+		runnable := compile(t, graph.NewNode("fanout").
+			WithParallel(branch("a"), branch("b"), branch("c")).
+			Build())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		result, err := runnable.InvokeDetailed(ctx, graph.NewState("run"))
+		require.ErrorIs(t, err, context.Canceled)
+		assert.EqualValues(t, 3, stopped.Load())
+		// Cooperative nodes return right away
+		assert.Less(t, result.WastedAfterCancel, 50*time.Millisecond)
+
+		stats := runnable.GetExecutionStats()
+		assert.EqualValues(t, 1, stats.CanceledExecutions)
+	})
+
+	t.Run("wasted work", func(t *testing.T) {
+		runnable := compile(t, graph.NewNode("stubborn").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+			<-ctx.Done()
+			time.Sleep(30 * time.Millisecond) // ignores the cancellation for a while
+			return state, nil
+		}).Build())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(5*time.Millisecond, cancel)
+		result, _ := runnable.InvokeDetailed(ctx, graph.NewState("run"))
+		assert.GreaterOrEqual(t, result.WastedAfterCancel, 30*time.Millisecond)
+
+		stats := runnable.GetExecutionStats()
+		assert.EqualValues(t, 1, stats.CanceledExecutions)
+		assert.Equal(t, result.WastedAfterCancel, stats.WastedAfterCancel)
+	})
+
+	t.Run("parallel invocations", func(t *testing.T) {
+		var started atomic.Int32
+		g, err := graph.NewGraph("limited").
+			WithMaxConcurrency(1).
+			AddNode(graph.NewNode("work").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				started.Add(1)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).Build()).
+			Connect("work", "END").
+			SetEntryPoint("work").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		states := []*graph.State{graph.NewState("a"), graph.NewState("b"), graph.NewState("c")}
+		results, err := runnable.InvokeParallel(ctx, states)
+		require.NoError(t, err)
+		// Only the first state got a slot; the waiting ones are not started after the cancellation
+		assert.EqualValues(t, 1, started.Load())
+		for _, result := range results {
+			assert.False(t, result.Success)
+			assert.ErrorIs(t, result.Error, context.Canceled)
+		}
+	})
+}
+
+// TestHumanInput tests pausing at a human input node and resuming with the response
+// TestHumanInput 测试在人工输入节点暂停并携带人工回复恢复执行
+func TestHumanInput(t *testing.T) {
+	ctx := context.Background()
+	var drafted, sent int
+	stateManager := graph.NewMemoryStateManager(100)
+	g, err := graph.NewGraph("refund").
+		WithStateManager(stateManager).
+		AddNodes(
+			graph.NewNode("draft").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				drafted++
+				state.SetVariable("amount", 500)
+				return state, nil
+			}).Build(),
+			graph.NewNode("review").WithHumanInput("Approve a refund of $500?", "decision").Build(),
+			graph.NewNode("send").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				sent++
+				decision, _ := state.GetVariable("decision")
+				state.SetVariable("status", fmt.Sprintf("%v", decision))
+				return state, nil
+			}).Build(),
+		).
+		Connect("draft", "review").
+		Connect("review", "send").
+		Connect("send", "END").
+		SetEntryPoint("draft").
+		BuildE()
+	require.NoError(t, err)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	result, err := runnable.InvokeDetailed(ctx, graph.NewState("ticket"))
+	require.ErrorIs(t, err, graph.ErrExecutionInterrupted)
+	require.NotNil(t, result.Pending)
+	assert.Equal(t, "review", result.Pending.NodeID)
+	assert.Equal(t, "ticket", result.Pending.StateID)
+	assert.Equal(t, "Approve a refund of $500?", result.Pending.Prompt)
+	assert.Equal(t, []string{"draft"}, result.Path)
+	amount, _ := result.State.GetVariable("amount")
+	assert.Equal(t, 500, amount)
+	// A paused execution is not a failure
+	assert.EqualValues(t, 0, runnable.GetExecutionStats().FailedExecutions)
+
+	// The state is persisted under the token, so another process can resume it
+	request, ok := graph.PendingInput(err)
+	require.True(t, ok)
+	saved, err := stateManager.Load(ctx, request.Token)
+	require.NoError(t, err)
+	node, _ := saved.GetMetadata(graph.HumanInputNodeKey)
+	assert.Equal(t, "review", node)
+
+	final, err := runnable.Resume(ctx, request.Token, "approved")
+	require.NoError(t, err)
+	assert.Equal(t, "ticket", final.ID)
+	status, _ := final.GetVariable("status")
+	assert.Equal(t, "approved", status)
+	assert.Equal(t, 1, drafted)
+	assert.Equal(t, 1, sent)
+
+	// Tokens are single use
+	_, err = runnable.Resume(ctx, request.Token, "approved")
+	assert.ErrorContains(t, err, "unknown resume token")
+
+	t.Run("requires a state manager", func(t *testing.T) {
+		g, err := graph.NewGraph("no_store").
+			AddNode(graph.NewNode("ask").WithHumanInput("Name?", "").Build()).
+			Connect("ask", "END").
+			SetEntryPoint("ask").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		_, err = runnable.Invoke(ctx, graph.NewState("run"))
+		assert.ErrorContains(t, err, "requires a state manager")
+		assert.NotErrorIs(t, err, graph.ErrExecutionInterrupted)
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		data, err := json.Marshal(g)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"type":"human_input"`)
+
+		noop := func(ctx context.Context, state *graph.State) (*graph.State, error) { return state, nil }
+		loaded, err := graph.LoadFromJSON(data, graph.NewFunctionRegistry().Register("draft", noop).Register("send", noop))
+		require.NoError(t, err)
+		node, ok := loaded.GetNode("review")
+		require.True(t, ok)
+		assert.Equal(t, graph.NodeTypeHumanInput, node.Type)
+		assert.Equal(t, "decision", node.Outputs[0].Name)
+	})
+}
+
+// recordingTracerProvider records the spans started through it
+type recordingTracerProvider struct {
+	embedded.TracerProvider
+	lock  sync.Mutex
+	spans []*recordedSpan
+}
+
+func (p *recordingTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{provider: p}
+}
+
+// find returns the first span with the name
+func (p *recordingTracerProvider) find(name string) *recordedSpan {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, span := range p.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+type recordingTracer struct {
+	embedded.Tracer
+	provider *recordingTracerProvider
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.provider.lock.Lock()
+	defer t.provider.lock.Unlock()
+
+	parent := trace.SpanContextFromContext(ctx)
+	span := &recordedSpan{
+		name:       name,
+		parent:     parent.SpanID(),
+		attributes: make(map[attribute.Key]attribute.Value),
+		context: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{byte(len(t.provider.spans) + 1)},
+		}),
+	}
+	config := trace.NewSpanStartConfig(options...)
+	for _, attr := range config.Attributes() {
+		span.attributes[attr.Key] = attr.Value
+	}
+	t.provider.spans = append(t.provider.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordedSpan struct {
+	noop.Span
+	lock       sync.Mutex
+	name       string
+	context    trace.SpanContext
+	parent     trace.SpanID
+	attributes map[attribute.Key]attribute.Value
+	events     []string
+	status     codes.Code
+	ended      bool
+}
+
+func (s *recordedSpan) SpanContext() trace.SpanContext { return s.context }
+func (s *recordedSpan) IsRecording() bool              { return true }
+
+func (s *recordedSpan) SetAttributes(attributes ...attribute.KeyValue) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, attr := range attributes {
+		s.attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.events = append(s.events, name)
+}
+
+func (s *recordedSpan) RecordError(err error, options ...trace.EventOption) {
+	s.AddEvent("exception")
+}
+
+func (s *recordedSpan) SetStatus(code codes.Code, description string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = code
+}
+
+func (s *recordedSpan) End(options ...trace.SpanEndOption) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ended = true
+}
+
+func (s *recordedSpan) attribute(key attribute.Key) attribute.Value {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.attributes[key]
+}
+
+// TestOpenTelemetryTracing tests the spans emitted per execution and per node
+// TestOpenTelemetryTracing 测试每次执行和每个节点生成的 span
+func TestOpenTelemetryTracing(t *testing.T) {
+	pass := func(ctx context.Context, state *graph.State) (*graph.State, error) { return state, nil }
+	newProvider := func() *recordingTracerProvider { return &recordingTracerProvider{} }
+
+	t.Run("execution and node spans", func(t *testing.T) {
+		provider := newProvider()
+		attempts := 0
+		child, err := graph.NewGraph("lookup").
+			AddNode(graph.NewNode("search").WithFunction(pass).Build()).
+			Connect("search", "END").
+			SetEntryPoint("search").
+			BuildE()
+		require.NoError(t, err)
+
+		g, err := graph.NewGraph("support").
+			WithTracerProvider(provider).
+			AddNodes(
+				graph.NewNode("flaky").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+					if attempts++; attempts < 3 {
+						return nil, errors.New("upstream busy")
+					}
+					return state, nil
+				}).WithRetries(2, time.Millisecond).Build(),
+				graph.NewNode("lookup").WithSubGraph(child).Build(),
+			).
+			Connect("flaky", "lookup").
+			Connect("lookup", "END").
+			SetEntryPoint("flaky").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		// The execution span joins the trace of the caller
+		parentCtx, parent := (&recordingTracer{provider: provider}).Start(context.Background(), "request")
+		_, err = runnable.InvokeWithOptions(parentCtx, graph.NewState("traced"), graph.WithExecutionID("exec_traced"))
+		require.NoError(t, err)
+		parent.End()
+
+		execution := provider.find("graph support")
+		require.NotNil(t, execution)
+		assert.True(t, execution.ended)
+		assert.Equal(t, parent.SpanContext().SpanID(), execution.parent)
+		assert.Equal(t, "support", execution.attribute(graph.AttrGraphID).AsString())
+		assert.Equal(t, "exec_traced", execution.attribute(graph.AttrExecutionID).AsString())
+		assert.Equal(t, graph.ExecutionStatusSuccess, execution.attribute(graph.AttrExecutionStatus).AsString())
+		assert.Equal(t, int64(2), execution.attribute(graph.AttrExecutionSteps).AsInt64())
+		assert.Equal(t, codes.Unset, execution.status)
+
+		flaky := provider.find("node flaky")
+		require.NotNil(t, flaky)
+		assert.True(t, flaky.ended)
+		assert.Equal(t, execution.context.SpanID(), flaky.parent)
+		assert.Equal(t, "flaky", flaky.attribute(graph.AttrNodeID).AsString())
+		assert.Equal(t, string(graph.NodeTypeFunction), flaky.attribute(graph.AttrNodeType).AsString())
+		assert.Equal(t, int64(2), flaky.attribute(graph.AttrNodeRetries).AsInt64())
+		assert.Equal(t, attribute.INT64, flaky.attribute(graph.AttrNodeDurationMs).Type())
+		assert.Equal(t, []string{"retry", "retry"}, flaky.events)
+
+		// Sub-graphs report to the provider of the enclosing execution
+		lookup := provider.find("node lookup")
+		require.NotNil(t, lookup)
+		subExecution := provider.find("graph lookup")
+		require.NotNil(t, subExecution)
+		assert.Equal(t, lookup.context.SpanID(), subExecution.parent)
+		search := provider.find("node search")
+		require.NotNil(t, search)
+		assert.Equal(t, subExecution.context.SpanID(), search.parent)
+	})
+
+	t.Run("failed node", func(t *testing.T) {
+		provider := newProvider()
+		g, err := graph.NewGraph("failing").
+			WithTracerProvider(provider).
+			AddNode(graph.NewNode("broken").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				return nil, errors.New("boom")
+			}).Build()).
+			Connect("broken", "END").
+			SetEntryPoint("broken").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), graph.NewState("failing"))
+		require.Error(t, err)
+
+		broken := provider.find("node broken")
+		require.NotNil(t, broken)
+		assert.Equal(t, codes.Error, broken.status)
+		assert.Contains(t, broken.events, "exception")
+		execution := provider.find("graph failing")
+		require.NotNil(t, execution)
+		assert.Equal(t, codes.Error, execution.status)
+		assert.Equal(t, graph.ExecutionStatusFailure, execution.attribute(graph.AttrExecutionStatus).AsString())
+	})
+
+	t.Run("untraced graphs emit nothing", func(t *testing.T) {
+		provider := newProvider()
+		g, err := graph.NewGraph("plain").
+			AddNode(graph.NewNode("step").WithFunction(pass).Build()).
+			Connect("step", "END").
+			SetEntryPoint("step").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		ctx, parent := (&recordingTracer{provider: provider}).Start(context.Background(), "request")
+		_, err = runnable.Invoke(ctx, graph.NewState("plain"))
+		require.NoError(t, err)
+		parent.End()
+		assert.Len(t, provider.spans, 1)
+	})
+}
+
+// TestExecutionTraces tests retrieving the traces of recent executions
+// TestExecutionTraces 测试查询最近执行的追踪
+func TestExecutionTraces(t *testing.T) {
+	newRunnable := func(t *testing.T) *graph.Runnable {
+		g, err := graph.NewGraph("traced").
+			AddNode(graph.NewNode("step").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				if fail, _ := state.GetVariable("fail"); fail == true {
+					return nil, errors.New("boom")
+				}
+				return state, nil
+			}).Build()).
+			Connect("step", "END").
+			SetEntryPoint("step").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+	run := func(t *testing.T, runnable *graph.Runnable, id string, fail bool) {
+		state := graph.NewState("state_" + id)
+		state.SetVariable("fail", fail)
+		_, _ = runnable.InvokeWithOptions(context.Background(), state, graph.WithExecutionID(id), graph.WithTracing(true))
+	}
+
+	t.Run("retained by execution ID", func(t *testing.T) {
+		runnable := newRunnable(t)
+		assert.Empty(t, runnable.GetExecutionTrace())
+		run(t, runnable, "exec_ok", false)
+		run(t, runnable, "exec_fail", true)
+
+		trace, err := runnable.GetTrace("exec_ok")
+		require.NoError(t, err)
+		assert.Equal(t, graph.ExecutionStatusSuccess, trace.Status)
+		assert.Equal(t, "state_exec_ok", trace.StateID)
+		assert.Equal(t, []string{"step"}, trace.Path)
+		require.NotEmpty(t, trace.Entries)
+		assert.Equal(t, "node_start", trace.Entries[0].Event)
+
+		failed, err := runnable.GetTrace("exec_fail")
+		require.NoError(t, err)
+		assert.Equal(t, graph.ExecutionStatusFailure, failed.Status)
+		assert.Contains(t, failed.Error, "boom")
+
+		// The last execution is the failed one
+		last := runnable.GetExecutionTrace()
+		require.NotEmpty(t, last)
+		assert.Equal(t, "node_error", last[1].Event)
+
+		recent := runnable.ListRecentExecutions()
+		require.Len(t, recent, 2)
+		assert.Equal(t, "exec_fail", recent[0].ExecutionID)
+		assert.Equal(t, "exec_ok", recent[1].ExecutionID)
+
+		data, err := runnable.ExportTrace("exec_ok")
+		require.NoError(t, err)
+		var exported graph.ExecutionTrace
+		require.NoError(t, json.Unmarshal(data, &exported))
+		assert.Equal(t, "exec_ok", exported.ExecutionID)
+		assert.Len(t, exported.Entries, len(trace.Entries))
+
+		_, err = runnable.GetTrace("exec_missing")
+		assert.ErrorIs(t, err, graph.ErrTraceNotFound)
+	})
+
+	t.Run("bounded ring buffer", func(t *testing.T) {
+		runnable := newRunnable(t).WithTraceRetention(3)
+		for i := 1; i <= 5; i++ {
+			run(t, runnable, fmt.Sprintf("exec_%d", i), false)
+		}
+
+		recent := runnable.ListRecentExecutions()
+		require.Len(t, recent, 3)
+		assert.Equal(t, []string{"exec_5", "exec_4", "exec_3"},
+			[]string{recent[0].ExecutionID, recent[1].ExecutionID, recent[2].ExecutionID})
+		_, err := runnable.GetTrace("exec_2")
+		assert.ErrorIs(t, err, graph.ErrTraceNotFound)
+	})
+
+	t.Run("persisted beyond retention", func(t *testing.T) {
+		store, err := graph.NewFileTraceStore(t.TempDir())
+		require.NoError(t, err)
+		runnable := newRunnable(t).WithTraceRetention(1).WithTraceStore(store)
+		run(t, runnable, "exec_old", false)
+		run(t, runnable, "exec_new", false)
+
+		trace, err := runnable.GetTrace("exec_old")
+		require.NoError(t, err)
+		assert.Equal(t, "exec_old", trace.ExecutionID)
+		assert.NotEmpty(t, trace.Entries)
+
+		_, err = store.LoadTrace(context.Background(), "../escape")
+		assert.Error(t, err)
+		_, err = runnable.GetTrace("exec_missing")
+		assert.ErrorIs(t, err, graph.ErrTraceNotFound)
+	})
+}
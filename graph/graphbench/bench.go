@@ -0,0 +1,547 @@
+// Package graphbench provides a sampling-based load test harness for compiled graphs.
+// 包 graphbench 为已编译的图提供基于采样的压力测试工具。
+//
+// Run replays a corpus of recorded input states against a Runnable at a configurable rate
+// and reports throughput, latency distribution, error rates and lock contention hotspots.
+// Use FakeModel in place of real providers to measure the engine itself.
+// Run 以可配置的速率将录制的输入状态语料回放到 Runnable 上，
+// 报告吞吐量、延迟分布、错误率和锁竞争热点。使用 FakeModel 替代真实服务商可单独测量引擎本身。
+package graphbench
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/graph"
+)
+
+// ================================
+// Configuration 配置
+// ================================
+
+// Config controls how the corpus is replayed.
+// Config 控制语料的回放方式。
+type Config struct {
+	// QPS is the target request rate. Zero runs closed-loop, each worker starting a new
+	// request as soon as the previous one finishes.
+	// QPS 目标请求速率。为零时以闭环方式运行，每个工作协程完成一个请求后立即发起下一个。
+	QPS float64
+
+	// Concurrency limits the number of in-flight requests; defaults to 8. When every worker
+	// is busy the dispatcher waits, so the achieved rate may fall below QPS.
+	// Concurrency 限制同时进行的请求数，默认8。所有工作协程都忙时调度会等待，实际速率可能低于 QPS。
+	Concurrency int
+
+	// Requests stops the run after this many requests.
+	// Requests 发出该数量的请求后停止。
+	Requests int
+
+	// Duration stops the run after this long. When neither Requests nor Duration is set,
+	// every corpus entry is replayed once.
+	// Duration 运行该时长后停止。Requests 与 Duration 均未设置时，语料中每个状态回放一次。
+	Duration time.Duration
+
+	// Sequential replays the corpus in order instead of sampling it randomly.
+	// Sequential 按顺序回放语料，而不是随机采样。
+	Sequential bool
+
+	// Seed seeds the random sampling, making runs reproducible.
+	// Seed 随机采样的种子，使多次运行可复现。
+	Seed int64
+
+	// Timeout bounds each request; zero means no per-request timeout.
+	// Timeout 单个请求的超时时间，为零表示不限制。
+	Timeout time.Duration
+
+	// Options are passed to every invocation.
+	// Options 传递给每次调用的执行选项。
+	Options []graph.ExecutionOption
+
+	// LockProfileRate enables mutex profiling during the run, sampling on average 1 in
+	// LockProfileRate contention events (see runtime.SetMutexProfileFraction). Zero disables it.
+	// LockProfileRate 在运行期间启用互斥锁分析，平均每 LockProfileRate 次竞争采样一次
+	// （见 runtime.SetMutexProfileFraction），为零表示不启用。
+	LockProfileRate int
+
+	// TopContention is the number of contention hotspots reported; defaults to 10.
+	// TopContention 报告的锁竞争热点数量，默认10。
+	TopContention int
+}
+
+// ================================
+// Report 报告
+// ================================
+
+// LatencyStats summarizes the latency distribution of successful and failed requests.
+// LatencyStats 汇总所有请求（含失败请求）的延迟分布。
+type LatencyStats struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// ErrorCount counts requests that failed with the same error message.
+// ErrorCount 统计以相同错误信息失败的请求数。
+type ErrorCount struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// Contention is a lock contention hotspot, attributed to the first caller outside the
+// runtime and sync packages. Cycles are only comparable within one report; Share is the
+// fraction of all contention cycles recorded during the run.
+// Contention 表示一个锁竞争热点，归属到 runtime 与 sync 包之外的第一个调用方。
+// Cycles 只在同一份报告内可比较；Share 为其在本次运行所记录竞争周期中的占比。
+type Contention struct {
+	Function string  `json:"function"`
+	Location string  `json:"location"`
+	Count    int64   `json:"count"`
+	Cycles   int64   `json:"cycles"`
+	Share    float64 `json:"share"`
+}
+
+// Report contains the results of a load test.
+// Report 包含压力测试的结果。
+type Report struct {
+	Requests   int           `json:"requests"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	Duration   time.Duration `json:"duration"`
+	TargetQPS  float64       `json:"target_qps,omitempty"`
+	Throughput float64       `json:"throughput"`
+	ErrorRate  float64       `json:"error_rate"`
+	Latency    LatencyStats  `json:"latency"`
+	Errors     []ErrorCount  `json:"errors,omitempty"`
+	Contention []Contention  `json:"contention,omitempty"`
+}
+
+// String renders the report as a human-readable summary.
+// String 将报告渲染为可读的摘要。
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests:   %d (%d succeeded, %d failed, error rate %.2f%%)\n",
+		r.Requests, r.Succeeded, r.Failed, r.ErrorRate*100)
+	fmt.Fprintf(&b, "duration:   %s\n", r.Duration.Round(time.Millisecond))
+	if r.TargetQPS > 0 {
+		fmt.Fprintf(&b, "throughput: %.2f req/s (target %.2f)\n", r.Throughput, r.TargetQPS)
+	} else {
+		fmt.Fprintf(&b, "throughput: %.2f req/s\n", r.Throughput)
+	}
+	l := r.Latency
+	fmt.Fprintf(&b, "latency:    min %s  mean %s  p50 %s  p90 %s  p95 %s  p99 %s  max %s\n",
+		l.Min, l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+	if len(r.Errors) > 0 {
+		b.WriteString("errors:\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "  %6d  %s\n", e.Count, e.Error)
+		}
+	}
+	if len(r.Contention) > 0 {
+		b.WriteString("lock contention:\n")
+		for _, c := range r.Contention {
+			fmt.Fprintf(&b, "  %5.1f%%  %6d  %s (%s)\n", c.Share*100, c.Count, c.Function, c.Location)
+		}
+	}
+	return b.String()
+}
+
+// ================================
+// Runner 运行器
+// ================================
+
+// Run replays the corpus against the runnable and reports the results. Each request
+// executes a clone of a sampled corpus state with a unique ID. Request failures are
+// reported, not returned; an error is returned only for invalid arguments.
+// Run 将语料回放到 runnable 上并报告结果。每个请求执行采样状态的克隆，并分配唯一ID。
+// 请求失败记录在报告中而不作为错误返回，只有参数无效时才返回错误。
+func Run(ctx context.Context, runnable *graph.Runnable, corpus []*graph.State, config Config) (*Report, error) {
+	if runnable == nil {
+		return nil, errors.New("runnable is nil")
+	}
+	if len(corpus) == 0 {
+		return nil, errors.New("corpus is empty")
+	}
+	if config.QPS < 0 {
+		return nil, fmt.Errorf("invalid QPS: %v", config.QPS)
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 8
+	}
+	if config.TopContention <= 0 {
+		config.TopContention = 10
+	}
+	if config.Requests <= 0 && config.Duration <= 0 {
+		config.Requests = len(corpus)
+	}
+
+	var before map[string]*Contention
+	if config.LockProfileRate > 0 {
+		previous := runtime.SetMutexProfileFraction(config.LockProfileRate)
+		defer runtime.SetMutexProfileFraction(previous)
+		before = contentionSnapshot()
+	}
+
+	rec := &recorder{errors: make(map[string]int)}
+	jobs := make(chan *graph.State)
+	var wg sync.WaitGroup
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for state := range jobs {
+				rec.record(invoke(ctx, runnable, state, config))
+			}
+		}()
+	}
+
+	start := time.Now()
+	dispatch(ctx, corpus, config, jobs)
+	wg.Wait()
+
+	report := rec.report(time.Since(start))
+	report.TargetQPS = config.QPS
+	if config.LockProfileRate > 0 {
+		report.Contention = contentionDiff(before, contentionSnapshot(), config.TopContention)
+	}
+	return report, nil
+}
+
+// result is the outcome of a single request.
+type result struct {
+	latency time.Duration
+	err     error
+}
+
+// invoke executes a single request.
+func invoke(ctx context.Context, runnable *graph.Runnable, state *graph.State, config Config) result {
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	_, err := runnable.InvokeWithOptions(ctx, state, config.Options...)
+	return result{latency: time.Since(start), err: err}
+}
+
+// dispatch sends sampled states to the workers until a stop condition is reached,
+// then closes jobs.
+func dispatch(ctx context.Context, corpus []*graph.State, config Config, jobs chan<- *graph.State) {
+	defer close(jobs)
+
+	var deadline <-chan time.Time
+	if config.Duration > 0 {
+		timer := time.NewTimer(config.Duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	var tick <-chan time.Time
+	if config.QPS > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / config.QPS))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	rng := rand.New(rand.NewSource(config.Seed))
+	for i := 0; config.Requests <= 0 || i < config.Requests; i++ {
+		if tick != nil && i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline:
+				return
+			case <-tick:
+			}
+		}
+
+		index := i % len(corpus)
+		if !config.Sequential {
+			index = rng.Intn(len(corpus))
+		}
+		state := corpus[index].Clone()
+		state.ID = fmt.Sprintf("%s-bench-%d", corpus[index].ID, i)
+		state.CreatedAt = state.UpdatedAt
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			return
+		case jobs <- state:
+		}
+	}
+}
+
+// recorder collects request results.
+type recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	failed    int
+	errors    map[string]int
+}
+
+// record adds a request result.
+func (r *recorder) record(res result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, res.latency)
+	if res.err != nil {
+		r.failed++
+		r.errors[res.err.Error()]++
+	}
+}
+
+// report builds the report from the collected results.
+func (r *recorder) report(elapsed time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{
+		Requests:  len(r.latencies),
+		Succeeded: len(r.latencies) - r.failed,
+		Failed:    r.failed,
+		Duration:  elapsed,
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Requests) / elapsed.Seconds()
+	}
+	if report.Requests > 0 {
+		report.ErrorRate = float64(r.failed) / float64(report.Requests)
+		report.Latency = latencyStats(r.latencies)
+	}
+	for message, count := range r.errors {
+		report.Errors = append(report.Errors, ErrorCount{Error: message, Count: count})
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		if report.Errors[i].Count != report.Errors[j].Count {
+			return report.Errors[i].Count > report.Errors[j].Count
+		}
+		return report.Errors[i].Error < report.Errors[j].Error
+	})
+	return report
+}
+
+// latencyStats computes the distribution of a non-empty set of latencies.
+func latencyStats(latencies []time.Duration) LatencyStats {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	percentile := func(p float64) time.Duration {
+		index := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if index < 0 {
+			index = 0
+		}
+		return sorted[index]
+	}
+	return LatencyStats{
+		Min:  sorted[0],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// ================================
+// Lock Contention 锁竞争分析
+// ================================
+
+// contentionSnapshot aggregates the cumulative mutex profile by hotspot.
+func contentionSnapshot() map[string]*Contention {
+	records := make([]runtime.BlockProfileRecord, 64)
+	for {
+		n, ok := runtime.MutexProfile(records)
+		if ok {
+			records = records[:n]
+			break
+		}
+		records = make([]runtime.BlockProfileRecord, n+64)
+	}
+
+	sites := make(map[string]*Contention)
+	for _, record := range records {
+		function, location := hotspot(record.Stack())
+		key := function + " " + location
+		site, ok := sites[key]
+		if !ok {
+			site = &Contention{Function: function, Location: location}
+			sites[key] = site
+		}
+		site.Count += record.Count
+		site.Cycles += record.Cycles
+	}
+	return sites
+}
+
+// contentionDiff returns the top hotspots recorded between two snapshots.
+func contentionDiff(before, after map[string]*Contention, top int) []Contention {
+	var total int64
+	var result []Contention
+	for key, site := range after {
+		delta := *site
+		if previous, ok := before[key]; ok {
+			delta.Count -= previous.Count
+			delta.Cycles -= previous.Cycles
+		}
+		if delta.Count <= 0 || delta.Cycles <= 0 {
+			continue
+		}
+		total += delta.Cycles
+		result = append(result, delta)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Cycles > result[j].Cycles })
+	if len(result) > top {
+		result = result[:top]
+	}
+	for i := range result {
+		result[i].Share = float64(result[i].Cycles) / float64(total)
+	}
+	return result
+}
+
+// hotspot returns the first frame of the stack outside the runtime and sync packages.
+func hotspot(stack []uintptr) (string, string) {
+	frames := runtime.CallersFrames(stack)
+	var first runtime.Frame
+	for {
+		frame, more := frames.Next()
+		if first.Function == "" {
+			first = frame
+		}
+		if !strings.HasPrefix(frame.Function, "runtime.") &&
+			!strings.HasPrefix(frame.Function, "sync.") &&
+			!strings.HasPrefix(frame.Function, "internal/") {
+			return frame.Function, fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return first.Function, fmt.Sprintf("%s:%d", filepath.Base(first.File), first.Line)
+		}
+	}
+}
+
+// ================================
+// Corpus 语料
+// ================================
+
+// LoadCorpus reads recorded input states from a file or a directory of *.json and
+// *.jsonl files. A file may contain a JSON array of states or a sequence of state objects
+// (one per line for JSONL).
+// LoadCorpus 从文件或包含 *.json、*.jsonl 文件的目录中读取录制的输入状态。
+// 文件可以是状态的JSON数组，也可以是连续的状态对象（JSONL 每行一个）。
+func LoadCorpus(path string) ([]*graph.State, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.json", "*.jsonl"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	var corpus []*graph.State
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read corpus: %w", err)
+		}
+		states, err := decodeStates(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse corpus file %s: %w", file, err)
+		}
+		corpus = append(corpus, states...)
+	}
+	return corpus, nil
+}
+
+// decodeStates decodes a JSON array of states or a sequence of state objects.
+func decodeStates(data []byte) ([]*graph.State, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var states []*graph.State
+		if err := json.Unmarshal(data, &states); err != nil {
+			return nil, err
+		}
+		return normalizeStates(states), nil
+	}
+
+	var states []*graph.State
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var state graph.State
+		err := decoder.Decode(&state)
+		if err == io.EOF {
+			return normalizeStates(states), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, &state)
+	}
+}
+
+// normalizeStates initializes maps missing from decoded states.
+func normalizeStates(states []*graph.State) []*graph.State {
+	for _, state := range states {
+		if state.Variables == nil {
+			state.Variables = make(map[string]interface{})
+		}
+		if state.Metadata == nil {
+			state.Metadata = make(map[string]interface{})
+		}
+	}
+	return states
+}
+
+// SaveCorpus writes states as JSONL, one state per line, for later replay with LoadCorpus.
+// SaveCorpus 将状态以 JSONL 格式写入文件（每行一个），供 LoadCorpus 回放。
+func SaveCorpus(path string, states []*graph.State) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write corpus: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, state := range states {
+		if err := encoder.Encode(state); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to encode state %s: %w", state.ID, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write corpus: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,105 @@
+package graphbench_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/graph"
+	"github.com/sjzsdu/langchaingo-cn/graph/graphbench"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func benchGraph(t *testing.T, model llms.Model) *graph.Runnable {
+	g := graph.NewGraph("bench").
+		AddNodes(
+			graph.NewNode("answer").
+				WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+					question, _ := state.GetVariable("question")
+					reply, err := llms.GenerateFromSinglePrompt(ctx, model, fmt.Sprint(question))
+					if err != nil {
+						return nil, err
+					}
+					state.SetVariable("answer", reply)
+					return state, nil
+				}).
+				Build(),
+		).
+		Connect("answer", "END").
+		SetEntryPoint("answer").
+		Build()
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestRun(t *testing.T) {
+	model := graphbench.NewFakeModel("ok", time.Millisecond).WithJitter(time.Millisecond)
+	runnable := benchGraph(t, model)
+
+	var corpus []*graph.State
+	for i := 0; i < 3; i++ {
+		state := graph.NewState(fmt.Sprintf("input-%d", i))
+		state.SetVariable("question", fmt.Sprintf("question %d", i))
+		corpus = append(corpus, state)
+	}
+
+	report, err := graphbench.Run(context.Background(), runnable, corpus, graphbench.Config{
+		Requests:        40,
+		Concurrency:     4,
+		Seed:            1,
+		LockProfileRate: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 40, report.Requests)
+	assert.Equal(t, 40, report.Succeeded)
+	assert.Equal(t, int64(40), model.Calls())
+	assert.Zero(t, report.ErrorRate)
+	assert.Greater(t, report.Throughput, 0.0)
+	assert.GreaterOrEqual(t, report.Latency.P50, time.Millisecond)
+	assert.LessOrEqual(t, report.Latency.P50, report.Latency.P99)
+	assert.LessOrEqual(t, report.Latency.P99, report.Latency.Max)
+	assert.Contains(t, report.String(), "throughput:")
+
+	// Corpus states are cloned, never mutated
+	_, answered := corpus[0].GetVariable("answer")
+	assert.False(t, answered)
+
+	// Paced replay with injected failures
+	failing := graphbench.NewFakeModel("ok", 0).WithErrorRate(1)
+	report, err = graphbench.Run(context.Background(), benchGraph(t, failing), corpus, graphbench.Config{
+		QPS:        200,
+		Sequential: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Requests)
+	assert.Equal(t, 3, report.Failed)
+	assert.Equal(t, 1.0, report.ErrorRate)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 3, report.Errors[0].Count)
+
+	_, err = graphbench.Run(context.Background(), runnable, nil, graphbench.Config{})
+	assert.Error(t, err)
+}
+
+func TestCorpus(t *testing.T) {
+	state := graph.NewState("recorded")
+	state.SetVariable("question", "你好")
+
+	path := filepath.Join(t.TempDir(), "corpus.jsonl")
+	require.NoError(t, graphbench.SaveCorpus(path, []*graph.State{state, state}))
+
+	corpus, err := graphbench.LoadCorpus(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, corpus, 2)
+	assert.Equal(t, "recorded", corpus[0].ID)
+	assert.Equal(t, "你好", corpus[1].Variables["question"])
+
+	_, err = graphbench.LoadCorpus(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package graphbench
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ================================
+// Fake Provider 模拟服务商
+// ================================
+
+// ErrFakeFailure is returned by FakeModel for injected failures.
+// ErrFakeFailure 是 FakeModel 注入失败时返回的错误。
+var ErrFakeFailure = errors.New("graphbench: injected provider failure")
+
+// FakeModel is an llms.Model that simulates provider latency and failures without network
+// calls, so load tests measure the graph engine rather than the provider.
+// FakeModel 是模拟服务商延迟和失败的 llms.Model，不发起网络请求，
+// 使压力测试测量的是图引擎本身而非服务商。
+type FakeModel struct {
+	// Reply is the generated content.
+	// Reply 生成的内容。
+	Reply string
+
+	// Latency is the base latency of each call.
+	// Latency 每次调用的基础延迟。
+	Latency time.Duration
+
+	// Jitter adds a uniformly distributed random delay in [0, Jitter).
+	// Jitter 额外增加 [0, Jitter) 区间内均匀分布的随机延迟。
+	Jitter time.Duration
+
+	// ErrorRate is the fraction of calls, between 0 and 1, that fail with ErrFakeFailure.
+	// ErrorRate 以 ErrFakeFailure 失败的调用比例，取值 0 到 1。
+	ErrorRate float64
+
+	calls atomic.Int64
+	mu    sync.Mutex
+	rng   *rand.Rand
+}
+
+var _ llms.Model = (*FakeModel)(nil)
+
+// NewFakeModel creates a fake model with the given reply and latency.
+// NewFakeModel 使用给定的回复和延迟创建模拟模型。
+func NewFakeModel(reply string, latency time.Duration) *FakeModel {
+	return &FakeModel{Reply: reply, Latency: latency}
+}
+
+// WithJitter sets the random latency jitter.
+// WithJitter 设置随机延迟抖动。
+func (m *FakeModel) WithJitter(jitter time.Duration) *FakeModel {
+	m.Jitter = jitter
+	return m
+}
+
+// WithErrorRate sets the fraction of failing calls.
+// WithErrorRate 设置失败调用的比例。
+func (m *FakeModel) WithErrorRate(rate float64) *FakeModel {
+	m.ErrorRate = rate
+	return m
+}
+
+// Calls returns the number of calls made to the model.
+// Calls 返回模型被调用的次数。
+func (m *FakeModel) Calls() int64 {
+	return m.calls.Load()
+}
+
+// GenerateContent implements the llms.Model interface.
+// GenerateContent 实现 llms.Model 接口。
+func (m *FakeModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls.Add(1)
+	delay, fail := m.sample()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if fail {
+		return nil, ErrFakeFailure
+	}
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: m.Reply, StopReason: "stop"}},
+	}, nil
+}
+
+// Call implements the llms.Model interface.
+// Call 实现 llms.Model 接口。
+func (m *FakeModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// sample draws the latency and failure of a call.
+func (m *FakeModel) sample() (time.Duration, bool) {
+	if m.Jitter <= 0 && m.ErrorRate <= 0 {
+		return m.Latency, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rng == nil {
+		m.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	delay := m.Latency
+	if m.Jitter > 0 {
+		delay += time.Duration(m.rng.Int63n(int64(m.Jitter)))
+	}
+	return delay, m.ErrorRate > 0 && m.rng.Float64() < m.ErrorRate
+}
@@ -0,0 +1,271 @@
+// Package graphtest provides golden-file helpers for regression testing graph executions.
+// 包 graphtest 为图执行的回归测试提供黄金文件工具。
+//
+// A golden file stores the normalized final State of an execution as JSON. Timestamps,
+// durations, UUIDs and the state ID are normalized so that repeated runs produce identical
+// snapshots. Run tests with -update-golden (or GRAPH_UPDATE_GOLDEN=1) to rewrite the files.
+// 黄金文件以JSON保存执行结束时归一化后的状态。时间戳、耗时、UUID和状态ID会被归一化，
+// 使重复运行产生相同的快照。使用 -update-golden（或 GRAPH_UPDATE_GOLDEN=1）运行测试以重写文件。
+package graphtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/graph"
+)
+
+// update rewrites golden files instead of comparing against them.
+var update = flag.Bool("update-golden", false, "rewrite graph golden files")
+
+var (
+	timestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+	uuidPattern      = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+)
+
+// Option configures snapshot normalization.
+// Option 配置快照的归一化方式。
+type Option func(*options)
+
+type options struct {
+	dir             string
+	ignoreVariables map[string]bool
+	ignoreMetadata  map[string]bool
+	replacements    []replacement
+	withoutHistory  bool
+	withoutMessages bool
+}
+
+type replacement struct {
+	pattern *regexp.Regexp
+	value   string
+}
+
+// WithDir sets the directory holding golden files. Defaults to "testdata".
+// WithDir 设置黄金文件目录，默认为 "testdata"。
+func WithDir(dir string) Option {
+	return func(o *options) { o.dir = dir }
+}
+
+// IgnoreVariables removes nondeterministic variables from the snapshot.
+// IgnoreVariables 从快照中移除不确定的变量。
+func IgnoreVariables(keys ...string) Option {
+	return func(o *options) {
+		for _, key := range keys {
+			o.ignoreVariables[key] = true
+		}
+	}
+}
+
+// IgnoreMetadata removes nondeterministic metadata from the snapshot.
+// IgnoreMetadata 从快照中移除不确定的元数据。
+func IgnoreMetadata(keys ...string) Option {
+	return func(o *options) {
+		for _, key := range keys {
+			o.ignoreMetadata[key] = true
+		}
+	}
+}
+
+// WithReplacement replaces every match of pattern in string values with value,
+// e.g. to normalize generated IDs that are not UUIDs.
+// WithReplacement 将字符串值中匹配 pattern 的部分替换为 value，
+// 例如归一化非UUID格式的生成ID。
+func WithReplacement(pattern *regexp.Regexp, value string) Option {
+	return func(o *options) {
+		o.replacements = append(o.replacements, replacement{pattern: pattern, value: value})
+	}
+}
+
+// WithoutHistory omits the execution history from the snapshot.
+// WithoutHistory 从快照中省略执行历史。
+func WithoutHistory() Option {
+	return func(o *options) { o.withoutHistory = true }
+}
+
+// WithoutMessages omits the messages from the snapshot.
+// WithoutMessages 从快照中省略消息。
+func WithoutMessages() Option {
+	return func(o *options) { o.withoutMessages = true }
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		dir:             "testdata",
+		ignoreVariables: make(map[string]bool),
+		ignoreMetadata:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Snapshot returns the normalized, indented JSON representation of state.
+// Snapshot 返回归一化并缩进后的状态JSON表示。
+func Snapshot(state *graph.State, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+
+	doc["id"] = "<id>"
+	delete(doc, "created_at")
+	delete(doc, "updated_at")
+	removeKeys(doc["variables"], o.ignoreVariables)
+	removeKeys(doc["metadata"], o.ignoreMetadata)
+
+	if o.withoutMessages {
+		delete(doc, "messages")
+	}
+	if o.withoutHistory {
+		delete(doc, "history")
+	} else if history, ok := doc["history"].([]interface{}); ok {
+		for _, step := range history {
+			if step, ok := step.(map[string]interface{}); ok {
+				delete(step, "start_time")
+				delete(step, "end_time")
+				delete(step, "duration")
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(o.normalize(doc)); err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// AssertGolden compares the snapshot of state with the golden file <dir>/<name>.golden.json,
+// writing the file instead when updating is enabled or the file does not exist yet.
+// AssertGolden 将状态快照与黄金文件 <dir>/<name>.golden.json 比较，
+// 启用更新或文件尚不存在时改为写入该文件。
+func AssertGolden(t testing.TB, name string, state *graph.State, opts ...Option) {
+	t.Helper()
+
+	snapshot, err := Snapshot(state, opts...)
+	if err != nil {
+		t.Fatalf("graphtest: %v", err)
+	}
+
+	path := filepath.Join(newOptions(opts).dir, name+".golden.json")
+	want, err := os.ReadFile(path)
+	if shouldUpdate() || os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("graphtest: failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, snapshot, 0644); err != nil {
+			t.Fatalf("graphtest: failed to write golden file: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("graphtest: failed to read golden file: %v", err)
+	}
+
+	if !bytes.Equal(want, snapshot) {
+		t.Errorf("graphtest: state does not match %s (run with -update-golden to accept):\n%s",
+			path, Diff(string(want), string(snapshot)))
+	}
+}
+
+// Diff returns a line diff between want and got, prefixing removed lines with "-"
+// and added lines with "+".
+// Diff 返回 want 与 got 之间的逐行差异，删除的行以 "-" 开头，新增的行以 "+" 开头。
+func Diff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
+
+// shouldUpdate reports whether golden files should be rewritten.
+func shouldUpdate() bool {
+	return *update || os.Getenv("GRAPH_UPDATE_GOLDEN") == "1"
+}
+
+// removeKeys deletes keys from a decoded JSON object.
+func removeKeys(value interface{}, keys map[string]bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key := range keys {
+		delete(object, key)
+	}
+}
+
+// normalize replaces nondeterministic string values throughout a decoded JSON value.
+func (o *options) normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = o.normalize(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = o.normalize(item)
+		}
+		return v
+	case string:
+		if timestampPattern.MatchString(v) {
+			return "<timestamp>"
+		}
+		v = uuidPattern.ReplaceAllString(v, "<uuid>")
+		for _, r := range o.replacements {
+			v = r.pattern.ReplaceAllString(v, r.value)
+		}
+		return v
+	default:
+		return v
+	}
+}
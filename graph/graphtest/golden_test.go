@@ -0,0 +1,62 @@
+package graphtest_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/graph"
+	"github.com/sjzsdu/langchaingo-cn/graph/graphtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertGolden(t *testing.T) {
+	g := graph.NewGraph("greeting").
+		AddNodes(
+			graph.NewNode("greet").
+				WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+					name, _ := state.GetVariable("name")
+					state.SetVariable("greeting", "你好, "+name.(string))
+					state.SetVariable("request_id", "req-"+state.UpdatedAt.Format("150405.000"))
+					return state, nil
+				}).
+				Build(),
+		).
+		Connect("greet", "END").
+		SetEntryPoint("greet").
+		Build()
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	state := graph.NewState("run-1")
+	state.SetVariable("name", "世界")
+	result, err := runnable.Invoke(context.Background(), state)
+	require.NoError(t, err)
+
+	graphtest.AssertGolden(t, "greeting", result,
+		graphtest.WithReplacement(regexp.MustCompile(`req-\S+`), "req-<n>"))
+}
+
+func TestSnapshotNormalization(t *testing.T) {
+	state := graph.NewState("550e8400-e29b-41d4-a716-446655440000")
+	state.SetVariable("trace", "span 550e8400-e29b-41d4-a716-446655440000")
+	state.SetVariable("noise", 42)
+	state.SetMetadata("started", "2024-05-01T10:00:00Z")
+
+	snapshot, err := graphtest.Snapshot(state, graphtest.IgnoreVariables("noise"))
+	require.NoError(t, err)
+	text := string(snapshot)
+	assert.Contains(t, text, `"id": "<id>"`)
+	assert.Contains(t, text, `"trace": "span <uuid>"`)
+	assert.Contains(t, text, `"started": "<timestamp>"`)
+	assert.NotContains(t, text, "noise")
+	assert.NotContains(t, text, "created_at")
+}
+
+func TestDiff(t *testing.T) {
+	diff := graphtest.Diff("a\nb\nc", "a\nx\nc")
+	assert.Equal(t, []string{"  a", "- b", "+ x", "  c"}, strings.Split(strings.TrimRight(diff, "\n"), "\n"))
+}
@@ -0,0 +1,204 @@
+// Package graph - Human-in-the-loop interrupts
+// 包 graph - 人工介入中断
+package graph
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ================================
+// Human Input 人工输入
+// ================================
+
+// ErrExecutionInterrupted is matched by the error of executions paused at a human input node.
+// ErrExecutionInterrupted 表示执行在人工输入节点暂停，可通过 errors.Is 判断。
+var ErrExecutionInterrupted = errors.New("execution is waiting for human input")
+
+// Metadata keys of the state saved when an execution pauses for human input.
+// 执行因等待人工输入而暂停时，所保存状态使用的元数据键。
+const (
+	// HumanInputNodeKey holds the ID of the node waiting for input.
+	HumanInputNodeKey = "human_input_node"
+	// HumanInputStateIDKey holds the ID of the paused state; the saved copy is stored under the token.
+	HumanInputStateIDKey = "human_input_state_id"
+)
+
+// humanInputAnsweredKey marks that the response for the node is in the state.
+const humanInputAnsweredKey = "human_input_answered"
+
+// HumanInputRequest describes an execution paused at a human input node.
+// HumanInputRequest 描述在人工输入节点暂停的执行。
+type HumanInputRequest struct {
+	// Token resumes the execution with Runnable.Resume.
+	Token string `json:"token"`
+
+	// NodeID is the ID of the human input node.
+	NodeID string `json:"node_id"`
+
+	// StateID is the ID of the paused state.
+	StateID string `json:"state_id"`
+
+	// Prompt tells the human what input is expected.
+	Prompt string `json:"prompt,omitempty"`
+
+	// CreatedAt is when the execution paused.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InterruptError is returned by executions that paused at a human input node. The state
+// returned alongside it is the state before the node.
+// InterruptError 由在人工输入节点暂停的执行返回，同时返回的状态为该节点之前的状态。
+type InterruptError struct {
+	Request HumanInputRequest
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *InterruptError) Error() string {
+	return fmt.Sprintf("execution is waiting for human input at node %s", e.Request.NodeID)
+}
+
+// Unwrap returns ErrExecutionInterrupted.
+// Unwrap 返回 ErrExecutionInterrupted。
+func (e *InterruptError) Unwrap() error {
+	return ErrExecutionInterrupted
+}
+
+// PendingInput returns the request of an execution that paused for human input.
+// PendingInput 返回因等待人工输入而暂停的执行的请求。
+func PendingInput(err error) (*HumanInputRequest, bool) {
+	var interrupt *InterruptError
+	if !errors.As(err, &interrupt) {
+		return nil, false
+	}
+	return &interrupt.Request, true
+}
+
+// WithHumanInput turns the node into a human input node. An execution reaching it pauses:
+// the state is saved through the graph's state manager and the execution returns an
+// InterruptError whose token is passed to Runnable.Resume together with the response, which
+// is stored in the outputKey variable (the node ID when empty) before the execution goes on.
+// Human input nodes only pause the top-level graph, not sub-graphs or parallel branches.
+// WithHumanInput 将节点设为人工输入节点。执行到达该节点时暂停：状态通过图的状态管理器保存，
+// 执行返回 InterruptError，将其中的令牌与人工回复一起传给 Runnable.Resume 即可继续执行，
+// 回复保存在 outputKey 变量中（为空时使用节点ID）。人工输入节点只能暂停顶层图，不能用于子图或并行分支。
+func (nb *NodeBuilder) WithHumanInput(prompt, outputKey string) *NodeBuilder {
+	if outputKey == "" {
+		outputKey = nb.node.ID
+	}
+	nb.node.Type = NodeTypeHumanInput
+	nb.node.Description = prompt
+	nb.node.Outputs = []ParameterDef{{Name: outputKey, Type: "any", Description: "Human response"}}
+	return nb
+}
+
+// humanInputKey returns the variable a human input node stores the response in.
+func (n *Node) humanInputKey() string {
+	if len(n.Outputs) > 0 {
+		return n.Outputs[0].Name
+	}
+	return n.ID
+}
+
+// executeHumanInput runs a human input node once its response is in the state.
+// executeHumanInput 在状态中已有人工回复时执行人工输入节点。
+func (n *Node) executeHumanInput(ctx context.Context, state *State) (*State, error) {
+	if answered, _ := state.GetMetadata(humanInputAnsweredKey); answered != n.ID {
+		return nil, fmt.Errorf("human input node %s can only pause the top-level graph", n.ID)
+	}
+	delete(state.Metadata, humanInputAnsweredKey)
+	return state, nil
+}
+
+// awaitsHumanInput reports whether the execution must pause before the node.
+func awaitsHumanInput(node *Node, state *State) bool {
+	if node.Type != NodeTypeHumanInput {
+		return false
+	}
+	answered, _ := state.GetMetadata(humanInputAnsweredKey)
+	return answered != node.ID
+}
+
+// pauseForHumanInput saves the state under a new resume token and returns the InterruptError.
+// pauseForHumanInput 以新的恢复令牌保存状态，并返回 InterruptError。
+func (r *Runnable) pauseForHumanInput(execCtx *ExecutionContext, node *Node, state *State) error {
+	if r.graph.stateManager == nil {
+		return fmt.Errorf("human input node %s requires a state manager", node.ID)
+	}
+	token, err := newResumeToken()
+	if err != nil {
+		return err
+	}
+
+	request := HumanInputRequest{
+		Token:     token,
+		NodeID:    node.ID,
+		StateID:   state.ID,
+		Prompt:    node.Description,
+		CreatedAt: time.Now(),
+	}
+	saved := state.Clone()
+	saved.ID = token
+	saved.SetMetadata(HumanInputNodeKey, node.ID)
+	saved.SetMetadata(HumanInputStateIDKey, state.ID)
+	if err := r.graph.stateManager.Save(execCtx.Context, saved); err != nil {
+		return fmt.Errorf("failed to save state for human input node %s: %w", node.ID, err)
+	}
+
+	if execCtx.EnableTracing {
+		r.addTraceEntry(execCtx, node.ID, "interrupt", "Waiting for human input", map[string]interface{}{
+			"token": token,
+		})
+	}
+	return &InterruptError{Request: request}
+}
+
+// Resume continues an execution paused at a human input node. The response is stored in
+// the node's output variable and the execution goes on from the node. A token can be used
+// once; an execution that pauses again returns a new one.
+// Resume 继续在人工输入节点暂停的执行。回复保存在节点的输出变量中，执行从该节点继续。
+// 令牌只能使用一次，再次暂停的执行会返回新的令牌。
+func (r *Runnable) Resume(ctx context.Context, token string, response interface{}, options ...ExecutionOption) (*State, error) {
+	if r.graph.stateManager == nil {
+		return nil, fmt.Errorf("no state manager configured")
+	}
+	state, err := r.graph.stateManager.Load(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("unknown resume token %s: %w", token, err)
+	}
+	nodeID, _ := state.GetMetadata(HumanInputNodeKey)
+	stateID, _ := state.GetMetadata(HumanInputStateIDKey)
+	node, exists := r.graph.GetNode(fmt.Sprint(nodeID))
+	if !exists || node.Type != NodeTypeHumanInput {
+		return nil, fmt.Errorf("resume token %s does not belong to a human input node of graph %s", token, r.graph.ID)
+	}
+	if err := r.graph.stateManager.Delete(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to claim resume token %s: %w", token, err)
+	}
+
+	state.ID = fmt.Sprint(stateID)
+	delete(state.Metadata, HumanInputNodeKey)
+	delete(state.Metadata, HumanInputStateIDKey)
+	state.SetVariable(node.humanInputKey(), response)
+	state.SetMetadata(humanInputAnsweredKey, node.ID)
+
+	opts := append(options[:len(options):len(options)], func(execCtx *ExecutionContext) {
+		execCtx.startNode = node.ID
+	})
+	result, _, err := r.invoke(ctx, state, opts...)
+	return result, err
+}
+
+// newResumeToken returns a random resume token.
+func newResumeToken() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate resume token: %w", err)
+	}
+	return "hitl_" + hex.EncodeToString(buf), nil
+}
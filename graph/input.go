@@ -0,0 +1,180 @@
+// Package graph - Graph input schema
+// 包 graph - 图输入参数定义
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// ================================
+// Input Schema 输入参数定义
+// ================================
+
+// Parameter types recognised by ParameterDef.Type when validating graph inputs.
+// An empty type accepts any value.
+// ParameterDef.Type 在校验图输入时支持的参数类型，为空时接受任意值。
+const (
+	ParameterTypeAny     = "any"
+	ParameterTypeString  = "string"
+	ParameterTypeNumber  = "number"
+	ParameterTypeInteger = "integer"
+	ParameterTypeBoolean = "boolean"
+	ParameterTypeArray   = "array"
+	ParameterTypeObject  = "object"
+)
+
+// ErrInvalidInput is returned by Invoke when the initial state does not satisfy the graph's input schema.
+// ErrInvalidInput 表示初始状态不满足图的输入参数定义。
+var ErrInvalidInput = errors.New("invalid graph input")
+
+// Validate checks that the parameter definition itself is well formed.
+// Validate 检查参数定义本身是否有效。
+func (p ParameterDef) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("parameter name cannot be empty")
+	}
+	switch p.Type {
+	case "", ParameterTypeAny, ParameterTypeString, ParameterTypeNumber, ParameterTypeInteger,
+		ParameterTypeBoolean, ParameterTypeArray, ParameterTypeObject:
+	default:
+		return fmt.Errorf("parameter %s has unsupported type: %s", p.Name, p.Type)
+	}
+	if p.Default != nil && !p.accepts(p.Default) {
+		return fmt.Errorf("parameter %s has a default value of the wrong type: expected %s, got %T", p.Name, p.Type, p.Default)
+	}
+	return nil
+}
+
+// accepts reports whether the value matches the parameter type.
+func (p ParameterDef) accepts(value interface{}) bool {
+	if value == nil {
+		return p.Type == "" || p.Type == ParameterTypeAny
+	}
+
+	v := reflect.ValueOf(value)
+	switch p.Type {
+	case ParameterTypeString:
+		return v.Kind() == reflect.String
+	case ParameterTypeBoolean:
+		return v.Kind() == reflect.Bool
+	case ParameterTypeNumber:
+		return isIntegerKind(v.Kind()) || v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+	case ParameterTypeInteger:
+		if isIntegerKind(v.Kind()) {
+			return true
+		}
+		if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+			f := v.Float()
+			return f == math.Trunc(f) && !math.IsInf(f, 0)
+		}
+		return false
+	case ParameterTypeArray:
+		return v.Kind() == reflect.Slice || v.Kind() == reflect.Array
+	case ParameterTypeObject:
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+	default:
+		return true
+	}
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// WithInputSchema declares the input variables the graph expects in its initial state.
+// They are validated at Invoke time and exported with the graph definition.
+// WithInputSchema 声明图在初始状态中期望的输入变量，调用时校验，并随图定义一同导出。
+func (gb *GraphBuilder) WithInputSchema(params ...ParameterDef) *GraphBuilder {
+	for _, param := range params {
+		if err := param.Validate(); err != nil {
+			gb.errs = append(gb.errs, fmt.Errorf("invalid input schema: %w", err))
+			continue
+		}
+		for _, existing := range gb.graph.InputSchema {
+			if existing.Name == param.Name {
+				gb.errs = append(gb.errs, fmt.Errorf("duplicate input parameter: %s", param.Name))
+				break
+			}
+		}
+		gb.graph.InputSchema = append(gb.graph.InputSchema, param)
+	}
+	return gb
+}
+
+// ValidateInput checks the state against the input schema and fills in defaults for missing
+// optional variables. All problems are reported together; the error matches ErrInvalidInput.
+// ValidateInput 按输入参数定义校验状态，并为缺失的可选变量填充默认值。
+// 所有问题会一并报告，返回的错误可与 ErrInvalidInput 匹配。
+func (g *Graph) ValidateInput(state *State) error {
+	return g.validateInput(state, g.Config.Locale)
+}
+
+// validateInput validates the state and renders errors in the given locale.
+func (g *Graph) validateInput(state *State, locale Locale) error {
+	en, zh := checkParameters(g.InputSchema, state)
+	if len(en) > 0 {
+		return newGraphError(locale, ErrCodeInvalidInput, "", nil,
+			fmt.Sprintf("%s for graph %s: %s", ErrInvalidInput, g.ID, strings.Join(en, "; ")),
+			fmt.Sprintf("图 %s 的输入无效: %s", g.ID, strings.Join(zh, "; ")))
+	}
+	return nil
+}
+
+// validateNodeInput checks the state against the declared inputs of a node, filling in
+// defaults for missing optional variables. The error matches ErrInvalidInput.
+func (g *Graph) validateNodeInput(node *Node, state *State, locale Locale) error {
+	en, zh := checkParameters(node.Inputs, state)
+	if len(en) > 0 {
+		return newGraphError(locale, ErrCodeInvalidInput, node.ID, nil,
+			fmt.Sprintf("%s for node %s: %s", ErrInvalidInput, node.ID, strings.Join(en, "; ")),
+			fmt.Sprintf("节点 %s 的输入无效: %s", node.ID, strings.Join(zh, "; ")))
+	}
+	return nil
+}
+
+// checkParameters validates the state variables against the parameters and fills in
+// defaults, returning the problems found in English and Chinese.
+func checkParameters(params []ParameterDef, state *State) (en, zh []string) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	var variables map[string]interface{}
+	if state != nil {
+		variables = state.Variables
+	}
+
+	for _, param := range params {
+		value, exists := variables[param.Name]
+		if !exists {
+			switch {
+			case param.Required:
+				en = append(en, fmt.Sprintf("missing required variable %q", param.Name))
+				zh = append(zh, fmt.Sprintf("缺少必填变量 %q", param.Name))
+			case param.Default != nil && state != nil:
+				if state.Variables == nil {
+					state.Variables = make(map[string]interface{})
+				}
+				state.SetVariable(param.Name, param.Default)
+			}
+			continue
+		}
+		if !param.accepts(value) {
+			en = append(en, fmt.Sprintf("variable %q must be %s, got %T", param.Name, param.Type, value))
+			zh = append(zh, fmt.Sprintf("变量 %q 应为 %s 类型，实际为 %T", param.Name, param.Type, value))
+		}
+	}
+	return en, zh
+}
@@ -0,0 +1,248 @@
+// Package graph - Language normalization nodes
+// 包 graph - 语言归一化节点
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ================================
+// Language Normalization 语言归一化
+// ================================
+
+// DetectedLanguageVariable is the variable under which the detected input language is stored.
+// DetectedLanguageVariable 是存储检测到的输入语言的变量名。
+const DetectedLanguageVariable = "detected_language"
+
+// LanguageNormalizerConfig configures NormalizeLanguageNode and RestoreLanguageNode.
+// LanguageNormalizerConfig 配置 NormalizeLanguageNode 和 RestoreLanguageNode。
+type LanguageNormalizerConfig struct {
+	// PivotLanguage is the language used for internal processing, e.g. "en" or "zh".
+	// Empty disables translation and only detects the input language.
+	PivotLanguage string
+
+	// InputKey is the variable holding the user input. Defaults to "input".
+	InputKey string
+
+	// OutputKey is the variable holding the final answer. Defaults to "output".
+	OutputKey string
+
+	// InboundModel translates the input into the pivot language.
+	InboundModel llms.Model
+
+	// OutboundModel translates the answer back. Defaults to InboundModel.
+	OutboundModel llms.Model
+
+	// Detector detects the language of a text. Defaults to DetectLanguage.
+	Detector func(text string) string
+
+	// CostFunc converts token usage of a translation call into cost. Optional.
+	CostFunc func(usage Usage) float64
+
+	// Timeout is the node timeout. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+// withDefaults returns a copy of the config with defaults applied.
+func (c LanguageNormalizerConfig) withDefaults() LanguageNormalizerConfig {
+	if c.InputKey == "" {
+		c.InputKey = "input"
+	}
+	if c.OutputKey == "" {
+		c.OutputKey = "output"
+	}
+	if c.OutboundModel == nil {
+		c.OutboundModel = c.InboundModel
+	}
+	if c.Detector == nil {
+		c.Detector = DetectLanguage
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 30 * time.Second
+	}
+	return c
+}
+
+// NormalizeLanguageNode creates a node that detects the language of the input variable
+// and translates it into the pivot language. The original input is kept in "<InputKey>_original".
+// NormalizeLanguageNode 创建一个节点，检测输入变量的语言并将其翻译为中间语言，
+// 原始输入保存在 "<InputKey>_original" 中。
+func NormalizeLanguageNode(id string, config LanguageNormalizerConfig) *Node {
+	config = config.withDefaults()
+
+	return NewNode(id).
+		WithType(NodeTypeFunction).
+		WithTags(NodeTagLLM).
+		WithTimeout(config.Timeout).
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			input, err := stringVariable(state, config.InputKey)
+			if err != nil {
+				return nil, err
+			}
+
+			language := config.Detector(input)
+			state.SetVariable(DetectedLanguageVariable, language)
+
+			if !needsTranslation(language, config.PivotLanguage) || config.InboundModel == nil {
+				return state, nil
+			}
+
+			translated, err := translateText(ctx, config.InboundModel, input, config.PivotLanguage, config.CostFunc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to translate input: %w", err)
+			}
+			state.SetVariable(config.InputKey+"_original", input)
+			state.SetVariable(config.InputKey, translated)
+			return state, nil
+		}).
+		Build()
+}
+
+// RestoreLanguageNode creates a node that translates the output variable from the pivot
+// language back into the language detected by NormalizeLanguageNode.
+// The pivot-language answer is kept in "<OutputKey>_pivot".
+// RestoreLanguageNode 创建一个节点，将输出变量从中间语言翻译回
+// NormalizeLanguageNode 检测到的语言，中间语言的答案保存在 "<OutputKey>_pivot" 中。
+func RestoreLanguageNode(id string, config LanguageNormalizerConfig) *Node {
+	config = config.withDefaults()
+
+	return NewNode(id).
+		WithType(NodeTypeFunction).
+		WithTags(NodeTagLLM).
+		WithTimeout(config.Timeout).
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			output, err := stringVariable(state, config.OutputKey)
+			if err != nil {
+				return nil, err
+			}
+
+			language, _ := state.GetVariable(DetectedLanguageVariable)
+			target, _ := language.(string)
+			if !needsTranslation(target, config.PivotLanguage) || config.OutboundModel == nil {
+				return state, nil
+			}
+
+			translated, err := translateText(ctx, config.OutboundModel, output, target, config.CostFunc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to translate output: %w", err)
+			}
+			state.SetVariable(config.OutputKey+"_pivot", output)
+			state.SetVariable(config.OutputKey, translated)
+			return state, nil
+		}).
+		Build()
+}
+
+// DetectLanguage detects the dominant language of text using Unicode script counts.
+// It returns "zh", "ja", "ko", "en", or "" when the language cannot be determined.
+// DetectLanguage 根据Unicode字符集统计检测文本的主要语言，
+// 返回 "zh"、"ja"、"ko"、"en"，无法判断时返回空字符串。
+func DetectLanguage(text string) string {
+	var han, kana, hangul, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+
+	switch {
+	case kana > 0:
+		return "ja"
+	case hangul > 0 && hangul >= han:
+		return "ko"
+	case han > 0 && han*4 >= latin:
+		// A Han character carries roughly as much content as a short English word.
+		return "zh"
+	case latin > 0:
+		return "en"
+	default:
+		return ""
+	}
+}
+
+// languageNames maps language codes to names used in translation prompts.
+var languageNames = map[string]string{
+	"zh": "Simplified Chinese",
+	"en": "English",
+	"ja": "Japanese",
+	"ko": "Korean",
+}
+
+// needsTranslation reports whether text in language must be translated to target.
+func needsTranslation(language, target string) bool {
+	return language != "" && target != "" && language != target
+}
+
+// stringVariable returns a string variable or an error if it is missing.
+func stringVariable(state *State, key string) (string, error) {
+	value, exists := state.GetVariable(key)
+	if !exists {
+		return "", fmt.Errorf("variable %s not found", key)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("variable %s is not a string", key)
+	}
+	return text, nil
+}
+
+// translateText translates text into target with model and records the usage.
+func translateText(ctx context.Context, model llms.Model, text, target string, costFunc func(Usage) float64) (string, error) {
+	name, ok := languageNames[target]
+	if !ok {
+		name = target
+	}
+	prompt := fmt.Sprintf("Translate the following text into %s. Output only the translation.\n\n%s", name, text)
+
+	resp, err := model.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty translation response")
+	}
+
+	usage := usageFromGenerationInfo(resp.Choices[0].GenerationInfo)
+	if costFunc != nil {
+		usage.Cost = costFunc(usage)
+	}
+	RecordUsage(ctx, usage)
+
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+// usageFromGenerationInfo extracts token usage reported by OpenAI-compatible providers.
+func usageFromGenerationInfo(info map[string]interface{}) Usage {
+	toInt := func(key string) int {
+		switch v := info[key].(type) {
+		case int:
+			return v
+		case int64:
+			return int(v)
+		case float64:
+			return int(v)
+		default:
+			return 0
+		}
+	}
+	return Usage{
+		PromptTokens:     toInt("PromptTokens"),
+		CompletionTokens: toInt("CompletionTokens"),
+		TotalTokens:      toInt("TotalTokens"),
+	}
+}
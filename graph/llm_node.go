@@ -0,0 +1,164 @@
+// Package graph - LLM node helper
+// 包 graph - LLM 节点辅助函数
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ================================
+// LLM Node LLM节点
+// ================================
+
+// DefaultLLMNodeTimeout is the timeout of LLM nodes when WithLLMTimeout is not used.
+// DefaultLLMNodeTimeout 是未使用 WithLLMTimeout 时 LLM 节点的超时时间。
+const DefaultLLMNodeTimeout = 60 * time.Second
+
+// DefaultLLMOutputKey is the variable the response text is stored in by default.
+// DefaultLLMOutputKey 是默认存储回复文本的变量名。
+const DefaultLLMOutputKey = "output"
+
+// LLMNodeOption configures a node created by LLMNode.
+// LLMNodeOption 配置 LLMNode 创建的节点。
+type LLMNodeOption func(*llmNodeOptions)
+
+// llmNodeOptions holds the settings of an LLM node.
+type llmNodeOptions struct {
+	outputKey    string
+	systemPrompt string
+	callOptions  []llms.CallOption
+	streaming    *bool
+	timeout      time.Duration
+	costFunc     func(Usage) float64
+}
+
+// WithLLMOutputKey sets the variable the response text is stored in. Defaults to DefaultLLMOutputKey.
+// WithLLMOutputKey 设置存储回复文本的变量名，默认为 DefaultLLMOutputKey。
+func WithLLMOutputKey(key string) LLMNodeOption {
+	return func(o *llmNodeOptions) {
+		o.outputKey = key
+	}
+}
+
+// WithLLMSystemPrompt prepends a system message to every call. The message is not added to the state.
+// WithLLMSystemPrompt 在每次调用前添加系统消息，该消息不会写入状态。
+func WithLLMSystemPrompt(prompt string) LLMNodeOption {
+	return func(o *llmNodeOptions) {
+		o.systemPrompt = prompt
+	}
+}
+
+// WithLLMCallOptions sets the options passed to GenerateContent, such as llms.WithTemperature or llms.WithTools.
+// WithLLMCallOptions 设置传给 GenerateContent 的选项，例如 llms.WithTemperature 或 llms.WithTools。
+func WithLLMCallOptions(options ...llms.CallOption) LLMNodeOption {
+	return func(o *llmNodeOptions) {
+		o.callOptions = append(o.callOptions, options...)
+	}
+}
+
+// WithLLMStreaming forces streaming on or off. By default the node streams only when the
+// graph runs under Runnable.Stream, publishing every chunk as a draft event for the output key.
+// WithLLMStreaming 强制开启或关闭流式输出。默认仅在通过 Runnable.Stream 执行图时流式输出，
+// 每个分片作为输出变量的草稿事件发布。
+func WithLLMStreaming(enabled bool) LLMNodeOption {
+	return func(o *llmNodeOptions) {
+		o.streaming = &enabled
+	}
+}
+
+// WithLLMTimeout sets the node timeout. Defaults to DefaultLLMNodeTimeout.
+// WithLLMTimeout 设置节点超时时间，默认为 DefaultLLMNodeTimeout。
+func WithLLMTimeout(timeout time.Duration) LLMNodeOption {
+	return func(o *llmNodeOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithLLMCostFunc converts the token usage of each call into cost for RecordUsage.
+// WithLLMCostFunc 将每次调用的token用量换算为成本，供 RecordUsage 使用。
+func WithLLMCostFunc(costFunc func(Usage) float64) LLMNodeOption {
+	return func(o *llmNodeOptions) {
+		o.costFunc = costFunc
+	}
+}
+
+// LLMNode creates a node that sends the state's messages to model, appends the AI response
+// (including tool calls) to the messages, stores the response text in the output variable
+// and records the token usage.
+// LLMNode 创建一个节点：将状态中的消息发送给模型，把 AI 回复（包括工具调用）追加到消息中，
+// 将回复文本存入输出变量并记录token用量。
+func LLMNode(id string, model llms.Model, opts ...LLMNodeOption) *Node {
+	options := &llmNodeOptions{
+		outputKey: DefaultLLMOutputKey,
+		timeout:   DefaultLLMNodeTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return NewNode(id).
+		WithType(NodeTypeFunction).
+		WithTags(NodeTagLLM).
+		WithTimeout(options.timeout).
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			return options.generate(ctx, model, state)
+		}).
+		Build()
+}
+
+// generate calls the model with the messages of state and applies the response.
+func (o *llmNodeOptions) generate(ctx context.Context, model llms.Model, state *State) (*State, error) {
+	if model == nil {
+		return nil, fmt.Errorf("LLM node has no model")
+	}
+	if len(state.Messages) == 0 {
+		return nil, fmt.Errorf("no messages to send to the model")
+	}
+
+	messages := state.Messages
+	if o.systemPrompt != "" {
+		messages = append([]llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeSystem, o.systemPrompt),
+		}, messages...)
+	}
+
+	callOptions := o.callOptions
+	_, underStream := streamEmitter(ctx)
+	if o.streaming != nil && *o.streaming || o.streaming == nil && underStream {
+		callOptions = append(callOptions[:len(callOptions):len(callOptions)],
+			llms.WithStreamingFunc(DraftStreamingFunc(state, o.outputKey)))
+	}
+
+	resp, err := model.GenerateContent(ctx, messages, callOptions...)
+	// Partial drafts are not kept; the response replaces them
+	state.DiscardDraft(o.outputKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from the model")
+	}
+	choice := resp.Choices[0]
+
+	reply := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+	if choice.Content != "" || len(choice.ToolCalls) == 0 {
+		reply.Parts = append(reply.Parts, llms.TextContent{Text: choice.Content})
+	}
+	for _, call := range choice.ToolCalls {
+		reply.Parts = append(reply.Parts, call)
+	}
+	state.AddMessage(reply)
+	state.SetVariable(o.outputKey, choice.Content)
+
+	usage := usageFromGenerationInfo(choice.GenerationInfo)
+	if o.costFunc != nil {
+		usage.Cost = o.costFunc(usage)
+	}
+	RecordUsage(ctx, usage)
+
+	return state, nil
+}
@@ -0,0 +1,311 @@
+// Package graph - Localized error messages
+// 包 graph - 本地化错误信息
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ================================
+// Locale 语言
+// ================================
+
+// Locale selects the language of error messages produced by the graph.
+// Locale 选择图产生的错误信息所使用的语言。
+type Locale string
+
+const (
+	// LocaleEnglish renders messages in English (default).
+	// LocaleEnglish 使用英文（默认）。
+	LocaleEnglish Locale = "en"
+
+	// LocaleChinese renders messages in Chinese.
+	// LocaleChinese 使用中文。
+	LocaleChinese Locale = "zh"
+
+	// LocaleBilingual renders the Chinese message followed by the English one.
+	// LocaleBilingual 先输出中文信息，再输出英文信息。
+	LocaleBilingual Locale = "bilingual"
+)
+
+// WithLocale sets the language of errors returned by this execution.
+// WithLocale 设置本次执行返回的错误信息语言。
+func WithLocale(locale Locale) ExecutionOption {
+	return func(ctx *ExecutionContext) {
+		ctx.Locale = locale
+	}
+}
+
+// WithLocale sets the default language of errors returned by Compile and Invoke.
+// WithLocale 设置 Compile 和 Invoke 返回的错误信息的默认语言。
+func (gb *GraphBuilder) WithLocale(locale Locale) *GraphBuilder {
+	gb.graph.Config.Locale = locale
+	return gb
+}
+
+// ================================
+// Error Codes 错误码
+// ================================
+
+// Error codes attached to graph and provider errors.
+// 图错误与服务商错误的错误码。
+const (
+	ErrCodeValidationFailed    = "VALIDATION_FAILED"
+	ErrCodeInvalidInput        = "INVALID_INPUT"
+	ErrCodeNodeFailed          = "NODE_FAILED"
+	ErrCodeNodeNotFound        = "NODE_NOT_FOUND"
+	ErrCodeRoutingFailed       = "ROUTING_FAILED"
+	ErrCodeMaxStepsExceeded    = "MAX_STEPS_EXCEEDED"
+	ErrCodeTimeout             = "TIMEOUT"
+	ErrCodeCanceled            = "CANCELED"
+	ErrCodeProviderAuth        = "PROVIDER_AUTH"
+	ErrCodeProviderRateLimit   = "PROVIDER_RATE_LIMIT"
+	ErrCodeProviderBadRequest  = "PROVIDER_BAD_REQUEST"
+	ErrCodeProviderUnavailable = "PROVIDER_UNAVAILABLE"
+	ErrCodeProviderError       = "PROVIDER_ERROR"
+	ErrCodeUnknown             = "UNKNOWN"
+)
+
+// GraphError is an error raised by the graph with a stable code and messages in each locale.
+// The English rendering matches the messages returned before localization was added.
+// GraphError 是图产生的错误，带有稳定的错误码和各语言的信息。
+// 英文信息与引入本地化之前返回的信息保持一致。
+type GraphError struct {
+	// Code is the stable error code.
+	Code string
+
+	// NodeID is the node the error relates to (if applicable).
+	NodeID string
+
+	// Cause is the underlying error (if any).
+	Cause error
+
+	// Locale selects the language used by Error.
+	Locale Locale
+
+	// en and zh are the messages without the cause.
+	en string
+	zh string
+}
+
+// newGraphError creates a graph error with English and Chinese messages.
+func newGraphError(locale Locale, code, nodeID string, cause error, en, zh string) *GraphError {
+	return &GraphError{Code: code, NodeID: nodeID, Cause: cause, Locale: locale, en: en, zh: zh}
+}
+
+// Error returns the message in the error's locale.
+// Error 返回错误所设语言的信息。
+func (e *GraphError) Error() string {
+	return e.Render(e.Locale)
+}
+
+// Unwrap returns the underlying error.
+// Unwrap 返回原始错误。
+func (e *GraphError) Unwrap() error {
+	return e.Cause
+}
+
+// Is makes input validation errors match ErrInvalidInput.
+// Is 使输入校验错误可与 ErrInvalidInput 匹配。
+func (e *GraphError) Is(target error) bool {
+	return target == ErrInvalidInput && e.Code == ErrCodeInvalidInput
+}
+
+// Render returns the message in the given locale, localizing nested causes where possible.
+// Render 返回指定语言的信息，并尽可能本地化嵌套的原因。
+func (e *GraphError) Render(locale Locale) string {
+	if locale == LocaleBilingual {
+		return e.Render(LocaleChinese) + " | " + e.Render(LocaleEnglish)
+	}
+
+	message := e.en
+	if locale == LocaleChinese {
+		message = e.zh
+	}
+	if e.Cause == nil {
+		return message
+	}
+	cause := renderCause(e.Cause, locale)
+	if message == "" {
+		return cause
+	}
+	return message + ": " + cause
+}
+
+// renderCause renders an error nested in a GraphError.
+func renderCause(err error, locale Locale) string {
+	if graphErr, ok := err.(*GraphError); ok {
+		return graphErr.Render(locale)
+	}
+	if locale != LocaleChinese {
+		return err.Error()
+	}
+	if code, status := classifyError(err); code != ErrCodeUnknown {
+		return describeCode(code, status) + ": " + err.Error()
+	}
+	return err.Error()
+}
+
+// ================================
+// Diagnostics 诊断
+// ================================
+
+// ErrorCode returns the code of a graph error, or classifies provider and context errors.
+// ErrorCode 返回图错误的错误码，或对服务商错误和上下文错误进行分类。
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var graphErr *GraphError
+	if errors.As(err, &graphErr) {
+		return graphErr.Code
+	}
+	code, _ := classifyError(err)
+	return code
+}
+
+// RenderError renders any error as a diagnostic line prefixed with its code,
+// e.g. "[NODE_FAILED] 节点 llm 执行失败: 服务商限流 (HTTP 429): ...".
+// RenderError 将任意错误渲染为带错误码前缀的诊断信息。
+func RenderError(err error, locale Locale) string {
+	if err == nil {
+		return ""
+	}
+	code := ErrorCode(err)
+	var graphErr *GraphError
+	if errors.As(err, &graphErr) && graphErr == err {
+		return "[" + code + "] " + graphErr.Render(locale)
+	}
+	if locale == LocaleBilingual {
+		return "[" + code + "] " + renderCause(err, LocaleChinese) + " | " + err.Error()
+	}
+	return "[" + code + "] " + renderCause(err, locale)
+}
+
+// statusCodePattern extracts the HTTP status from provider client errors.
+var statusCodePattern = regexp.MustCompile(`(?i)status(?: code)?[:= ]+(\d{3})`)
+
+// classifyError maps context and provider errors to error codes.
+func classifyError(err error) (string, int) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeTimeout, 0
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCanceled, 0
+	}
+
+	message := err.Error()
+	status := 0
+	if match := statusCodePattern.FindStringSubmatch(message); match != nil {
+		status, _ = strconv.Atoi(match[1])
+	}
+	lower := strings.ToLower(message)
+	switch {
+	case status == 401 || status == 403:
+		return ErrCodeProviderAuth, status
+	case status == 429 || strings.Contains(lower, "rate limit"):
+		return ErrCodeProviderRateLimit, status
+	case status >= 500:
+		return ErrCodeProviderUnavailable, status
+	case status >= 400:
+		return ErrCodeProviderBadRequest, status
+	case strings.Contains(lower, "api returned"):
+		return ErrCodeProviderError, status
+	}
+	return ErrCodeUnknown, 0
+}
+
+// describeCode returns the Chinese description of a classified error.
+func describeCode(code string, status int) string {
+	var description string
+	switch code {
+	case ErrCodeTimeout:
+		return "执行超时"
+	case ErrCodeCanceled:
+		return "执行已取消"
+	case ErrCodeProviderAuth:
+		description = "服务商认证失败，请检查API密钥"
+	case ErrCodeProviderRateLimit:
+		description = "服务商限流，请稍后重试"
+	case ErrCodeProviderBadRequest:
+		description = "服务商拒绝了请求"
+	case ErrCodeProviderUnavailable:
+		description = "服务商暂时不可用"
+	default:
+		description = "服务商返回错误"
+	}
+	if status > 0 {
+		description += fmt.Sprintf(" (HTTP %d)", status)
+	}
+	return description
+}
+
+// ================================
+// Validation Messages 验证信息
+// ================================
+
+// Localize returns the validation error message in the given locale.
+// Localize 返回指定语言的验证错误信息。
+func (ve ValidationError) Localize(locale Locale) string {
+	switch locale {
+	case LocaleChinese:
+		return localizeValidation(ve.Code, ve.Message, ve.NodeID, ve.Details)
+	case LocaleBilingual:
+		return localizeValidation(ve.Code, ve.Message, ve.NodeID, ve.Details) + " | " + ve.Error()
+	default:
+		return ve.Error()
+	}
+}
+
+// Localize returns the validation warning message in the given locale.
+// Localize 返回指定语言的验证警告信息。
+func (vw ValidationWarning) Localize(locale Locale) string {
+	return ValidationError(vw).Localize(locale)
+}
+
+// localizeValidation renders a validation issue in Chinese.
+func localizeValidation(code, message, nodeID string, details map[string]interface{}) string {
+	var text string
+	switch code {
+	case "NO_ENTRY_POINT":
+		text = "图未设置入口节点"
+	case "INVALID_ENTRY_POINT":
+		text = fmt.Sprintf("入口节点 %s 不存在", nodeID)
+	case "INVALID_NODE":
+		text = "节点配置无效: " + message
+	case "INVALID_EDGE":
+		text = fmt.Sprintf("边 %v（%v → %v）配置无效: %s", details["edge_id"], details["from"], details["to"], message)
+	case "UNREACHABLE_NODE":
+		text = fmt.Sprintf("节点 %s 无法从入口节点到达", nodeID)
+	case "NO_OUTGOING_EDGES":
+		text = fmt.Sprintf("节点 %s 没有出边", nodeID)
+	case "MISSING_DEFAULT_EDGE":
+		text = fmt.Sprintf("节点 %s 有条件边但没有默认边", nodeID)
+	case "MISSING_NODE_TIMEOUT":
+		text = fmt.Sprintf("LLM 节点 %s 未配置超时", nodeID)
+	default:
+		text = message
+	}
+	if nodeID != "" {
+		return fmt.Sprintf("[%s] %s（节点: %s）", code, text, nodeID)
+	}
+	return fmt.Sprintf("[%s] %s", code, text)
+}
+
+// validationFailedError builds the error returned by Compile for an invalid graph.
+func validationFailedError(locale Locale, errs []ValidationError) *GraphError {
+	en := make([]string, len(errs))
+	zh := make([]string, len(errs))
+	for i, err := range errs {
+		en[i] = err.Error()
+		zh[i] = err.Localize(LocaleChinese)
+	}
+	return newGraphError(locale, ErrCodeValidationFailed, "", nil,
+		"graph validation failed: "+strings.Join(en, "; "),
+		"图验证失败: "+strings.Join(zh, "; "))
+}
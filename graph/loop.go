@@ -0,0 +1,209 @@
+// Package graph - Loop node implementation
+// 包 graph - 循环节点实现
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ================================
+// Loop Node 循环节点
+// ================================
+
+// DefaultMaxLoopIterations is the iteration guard used when NodeConfig.MaxIterations is not set.
+// DefaultMaxLoopIterations 是 NodeConfig.MaxIterations 未设置时使用的最大迭代次数。
+const DefaultMaxLoopIterations = 100
+
+// Metadata keys used by loop nodes.
+// 循环节点使用的元数据键。
+const (
+	// LoopIterationMetadata holds the current 1-based iteration while the body runs, and the
+	// number of completed iterations once the loop has finished.
+	LoopIterationMetadata = "loop_iteration"
+	// LoopControlMetadata holds the LoopControl requested by the body; it is cleared after
+	// every iteration.
+	LoopControlMetadata = "loop_control"
+)
+
+// LoopControl is a request of the loop body to change the flow of the loop.
+// LoopControl 是循环体改变循环流程的请求。
+type LoopControl string
+
+const (
+	// LoopBreak ends the current iteration and leaves the loop.
+	LoopBreak LoopControl = "break"
+	// LoopContinue ends the current iteration and goes on with the next one.
+	LoopContinue LoopControl = "continue"
+)
+
+// LoopCondition decides whether the loop runs another iteration. It is evaluated before
+// every iteration, so a loop whose condition is false from the start never runs its body.
+// LoopCondition 决定循环是否执行下一次迭代。条件在每次迭代之前计算，
+// 一开始即为 false 的循环不会执行循环体。
+type LoopCondition func(ctx context.Context, state *State) (bool, error)
+
+// loopBodyContextKey marks contexts in which a loop body runs.
+type loopBodyContextKey struct{}
+
+// BreakLoop asks the enclosing loop node to leave the loop. In a sub-graph body the
+// remaining nodes of the iteration are skipped.
+// BreakLoop 请求所在的循环节点退出循环。循环体为子图时跳过本次迭代的剩余节点。
+func BreakLoop(state *State) {
+	state.SetMetadata(LoopControlMetadata, LoopBreak)
+}
+
+// ContinueLoop asks the enclosing loop node to go on with the next iteration. In a
+// sub-graph body the remaining nodes of the iteration are skipped.
+// ContinueLoop 请求所在的循环节点进入下一次迭代。循环体为子图时跳过本次迭代的剩余节点。
+func ContinueLoop(state *State) {
+	state.SetMetadata(LoopControlMetadata, LoopContinue)
+}
+
+// WithLoop turns the node into a loop node that runs body while condition holds. A nil
+// condition loops until the body calls BreakLoop.
+// WithLoop 将节点设为循环节点，在 condition 成立时反复执行 body。
+// condition 为 nil 时循环直到循环体调用 BreakLoop。
+func (nb *NodeBuilder) WithLoop(condition LoopCondition, body NodeFunction) *NodeBuilder {
+	nb.node.LoopCondition = condition
+	nb.node.Function = body
+	nb.node.Type = NodeTypeLoop
+	return nb
+}
+
+// WithNamedLoop is WithLoop with a condition registered with RegisterCondition, which keeps
+// the node serializable.
+// WithNamedLoop 与 WithLoop 相同，但使用通过 RegisterCondition 注册的条件，使节点可以序列化。
+func (nb *NodeBuilder) WithNamedLoop(condition string, body NodeFunction) *NodeBuilder {
+	nb.WithLoop(LoopCondition(NamedCondition(condition)), body)
+	nb.node.ConditionName = condition
+	return nb
+}
+
+// WithLoopSubGraph turns the node into a loop node that runs a sub-graph while condition holds.
+// WithLoopSubGraph 将节点设为循环节点，在 condition 成立时反复执行子图。
+func (nb *NodeBuilder) WithLoopSubGraph(condition LoopCondition, subGraph *Graph) *NodeBuilder {
+	nb.node.LoopCondition = condition
+	nb.node.SubGraph = subGraph
+	nb.node.Type = NodeTypeLoop
+	return nb
+}
+
+// WithMaxIterations sets the iteration guard of a loop node; the node fails when the
+// condition still holds after this many iterations. Defaults to DefaultMaxLoopIterations.
+// WithMaxIterations 设置循环节点的最大迭代次数，达到该次数后条件仍成立时节点失败。
+// 默认为 DefaultMaxLoopIterations。
+func (nb *NodeBuilder) WithMaxIterations(maxIterations int) *NodeBuilder {
+	nb.node.Config.MaxIterations = maxIterations
+	return nb
+}
+
+// executeLoop runs the body of a loop node until the condition is false or the body breaks,
+// recording a snapshot of the variables after every iteration in the history.
+// executeLoop 执行循环节点的循环体，直到条件不成立或循环体请求退出，
+// 并在每次迭代后将变量快照记录到执行历史中。
+func (n *Node) executeLoop(ctx context.Context, state *State) (*State, error) {
+	body, err := n.loopBody()
+	if err != nil {
+		return nil, err
+	}
+
+	maxIterations := n.Config.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxLoopIterations
+	}
+	bodyCtx := context.WithValue(ctx, loopBodyContextKey{}, true)
+
+	current := state
+	completed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if n.LoopCondition != nil {
+			more, err := n.LoopCondition(ctx, current)
+			if err != nil {
+				return nil, fmt.Errorf("loop condition of node %s failed: %w", n.ID, err)
+			}
+			if !more {
+				break
+			}
+		}
+		if completed >= maxIterations {
+			return nil, fmt.Errorf("loop node %s reached the maximum of %d iterations", n.ID, maxIterations)
+		}
+
+		iteration := completed + 1
+		current.SetMetadata(LoopIterationMetadata, iteration)
+		start := time.Now()
+		next, err := body(bodyCtx, current)
+		if err != nil {
+			return nil, fmt.Errorf("iteration %d of loop node %s failed: %w", iteration, n.ID, err)
+		}
+		if next != nil {
+			current = next
+		}
+		completed = iteration
+
+		control, _ := current.GetMetadata(LoopControlMetadata)
+		delete(current.Metadata, LoopControlMetadata)
+
+		end := time.Now()
+		snapshot := make(map[string]interface{}, len(current.Variables))
+		for k, v := range current.Variables {
+			snapshot[k] = v
+		}
+		current.AddExecutionStep(ExecutionStep{
+			NodeID:    n.ID,
+			Iteration: iteration,
+			StartTime: start,
+			EndTime:   end,
+			Duration:  end.Sub(start),
+			Success:   true,
+			Output:    snapshot,
+		})
+
+		if control == LoopBreak {
+			break
+		}
+	}
+
+	current.SetMetadata(LoopIterationMetadata, completed)
+	return current, nil
+}
+
+// loopBody returns the function that runs one iteration of a loop node.
+func (n *Node) loopBody() (NodeFunction, error) {
+	if n.SubGraph == nil {
+		if n.Function == nil {
+			return nil, fmt.Errorf("loop node %s has no body", n.ID)
+		}
+		return n.Function, nil
+	}
+
+	// Compile once for all iterations
+	runnable, err := n.SubGraph.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile loop body of node %s: %w", n.ID, err)
+	}
+	return func(ctx context.Context, state *State) (*State, error) {
+		result, err := runnable.Invoke(ctx, state)
+		if err != nil {
+			return nil, err
+		}
+		// Keep publishing on the enclosing execution's bus rather than the sub-graph's
+		result.Events = state.Events
+		return result, nil
+	}, nil
+}
+
+// loopIterationEnded reports whether a node inside a loop body requested to end the
+// iteration, in which case the sub-graph running the body stops early.
+func loopIterationEnded(ctx context.Context, state *State) bool {
+	if inBody, _ := ctx.Value(loopBodyContextKey{}).(bool); !inBody || state == nil {
+		return false
+	}
+	_, requested := state.GetMetadata(LoopControlMetadata)
+	return requested
+}
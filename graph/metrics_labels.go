@@ -0,0 +1,192 @@
+// Package graph - Metrics labels
+// 包 graph - 指标标签
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ================================
+// Metrics Labels 指标标签
+// ================================
+
+// OtherLabelValue replaces label values beyond the cardinality limit of a label.
+// OtherLabelValue 替换超出标签基数上限的标签值。
+const OtherLabelValue = "__other__"
+
+// DefaultMaxLabelValues is the default number of distinct values kept per label.
+// DefaultMaxLabelValues 是每个标签默认保留的不同取值数量。
+const DefaultMaxLabelValues = 100
+
+// LabelFunc derives the value of a metrics label from the state.
+// LabelFunc 从状态中计算指标标签的值。
+type LabelFunc func(state *State) string
+
+// metricsLabel is a label attached to node metrics.
+type metricsLabel struct {
+	name string
+	fn   LabelFunc
+}
+
+// LabeledMetrics contains the metrics of a node for one combination of label values.
+// LabeledMetrics 包含节点在某一组标签取值下的指标。
+type LabeledMetrics struct {
+	// NodeID is the ID of the node.
+	NodeID string `json:"node_id"`
+
+	// Labels maps label names to their values.
+	Labels map[string]string `json:"labels"`
+
+	// Metrics contains the metrics of the series.
+	Metrics *NodeMetrics `json:"metrics"`
+}
+
+// labeledSeries stores the metrics of one label combination.
+type labeledSeries struct {
+	nodeID  string
+	labels  map[string]string
+	metrics *NodeMetrics
+}
+
+// WithLabel attaches a label computed from the state to the collected metrics, so that node
+// performance can be sliced by business dimensions such as tenant or model. The function
+// receives the state returned by the node, or the input state when the node failed.
+// WithLabel 为收集的指标添加从状态计算的标签，以便按租户、模型等业务维度划分节点性能。
+// 函数接收节点返回的状态，节点失败时接收输入状态。
+func (mm *MetricsMiddleware) WithLabel(name string, fn LabelFunc) *MetricsMiddleware {
+	mm.lock.Lock()
+	defer mm.lock.Unlock()
+
+	for i, label := range mm.labels {
+		if label.name == name {
+			mm.labels[i].fn = fn
+			return mm
+		}
+	}
+	mm.labels = append(mm.labels, metricsLabel{name: name, fn: fn})
+	return mm
+}
+
+// WithVariableLabel attaches a label whose value is the given state variable.
+// WithVariableLabel 添加取值为指定状态变量的标签。
+func (mm *MetricsMiddleware) WithVariableLabel(name, variable string) *MetricsMiddleware {
+	return mm.WithLabel(name, func(state *State) string {
+		value, exists := state.GetVariable(variable)
+		if !exists || value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	})
+}
+
+// WithMaxLabelValues limits the number of distinct values kept per label; further values
+// are recorded as OtherLabelValue. Defaults to DefaultMaxLabelValues.
+// WithMaxLabelValues 限制每个标签保留的不同取值数量，超出的取值记为 OtherLabelValue，
+// 默认为 DefaultMaxLabelValues。
+func (mm *MetricsMiddleware) WithMaxLabelValues(max int) *MetricsMiddleware {
+	mm.lock.Lock()
+	defer mm.lock.Unlock()
+	mm.maxLabelValues = max
+	return mm
+}
+
+// GetLabeledMetrics returns the metrics of every node and label combination, ordered by
+// node ID and label values. It is empty when no labels are configured.
+// GetLabeledMetrics 返回每个节点在各组标签取值下的指标，按节点ID和标签取值排序。
+// 未配置标签时为空。
+func (mm *MetricsMiddleware) GetLabeledMetrics() []LabeledMetrics {
+	mm.lock.RLock()
+	defer mm.lock.RUnlock()
+
+	keys := make([]string, 0, len(mm.series))
+	for key := range mm.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]LabeledMetrics, 0, len(keys))
+	for _, key := range keys {
+		series := mm.series[key]
+		labels := make(map[string]string, len(series.labels))
+		for name, value := range series.labels {
+			labels[name] = value
+		}
+		metrics := *series.metrics
+		result = append(result, LabeledMetrics{
+			NodeID:  series.nodeID,
+			Labels:  labels,
+			Metrics: &metrics,
+		})
+	}
+	return result
+}
+
+// labelValues computes the label values for a node execution, applying the cardinality limit.
+// The caller must hold the lock.
+func (mm *MetricsMiddleware) labelValues(state *State) map[string]string {
+	maxValues := mm.maxLabelValues
+	if maxValues <= 0 {
+		maxValues = DefaultMaxLabelValues
+	}
+
+	labels := make(map[string]string, len(mm.labels))
+	for _, label := range mm.labels {
+		value := ""
+		if state != nil {
+			value = label.fn(state)
+		}
+
+		seen := mm.labelSeen[label.name]
+		if seen == nil {
+			seen = make(map[string]struct{})
+			mm.labelSeen[label.name] = seen
+		}
+		if _, ok := seen[value]; !ok {
+			if len(seen) >= maxValues {
+				value = OtherLabelValue
+			} else {
+				seen[value] = struct{}{}
+			}
+		}
+		labels[label.name] = value
+	}
+	return labels
+}
+
+// recordLabeled records a node execution in the series of its label values.
+// The caller must hold the lock.
+func (mm *MetricsMiddleware) recordLabeled(nodeID string, state *State, duration time.Duration, err error) {
+	labels := mm.labelValues(state)
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(nodeID)
+	for _, name := range names {
+		key.WriteString("\x00")
+		key.WriteString(name)
+		key.WriteString("=")
+		key.WriteString(labels[name])
+	}
+
+	series, exists := mm.series[key.String()]
+	if !exists {
+		series = &labeledSeries{
+			nodeID: nodeID,
+			labels: labels,
+			metrics: &NodeMetrics{
+				MinDuration: duration,
+				MaxDuration: duration,
+			},
+		}
+		mm.series[key.String()] = series
+	}
+	series.metrics.record(duration, err)
+}
@@ -4,6 +4,7 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -99,6 +100,18 @@ type MetricsMiddleware struct {
 	// metrics stores the collected metrics.
 	metrics map[string]*NodeMetrics
 
+	// labels are the labels attached to the labeled series.
+	labels []metricsLabel
+
+	// maxLabelValues limits the distinct values kept per label.
+	maxLabelValues int
+
+	// labelSeen tracks the distinct values of each label.
+	labelSeen map[string]map[string]struct{}
+
+	// series stores the metrics per node and label combination.
+	series map[string]*labeledSeries
+
 	// lock protects concurrent access to metrics.
 	lock sync.RWMutex
 }
@@ -135,15 +148,21 @@ type NodeMetrics struct {
 // NewMetricsMiddleware 创建一个新的指标中间件。
 func NewMetricsMiddleware() *MetricsMiddleware {
 	return &MetricsMiddleware{
-		metrics: make(map[string]*NodeMetrics),
+		metrics:   make(map[string]*NodeMetrics),
+		labelSeen: make(map[string]map[string]struct{}),
+		series:    make(map[string]*labeledSeries),
 	}
 }
 
 // Process implements the Middleware interface.
 // Process 实现 Middleware 接口。
 func (mm *MetricsMiddleware) Process(ctx context.Context, next func(ctx context.Context, state *State) (*State, error), state *State) (*State, error) {
-	// Try to get nodeID from state, fallback to "unknown" if not available
-	nodeID := state.CurrentNode
+	// Prefer the executing node from the context, then the state, fallback to "unknown"
+	nodeID := NodeIDFromContext(ctx)
+	fromContext := nodeID != ""
+	if !fromContext {
+		nodeID = state.CurrentNode
+	}
 	if nodeID == "" {
 		nodeID = "unknown"
 	}
@@ -154,18 +173,22 @@ func (mm *MetricsMiddleware) Process(ctx context.Context, next func(ctx context.
 	duration := time.Since(start)
 
 	// Use the result state's CurrentNode if available
-	if result != nil && result.CurrentNode != "" {
-		nodeID = result.CurrentNode
+	labelState := state
+	if result != nil {
+		labelState = result
+		if !fromContext && result.CurrentNode != "" {
+			nodeID = result.CurrentNode
+		}
 	}
 
-	mm.recordMetrics(nodeID, duration, err)
+	mm.recordMetrics(nodeID, labelState, duration, err)
 
 	return result, err
 }
 
 // recordMetrics records metrics for a node execution.
 // recordMetrics 记录节点执行的指标。
-func (mm *MetricsMiddleware) recordMetrics(nodeID string, duration time.Duration, err error) {
+func (mm *MetricsMiddleware) recordMetrics(nodeID string, state *State, duration time.Duration, err error) {
 	mm.lock.Lock()
 	defer mm.lock.Unlock()
 
@@ -177,7 +200,15 @@ func (mm *MetricsMiddleware) recordMetrics(nodeID string, duration time.Duration
 		}
 		mm.metrics[nodeID] = metrics
 	}
+	metrics.record(duration, err)
+
+	if len(mm.labels) > 0 {
+		mm.recordLabeled(nodeID, state, duration, err)
+	}
+}
 
+// record adds an execution to the metrics.
+func (metrics *NodeMetrics) record(duration time.Duration, err error) {
 	metrics.ExecutionCount++
 	metrics.TotalDuration += duration
 	metrics.LastExecution = time.Now()
@@ -251,6 +282,8 @@ func (mm *MetricsMiddleware) ResetMetrics() {
 	mm.lock.Lock()
 	defer mm.lock.Unlock()
 	mm.metrics = make(map[string]*NodeMetrics)
+	mm.labelSeen = make(map[string]map[string]struct{})
+	mm.series = make(map[string]*labeledSeries)
 }
 
 // ================================
@@ -429,6 +462,12 @@ type CircuitBreakerMiddleware struct {
 	// lastFailureTime tracks when the last failure occurred.
 	lastFailureTime time.Time
 
+	// store shares the breaker state across replicas. Nil keeps the state in-process.
+	store CounterStore
+
+	// storeKey identifies the breaker in the store.
+	storeKey string
+
 	// lock protects concurrent access.
 	lock sync.RWMutex
 }
@@ -459,16 +498,51 @@ func NewCircuitBreakerMiddleware(failureThreshold int, resetTimeout time.Duratio
 // Process implements the Middleware interface.
 // Process 实现 Middleware 接口。
 func (cb *CircuitBreakerMiddleware) Process(ctx context.Context, next func(ctx context.Context, state *State) (*State, error), state *State) (*State, error) {
-	if !cb.allowRequest() {
+	if !cb.allow(ctx) {
 		return nil, fmt.Errorf("circuit breaker is open")
 	}
 
 	result, err := next(ctx, state)
-	cb.recordResult(err)
+	cb.record(ctx, err)
 
 	return result, err
 }
 
+// WithStore shares the breaker state under key in store, e.g. a RedisCounterStore.
+// The in-process state is used as a fallback when the store is unreachable.
+// WithStore 将断路器状态以 key 共享到 store（如 RedisCounterStore），
+// 存储不可用时回退到进程内状态。
+func (cb *CircuitBreakerMiddleware) WithStore(store CounterStore, key string) *CircuitBreakerMiddleware {
+	cb.store = store
+	cb.storeKey = key
+	return cb
+}
+
+// allow checks the shared store first and falls back to the local state on error.
+// allow 优先检查共享存储，出错时回退到本地状态。
+func (cb *CircuitBreakerMiddleware) allow(ctx context.Context) bool {
+	if cb.store != nil {
+		if allowed, err := cb.store.AllowCircuit(ctx, cb.storeKey, cb.ResetTimeout); err == nil {
+			return allowed
+		}
+	}
+	return cb.allowRequest()
+}
+
+// record records the result in the shared store and falls back to the local state on error.
+// record 将结果记录到共享存储，出错时回退到本地状态。
+func (cb *CircuitBreakerMiddleware) record(ctx context.Context, err error) {
+	if cb.store != nil {
+		if state, storeErr := cb.store.RecordCircuit(ctx, cb.storeKey, err != nil, cb.FailureThreshold); storeErr == nil {
+			cb.lock.Lock()
+			cb.state = state
+			cb.lock.Unlock()
+			return
+		}
+	}
+	cb.recordResult(err)
+}
+
 // allowRequest determines if a request should be allowed through.
 // allowRequest 确定是否应该允许请求通过。
 func (cb *CircuitBreakerMiddleware) allowRequest() bool {
@@ -539,6 +613,12 @@ type RateLimitMiddleware struct {
 	// lastRefill tracks when tokens were last refilled.
 	lastRefill time.Time
 
+	// store shares the token bucket across replicas. Nil keeps the bucket in-process.
+	store CounterStore
+
+	// storeKey identifies the bucket in the store.
+	storeKey string
+
 	// lock protects concurrent access.
 	lock sync.Mutex
 }
@@ -557,13 +637,34 @@ func NewRateLimitMiddleware(rate float64, burstSize int) *RateLimitMiddleware {
 // Process implements the Middleware interface.
 // Process 实现 Middleware 接口。
 func (rl *RateLimitMiddleware) Process(ctx context.Context, next func(ctx context.Context, state *State) (*State, error), state *State) (*State, error) {
-	if !rl.allowRequest() {
+	if !rl.allow(ctx) {
 		return nil, fmt.Errorf("rate limit exceeded")
 	}
 
 	return next(ctx, state)
 }
 
+// WithStore shares the token bucket under key in store, e.g. a RedisCounterStore.
+// The in-process bucket is used as a fallback when the store is unreachable.
+// WithStore 将令牌桶以 key 共享到 store（如 RedisCounterStore），
+// 存储不可用时回退到进程内令牌桶。
+func (rl *RateLimitMiddleware) WithStore(store CounterStore, key string) *RateLimitMiddleware {
+	rl.store = store
+	rl.storeKey = key
+	return rl
+}
+
+// allow checks the shared store first and falls back to the local bucket on error.
+// allow 优先检查共享存储，出错时回退到本地令牌桶。
+func (rl *RateLimitMiddleware) allow(ctx context.Context) bool {
+	if rl.store != nil {
+		if allowed, err := rl.store.AllowRate(ctx, rl.storeKey, rl.Rate, rl.BurstSize); err == nil {
+			return allowed
+		}
+	}
+	return rl.allowRequest()
+}
+
 // allowRequest determines if a request should be allowed based on rate limiting.
 // allowRequest 基于限流确定是否应该允许请求。
 func (rl *RateLimitMiddleware) allowRequest() bool {
@@ -589,6 +690,118 @@ func (rl *RateLimitMiddleware) allowRequest() bool {
 	return false
 }
 
+// ================================
+// Budget Middleware 预算中间件
+// ================================
+
+// BudgetMetadataKey is the state metadata key under which the remaining budget is published.
+// BudgetMetadataKey 是发布剩余预算的状态元数据键。
+const BudgetMetadataKey = "budget"
+
+// ErrBudgetExceeded is returned when a node is about to run after the budget is exhausted.
+// ErrBudgetExceeded 表示预算耗尽后仍尝试执行节点。
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget describes the token and cost budget of an execution.
+// A zero maximum means the dimension is unlimited.
+// Budget 描述一次执行的token与成本预算，最大值为0表示不限制。
+type Budget struct {
+	// MaxTokens is the token limit.
+	MaxTokens int `json:"max_tokens,omitempty"`
+
+	// UsedTokens is the number of tokens used so far.
+	UsedTokens int `json:"used_tokens"`
+
+	// MaxCost is the cost limit.
+	MaxCost float64 `json:"max_cost,omitempty"`
+
+	// UsedCost is the cost spent so far.
+	UsedCost float64 `json:"used_cost"`
+}
+
+// RemainingTokens returns the remaining tokens, or -1 when tokens are unlimited.
+// RemainingTokens 返回剩余token数，不限制时返回 -1。
+func (b Budget) RemainingTokens() int {
+	if b.MaxTokens <= 0 {
+		return -1
+	}
+	return max(b.MaxTokens-b.UsedTokens, 0)
+}
+
+// RemainingCost returns the remaining cost, or -1 when cost is unlimited.
+// RemainingCost 返回剩余成本，不限制时返回 -1。
+func (b Budget) RemainingCost() float64 {
+	if b.MaxCost <= 0 {
+		return -1
+	}
+	return max(b.MaxCost-b.UsedCost, 0)
+}
+
+// Exhausted reports whether any limited dimension has been used up.
+// Exhausted 判断是否有任一受限维度已耗尽。
+func (b Budget) Exhausted() bool {
+	return b.RemainingTokens() == 0 || b.RemainingCost() == 0
+}
+
+// BudgetMiddleware enforces a per-execution token and cost budget based on usage
+// reported through RecordUsage, and publishes the remaining budget to the state
+// so that edge conditions such as IfBudgetAbove can route to cheaper paths.
+// BudgetMiddleware 基于 RecordUsage 报告的用量限制单次执行的token与成本预算，
+// 并将剩余预算发布到状态中，供 IfBudgetAbove 等边条件选择更便宜的路径。
+type BudgetMiddleware struct {
+	// MaxTokens is the token limit per execution; 0 means unlimited.
+	MaxTokens int
+
+	// MaxCost is the cost limit per execution; 0 means unlimited.
+	MaxCost float64
+}
+
+// NewBudgetMiddleware creates a new budget middleware.
+// NewBudgetMiddleware 创建一个新的预算中间件。
+func NewBudgetMiddleware(maxTokens int, maxCost float64) *BudgetMiddleware {
+	return &BudgetMiddleware{
+		MaxTokens: maxTokens,
+		MaxCost:   maxCost,
+	}
+}
+
+// Process implements the Middleware interface.
+// Process 实现 Middleware 接口。
+func (bm *BudgetMiddleware) Process(ctx context.Context, next func(ctx context.Context, state *State) (*State, error), state *State) (*State, error) {
+	if budget := bm.budget(ctx); budget.Exhausted() {
+		state.SetMetadata(BudgetMetadataKey, budget)
+		return nil, fmt.Errorf("%w: node %s not executed", ErrBudgetExceeded, NodeIDFromContext(ctx))
+	}
+
+	result, err := next(ctx, state)
+	if result != nil {
+		result.SetMetadata(BudgetMetadataKey, bm.budget(ctx))
+	}
+	return result, err
+}
+
+// budget returns the current budget of the execution in ctx.
+func (bm *BudgetMiddleware) budget(ctx context.Context) Budget {
+	used := UsageFromContext(ctx)
+	return Budget{
+		MaxTokens:  bm.MaxTokens,
+		UsedTokens: used.TotalTokens,
+		MaxCost:    bm.MaxCost,
+		UsedCost:   used.Cost,
+	}
+}
+
+// RemainingBudget returns the budget published by BudgetMiddleware, if any.
+// RemainingBudget 返回 BudgetMiddleware 发布的预算（如有）。
+func RemainingBudget(state *State) (Budget, bool) {
+	value, exists := state.GetMetadata(BudgetMetadataKey)
+	if !exists {
+		return Budget{}, false
+	}
+	budget, ok := value.(Budget)
+	return budget, ok
+}
+
 // ================================
 // Validation Middleware 验证中间件
 // ================================
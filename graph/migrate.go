@@ -0,0 +1,373 @@
+// Package graph - Migration from langchaingo chains and agents
+// 包 graph - 从 langchaingo 链与智能体迁移
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ================================
+// Migration 迁移
+// ================================
+
+// Variables used by the graphs generated by FromExecutor.
+// FromExecutor 生成的图使用的变量。
+const (
+	// AgentStepsVariable holds the intermediate steps ([]schema.AgentStep).
+	AgentStepsVariable = "agent_steps"
+	// AgentActionsVariable holds the actions planned but not yet run ([]schema.AgentAction).
+	AgentActionsVariable = "agent_actions"
+	// AgentIterationsVariable counts the planning iterations.
+	AgentIterationsVariable = "agent_iterations"
+	// AgentFinishedVariable is true once the agent returned a finish.
+	AgentFinishedVariable = "agent_finished"
+)
+
+// FromChain converts a langchaingo chain into an equivalent graph:
+// load_memory -> chain -> save_memory -> END.
+// Chain inputs are read from state variables; a single missing input is taken from the last
+// human message. Outputs are written to variables, and a string output is also appended as
+// an AI message, so existing chains gain checkpointing, streaming and middleware unchanged.
+// FromChain 将 langchaingo 链转换为等价的图：load_memory -> chain -> save_memory -> END。
+// 链的输入从状态变量读取，仅缺少一个输入时取最后一条用户消息；输出写入变量，
+// 字符串输出同时追加为AI消息，使现有链无需改写即可使用检查点、流式和中间件等功能。
+func FromChain(chain chains.Chain) (*Graph, error) {
+	if chain == nil {
+		return nil, fmt.Errorf("chain cannot be nil")
+	}
+
+	run := NewNode("chain").
+		WithName("Chain").
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			inputs, err := chainInputs(ctx, state, chain.GetInputKeys(), chain.GetMemory())
+			if err != nil {
+				return nil, err
+			}
+			outputs, err := chain.Call(ctx, inputs)
+			if err != nil {
+				return nil, err
+			}
+			writeOutputs(state, chain.GetOutputKeys(), outputs)
+			return state, nil
+		})
+	switch chain.(type) {
+	case chains.LLMChain, *chains.LLMChain:
+		run.WithTags(NodeTagLLM)
+	}
+
+	return NewGraph("chain").
+		WithDescription(fmt.Sprintf("Converted from %T", chain)).
+		AddNode(loadMemoryNode(chain.GetInputKeys(), chain.GetMemory())).
+		AddNode(run.Build()).
+		AddNode(saveMemoryNode(chain.GetInputKeys(), chain.GetOutputKeys(), chain.GetMemory())).
+		Connect("load_memory", "chain").
+		Connect("chain", "save_memory").
+		Connect("save_memory", "END").
+		SetEntryPoint("load_memory").
+		BuildE()
+}
+
+// FromExecutor converts a langchaingo agent executor into an equivalent graph with an explicit
+// tool loop: load_memory -> plan -> tools -> plan ... -> save_memory -> END.
+// Every planning iteration and tool call is a separate step, so long agent runs can be
+// checkpointed and resumed. MaxIterations, ErrorHandler, CallbacksHandler and
+// ReturnIntermediateSteps behave as in Executor.Call.
+// FromExecutor 将 langchaingo 智能体执行器转换为带显式工具循环的等价图：
+// load_memory -> plan -> tools -> plan ... -> save_memory -> END。
+// 每次规划和工具调用都是独立的步骤，长时间运行的智能体可以通过检查点恢复。
+// MaxIterations、ErrorHandler、CallbacksHandler 和 ReturnIntermediateSteps 的行为与 Executor.Call 一致。
+func FromExecutor(executor *agents.Executor) (*Graph, error) {
+	if executor == nil || executor.Agent == nil {
+		return nil, fmt.Errorf("executor requires an agent")
+	}
+	agent := executor.Agent
+	memory := executor.GetMemory()
+
+	plan := NewNode("plan").
+		WithName("Plan").
+		WithTags(NodeTagLLM).
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			iterations, _ := state.GetVariable(AgentIterationsVariable)
+			count := toInt(iterations)
+			if count >= executor.MaxIterations {
+				return nil, agents.ErrNotFinished
+			}
+			state.SetVariable(AgentIterationsVariable, count+1)
+
+			values, err := chainInputs(ctx, state, agent.GetInputKeys(), memory)
+			if err != nil {
+				return nil, err
+			}
+			inputs := make(map[string]string, len(values))
+			for key, value := range values {
+				text, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", agents.ErrExecutorInputNotString, key)
+				}
+				inputs[key] = text
+			}
+
+			steps, err := agentSteps(state)
+			if err != nil {
+				return nil, err
+			}
+			actions, finish, err := agent.Plan(ctx, steps, inputs)
+			if errors.Is(err, agents.ErrUnableToParseOutput) && executor.ErrorHandler != nil {
+				// Feed the parse error back to the agent as an observation, as Executor does
+				observation := err.Error()
+				if executor.ErrorHandler.Formatter != nil {
+					observation = executor.ErrorHandler.Formatter(observation)
+				}
+				state.SetVariable(AgentStepsVariable, append(steps, schema.AgentStep{Observation: observation}))
+				state.SetVariable(AgentActionsVariable, []schema.AgentAction{})
+				return state, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(actions) == 0 && finish == nil {
+				return nil, agents.ErrAgentNoReturn
+			}
+
+			if finish != nil {
+				if executor.CallbacksHandler != nil {
+					executor.CallbacksHandler.HandleAgentFinish(ctx, *finish)
+				}
+				outputs := make(map[string]any, len(finish.ReturnValues)+1)
+				for key, value := range finish.ReturnValues {
+					outputs[key] = value
+				}
+				if executor.ReturnIntermediateSteps {
+					outputs["intermediateSteps"] = steps
+				}
+				writeOutputs(state, agent.GetOutputKeys(), outputs)
+				state.SetVariable(AgentFinishedVariable, true)
+				return state, nil
+			}
+
+			state.SetVariable(AgentActionsVariable, actions)
+			return state, nil
+		}).
+		Build()
+
+	nameToTool := make(map[string]tools.Tool, len(agent.GetTools()))
+	for _, tool := range agent.GetTools() {
+		nameToTool[strings.ToUpper(tool.Name())] = tool
+	}
+	runTools := NewNode("tools").
+		WithName("Tools").
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			var actions []schema.AgentAction
+			if err := decodeVariable(state, AgentActionsVariable, &actions); err != nil {
+				return nil, err
+			}
+			steps, err := agentSteps(state)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, action := range actions {
+				if executor.CallbacksHandler != nil {
+					executor.CallbacksHandler.HandleAgentAction(ctx, action)
+				}
+				tool, ok := nameToTool[strings.ToUpper(action.Tool)]
+				if !ok {
+					steps = append(steps, schema.AgentStep{
+						Action:      action,
+						Observation: fmt.Sprintf("%s is not a valid tool, try another one", action.Tool),
+					})
+					continue
+				}
+				observation, err := tool.Call(ctx, strings.TrimSuffix(action.ToolInput, "\nObservation:"))
+				if err != nil {
+					return nil, fmt.Errorf("tool %s failed: %w", action.Tool, err)
+				}
+				steps = append(steps, schema.AgentStep{Action: action, Observation: observation})
+			}
+
+			state.SetVariable(AgentStepsVariable, steps)
+			state.SetVariable(AgentActionsVariable, []schema.AgentAction{})
+			return state, nil
+		}).
+		Build()
+
+	finished := func(ctx context.Context, state *State) (bool, error) {
+		done, _ := state.GetVariable(AgentFinishedVariable)
+		return done == true, nil
+	}
+
+	return NewGraph("agent").
+		WithDescription(fmt.Sprintf("Converted from agent executor with %T", agent)).
+		AddNode(loadMemoryNode(agent.GetInputKeys(), memory)).
+		AddNode(plan).
+		AddNode(runTools).
+		AddNode(saveMemoryNode(agent.GetInputKeys(), agent.GetOutputKeys(), memory)).
+		Connect("load_memory", "plan").
+		ConnectWithCondition("plan", "save_memory", finished).
+		AddEdge(NewEdge("plan_to_tools", "plan", "tools").AsDefault().Build()).
+		Connect("tools", "plan").
+		Connect("save_memory", "END").
+		SetEntryPoint("load_memory").
+		BuildE()
+}
+
+// loadMemoryNode creates a node that loads the memory variables into state variables.
+func loadMemoryNode(inputKeys []string, memory schema.Memory) *Node {
+	return NewNode("load_memory").
+		WithName("Load Memory").
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			if memory == nil {
+				return state, nil
+			}
+			inputs, err := chainInputs(ctx, state, inputKeys, memory)
+			if err != nil {
+				return nil, err
+			}
+			values, err := memory.LoadMemoryVariables(ctx, inputs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load memory: %w", err)
+			}
+			for key, value := range values {
+				state.SetVariable(key, value)
+			}
+			return state, nil
+		}).
+		Build()
+}
+
+// saveMemoryNode creates a node that saves the inputs and outputs of the run to memory.
+func saveMemoryNode(inputKeys, outputKeys []string, memory schema.Memory) *Node {
+	return NewNode("save_memory").
+		WithName("Save Memory").
+		WithFunction(func(ctx context.Context, state *State) (*State, error) {
+			if memory == nil {
+				return state, nil
+			}
+			inputs, err := chainInputs(ctx, state, inputKeys, memory)
+			if err != nil {
+				return nil, err
+			}
+			// Memory variables are not user input
+			for _, key := range memory.MemoryVariables(ctx) {
+				delete(inputs, key)
+			}
+			outputs := make(map[string]any, len(outputKeys))
+			for _, key := range outputKeys {
+				if value, ok := state.GetVariable(key); ok {
+					outputs[key] = value
+				}
+			}
+			if err := memory.SaveContext(ctx, inputs, outputs); err != nil {
+				return nil, fmt.Errorf("failed to save memory: %w", err)
+			}
+			return state, nil
+		}).
+		Build()
+}
+
+// chainInputs collects the input values of a chain and the memory variables from state variables.
+// A single missing input is filled with the text of the last human message.
+func chainInputs(ctx context.Context, state *State, inputKeys []string, memory schema.Memory) (map[string]any, error) {
+	memoryKeys := make(map[string]bool)
+	if memory != nil {
+		for _, key := range memory.MemoryVariables(ctx) {
+			memoryKeys[key] = true
+		}
+	}
+
+	inputs := make(map[string]any, len(inputKeys)+len(memoryKeys))
+	for key := range memoryKeys {
+		if value, ok := state.GetVariable(key); ok {
+			inputs[key] = value
+		}
+	}
+	var missing []string
+	for _, key := range inputKeys {
+		if value, ok := state.GetVariable(key); ok {
+			inputs[key] = value
+		} else if !memoryKeys[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) == 1 {
+		for i := len(state.Messages) - 1; i >= 0; i-- {
+			if state.Messages[i].Role == llms.ChatMessageTypeHuman {
+				inputs[missing[0]] = messageText(state.Messages[i])
+				state.SetVariable(missing[0], inputs[missing[0]])
+				missing = nil
+				break
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing chain inputs: %s", strings.Join(missing, ", "))
+	}
+	return inputs, nil
+}
+
+// writeOutputs stores outputs in state variables and appends the main string output as an AI message.
+func writeOutputs(state *State, outputKeys []string, outputs map[string]any) {
+	for key, value := range outputs {
+		state.SetVariable(key, value)
+	}
+	for _, key := range outputKeys {
+		if text, ok := outputs[key].(string); ok {
+			state.AddMessage(llms.TextParts(llms.ChatMessageTypeAI, text))
+			return
+		}
+	}
+}
+
+// agentSteps returns the intermediate steps stored in the state.
+func agentSteps(state *State) ([]schema.AgentStep, error) {
+	var steps []schema.AgentStep
+	if err := decodeVariable(state, AgentStepsVariable, &steps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// decodeVariable reads a typed variable, converting values restored from JSON checkpoints.
+func decodeVariable[T any](state *State, key string, out *T) error {
+	value, ok := state.GetVariable(key)
+	if !ok || value == nil {
+		return nil
+	}
+	if typed, ok := value.(T); ok {
+		*out = typed
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode variable %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode variable %s: %w", key, err)
+	}
+	return nil
+}
+
+// toInt converts a numeric variable, which may be float64 after a JSON round trip.
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
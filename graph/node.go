@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/tmc/langchaingo/llms"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ================================
@@ -33,12 +35,28 @@ type Node struct {
 	// ConditionFunc is used for condition nodes to determine the next path.
 	ConditionFunc ConditionFunction `json:"-"`
 
+	// FunctionName names the Function or ConditionFunc in a FunctionRegistry; empty means the node ID.
+	FunctionName string `json:"function,omitempty"`
+
+	// ConditionName names the registered condition used as LoopCondition.
+	ConditionName string `json:"condition,omitempty"`
+
 	// Config contains configuration for this node.
 	Config NodeConfig `json:"config"`
 
 	// SubGraph contains a sub-graph for subgraph nodes.
 	SubGraph *Graph `json:"-"`
 
+	// Children contains the nodes run concurrently by parallel nodes.
+	Children []*Node `json:"children,omitempty"`
+
+	// MergeFunc merges the states of the children of parallel nodes; it replaces
+	// Config.MergeStrategy when set.
+	MergeFunc MergeFunc `json:"-"`
+
+	// LoopCondition decides whether loop nodes run another iteration.
+	LoopCondition LoopCondition `json:"-"`
+
 	// Inputs defines the expected input parameters.
 	Inputs []ParameterDef `json:"inputs,omitempty"`
 
@@ -158,6 +176,15 @@ func (nb *NodeBuilder) WithRetries(retries int, delay time.Duration) *NodeBuilde
 	return nb
 }
 
+// WithMaxConcurrent limits the number of concurrent executions of the node, shared by all
+// in-flight executions of the compiled graph, e.g. for a GPU-backed tool or a rate-limited provider.
+// WithMaxConcurrent 限制节点的并发执行数，由已编译图的所有进行中的执行共享，
+// 适用于依赖GPU的工具或限流严格的服务商等场景。
+func (nb *NodeBuilder) WithMaxConcurrent(maxConcurrent int) *NodeBuilder {
+	nb.node.Config.MaxConcurrent = maxConcurrent
+	return nb
+}
+
 // WithFailureMode sets the failure handling mode for the node.
 // WithFailureMode 设置节点的失败处理模式。
 func (nb *NodeBuilder) WithFailureMode(mode FailureMode) *NodeBuilder {
@@ -258,6 +285,13 @@ func (n *Node) Execute(ctx context.Context, state *State) (*State, error) {
 	var result *State
 	var err error
 
+	// Retries are recorded on the span of the node, not on the span of an enclosing parallel node
+	span := trace.SpanFromContext(ctx)
+	if NodeIDFromContext(ctx) != n.ID {
+		span = nil
+	}
+	retries := 0
+
 	// Execute with retries
 	for attempt := 0; attempt <= n.Config.Retries; attempt++ {
 		if attempt > 0 {
@@ -270,7 +304,14 @@ func (n *Node) Execute(ctx context.Context, state *State) (*State, error) {
 			}
 		}
 
+		if attempt > 0 && span != nil {
+			span.AddEvent("retry", trace.WithAttributes(
+				attribute.Int("attempt", attempt),
+				attribute.String("error", err.Error()),
+			))
+		}
 		result, err = n.executeOnce(ctx, state)
+		retries = attempt
 		if err == nil {
 			step.Success = true
 			break
@@ -286,6 +327,7 @@ func (n *Node) Execute(ctx context.Context, state *State) (*State, error) {
 		case FailureModeContinue, FailureModeSkip:
 			// Continue with current state, but mark as failed
 			result = state
+			step.Error = err.Error()
 			err = nil
 			step.Success = false
 		case FailureModeStop:
@@ -296,6 +338,9 @@ func (n *Node) Execute(ctx context.Context, state *State) (*State, error) {
 	}
 
 	// Record execution end
+	if span != nil {
+		span.SetAttributes(AttrNodeRetries.Int(retries))
+	}
 	step.EndTime = time.Now()
 	step.Duration = step.EndTime.Sub(step.StartTime)
 	if err != nil {
@@ -328,6 +373,8 @@ func (n *Node) executeOnce(ctx context.Context, state *State) (*State, error) {
 		finalFunc = n.executeLoop
 	case NodeTypeSubGraph:
 		finalFunc = n.executeSubGraph
+	case NodeTypeHumanInput:
+		finalFunc = n.executeHumanInput
 	case NodeTypeStart:
 		finalFunc = func(ctx context.Context, state *State) (*State, error) {
 			return state, nil
@@ -369,22 +416,6 @@ func (n *Node) executeCondition(ctx context.Context, state *State) (*State, erro
 	return state, nil
 }
 
-// executeParallel executes a parallel node (placeholder implementation).
-// executeParallel 执行并行节点（占位符实现）。
-func (n *Node) executeParallel(ctx context.Context, state *State) (*State, error) {
-	// This would be implemented to execute multiple sub-nodes in parallel
-	// For now, just return the state unchanged
-	return state, nil
-}
-
-// executeLoop executes a loop node (placeholder implementation).
-// executeLoop 执行循环节点（占位符实现）。
-func (n *Node) executeLoop(ctx context.Context, state *State) (*State, error) {
-	// This would be implemented to execute a sub-graph in a loop
-	// For now, just return the state unchanged
-	return state, nil
-}
-
 // executeSubGraph executes a sub-graph node.
 // executeSubGraph 执行子图节点。
 func (n *Node) executeSubGraph(ctx context.Context, state *State) (*State, error) {
@@ -434,6 +465,27 @@ func (n *Node) Validate() error {
 		if n.SubGraph == nil {
 			return fmt.Errorf("subgraph node %s must have a sub-graph", n.ID)
 		}
+	case NodeTypeLoop:
+		if n.Function == nil && n.SubGraph == nil {
+			return fmt.Errorf("loop node %s must have a body function or sub-graph", n.ID)
+		}
+	case NodeTypeParallel:
+		if len(n.Children) == 0 {
+			return fmt.Errorf("parallel node %s must have child nodes", n.ID)
+		}
+		switch n.Config.MergeStrategy {
+		case "", MergeStrategyOverwrite, MergeStrategyError, MergeStrategyNamespace:
+		default:
+			return fmt.Errorf("parallel node %s has unknown merge strategy %s", n.ID, n.Config.MergeStrategy)
+		}
+		for _, child := range n.Children {
+			if child == nil {
+				return fmt.Errorf("parallel node %s has a nil child node", n.ID)
+			}
+			if err := child.Validate(); err != nil {
+				return fmt.Errorf("parallel node %s: %w", n.ID, err)
+			}
+		}
 	}
 
 	return nil
@@ -490,6 +542,11 @@ func (n *Node) Clone() *Node {
 		ConditionFunc: n.ConditionFunc,
 		Config:        n.Config,
 		SubGraph:      n.SubGraph, // Note: This is a shallow copy
+		Children:      append([]*Node(nil), n.Children...),
+		MergeFunc:     n.MergeFunc,
+		LoopCondition: n.LoopCondition,
+		FunctionName:  n.FunctionName,
+		ConditionName: n.ConditionName,
 		Inputs:        make([]ParameterDef, len(n.Inputs)),
 		Outputs:       make([]ParameterDef, len(n.Outputs)),
 		Description:   n.Description,
@@ -0,0 +1,123 @@
+// Package graph - OpenTelemetry tracing
+// 包 graph - OpenTelemetry 链路追踪
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ================================
+// OpenTelemetry Tracing 链路追踪
+// ================================
+
+// TracerName is the instrumentation name of the tracer that emits the graph spans.
+// TracerName 是生成图执行 span 的 tracer 的 instrumentation 名称。
+const TracerName = "github.com/sjzsdu/langchaingo-cn/graph"
+
+// Attributes of the execution and node spans.
+// 执行 span 和节点 span 的属性。
+const (
+	AttrGraphID         = attribute.Key("graph.id")
+	AttrExecutionID     = attribute.Key("graph.execution.id")
+	AttrExecutionStatus = attribute.Key("graph.execution.status")
+	AttrExecutionSteps  = attribute.Key("graph.execution.steps")
+	AttrNodeID          = attribute.Key("graph.node.id")
+	AttrNodeType        = attribute.Key("graph.node.type")
+	AttrNodeDurationMs  = attribute.Key("graph.node.duration_ms")
+	AttrNodeRetries     = attribute.Key("graph.node.retries")
+)
+
+// tracerProviderContextKey carries the tracer provider of an execution to its sub-graphs.
+type tracerProviderContextKey struct{}
+
+// WithTracerProvider makes executions of the graph emit OpenTelemetry spans through the
+// provider: one "graph <id>" span per execution and a child "node <id>" span per node run,
+// with the node ID, type, duration, retries and error as attributes. The execution span is a
+// child of the span in the context passed to Invoke, and sub-graphs without a provider of
+// their own report to the same provider, so workflows show up in Jaeger or Tempo within the
+// trace of the request that started them.
+// WithTracerProvider 使图的执行通过 provider 生成 OpenTelemetry span：每次执行一个 "graph <id>" span，
+// 每次节点执行一个子 span "node <id>"，属性包含节点ID、类型、耗时、重试次数和错误。执行 span 是传入
+// Invoke 的 context 中 span 的子 span，未设置 provider 的子图使用同一 provider，
+// 因此工作流在 Jaeger 或 Tempo 中显示在发起它的请求的链路内。
+func (gb *GraphBuilder) WithTracerProvider(provider trace.TracerProvider) *GraphBuilder {
+	gb.graph.tracerProvider = provider
+	return gb
+}
+
+// startExecutionSpan starts the span of an execution when tracing is configured and returns
+// the context the nodes run in.
+// startExecutionSpan 在配置了链路追踪时开始执行的 span，并返回节点执行所用的 context。
+func (r *Runnable) startExecutionSpan(execCtx *ExecutionContext) (context.Context, trace.Span) {
+	ctx := execCtx.Context
+	provider := r.graph.tracerProvider
+	if provider == nil {
+		provider, _ = ctx.Value(tracerProviderContextKey{}).(trace.TracerProvider)
+	}
+	if provider == nil {
+		return ctx, nil
+	}
+
+	execCtx.tracer = provider.Tracer(TracerName)
+	ctx = context.WithValue(ctx, tracerProviderContextKey{}, provider)
+	return execCtx.tracer.Start(ctx, "graph "+r.graph.ID, trace.WithAttributes(
+		AttrGraphID.String(r.graph.ID),
+		AttrExecutionID.String(execCtx.ExecutionID),
+	))
+}
+
+// endExecutionSpan records the outcome of the execution on its span and ends it.
+// endExecutionSpan 在执行的 span 上记录执行结果并结束 span。
+func endExecutionSpan(execCtx *ExecutionContext, span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+
+	status := ExecutionStatusSuccess
+	switch {
+	case errors.Is(err, ErrExecutionInterrupted):
+		status = ExecutionStatusPending
+	case err != nil:
+		status = ExecutionStatusFailure
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(
+		AttrExecutionStatus.String(status),
+		AttrExecutionSteps.Int(execCtx.StepCount),
+	)
+	span.End()
+}
+
+// startNodeSpan starts the span of a node run when the execution is traced.
+// startNodeSpan 在执行被追踪时开始节点执行的 span。
+func startNodeSpan(ctx context.Context, execCtx *ExecutionContext, node *Node) (context.Context, trace.Span) {
+	if execCtx.tracer == nil {
+		return ctx, nil
+	}
+	return execCtx.tracer.Start(ctx, "node "+node.ID, trace.WithAttributes(
+		AttrNodeID.String(node.ID),
+		AttrNodeType.String(string(node.Type)),
+	))
+}
+
+// endNodeSpan records the duration and error of a node run on its span and ends it. The
+// retries are recorded by Node.Execute.
+// endNodeSpan 在节点的 span 上记录耗时和错误并结束 span，重试次数由 Node.Execute 记录。
+func endNodeSpan(span trace.Span, duration time.Duration, err error) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(AttrNodeDurationMs.Int64(duration.Milliseconds()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,244 @@
+// Package graph - Parallel node implementation
+// 包 graph - 并行节点实现
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ================================
+// Parallel Node 并行节点
+// ================================
+
+// ParallelErrorsMetadata is the metadata key under which a parallel node in continue or
+// skip failure mode records the errors of its failed children, keyed by child ID.
+// ParallelErrorsMetadata 是 continue 或 skip 失败模式的并行节点记录失败子节点错误的元数据键，
+// 以子节点ID为键。
+const ParallelErrorsMetadata = "parallel_errors"
+
+// MergeStrategy decides how the states of the children of a parallel node are merged.
+// MergeStrategy 决定并行节点各子节点的状态如何合并。
+type MergeStrategy string
+
+const (
+	// MergeStrategyOverwrite applies the changes of every child in declaration order, so
+	// later children win when several change the same variable. This is the default.
+	MergeStrategyOverwrite MergeStrategy = "overwrite"
+	// MergeStrategyError fails the node when children change the same variable to
+	// different values or rewrite the messages.
+	MergeStrategyError MergeStrategy = "error"
+	// MergeStrategyNamespace stores the variables changed by each child as a map under the
+	// child's ID instead of merging them into the top level.
+	MergeStrategyNamespace MergeStrategy = "namespace"
+)
+
+// MergeFunc merges the results of the children into the input state of a parallel node.
+// results is in the order of the children; failed children have a nil result.
+// MergeFunc 将各子节点的结果合并到并行节点的输入状态中。results 与子节点顺序一致，
+// 失败的子节点结果为 nil。
+type MergeFunc func(base *State, results []*State) (*State, error)
+
+// parallelLimitContextKey is the context key for the concurrency limit of parallel nodes.
+type parallelLimitContextKey struct{}
+
+// WithParallel turns the node into a parallel node that runs the children concurrently on
+// copies of the state and merges their changes. The number of children running at once is
+// bounded by the graph's MaxConcurrency. Each child applies its own timeout, retries,
+// failure mode and node middleware; graph middleware wraps the parallel node as a whole.
+// WithParallel 将节点设为并行节点：在状态副本上并发执行各子节点并合并其修改。
+// 同时执行的子节点数受图的 MaxConcurrency 限制。每个子节点使用自身的超时、重试、
+// 失败模式和节点中间件；图中间件作用于整个并行节点。
+func (nb *NodeBuilder) WithParallel(children ...*Node) *NodeBuilder {
+	nb.node.Children = append(nb.node.Children, children...)
+	nb.node.Type = NodeTypeParallel
+	return nb
+}
+
+// WithMergeStrategy sets how a parallel node merges the states of its children.
+// WithMergeStrategy 设置并行节点合并子节点状态的方式。
+func (nb *NodeBuilder) WithMergeStrategy(strategy MergeStrategy) *NodeBuilder {
+	nb.node.Config.MergeStrategy = strategy
+	return nb
+}
+
+// WithMergeFunc sets a custom merge function for a parallel node, replacing the merge strategy.
+// WithMergeFunc 为并行节点设置自定义合并函数，取代合并策略。
+func (nb *NodeBuilder) WithMergeFunc(fn MergeFunc) *NodeBuilder {
+	nb.node.MergeFunc = fn
+	return nb
+}
+
+// executeParallel runs the children of a parallel node concurrently and merges their states.
+// In stop mode the first failure cancels the other children and fails the node; in continue
+// and skip mode the successful children are merged and the failures are recorded in the
+// history and under ParallelErrorsMetadata.
+// executeParallel 并发执行并行节点的子节点并合并其状态。stop 模式下首个失败会取消其他子节点
+// 并使节点失败；continue 和 skip 模式下合并成功的子节点，失败记录在执行历史和
+// ParallelErrorsMetadata 中。
+func (n *Node) executeParallel(ctx context.Context, state *State) (*State, error) {
+	if len(n.Children) == 0 {
+		return nil, fmt.Errorf("parallel node %s has no child nodes", n.ID)
+	}
+
+	tolerant := n.Config.FailureMode == FailureModeContinue || n.Config.FailureMode == FailureModeSkip
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit, _ := ctx.Value(parallelLimitContextKey{}).(int)
+	if limit <= 0 || limit > len(n.Children) {
+		limit = len(n.Children)
+	}
+	semaphore := make(chan struct{}, limit)
+
+	results := make([]*State, len(n.Children))
+	errs := make([]error, len(n.Children))
+	var failOnce sync.Once
+	cause := -1
+	var wg sync.WaitGroup
+	for i, child := range n.Children {
+		wg.Add(1)
+		go func(i int, child *Node) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-runCtx.Done():
+				errs[i] = runCtx.Err()
+				return
+			}
+
+			childCtx := context.WithValue(runCtx, nodeIDContextKey{}, child.ID)
+			results[i], errs[i] = child.Execute(childCtx, state.Clone())
+			if errs[i] != nil && !tolerant {
+				failOnce.Do(func() {
+					cause = i
+					cancel()
+				})
+			}
+		}(i, child)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Report the failures that caused the cancellation rather than the cancelled siblings
+	var failures []error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		results[i] = nil
+		if !tolerant && i != cause && errors.Is(err, context.Canceled) {
+			continue
+		}
+		failures = append(failures, fmt.Errorf("child %s: %w", n.Children[i].ID, err))
+	}
+	if len(failures) > 0 && !tolerant {
+		return nil, errors.Join(failures...)
+	}
+
+	var merged *State
+	var err error
+	if n.MergeFunc != nil {
+		merged, err = n.MergeFunc(state, results)
+	} else {
+		merged, err = mergeParallelStates(state, n.Children, results, n.Config.MergeStrategy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge parallel results: %w", err)
+	}
+
+	if len(failures) > 0 {
+		childErrors := make(map[string]interface{})
+		now := time.Now()
+		for i, err := range errs {
+			if err == nil {
+				continue
+			}
+			childErrors[n.Children[i].ID] = err.Error()
+			merged.AddExecutionStep(ExecutionStep{
+				NodeID:    n.Children[i].ID,
+				StartTime: now,
+				EndTime:   now,
+				Error:     err.Error(),
+			})
+		}
+		merged.SetMetadata(ParallelErrorsMetadata, childErrors)
+	}
+	return merged, nil
+}
+
+// mergeParallelStates applies the changes each child made to its copy of before to a new
+// copy, leaving before untouched when the merge fails.
+func mergeParallelStates(before *State, children []*Node, results []*State, strategy MergeStrategy) (*State, error) {
+	historyLen := len(before.History)
+	base := before.Clone()
+	owners := make(map[string]string)
+
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		childID := children[i].ID
+		diff := DiffStates(before, result)
+
+		switch strategy {
+		case MergeStrategyNamespace:
+			if len(diff.Variables) > 0 {
+				base.SetVariable(childID, diff.Variables)
+			}
+		case MergeStrategyError:
+			for key, value := range diff.Variables {
+				if owner, exists := owners[key]; exists && !reflect.DeepEqual(base.Variables[key], value) {
+					return nil, fmt.Errorf("children %s and %s both changed variable %s", owner, childID, key)
+				}
+				owners[key] = childID
+				base.SetVariable(key, value)
+			}
+			for _, key := range diff.RemovedVariables {
+				if owner, exists := owners[key]; exists {
+					return nil, fmt.Errorf("children %s and %s both changed variable %s", owner, childID, key)
+				}
+				owners[key] = childID
+				delete(base.Variables, key)
+			}
+		default:
+			for key, value := range diff.Variables {
+				base.SetVariable(key, value)
+			}
+			for _, key := range diff.RemovedVariables {
+				delete(base.Variables, key)
+			}
+		}
+
+		for key, value := range diff.Metadata {
+			base.SetMetadata(key, value)
+		}
+		for _, key := range diff.RemovedMetadata {
+			delete(base.Metadata, key)
+		}
+
+		if diff.MessagesReset {
+			if strategy == MergeStrategyError {
+				return nil, fmt.Errorf("child %s rewrote the conversation messages", childID)
+			}
+			base.Messages = append(base.Messages[:0:0], result.Messages...)
+		} else {
+			base.Messages = append(base.Messages, diff.Messages...)
+		}
+
+		if len(result.History) > historyLen {
+			base.History = append(base.History, result.History[historyLen:]...)
+		}
+	}
+
+	base.UpdatedAt = time.Now()
+	return base, nil
+}
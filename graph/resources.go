@@ -0,0 +1,165 @@
+// Package graph - Setup nodes and shared resources
+// 包 graph - 初始化节点与共享资源
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ================================
+// Shared Resources 共享资源
+// ================================
+
+// ErrRunnableClosed is returned when invoking a runnable after Close.
+// ErrRunnableClosed 表示在 Close 之后调用可运行实例。
+var ErrRunnableClosed = errors.New("runnable is closed")
+
+// Resources is a registry of resources shared by every execution of a runnable,
+// such as database pools or loaded indexes. Setup nodes register resources; node
+// functions look them up through ResourceFromContext.
+// Resources 是可运行实例所有执行共享的资源注册表，例如数据库连接池或已加载的索引。
+// 初始化节点注册资源，节点函数通过 ResourceFromContext 获取。
+type Resources struct {
+	values    map[string]interface{}
+	teardowns []func() error
+	lock      sync.RWMutex
+}
+
+// newResources creates an empty resource registry.
+func newResources() *Resources {
+	return &Resources{values: make(map[string]interface{})}
+}
+
+// Set registers a resource. Values implementing io.Closer are closed on Runnable.Close.
+// Set 注册资源，实现 io.Closer 的资源会在 Runnable.Close 时关闭。
+func (r *Resources) Set(name string, value interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.values[name] = value
+	if closer, ok := value.(io.Closer); ok {
+		r.teardowns = append(r.teardowns, closer.Close)
+	}
+}
+
+// Get returns a registered resource.
+// Get 返回已注册的资源。
+func (r *Resources) Get(name string) (interface{}, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	value, ok := r.values[name]
+	return value, ok
+}
+
+// OnClose registers a teardown function run on Runnable.Close, for resources that
+// are not io.Closers.
+// OnClose 注册在 Runnable.Close 时运行的清理函数，用于未实现 io.Closer 的资源。
+func (r *Resources) OnClose(fn func() error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.teardowns = append(r.teardowns, fn)
+}
+
+// close runs the teardown functions in reverse registration order and clears the registry.
+func (r *Resources) close() error {
+	r.lock.Lock()
+	teardowns := r.teardowns
+	r.values = make(map[string]interface{})
+	r.teardowns = nil
+	r.lock.Unlock()
+
+	var errs []error
+	for i := len(teardowns) - 1; i >= 0; i-- {
+		if err := teardowns[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resourcesContextKey is the context key for the resource registry of a runnable.
+type resourcesContextKey struct{}
+
+// ResourcesFromContext returns the resource registry of the runnable executing in ctx.
+// ResourcesFromContext 返回 ctx 中正在执行的可运行实例的资源注册表。
+func ResourcesFromContext(ctx context.Context) (*Resources, bool) {
+	resources, ok := ctx.Value(resourcesContextKey{}).(*Resources)
+	return resources, ok
+}
+
+// ResourceFromContext returns a shared resource registered by a setup node.
+// ResourceFromContext 返回初始化节点注册的共享资源。
+func ResourceFromContext(ctx context.Context, name string) (interface{}, bool) {
+	resources, ok := ResourcesFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return resources.Get(name)
+}
+
+// ================================
+// Setup Nodes 初始化节点
+// ================================
+
+// WithSetupNode adds a node executed once per runnable, before its first invocation, rather
+// than once per invocation. Setup nodes are not part of the routing; they register shared
+// resources through ResourcesFromContext and run in the order they were added.
+// WithSetupNode 添加每个可运行实例只执行一次的初始化节点，在首次调用之前运行，而不是每次调用都运行。
+// 初始化节点不参与路由，通过 ResourcesFromContext 注册共享资源，按添加顺序执行。
+func (gb *GraphBuilder) WithSetupNode(node *Node) *GraphBuilder {
+	if node == nil {
+		gb.errs = append(gb.errs, fmt.Errorf("setup node cannot be nil"))
+		return gb
+	}
+	gb.graph.setupNodes = append(gb.graph.setupNodes, node)
+	return gb
+}
+
+// Setup runs the setup nodes of the graph if they have not run yet. It is called
+// automatically by the first invocation; call it directly to initialize resources at
+// startup. When a setup node fails, the resources registered so far are torn down and
+// the next call retries.
+// Setup 在初始化节点尚未执行时运行它们。首次调用时会自动执行，也可在启动时直接调用以提前初始化资源。
+// 初始化节点失败时，已注册的资源会被清理，下次调用会重试。
+func (r *Runnable) Setup(ctx context.Context) error {
+	r.setupLock.Lock()
+	defer r.setupLock.Unlock()
+
+	if r.closed {
+		return ErrRunnableClosed
+	}
+	if r.ready.Load() {
+		return nil
+	}
+
+	// Setup outlives the invocation that triggered it
+	ctx = context.WithValue(context.WithoutCancel(ctx), resourcesContextKey{}, r.resources)
+	for _, node := range r.graph.setupNodes {
+		if _, err := node.Execute(ctx, NewState("setup_"+node.ID)); err != nil {
+			if closeErr := r.resources.close(); closeErr != nil {
+				err = errors.Join(err, closeErr)
+			}
+			return fmt.Errorf("setup node %s failed: %w", node.ID, err)
+		}
+	}
+	r.ready.Store(true)
+	return nil
+}
+
+// Close tears down the shared resources in reverse registration order. Later invocations
+// fail with ErrRunnableClosed. Close is idempotent.
+// Close 按注册的逆序清理共享资源，之后的调用返回 ErrRunnableClosed。重复调用 Close 是安全的。
+func (r *Runnable) Close() error {
+	r.setupLock.Lock()
+	defer r.setupLock.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.ready.Store(false)
+	return r.resources.close()
+}
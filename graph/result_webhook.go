@@ -0,0 +1,277 @@
+// Package graph - Execution result webhooks
+// 包 graph - 执行结果 Webhook
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ================================
+// Result Webhooks 执行结果 Webhook
+// ================================
+
+// Execution statuses reported by result webhooks.
+// 执行结果 webhook 上报的执行状态。
+const (
+	ExecutionStatusSuccess = "success"
+	ExecutionStatusFailure = "failure"
+	ExecutionStatusPending = "pending"
+)
+
+// ResultWebhookConfig configures a webhook that receives a summary of every finished execution.
+// ResultWebhookConfig 配置接收每次执行结束摘要的 webhook。
+type ResultWebhookConfig struct {
+	// URL receives the signed summary as a JSON POST.
+	// URL 以 JSON POST 方式接收签名的执行摘要。
+	URL string
+
+	// Secret signs the requests like approval requests, see SignApprovalPayload; empty sends them unsigned.
+	// Secret 用于签名请求，签名方式与审批请求相同（见 SignApprovalPayload）；为空时不签名。
+	Secret string
+
+	// Variables lists the state variables included in the summary.
+	// Variables 列出摘要中包含的状态变量。
+	Variables []string
+
+	// Messages is the number of final messages included in the summary; defaults to 1,
+	// a negative value includes none.
+	// Messages 摘要中包含的最后几条消息的数量，默认1条，负数表示不包含消息。
+	Messages int
+
+	// MaxRetries is the number of retries after a failed delivery; defaults to 3, a negative
+	// value disables retries. Network errors, 429 and 5xx responses are retried.
+	// MaxRetries 投递失败后的重试次数，默认3次，负数表示不重试。网络错误、429 和 5xx 响应会重试。
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry, doubled for each further retry; defaults to 1 second.
+	// RetryDelay 首次重试前的等待时间，之后每次重试翻倍，默认1秒。
+	RetryDelay time.Duration
+
+	// HTTPClient sends the webhook; defaults to a client with a 10 second timeout.
+	// HTTPClient 发送 webhook 使用的客户端，默认超时10秒。
+	HTTPClient *http.Client
+
+	// OnError is called when a summary could not be delivered after all retries.
+	// OnError 在重试耗尽后仍投递失败时调用。
+	OnError func(summary *ExecutionSummary, err error)
+}
+
+// ExecutionSummary is the payload posted to result webhooks when an execution finishes.
+// ExecutionSummary 是执行结束时发送到结果 webhook 的内容。
+type ExecutionSummary struct {
+	ExecutionID string                 `json:"execution_id"`
+	GraphID     string                 `json:"graph_id"`
+	StateID     string                 `json:"state_id,omitempty"`
+	Status      string                 `json:"status"`
+	Error       string                 `json:"error,omitempty"`
+	StartedAt   time.Time              `json:"started_at"`
+	FinishedAt  time.Time              `json:"finished_at"`
+	DurationMs  int64                  `json:"duration_ms"`
+	Path        []string               `json:"path,omitempty"`
+	Messages    []SummaryMessage       `json:"messages,omitempty"`
+	Variables   map[string]interface{} `json:"variables,omitempty"`
+	Usage       Usage                  `json:"usage"`
+}
+
+// SummaryMessage is the text of a message included in an execution summary.
+// SummaryMessage 是执行摘要中包含的消息文本。
+type SummaryMessage struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// resultWebhooks holds the result webhooks of a runnable and tracks pending deliveries.
+type resultWebhooks struct {
+	lock     sync.RWMutex
+	configs  []ResultWebhookConfig
+	inflight sync.WaitGroup
+}
+
+// WithResultWebhook posts a signed summary of every execution that finishes, successfully or
+// not, to the configured URL. Deliveries run in the background with retries, so they never
+// delay or fail the execution; use FlushResultWebhooks to wait for them before shutting down.
+// WithResultWebhook 在每次执行结束（无论成功或失败）时向配置的地址发送签名的执行摘要。
+// 投递在后台进行并自动重试，不会延迟执行或导致执行失败；关闭前可调用 FlushResultWebhooks 等待投递完成。
+func (r *Runnable) WithResultWebhook(config ResultWebhookConfig) *Runnable {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.Messages == 0 {
+		config.Messages = 1
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Second
+	}
+
+	r.webhooks.lock.Lock()
+	defer r.webhooks.lock.Unlock()
+	r.webhooks.configs = append(r.webhooks.configs, config)
+	return r
+}
+
+// FlushResultWebhooks waits until the pending result webhook deliveries have finished or ctx is done.
+// FlushResultWebhooks 等待待投递的结果 webhook 完成，或直到 ctx 结束。
+func (r *Runnable) FlushResultWebhooks(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.webhooks.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notifyResultWebhooks sends the summary of a finished execution to every result webhook.
+// notifyResultWebhooks 将已结束执行的摘要发送到所有结果 webhook。
+func (r *Runnable) notifyResultWebhooks(execCtx *ExecutionContext, state *State, err error) {
+	r.webhooks.lock.RLock()
+	configs := r.webhooks.configs
+	r.webhooks.lock.RUnlock()
+	if len(configs) == 0 {
+		return
+	}
+
+	base := r.executionSummary(execCtx, state, err)
+	for _, config := range configs {
+		summary := base
+		if state != nil {
+			summary.Messages = summaryMessages(state, config.Messages)
+			summary.Variables = summaryVariables(state, config.Variables)
+		}
+
+		// Encode now, the state may change once the caller gets it back
+		body, err := json.Marshal(&summary)
+		if err != nil {
+			if config.OnError != nil {
+				config.OnError(&summary, fmt.Errorf("failed to encode execution summary: %w", err))
+			}
+			continue
+		}
+
+		r.webhooks.inflight.Add(1)
+		go func(config ResultWebhookConfig, summary *ExecutionSummary) {
+			defer r.webhooks.inflight.Done()
+			if err := deliverResultWebhook(config, body); err != nil && config.OnError != nil {
+				config.OnError(summary, err)
+			}
+		}(config, &summary)
+	}
+}
+
+// executionSummary describes a finished execution, without messages or variables.
+// executionSummary 描述已结束的执行，不包含消息和变量。
+func (r *Runnable) executionSummary(execCtx *ExecutionContext, state *State, err error) ExecutionSummary {
+	finished := time.Now()
+	summary := ExecutionSummary{
+		ExecutionID: execCtx.ExecutionID,
+		GraphID:     r.graph.ID,
+		Status:      ExecutionStatusSuccess,
+		StartedAt:   execCtx.StartTime,
+		FinishedAt:  finished,
+		DurationMs:  finished.Sub(execCtx.StartTime).Milliseconds(),
+		Path:        append([]string(nil), execCtx.Path...),
+	}
+	if err != nil {
+		summary.Status = ExecutionStatusFailure
+		summary.Error = err.Error()
+	}
+	if errors.Is(err, ErrExecutionInterrupted) {
+		summary.Status = ExecutionStatusPending
+	}
+	summary.Usage, _ = execCtx.usage.snapshot()
+	if state != nil {
+		summary.StateID = state.ID
+	}
+	return summary
+}
+
+// summaryMessages returns the text of the last count messages of the state.
+func summaryMessages(state *State, count int) []SummaryMessage {
+	if count <= 0 || len(state.Messages) == 0 {
+		return nil
+	}
+	messages := state.Messages
+	if len(messages) > count {
+		messages = messages[len(messages)-count:]
+	}
+	result := make([]SummaryMessage, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, SummaryMessage{Role: string(msg.Role), Text: messageText(msg)})
+	}
+	return result
+}
+
+// summaryVariables returns the selected state variables that are set.
+func summaryVariables(state *State, names []string) map[string]interface{} {
+	if len(names) == 0 {
+		return nil
+	}
+	variables := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		if value, ok := state.GetVariable(name); ok {
+			variables[name] = value
+		}
+	}
+	return variables
+}
+
+// deliverResultWebhook posts the summary, retrying with exponential backoff.
+// deliverResultWebhook 发送执行摘要，失败时按指数退避重试。
+func deliverResultWebhook(config ResultWebhookConfig, body []byte) error {
+	delay := config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		retry, err := postResultWebhook(config, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= config.MaxRetries {
+			return fmt.Errorf("result webhook failed after %d attempts: %w", attempt+1, err)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// postResultWebhook sends one delivery attempt and reports whether a failure may be retried.
+// Every attempt is signed with a fresh timestamp.
+func postResultWebhook(config ResultWebhookConfig, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create result webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.Secret != "" {
+		for key, values := range SignApprovalPayload(config.Secret, body, time.Now()) {
+			req.Header[key] = values
+		}
+	}
+
+	resp, err := config.HTTPClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send result webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return retry, fmt.Errorf("result webhook returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
@@ -0,0 +1,581 @@
+// Package graph - JSON serialization of graph topologies
+// 包 graph - 图拓扑的JSON序列化
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ================================
+// Graph Definition 图定义
+// ================================
+
+// GraphDefinition is the serializable topology of a graph: its nodes, edges, conditions
+// and entry point. Go functions are referred to by name and bound through a FunctionRegistry
+// when the graph is rebuilt; edge and loop conditions refer to conditions registered with
+// RegisterCondition. Middleware, setup nodes and state managers are runtime concerns and
+// are not part of the definition.
+// GraphDefinition 是图的可序列化拓扑：节点、边、条件和入口点。Go 函数按名称引用，
+// 重建图时通过 FunctionRegistry 绑定；边和循环的条件引用通过 RegisterCondition 注册的条件。
+// 中间件、初始化节点和状态管理器属于运行时配置，不包含在定义中。
+type GraphDefinition struct {
+	// ID is the unique identifier of the graph.
+	ID string `json:"id"`
+
+	// Name is a human-readable name for the graph.
+	Name string `json:"name,omitempty"`
+
+	// Description describes what the graph does.
+	Description string `json:"description,omitempty"`
+
+	// Version is the version of the graph.
+	Version string `json:"version,omitempty"`
+
+	// Config contains the configuration of the graph; nil keeps the builder's configuration.
+	Config *GraphConfig `json:"config,omitempty"`
+
+	// InputSchema declares the variables expected in the initial state.
+	InputSchema []ParameterDef `json:"input_schema,omitempty"`
+
+	// EntryPoint is the ID of the first node.
+	EntryPoint string `json:"entry_point"`
+
+	// Nodes are the nodes of the graph.
+	Nodes []*NodeDefinition `json:"nodes"`
+
+	// Edges are the edges of the graph, in routing order.
+	Edges []*EdgeDefinition `json:"edges"`
+}
+
+// NodeDefinition is the serializable form of a node.
+// NodeDefinition 是节点的可序列化形式。
+type NodeDefinition struct {
+	// ID is the unique identifier of the node.
+	ID string `json:"id"`
+
+	// Name is a human-readable name for the node.
+	Name string `json:"name,omitempty"`
+
+	// Type is the node type; defaults to function.
+	Type NodeType `json:"type,omitempty"`
+
+	// Function names the registered function of function and loop nodes, or the registered
+	// router of condition nodes. Defaults to the node ID.
+	Function string `json:"function,omitempty"`
+
+	// Condition names the registered condition of loop nodes.
+	Condition string `json:"condition,omitempty"`
+
+	// Expression makes a function node an ExprNode storing its result in OutputKey.
+	Expression string `json:"expression,omitempty"`
+
+	// OutputKey is the variable an expression node writes.
+	OutputKey string `json:"output_key,omitempty"`
+
+	// Config contains the configuration of the node.
+	Config NodeConfig `json:"config"`
+
+	// SubGraph is the sub-graph of subgraph nodes and the body of sub-graph loops.
+	SubGraph *GraphDefinition `json:"subgraph,omitempty"`
+
+	// Children are the nodes run concurrently by parallel nodes.
+	Children []*NodeDefinition `json:"children,omitempty"`
+
+	// Inputs defines the expected input parameters.
+	Inputs []ParameterDef `json:"inputs,omitempty"`
+
+	// Outputs defines the expected output parameters.
+	Outputs []ParameterDef `json:"outputs,omitempty"`
+
+	// Description describes what the node does.
+	Description string `json:"description,omitempty"`
+
+	// Version is the version of the node.
+	Version string `json:"version,omitempty"`
+
+	// Tags are labels for categorizing the node.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// EdgeDefinition is the serializable form of an edge. Unlike Edge, omitted fields take the
+// defaults of NewEdge, so hand-written definitions stay short.
+// EdgeDefinition 是边的可序列化形式。与 Edge 不同，省略的字段取 NewEdge 的默认值，便于手写定义。
+type EdgeDefinition struct {
+	// ID is the unique identifier of the edge; defaults to "<from>_to_<to>".
+	ID string `json:"id,omitempty"`
+
+	// Name is a human-readable name for the edge.
+	Name string `json:"name,omitempty"`
+
+	// Type is the edge type; defaults to conditional when Condition or Expression is set and normal otherwise.
+	Type EdgeType `json:"type,omitempty"`
+
+	// From is the ID of the source node.
+	From string `json:"from"`
+
+	// To is the ID of the destination node.
+	To string `json:"to"`
+
+	// Condition names a condition registered with RegisterCondition.
+	Condition string `json:"condition,omitempty"`
+
+	// Expression is a boolean expression over the state, such as "variables.category == 'math'".
+	Expression string `json:"expression,omitempty"`
+
+	// Priority is the priority of the edge.
+	Priority int `json:"priority,omitempty"`
+
+	// Weight is the weight of the edge; defaults to 1.
+	Weight float64 `json:"weight,omitempty"`
+
+	// Enabled disables the edge when false; defaults to true.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Mappings are data mappings applied when the edge is traversed.
+	Mappings []DataMapping `json:"mappings,omitempty"`
+
+	// Description describes what the edge represents.
+	Description string `json:"description,omitempty"`
+
+	// Tags are labels for categorizing the edge.
+	Tags []string `json:"tags,omitempty"`
+
+	// Metadata contains custom metadata for the edge.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ================================
+// Function Registry 函数注册表
+// ================================
+
+// FunctionRegistry binds the function names of a definition to Go functions.
+// FunctionRegistry 将定义中的函数名称绑定到 Go 函数。
+type FunctionRegistry struct {
+	functions map[string]NodeFunction
+	routers   map[string]ConditionFunction
+	lock      sync.RWMutex
+}
+
+// NewFunctionRegistry creates an empty function registry.
+// NewFunctionRegistry 创建空的函数注册表。
+func NewFunctionRegistry() *FunctionRegistry {
+	return &FunctionRegistry{
+		functions: make(map[string]NodeFunction),
+		routers:   make(map[string]ConditionFunction),
+	}
+}
+
+// Register binds name, usually a node ID, to the function of function and loop nodes.
+// Register 将名称（通常为节点ID）绑定到函数节点和循环节点的处理函数。
+func (r *FunctionRegistry) Register(name string, fn NodeFunction) *FunctionRegistry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.functions[name] = fn
+	return r
+}
+
+// RegisterRouter binds name, usually a node ID, to the condition function of condition nodes.
+// RegisterRouter 将名称（通常为节点ID）绑定到条件节点的条件函数。
+func (r *FunctionRegistry) RegisterRouter(name string, fn ConditionFunction) *FunctionRegistry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.routers[name] = fn
+	return r
+}
+
+// Function returns the function registered under name.
+// Function 返回以 name 注册的处理函数。
+func (r *FunctionRegistry) Function(name string) (NodeFunction, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	fn, ok := r.functions[name]
+	return fn, ok
+}
+
+// Router returns the condition function registered under name.
+// Router 返回以 name 注册的条件函数。
+func (r *FunctionRegistry) Router(name string) (ConditionFunction, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	fn, ok := r.routers[name]
+	return fn, ok
+}
+
+// ================================
+// Serialization 序列化
+// ================================
+
+// MarshalJSON encodes the topology of the graph as a GraphDefinition. It fails when the graph
+// holds logic that cannot be referred to by name, such as an edge condition closure that was
+// not registered with RegisterCondition.
+// MarshalJSON 将图的拓扑编码为 GraphDefinition。图中包含无法按名称引用的逻辑时
+// （例如未通过 RegisterCondition 注册的边条件闭包）返回错误。
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	definition, err := g.Definition()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(definition)
+}
+
+// Definition returns the serializable topology of the graph.
+// Definition 返回图的可序列化拓扑。
+func (g *Graph) Definition() (*GraphDefinition, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	config := g.Config
+	definition := &GraphDefinition{
+		ID:          g.ID,
+		Name:        g.Name,
+		Description: g.Description,
+		Version:     g.Version,
+		Config:      &config,
+		InputSchema: g.InputSchema,
+		EntryPoint:  g.entryPoint,
+		Nodes:       make([]*NodeDefinition, 0, len(g.nodes)),
+		Edges:       make([]*EdgeDefinition, 0),
+	}
+
+	for _, node := range sortedNodes(g.nodes) {
+		nodeDefinition, err := node.definition()
+		if err != nil {
+			return nil, err
+		}
+		definition.Nodes = append(definition.Nodes, nodeDefinition)
+	}
+
+	for _, edge := range g.router.edgeRefs() {
+		edgeDefinition, err := edge.definition()
+		if err != nil {
+			return nil, err
+		}
+		definition.Edges = append(definition.Edges, edgeDefinition)
+	}
+
+	return definition, nil
+}
+
+// definition returns the serializable form of the node.
+func (n *Node) definition() (*NodeDefinition, error) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	definition := &NodeDefinition{
+		ID:          n.ID,
+		Name:        n.Name,
+		Type:        n.Type,
+		Function:    n.FunctionName,
+		Condition:   n.ConditionName,
+		Config:      n.Config,
+		Inputs:      n.Inputs,
+		Outputs:     n.Outputs,
+		Description: n.Description,
+		Version:     n.Version,
+		Tags:        n.Tags,
+	}
+
+	switch n.Type {
+	case NodeTypeFunction:
+		// Expression nodes need no registered function
+		if expression, ok := n.Config.Metadata["expression"].(string); ok && n.FunctionName == "" && len(n.Outputs) == 1 {
+			definition.Expression = expression
+			definition.OutputKey = n.Outputs[0].Name
+		}
+	case NodeTypeLoop:
+		if n.LoopCondition != nil && n.ConditionName == "" {
+			return nil, fmt.Errorf("loop node %s has a condition function that cannot be serialized; register it with RegisterCondition", n.ID)
+		}
+	case NodeTypeParallel:
+		if n.MergeFunc != nil {
+			return nil, fmt.Errorf("parallel node %s has a merge function that cannot be serialized; use a merge strategy", n.ID)
+		}
+		for _, child := range n.Children {
+			childDefinition, err := child.definition()
+			if err != nil {
+				return nil, err
+			}
+			definition.Children = append(definition.Children, childDefinition)
+		}
+	}
+
+	if n.SubGraph != nil {
+		subGraph, err := n.SubGraph.Definition()
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.ID, err)
+		}
+		definition.SubGraph = subGraph
+	}
+
+	return definition, nil
+}
+
+// definition returns the serializable form of the edge.
+func (e *Edge) definition() (*EdgeDefinition, error) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if e.Condition != nil && e.ConditionName == "" {
+		return nil, fmt.Errorf("edge %s has a condition function that cannot be serialized; register it with RegisterCondition or use WithExpression", e.ID)
+	}
+
+	enabled := e.Enabled
+	return &EdgeDefinition{
+		ID:          e.ID,
+		Name:        e.Name,
+		Type:        e.Type,
+		From:        e.From,
+		To:          e.To,
+		Condition:   e.ConditionName,
+		Expression:  e.Expression,
+		Priority:    e.Priority,
+		Weight:      e.Weight,
+		Enabled:     &enabled,
+		Mappings:    e.Mappings,
+		Description: e.Description,
+		Tags:        e.Tags,
+		Metadata:    e.Metadata,
+	}, nil
+}
+
+// ================================
+// Loading 加载
+// ================================
+
+// LoadFromJSON rebuilds a graph from the JSON produced by Graph.MarshalJSON or written by hand,
+// binding function names through registry. registry may be nil when the graph only uses
+// expression nodes and registered conditions.
+// LoadFromJSON 根据 Graph.MarshalJSON 生成或手写的JSON重建图，通过 registry 绑定函数名称。
+// 图只使用表达式节点和已注册条件时 registry 可以为 nil。
+func LoadFromJSON(data []byte, registry *FunctionRegistry) (*Graph, error) {
+	var definition GraphDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse graph definition: %w", err)
+	}
+	return NewGraph(definition.ID).
+		WithDefinition(&definition).
+		WithFunctions(registry).
+		BuildE()
+}
+
+// WithDefinition applies the graph-level fields of definition and adds its nodes and edges
+// when the graph is built, once WithFunctions has provided the functions they refer to.
+// WithDefinition 应用定义中的图级字段，并在构建图时添加其节点和边，
+// 节点引用的函数由 WithFunctions 提供。
+func (gb *GraphBuilder) WithDefinition(definition *GraphDefinition) *GraphBuilder {
+	if definition == nil {
+		gb.errs = append(gb.errs, fmt.Errorf("graph definition cannot be nil"))
+		return gb
+	}
+
+	if definition.Name != "" {
+		gb.graph.Name = definition.Name
+	}
+	if definition.Description != "" {
+		gb.graph.Description = definition.Description
+	}
+	if definition.Version != "" {
+		gb.graph.Version = definition.Version
+	}
+	if definition.Config != nil {
+		gb.graph.Config = *definition.Config
+	}
+	if len(definition.InputSchema) > 0 {
+		gb.graph.InputSchema = definition.InputSchema
+	}
+	if definition.EntryPoint != "" {
+		gb.graph.entryPoint = definition.EntryPoint
+	}
+	gb.definition = definition
+	return gb
+}
+
+// WithFunctions sets the registry used to bind the function names of the definition.
+// WithFunctions 设置用于绑定定义中函数名称的注册表。
+func (gb *GraphBuilder) WithFunctions(registry *FunctionRegistry) *GraphBuilder {
+	gb.functions = registry
+	return gb
+}
+
+// applyDefinition adds the nodes and edges of the definition, once.
+func (gb *GraphBuilder) applyDefinition() {
+	definition := gb.definition
+	if definition == nil {
+		return
+	}
+	gb.definition = nil
+
+	for _, nodeDefinition := range definition.Nodes {
+		node, err := nodeDefinition.build(gb.functions)
+		if err != nil {
+			gb.errs = append(gb.errs, err)
+			continue
+		}
+		gb.addNode(node)
+	}
+	for _, edgeDefinition := range definition.Edges {
+		if edgeDefinition == nil {
+			gb.errs = append(gb.errs, fmt.Errorf("edge cannot be nil"))
+			continue
+		}
+		gb.addEdge(edgeDefinition.build())
+	}
+}
+
+// build creates the node, binding its functions through registry.
+func (d *NodeDefinition) build(registry *FunctionRegistry) (*Node, error) {
+	if d == nil {
+		return nil, fmt.Errorf("node cannot be nil")
+	}
+
+	nodeType := d.Type
+	if nodeType == "" {
+		nodeType = NodeTypeFunction
+	}
+	functionName := d.Function
+	if functionName == "" {
+		functionName = d.ID
+	}
+
+	var node *Node
+	if nodeType == NodeTypeFunction && d.Expression != "" {
+		node = ExprNode(d.ID, d.Expression, d.OutputKey)
+	} else {
+		node = NewNode(d.ID).WithType(nodeType).Build()
+	}
+
+	switch nodeType {
+	case NodeTypeFunction:
+		if d.Expression != "" {
+			break
+		}
+		fn, ok := registry.Function(functionName)
+		if !ok {
+			return nil, fmt.Errorf("node %s: no function registered as %s", d.ID, functionName)
+		}
+		node.Function = fn
+	case NodeTypeCondition:
+		fn, ok := registry.Router(functionName)
+		if !ok {
+			return nil, fmt.Errorf("node %s: no router registered as %s", d.ID, functionName)
+		}
+		node.ConditionFunc = fn
+	case NodeTypeLoop:
+		if d.Condition != "" {
+			if _, ok := LookupCondition(d.Condition); !ok {
+				return nil, fmt.Errorf("node %s references unknown condition %s", d.ID, d.Condition)
+			}
+			node.LoopCondition = LoopCondition(NamedCondition(d.Condition))
+		}
+		if d.SubGraph == nil {
+			fn, ok := registry.Function(functionName)
+			if !ok {
+				return nil, fmt.Errorf("node %s: no function registered as %s", d.ID, functionName)
+			}
+			node.Function = fn
+		}
+	case NodeTypeParallel:
+		for _, childDefinition := range d.Children {
+			child, err := childDefinition.build(registry)
+			if err != nil {
+				return nil, fmt.Errorf("parallel node %s: %w", d.ID, err)
+			}
+			node.Children = append(node.Children, child)
+		}
+	}
+
+	if d.SubGraph != nil {
+		subGraph, err := NewGraph(d.SubGraph.ID).
+			WithDefinition(d.SubGraph).
+			WithFunctions(registry).
+			BuildE()
+		if err != nil {
+			return nil, fmt.Errorf("node %s: invalid sub-graph: %w", d.ID, err)
+		}
+		node.SubGraph = subGraph
+	}
+
+	node.Name = d.Name
+	node.FunctionName = d.Function
+	node.ConditionName = d.Condition
+	metadata := node.Config.Metadata
+	node.Config = d.Config
+	if len(metadata) > 0 {
+		node.Config.Metadata = make(map[string]interface{}, len(metadata)+len(d.Config.Metadata))
+		for k, v := range metadata {
+			node.Config.Metadata[k] = v
+		}
+		for k, v := range d.Config.Metadata {
+			node.Config.Metadata[k] = v
+		}
+	}
+	if d.Description != "" {
+		node.Description = d.Description
+	}
+	if d.Version != "" {
+		node.Version = d.Version
+	}
+	if len(d.Inputs) > 0 {
+		node.Inputs = d.Inputs
+	}
+	if len(d.Outputs) > 0 {
+		node.Outputs = d.Outputs
+	}
+	if len(d.Tags) > 0 {
+		node.Tags = d.Tags
+	}
+	return node, nil
+}
+
+// build creates the edge, filling in the defaults of NewEdge.
+func (d *EdgeDefinition) build() *Edge {
+	id := d.ID
+	if id == "" {
+		id = fmt.Sprintf("%s_to_%s", d.From, d.To)
+	}
+
+	builder := NewEdge(id, d.From, d.To).
+		WithName(d.Name).
+		WithDescription(d.Description).
+		WithTags(d.Tags...)
+	if d.Condition != "" {
+		builder.WithNamedCondition(d.Condition)
+	}
+	if d.Expression != "" {
+		builder.WithExpression(d.Expression)
+	}
+	if d.Priority != 0 {
+		builder.WithPriority(d.Priority)
+	}
+	switch d.Type {
+	case "":
+	case EdgeTypeDefault:
+		builder.AsDefault()
+		if d.Priority != 0 {
+			builder.WithPriority(d.Priority)
+		}
+	default:
+		builder.WithType(d.Type)
+	}
+	if d.Weight != 0 {
+		builder.WithWeight(d.Weight)
+	}
+	if d.Enabled != nil {
+		builder.WithEnabled(*d.Enabled)
+	}
+	for _, mapping := range d.Mappings {
+		builder.WithMapping(mapping.From, mapping.To)
+	}
+	for k, v := range d.Metadata {
+		builder.WithMetadata(k, v)
+	}
+	return builder.Build()
+}
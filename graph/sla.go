@@ -0,0 +1,304 @@
+// Package graph - Workflow SLA evaluation
+// 包 graph - 工作流 SLA 评估
+package graph
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ================================
+// Service Level Agreements 服务等级目标
+// ================================
+
+// DefaultSLAWindow is the sliding window used when SLA.Window is not set.
+// DefaultSLAWindow 是 SLA.Window 未设置时使用的滑动窗口。
+const DefaultSLAWindow = 5 * time.Minute
+
+// DefaultSLAMinSamples is the number of executions required before an SLA is evaluated.
+// DefaultSLAMinSamples 是评估 SLA 前所需的最少执行次数。
+const DefaultSLAMinSamples = 10
+
+// maxSLASamples bounds the memory used by the sliding window of busy graphs.
+const maxSLASamples = 10000
+
+// SLA declares the service level targets of a workflow. Targets left at zero are not checked.
+// SLA 声明工作流的服务等级目标，值为0的目标不检查。
+type SLA struct {
+	// MaxP95Duration is the maximum 95th percentile execution duration.
+	MaxP95Duration time.Duration `json:"max_p95_duration,omitempty"`
+
+	// MaxErrorRate is the maximum ratio of failed executions, between 0 and 1.
+	MaxErrorRate float64 `json:"max_error_rate,omitempty"`
+
+	// Window is the sliding window the targets are evaluated over; defaults to DefaultSLAWindow.
+	Window time.Duration `json:"window,omitempty"`
+
+	// MinSamples is the number of executions in the window required before the targets are
+	// evaluated, so that a single slow start-up run does not raise an alert; defaults to
+	// DefaultSLAMinSamples.
+	MinSamples int `json:"min_samples,omitempty"`
+}
+
+// SLAMetric identifies an SLA target.
+// SLAMetric 标识一个 SLA 目标。
+type SLAMetric string
+
+const (
+	// SLAMetricP95Duration is the 95th percentile execution duration, in milliseconds.
+	SLAMetricP95Duration SLAMetric = "p95_duration"
+	// SLAMetricErrorRate is the ratio of failed executions.
+	SLAMetricErrorRate SLAMetric = "error_rate"
+)
+
+// SLAViolation is emitted when an SLA target starts being violated and again, with Resolved
+// set, when it is met again. Events are only emitted on these transitions, not for every
+// execution while the violation lasts.
+// SLAViolation 在 SLA 目标开始被违反时发出，目标恢复满足时再次发出并设置 Resolved。
+// 事件只在状态切换时发出，违反持续期间的每次执行不会重复发出。
+type SLAViolation struct {
+	// GraphID is the ID of the graph.
+	GraphID string `json:"graph_id"`
+
+	// Metric is the violated target.
+	Metric SLAMetric `json:"metric"`
+
+	// Target is the declared target, in milliseconds for SLAMetricP95Duration.
+	Target float64 `json:"target"`
+
+	// Actual is the value measured over the window, in the same unit as Target.
+	Actual float64 `json:"actual"`
+
+	// Samples is the number of executions in the window.
+	Samples int `json:"samples"`
+
+	// Window is the sliding window the value was measured over.
+	Window time.Duration `json:"window"`
+
+	// Resolved indicates that the target is met again.
+	Resolved bool `json:"resolved"`
+
+	// Timestamp is when the transition was detected.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// String returns a human-readable description of the event.
+// String 返回事件的可读描述。
+func (v SLAViolation) String() string {
+	status := "violated"
+	if v.Resolved {
+		status = "resolved"
+	}
+	return fmt.Sprintf("graph %s SLA %s %s: %.4g (target %.4g, %d executions in %s)",
+		v.GraphID, v.Metric, status, v.Actual, v.Target, v.Samples, v.Window)
+}
+
+// SLAListener receives SLA violation events. Listeners are called synchronously by the
+// execution that caused the transition, after it has finished.
+// SLAListener 接收 SLA 违反事件。监听器在导致状态切换的执行结束后，由该执行同步调用。
+type SLAListener func(violation SLAViolation)
+
+// SLAStatus reports the values measured over the current window.
+// SLAStatus 报告当前窗口内的测量值。
+type SLAStatus struct {
+	// SLA is the evaluated SLA.
+	SLA SLA `json:"sla"`
+
+	// Samples is the number of executions in the window.
+	Samples int `json:"samples"`
+
+	// P95Duration is the 95th percentile execution duration.
+	P95Duration time.Duration `json:"p95_duration"`
+
+	// ErrorRate is the ratio of failed executions.
+	ErrorRate float64 `json:"error_rate"`
+
+	// Violated lists the targets currently violated.
+	Violated []SLAMetric `json:"violated,omitempty"`
+
+	// Violations counts the violation events emitted since the last ResetStats.
+	Violations int64 `json:"violations"`
+}
+
+// slaSample is one execution in the sliding window.
+type slaSample struct {
+	at       time.Time
+	duration time.Duration
+	failed   bool
+}
+
+// slaTracker evaluates the SLA of a runnable over a sliding window.
+type slaTracker struct {
+	graphID    string
+	sla        *SLA
+	samples    []slaSample
+	violated   map[SLAMetric]bool
+	violations int64
+	listeners  []SLAListener
+	lock       sync.Mutex
+}
+
+// newSLATracker creates the tracker of a graph; sla may be nil.
+func newSLATracker(graphID string, sla *SLA) *slaTracker {
+	return &slaTracker{
+		graphID:  graphID,
+		sla:      sla,
+		violated: make(map[SLAMetric]bool),
+	}
+}
+
+// WithSLA declares the SLA targets of the graph.
+// WithSLA 声明图的 SLA 目标。
+func (gb *GraphBuilder) WithSLA(sla SLA) *GraphBuilder {
+	gb.graph.Config.SLA = &sla
+	return gb
+}
+
+// OnSLAViolation registers a listener for SLA violation events, for example to page the
+// on-call engineer when a workflow degrades.
+// OnSLAViolation 注册 SLA 违反事件的监听器，例如在工作流性能下降时通知值班人员。
+func (r *Runnable) OnSLAViolation(listener SLAListener) *Runnable {
+	r.sla.lock.Lock()
+	defer r.sla.lock.Unlock()
+	r.sla.listeners = append(r.sla.listeners, listener)
+	return r
+}
+
+// SLAStatus returns the values measured over the current window, or nil when the graph
+// declares no SLA.
+// SLAStatus 返回当前窗口内的测量值，图未声明 SLA 时返回 nil。
+func (r *Runnable) SLAStatus() *SLAStatus {
+	r.sla.lock.Lock()
+	defer r.sla.lock.Unlock()
+
+	if r.sla.sla == nil {
+		return nil
+	}
+	r.sla.prune(time.Now())
+	return r.sla.status()
+}
+
+// record adds an execution to the window and returns the events of the targets whose
+// state changed, together with the listeners to notify.
+func (t *slaTracker) record(duration time.Duration, err error) ([]SLAViolation, []SLAListener) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.sla == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	t.samples = append(t.samples, slaSample{at: now, duration: duration, failed: err != nil})
+	t.prune(now)
+
+	minSamples := t.sla.MinSamples
+	if minSamples <= 0 {
+		minSamples = DefaultSLAMinSamples
+	}
+	if len(t.samples) < minSamples {
+		return nil, nil
+	}
+
+	status := t.status()
+	var events []SLAViolation
+	check := func(metric SLAMetric, target, actual float64) {
+		if target <= 0 {
+			return
+		}
+		violated := actual > target
+		if violated == t.violated[metric] {
+			return
+		}
+		t.violated[metric] = violated
+		if violated {
+			t.violations++
+		}
+		events = append(events, SLAViolation{
+			GraphID:   t.graphID,
+			Metric:    metric,
+			Target:    target,
+			Actual:    actual,
+			Samples:   status.Samples,
+			Window:    t.window(),
+			Resolved:  !violated,
+			Timestamp: now,
+		})
+	}
+	check(SLAMetricP95Duration, durationMillis(t.sla.MaxP95Duration), durationMillis(status.P95Duration))
+	check(SLAMetricErrorRate, t.sla.MaxErrorRate, status.ErrorRate)
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return events, append([]SLAListener(nil), t.listeners...)
+}
+
+// prune drops the samples outside the window. The caller must hold the lock.
+func (t *slaTracker) prune(now time.Time) {
+	cutoff := now.Add(-t.window())
+	drop := sort.Search(len(t.samples), func(i int) bool {
+		return t.samples[i].at.After(cutoff)
+	})
+	if excess := len(t.samples) - drop - maxSLASamples; excess > 0 {
+		drop += excess
+	}
+	if drop > 0 {
+		t.samples = append(t.samples[:0], t.samples[drop:]...)
+	}
+}
+
+// status computes the values over the window. The caller must hold the lock.
+func (t *slaTracker) status() *SLAStatus {
+	status := &SLAStatus{SLA: *t.sla, Samples: len(t.samples), Violations: t.violations}
+	if len(t.samples) == 0 {
+		return status
+	}
+
+	durations := make([]time.Duration, len(t.samples))
+	failed := 0
+	for i, sample := range t.samples {
+		durations[i] = sample.duration
+		if sample.failed {
+			failed++
+		}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	// Nearest-rank percentile
+	rank := int(math.Ceil(0.95*float64(len(durations)))) - 1
+	status.P95Duration = durations[rank]
+	status.ErrorRate = float64(failed) / float64(len(t.samples))
+
+	for _, metric := range []SLAMetric{SLAMetricP95Duration, SLAMetricErrorRate} {
+		if t.violated[metric] {
+			status.Violated = append(status.Violated, metric)
+		}
+	}
+	return status
+}
+
+// reset clears the window and the violation state.
+func (t *slaTracker) reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.samples = nil
+	t.violated = make(map[SLAMetric]bool)
+	t.violations = 0
+}
+
+// window returns the sliding window of the SLA.
+func (t *slaTracker) window() time.Duration {
+	if t.sla.Window > 0 {
+		return t.sla.Window
+	}
+	return DefaultSLAWindow
+}
+
+// durationMillis converts a duration to fractional milliseconds.
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
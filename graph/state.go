@@ -3,14 +3,23 @@
 package graph
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // ================================
@@ -109,6 +118,20 @@ func (msm *MemoryStateManager) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// ListStates returns a list of all stored state IDs.
+// ListStates 返回所有存储的状态ID列表。
+func (msm *MemoryStateManager) ListStates() ([]string, error) {
+	msm.lock.RLock()
+	defer msm.lock.RUnlock()
+
+	stateIDs := make([]string, 0, len(msm.states))
+	for id := range msm.states {
+		stateIDs = append(stateIDs, id)
+	}
+
+	return stateIDs, nil
+}
+
 // evictOldest removes the oldest accessed state.
 // evictOldest 移除最久未访问的状态。
 func (msm *MemoryStateManager) evictOldest() {
@@ -316,27 +339,30 @@ func (fsm *FileStateManager) Cleanup(maxAge time.Duration) error {
 // Redis State Manager Redis状态管理器
 // ================================
 
-// RedisStateManager provides Redis-based state persistence.
-// Note: This is a placeholder implementation. In a real scenario,
-// you would use a Redis client library like go-redis.
-// RedisStateManager 提供基于Redis的状态持久化。
-// 注意：这是一个占位符实现。在实际场景中，
-// 你会使用Redis客户端库，如go-redis。
+// RedisStateManager provides Redis-based state persistence. Each state is stored as a JSON
+// string under keyPrefix + ID, optionally gzip-compressed, and expires after the TTL.
+// RedisStateManager 提供基于Redis的状态持久化。每个状态以JSON字符串存储在 keyPrefix + ID 键下，
+// 可选使用gzip压缩，并在TTL后过期。
 type RedisStateManager struct {
-	// client would be a Redis client instance
-	// client 应该是Redis客户端实例
-	client interface{}
+	// client is the Redis client.
+	client redis.UniversalClient
 
-	// keyPrefix is the prefix for Redis keys
+	// keyPrefix is the prefix for Redis keys.
 	keyPrefix string
 
-	// ttl is the time-to-live for stored states
+	// ttl is the time-to-live for stored states; zero means no expiration.
 	ttl time.Duration
+
+	// compression indicates whether to gzip states before storing them.
+	compression bool
 }
 
+var _ StateLister = (*RedisStateManager)(nil)
+
 // NewRedisStateManager creates a new Redis-based state manager.
-// NewRedisStateManager 创建一个新的基于Redis的状态管理器。
-func NewRedisStateManager(client interface{}, keyPrefix string, ttl time.Duration) *RedisStateManager {
+// A zero ttl keeps states until they are deleted.
+// NewRedisStateManager 创建一个新的基于Redis的状态管理器。ttl 为0时状态在删除前一直保留。
+func NewRedisStateManager(client redis.UniversalClient, keyPrefix string, ttl time.Duration) *RedisStateManager {
 	return &RedisStateManager{
 		client:    client,
 		keyPrefix: keyPrefix,
@@ -344,32 +370,132 @@ func NewRedisStateManager(client interface{}, keyPrefix string, ttl time.Duratio
 	}
 }
 
+// WithCompression enables gzip compression of stored states. States written with either
+// setting can always be loaded.
+// WithCompression 启用存储状态的gzip压缩。无论是否启用，两种方式写入的状态都可以加载。
+func (rsm *RedisStateManager) WithCompression(enabled bool) *RedisStateManager {
+	rsm.compression = enabled
+	return rsm
+}
+
 // Save implements the StateManager interface.
 // Save 实现 StateManager 接口。
 func (rsm *RedisStateManager) Save(ctx context.Context, state *State) error {
-	// This is a placeholder implementation
-	// In a real implementation, you would:
-	// 1. Serialize the state to JSON
-	// 2. Store it in Redis with the appropriate key and TTL
-	return fmt.Errorf("Redis state manager not implemented")
+	if state == nil {
+		return fmt.Errorf("state cannot be nil")
+	}
+	if state.ID == "" {
+		return fmt.Errorf("state ID cannot be empty")
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to serialize state: %w", err)
+	}
+
+	if rsm.compression {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(data); err != nil {
+			return fmt.Errorf("failed to compress state: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("failed to compress state: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	if err := rsm.client.Set(ctx, rsm.keyPrefix+state.ID, data, rsm.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save state %s: %w", state.ID, err)
+	}
+	return nil
 }
 
 // Load implements the StateManager interface.
 // Load 实现 StateManager 接口。
 func (rsm *RedisStateManager) Load(ctx context.Context, id string) (*State, error) {
-	// This is a placeholder implementation
-	// In a real implementation, you would:
-	// 1. Retrieve the state from Redis using the key
-	// 2. Deserialize it from JSON
-	return nil, fmt.Errorf("Redis state manager not implemented")
+	if id == "" {
+		return nil, fmt.Errorf("state ID cannot be empty")
+	}
+
+	data, err := rsm.client.Get(ctx, rsm.keyPrefix+id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("state %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to load state %s: %w", id, err)
+	}
+
+	// Compressed states start with the gzip magic number, JSON never does
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress state: %w", err)
+		}
+		defer reader.Close()
+		if data, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("failed to decompress state: %w", err)
+		}
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to deserialize state: %w", err)
+	}
+
+	return &state, nil
 }
 
 // Delete implements the StateManager interface.
 // Delete 实现 StateManager 接口。
 func (rsm *RedisStateManager) Delete(ctx context.Context, id string) error {
-	// This is a placeholder implementation
-	// In a real implementation, you would delete the key from Redis
-	return fmt.Errorf("Redis state manager not implemented")
+	if id == "" {
+		return fmt.Errorf("state ID cannot be empty")
+	}
+
+	if err := rsm.client.Del(ctx, rsm.keyPrefix+id).Err(); err != nil {
+		return fmt.Errorf("failed to delete state %s: %w", id, err)
+	}
+	return nil
+}
+
+// ListStates returns the IDs of all stored states, scanning the key prefix incrementally
+// so that large keyspaces do not block Redis.
+// ListStates 返回所有已存储状态的ID，按键前缀增量扫描，避免键数量较多时阻塞Redis。
+func (rsm *RedisStateManager) ListStates() ([]string, error) {
+	ctx := context.Background()
+	pattern := escapeGlob(rsm.keyPrefix) + "*"
+
+	var stateIDs []string
+	seen := make(map[string]bool)
+	iter := rsm.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		id := strings.TrimPrefix(iter.Val(), rsm.keyPrefix)
+		// SCAN may return a key more than once
+		if !seen[id] {
+			seen[id] = true
+			stateIDs = append(stateIDs, id)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list states: %w", err)
+	}
+
+	sort.Strings(stateIDs)
+	return stateIDs, nil
+}
+
+// escapeGlob escapes the characters that have a special meaning in Redis MATCH patterns.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
 
 // ================================
@@ -530,6 +656,25 @@ type CheckpointInfo struct {
 	StepCount int `json:"step_count"`
 }
 
+// checkpointIDSeparator separates the original state ID from the checkpoint timestamp.
+const checkpointIDSeparator = "_checkpoint_"
+
+// ParseCheckpointID splits a checkpoint state ID into the original state ID and creation time.
+// ParseCheckpointID 将检查点状态ID拆分为原始状态ID和创建时间。
+func ParseCheckpointID(id string) (stateID string, createdAt time.Time, ok bool) {
+	idx := strings.LastIndex(id, checkpointIDSeparator)
+	if idx <= 0 {
+		return "", time.Time{}, false
+	}
+
+	nanos, err := strconv.ParseInt(id[idx+len(checkpointIDSeparator):], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	return id[:idx], time.Unix(0, nanos), true
+}
+
 // NewCheckpointManager creates a new checkpoint manager.
 // NewCheckpointManager 创建一个新的检查点管理器。
 func NewCheckpointManager(stateManager StateManager, checkpointInterval time.Duration, maxCheckpoints int) *CheckpointManager {
@@ -552,7 +697,7 @@ func (cm *CheckpointManager) CreateCheckpoint(ctx context.Context, state *State)
 	defer cm.lock.Unlock()
 
 	// Create checkpoint ID
-	checkpointID := fmt.Sprintf("%s_checkpoint_%d", state.ID, time.Now().UnixNano())
+	checkpointID := fmt.Sprintf("%s%s%d", state.ID, checkpointIDSeparator, time.Now().UnixNano())
 
 	// Create checkpoint state
 	checkpointState := state.Clone()
@@ -615,6 +760,51 @@ func (cm *CheckpointManager) RestoreFromCheckpoint(ctx context.Context, stateID
 	return checkpointState, nil
 }
 
+// LatestCheckpoint restores the most recent checkpoint of a state. Checkpoints created by
+// another process are found too when the state manager implements StateLister, so that an
+// execution can be resumed after a crash.
+// LatestCheckpoint 恢复状态最近的检查点。状态管理器实现 StateLister 时，也能找到其他进程创建的检查点，
+// 以便在崩溃后恢复执行。
+func (cm *CheckpointManager) LatestCheckpoint(ctx context.Context, stateID string) (*State, error) {
+	cm.lock.RLock()
+	checkpoints := cm.checkpoints[stateID]
+	latestID := ""
+	if len(checkpoints) > 0 {
+		latestID = checkpoints[len(checkpoints)-1].ID
+	}
+	cm.lock.RUnlock()
+
+	if latestID == "" {
+		lister, ok := cm.stateManager.(StateLister)
+		if !ok {
+			return nil, fmt.Errorf("no checkpoints found for state %s", stateID)
+		}
+		ids, err := lister.ListStates()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+		}
+		var latest time.Time
+		for _, id := range ids {
+			if owner, createdAt, ok := ParseCheckpointID(id); ok && owner == stateID && createdAt.After(latest) {
+				latestID, latest = id, createdAt
+			}
+		}
+		if latestID == "" {
+			return nil, fmt.Errorf("no checkpoints found for state %s", stateID)
+		}
+	}
+
+	checkpointState, err := cm.stateManager.Load(ctx, latestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	// Restore original state ID
+	checkpointState.ID = stateID
+
+	return checkpointState, nil
+}
+
 // GetCheckpoints returns all checkpoints for a state.
 // GetCheckpoints 返回状态的所有检查点。
 func (cm *CheckpointManager) GetCheckpoints(stateID string) []CheckpointInfo {
@@ -0,0 +1,285 @@
+// Package graph - Conversation summarization
+// 包 graph - 对话摘要
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ================================
+// Summarization Policy 摘要策略
+// ================================
+
+// SummaryPrefix marks the system note that holds the summary of earlier turns.
+// SummaryPrefix 标记保存早期对话摘要的系统消息。
+const SummaryPrefix = "[Conversation summary] "
+
+// SummarizationUsageNode is the node ID under which summarization usage is reported.
+// SummarizationUsageNode 是报告摘要用量时使用的节点ID。
+const SummarizationUsageNode = "summarization"
+
+// Metadata keys written by SummarizationPolicy.Apply.
+// SummarizationPolicy.Apply 写入的元数据键。
+const (
+	// SummarizationCountMetadata counts how many times the state has been summarized.
+	SummarizationCountMetadata = "summarization_count"
+	// SummarizationArchivesMetadata lists the IDs of the archived full histories.
+	SummarizationArchivesMetadata = "summarization_archives"
+)
+
+// archiveIDSeparator separates the original state ID from the archive timestamp.
+const archiveIDSeparator = "_history_"
+
+// defaultSummaryPrompt is used when SummarizationPolicy.Prompt is empty.
+const defaultSummaryPrompt = "Summarize the following conversation so that it can replace the original messages. " +
+	"Keep facts, decisions, open questions and user preferences. Answer in the language of the conversation. " +
+	"Output only the summary.\n\n%s"
+
+// SummarizationPolicy compacts long conversations: when the messages exceed MaxTokens, the
+// older turns are summarized by a cheap model into a system note and only the most recent
+// messages are kept verbatim. Set it on GraphConfig to apply it after every node, or call
+// Apply and Compact directly outside graphs.
+// SummarizationPolicy 压缩长对话：当消息超过 MaxTokens 时，由低成本模型将较早的轮次
+// 摘要为一条系统消息，仅原样保留最近的消息。设置在 GraphConfig 上时会在每个节点之后执行，
+// 也可以在图之外直接调用 Apply 和 Compact。
+type SummarizationPolicy struct {
+	// MaxTokens is the token threshold that triggers summarization.
+	MaxTokens int `json:"max_tokens"`
+
+	// KeepRecent is the number of recent messages kept verbatim. Defaults to 4.
+	KeepRecent int `json:"keep_recent,omitempty"`
+
+	// Prompt is the summarization prompt; %s is replaced with the transcript.
+	Prompt string `json:"prompt,omitempty"`
+
+	// Model is the model used to summarize, usually a cheap one.
+	Model llms.Model `json:"-"`
+
+	// Archive stores the full state before each compaction for audit. Optional.
+	Archive StateManager `json:"-"`
+
+	// TokenCounter counts the tokens of a message. Defaults to EstimateMessageTokens.
+	TokenCounter func(msg llms.MessageContent) int `json:"-"`
+
+	// CostFunc converts token usage of a summarization call into cost. Optional.
+	CostFunc func(usage Usage) float64 `json:"-"`
+}
+
+// NewSummarizationPolicy creates a policy that summarizes with model above maxTokens.
+// NewSummarizationPolicy 创建在超过 maxTokens 时使用 model 生成摘要的策略。
+func NewSummarizationPolicy(model llms.Model, maxTokens int) *SummarizationPolicy {
+	return &SummarizationPolicy{Model: model, MaxTokens: maxTokens}
+}
+
+// WithKeepRecent sets the number of recent messages kept verbatim.
+// WithKeepRecent 设置原样保留的最近消息数。
+func (p *SummarizationPolicy) WithKeepRecent(n int) *SummarizationPolicy {
+	p.KeepRecent = n
+	return p
+}
+
+// WithArchive stores the full history in manager before each compaction.
+// WithArchive 在每次压缩前将完整历史保存到 manager。
+func (p *SummarizationPolicy) WithArchive(manager StateManager) *SummarizationPolicy {
+	p.Archive = manager
+	return p
+}
+
+// WithSummarization summarizes State.Messages after each node once they exceed the policy threshold.
+// WithSummarization 在每个节点执行后，当 State.Messages 超过策略阈值时生成摘要。
+func (gb *GraphBuilder) WithSummarization(policy *SummarizationPolicy) *GraphBuilder {
+	if policy == nil || policy.Model == nil {
+		gb.errs = append(gb.errs, fmt.Errorf("summarization policy requires a model"))
+		return gb
+	}
+	if policy.MaxTokens <= 0 {
+		gb.errs = append(gb.errs, fmt.Errorf("summarization policy requires a positive max tokens"))
+		return gb
+	}
+	gb.graph.Config.Summarization = policy
+	return gb
+}
+
+// CountTokens returns the token count of messages using the policy's counter.
+// CountTokens 使用策略的计数函数返回消息的token数。
+func (p *SummarizationPolicy) CountTokens(messages []llms.MessageContent) int {
+	counter := p.TokenCounter
+	if counter == nil {
+		counter = EstimateMessageTokens
+	}
+	total := 0
+	for _, msg := range messages {
+		total += counter(msg)
+	}
+	return total
+}
+
+// Compact summarizes the older messages when messages exceed MaxTokens. It returns the
+// compacted messages and whether a summary was produced; messages is never modified.
+// Compact 在消息超过 MaxTokens 时为较早的消息生成摘要，返回压缩后的消息以及是否生成了摘要，
+// 不会修改传入的 messages。
+func (p *SummarizationPolicy) Compact(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, bool, error) {
+	if p.MaxTokens <= 0 || p.CountTokens(messages) <= p.MaxTokens {
+		return messages, false, nil
+	}
+	if p.Model == nil {
+		return messages, false, fmt.Errorf("summarization policy requires a model")
+	}
+
+	// Leading system prompts are instructions, not turns; keep them as they are
+	head := 0
+	for head < len(messages) && messages[head].Role == llms.ChatMessageTypeSystem && !isSummaryNote(messages[head]) {
+		head++
+	}
+
+	keep := p.KeepRecent
+	if keep <= 0 {
+		keep = 4
+	}
+	cut := len(messages) - keep
+	// Never separate tool results from the call that produced them
+	for cut > head && messages[cut].Role == llms.ChatMessageTypeTool {
+		cut--
+	}
+	if cut <= head {
+		return messages, false, nil
+	}
+
+	summary, err := p.summarize(ctx, messages[head:cut])
+	if err != nil {
+		return messages, false, err
+	}
+
+	compacted := make([]llms.MessageContent, 0, head+1+len(messages)-cut)
+	compacted = append(compacted, messages[:head]...)
+	compacted = append(compacted, llms.TextParts(llms.ChatMessageTypeSystem, SummaryPrefix+summary))
+	compacted = append(compacted, messages[cut:]...)
+	return compacted, true, nil
+}
+
+// Apply compacts state.Messages in place. The full state is archived first when Archive is set,
+// and the archive ID is appended to the SummarizationArchivesMetadata metadata.
+// Apply 原地压缩 state.Messages。设置了 Archive 时会先归档完整状态，
+// 归档ID追加到元数据 SummarizationArchivesMetadata 中。
+func (p *SummarizationPolicy) Apply(ctx context.Context, state *State) (bool, error) {
+	compacted, summarized, err := p.Compact(ctx, state.Messages)
+	if err != nil || !summarized {
+		return false, err
+	}
+
+	if p.Archive != nil {
+		archive := state.Clone()
+		archive.ID = fmt.Sprintf("%s%s%d", state.ID, archiveIDSeparator, time.Now().UnixNano())
+		if err := p.Archive.Save(ctx, archive); err != nil {
+			return false, fmt.Errorf("failed to archive conversation history: %w", err)
+		}
+		var archives []string
+		switch v := state.Metadata[SummarizationArchivesMetadata].(type) {
+		case []string:
+			archives = append(archives, v...)
+		case []interface{}:
+			// Restored from JSON
+			for _, id := range v {
+				archives = append(archives, fmt.Sprint(id))
+			}
+		}
+		state.SetMetadata(SummarizationArchivesMetadata, append(archives, archive.ID))
+	}
+
+	count := 0
+	switch v := state.Metadata[SummarizationCountMetadata].(type) {
+	case int:
+		count = v
+	case float64:
+		count = int(v)
+	}
+	state.SetMetadata(SummarizationCountMetadata, count+1)
+	state.Messages = compacted
+	return true, nil
+}
+
+// summarize asks the model for a summary of messages and records the usage.
+func (p *SummarizationPolicy) summarize(ctx context.Context, messages []llms.MessageContent) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		text := messageText(msg)
+		if text == "" {
+			continue
+		}
+		if isSummaryNote(msg) {
+			fmt.Fprintf(&transcript, "earlier summary: %s\n", strings.TrimPrefix(text, SummaryPrefix))
+			continue
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, text)
+	}
+
+	prompt := p.Prompt
+	if prompt == "" {
+		prompt = defaultSummaryPrompt
+	}
+
+	resp, err := p.Model.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf(prompt, transcript.String())),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Content) == "" {
+		return "", fmt.Errorf("empty summarization response")
+	}
+
+	usage := usageFromGenerationInfo(resp.Choices[0].GenerationInfo)
+	if p.CostFunc != nil {
+		usage.Cost = p.CostFunc(usage)
+	}
+	if collector, ok := ctx.Value(usageCollectorContextKey{}).(*usageCollector); ok {
+		collector.add(SummarizationUsageNode, usage)
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+// isSummaryNote reports whether msg is a summary produced by a SummarizationPolicy.
+func isSummaryNote(msg llms.MessageContent) bool {
+	return msg.Role == llms.ChatMessageTypeSystem && strings.HasPrefix(messageText(msg), SummaryPrefix)
+}
+
+// messageText concatenates the text parts of a message.
+func messageText(msg llms.MessageContent) string {
+	var text strings.Builder
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			text.WriteString(p.Text)
+		case llms.ToolCall:
+			if p.FunctionCall != nil {
+				fmt.Fprintf(&text, "[call %s %s]", p.FunctionCall.Name, p.FunctionCall.Arguments)
+			}
+		case llms.ToolCallResponse:
+			fmt.Fprintf(&text, "[result %s %s]", p.Name, p.Content)
+		}
+	}
+	return text.String()
+}
+
+// EstimateMessageTokens roughly estimates the tokens of a message: CJK characters count as one
+// token each and other characters as one token per four.
+// EstimateMessageTokens 粗略估算消息的token数：中日韩字符按1个token计，其余按每4个字符1个token计。
+func EstimateMessageTokens(msg llms.MessageContent) int {
+	cjk, other := 0, 0
+	for _, r := range messageText(msg) {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	// Each message carries a few tokens of role and framing overhead
+	return cjk + (other+3)/4 + 4
+}
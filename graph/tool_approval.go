@@ -0,0 +1,135 @@
+// Package graph - Approval-gated tool execution
+// 包 graph - 需审批的工具执行
+package graph
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ================================
+// Tool Approval 工具审批
+// ================================
+
+// ToolCallRequest describes a tool call waiting for approval.
+// ToolCallRequest 描述等待审批的工具调用。
+type ToolCallRequest struct {
+	Tool   string `json:"tool"`
+	Input  string `json:"input"`
+	NodeID string `json:"node_id,omitempty"`
+}
+
+// ToolApprover decides whether a tool call may run. A denied call returns a decision with
+// Approved set to false; an error (e.g. a timeout) fails the call.
+// ToolApprover 决定工具调用是否可以执行。拒绝时返回 Approved 为 false 的审批结果；
+// 返回错误（如超时）时工具调用失败。
+type ToolApprover interface {
+	ApproveToolCall(ctx context.Context, call ToolCallRequest) (*ApprovalDecision, error)
+}
+
+// ToolApproverFunc adapts a function to ToolApprover.
+// ToolApproverFunc 将函数适配为 ToolApprover。
+type ToolApproverFunc func(ctx context.Context, call ToolCallRequest) (*ApprovalDecision, error)
+
+// ApproveToolCall implements ToolApprover.
+// ApproveToolCall 实现 ToolApprover 接口。
+func (f ToolApproverFunc) ApproveToolCall(ctx context.Context, call ToolCallRequest) (*ApprovalDecision, error) {
+	return f(ctx, call)
+}
+
+// ToolApprovalPolicy gates selected tools behind human approval. Gated tools pause before
+// running until the approver decides; approved calls run normally and denied calls return
+// a denial message as the tool observation, so the agent can continue without the tool.
+// Wrap the tools before creating the agent; this covers both agents.Executor and graphs
+// built with FromExecutor.
+// ToolApprovalPolicy 为指定的工具设置人工审批。需审批的工具在执行前暂停，等待审批人决定；
+// 审批通过后正常执行，被拒绝时以拒绝信息作为工具的观察结果返回，智能体可以在不使用该工具的情况下继续。
+// 在创建智能体之前包装工具，对 agents.Executor 和 FromExecutor 构建的图均有效。
+type ToolApprovalPolicy struct {
+	// Tools lists tool names or path.Match patterns (e.g. "delete_*", "*") that require
+	// approval. Matching is case-insensitive.
+	// Tools 列出需要审批的工具名称或 path.Match 模式（如 "delete_*"、"*"），不区分大小写。
+	Tools []string
+
+	// Approver decides on gated calls, e.g. a WebhookApprover.
+	// Approver 对需审批的调用做出决定，例如 WebhookApprover。
+	Approver ToolApprover
+
+	// DenialMessage formats the observation returned for a denied call. Optional.
+	// DenialMessage 生成被拒绝调用返回的观察结果，可选。
+	DenialMessage func(call ToolCallRequest, decision *ApprovalDecision) string
+}
+
+// RequiresApproval reports whether the named tool matches the policy.
+// RequiresApproval 判断指定工具是否需要审批。
+func (p *ToolApprovalPolicy) RequiresApproval(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range p.Tools {
+		if matched, err := path.Match(strings.ToLower(pattern), name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap returns tool gated by the policy, or tool itself if it does not require approval.
+// Wrap 返回受策略控制的工具，不需要审批的工具原样返回。
+func (p *ToolApprovalPolicy) Wrap(tool tools.Tool) tools.Tool {
+	if !p.RequiresApproval(tool.Name()) {
+		return tool
+	}
+	return &approvalGatedTool{Tool: tool, policy: p}
+}
+
+// WrapAll applies Wrap to each tool.
+// WrapAll 对每个工具调用 Wrap。
+func (p *ToolApprovalPolicy) WrapAll(toolList []tools.Tool) []tools.Tool {
+	wrapped := make([]tools.Tool, len(toolList))
+	for i, tool := range toolList {
+		wrapped[i] = p.Wrap(tool)
+	}
+	return wrapped
+}
+
+// approvalGatedTool asks the policy's approver before calling the wrapped tool.
+type approvalGatedTool struct {
+	tools.Tool
+	policy *ToolApprovalPolicy
+}
+
+// Call implements tools.Tool.
+func (t *approvalGatedTool) Call(ctx context.Context, input string) (string, error) {
+	if t.policy.Approver == nil {
+		return "", fmt.Errorf("tool %s requires approval but no approver is configured", t.Name())
+	}
+
+	call := ToolCallRequest{
+		Tool:   t.Name(),
+		Input:  input,
+		NodeID: NodeIDFromContext(ctx),
+	}
+	decision, err := t.policy.Approver.ApproveToolCall(ctx, call)
+	if err != nil {
+		return "", fmt.Errorf("approval for tool %s failed: %w", call.Tool, err)
+	}
+	if decision == nil || !decision.Approved {
+		if t.policy.DenialMessage != nil {
+			return t.policy.DenialMessage(call, decision), nil
+		}
+		return defaultDenialMessage(call, decision), nil
+	}
+	return t.Tool.Call(ctx, input)
+}
+
+// defaultDenialMessage tells the model the call was denied and should not be retried.
+func defaultDenialMessage(call ToolCallRequest, decision *ApprovalDecision) string {
+	message := fmt.Sprintf("The call to tool %s was denied by a human reviewer and was not executed. Do not retry it; continue without this tool.", call.Tool)
+	if decision != nil && decision.Comment != "" {
+		message += " Reviewer comment: " + decision.Comment
+	}
+	return message
+}
@@ -0,0 +1,260 @@
+// Package graph - Execution trace retention
+// 包 graph - 执行追踪保留
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ================================
+// Execution Traces 执行追踪
+// ================================
+
+// DefaultTraceRetention is the number of recent executions whose traces a runnable keeps in memory.
+// DefaultTraceRetention 是可运行实例在内存中保留追踪的最近执行数量。
+const DefaultTraceRetention = 100
+
+// ErrTraceNotFound is returned for executions whose trace is not retained.
+// ErrTraceNotFound 表示执行的追踪未被保留。
+var ErrTraceNotFound = errors.New("execution trace not found")
+
+// ExecutionTrace is the summary of a finished execution with its trace entries. Entries are
+// only recorded for executions run with WithTracing(true).
+// ExecutionTrace 是已结束执行的摘要及其追踪条目，只有使用 WithTracing(true) 的执行才会记录条目。
+type ExecutionTrace struct {
+	ExecutionSummary
+
+	// Warnings are the warnings raised during the execution.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Entries are the trace entries in the order they were recorded.
+	Entries []TraceEntry `json:"entries"`
+}
+
+// TraceStore persists execution traces beyond the in-memory retention.
+// TraceStore 在内存保留之外持久化执行追踪。
+type TraceStore interface {
+	// SaveTrace stores the trace of a finished execution.
+	SaveTrace(ctx context.Context, trace *ExecutionTrace) error
+
+	// LoadTrace returns the trace of an execution, or an error wrapping ErrTraceNotFound.
+	LoadTrace(ctx context.Context, executionID string) (*ExecutionTrace, error)
+}
+
+// traceBuffer keeps the traces of the most recent executions in a ring buffer.
+type traceBuffer struct {
+	lock     sync.RWMutex
+	capacity int
+	ring     []*ExecutionTrace
+	next     int
+	byID     map[string]*ExecutionTrace
+	store    TraceStore
+}
+
+// newTraceBuffer creates a buffer retaining the traces of capacity executions.
+func newTraceBuffer(capacity int) *traceBuffer {
+	return &traceBuffer{
+		capacity: capacity,
+		byID:     make(map[string]*ExecutionTrace),
+	}
+}
+
+// add retains the trace, evicting the oldest one once the buffer is full.
+func (b *traceBuffer) add(trace *ExecutionTrace) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.capacity <= 0 {
+		return
+	}
+
+	if len(b.ring) < b.capacity {
+		b.ring = append(b.ring, trace)
+	} else {
+		if evicted := b.ring[b.next]; b.byID[evicted.ExecutionID] == evicted {
+			delete(b.byID, evicted.ExecutionID)
+		}
+		b.ring[b.next] = trace
+		b.next = (b.next + 1) % b.capacity
+	}
+	b.byID[trace.ExecutionID] = trace
+}
+
+// recent returns the retained traces, most recent first.
+func (b *traceBuffer) recent() []*ExecutionTrace {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	traces := make([]*ExecutionTrace, 0, len(b.ring))
+	for i := len(b.ring) - 1; i >= 0; i-- {
+		traces = append(traces, b.ring[(b.next+i)%len(b.ring)])
+	}
+	return traces
+}
+
+// WithTraceRetention sets how many recent executions keep their trace in memory; defaults to
+// DefaultTraceRetention, zero keeps none. Retained traces are dropped.
+// WithTraceRetention 设置在内存中保留追踪的最近执行数量，默认为 DefaultTraceRetention，0表示不保留。
+// 已保留的追踪会被丢弃。
+func (r *Runnable) WithTraceRetention(executions int) *Runnable {
+	r.traces.lock.Lock()
+	defer r.traces.lock.Unlock()
+	r.traces.capacity = executions
+	r.traces.ring = nil
+	r.traces.next = 0
+	r.traces.byID = make(map[string]*ExecutionTrace)
+	return r
+}
+
+// WithTraceStore persists the trace of every execution to store, so that GetTrace finds
+// executions no longer retained in memory. Failing to save a trace is reported as a warning
+// of the execution.
+// WithTraceStore 将每次执行的追踪持久化到 store，使 GetTrace 能够查到已不在内存中的执行。
+// 保存失败时记录为执行的警告。
+func (r *Runnable) WithTraceStore(store TraceStore) *Runnable {
+	r.traces.lock.Lock()
+	defer r.traces.lock.Unlock()
+	r.traces.store = store
+	return r
+}
+
+// recordTrace retains the trace of a finished execution and persists it when a store is set.
+// recordTrace 保留已结束执行的追踪，配置了存储时将其持久化。
+func (r *Runnable) recordTrace(execCtx *ExecutionContext, state *State, err error) {
+	trace := &ExecutionTrace{
+		ExecutionSummary: r.executionSummary(execCtx, state, err),
+		Warnings:         append([]string(nil), execCtx.Warnings...),
+		Entries:          append([]TraceEntry{}, execCtx.Trace...),
+	}
+	r.traces.add(trace)
+
+	r.traces.lock.RLock()
+	store := r.traces.store
+	r.traces.lock.RUnlock()
+	if store == nil {
+		return
+	}
+	if err := store.SaveTrace(context.WithoutCancel(execCtx.Context), trace); err != nil {
+		execCtx.Warnings = append(execCtx.Warnings, fmt.Sprintf("failed to save trace of execution %s: %v", execCtx.ExecutionID, err))
+	}
+}
+
+// GetTrace returns the trace of an execution, from memory or from the trace store. The
+// error wraps ErrTraceNotFound when the trace is not retained.
+// GetTrace 返回执行的追踪，先从内存中查找，再从追踪存储中加载。追踪未被保留时返回的错误包装 ErrTraceNotFound。
+func (r *Runnable) GetTrace(executionID string) (*ExecutionTrace, error) {
+	r.traces.lock.RLock()
+	trace, ok := r.traces.byID[executionID]
+	store := r.traces.store
+	r.traces.lock.RUnlock()
+	if ok {
+		return trace.copy(), nil
+	}
+	if store == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTraceNotFound, executionID)
+	}
+	return store.LoadTrace(context.Background(), executionID)
+}
+
+// ListRecentExecutions returns the summaries of the executions retained in memory, most
+// recent first.
+// ListRecentExecutions 返回内存中保留的执行摘要，最近的在前。
+func (r *Runnable) ListRecentExecutions() []ExecutionSummary {
+	traces := r.traces.recent()
+	summaries := make([]ExecutionSummary, 0, len(traces))
+	for _, trace := range traces {
+		summaries = append(summaries, trace.ExecutionSummary)
+	}
+	return summaries
+}
+
+// ExportTrace returns the trace of an execution as indented JSON.
+// ExportTrace 以缩进的 JSON 格式返回执行的追踪。
+func (r *Runnable) ExportTrace(executionID string) ([]byte, error) {
+	trace, err := r.GetTrace(executionID)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(trace, "", "  ")
+}
+
+// copy returns a copy that callers may modify without affecting the retained trace.
+func (t *ExecutionTrace) copy() *ExecutionTrace {
+	cp := *t
+	cp.Path = append([]string(nil), t.Path...)
+	cp.Warnings = append([]string(nil), t.Warnings...)
+	cp.Entries = append([]TraceEntry{}, t.Entries...)
+	return &cp
+}
+
+// ================================
+// File Trace Store 文件追踪存储
+// ================================
+
+// FileTraceStore stores each execution trace as a JSON file named after the execution ID.
+// FileTraceStore 将每次执行的追踪保存为以执行ID命名的 JSON 文件。
+type FileTraceStore struct {
+	baseDir string
+}
+
+// NewFileTraceStore creates a trace store in baseDir, creating the directory if needed.
+// NewFileTraceStore 在 baseDir 中创建追踪存储，目录不存在时自动创建。
+func NewFileTraceStore(baseDir string) (*FileTraceStore, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create trace directory: %w", err)
+	}
+	return &FileTraceStore{baseDir: baseDir}, nil
+}
+
+// SaveTrace implements the TraceStore interface.
+// SaveTrace 实现 TraceStore 接口。
+func (s *FileTraceStore) SaveTrace(ctx context.Context, trace *ExecutionTrace) error {
+	filename, err := s.filename(trace.ExecutionID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize trace: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write trace file: %w", err)
+	}
+	return nil
+}
+
+// LoadTrace implements the TraceStore interface.
+// LoadTrace 实现 TraceStore 接口。
+func (s *FileTraceStore) LoadTrace(ctx context.Context, executionID string) (*ExecutionTrace, error) {
+	filename, err := s.filename(executionID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrTraceNotFound, executionID)
+		}
+		return nil, fmt.Errorf("failed to read trace file: %w", err)
+	}
+
+	var trace ExecutionTrace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, fmt.Errorf("failed to deserialize trace: %w", err)
+	}
+	return &trace, nil
+}
+
+// filename returns the file of an execution, rejecting IDs that are not plain file names.
+func (s *FileTraceStore) filename(executionID string) (string, error) {
+	if executionID == "" || executionID == "." || executionID == ".." || strings.ContainsAny(executionID, `/\`) {
+		return "", fmt.Errorf("invalid execution ID: %q", executionID)
+	}
+	return filepath.Join(s.baseDir, executionID+".json"), nil
+}
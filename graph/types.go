@@ -40,6 +40,13 @@ type StateManager interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// StateLister is implemented by state managers that can enumerate stored state IDs.
+// StateLister 由可以枚举已存储状态ID的状态管理器实现。
+type StateLister interface {
+	StateManager
+	ListStates() ([]string, error)
+}
+
 // ================================
 // Core Types 核心类型
 // ================================
@@ -63,6 +70,8 @@ const (
 	NodeTypeStart NodeType = "start"
 	// NodeTypeEnd represents the end node.
 	NodeTypeEnd NodeType = "end"
+	// NodeTypeHumanInput represents a node that pauses the execution for human input.
+	NodeTypeHumanInput NodeType = "human_input"
 )
 
 // ExecutionMode represents how a node should be executed.
@@ -104,6 +113,10 @@ type State struct {
 
 	// UpdatedAt indicates when this state was last updated.
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Events is the event bus of the execution. Clones share it so that concurrent
+	// branches can coordinate; each graph execution starts with a fresh bus.
+	Events *EventBus `json:"-"`
 }
 
 // ExecutionStep represents a single step in the execution history.
@@ -127,6 +140,9 @@ type ExecutionStep struct {
 	// Error contains any error that occurred.
 	Error string `json:"error,omitempty"`
 
+	// Iteration is the 1-based iteration of loop node steps; zero for other steps.
+	Iteration int `json:"iteration,omitempty"`
+
 	// Input is the input state for this step.
 	Input map[string]interface{} `json:"input,omitempty"`
 
@@ -157,6 +173,16 @@ type NodeConfig struct {
 	// FailureMode specifies how to handle failures.
 	FailureMode FailureMode `json:"failure_mode,omitempty"`
 
+	// MaxConcurrent limits how many executions of this node may run at once across all
+	// in-flight executions of a runnable. Zero means no limit beyond the graph's own.
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
+
+	// MaxIterations guards loop nodes against running forever; defaults to DefaultMaxLoopIterations.
+	MaxIterations int `json:"max_iterations,omitempty"`
+
+	// MergeStrategy decides how parallel nodes merge the states of their children.
+	MergeStrategy MergeStrategy `json:"merge_strategy,omitempty"`
+
 	// Middleware contains middleware to apply to this node.
 	Middleware []string `json:"middleware,omitempty"`
 
@@ -179,6 +205,21 @@ const (
 	FailureModeSkip FailureMode = "skip"
 )
 
+// ValidationProfile represents how strictly a graph is validated.
+// ValidationProfile 表示图验证的严格程度。
+type ValidationProfile string
+
+const (
+	// ValidationProfileDev reports hygiene issues as warnings only.
+	ValidationProfileDev ValidationProfile = "dev"
+	// ValidationProfileProd promotes hygiene issues to errors for deployed graphs.
+	ValidationProfileProd ValidationProfile = "prod"
+)
+
+// NodeTagLLM marks nodes that call an LLM; prod validation requires them to set a timeout.
+// NodeTagLLM 标记调用LLM的节点；prod验证要求这些节点设置超时。
+const NodeTagLLM = "llm"
+
 // GraphConfig contains configuration for the entire graph.
 // GraphConfig 包含整个图的配置。
 type GraphConfig struct {
@@ -206,6 +247,18 @@ type GraphConfig struct {
 	// StateManager specifies which state manager to use.
 	StateManager string `json:"state_manager,omitempty"`
 
+	// ValidationProfile specifies the default validation strictness used at Compile time.
+	ValidationProfile ValidationProfile `json:"validation_profile,omitempty"`
+
+	// Locale selects the default language of errors returned by Compile and Invoke.
+	Locale Locale `json:"locale,omitempty"`
+
+	// Summarization compacts State.Messages after each node once they exceed a token threshold.
+	Summarization *SummarizationPolicy `json:"summarization,omitempty"`
+
+	// SLA declares service level targets evaluated over a sliding window of executions.
+	SLA *SLA `json:"sla,omitempty"`
+
 	// Metadata contains custom metadata for this graph.
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
@@ -231,6 +284,58 @@ type Result struct {
 
 	// NodesExecuted is the number of nodes that were executed.
 	NodesExecuted int
+
+	// ExecutionID is the ID of the execution that produced this result.
+	ExecutionID string
+
+	// Path is the ordered list of executed node IDs.
+	Path []string
+
+	// NodeDurations is the total time spent in each node.
+	NodeDurations map[string]time.Duration
+
+	// Usage is the token and cost usage reported by all nodes.
+	Usage Usage
+
+	// NodeUsage is the token and cost usage reported by each node.
+	NodeUsage map[string]Usage
+
+	// Trace contains the execution trace.
+	Trace []TraceEntry
+
+	// Warnings contains non-fatal issues encountered during execution.
+	Warnings []string
+
+	// WastedAfterCancel is the time nodes kept running after the execution was cancelled.
+	WastedAfterCancel time.Duration
+
+	// Pending is set when the execution paused at a human input node.
+	Pending *HumanInputRequest
+}
+
+// Usage represents token and cost usage reported by nodes.
+// Usage 表示节点报告的token与成本用量。
+type Usage struct {
+	// PromptTokens is the number of input tokens.
+	PromptTokens int `json:"prompt_tokens"`
+
+	// CompletionTokens is the number of output tokens.
+	CompletionTokens int `json:"completion_tokens"`
+
+	// TotalTokens is the total number of tokens.
+	TotalTokens int `json:"total_tokens"`
+
+	// Cost is the monetary cost of the usage.
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// Add accumulates other into u.
+// Add 将 other 累加到 u。
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
 }
 
 // ValidationResult represents the result of graph validation.
@@ -295,6 +400,7 @@ func NewState(id string) *State {
 		CurrentNode: "",
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Events:      NewEventBus(),
 	}
 }
 
@@ -309,7 +415,8 @@ func (s *State) Clone() *State {
 		History:     make([]ExecutionStep, len(s.History)),
 		CurrentNode: s.CurrentNode,
 		CreatedAt:   s.CreatedAt,
-		UpdatedAt:   time.Now(),
+		UpdatedAt:   s.UpdatedAt,
+		Events:      s.Events,
 	}
 
 	copy(clone.Messages, s.Messages)
@@ -361,6 +468,43 @@ func (s *State) GetMetadata(key string) (interface{}, bool) {
 	return value, exists
 }
 
+// DraftVariablePrefix is the variable key prefix under which streaming drafts are stored.
+// Drafts live in Variables so that checkpoints taken mid-generation keep partial output.
+// DraftVariablePrefix 是流式草稿在变量中存储时使用的键前缀。
+const DraftVariablePrefix = "__draft__."
+
+// AppendDraft appends a streamed chunk to the draft for key and returns the accumulated text.
+// AppendDraft 将流式分片追加到指定键的草稿中，并返回累积的文本。
+func (s *State) AppendDraft(key, chunk string) string {
+	draft, _ := s.Variables[DraftVariablePrefix+key].(string)
+	draft += chunk
+	s.SetVariable(DraftVariablePrefix+key, draft)
+	return draft
+}
+
+// GetDraft returns the in-progress draft for key.
+// GetDraft 返回指定键正在生成的草稿。
+func (s *State) GetDraft(key string) (string, bool) {
+	draft, exists := s.Variables[DraftVariablePrefix+key].(string)
+	return draft, exists
+}
+
+// FinalizeDraft moves the draft for key into the variable key and returns the final text.
+// FinalizeDraft 将草稿移动到同名变量中，并返回最终文本。
+func (s *State) FinalizeDraft(key string) string {
+	draft, _ := s.Variables[DraftVariablePrefix+key].(string)
+	delete(s.Variables, DraftVariablePrefix+key)
+	s.SetVariable(key, draft)
+	return draft
+}
+
+// DiscardDraft removes the draft for key without finalizing it.
+// DiscardDraft 移除指定键的草稿而不进行最终化。
+func (s *State) DiscardDraft(key string) {
+	delete(s.Variables, DraftVariablePrefix+key)
+	s.UpdatedAt = time.Now()
+}
+
 // AddExecutionStep adds an execution step to the history.
 // AddExecutionStep 向历史记录添加执行步骤。
 func (s *State) AddExecutionStep(step ExecutionStep) {
@@ -0,0 +1,109 @@
+// Package graph - Graph visualization
+// 包 graph - 图可视化
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ================================
+// Visualization 可视化
+// ================================
+
+// ExportMermaid renders the graph as a Mermaid flowchart: every node, shaped by its type,
+// the entry point, and every edge. Conditional and default edges are dashed and labelled with
+// their condition, and edges with a priority show it in the label. Conditions are taken from the
+// edge like in GenerateDocs.
+// ExportMermaid 将图渲染为 Mermaid 流程图：按类型区分形状的节点、入口点以及所有边。
+// 条件边和默认边使用虚线并以条件作为标签，设置了优先级的边在标签中显示优先级。条件的取值方式与 GenerateDocs 相同。
+func (g *Graph) ExportMermaid() string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return mermaidDiagram(g, g.router.edgeRefs())
+}
+
+// ExportDOT renders the graph in the Graphviz DOT language, with the same nodes, edges and
+// labels as ExportMermaid, for example to render large workflows with `dot -Tsvg`.
+// ExportDOT 将图渲染为 Graphviz DOT 语言，节点、边和标签与 ExportMermaid 相同，
+// 可用于通过 `dot -Tsvg` 渲染大型工作流。
+func (g *Graph) ExportDOT() string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.ID))
+	b.WriteString("    rankdir=TB;\n")
+	if g.Name != "" {
+		fmt.Fprintf(&b, "    label=%s;\n    labelloc=t;\n", dotQuote(g.Name))
+	}
+	b.WriteString("    node [shape=box, style=rounded];\n")
+	b.WriteString("    \"__start__\" [label=\"Start\", shape=oval];\n")
+	for _, node := range sortedNodes(g.nodes) {
+		label := node.ID
+		if node.Name != "" {
+			label = node.Name
+		}
+		attrs := "label=" + dotQuote(label)
+		switch node.Type {
+		case NodeTypeCondition:
+			attrs += ", shape=diamond, style=solid"
+		case NodeTypeSubGraph:
+			attrs += ", shape=box3d, style=solid"
+		case NodeTypeParallel, NodeTypeLoop:
+			attrs += ", shape=parallelogram, style=solid"
+		case NodeTypeHumanInput:
+			attrs += ", shape=trapezium, style=solid"
+		}
+		fmt.Fprintf(&b, "    %s [%s];\n", dotQuote(node.ID), attrs)
+	}
+	b.WriteString("    \"END\" [label=\"End\", shape=oval];\n")
+	if g.entryPoint != "" {
+		fmt.Fprintf(&b, "    \"__start__\" -> %s;\n", dotQuote(g.entryPoint))
+	}
+
+	for _, edge := range g.router.edgeRefs() {
+		var attrs []string
+		if label := edgeLabel(edge); label != "" {
+			attrs = append(attrs, "label="+dotQuote(label))
+		}
+		if isConditionalEdge(edge) {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "    %s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To), strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&b, "    %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// isConditionalEdge reports whether the edge is only taken under a condition.
+func isConditionalEdge(edge *Edge) bool {
+	return edge.Type == EdgeTypeConditional || edge.Type == EdgeTypeDefault ||
+		edge.Condition != nil || edge.ConditionName != "" || edge.Expression != ""
+}
+
+// edgeLabel describes the condition and priority of an edge for diagrams. The implicit
+// lowest priority of default edges is not shown.
+func edgeLabel(edge *Edge) string {
+	label := edgeCondition(edge)
+	if label == "" && edge.Type == EdgeTypeDefault {
+		label = "default"
+	}
+	if edge.Priority == 0 || edge.Type == EdgeTypeDefault && edge.Priority == -1 {
+		return label
+	}
+	priority := fmt.Sprintf("priority %d", edge.Priority)
+	if label == "" {
+		return priority
+	}
+	return fmt.Sprintf("%s (%s)", label, priority)
+}
+
+// dotQuote quotes an identifier or label for DOT.
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
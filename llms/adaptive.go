@@ -0,0 +1,394 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// RequestTagConversation 标识请求所属的会话，AdaptiveModel 按会话记录反馈并调整参数
+const RequestTagConversation = "conversation"
+
+// AdaptiveDecisionsKey 本次请求的参数调整决策（[]AdaptiveDecision），写入每个候选的 GenerationInfo
+const AdaptiveDecisionsKey = "adaptive_decisions"
+
+// 内置的反馈信号类型
+const (
+	// FeedbackParseFailed 输出无法解析（如JSON格式错误），由调用方通过 RecordFeedback 记录
+	FeedbackParseFailed = "parse_failed"
+	// FeedbackTruncated 输出因达到 max_tokens 被截断，根据回复的停止原因自动记录
+	FeedbackTruncated = "truncated"
+)
+
+const (
+	// defaultAdaptiveWindow 策略统计反馈的默认窗口（最近的请求数）
+	defaultAdaptiveWindow = 3
+	// maxConversationSignals 每个会话保留的最大反馈数
+	maxConversationSignals = 50
+	// maxConversationDecisions 每个会话保留的最大决策数
+	maxConversationDecisions = 100
+)
+
+// truncatedStopReasons 表示输出被截断的停止原因
+var truncatedStopReasons = map[string]bool{
+	"length":     true, // OpenAI 兼容接口
+	"max_tokens": true, // Anthropic
+	"MAX_TOKENS": true, // Gemini
+}
+
+// FeedbackSignal 会话的一条反馈
+type FeedbackSignal struct {
+	// Type 反馈类型，如 FeedbackParseFailed
+	Type string `json:"type"`
+	// Call 反馈针对的请求序号，从1开始
+	Call int `json:"call"`
+	// Detail 说明，可选
+	Detail string `json:"detail,omitempty"`
+	// Time 记录时间
+	Time time.Time `json:"time"`
+}
+
+// ConversationFeedback 会话的请求数与近期反馈
+type ConversationFeedback struct {
+	// Calls 会话已发送的请求数
+	Calls int
+	// Signals 近期反馈，按记录顺序排列
+	Signals []FeedbackSignal
+}
+
+// Recent 返回最近 window 次请求中指定类型的反馈数，window 小于等于0时统计全部保留的反馈
+func (f ConversationFeedback) Recent(signalType string, window int) int {
+	count := 0
+	for _, signal := range f.Signals {
+		if signal.Type != signalType {
+			continue
+		}
+		if window > 0 && signal.Call <= f.Calls-window {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// GenerationParams 可调整的生成参数，0表示未设置（使用服务商默认值）
+type GenerationParams struct {
+	Temperature float64 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+	TopP        float64 `json:"top_p"`
+}
+
+// AdaptivePolicy 参数调整策略
+// Adjust 根据会话反馈修改 params，发生调整时返回原因，未调整时返回空字符串
+type AdaptivePolicy interface {
+	Name() string
+	Adjust(feedback ConversationFeedback, params *GenerationParams) string
+}
+
+// AdaptivePolicyFunc 将函数适配为 AdaptivePolicy
+type AdaptivePolicyFunc struct {
+	PolicyName string
+	Fn         func(feedback ConversationFeedback, params *GenerationParams) string
+}
+
+// Name 实现 AdaptivePolicy 接口
+func (p AdaptivePolicyFunc) Name() string {
+	return p.PolicyName
+}
+
+// Adjust 实现 AdaptivePolicy 接口
+func (p AdaptivePolicyFunc) Adjust(feedback ConversationFeedback, params *GenerationParams) string {
+	return p.Fn(feedback, params)
+}
+
+// LowerTemperatureOnParseFailure 最近 window 次请求中每有一次解析失败，温度降低 step，最低为 floor
+// 请求未设置温度时以 base 为起点。温度为0表示未设置，floor 应大于0
+func LowerTemperatureOnParseFailure(base, step, floor float64, window int) AdaptivePolicy {
+	return AdaptivePolicyFunc{
+		PolicyName: "lower_temperature_on_parse_failure",
+		Fn: func(feedback ConversationFeedback, params *GenerationParams) string {
+			failures := feedback.Recent(FeedbackParseFailed, window)
+			if failures == 0 {
+				return ""
+			}
+			current := params.Temperature
+			if current == 0 {
+				current = base
+			}
+			lowered := current - step*float64(failures)
+			if lowered < floor {
+				lowered = floor
+			}
+			if lowered >= params.Temperature && params.Temperature != 0 {
+				return ""
+			}
+			params.Temperature = lowered
+			return fmt.Sprintf("最近%d次请求中有%d次解析失败", window, failures)
+		},
+	}
+}
+
+// RaiseMaxTokensOnTruncation 最近 window 次请求中每有一次输出被截断，max_tokens 乘以 factor，最高为 ceiling
+// 请求未设置 max_tokens 时以 base 为起点
+func RaiseMaxTokensOnTruncation(base int, factor float64, ceiling, window int) AdaptivePolicy {
+	return AdaptivePolicyFunc{
+		PolicyName: "raise_max_tokens_on_truncation",
+		Fn: func(feedback ConversationFeedback, params *GenerationParams) string {
+			truncations := feedback.Recent(FeedbackTruncated, window)
+			if truncations == 0 {
+				return ""
+			}
+			raised := params.MaxTokens
+			if raised == 0 {
+				raised = base
+			}
+			for i := 0; i < truncations; i++ {
+				raised = int(float64(raised) * factor)
+			}
+			if ceiling > 0 && raised > ceiling {
+				raised = ceiling
+			}
+			if raised <= params.MaxTokens {
+				return ""
+			}
+			params.MaxTokens = raised
+			return fmt.Sprintf("最近%d次请求中有%d次输出被截断", window, truncations)
+		},
+	}
+}
+
+// AdaptiveDecision 一次参数调整的记录
+type AdaptiveDecision struct {
+	Conversation string           `json:"conversation"`
+	Call         int              `json:"call"`
+	Policy       string           `json:"policy"`
+	Reason       string           `json:"reason"`
+	Before       GenerationParams `json:"before"`
+	After        GenerationParams `json:"after"`
+	Time         time.Time        `json:"time"`
+}
+
+// AdaptiveOptions 自适应参数装饰器配置
+type AdaptiveOptions struct {
+	// Policies 按顺序执行的调整策略，后面的策略看到前面策略调整后的参数
+	Policies []AdaptivePolicy
+	// OnDecision 每次调整时回调，用于记录决策日志，可选
+	OnDecision func(decision AdaptiveDecision)
+}
+
+// conversationState 会话的反馈与决策记录
+type conversationState struct {
+	feedback  ConversationFeedback
+	decisions []AdaptiveDecision
+}
+
+// AdaptiveModel 按会话自适应调整生成参数的装饰器
+// 会话由请求标签 RequestTagConversation 标识，未设置时所有请求共用一个默认会话。
+// 每次请求前，策略根据会话近期的反馈（调用方通过 RecordFeedback 记录的解析失败等，
+// 以及根据停止原因自动记录的截断）调整温度、max_tokens 等参数，调整以调用选项的形式
+// 追加在请求之后；每个决策都会回调 OnDecision、保存在会话中，并写入回复的 GenerationInfo。
+// 会话结束后调用 Forget 释放记录
+type AdaptiveModel struct {
+	model   llms.Model
+	options AdaptiveOptions
+
+	conversations map[string]*conversationState
+	mu            sync.Mutex
+}
+
+var _ llms.Model = (*AdaptiveModel)(nil)
+
+// NewAdaptiveModel 创建自适应参数装饰器，未指定策略时使用
+// LowerTemperatureOnParseFailure(0.7, 0.3, 0.1, 3) 与 RaiseMaxTokensOnTruncation(1024, 2, 8192, 3)
+func NewAdaptiveModel(model llms.Model, options AdaptiveOptions) *AdaptiveModel {
+	if len(options.Policies) == 0 {
+		options.Policies = []AdaptivePolicy{
+			LowerTemperatureOnParseFailure(0.7, 0.3, 0.1, defaultAdaptiveWindow),
+			RaiseMaxTokensOnTruncation(1024, 2, 8192, defaultAdaptiveWindow),
+		}
+	}
+	return &AdaptiveModel{
+		model:         model,
+		options:       options,
+		conversations: make(map[string]*conversationState),
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *AdaptiveModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	conversation := RequestTags(opts)[RequestTagConversation]
+
+	original := GenerationParams{
+		Temperature: opts.Temperature,
+		MaxTokens:   opts.MaxTokens,
+		TopP:        opts.TopP,
+	}
+
+	m.mu.Lock()
+	state := m.conversation(conversation)
+	state.feedback.Calls++
+	call := state.feedback.Calls
+	feedback := ConversationFeedback{
+		Calls:   call - 1, // 策略只看到之前请求的反馈
+		Signals: append([]FeedbackSignal(nil), state.feedback.Signals...),
+	}
+	m.mu.Unlock()
+
+	params := original
+	var decisions []AdaptiveDecision
+	for _, policy := range m.options.Policies {
+		before := params
+		reason := policy.Adjust(feedback, &params)
+		if reason == "" || params == before {
+			continue
+		}
+		decisions = append(decisions, AdaptiveDecision{
+			Conversation: conversation,
+			Call:         call,
+			Policy:       policy.Name(),
+			Reason:       reason,
+			Before:       before,
+			After:        params,
+			Time:         time.Now(),
+		})
+	}
+	m.recordDecisions(conversation, decisions)
+
+	if params.Temperature != original.Temperature {
+		options = append(options[:len(options):len(options)], llms.WithTemperature(params.Temperature))
+	}
+	if params.MaxTokens != original.MaxTokens {
+		options = append(options[:len(options):len(options)], llms.WithMaxTokens(params.MaxTokens))
+	}
+	if params.TopP != original.TopP {
+		options = append(options[:len(options):len(options)], llms.WithTopP(params.TopP))
+	}
+
+	resp, err := m.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, choice := range resp.Choices {
+		if truncatedStopReasons[choice.StopReason] {
+			m.record(conversation, call, FeedbackTruncated, "stop_reason="+choice.StopReason)
+			break
+		}
+	}
+	if len(decisions) > 0 {
+		for _, choice := range resp.Choices {
+			if choice.GenerationInfo == nil {
+				choice.GenerationInfo = make(map[string]any)
+			}
+			choice.GenerationInfo[AdaptiveDecisionsKey] = decisions
+		}
+	}
+	return resp, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *AdaptiveModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// RecordFeedback 为会话最近一次请求记录反馈，如输出无法解析时记录 FeedbackParseFailed
+func (m *AdaptiveModel) RecordFeedback(conversation, signalType, detail string) {
+	m.mu.Lock()
+	call := m.conversation(conversation).feedback.Calls
+	m.mu.Unlock()
+	m.record(conversation, call, signalType, detail)
+}
+
+// Feedback 返回会话的请求数与近期反馈
+func (m *AdaptiveModel) Feedback(conversation string) ConversationFeedback {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.conversations[conversation]
+	if !ok {
+		return ConversationFeedback{}
+	}
+	return ConversationFeedback{
+		Calls:   state.feedback.Calls,
+		Signals: append([]FeedbackSignal(nil), state.feedback.Signals...),
+	}
+}
+
+// Decisions 返回会话近期的参数调整决策
+func (m *AdaptiveModel) Decisions(conversation string) []AdaptiveDecision {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.conversations[conversation]
+	if !ok {
+		return nil
+	}
+	return append([]AdaptiveDecision(nil), state.decisions...)
+}
+
+// Forget 删除会话的反馈与决策记录
+func (m *AdaptiveModel) Forget(conversation string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.conversations, conversation)
+}
+
+// Endpoint 返回被包装模型的API地址，供 Warmup 预建连接
+func (m *AdaptiveModel) Endpoint() string {
+	return modelEndpoint(m.model)
+}
+
+// conversation 返回会话记录，不存在时创建，调用方需持有锁
+func (m *AdaptiveModel) conversation(conversation string) *conversationState {
+	state, ok := m.conversations[conversation]
+	if !ok {
+		state = &conversationState{}
+		m.conversations[conversation] = state
+	}
+	return state
+}
+
+// record 为会话的指定请求追加反馈
+func (m *AdaptiveModel) record(conversation string, call int, signalType, detail string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.conversation(conversation)
+	state.feedback.Signals = append(state.feedback.Signals, FeedbackSignal{
+		Type:   signalType,
+		Call:   call,
+		Detail: detail,
+		Time:   time.Now(),
+	})
+	if overflow := len(state.feedback.Signals) - maxConversationSignals; overflow > 0 {
+		state.feedback.Signals = state.feedback.Signals[overflow:]
+	}
+}
+
+// recordDecisions 保存决策并回调 OnDecision
+func (m *AdaptiveModel) recordDecisions(conversation string, decisions []AdaptiveDecision) {
+	if len(decisions) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	state := m.conversation(conversation)
+	state.decisions = append(state.decisions, decisions...)
+	if overflow := len(state.decisions) - maxConversationDecisions; overflow > 0 {
+		state.decisions = state.decisions[overflow:]
+	}
+	m.mu.Unlock()
+
+	if m.options.OnDecision != nil {
+		for _, decision := range decisions {
+			m.options.OnDecision(decision)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package llms_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// paramsModel 记录每次请求的调用选项，stopReasons 依次作为回复的停止原因
+type paramsModel struct {
+	mu          sync.Mutex
+	calls       []llms.CallOptions
+	stopReasons []string
+}
+
+func (m *paramsModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, opts)
+	stopReason := "stop"
+	if len(m.stopReasons) > 0 {
+		stopReason, m.stopReasons = m.stopReasons[0], m.stopReasons[1:]
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "ok", StopReason: stopReason}}}, nil
+}
+
+func (m *paramsModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func (m *paramsModel) last() llms.CallOptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[len(m.calls)-1]
+}
+
+func TestAdaptiveModel(t *testing.T) {
+	ctx := context.Background()
+	base := &paramsModel{stopReasons: []string{"length"}}
+	var logged []llmscn.AdaptiveDecision
+	model := llmscn.NewAdaptiveModel(base, llmscn.AdaptiveOptions{
+		OnDecision: func(decision llmscn.AdaptiveDecision) { logged = append(logged, decision) },
+	})
+
+	generate := func(conversation string) *llms.ContentResponse {
+		resp, err := model.GenerateContent(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")},
+			llms.WithTemperature(0.8), llms.WithMaxTokens(500),
+			llmscn.WithRequestTags(map[string]string{llmscn.RequestTagConversation: conversation}))
+		require.NoError(t, err)
+		return resp
+	}
+
+	// 第一次请求被截断，自动记录反馈
+	generate("c1")
+	assert.Equal(t, 500, base.last().MaxTokens)
+	feedback := model.Feedback("c1")
+	assert.Equal(t, 1, feedback.Recent(llmscn.FeedbackTruncated, 0))
+
+	// 下一次请求提高 max_tokens
+	resp := generate("c1")
+	assert.Equal(t, 1000, base.last().MaxTokens)
+	assert.Equal(t, 0.8, base.last().Temperature)
+	decisions, ok := resp.Choices[0].GenerationInfo[llmscn.AdaptiveDecisionsKey].([]llmscn.AdaptiveDecision)
+	require.True(t, ok)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, "raise_max_tokens_on_truncation", decisions[0].Policy)
+	assert.Equal(t, 500, decisions[0].Before.MaxTokens)
+	assert.Equal(t, 1000, decisions[0].After.MaxTokens)
+	assert.Equal(t, 2, decisions[0].Call)
+
+	// 调用方记录解析失败后降低温度
+	model.RecordFeedback("c1", llmscn.FeedbackParseFailed, "unexpected end of JSON input")
+	generate("c1")
+	assert.InDelta(t, 0.5, base.last().Temperature, 1e-9)
+	assert.Equal(t, 1000, base.last().MaxTokens)
+
+	// 其他会话不受影响
+	generate("c2")
+	assert.Equal(t, 0.8, base.last().Temperature)
+	assert.Equal(t, 500, base.last().MaxTokens)
+
+	// 反馈超出窗口后恢复原参数
+	generate("c1")
+	generate("c1")
+	generate("c1")
+	assert.Equal(t, 0.8, base.last().Temperature)
+	assert.Equal(t, 500, base.last().MaxTokens)
+
+	assert.Len(t, model.Decisions("c1"), len(logged))
+	assert.Empty(t, model.Decisions("c2"))
+	model.Forget("c1")
+	assert.Empty(t, model.Decisions("c1"))
+}
+
+func TestAdaptivePolicies(t *testing.T) {
+	feedback := llmscn.ConversationFeedback{
+		Calls: 4,
+		Signals: []llmscn.FeedbackSignal{
+			{Type: llmscn.FeedbackParseFailed, Call: 1},
+			{Type: llmscn.FeedbackParseFailed, Call: 3},
+			{Type: llmscn.FeedbackParseFailed, Call: 4},
+		},
+	}
+	assert.Equal(t, 2, feedback.Recent(llmscn.FeedbackParseFailed, 3))
+	assert.Equal(t, 3, feedback.Recent(llmscn.FeedbackParseFailed, 0))
+
+	// 未设置温度时从 base 开始，不低于 floor
+	params := llmscn.GenerationParams{}
+	reason := llmscn.LowerTemperatureOnParseFailure(0.7, 0.3, 0.2, 3).Adjust(feedback, &params)
+	assert.NotEmpty(t, reason)
+	assert.Equal(t, 0.2, params.Temperature)
+
+	params = llmscn.GenerationParams{MaxTokens: 3000}
+	feedback.Signals = []llmscn.FeedbackSignal{{Type: llmscn.FeedbackTruncated, Call: 4}}
+	llmscn.RaiseMaxTokensOnTruncation(1024, 2, 4096, 3).Adjust(feedback, &params)
+	assert.Equal(t, 4096, params.MaxTokens)
+}
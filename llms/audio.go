@@ -0,0 +1,38 @@
+package llms
+
+import (
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/media"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// 音频内容以 audio/* MIME 类型的 llms.BinaryContent 表示，支持音频输入的服务商
+// （通义千问 Omni/Audio 模型、硅基流动音频模型）在发送请求前将其转换为各自的请求格式；
+// 其他模型收到音频时返回 *CapabilityError
+
+// AudioInput 解析后的音频输入
+type AudioInput = media.Audio
+
+// CapabilityError 模型能力错误，如向不支持音频的模型发送音频
+// 可通过 errors.Is(err, ErrCapabilityNotSupported) 判断
+type CapabilityError = media.CapabilityError
+
+// ErrCapabilityNotSupported 表示模型不支持请求中的输入类型
+var ErrCapabilityNotSupported = media.ErrCapabilityNotSupported
+
+// AudioContent 创建音频数据内容片段，format 为音频格式（如 wav、mp3），为空时默认 wav
+func AudioContent(data []byte, format string) llms.ContentPart {
+	return media.NewPart(media.Audio{Format: format, Data: data})
+}
+
+// AudioURLContent 创建音频地址内容片段，format 为空时根据地址扩展名推断
+func AudioURLContent(url, format string) llms.ContentPart {
+	if format == "" {
+		format = media.FormatFromURL(url)
+	}
+	return media.NewPart(media.Audio{Format: format, URL: url})
+}
+
+// ParseAudioContent 解析音频内容片段，不是音频时返回 false
+func ParseAudioContent(part llms.ContentPart) (AudioInput, bool) {
+	return media.Parse(part)
+}
@@ -0,0 +1,54 @@
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestAudioContent(t *testing.T) {
+	part := llmscn.AudioContent([]byte("RIFF"), "")
+	audio, ok := llmscn.ParseAudioContent(part)
+	require.True(t, ok)
+	assert.Equal(t, "wav", audio.Format)
+	assert.Equal(t, []byte("RIFF"), audio.Data)
+	assert.Equal(t, "data:audio/wav;base64,UklGRg==", audio.Source())
+	assert.Equal(t, "audio", llmscn.PartKind(part))
+
+	audio, ok = llmscn.ParseAudioContent(llmscn.AudioURLContent("https://example.com/a/question.MP3?x=1", ""))
+	require.True(t, ok)
+	assert.Equal(t, "mp3", audio.Format)
+	assert.Equal(t, "https://example.com/a/question.MP3?x=1", audio.Source())
+
+	_, ok = llmscn.ParseAudioContent(llms.BinaryContent{MIMEType: "image/png", Data: []byte{1}})
+	assert.False(t, ok)
+	assert.Equal(t, "binary", llmscn.PartKind(llms.BinaryContent{MIMEType: "image/png"}))
+
+	// 不支持音频的模型在发送请求前返回能力错误
+	messages := []llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.TextContent{Text: "这段录音说了什么？"}, part},
+	}}
+	qwenLLM, err := qwen.New(qwen.WithAPIKey("test"), qwen.WithModel(qwen.ModelQWenMax))
+	require.NoError(t, err)
+	_, err = qwenLLM.GenerateContent(context.Background(), messages)
+	require.ErrorIs(t, err, llmscn.ErrCapabilityNotSupported)
+	var capErr *llmscn.CapabilityError
+	require.True(t, errors.As(err, &capErr))
+	assert.Equal(t, "audio", capErr.Capability)
+	assert.Equal(t, qwen.ModelQWenMax, capErr.Model)
+	assert.True(t, qwen.SupportsAudio(qwen.ModelQWenOmniTurbo))
+
+	sfLLM, err := siliconflow.New(siliconflow.WithAPIKey("test"))
+	require.NoError(t, err)
+	_, err = sfLLM.GenerateContent(context.Background(), messages)
+	require.ErrorIs(t, err, llmscn.ErrCapabilityNotSupported)
+	assert.True(t, siliconflow.SupportsAudio("Qwen/Qwen2-Audio-7B-Instruct"))
+}
@@ -0,0 +1,26 @@
+package llms
+
+import "github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+
+// AuthProvider 请求认证方式，通过各服务商的 WithAuthProvider 选项设置（目前支持 qwen、zhipu、siliconflow）
+// 用于要求 AK/SK 签名或自定义认证头而不是 Bearer 令牌的企业部署
+type AuthProvider = auth.Provider
+
+// AuthProviderFunc 将函数适配为 AuthProvider，适用于只需设置自定义认证头的场景
+type AuthProviderFunc = auth.Func
+
+// BearerAuth Bearer 令牌认证
+type BearerAuth = auth.Bearer
+
+// AKSKAuth 阿里云 V3 风格的 AK/SK 签名（ACS3-HMAC-SHA256）
+type AKSKAuth = auth.AKSK
+
+// NewBearerAuth 创建 Bearer 令牌认证
+func NewBearerAuth(token string) *BearerAuth {
+	return &BearerAuth{Token: token}
+}
+
+// NewAKSKAuth 创建 AK/SK 签名认证，securityToken 为 STS 临时凭证的安全令牌，不使用时传空字符串
+func NewAKSKAuth(accessKeyID, accessKeySecret, securityToken string) *AKSKAuth {
+	return &AKSKAuth{AccessKeyID: accessKeyID, AccessKeySecret: accessKeySecret, SecurityToken: securityToken}
+}
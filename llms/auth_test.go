@@ -0,0 +1,131 @@
+package llms_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// verifyACS3 按 ACS3-HMAC-SHA256 规范在服务端重新计算签名
+func verifyACS3(r *http.Request, body []byte, secret string) (string, error) {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "ACS3-HMAC-SHA256 ") {
+		return "", fmt.Errorf("unexpected authorization: %s", authorization)
+	}
+	fields := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "ACS3-HMAC-SHA256 "), ",") {
+		kv := strings.SplitN(part, "=", 2)
+		fields[kv[0]] = kv[1]
+	}
+
+	payloadHash := sha256.Sum256(body)
+	if r.Header.Get("x-acs-content-sha256") != hex.EncodeToString(payloadHash[:]) {
+		return "", fmt.Errorf("payload hash mismatch")
+	}
+
+	names := strings.Split(fields["SignedHeaders"], ";")
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonical := strings.Join([]string{r.Method, r.URL.EscapedPath(), r.URL.RawQuery, headers.String(), fields["SignedHeaders"], hex.EncodeToString(payloadHash[:])}, "\n")
+	requestHash := sha256.Sum256([]byte(canonical))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("ACS3-HMAC-SHA256\n" + hex.EncodeToString(requestHash[:])))
+	if expected := hex.EncodeToString(mac.Sum(nil)); expected != fields["Signature"] {
+		return "", fmt.Errorf("signature mismatch")
+	}
+	return fields["Credential"], nil
+}
+
+func TestAuthProvider(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   string
+		err    error
+		cred   string
+	}
+	var last received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		last = received{header: r.Header.Clone(), body: string(body)}
+		if strings.HasPrefix(r.Header.Get("Authorization"), "ACS3-") {
+			last.cred, last.err = verifyACS3(r, body, "secret")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","created":1,"model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	t.Run("通义千问AK/SK签名", func(t *testing.T) {
+		t.Setenv(qwen.TokenEnvVarName, "")
+		model, err := qwen.New(
+			qwen.WithBaseURL(server.URL),
+			qwen.WithModel("qwen-turbo"),
+			qwen.WithAuthProvider(llmscn.NewAKSKAuth("ak-id", "secret", "sts-token")),
+		)
+		require.NoError(t, err)
+
+		reply, err := llms.GenerateFromSinglePrompt(context.Background(), model, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", reply)
+
+		require.NoError(t, last.err)
+		assert.Equal(t, "ak-id", last.cred)
+		assert.Equal(t, "sts-token", last.header.Get("x-acs-security-token"))
+		assert.NotEmpty(t, last.header.Get("x-acs-signature-nonce"))
+		assert.Contains(t, last.body, "qwen-turbo")
+	})
+
+	t.Run("智谱自定义认证头", func(t *testing.T) {
+		model, err := zhipu.New(
+			zhipu.WithAPIKey("ignored"),
+			zhipu.WithBaseURL(server.URL),
+			zhipu.WithAuthProvider(llmscn.AuthProviderFunc(func(req *http.Request) error {
+				req.Header.Set("X-Api-Token", "gateway-token")
+				return nil
+			})),
+		)
+		require.NoError(t, err)
+
+		_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "gateway-token", last.header.Get("X-Api-Token"))
+		// 默认的 Bearer 令牌不会被发送
+		assert.Empty(t, last.header.Get("Authorization"))
+	})
+
+	t.Run("Bearer与签名失败", func(t *testing.T) {
+		model, err := qwen.New(qwen.WithBaseURL(server.URL), qwen.WithAuthProvider(llmscn.NewBearerAuth("vpc-token")))
+		require.NoError(t, err)
+		_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer vpc-token", last.header.Get("Authorization"))
+
+		model, err = qwen.New(qwen.WithBaseURL(server.URL), qwen.WithAuthProvider(llmscn.NewAKSKAuth("", "", "")))
+		require.NoError(t, err)
+		_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "你好")
+		assert.Error(t, err)
+	})
+}
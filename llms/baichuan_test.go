@@ -0,0 +1,54 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/baichuan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestBaichuanLLM(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("BAICHUAN_MODEL", "")
+
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"b-1","object":"chat.completion","created":1,"model":"Baichuan4-Turbo","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"成都\"}"}}]}}],"usage":{"prompt_tokens":15,"completion_tokens":8,"total_tokens":23}}`))
+	}))
+	defer server.Close()
+
+	llm, err := llmscn.CreateLLM(llmscn.BaichuanLLM, map[string]interface{}{"api_key": "test-key", "base_url": server.URL})
+	require.NoError(t, err)
+
+	resp, err := llm.GenerateContent(ctx,
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "成都天气怎么样")},
+		llms.WithTools([]llms.Tool{{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:       "get_weather",
+				Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+			},
+		}}))
+	require.NoError(t, err)
+	require.Len(t, resp.Choices[0].ToolCalls, 1)
+	assert.Equal(t, "get_weather", resp.Choices[0].ToolCalls[0].FunctionCall.Name)
+	assert.JSONEq(t, `{"city":"成都"}`, resp.Choices[0].ToolCalls[0].FunctionCall.Arguments)
+	assertTokenUsage(t, resp.Choices[0].GenerationInfo, 15, 8, 23)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, baichuan.DefaultModel, requests[0]["model"])
+	assert.Len(t, requests[0]["tools"], 1)
+}
@@ -0,0 +1,387 @@
+package llms
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// CacheHitKey 命中缓存的回复在每个候选的 GenerationInfo 中记录为 true
+const CacheHitKey = "cache_hit"
+
+// ResponseCache 模型回复的缓存存储
+type ResponseCache interface {
+	// Get 查找缓存的回复，不存在或已过期时返回 false
+	Get(ctx context.Context, key string) (*llms.ContentResponse, bool, error)
+	// Set 缓存回复，ttl 不大于0表示不过期
+	Set(ctx context.Context, key string, resp *llms.ContentResponse, ttl time.Duration) error
+}
+
+// CacheStats 缓存统计
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Errors int64 `json:"errors"`
+}
+
+// CachedModel 回复缓存装饰器
+// 以消息内容、调用参数和模型类型的哈希作为键缓存回复，相同的请求不会再次发送给服务商，
+// 适用于测试流水线和智能体中重复的提示词。请求标签与首个token超时不参与计算。
+// 失败的请求不缓存；缓存读写出错时直接调用模型，不影响请求。命中缓存的流式调用会一次性回调全部内容
+type CachedModel struct {
+	model     llms.Model
+	cache     ResponseCache
+	ttl       time.Duration
+	namespace string
+	onError   func(err error)
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+var _ llms.Model = (*CachedModel)(nil)
+
+// NewCachedModel 创建回复缓存装饰器，缓存有效期默认为24小时
+func NewCachedModel(model llms.Model, cache ResponseCache) *CachedModel {
+	return &CachedModel{
+		model: model,
+		cache: cache,
+		ttl:   24 * time.Hour,
+	}
+}
+
+// WithTTL 设置缓存有效期，不大于0表示不过期
+func (m *CachedModel) WithTTL(ttl time.Duration) *CachedModel {
+	m.ttl = ttl
+	return m
+}
+
+// WithNamespace 设置缓存键的命名空间，多个模型（如同一服务商的不同模型）共用一个缓存时用于区分
+func (m *CachedModel) WithNamespace(namespace string) *CachedModel {
+	m.namespace = namespace
+	return m
+}
+
+// WithErrorHandler 设置缓存读写失败时的回调，可用于记录日志
+func (m *CachedModel) WithErrorHandler(onError func(err error)) *CachedModel {
+	m.onError = onError
+	return m
+}
+
+// Stats 返回缓存统计
+func (m *CachedModel) Stats() CacheStats {
+	return CacheStats{
+		Hits:   m.hits.Load(),
+		Misses: m.misses.Load(),
+		Errors: m.errors.Load(),
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *CachedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	key, ok := m.key(messages, opts)
+	if !ok {
+		return m.model.GenerateContent(ctx, messages, options...)
+	}
+
+	resp, hit, err := m.cache.Get(ctx, key)
+	if err != nil {
+		m.handleError(fmt.Errorf("读取缓存失败: %w", err))
+	}
+	if hit && resp != nil {
+		m.hits.Add(1)
+		resp = copyResponse(resp, false)
+		for _, choice := range resp.Choices {
+			choice.GenerationInfo[CacheHitKey] = true
+		}
+		if opts.StreamingFunc != nil && len(resp.Choices) > 0 {
+			if err := opts.StreamingFunc(ctx, []byte(resp.Choices[0].Content)); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+	m.misses.Add(1)
+
+	resp, err = m.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.cache.Set(ctx, key, copyResponse(resp, false), m.ttl); err != nil {
+		m.handleError(fmt.Errorf("写入缓存失败: %w", err))
+	}
+	return resp, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *CachedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Endpoint 返回被装饰模型的服务地址，供预热使用
+func (m *CachedModel) Endpoint() string {
+	return modelEndpoint(m.model)
+}
+
+// key 计算请求的缓存键，消息或参数无法序列化时返回 false
+func (m *CachedModel) key(messages []llms.MessageContent, opts llms.CallOptions) (string, bool) {
+	// 请求标签与首个token超时不影响生成结果
+	if metadata := CallMetadata(opts); len(metadata) > 0 {
+		filtered := make(map[string]interface{}, len(metadata))
+		for k, v := range metadata {
+			if k != RequestTagsKey && k != FirstTokenTimeoutKey {
+				filtered[k] = v
+			}
+		}
+		SetCallMetadata(&opts, filtered)
+	}
+
+	data, err := json.Marshal(struct {
+		Namespace string                `json:"namespace"`
+		Model     string                `json:"model"`
+		Messages  []llms.MessageContent `json:"messages"`
+		Options   llms.CallOptions      `json:"options"`
+	}{m.namespace, fmt.Sprintf("%T", m.model), messages, opts})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// handleError 记录缓存读写错误
+func (m *CachedModel) handleError(err error) {
+	m.errors.Add(1)
+	if m.onError != nil {
+		m.onError(err)
+	}
+}
+
+// ================================
+// 内存缓存
+// ================================
+
+// MemoryCache 进程内的LRU缓存
+type MemoryCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+var _ ResponseCache = (*MemoryCache)(nil)
+
+// memoryCacheEntry 内存缓存条目
+type memoryCacheEntry struct {
+	key       string
+	resp      *llms.ContentResponse
+	expiresAt time.Time
+}
+
+// NewMemoryCache 创建内存缓存，最多缓存 maxEntries 条回复（默认1000），超出时淘汰最久未使用的回复
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get 实现 ResponseCache 接口
+func (c *MemoryCache) Get(ctx context.Context, key string) (*llms.ContentResponse, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.lru.MoveToFront(element)
+	return entry.resp, true, nil
+}
+
+// Set 实现 ResponseCache 接口
+func (c *MemoryCache) Set(ctx context.Context, key string, resp *llms.ContentResponse, ttl time.Duration) error {
+	entry := &memoryCacheEntry{key: key, resp: resp}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.lru.Remove(element)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// Len 返回缓存的回复数
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// ================================
+// 文件缓存
+// ================================
+
+// FileCache 文件缓存，每条回复保存为目录下的一个JSON文件，可在进程重启后继续使用
+// 回复经过JSON序列化，GenerationInfo 中的数值读取后为 float64
+type FileCache struct {
+	dir string
+}
+
+var _ ResponseCache = (*FileCache)(nil)
+
+// fileCacheEntry 文件缓存条目
+type fileCacheEntry struct {
+	ExpiresAt time.Time             `json:"expires_at,omitempty"`
+	Response  *llms.ContentResponse `json:"response"`
+}
+
+// NewFileCache 创建文件缓存，目录不存在时自动创建
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+// Get 实现 ResponseCache 接口
+func (c *FileCache) Get(ctx context.Context, key string) (*llms.ContentResponse, bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entry fileCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false, fmt.Errorf("解析缓存文件失败: %w", err)
+	}
+	if !entry.ExpiresAt.IsZero() && !time.Now().Before(entry.ExpiresAt) {
+		_ = os.Remove(c.path(key))
+		return nil, false, nil
+	}
+	return entry.Response, entry.Response != nil, nil
+}
+
+// Set 实现 ResponseCache 接口，先写入临时文件再重命名，避免读到不完整的内容
+func (c *FileCache) Set(ctx context.Context, key string, resp *llms.ContentResponse, ttl time.Duration) error {
+	entry := fileCacheEntry{Response: resp}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化回复失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// path 返回缓存文件路径
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// ================================
+// Redis 缓存
+// ================================
+
+// RedisCache Redis缓存，可在多个进程之间共享，过期由 Redis 处理
+// 回复经过JSON序列化，GenerationInfo 中的数值读取后为 float64
+type RedisCache struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+var _ ResponseCache = (*RedisCache)(nil)
+
+// NewRedisCache 创建Redis缓存，keyPrefix 为空时使用 "llmscn:cache:"
+func NewRedisCache(client redis.UniversalClient, keyPrefix string) *RedisCache {
+	if keyPrefix == "" {
+		keyPrefix = "llmscn:cache:"
+	}
+	return &RedisCache{client: client, keyPrefix: keyPrefix}
+}
+
+// Get 实现 ResponseCache 接口
+func (c *RedisCache) Get(ctx context.Context, key string) (*llms.ContentResponse, bool, error) {
+	data, err := c.client.Get(ctx, c.keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var resp llms.ContentResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false, fmt.Errorf("解析缓存失败: %w", err)
+	}
+	return &resp, true, nil
+}
+
+// Set 实现 ResponseCache 接口
+func (c *RedisCache) Set(ctx context.Context, key string, resp *llms.ContentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("序列化回复失败: %w", err)
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	return c.client.Set(ctx, c.keyPrefix+key, data, ttl).Err()
+}
@@ -0,0 +1,116 @@
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// brokenCache 读写都失败的缓存
+type brokenCache struct{}
+
+func (brokenCache) Get(ctx context.Context, key string) (*llms.ContentResponse, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (brokenCache) Set(ctx context.Context, key string, resp *llms.ContentResponse, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func TestCachedModel(t *testing.T) {
+	ctx := context.Background()
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")}
+
+	t.Run("相同请求命中缓存", func(t *testing.T) {
+		inner := &fakeModel{reply: "你好！"}
+		model := llmscn.NewCachedModel(inner, llmscn.NewMemoryCache(0))
+
+		_, err := model.GenerateContent(ctx, messages)
+		require.NoError(t, err)
+		var chunks []string
+		resp, err := model.GenerateContent(ctx, messages,
+			llmscn.WithRequestTags(map[string]string{"tenant": "a"}),
+			llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+				chunks = append(chunks, string(chunk))
+				return nil
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, "你好！", resp.Choices[0].Content)
+		assert.Equal(t, true, resp.Choices[0].GenerationInfo[llmscn.CacheHitKey])
+		assert.Equal(t, []string{"你好！"}, chunks)
+		assert.Len(t, inner.received, 1)
+
+		// 参数或回复语言不同时不命中
+		_, err = model.GenerateContent(ctx, messages, llms.WithTemperature(0.1))
+		require.NoError(t, err)
+		_, err = model.GenerateContent(ctx, messages, llmscn.WithResponseLanguage(llmscn.LanguageEnglish))
+		require.NoError(t, err)
+		assert.Len(t, inner.received, 3)
+		assert.Equal(t, llmscn.CacheStats{Hits: 1, Misses: 3}, model.Stats())
+	})
+
+	t.Run("文件缓存在实例之间共享并按有效期过期", func(t *testing.T) {
+		dir := t.TempDir()
+		inner := &fakeModel{reply: "缓存"}
+
+		cache, err := llmscn.NewFileCache(dir)
+		require.NoError(t, err)
+		_, err = llmscn.NewCachedModel(inner, cache).Call(ctx, "你好")
+		require.NoError(t, err)
+
+		reopened, err := llmscn.NewFileCache(dir)
+		require.NoError(t, err)
+		text, err := llmscn.NewCachedModel(inner, reopened).Call(ctx, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "缓存", text)
+		assert.Len(t, inner.received, 1)
+
+		expiring := llmscn.NewCachedModel(inner, reopened).WithNamespace("short").WithTTL(time.Millisecond)
+		_, err = expiring.Call(ctx, "你好")
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		_, err = expiring.Call(ctx, "你好")
+		require.NoError(t, err)
+		assert.Len(t, inner.received, 3)
+	})
+
+	t.Run("Redis缓存", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		defer client.Close()
+
+		inner := &fakeModel{reply: "redis"}
+		model := llmscn.NewCachedModel(inner, llmscn.NewRedisCache(client, "")).WithTTL(time.Minute)
+		for i := 0; i < 2; i++ {
+			text, err := model.Call(ctx, "你好")
+			require.NoError(t, err)
+			assert.Equal(t, "redis", text)
+		}
+		assert.Len(t, inner.received, 1)
+
+		keys := server.Keys()
+		require.Len(t, keys, 1)
+		assert.Contains(t, keys[0], "llmscn:cache:")
+		assert.Equal(t, time.Minute, server.TTL(keys[0]))
+	})
+
+	t.Run("缓存出错时直接调用模型", func(t *testing.T) {
+		var cacheErrs []error
+		model := llmscn.NewCachedModel(&fakeModel{reply: "ok"}, brokenCache{}).
+			WithErrorHandler(func(err error) { cacheErrs = append(cacheErrs, err) })
+
+		text, err := model.Call(ctx, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", text)
+		assert.Len(t, cacheErrs, 2)
+		assert.Equal(t, int64(2), model.Stats().Errors)
+	})
+}
@@ -0,0 +1,207 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// 多候选生成时写入每个候选 GenerationInfo 的字段
+const (
+	// CandidateIndexKey 候选的序号，从0开始
+	CandidateIndexKey = "candidate_index"
+	// CandidateUsageKey 用量的归属方式，CandidateUsageExact 或 CandidateUsageEstimated
+	CandidateUsageKey = "candidate_usage"
+)
+
+const (
+	// CandidateUsageExact 候选由单独的请求生成，用量为该请求的实际用量
+	CandidateUsageExact = "exact"
+	// CandidateUsageEstimated 候选由同一请求生成，提示词用量计入第一个候选，
+	// 生成用量按各候选的内容长度分摊
+	CandidateUsageEstimated = "estimated"
+)
+
+// nativeCandidates 请求参数 n 能够生效的LLM类型，其余类型通过并行采样模拟
+var nativeCandidates = map[LLMType]bool{
+	OpenAILLM:      true,
+	QwenLLM:        true,
+	SiliconFlowLLM: true,
+	RemoteLLM:      true,
+}
+
+// CandidatesModel 多候选生成装饰器
+// 调用选项通过 llms.WithN 或 llms.WithCandidateCount 请求多个候选时，支持参数 n 的服务商
+// 在一次请求中生成，返回的候选不足时补充采样；其余服务商并行发送多次请求模拟。
+// 每个候选的 GenerationInfo 记录序号与归属的用量，各候选用量之和等于实际消耗。
+// 流式调用时只有第一个候选的输出会回调给调用方
+type CandidatesModel struct {
+	model  llms.Model
+	native bool
+}
+
+var _ llms.Model = (*CandidatesModel)(nil)
+
+// NewCandidatesModel 创建多候选生成装饰器，native 表示模型的API支持参数 n
+func NewCandidatesModel(model llms.Model, native bool) *CandidatesModel {
+	return &CandidatesModel{
+		model:  model,
+		native: native,
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *CandidatesModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	n := opts.N
+	if opts.CandidateCount > n {
+		n = opts.CandidateCount
+	}
+	if n <= 1 {
+		return m.model.GenerateContent(ctx, messages, options...)
+	}
+
+	var choices []*llms.ContentChoice
+	if m.native {
+		resp, err := m.model.GenerateContent(ctx, messages, options...)
+		if err != nil {
+			return nil, err
+		}
+		choices = resp.Choices
+		if len(choices) > n {
+			choices = choices[:n]
+		}
+		attributeSharedUsage(choices)
+	}
+
+	// 服务商不支持或忽略了参数 n 时，并行采样补足候选
+	if missing := n - len(choices); missing > 0 {
+		sampled, err := m.sample(ctx, messages, options, opts, len(choices), missing)
+		if err != nil {
+			return nil, err
+		}
+		choices = append(choices, sampled...)
+	}
+
+	for i, choice := range choices {
+		choice.GenerationInfo[CandidateIndexKey] = i
+	}
+	return &llms.ContentResponse{Choices: choices}, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *CandidatesModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Endpoint 返回被包装模型的API地址，供 Warmup 预建连接
+func (m *CandidatesModel) Endpoint() string {
+	return modelEndpoint(m.model)
+}
+
+// sample 并行发送 count 个单候选请求，任一请求失败时取消其余请求并返回错误
+// offset 为已生成的候选数，offset 为0时第一个请求保留调用方的流式回调
+func (m *CandidatesModel) sample(ctx context.Context, messages []llms.MessageContent, options []llms.CallOption, opts llms.CallOptions, offset, count int) ([]*llms.ContentChoice, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	choices := make([]*llms.ContentChoice, count)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i := 0; i < count; i++ {
+		index := offset + i
+		callOptions := append(options[:len(options):len(options)], llms.WithN(1), llms.WithCandidateCount(0))
+		if index > 0 {
+			callOptions = append(callOptions, llms.WithStreamingFunc(nil))
+		}
+		// 固定种子时各请求的结果相同，为每个候选使用不同的种子
+		if opts.Seed != 0 {
+			callOptions = append(callOptions, llms.WithSeed(opts.Seed+index))
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := m.model.GenerateContent(ctx, messages, callOptions...)
+			if err == nil && len(resp.Choices) == 0 {
+				err = errors.New("模型未返回候选")
+			}
+			if err != nil {
+				// 记录最先失败的错误，其余请求因取消返回的错误忽略
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			choice := resp.Choices[0]
+			if choice.GenerationInfo == nil {
+				choice.GenerationInfo = make(map[string]any)
+			}
+			choice.GenerationInfo[CandidateUsageKey] = CandidateUsageExact
+			choices[i] = choice
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return choices, nil
+}
+
+// attributeSharedUsage 将同一请求的用量分摊到各候选
+// OpenAI兼容接口在每个候选中重复返回整个请求的用量，分摊后各候选之和等于实际用量
+func attributeSharedUsage(choices []*llms.ContentChoice) {
+	if len(choices) == 0 {
+		return
+	}
+	if len(choices) == 1 {
+		if choices[0].GenerationInfo == nil {
+			choices[0].GenerationInfo = make(map[string]any)
+		}
+		choices[0].GenerationInfo[CandidateUsageKey] = CandidateUsageExact
+		return
+	}
+	info := choices[0].GenerationInfo
+	prompt, completion, _ := TokenUsage(info)
+
+	weights := make([]int, len(choices))
+	total := 0
+	for i, choice := range choices {
+		weights[i] = estimateTokens(choice.Content) + 1
+		total += weights[i]
+	}
+
+	remaining := completion
+	for i, choice := range choices {
+		share := completion * weights[i] / total
+		if i == len(choices)-1 {
+			share = remaining
+		}
+		remaining -= share
+
+		promptShare := 0
+		if i == 0 {
+			promptShare = prompt
+		}
+
+		attributed := make(map[string]any, len(choice.GenerationInfo)+7)
+		for k, v := range choice.GenerationInfo {
+			attributed[k] = v
+		}
+		usage.Set(attributed, promptShare, share, promptShare+share)
+		attributed[CandidateUsageKey] = CandidateUsageEstimated
+		choice.GenerationInfo = attributed
+	}
+}
@@ -0,0 +1,128 @@
+package llms_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// candidateModel 按请求的 n 返回候选，native 为 false 时始终只返回一个候选
+type candidateModel struct {
+	native bool
+
+	mu    sync.Mutex
+	seeds []int
+}
+
+func (m *candidateModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.mu.Lock()
+	m.seeds = append(m.seeds, opts.Seed)
+	m.mu.Unlock()
+
+	n := 1
+	if m.native && opts.N > 1 {
+		n = opts.N
+	}
+	resp := &llms.ContentResponse{}
+	for i := 0; i < n; i++ {
+		// 与OpenAI兼容接口一致，每个候选都带有整个请求的用量
+		resp.Choices = append(resp.Choices, &llms.ContentChoice{
+			Content: fmt.Sprintf("candidate %d", i),
+			GenerationInfo: map[string]any{
+				"PromptTokens":     10,
+				"CompletionTokens": 6 * n,
+				"TotalTokens":      10 + 6*n,
+			},
+		})
+	}
+	return resp, nil
+}
+
+func (m *candidateModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// candidateUsage 汇总各候选归属的用量
+func candidateUsage(t *testing.T, resp *llms.ContentResponse) (prompt, completion int) {
+	for i, choice := range resp.Choices {
+		assert.Equal(t, i, choice.GenerationInfo[llmscn.CandidateIndexKey])
+		prompt += choice.GenerationInfo["PromptTokens"].(int)
+		completion += choice.GenerationInfo["CompletionTokens"].(int)
+	}
+	return prompt, completion
+}
+
+func TestCandidatesModel(t *testing.T) {
+	ctx := context.Background()
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")}
+
+	t.Run("原生支持", func(t *testing.T) {
+		inner := &candidateModel{native: true}
+		resp, err := llmscn.NewCandidatesModel(inner, true).GenerateContent(ctx, messages, llms.WithN(3))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices, 3)
+		assert.Len(t, inner.seeds, 1)
+
+		// 提示词只计一次，生成用量分摊后等于实际用量
+		prompt, completion := candidateUsage(t, resp)
+		assert.Equal(t, 10, prompt)
+		assert.Equal(t, 18, completion)
+		assert.Equal(t, llmscn.CandidateUsageEstimated, resp.Choices[0].GenerationInfo[llmscn.CandidateUsageKey])
+	})
+
+	t.Run("并行采样模拟", func(t *testing.T) {
+		inner := &candidateModel{}
+		resp, err := llmscn.NewCandidatesModel(inner, false).GenerateContent(ctx, messages, llms.WithCandidateCount(3), llms.WithSeed(7))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices, 3)
+		assert.ElementsMatch(t, []int{7, 8, 9}, inner.seeds)
+
+		prompt, completion := candidateUsage(t, resp)
+		assert.Equal(t, 30, prompt)
+		assert.Equal(t, 18, completion)
+		assert.Equal(t, llmscn.CandidateUsageExact, resp.Choices[2].GenerationInfo[llmscn.CandidateUsageKey])
+	})
+
+	t.Run("服务商忽略参数时补足候选", func(t *testing.T) {
+		inner := &candidateModel{}
+		resp, err := llmscn.NewCandidatesModel(inner, true).GenerateContent(ctx, messages, llms.WithN(2))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices, 2)
+		assert.Len(t, inner.seeds, 2)
+	})
+
+	t.Run("用量上报累加各候选", func(t *testing.T) {
+		sink := &memoryUsageSink{}
+		reporter, err := llmscn.NewUsageReporter(sink, llmscn.UsageReporterOptions{})
+		require.NoError(t, err)
+
+		model := llmscn.NewUsageReportingModel(llmscn.NewCandidatesModel(&candidateModel{native: true}, true), reporter, "qwen", "qwen-plus")
+		_, err = model.GenerateContent(ctx, messages, llms.WithN(3))
+		require.NoError(t, err)
+		require.NoError(t, reporter.Close(ctx))
+
+		require.Len(t, sink.records, 1)
+		assert.Equal(t, 10, sink.records[0].PromptTokens)
+		assert.Equal(t, 18, sink.records[0].CompletionTokens)
+	})
+}
+
+// memoryUsageSink 在内存中保存上报的记录
+type memoryUsageSink struct {
+	records []llmscn.UsageRecord
+}
+
+func (s *memoryUsageSink) WriteUsage(ctx context.Context, batch llmscn.UsageBatch) error {
+	s.records = append(s.records, batch.Records...)
+	return nil
+}
@@ -0,0 +1,174 @@
+package llms
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// 上游 tmc/langchaingo 的选项结构和 ContentPart 类型会随版本增减字段。
+// 本文件提供兼容层：对可能不存在的字段通过反射按名称访问，对内容片段通过接口识别能力，
+// 这样依赖方固定在不同上游版本时本包仍能编译，缺失的特性会被安全地忽略。
+// 新代码访问以下字段时应使用这里的辅助函数，而不是直接访问结构体字段。
+
+// 可能因上游版本不同而缺失的 CallOptions 字段
+const (
+	CallOptionMetadata               = "Metadata"
+	CallOptionResponseMIMEType       = "ResponseMIMEType"
+	CallOptionStreamingReasoningFunc = "StreamingReasoningFunc"
+	CallOptionJSONMode               = "JSONMode"
+	CallOptionToolChoice             = "ToolChoice"
+)
+
+// UpstreamFeatures 当前编译使用的上游版本支持的特性
+type UpstreamFeatures struct {
+	// Metadata CallOptions.Metadata，请求标签等特性依赖它传递额外字段
+	Metadata bool `json:"metadata"`
+	// ResponseMIMEType CallOptions.ResponseMIMEType
+	ResponseMIMEType bool `json:"response_mime_type"`
+	// StreamingReasoningFunc CallOptions.StreamingReasoningFunc，推理内容流式输出
+	StreamingReasoningFunc bool `json:"streaming_reasoning_func"`
+	// ReasoningContent ContentChoice.ReasoningContent
+	ReasoningContent bool `json:"reasoning_content"`
+}
+
+var (
+	upstreamFeaturesOnce sync.Once
+	upstreamFeatures     UpstreamFeatures
+)
+
+// DetectUpstreamFeatures 检测当前上游版本支持的特性，结果会被缓存
+func DetectUpstreamFeatures() UpstreamFeatures {
+	upstreamFeaturesOnce.Do(func() {
+		options := reflect.TypeOf(llms.CallOptions{})
+		choice := reflect.TypeOf(llms.ContentChoice{})
+		upstreamFeatures = UpstreamFeatures{
+			Metadata:               hasField(options, CallOptionMetadata),
+			ResponseMIMEType:       hasField(options, CallOptionResponseMIMEType),
+			StreamingReasoningFunc: hasField(options, CallOptionStreamingReasoningFunc),
+			ReasoningContent:       hasField(choice, "ReasoningContent"),
+		}
+	})
+	return upstreamFeatures
+}
+
+func hasField(t reflect.Type, name string) bool {
+	_, ok := t.FieldByName(name)
+	return ok
+}
+
+// GetCallOption 按字段名读取调用选项，字段在当前上游版本中不存在时返回 false
+func GetCallOption(opts llms.CallOptions, name string) (interface{}, bool) {
+	field := reflect.ValueOf(opts).FieldByName(name)
+	if !field.IsValid() {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+// SetCallOption 按字段名设置调用选项，字段不存在或类型不匹配时不做修改并返回 false
+func SetCallOption(opts *llms.CallOptions, name string, value interface{}) bool {
+	field := reflect.ValueOf(opts).Elem().FieldByName(name)
+	if !field.IsValid() || !field.CanSet() {
+		return false
+	}
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case v.Type().ConvertibleTo(field.Type()) && v.Kind() == field.Kind():
+		field.Set(v.Convert(field.Type()))
+	default:
+		return false
+	}
+	return true
+}
+
+// WithCallOption 返回按字段名设置选项的 CallOption，当前上游版本不支持该字段时忽略
+func WithCallOption(name string, value interface{}) llms.CallOption {
+	return func(o *llms.CallOptions) {
+		SetCallOption(o, name, value)
+	}
+}
+
+// CallMetadata 返回调用选项的 Metadata，上游版本不支持时返回 nil
+func CallMetadata(opts llms.CallOptions) map[string]interface{} {
+	value, _ := GetCallOption(opts, CallOptionMetadata)
+	metadata, _ := value.(map[string]interface{})
+	return metadata
+}
+
+// SetCallMetadata 设置调用选项的 Metadata，上游版本不支持时返回 false
+func SetCallMetadata(opts *llms.CallOptions, metadata map[string]interface{}) bool {
+	return SetCallOption(opts, CallOptionMetadata, metadata)
+}
+
+// ReasoningContent 返回回复中的推理内容，上游版本不支持时返回空字符串
+func ReasoningContent(choice *llms.ContentChoice) string {
+	if choice == nil {
+		return ""
+	}
+	field := reflect.ValueOf(choice).Elem().FieldByName("ReasoningContent")
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
+
+// PartKind 返回内容片段的类型名称，如 text、image_url、audio、binary、tool_call、tool_response；
+// 当前版本未知的片段类型返回其 Go 类型名
+func PartKind(part llms.ContentPart) string {
+	switch part.(type) {
+	case llms.TextContent:
+		return "text"
+	case llms.ImageURLContent:
+		return "image_url"
+	case llms.BinaryContent:
+		if _, ok := ParseAudioContent(part); ok {
+			return "audio"
+		}
+		return "binary"
+	case llms.ToolCall:
+		return "tool_call"
+	case llms.ToolCallResponse:
+		return "tool_response"
+	}
+	if part == nil {
+		return ""
+	}
+	return reflect.TypeOf(part).Name()
+}
+
+// PartText 提取内容片段中的文本，用于计数、缓存键、日志等只需要文本的场景
+// 除已知类型外，实现了 Text() 或 String() 方法的新片段类型也能被识别；
+// 其余片段返回 JSON 表示，ok 为 false
+func PartText(part llms.ContentPart) (text string, ok bool) {
+	switch p := part.(type) {
+	case llms.TextContent:
+		return p.Text, true
+	case llms.ToolCallResponse:
+		return p.Content, true
+	case llms.ToolCall:
+		if p.FunctionCall != nil {
+			return p.FunctionCall.Arguments, true
+		}
+		return "", true
+	case interface{ Text() string }:
+		return p.Text(), true
+	case llms.ImageURLContent, llms.BinaryContent:
+		return "", false
+	case interface{ String() string }:
+		return p.String(), true
+	}
+	data, err := json.Marshal(part)
+	if err != nil {
+		return "", false
+	}
+	return string(data), false
+}
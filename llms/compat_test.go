@@ -0,0 +1,67 @@
+package llms_test
+
+import (
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// callOptionMissing 是任何上游版本都不存在的字段
+const callOptionMissing = "NoSuchOption"
+
+// futurePart 模拟上游新增的内容片段类型
+type futurePart struct {
+	llms.TextContent
+	Format string
+}
+
+func TestUpstreamCompat(t *testing.T) {
+	features := llmscn.DetectUpstreamFeatures()
+
+	// 无论上游是否支持 Metadata，设置与读取都不应 panic，且结果与检测一致
+	var opts llms.CallOptions
+	ok := llmscn.SetCallMetadata(&opts, map[string]interface{}{"k": "v"})
+	assert.Equal(t, features.Metadata, ok)
+	if features.Metadata {
+		assert.Equal(t, "v", llmscn.CallMetadata(opts)["k"])
+	} else {
+		assert.Nil(t, llmscn.CallMetadata(opts))
+	}
+
+	llms.WithModel("m")(&opts)
+	llmscn.WithCallOption(callOptionMissing, true)(&opts)
+	model, ok := llmscn.GetCallOption(opts, "Model")
+	assert.True(t, ok)
+	assert.Equal(t, "m", model)
+	_, ok = llmscn.GetCallOption(opts, callOptionMissing)
+	assert.False(t, ok)
+
+	// 类型不匹配时不修改
+	assert.False(t, llmscn.SetCallOption(&opts, "Model", 1))
+	assert.True(t, llmscn.SetCallOption(&opts, "Temperature", 0.5))
+	assert.Equal(t, 0.5, opts.Temperature)
+
+	choice := &llms.ContentChoice{Content: "answer"}
+	assert.Empty(t, llmscn.ReasoningContent(choice))
+
+	tests := []struct {
+		part llms.ContentPart
+		kind string
+		text string
+		ok   bool
+	}{
+		{llms.TextContent{Text: "hi"}, "text", "hi", true},
+		{llms.ToolCallResponse{Content: "42"}, "tool_response", "42", true},
+		{llms.ToolCall{FunctionCall: &llms.FunctionCall{Arguments: `{"a":1}`}}, "tool_call", `{"a":1}`, true},
+		{llms.ImageURLContent{URL: "http://img"}, "image_url", "", false},
+		{futurePart{TextContent: llms.TextContent{Text: "transcript"}}, "futurePart", "transcript", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.kind, llmscn.PartKind(tt.part))
+		text, ok := llmscn.PartText(tt.part)
+		assert.Equal(t, tt.text, text)
+		assert.Equal(t, tt.ok, ok)
+	}
+}
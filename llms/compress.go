@@ -0,0 +1,437 @@
+package llms
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// CompressionOptions 提示词压缩配置
+type CompressionOptions struct {
+	// Ratio 压缩后保留的token比例，取值 (0, 1]，默认0.5
+	Ratio float64
+	// MinTokens 上下文总长度不超过该token数时不压缩，默认200
+	MinTokens int
+	// MinQueryCoverage 质量护栏：问题中在原文出现过的关键词，压缩后至少保留的比例，默认0.8。
+	// 不满足时补回包含缺失关键词的句子，实际保留比例可能高于 Ratio；设为负数关闭
+	MinQueryCoverage float64
+	// Scorer 句子打分器，默认使用本地启发式打分 HeuristicScorer，也可通过 NewModelScorer 调用廉价模型打分
+	Scorer SentenceScorer
+	// TokenCounter 计算token数，默认按中日韩字符1个token、其余每4个字符1个token粗略估算
+	TokenCounter func(text string) int
+}
+
+// SentenceScorer 为句子打分，分数越高表示与问题越相关、信息量越大
+type SentenceScorer interface {
+	ScoreSentences(ctx context.Context, query string, sentences []string) ([]float64, error)
+}
+
+// CompressionResult 压缩结果与质量指标
+type CompressionResult struct {
+	// Contexts 与输入一一对应的压缩后文本，句子保持原有顺序，所有句子都被移除时为空字符串
+	Contexts []string `json:"contexts"`
+	// OriginalTokens 压缩前的token数
+	OriginalTokens int `json:"original_tokens"`
+	// CompressedTokens 压缩后的token数
+	CompressedTokens int `json:"compressed_tokens"`
+	// Ratio 实际保留的token比例
+	Ratio float64 `json:"ratio"`
+	// QueryCoverage 问题关键词的保留比例，原文不包含问题关键词时为1
+	QueryCoverage float64 `json:"query_coverage"`
+	// MissingTerms 压缩后丢失的问题关键词
+	MissingTerms []string `json:"missing_terms,omitempty"`
+	// SentencesKept 保留的句子数
+	SentencesKept int `json:"sentences_kept"`
+	// SentencesDropped 移除的句子数，包括重复的句子
+	SentencesDropped int `json:"sentences_dropped"`
+}
+
+// Text 返回以空行连接的非空压缩文本，可直接放入提示词
+func (r *CompressionResult) Text() string {
+	parts := make([]string, 0, len(r.Contexts))
+	for _, text := range r.Contexts {
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// PromptCompressor 提示词压缩器（LLMLingua 风格）
+// 将检索到的长上下文切分为句子，按与问题的相关性和信息量打分，在token预算内保留得分最高的句子并去除重复内容，
+// 降低 RAG 场景的token成本。结果中的 QueryCoverage 等指标用于衡量压缩对回答质量的影响
+type PromptCompressor struct {
+	options CompressionOptions
+}
+
+// NewPromptCompressor 创建提示词压缩器
+func NewPromptCompressor(options CompressionOptions) *PromptCompressor {
+	if options.Ratio <= 0 || options.Ratio > 1 {
+		options.Ratio = 0.5
+	}
+	if options.MinTokens == 0 {
+		options.MinTokens = 200
+	}
+	if options.MinQueryCoverage == 0 {
+		options.MinQueryCoverage = 0.8
+	}
+	if options.Scorer == nil {
+		options.Scorer = HeuristicScorer{}
+	}
+	if options.TokenCounter == nil {
+		options.TokenCounter = estimateTokens
+	}
+	return &PromptCompressor{options: options}
+}
+
+// compressionSentence 参与压缩的句子
+type compressionSentence struct {
+	context   int
+	text      string
+	tokens    int
+	score     float64
+	duplicate bool
+	kept      bool
+}
+
+// Compress 按问题压缩一组检索到的上下文
+func (c *PromptCompressor) Compress(ctx context.Context, query string, contexts ...string) (*CompressionResult, error) {
+	result := &CompressionResult{Contexts: make([]string, len(contexts))}
+
+	var sentences []*compressionSentence
+	seen := make(map[string]bool)
+	for i, text := range contexts {
+		result.OriginalTokens += c.options.TokenCounter(text)
+		for _, sentence := range splitSentences(text) {
+			key := strings.ToLower(strings.Join(strings.Fields(sentence), " "))
+			sentences = append(sentences, &compressionSentence{
+				context:   i,
+				text:      sentence,
+				tokens:    c.options.TokenCounter(sentence),
+				duplicate: seen[key],
+			})
+			seen[key] = true
+		}
+	}
+
+	terms := queryTerms(query, strings.Join(contexts, "\n"))
+
+	// 较短的上下文压缩收益有限，原样返回
+	if result.OriginalTokens <= c.options.MinTokens {
+		copy(result.Contexts, contexts)
+		result.CompressedTokens = result.OriginalTokens
+		result.Ratio = 1
+		result.SentencesKept = len(sentences)
+		result.QueryCoverage, result.MissingTerms = termCoverage(terms, strings.Join(contexts, "\n"))
+		return result, nil
+	}
+
+	texts := make([]string, len(sentences))
+	for i, s := range sentences {
+		texts[i] = s.text
+	}
+	scores, err := c.options.Scorer.ScoreSentences(ctx, query, texts)
+	if err != nil {
+		return nil, fmt.Errorf("句子打分失败: %w", err)
+	}
+	if len(scores) != len(sentences) {
+		return nil, fmt.Errorf("句子打分数量不匹配: 期望 %d，实际 %d", len(sentences), len(scores))
+	}
+	for i, s := range sentences {
+		s.score = scores[i]
+	}
+
+	// 按得分从高到低在预算内选取句子，放不下的句子跳过，继续尝试更短的句子
+	ranked := make([]*compressionSentence, 0, len(sentences))
+	for _, s := range sentences {
+		if !s.duplicate {
+			ranked = append(ranked, s)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	budget := int(math.Round(float64(result.OriginalTokens) * c.options.Ratio))
+	used := 0
+	for _, s := range ranked {
+		if used+s.tokens <= budget || used == 0 {
+			s.kept = true
+			used += s.tokens
+		}
+	}
+
+	// 质量护栏：补回包含缺失关键词的最高分句子
+	if c.options.MinQueryCoverage > 0 {
+		for {
+			coverage, missing := termCoverage(terms, keptText(sentences))
+			if coverage >= c.options.MinQueryCoverage || len(missing) == 0 {
+				break
+			}
+			added := false
+			for _, term := range missing {
+				for _, s := range ranked {
+					if !s.kept && strings.Contains(strings.ToLower(s.text), term) {
+						s.kept = true
+						added = true
+						break
+					}
+				}
+				if added {
+					break
+				}
+			}
+			if !added {
+				break
+			}
+		}
+	}
+
+	// 按原有顺序重新组装每个上下文
+	builders := make([]strings.Builder, len(contexts))
+	for _, s := range sentences {
+		if !s.kept {
+			result.SentencesDropped++
+			continue
+		}
+		result.SentencesKept++
+		b := &builders[s.context]
+		if b.Len() > 0 && needsSpace(b.String(), s.text) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(s.text)
+	}
+	for i := range builders {
+		result.Contexts[i] = builders[i].String()
+		result.CompressedTokens += c.options.TokenCounter(result.Contexts[i])
+	}
+	if result.OriginalTokens > 0 {
+		result.Ratio = float64(result.CompressedTokens) / float64(result.OriginalTokens)
+	}
+	result.QueryCoverage, result.MissingTerms = termCoverage(terms, strings.Join(result.Contexts, "\n"))
+	return result, nil
+}
+
+// keptText 连接已保留的句子
+func keptText(sentences []*compressionSentence) string {
+	var b strings.Builder
+	for _, s := range sentences {
+		if s.kept {
+			b.WriteString(s.text)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// needsSpace 判断拉丁文字的句子之间是否需要空格
+func needsSpace(prev, next string) bool {
+	last := []rune(prev)[len([]rune(prev))-1]
+	first := []rune(next)[0]
+	return last < unicode.MaxASCII && first < unicode.MaxASCII
+}
+
+// splitSentences 按中英文句末标点和换行切分句子，标点保留在句末
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	flush := func(end int) {
+		if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+	for i, r := range runes {
+		switch r {
+		case '。', '！', '？', '；', '!', '?', ';':
+			flush(i + 1)
+		case '\n':
+			flush(i + 1)
+		case '.':
+			// 后面没有空白的句点（如小数点、网址）不切分
+			if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+				flush(i + 1)
+			}
+		}
+	}
+	flush(len(runes))
+	return sentences
+}
+
+// chineseStopChars 切分中文关键词时视为分隔符的虚词
+const chineseStopChars = "的了是在和与及或吗呢吧啊么哪些这那个什怎如何为请"
+
+// englishStopWords 不作为关键词的英文常用词
+var englishStopWords = map[string]bool{
+	"the": true, "a": true, "an": true, "of": true, "to": true, "in": true, "on": true, "for": true,
+	"and": true, "or": true, "is": true, "are": true, "was": true, "were": true, "be": true, "by": true,
+	"with": true, "as": true, "at": true, "it": true, "this": true, "that": true, "what": true,
+	"which": true, "who": true, "how": true, "why": true, "when": true, "where": true, "do": true,
+	"does": true, "did": true, "from": true, "can": true, "about": true,
+}
+
+// extractTerms 提取文本中的关键词：英文单词与数字（小写），以及中文相邻两字组成的词
+func extractTerms(text string) []string {
+	var terms []string
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if w := strings.ToLower(string(word)); len(word) > 1 && !englishStopWords[w] || len(word) == 1 && unicode.IsDigit(word[0]) {
+			terms = append(terms, w)
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			terms = append(terms, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			terms = append(terms, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) && !strings.ContainsRune(chineseStopChars, r):
+			flushWord()
+			han = append(han, r)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
+
+// queryTerms 返回问题中在原文出现过的关键词（去重）
+func queryTerms(query, original string) []string {
+	original = strings.ToLower(original)
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range extractTerms(query) {
+		if !seen[term] && strings.Contains(original, term) {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// termCoverage 计算关键词在文本中的保留比例与缺失的关键词
+func termCoverage(terms []string, text string) (float64, []string) {
+	if len(terms) == 0 {
+		return 1, nil
+	}
+	text = strings.ToLower(text)
+	var missing []string
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			missing = append(missing, term)
+		}
+	}
+	return float64(len(terms)-len(missing)) / float64(len(terms)), missing
+}
+
+// HeuristicScorer 本地启发式句子打分，无需调用模型
+// 综合问题关键词命中（按逆文档频率加权，罕见词权重更高）、关键词密度以及是否包含数字
+type HeuristicScorer struct{}
+
+// ScoreSentences 实现 SentenceScorer 接口
+func (HeuristicScorer) ScoreSentences(ctx context.Context, query string, sentences []string) ([]float64, error) {
+	sentenceTerms := make([]map[string]bool, len(sentences))
+	df := make(map[string]int)
+	for i, sentence := range sentences {
+		sentenceTerms[i] = make(map[string]bool)
+		for _, term := range extractTerms(sentence) {
+			if !sentenceTerms[i][term] {
+				sentenceTerms[i][term] = true
+				df[term]++
+			}
+		}
+	}
+
+	idf := func(term string) float64 {
+		return math.Log(1 + float64(len(sentences))/float64(1+df[term]))
+	}
+	var queryWeight float64
+	qTerms := make(map[string]bool)
+	for _, term := range extractTerms(query) {
+		if !qTerms[term] {
+			qTerms[term] = true
+			queryWeight += idf(term)
+		}
+	}
+
+	scores := make([]float64, len(sentences))
+	for i, sentence := range sentences {
+		var relevance float64
+		for term := range qTerms {
+			if sentenceTerms[i][term] {
+				relevance += idf(term)
+			}
+		}
+		if queryWeight > 0 {
+			relevance /= queryWeight
+		}
+
+		density := float64(len(sentenceTerms[i])) / float64(estimateTokens(sentence)+1)
+		if density > 1 {
+			density = 1
+		}
+		score := 2*relevance + density
+		if strings.IndexFunc(sentence, unicode.IsDigit) >= 0 {
+			score += 0.2
+		}
+		scores[i] = score
+	}
+	return scores, nil
+}
+
+// ModelScorer 调用模型为句子打分，适合使用价格较低的小模型
+type ModelScorer struct {
+	model llms.Model
+}
+
+// NewModelScorer 创建基于模型的句子打分器
+func NewModelScorer(model llms.Model) *ModelScorer {
+	return &ModelScorer{model: model}
+}
+
+// ScoreSentences 实现 SentenceScorer 接口
+func (s *ModelScorer) ScoreSentences(ctx context.Context, query string, sentences []string) ([]float64, error) {
+	var prompt strings.Builder
+	prompt.WriteString("请判断下列每个句子对回答问题的帮助程度，按0到10打分：与问题无关或没有实际信息的句子打0分，包含答案所需关键信息的句子打10分。\n")
+	fmt.Fprintf(&prompt, "问题：%s\n句子：\n", query)
+	for i, sentence := range sentences {
+		fmt.Fprintf(&prompt, "%d. %s\n", i+1, sentence)
+	}
+	fmt.Fprintf(&prompt, "只输出包含 %d 个数字的JSON数组，顺序与句子编号一致，不要输出其他内容。", len(sentences))
+
+	resp, err := s.model.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt.String()),
+	}, llms.WithTemperature(0))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("模型没有返回打分结果")
+	}
+
+	var scores []float64
+	if err := ParseJSONOutput("prompt_compressor", resp.Choices[0].Content, &scores); err != nil {
+		return nil, err
+	}
+	if len(scores) != len(sentences) {
+		return nil, fmt.Errorf("模型返回 %d 个分数，期望 %d 个", len(scores), len(sentences))
+	}
+	return scores, nil
+}
@@ -0,0 +1,89 @@
+package llms_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retrievedContexts 模拟检索到的文档：相关事实夹杂在大量无关内容中
+var retrievedContexts = []string{
+	"欢迎阅读本公司的产品手册。本手册内容仅供参考，如有变更恕不另行通知。退货政策规定，商品签收后7天内可以无理由退货。" +
+		"我们致力于为客户提供优质的服务体验。感谢您一直以来的支持与信任。",
+	"欢迎阅读本公司的产品手册。会员等级分为普通、银卡和金卡三档，每年一月统一调整。" +
+		"金卡会员的退货期限延长至30天。活动最终解释权归本公司所有。如需帮助请联系在线客服。",
+}
+
+func TestPromptCompressor(t *testing.T) {
+	ctx := context.Background()
+	compressor := llmscn.NewPromptCompressor(llmscn.CompressionOptions{Ratio: 0.4, MinTokens: 10})
+
+	result, err := compressor.Compress(ctx, "金卡会员的退货期限是多少天？", retrievedContexts...)
+	require.NoError(t, err)
+	require.Len(t, result.Contexts, 2)
+
+	text := result.Text()
+	assert.Contains(t, text, "金卡会员的退货期限延长至30天。")
+	assert.NotContains(t, text, "感谢您一直以来的支持与信任")
+	// 重复的句子只保留一次
+	assert.LessOrEqual(t, strings.Count(text, "欢迎阅读本公司的产品手册"), 1)
+	assert.Less(t, result.CompressedTokens, result.OriginalTokens)
+	assert.InDelta(t, float64(result.CompressedTokens)/float64(result.OriginalTokens), result.Ratio, 1e-9)
+	assert.Equal(t, 1.0, result.QueryCoverage)
+	assert.Equal(t, 10, result.SentencesKept+result.SentencesDropped)
+
+	t.Run("关键词护栏", func(t *testing.T) {
+		// 预算只够一句时补回包含缺失关键词的句子
+		strict := llmscn.NewPromptCompressor(llmscn.CompressionOptions{Ratio: 0.05, MinTokens: 10, MinQueryCoverage: 1})
+		result, err := strict.Compress(ctx, "退货政策和会员等级", retrievedContexts...)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, result.QueryCoverage)
+		assert.Contains(t, result.Text(), "退货政策")
+		assert.Contains(t, result.Text(), "会员等级")
+
+		// 关闭护栏时按预算压缩，并报告丢失的关键词
+		loose := llmscn.NewPromptCompressor(llmscn.CompressionOptions{Ratio: 0.05, MinTokens: 10, MinQueryCoverage: -1})
+		result, err = loose.Compress(ctx, "退货政策和会员等级", retrievedContexts...)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.SentencesKept)
+		assert.Less(t, result.QueryCoverage, 1.0)
+		assert.NotEmpty(t, result.MissingTerms)
+	})
+
+	t.Run("短上下文不压缩", func(t *testing.T) {
+		result, err := llmscn.NewPromptCompressor(llmscn.CompressionOptions{}).Compress(ctx, "退货", retrievedContexts...)
+		require.NoError(t, err)
+		assert.Equal(t, retrievedContexts, result.Contexts)
+		assert.Equal(t, 1.0, result.Ratio)
+	})
+
+	t.Run("英文句子", func(t *testing.T) {
+		result, err := compressor.Compress(ctx, "What is the refund window for gold members?",
+			"Thanks for reading. Gold members can request a refund within 30 days. Our office is open on weekdays. Have a nice day.")
+		require.NoError(t, err)
+		assert.Equal(t, "Gold members can request a refund within 30 days.", result.Contexts[0])
+	})
+}
+
+func TestModelScorer(t *testing.T) {
+	model := &scriptedModel{replies: []string{"```json\n[0, 9, 1]\n```"}}
+	compressor := llmscn.NewPromptCompressor(llmscn.CompressionOptions{
+		Ratio:     0.3,
+		MinTokens: 1,
+		Scorer:    llmscn.NewModelScorer(model),
+	})
+
+	result, err := compressor.Compress(context.Background(), "北京的天气", "以下是今天的新闻。北京今天晴，气温二十五度。其他城市多云。")
+	require.NoError(t, err)
+	assert.Equal(t, "北京今天晴，气温二十五度。", result.Contexts[0])
+	require.Len(t, model.received, 1)
+
+	// 分数数量与句子不一致时返回错误
+	model.replies = []string{"[1, 2]"}
+	_, err = compressor.Compress(context.Background(), "北京的天气", "以下是今天的新闻。北京今天晴，气温二十五度。其他城市多云。")
+	assert.ErrorContains(t, err, "期望 3 个")
+}
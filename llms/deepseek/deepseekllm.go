@@ -8,6 +8,9 @@ import (
 	"os"
 
 	"github.com/sjzsdu/langchaingo-cn/llms/deepseek/internal/deepseekclient"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
 )
@@ -83,7 +86,7 @@ func newClient(opts ...Option) (*deepseekclient.Client, error) {
 		options.APIKey,
 		options.BaseURL,
 		options.Model,
-		options.HTTPClient,
+		retry.Wrap(ratelimit.Wrap(options.HTTPClient, options.RateLimiter), retry.Policy{MaxRetries: options.MaxRetries, Backoff: options.RetryBackoff}),
 	)
 }
 
@@ -143,9 +146,14 @@ func generateMessagesContent(ctx context.Context, o *LLM, messages []llms.Messag
 		ToolChoice:       convertToolChoice(opts.ToolChoice),
 	}
 
-	// 处理JSON模式
+	// 流式调用时请求在最后一个数据块中返回用量
+	if request.Stream {
+		request.StreamOptions = &deepseekclient.StreamOptions{IncludeUsage: true}
+	}
+
+	// 处理JSON模式，DeepSeek 通过 response_format 启用，提示词中需要包含 json 字样
 	if opts.JSONMode {
-		request.JSONMode = true
+		request.ResponseFormat = &deepseekclient.ResponseFormat{Type: "json_object"}
 	}
 
 	// 处理种子
@@ -213,6 +221,11 @@ func generateMessagesContent(ctx context.Context, o *LLM, messages []llms.Messag
 			}
 		}
 
+		// 处理用量，多个选择共享同一次请求的用量
+		if resp.Usage != nil {
+			contentChoice.GenerationInfo = usage.Set(nil, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.Usage.TotalTokens)
+		}
+
 		contentResponse.Choices[i] = contentChoice
 	}
 
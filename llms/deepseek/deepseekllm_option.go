@@ -2,8 +2,10 @@ package deepseek
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/sjzsdu/langchaingo-cn/llms/deepseek/internal/deepseekclient"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
 	"github.com/tmc/langchaingo/callbacks"
 )
 
@@ -14,6 +16,12 @@ type Options struct {
 	BaseURL          string
 	HTTPClient       deepseekclient.Doer
 	CallbacksHandler interface{}
+	// MaxRetries is the maximum number of retries on 429, 5xx or timeouts.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled on each retry.
+	RetryBackoff time.Duration
+	// RateLimiter limits the request rate, possibly shared with other clients.
+	RateLimiter *ratelimit.Limiter
 }
 
 // Option is a function that configures a DeepSeek LLM.
@@ -47,6 +55,31 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithMaxRetries sets the maximum number of retries when a request is rate limited (429),
+// fails with a server error (5xx) or times out. Requests are not retried by default.
+// Retries wait with jittered exponential backoff and honor the Retry-After header.
+func WithMaxRetries(n int) Option {
+	return func(o *Options) {
+		o.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff sets the wait before the first retry, doubled on each retry. Defaults to 500ms.
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *Options) {
+		o.RetryBackoff = backoff
+	}
+}
+
+// WithRateLimiter sets a rate limiter that may be shared across clients, see llmscn.NewRateLimiter.
+// Every request, including retries, waits for a token and fails with llmscn.ErrRateLimited
+// when the limiter's queue limits are exceeded.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *Options) {
+		o.RateLimiter = limiter
+	}
+}
+
 // WithCallbacksHandler sets the callbacks handler to use.
 func WithCallbacksHandler(callbacksHandler callbacks.Handler) Option {
 	return func(o *Options) {
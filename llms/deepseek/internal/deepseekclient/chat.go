@@ -62,6 +62,8 @@ type ChatRequest struct {
 	PresencePenalty float64 `json:"presence_penalty,omitempty"`
 	// Stream indicates whether to stream the response.
 	Stream bool `json:"stream,omitempty"`
+	// StreamOptions configures the streaming response.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
 	// StreamingFunc is a function to be called for each chunk of a streaming response.
 	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
 	// StreamingReasoningFunc is a function to be called for each chunk of a streaming reasoning response.
@@ -82,6 +84,12 @@ type ChatRequest struct {
 	Seed int `json:"seed,omitempty"`
 }
 
+// StreamOptions configures the streaming response.
+type StreamOptions struct {
+	// IncludeUsage requests a final chunk carrying the token usage.
+	IncludeUsage bool `json:"include_usage"`
+}
+
 // ResponseFormat specifies the format of the response.
 type ResponseFormat struct {
 	// Type is the format type (text, json_object).
@@ -164,6 +172,8 @@ type ChatResponseMessage struct {
 
 // ToolCall represents a tool call in a chat completion response.
 type ToolCall struct {
+	// Index is the position of the tool call, present in streaming deltas.
+	Index *int `json:"index,omitempty"`
 	// ID is the unique identifier for the tool call.
 	ID string `json:"id"`
 	// Type is the type of the tool call (function).
@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/toolcall"
 )
 
 const (
@@ -35,61 +37,6 @@ type Client struct {
 	httpClient Doer
 }
 
-// updateToolCalls updates the tool calls in a message with new tool calls from a delta.
-func (c *Client) updateToolCalls(message *ChatResponseMessage, deltaToolCalls []ToolCall) {
-	// If there are no existing tool calls, initialize the slice
-	if message.ToolCalls == nil {
-		message.ToolCalls = make([]ToolCall, 0)
-	}
-
-	// Process each delta tool call
-	for _, deltaToolCall := range deltaToolCalls {
-		// Check if this is a new tool call or an update to an existing one
-		var existingToolCall *ToolCall
-		for i := range message.ToolCalls {
-			if message.ToolCalls[i].ID == deltaToolCall.ID {
-				existingToolCall = &message.ToolCalls[i]
-				break
-			}
-		}
-
-		// If it's a new tool call, add it to the list
-		if existingToolCall == nil {
-			message.ToolCalls = append(message.ToolCalls, ToolCall{
-				ID:   deltaToolCall.ID,
-				Type: deltaToolCall.Type,
-				Function: &FunctionCall{
-					Name:      deltaToolCall.Function.Name,
-					Arguments: deltaToolCall.Function.Arguments,
-				},
-			})
-		} else {
-			// Update the existing tool call
-			if deltaToolCall.Type != "" {
-				existingToolCall.Type = deltaToolCall.Type
-			}
-
-			// Update the function if available
-			if deltaToolCall.Function != nil {
-				// Initialize the function if it doesn't exist
-				if existingToolCall.Function == nil {
-					existingToolCall.Function = &FunctionCall{}
-				}
-
-				// Update the function name if available
-				if deltaToolCall.Function.Name != "" {
-					existingToolCall.Function.Name = deltaToolCall.Function.Name
-				}
-
-				// Update the function arguments if available
-				if deltaToolCall.Function.Arguments != "" {
-					existingToolCall.Function.Arguments += deltaToolCall.Function.Arguments
-				}
-			}
-		}
-	}
-}
-
 // New creates a new DeepSeek API client.
 func New(apiKey, baseURL string, model string, httpClient Doer) (*Client, error) {
 	if apiKey == "" {
@@ -209,6 +156,7 @@ func (c *Client) createChatStream(
 	reader := bufio.NewReader(resp.Body)
 	var finalResponse ChatResponse
 	var reasoningContent, content string
+	toolCalls := toolcall.New()
 
 	for {
 		line, err := reader.ReadString('\n')
@@ -241,6 +189,11 @@ func (c *Client) createChatStream(
 		finalResponse.SystemFingerprint = streamResp.SystemFingerprint
 		finalResponse.Object = "chat.completion"
 
+		// 用量在最后一个数据块中返回，该数据块的 choices 为空
+		if streamResp.Usage != nil {
+			finalResponse.Usage = streamResp.Usage
+		}
+
 		// 处理增量内容
 		if len(streamResp.Choices) > 0 {
 			choice := streamResp.Choices[0]
@@ -277,9 +230,14 @@ func (c *Client) createChatStream(
 					}}
 				}
 
-				// 更新工具调用
-				if len(choice.Delta.ToolCalls) > 0 {
-					c.updateToolCalls(&finalResponse.Choices[0].Message, choice.Delta.ToolCalls)
+				// 累积工具调用增量
+				for _, tc := range choice.Delta.ToolCalls {
+					delta := toolcall.Delta{Index: tc.Index, ID: tc.ID, Type: tc.Type}
+					if tc.Function != nil {
+						delta.Name = tc.Function.Name
+						delta.Arguments = tc.Function.Arguments
+					}
+					toolCalls.Add(delta)
 				}
 			}
 
@@ -308,6 +266,18 @@ func (c *Client) createChatStream(
 		finalResponse.Choices[0].Message.Content = content
 	}
 
+	// 添加工具调用（如果有）
+	for _, tc := range toolCalls.ToolCalls() {
+		finalResponse.Choices[0].Message.ToolCalls = append(finalResponse.Choices[0].Message.ToolCalls, ToolCall{
+			ID:   tc.ID,
+			Type: tc.Type,
+			Function: &FunctionCall{
+				Name:      tc.FunctionCall.Name,
+				Arguments: tc.FunctionCall.Arguments,
+			},
+		})
+	}
+
 	// 添加推理内容（如果有）
 	if reasoningContent != "" {
 		finalResponse.Choices[0].Message.ReasoningContent = reasoningContent
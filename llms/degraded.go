@@ -0,0 +1,164 @@
+package llms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// DegradedKey 降级回复在 GenerationInfo 中记录为 true，上游界面可据此区分降级回复
+const DegradedKey = "degraded"
+
+// DegradationIncidentKey 降级回复的事件信息在 GenerationInfo 中的键，值为 DegradationIncident
+const DegradationIncidentKey = "degradation_incident"
+
+// IncidentIDPlaceholder 降级回复中的占位符，替换为事件编号，便于用户反馈时定位日志
+const IncidentIDPlaceholder = "{incident_id}"
+
+// defaultDegradationMessages 内置的降级回复，按回复语言区分
+var defaultDegradationMessages = map[string]string{
+	LanguageChinese: "抱歉，服务暂时繁忙，请稍后再试。（事件编号：" + IncidentIDPlaceholder + "）",
+	LanguageEnglish: "Sorry, the service is temporarily unavailable. Please try again later. (Incident ID: " + IncidentIDPlaceholder + ")",
+}
+
+// DegradationIncident 一次降级的事件信息
+type DegradationIncident struct {
+	ID        string    `json:"id"`
+	Error     string    `json:"error"`
+	Language  string    `json:"language"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DegradationOptions 降级配置
+type DegradationOptions struct {
+	// Messages 按回复语言（"zh"、"en"）配置的降级回复，可包含 IncidentIDPlaceholder；未配置的语言使用内置文案
+	Messages map[string]string
+	// Language 默认回复语言，调用未通过 WithResponseLanguage 指定语言时使用，默认为 "zh"
+	Language string
+	// ShouldDegrade 判断错误是否需要降级，默认对调用方取消或超时以外的所有错误降级
+	ShouldDegrade func(err error) bool
+	// OnDegrade 降级时同步调用，用于告警和记录原始错误
+	OnDegrade func(ctx context.Context, incident DegradationIncident)
+}
+
+// DegradedModel 服务降级装饰器
+// 服务商彻底不可用时（重试和故障转移之后仍然失败），将错误转换为可配置的友好回复，而不是把错误暴露给终端用户。
+// 降级回复的 GenerationInfo 中 DegradedKey 为 true，DegradationIncidentKey 记录事件编号与原始错误。
+// 应放在重试、故障转移等装饰器的外层。流式调用在已输出部分内容后失败时无法降级，仍返回原始错误
+type DegradedModel struct {
+	model   llms.Model
+	options DegradationOptions
+}
+
+var _ llms.Model = (*DegradedModel)(nil)
+
+// NewDegradedModel 创建服务降级装饰器
+func NewDegradedModel(model llms.Model, options DegradationOptions) *DegradedModel {
+	if options.Language == "" {
+		options.Language = LanguageChinese
+	}
+	return &DegradedModel{
+		model:   model,
+		options: options,
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *DegradedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	// 记录是否已向调用方输出内容
+	streamed := false
+	if opts.StreamingFunc != nil {
+		streamingFunc := opts.StreamingFunc
+		options = append(options[:len(options):len(options)], llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return streamingFunc(ctx, chunk)
+		}))
+	}
+
+	resp, err := m.model.GenerateContent(ctx, messages, options...)
+	if err == nil || streamed || !m.shouldDegrade(ctx, err) {
+		return resp, err
+	}
+
+	language := ResponseLanguage(opts)
+	if language == "" {
+		language = m.options.Language
+	}
+	incident := DegradationIncident{
+		ID:        newIncidentID(),
+		Error:     err.Error(),
+		Language:  language,
+		Timestamp: time.Now(),
+	}
+	if m.options.OnDegrade != nil {
+		m.options.OnDegrade(ctx, incident)
+	}
+
+	content := strings.ReplaceAll(m.message(language), IncidentIDPlaceholder, incident.ID)
+	if opts.StreamingFunc != nil {
+		if err := opts.StreamingFunc(ctx, []byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:    content,
+		StopReason: "stop",
+		GenerationInfo: map[string]any{
+			DegradedKey:            true,
+			DegradationIncidentKey: incident,
+		},
+	}}}, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *DegradedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// shouldDegrade 判断错误是否需要降级
+func (m *DegradedModel) shouldDegrade(ctx context.Context, err error) bool {
+	if m.options.ShouldDegrade != nil {
+		return m.options.ShouldDegrade(err)
+	}
+	// 调用方已放弃请求，降级回复不会被使用
+	return ctx.Err() == nil
+}
+
+// message 返回指定语言的降级回复
+func (m *DegradedModel) message(language string) string {
+	if message, ok := m.options.Messages[language]; ok && message != "" {
+		return message
+	}
+	if message, ok := defaultDegradationMessages[language]; ok {
+		return message
+	}
+	return defaultDegradationMessages[LanguageChinese]
+}
+
+// IsDegraded 判断回复是否为降级回复
+func IsDegraded(resp *llms.ContentResponse) bool {
+	if resp == nil || len(resp.Choices) == 0 {
+		return false
+	}
+	degraded, _ := resp.Choices[0].GenerationInfo[DegradedKey].(bool)
+	return degraded
+}
+
+// newIncidentID 生成带日期前缀的随机事件编号
+func newIncidentID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return time.Now().Format("20060102") + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,96 @@
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// outageModel 模拟不可用的服务商，可先输出部分内容再失败
+type outageModel struct {
+	partial string
+}
+
+func (m outageModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if m.partial != "" && opts.StreamingFunc != nil {
+		if err := opts.StreamingFunc(ctx, []byte(m.partial)); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("503 service unavailable")
+}
+
+func (m outageModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestDegradedModel(t *testing.T) {
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")}
+
+	var incidents []llmscn.DegradationIncident
+	model := llmscn.NewDegradedModel(outageModel{}, llmscn.DegradationOptions{
+		OnDegrade: func(ctx context.Context, incident llmscn.DegradationIncident) {
+			incidents = append(incidents, incident)
+		},
+	})
+
+	resp, err := model.GenerateContent(context.Background(), messages)
+	require.NoError(t, err)
+	assert.True(t, llmscn.IsDegraded(resp))
+	require.Len(t, incidents, 1)
+	assert.Equal(t, "503 service unavailable", incidents[0].Error)
+	assert.Contains(t, resp.Choices[0].Content, "服务暂时繁忙")
+	assert.Contains(t, resp.Choices[0].Content, incidents[0].ID)
+	assert.Equal(t, incidents[0], resp.Choices[0].GenerationInfo[llmscn.DegradationIncidentKey])
+
+	// 按调用要求的语言选择文案，流式调用同样输出降级回复
+	var chunks []string
+	resp, err = model.GenerateContent(context.Background(), messages,
+		llmscn.WithResponseLanguage(llmscn.LanguageEnglish),
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Choices[0].Content, "temporarily unavailable")
+	assert.Equal(t, []string{resp.Choices[0].Content}, chunks)
+
+	t.Run("自定义文案", func(t *testing.T) {
+		model := llmscn.NewDegradedModel(outageModel{}, llmscn.DegradationOptions{
+			Messages: map[string]string{llmscn.LanguageChinese: "小助手开小差了，请稍后再问（" + llmscn.IncidentIDPlaceholder + "）"},
+		})
+		reply, err := model.Call(context.Background(), "你好")
+		require.NoError(t, err)
+		assert.Contains(t, reply, "小助手开小差了")
+		assert.NotContains(t, reply, llmscn.IncidentIDPlaceholder)
+	})
+
+	t.Run("不降级的情况", func(t *testing.T) {
+		// 已输出部分内容
+		_, err := llmscn.NewDegradedModel(outageModel{partial: "你"}, llmscn.DegradationOptions{}).
+			GenerateContent(context.Background(), messages,
+				llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error { return nil }))
+		assert.EqualError(t, err, "503 service unavailable")
+
+		// 调用方已取消
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = model.GenerateContent(ctx, messages)
+		assert.Error(t, err)
+
+		// 自定义判断
+		_, err = llmscn.NewDegradedModel(outageModel{}, llmscn.DegradationOptions{
+			ShouldDegrade: func(err error) bool { return false },
+		}).GenerateContent(context.Background(), messages)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,237 @@
+// Package doubao 提供了字节跳动豆包大模型（火山方舟）的Go语言客户端实现，使用方舟兼容OpenAI的接口
+package doubao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+const (
+	// 环境变量名
+	TokenEnvVarName      = "DOUBAO_API_KEY"     //nolint:gosec
+	ModelEnvVarName      = "DOUBAO_MODEL"       //nolint:gosec
+	EndpointIDEnvVarName = "DOUBAO_ENDPOINT_ID" //nolint:gosec
+
+	// OpenAI兼容模式基础URL
+	OpenAICompatibleBaseURL = "https://ark.cn-beijing.volces.com/api/v3"
+	// 默认模型
+	DefaultModel = ModelDoubao15Pro32K
+)
+
+// 方舟上可直接调用的模型ID，也可以使用在方舟控制台创建的推理接入点ID（ep- 开头）
+const (
+	// ModelDoubao15Pro32K 是豆包1.5 Pro 32K模型
+	ModelDoubao15Pro32K = "doubao-1-5-pro-32k-250115"
+
+	// ModelDoubao15Pro256K 是豆包1.5 Pro 256K长上下文模型
+	ModelDoubao15Pro256K = "doubao-1-5-pro-256k-250115"
+
+	// ModelDoubao15Lite32K 是豆包1.5 Lite 32K轻量级模型
+	ModelDoubao15Lite32K = "doubao-1-5-lite-32k-250115"
+
+	// ModelDoubao15VisionPro32K 是豆包1.5 视觉理解 Pro 模型，支持图片输入
+	ModelDoubao15VisionPro32K = "doubao-1-5-vision-pro-32k-250115"
+
+	// ModelDoubaoPro32K 是豆包 Pro 32K模型
+	ModelDoubaoPro32K = "doubao-pro-32k-241215"
+
+	// ModelDoubaoVisionPro32K 是豆包视觉理解 Pro 模型，支持图片输入
+	ModelDoubaoVisionPro32K = "doubao-vision-pro-32k-241028"
+)
+
+// LLM 是豆包大语言模型的实现
+type LLM struct {
+	*openai.LLM // 匿名嵌入OpenAI LLM，自动继承其所有方法
+
+	endpoints map[string]string // 模型名称到推理接入点ID的映射
+}
+
+// Option 是LLM的配置选项函数类型
+type Option func(*options)
+
+// options 是LLM的配置选项
+type options struct {
+	apiKey      string
+	baseURL     string
+	model       string
+	endpointID  string
+	endpoints   map[string]string
+	httpClient  *http.Client
+	retryPolicy retry.Policy
+	rateLimiter *ratelimit.Limiter
+}
+
+// WithAPIKey 设置API密钥
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithBaseURL 设置API基础URL，其他地域的方舟服务可通过该选项指定
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithModel 设置模型，可以是模型ID或推理接入点ID
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+// WithEndpointID 设置默认使用的推理接入点ID（ep- 开头），优先于 WithModel
+func WithEndpointID(endpointID string) Option {
+	return func(o *options) {
+		o.endpointID = endpointID
+	}
+}
+
+// WithEndpoints 设置模型名称到推理接入点ID的映射，
+// 调用时通过 llms.WithModel 指定的模型名称会被替换为对应的接入点ID，便于按模型名称切换接入点
+func WithEndpoints(endpoints map[string]string) Option {
+	return func(o *options) {
+		o.endpoints = endpoints
+	}
+}
+
+// WithHTTPClient 设置自定义的HTTP客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
+// defaultOptions 返回默认选项
+func defaultOptions() options {
+	return options{
+		apiKey:     os.Getenv(TokenEnvVarName),
+		baseURL:    OpenAICompatibleBaseURL,
+		model:      getEnvOrDefault(ModelEnvVarName, DefaultModel),
+		endpointID: os.Getenv(EndpointIDEnvVarName),
+	}
+}
+
+// getEnvOrDefault 获取环境变量值，如果不存在则返回默认值
+func getEnvOrDefault(envVar, defaultValue string) string {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// IsEndpointID 判断名称是否为推理接入点ID
+func IsEndpointID(name string) bool {
+	return strings.HasPrefix(name, "ep-")
+}
+
+// New 创建一个新的豆包 LLM实例
+func New(opts ...Option) (*LLM, error) {
+	options := defaultOptions()
+
+	// 应用选项
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// 验证API密钥
+	if options.apiKey == "" {
+		return nil, errors.New("API密钥不能为空，请设置DOUBAO_API_KEY环境变量或使用WithAPIKey选项")
+	}
+
+	model := options.model
+	if options.endpointID != "" {
+		model = options.endpointID
+	} else if endpoint, ok := options.endpoints[model]; ok {
+		model = endpoint
+	}
+
+	httpClient := options.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// 创建OpenAI客户端
+	openaiLLM, err := openai.New(
+		openai.WithToken(options.apiKey),
+		openai.WithModel(model),
+		openai.WithBaseURL(options.baseURL),
+		openai.WithHTTPClient(retry.Wrap(ratelimit.Wrap(httpClient, options.rateLimiter), options.retryPolicy)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("创建OpenAI客户端失败: %w", err)
+	}
+
+	return &LLM{LLM: openaiLLM, endpoints: options.endpoints}, nil
+}
+
+// GetModels 返回豆包常用的模型ID列表
+func (d *LLM) GetModels() []string {
+	return []string{
+		ModelDoubao15Pro32K,
+		ModelDoubao15Pro256K,
+		ModelDoubao15Lite32K,
+		ModelDoubao15VisionPro32K,
+		ModelDoubaoPro32K,
+		ModelDoubaoVisionPro32K,
+	}
+}
+
+// GenerateContent 生成内容，支持流式输出、工具调用和图片输入
+// 调用时指定的模型名称在接入点映射中时替换为对应的接入点ID
+func (d *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if len(d.endpoints) > 0 {
+		opts := llms.CallOptions{}
+		for _, opt := range options {
+			opt(&opts)
+		}
+		if endpoint, ok := d.endpoints[opts.Model]; ok {
+			options = append(options[:len(options):len(options)], llms.WithModel(endpoint))
+		}
+	}
+
+	resp, err := d.LLM.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	// openai 客户端只写入 PromptTokens 等键，补充统一的用量键
+	usage.Normalize(resp)
+	return resp, nil
+}
@@ -0,0 +1,151 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/doubao"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestDoubaoLLM(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("DOUBAO_ENDPOINT_ID", "")
+
+	var (
+		requests []map[string]any
+		reply    string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	newLLM := func(t *testing.T, params map[string]interface{}) llms.Model {
+		params["api_key"] = "test-key"
+		params["base_url"] = server.URL
+		llm, err := llmscn.CreateLLM(llmscn.DoubaoLLM, params)
+		require.NoError(t, err)
+		return llm
+	}
+
+	textReply := `{"id":"d-1","object":"chat.completion","created":1,"model":"doubao","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"你好"}}],"usage":{"prompt_tokens":6,"completion_tokens":2,"total_tokens":8}}`
+
+	t.Run("接入点映射", func(t *testing.T) {
+		requests = nil
+		reply = textReply
+
+		llm := newLLM(t, map[string]interface{}{
+			"endpoints": map[string]interface{}{"doubao-pro": "ep-20250101-pro", "doubao-lite": "ep-20250101-lite"},
+		})
+		_, err := llm.Call(ctx, "你好")
+		require.NoError(t, err)
+		_, err = llm.Call(ctx, "你好", llms.WithModel("doubao-pro"))
+		require.NoError(t, err)
+		_, err = llm.Call(ctx, "你好", llms.WithModel("ep-other"))
+		require.NoError(t, err)
+
+		require.Len(t, requests, 3)
+		assert.Equal(t, doubao.DefaultModel, requests[0]["model"])
+		assert.Equal(t, "ep-20250101-pro", requests[1]["model"])
+		assert.Equal(t, "ep-other", requests[2]["model"])
+	})
+
+	t.Run("接入点ID优先", func(t *testing.T) {
+		requests = nil
+		reply = textReply
+
+		llm := newLLM(t, map[string]interface{}{"model": doubao.ModelDoubaoPro32K, "endpoint_id": "ep-20250101-main"})
+		resp, err := llm.GenerateContent(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")})
+		require.NoError(t, err)
+		assert.Equal(t, "你好", resp.Choices[0].Content)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 6, 2, 8)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "ep-20250101-main", requests[0]["model"])
+	})
+
+	t.Run("图片输入", func(t *testing.T) {
+		requests = nil
+		reply = textReply
+
+		llm := newLLM(t, map[string]interface{}{"model": doubao.ModelDoubao15VisionPro32K})
+		_, err := llm.GenerateContent(ctx, []llms.MessageContent{{
+			Role: llms.ChatMessageTypeHuman,
+			Parts: []llms.ContentPart{
+				llms.ImageURLContent{URL: "https://example.com/cat.png"},
+				llms.TextContent{Text: "图片里是什么"},
+			},
+		}})
+		require.NoError(t, err)
+
+		require.Len(t, requests, 1)
+		messages := requests[0]["messages"].([]any)
+		content := messages[0].(map[string]any)["content"].([]any)
+		require.Len(t, content, 2)
+		assert.Equal(t, "image_url", content[0].(map[string]any)["type"])
+		assert.Equal(t, "https://example.com/cat.png", content[0].(map[string]any)["image_url"].(map[string]any)["url"])
+		assert.Equal(t, "text", content[1].(map[string]any)["type"])
+	})
+
+	t.Run("工具调用", func(t *testing.T) {
+		requests = nil
+		reply = `{"id":"d-2","object":"chat.completion","created":1,"model":"doubao","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"北京\"}"}}]}}],"usage":{"prompt_tokens":12,"completion_tokens":6,"total_tokens":18}}`
+
+		llm := newLLM(t, map[string]interface{}{})
+		resp, err := llm.GenerateContent(ctx,
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "北京天气怎么样")},
+			llms.WithTools([]llms.Tool{{
+				Type: "function",
+				Function: &llms.FunctionDefinition{
+					Name:       "get_weather",
+					Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+				},
+			}}))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices[0].ToolCalls, 1)
+		assert.Equal(t, "get_weather", resp.Choices[0].ToolCalls[0].FunctionCall.Name)
+		assert.JSONEq(t, `{"city":"北京"}`, resp.Choices[0].ToolCalls[0].FunctionCall.Arguments)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 12, 6, 18)
+
+		require.Len(t, requests, 1)
+		assert.Len(t, requests[0]["tools"], 1)
+	})
+
+	t.Run("流式输出", func(t *testing.T) {
+		requests = nil
+		reply = "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"你\"}}]}\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"好\"}}]}\n\n" +
+			"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+			"data: [DONE]\n\n"
+
+		var streamed strings.Builder
+		llm := newLLM(t, map[string]interface{}{})
+		resp, err := llm.GenerateContent(ctx,
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")},
+			llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+				streamed.Write(chunk)
+				return nil
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, "你好", resp.Choices[0].Content)
+		assert.Equal(t, "你好", streamed.String())
+	})
+}
@@ -11,6 +11,8 @@ import (
 
 	// add qwen
 	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
 )
 
 // EmbeddingType 表示向量模型类型
@@ -23,6 +25,16 @@ const (
 	HuggingFaceEmbedding EmbeddingType = "huggingface"
 	// qwen
 	QwenEmbedding EmbeddingType = "qwen"
+	// 智谱、硅基流动与任意OpenAI兼容端点，见 CreateEmbedder
+	ZhipuEmbedding       EmbeddingType = "zhipu"
+	SiliconFlowEmbedding EmbeddingType = "siliconflow"
+	RemoteEmbedding      EmbeddingType = "remote"
+)
+
+// 各服务商单次请求的默认文本数量，与服务商的批量上限保持一致
+const (
+	zhipuEmbeddingBatchSize       = 64
+	siliconFlowEmbeddingBatchSize = 32
 )
 
 // ErrUnsupportedEmbeddingType 表示不支持的Embedding类型错误
@@ -35,6 +47,8 @@ var ErrUnsupportedEmbeddingType = errors.New("不支持的Embedding类型")
 // - Ollama："server_url"(默认 http://localhost:11434)、"model"(默认 bge-m3)
 // - HuggingFace："api_key"、"model"(默认 sentence-transformers/all-MiniLM-L6-v2)、"task"(默认 feature-extraction)
 // - Qwen："api_key"、"model"(默认 qwen-max)、"embedding_model"(默认 text-embedding-v1)
+//
+// 新代码建议使用 CreateEmbedder，其参数 "model" 统一表示向量模型
 func CreateEmbedding(embType EmbeddingType, params map[string]interface{}) (embeddings.Embedder, error) {
 	switch embType {
 	case OpenAIEmbedding:
@@ -51,6 +65,15 @@ func CreateEmbedding(embType EmbeddingType, params map[string]interface{}) (embe
 }
 
 func createOpenAIEmbedding(params map[string]interface{}) (embeddings.Embedder, error) {
+	llm, err := createOpenAIEmbeddingLLM(params)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings.NewEmbedder(llm)
+}
+
+// createOpenAIEmbeddingLLM 创建用于生成向量的 OpenAI 客户端
+func createOpenAIEmbeddingLLM(params map[string]interface{}) (*openai.LLM, error) {
 	opts := []openai.Option{}
 
 	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
@@ -85,11 +108,7 @@ func createOpenAIEmbedding(params map[string]interface{}) (embeddings.Embedder,
 		opts = append(opts, openai.WithEmbeddingModel("text-embedding-3-large"))
 	}
 
-	llm, err := openai.New(opts...)
-	if err != nil {
-		return nil, err
-	}
-	return embeddings.NewEmbedder(llm)
+	return openai.New(opts...)
 }
 
 func createOllamaEmbedding(params map[string]interface{}) (embeddings.Embedder, error) {
@@ -161,3 +180,181 @@ func createQwenEmbedding(params map[string]interface{}) (embeddings.Embedder, er
 	}
 	return embeddings.NewEmbedder(llm)
 }
+
+// CreateEmbedder 创建指定类型的Embedding实例，与 CreateLLM 对应
+// embType: Embedding类型，支持 qwen、zhipu、siliconflow、remote、openai、ollama、huggingface
+// params: 创建参数，可由 schema 配置的 EmbeddingConfig 转换而来
+//
+// 通用参数：
+// - "api_key": API密钥，未设置时读取各服务商的环境变量（remote 类型为空时不发送鉴权头）
+// - "model": 向量模型名称（remote 类型必需），未设置时使用服务商的默认向量模型
+// - "base_url": API基础URL（remote 类型必需）；qwen 类型为 DashScope 原生接口地址
+// - "batch_size": 单次请求的文本数量，默认使用服务商的批量上限
+// - "strip_new_lines": 是否移除文本中的换行符（qwen 类型不支持）
+//
+// 特定参数：
+// - "dimension": 向量维度（仅 qwen 支持，需 text-embedding-v3 及以上模型）
+// - ollama 的 "server_url"、huggingface 的 "task" 同 CreateEmbedding
+func CreateEmbedder(embType EmbeddingType, params map[string]interface{}) (embeddings.Embedder, error) {
+	batchSize, err := intParam(params, "batch_size")
+	if err != nil {
+		return nil, err
+	}
+
+	switch embType {
+	case QwenEmbedding:
+		return createQwenEmbedder(params, batchSize)
+	case ZhipuEmbedding:
+		return createZhipuEmbedder(params, batchSize)
+	case SiliconFlowEmbedding:
+		return createSiliconFlowEmbedder(params, batchSize)
+	case RemoteEmbedding:
+		return createRemoteEmbedder(params, batchSize)
+	case OpenAIEmbedding:
+		params = withEmbeddingModelParam(params)
+		llm, err := createOpenAIEmbeddingLLM(params)
+		if err != nil {
+			return nil, err
+		}
+		return newBatchEmbedder(llm, params, batchSize)
+	case OllamaEmbedding:
+		return createOllamaEmbedding(params)
+	case HuggingFaceEmbedding:
+		return createHuggingFaceEmbedding(params)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEmbeddingType, embType)
+	}
+}
+
+// createQwenEmbedder 通过 DashScope 原生接口创建通义千问向量实例
+func createQwenEmbedder(params map[string]interface{}, batchSize int) (embeddings.Embedder, error) {
+	dimension, err := intParam(params, "dimension")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []qwen.Option{qwen.WithEmbeddingDimension(dimension)}
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, qwen.WithAPIKey(apiKey))
+	}
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, qwen.WithNativeBaseURL(baseURL))
+	}
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, qwen.WithEmbeddingModel(model))
+	}
+	if batchSize > 0 {
+		opts = append(opts, qwen.WithEmbeddingBatchSize(batchSize))
+	}
+	return qwen.NewEmbedder(opts...)
+}
+
+// createZhipuEmbedder 创建智谱向量实例
+func createZhipuEmbedder(params map[string]interface{}, batchSize int) (embeddings.Embedder, error) {
+	opts := []zhipu.Option{}
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, zhipu.WithAPIKey(apiKey))
+	}
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, zhipu.WithBaseURL(baseURL))
+	}
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, zhipu.WithEmbeddingModel(model))
+	}
+
+	llm, err := zhipu.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if batchSize == 0 {
+		batchSize = zhipuEmbeddingBatchSize
+	}
+	return newBatchEmbedder(llm, params, batchSize)
+}
+
+// createSiliconFlowEmbedder 创建硅基流动向量实例
+func createSiliconFlowEmbedder(params map[string]interface{}, batchSize int) (embeddings.Embedder, error) {
+	opts := []siliconflow.Option{}
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, siliconflow.WithAPIKey(apiKey))
+	}
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, siliconflow.WithBaseURL(baseURL))
+	}
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, siliconflow.WithEmbeddingModel(model))
+	}
+
+	llm, err := siliconflow.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if batchSize == 0 {
+		batchSize = siliconFlowEmbeddingBatchSize
+	}
+	return newBatchEmbedder(llm, params, batchSize)
+}
+
+// createRemoteEmbedder 创建访问OpenAI兼容端点的向量实例
+func createRemoteEmbedder(params map[string]interface{}, batchSize int) (embeddings.Embedder, error) {
+	baseURL, _ := params["base_url"].(string)
+	apiKey, _ := params["api_key"].(string)
+	model, _ := params["model"].(string)
+
+	llm, err := NewRemoteModel(baseURL, apiKey, model, openai.WithEmbeddingModel(model))
+	if err != nil {
+		return nil, err
+	}
+	return newBatchEmbedder(llm, params, batchSize)
+}
+
+// withEmbeddingModelParam 将参数 "model" 作为 CreateEmbedding 的 "embedding_model" 参数
+func withEmbeddingModelParam(params map[string]interface{}) map[string]interface{} {
+	model, ok := params["model"].(string)
+	if !ok || model == "" {
+		return params
+	}
+	converted := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		converted[k] = v
+	}
+	delete(converted, "model")
+	if _, ok := converted["embedding_model"]; !ok {
+		converted["embedding_model"] = model
+	}
+	return converted
+}
+
+// newBatchEmbedder 按批量大小与 "strip_new_lines" 参数创建向量实例，batchSize 为 0 时使用默认值
+func newBatchEmbedder(client embeddings.EmbedderClient, params map[string]interface{}, batchSize int) (embeddings.Embedder, error) {
+	var opts []embeddings.Option
+	if batchSize > 0 {
+		opts = append(opts, embeddings.WithBatchSize(batchSize))
+	}
+	if stripNewLines, ok := params["strip_new_lines"].(bool); ok {
+		opts = append(opts, embeddings.WithStripNewLines(stripNewLines))
+	}
+	return embeddings.NewEmbedder(client, opts...)
+}
+
+// intParam 读取非负整数参数，也接受JSON解码得到的 float64
+func intParam(params map[string]interface{}, key string) (int, error) {
+	var value int
+	switch v := params[key].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		value = v
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("参数 %s 必须是整数，实际为 %v", key, v)
+		}
+		value = int(v)
+	default:
+		return 0, fmt.Errorf("参数 %s 必须是整数，实际为 %T", key, v)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("参数 %s 不能为负数: %d", key, value)
+	}
+	return value, nil
+}
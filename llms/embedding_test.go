@@ -0,0 +1,212 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateEmbedder(t *testing.T) {
+	t.Run("OpenAI兼容端点按批量大小分批请求", func(t *testing.T) {
+		var (
+			mu      sync.Mutex
+			batches [][]string
+			auth    string
+			model   string
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Model string   `json:"model"`
+				Input []string `json:"input"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			batches = append(batches, body.Input)
+			auth = r.Header.Get("Authorization")
+			model = body.Model
+			mu.Unlock()
+
+			data := make([]string, len(body.Input))
+			for i := range body.Input {
+				data[i] = fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":[%d]}`, i, len(body.Input[i]))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"object":"list","data":[` + strings.Join(data, ",") + `]}`))
+		}))
+		defer server.Close()
+
+		embedder, err := llmscn.CreateEmbedder(llmscn.RemoteEmbedding, map[string]interface{}{
+			"base_url":   server.URL + "/v1",
+			"model":      "bge-m3",
+			"batch_size": float64(2),
+		})
+		require.NoError(t, err)
+
+		vectors, err := embedder.EmbedDocuments(context.Background(), []string{"a", "bb", "ccc"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]float32{{1}, {2}, {3}}, vectors)
+		assert.Len(t, batches, 2)
+		assert.Equal(t, "bge-m3", model)
+		assert.Empty(t, auth)
+	})
+
+	t.Run("通义千问使用原生接口并传递向量维度", func(t *testing.T) {
+		var body struct {
+			Model      string `json:"model"`
+			Parameters struct {
+				Dimension int `json:"dimension"`
+			} `json:"parameters"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			_, _ = w.Write([]byte(`{"output":{"embeddings":[{"text_index":0,"embedding":[0.5]}]}}`))
+		}))
+		defer server.Close()
+
+		embedder, err := llmscn.CreateEmbedder(llmscn.QwenEmbedding, map[string]interface{}{
+			"api_key":   "test-key",
+			"base_url":  server.URL,
+			"dimension": float64(512),
+		})
+		require.NoError(t, err)
+
+		vector, err := embedder.EmbedQuery(context.Background(), "杭州")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.5}, vector)
+		assert.Equal(t, "text-embedding-v3", body.Model)
+		assert.Equal(t, 512, body.Parameters.Dimension)
+	})
+
+	t.Run("智谱与硅基流动", func(t *testing.T) {
+		for _, embType := range []llmscn.EmbeddingType{llmscn.ZhipuEmbedding, llmscn.SiliconFlowEmbedding} {
+			embedder, err := llmscn.CreateEmbedder(embType, map[string]interface{}{"api_key": "test-key"})
+			require.NoError(t, err, embType)
+			assert.NotNil(t, embedder)
+		}
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		_, err := llmscn.CreateEmbedder(llmscn.RemoteEmbedding, map[string]interface{}{"model": "bge-m3"})
+		assert.True(t, errors.Is(err, llmscn.ErrMissingRequiredParam))
+
+		_, err = llmscn.CreateEmbedder(llmscn.RemoteEmbedding, map[string]interface{}{
+			"base_url": "http://localhost", "model": "bge-m3", "batch_size": "10",
+		})
+		assert.ErrorContains(t, err, "batch_size")
+
+		_, err = llmscn.CreateEmbedder("unknown", nil)
+		assert.True(t, errors.Is(err, llmscn.ErrUnsupportedEmbeddingType))
+	})
+}
+
+func TestQwenEmbedder(t *testing.T) {
+	ctx := context.Background()
+
+	type request struct {
+		Model string `json:"model"`
+		Input struct {
+			Texts []string `json:"texts"`
+		} `json:"input"`
+		Parameters struct {
+			TextType  string `json:"text_type"`
+			Dimension int    `json:"dimension"`
+		} `json:"parameters"`
+	}
+	var (
+		requests []request
+		reply    func(req request) (int, string)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/embeddings/text-embedding/text-embedding", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var req request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		status, body := reply(req)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	// reversed 按 text_index 倒序返回向量，向量值为文本长度
+	reversed := func(req request) (int, string) {
+		items := make([]string, 0, len(req.Input.Texts))
+		for i := len(req.Input.Texts) - 1; i >= 0; i-- {
+			items = append(items, fmt.Sprintf(`{"text_index":%d,"embedding":[%d]}`, i, len(req.Input.Texts[i])))
+		}
+		return http.StatusOK, `{"output":{"embeddings":[` + strings.Join(items, ",") + `]},"request_id":"r-1"}`
+	}
+
+	newEmbedder := func(t *testing.T, opts ...qwen.Option) *qwen.Embedder {
+		opts = append([]qwen.Option{qwen.WithAPIKey("test-key"), qwen.WithNativeBaseURL(server.URL)}, opts...)
+		embedder, err := qwen.NewEmbedder(opts...)
+		require.NoError(t, err)
+		return embedder
+	}
+
+	t.Run("文档按批量大小分批并按序号排列", func(t *testing.T) {
+		requests = nil
+		reply = reversed
+
+		embedder := newEmbedder(t, qwen.WithEmbeddingBatchSize(2), qwen.WithEmbeddingDimension(768))
+		vectors, err := embedder.EmbedDocuments(ctx, []string{"a", "bb", "ccc", "dddd", "eeeee"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]float32{{1}, {2}, {3}, {4}, {5}}, vectors)
+
+		require.Len(t, requests, 3)
+		assert.Equal(t, []string{"a", "bb"}, requests[0].Input.Texts)
+		assert.Equal(t, []string{"ccc", "dddd"}, requests[1].Input.Texts)
+		assert.Equal(t, []string{"eeeee"}, requests[2].Input.Texts)
+		for _, req := range requests {
+			assert.Equal(t, qwen.ModelTextEmbeddingV3, req.Model)
+			assert.Equal(t, "document", req.Parameters.TextType)
+			assert.Equal(t, 768, req.Parameters.Dimension)
+		}
+	})
+
+	t.Run("查询使用query文本类型", func(t *testing.T) {
+		requests = nil
+		reply = reversed
+
+		vector, err := newEmbedder(t).EmbedQuery(ctx, "杭州")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{6}, vector)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "query", requests[0].Parameters.TextType)
+		assert.Zero(t, requests[0].Parameters.Dimension)
+	})
+
+	t.Run("接口错误", func(t *testing.T) {
+		requests = nil
+		reply = func(request) (int, string) {
+			return http.StatusBadRequest, `{"code":"InvalidParameter","message":"dimension is invalid","request_id":"r-2"}`
+		}
+
+		_, err := newEmbedder(t).EmbedDocuments(ctx, []string{"a"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status code 400")
+		assert.Contains(t, err.Error(), "InvalidParameter - dimension is invalid")
+	})
+
+	t.Run("文本序号越界", func(t *testing.T) {
+		requests = nil
+		reply = func(request) (int, string) {
+			return http.StatusOK, `{"output":{"embeddings":[{"text_index":0,"embedding":[1]},{"text_index":2,"embedding":[2]}]}}`
+		}
+
+		_, err := newEmbedder(t).EmbedDocuments(ctx, []string{"a", "b"})
+		assert.ErrorContains(t, err, "文本序号越界: 2")
+	})
+}
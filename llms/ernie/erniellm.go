@@ -0,0 +1,332 @@
+// Package ernie 提供了百度千帆平台文心一言（ERNIE）大语言模型的Go语言客户端实现
+package ernie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/ernie/internal/ernieclient"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// 环境变量名
+	TokenEnvVarName     = "ERNIE_API_KEY"    //nolint:gosec
+	SecretKeyEnvVarName = "ERNIE_SECRET_KEY" //nolint:gosec
+	ModelEnvVarName     = "ERNIE_MODEL"      //nolint:gosec
+
+	// DefaultBaseURL 千帆API基础URL
+	DefaultBaseURL = ernieclient.DefaultBaseURL
+	// DefaultModel 默认模型
+	DefaultModel = ModelERNIESpeed
+)
+
+const (
+	// ModelERNIE4 是文心一言 ERNIE 4.0 模型
+	ModelERNIE4 = "ernie-4.0-8k"
+
+	// ModelERNIE4Turbo 是文心一言 ERNIE 4.0 Turbo 模型
+	ModelERNIE4Turbo = "ernie-4.0-turbo-8k"
+
+	// ModelERNIE35 是文心一言 ERNIE 3.5 模型
+	ModelERNIE35 = "ernie-3.5-8k"
+
+	// ModelERNIESpeed 是文心一言 ERNIE Speed 模型
+	ModelERNIESpeed = "ernie-speed-8k"
+
+	// ModelERNIESpeed128K 是文心一言 ERNIE Speed 128K 长上下文模型
+	ModelERNIESpeed128K = "ernie-speed-128k"
+
+	// ModelERNIELite 是文心一言 ERNIE Lite 轻量级模型
+	ModelERNIELite = "ernie-lite-8k"
+
+	// ModelERNIETiny 是文心一言 ERNIE Tiny 模型
+	ModelERNIETiny = "ernie-tiny-8k"
+)
+
+var (
+	// ErrEmptyResponse 表示API返回了空响应
+	ErrEmptyResponse = errors.New("空响应")
+
+	// ErrMissingAPIKey 表示缺少鉴权信息
+	ErrMissingAPIKey = errors.New("缺少API密钥，请通过参数或环境变量 ERNIE_API_KEY、ERNIE_SECRET_KEY 设置，或直接设置访问令牌")
+)
+
+// LLM 是文心一言大语言模型的客户端
+type LLM struct {
+	client           *ernieclient.Client
+	callbacksHandler callbacks.Handler
+
+	model       string
+	temperature float64
+	topP        float64
+	maxTokens   int
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// New 创建一个新的文心一言 LLM客户端
+// 鉴权使用千帆应用的 API Key 和 Secret Key 换取访问令牌，令牌在过期前自动刷新
+func New(opts ...Option) (*LLM, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.accessToken == "" && (options.apiKey == "" || options.secretKey == "") {
+		return nil, ErrMissingAPIKey
+	}
+	if options.model == "" {
+		options.model = DefaultModel
+	}
+
+	// 按限流器和重试策略包装HTTP客户端
+	var httpClient retry.Doer = http.DefaultClient
+	if options.httpClient != nil {
+		httpClient = options.httpClient
+	}
+	clientOpts := []ernieclient.Option{
+		ernieclient.WithHTTPClient(retry.Wrap(ratelimit.Wrap(httpClient, options.rateLimiter), options.retryPolicy)),
+	}
+	if options.baseURL != "" {
+		clientOpts = append(clientOpts, ernieclient.WithBaseURL(options.baseURL))
+	}
+	if options.accessToken != "" {
+		clientOpts = append(clientOpts, ernieclient.WithAccessToken(options.accessToken))
+	}
+
+	return &LLM{
+		client:           ernieclient.New(options.apiKey, options.secretKey, clientOpts...),
+		callbacksHandler: options.callbacksHandler,
+		model:            options.model,
+		temperature:      options.temperature,
+		topP:             options.topP,
+		maxTokens:        options.maxTokens,
+	}, nil
+}
+
+// GetModels 返回文心一言支持的常用模型列表
+func (o *LLM) GetModels() []string {
+	return []string{
+		ModelERNIE4,
+		ModelERNIE4Turbo,
+		ModelERNIE35,
+		ModelERNIESpeed,
+		ModelERNIESpeed128K,
+		ModelERNIELite,
+		ModelERNIETiny,
+	}
+}
+
+// Call 调用文心一言生成文本
+func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, o, prompt, options...)
+}
+
+// GenerateContent 生成内容，支持流式输出和工具调用
+func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if o.callbacksHandler != nil {
+		o.callbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+
+	system, chatMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, fmt.Errorf("转换消息格式失败: %w", err)
+	}
+
+	request := &ernieclient.ChatRequest{
+		Model:           o.model,
+		Messages:        chatMessages,
+		System:          system,
+		Temperature:     o.temperature,
+		TopP:            o.topP,
+		MaxOutputTokens: o.maxTokens,
+		StreamingFunc:   opts.StreamingFunc,
+	}
+	applyCallOptions(request, opts)
+
+	response, err := o.client.CreateChat(ctx, request)
+	if err != nil {
+		if o.callbacksHandler != nil {
+			o.callbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+	if response.Result == "" && response.FunctionCall == nil {
+		return nil, ErrEmptyResponse
+	}
+
+	choice := &llms.ContentChoice{
+		Content:    response.Result,
+		StopReason: response.FinishReason,
+	}
+	if u := response.Usage; u.PromptTokens+u.CompletionTokens+u.TotalTokens > 0 {
+		choice.GenerationInfo = usage.Set(nil, u.PromptTokens, u.CompletionTokens, u.TotalTokens)
+	}
+	if call := response.FunctionCall; call != nil {
+		// 千帆的函数调用没有ID，使用响应ID，工具结果按函数名称对应
+		toolCall := llms.ToolCall{
+			ID:   response.ID,
+			Type: "function",
+			FunctionCall: &llms.FunctionCall{
+				Name:      call.Name,
+				Arguments: call.Arguments,
+			},
+		}
+		choice.ToolCalls = []llms.ToolCall{toolCall}
+		choice.FuncCall = toolCall.FunctionCall
+	}
+
+	contentResponse := &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}
+	if o.callbacksHandler != nil {
+		o.callbacksHandler.HandleLLMGenerateContentEnd(ctx, contentResponse)
+	}
+	return contentResponse, nil
+}
+
+// applyCallOptions 用调用时传入的选项覆盖客户端默认的模型与采样参数，并设置工具、JSON模式与用户标识
+func applyCallOptions(request *ernieclient.ChatRequest, options llms.CallOptions) {
+	if options.Model != "" {
+		request.Model = options.Model
+	}
+	if options.Temperature != 0 {
+		request.Temperature = options.Temperature
+	}
+	if options.TopP != 0 {
+		request.TopP = options.TopP
+	}
+	if options.MaxTokens != 0 {
+		request.MaxOutputTokens = options.MaxTokens
+	}
+	if len(options.StopWords) > 0 {
+		request.Stop = options.StopWords
+	}
+	if options.JSONMode {
+		request.ResponseFormat = "json_object"
+	}
+
+	// 请求标签中的 user 标签转发为 user_id 字段
+	if tags, ok := options.Metadata["request_tags"].(map[string]string); ok && tags["user"] != "" {
+		request.UserID = tags["user"]
+	}
+
+	// 千帆不支持 tool_choice 为 none，此时不发送函数定义
+	if choice, ok := options.ToolChoice.(string); ok && choice == "none" {
+		return
+	}
+	for _, tool := range options.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		request.Functions = append(request.Functions, ernieclient.Function{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+		})
+	}
+	if choice, ok := options.ToolChoice.(llms.ToolChoice); ok && choice.Function != nil {
+		request.ToolChoice = &ernieclient.ToolChoice{Type: "function"}
+		request.ToolChoice.Function.Name = choice.Function.Name
+	}
+}
+
+// convertMessages 将LangChain消息转换为千帆消息
+// 系统消息放在单独的 system 字段中；千帆要求用户与助手消息交替出现，连续的同角色文本消息合并为一条
+func convertMessages(messages []llms.MessageContent) (string, []ernieclient.ChatMessage, error) {
+	var system []string
+	result := make([]ernieclient.ChatMessage, 0, len(messages))
+
+	for _, message := range messages {
+		switch message.Role {
+		case llms.ChatMessageTypeSystem:
+			text, err := messageText(message)
+			if err != nil {
+				return "", nil, err
+			}
+			system = append(system, text)
+			continue
+		case llms.ChatMessageTypeTool:
+			for _, part := range message.Parts {
+				response, ok := part.(llms.ToolCallResponse)
+				if !ok {
+					return "", nil, fmt.Errorf("工具消息只能包含工具调用结果，实际为 %T", part)
+				}
+				result = append(result, ernieclient.ChatMessage{
+					Role:    "function",
+					Name:    response.Name,
+					Content: response.Content,
+				})
+			}
+			continue
+		}
+
+		var role string
+		switch message.Role {
+		case llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric:
+			role = "user"
+		case llms.ChatMessageTypeAI:
+			role = "assistant"
+		default:
+			return "", nil, fmt.Errorf("不支持的消息类型: %s", message.Role)
+		}
+
+		// 助手发起的函数调用
+		if call, ok := findToolCall(message); ok {
+			result = append(result, ernieclient.ChatMessage{
+				Role: role,
+				FunctionCall: &ernieclient.FunctionCall{
+					Name:      call.FunctionCall.Name,
+					Arguments: call.FunctionCall.Arguments,
+				},
+			})
+			continue
+		}
+
+		text, err := messageText(message)
+		if err != nil {
+			return "", nil, err
+		}
+		if last := len(result) - 1; last >= 0 && result[last].Role == role && result[last].FunctionCall == nil {
+			result[last].Content += "\n" + text
+			continue
+		}
+		result = append(result, ernieclient.ChatMessage{Role: role, Content: text})
+	}
+
+	return strings.Join(system, "\n"), result, nil
+}
+
+// findToolCall 返回消息中的第一个工具调用，千帆每轮只支持一个函数调用
+func findToolCall(message llms.MessageContent) (llms.ToolCall, bool) {
+	for _, part := range message.Parts {
+		if call, ok := part.(llms.ToolCall); ok && call.FunctionCall != nil {
+			return call, true
+		}
+	}
+	return llms.ToolCall{}, false
+}
+
+// messageText 拼接消息中的文本，千帆对话接口只支持文本输入
+func messageText(message llms.MessageContent) (string, error) {
+	var text strings.Builder
+	for _, part := range message.Parts {
+		p, ok := part.(llms.TextContent)
+		if !ok {
+			return "", fmt.Errorf("不支持的内容类型: %T", part)
+		}
+		text.WriteString(p.Text)
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,155 @@
+package ernie
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/tmc/langchaingo/callbacks"
+)
+
+// options 包含创建文心一言 LLM客户端的选项
+type options struct {
+	// apiKey 是千帆应用的 API Key，与 secretKey 一起用于换取访问令牌
+	apiKey string
+
+	// secretKey 是千帆应用的 Secret Key
+	secretKey string
+
+	// accessToken 是已有的访问令牌，设置后不再换取
+	accessToken string
+
+	// model 是要使用的模型名称
+	model string
+
+	// baseURL 是API的基础URL
+	baseURL string
+
+	// httpClient 是自定义的HTTP客户端
+	httpClient *http.Client
+
+	// retryPolicy 是请求失败时的重试策略
+	retryPolicy retry.Policy
+
+	// rateLimiter 是可在多个客户端之间共享的限流器
+	rateLimiter *ratelimit.Limiter
+
+	// callbacksHandler 是回调处理器
+	callbacksHandler callbacks.Handler
+
+	// temperature 控制随机性，取值范围 (0, 1]
+	temperature float64
+
+	// topP 控制词汇选择的多样性
+	topP float64
+
+	// maxTokens 是生成的最大令牌数
+	maxTokens int
+}
+
+// Option 是配置文心一言 LLM客户端的函数类型
+type Option func(*options)
+
+// defaultOptions 返回默认选项
+func defaultOptions() *options {
+	return &options{
+		apiKey:    os.Getenv(TokenEnvVarName),
+		secretKey: os.Getenv(SecretKeyEnvVarName),
+		model:     os.Getenv(ModelEnvVarName),
+	}
+}
+
+// WithAPIKey 设置千帆应用的 API Key
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithSecretKey 设置千帆应用的 Secret Key
+func WithSecretKey(secretKey string) Option {
+	return func(o *options) {
+		o.secretKey = secretKey
+	}
+}
+
+// WithAccessToken 直接使用已有的访问令牌，不再通过 API Key 和 Secret Key 换取，令牌过期后需由调用方更新
+func WithAccessToken(token string) Option {
+	return func(o *options) {
+		o.accessToken = token
+	}
+}
+
+// WithModel 设置模型名称
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+// WithBaseURL 设置API的基础URL
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient 设置自定义的HTTP客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试和获取访问令牌）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
+// WithCallbacksHandler 设置回调处理器
+func WithCallbacksHandler(handler callbacks.Handler) Option {
+	return func(o *options) {
+		o.callbacksHandler = handler
+	}
+}
+
+// WithTemperature 设置温度参数，取值范围 (0, 1]
+func WithTemperature(temperature float64) Option {
+	return func(o *options) {
+		o.temperature = temperature
+	}
+}
+
+// WithTopP 设置topP参数
+func WithTopP(topP float64) Option {
+	return func(o *options) {
+		o.topP = topP
+	}
+}
+
+// WithMaxTokens 设置最大令牌数，对应请求的 max_output_tokens
+func WithMaxTokens(maxTokens int) Option {
+	return func(o *options) {
+		o.maxTokens = maxTokens
+	}
+}
@@ -0,0 +1,132 @@
+package ernieclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CreateChat 创建一个聊天请求，设置了 StreamingFunc 时使用流式请求并返回拼接后的完整响应
+// 访问令牌被服务端判定为无效或过期时重新获取令牌并重试一次
+func (c *Client) CreateChat(ctx context.Context, request *ChatRequest) (*ChatResponse, error) {
+	if request.StreamingFunc != nil {
+		request.Stream = true
+	}
+
+	// 序列化请求
+	payloadBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := c.createChat(ctx, request, token, payloadBytes)
+		var apiErr *APIError
+		if attempt == 0 && errors.As(err, &apiErr) && apiErr.tokenExpired() {
+			c.invalidate(token)
+			continue
+		}
+		return response, err
+	}
+}
+
+// createChat 使用指定的访问令牌发送一次聊天请求
+func (c *Client) createChat(ctx context.Context, request *ChatRequest, token string, payloadBytes []byte) (*ChatResponse, error) {
+	path := chatPath + Endpoint(request.Model) + "?access_token=" + url.QueryEscape(token)
+	resp, err := c.do(ctx, path, payloadBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 检查响应状态
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.decodeError(resp)
+	}
+
+	if request.Stream {
+		return parseStreamingChatResponse(ctx, resp, request)
+	}
+
+	// 解析响应
+	var response ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if apiErr := response.apiError(); apiErr != nil {
+		return nil, apiErr
+	}
+	return &response, nil
+}
+
+// parseStreamingChatResponse 解析流式聊天响应
+// 千帆的每个 SSE 数据块携带一段增量文本，函数调用在数据块中完整返回，用量以最后一个数据块为准；
+// 请求出错时响应体为不带 data: 前缀的JSON错误
+func parseStreamingChatResponse(ctx context.Context, r *http.Response, request *ChatRequest) (*ChatResponse, error) {
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	response := &ChatResponse{}
+	var text strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "event:") || strings.HasPrefix(line, "id:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+		// 解析流式响应块
+		var chunk ChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("解析流事件失败: %w", err)
+		}
+		if apiErr := chunk.apiError(); apiErr != nil {
+			return nil, apiErr
+		}
+
+		if chunk.Result != "" {
+			text.WriteString(chunk.Result)
+			if request.StreamingFunc != nil {
+				if err := request.StreamingFunc(ctx, []byte(chunk.Result)); err != nil {
+					return nil, fmt.Errorf("流式函数返回错误: %w", err)
+				}
+			}
+		}
+
+		response.ID = chunk.ID
+		response.Object = chunk.Object
+		response.Created = chunk.Created
+		response.SentenceID = chunk.SentenceID
+		response.IsTruncated = chunk.IsTruncated
+		response.NeedClearHistory = chunk.NeedClearHistory
+		if chunk.FunctionCall != nil {
+			response.FunctionCall = chunk.FunctionCall
+		}
+		if chunk.FinishReason != "" {
+			response.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			response.Usage = chunk.Usage
+		}
+		if chunk.IsEnd {
+			response.IsEnd = true
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("扫描响应时出现问题: %w", err)
+	}
+
+	response.Result = text.String()
+	return response, nil
+}
@@ -0,0 +1,289 @@
+package ernieclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBaseURL 是百度千帆API的默认基础URL，鉴权和对话接口使用同一域名
+	DefaultBaseURL = "https://aip.baidubce.com"
+
+	tokenPath = "/oauth/2.0/token"
+	chatPath  = "/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/"
+
+	// tokenRefreshMargin 访问令牌到期前提前刷新的时间
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// ErrEmptyResponse 当千帆API返回空响应时返回此错误
+var ErrEmptyResponse = errors.New("空响应")
+
+// modelEndpoints 模型名称到千帆对话接口路径的映射，未列出的模型直接使用模型名称作为路径（如自定义部署的服务）
+var modelEndpoints = map[string]string{
+	"ernie-4.0-8k":        "completions_pro",
+	"ernie-4.0-8k-latest": "ernie-4.0-8k-latest",
+	"ernie-4.0-turbo-8k":  "ernie-4.0-turbo-8k",
+	"ernie-3.5-8k":        "completions",
+	"ernie-3.5-128k":      "ernie-3.5-128k",
+	"ernie-speed-8k":      "ernie_speed",
+	"ernie-speed-128k":    "ernie-speed-128k",
+	"ernie-lite-8k":       "ernie-lite-8k",
+	"ernie-tiny-8k":       "ernie-tiny-8k",
+	// 旧版模型名称
+	"ernie-bot-4":     "completions_pro",
+	"ernie-bot":       "completions",
+	"ernie-bot-turbo": "eb-instant",
+}
+
+// Endpoint 返回模型对应的对话接口路径，模型名称不区分大小写（如 ERNIE-4.0-8K）
+func Endpoint(model string) string {
+	if endpoint, ok := modelEndpoints[strings.ToLower(model)]; ok {
+		return endpoint
+	}
+	return model
+}
+
+// APIError 是千帆API返回的错误，千帆在HTTP状态码为200时通过 error_code 返回错误
+type APIError struct {
+	Code    int    `json:"error_code"`
+	Message string `json:"error_msg"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API错误 (%d): %s", e.Code, e.Message)
+}
+
+// tokenExpired 判断是否为访问令牌无效（110）或过期（111）
+func (e *APIError) tokenExpired() bool {
+	return e.Code == 110 || e.Code == 111
+}
+
+// Doer 执行HTTP请求
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client 是千帆API的客户端，负责管理OAuth访问令牌
+type Client struct {
+	apiKey    string
+	secretKey string
+	baseURL   string
+
+	httpClient Doer
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+	// fixedToken 为 true 时使用调用方提供的访问令牌，不再通过 API Key 换取
+	fixedToken bool
+}
+
+// Option 是千帆客户端的选项
+type Option func(*Client)
+
+// WithHTTPClient 允许设置自定义HTTP客户端
+func WithHTTPClient(client Doer) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithBaseURL 设置API的基础URL
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithAccessToken 使用已有的访问令牌，不再通过 API Key 和 Secret Key 换取
+func WithAccessToken(token string) Option {
+	return func(c *Client) {
+		c.accessToken = token
+		c.fixedToken = true
+	}
+}
+
+// New 返回一个新的千帆客户端，apiKey 和 secretKey 用于换取访问令牌
+func New(apiKey, secretKey string, opts ...Option) *Client {
+	c := &Client{
+		apiKey:     apiKey,
+		secretKey:  secretKey,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// tokenResponse 是OAuth接口的响应
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// token 返回有效的访问令牌，未获取或即将过期时通过 API Key 和 Secret Key 重新获取
+// 获取期间持有锁，并发请求只会触发一次刷新
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fixedToken || (c.accessToken != "" && time.Now().Before(c.expiresAt)) {
+		return c.accessToken, nil
+	}
+
+	query := url.Values{}
+	query.Set("grant_type", "client_credentials")
+	query.Set("client_id", c.apiKey)
+	query.Set("client_secret", c.secretKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+tokenPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析访问令牌响应失败 (%d): %w", resp.StatusCode, err)
+	}
+	if result.Error != "" || result.AccessToken == "" {
+		return "", fmt.Errorf("获取访问令牌失败 (%d): %s %s", resp.StatusCode, result.Error, result.ErrorDescription)
+	}
+
+	c.accessToken = result.AccessToken
+	c.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - tokenRefreshMargin)
+	return c.accessToken, nil
+}
+
+// invalidate 丢弃服务端判定为无效的访问令牌，下次请求时重新获取
+func (c *Client) invalidate(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fixedToken && c.accessToken == token {
+		c.accessToken = ""
+	}
+}
+
+// ChatRequest 是创建聊天请求的结构体
+type ChatRequest struct {
+	// Model 用于选择对话接口路径，不会发送给服务端
+	Model           string        `json:"-"`
+	Messages        []ChatMessage `json:"messages"`
+	System          string        `json:"system,omitempty"`
+	Temperature     float64       `json:"temperature,omitempty"`
+	TopP            float64       `json:"top_p,omitempty"`
+	PenaltyScore    float64       `json:"penalty_score,omitempty"`
+	MaxOutputTokens int           `json:"max_output_tokens,omitempty"`
+	Stop            []string      `json:"stop,omitempty"`
+	Stream          bool          `json:"stream,omitempty"`
+	Functions       []Function    `json:"functions,omitempty"`
+	ToolChoice      *ToolChoice   `json:"tool_choice,omitempty"`
+	// ResponseFormat 设置为 json_object 时以JSON格式返回
+	ResponseFormat string `json:"response_format,omitempty"`
+	UserID         string `json:"user_id,omitempty"`
+
+	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
+}
+
+// ChatMessage 是聊天消息，角色为 user、assistant 或 function
+type ChatMessage struct {
+	Role         string        `json:"role"`
+	Content      string        `json:"content"`
+	Name         string        `json:"name,omitempty"`
+	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+}
+
+// FunctionCall 是模型发起的函数调用
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Thoughts  string `json:"thoughts,omitempty"`
+}
+
+// Function 是函数定义
+type Function struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parameters  any    `json:"parameters"`
+}
+
+// ToolChoice 指定模型必须调用的函数
+type ToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// Usage 是token用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatResponse 是聊天响应，流式请求的每个数据块也使用该结构
+type ChatResponse struct {
+	ID               string        `json:"id"`
+	Object           string        `json:"object"`
+	Created          int64         `json:"created"`
+	SentenceID       int           `json:"sentence_id"`
+	IsEnd            bool          `json:"is_end"`
+	IsTruncated      bool          `json:"is_truncated"`
+	Result           string        `json:"result"`
+	NeedClearHistory bool          `json:"need_clear_history"`
+	FinishReason     string        `json:"finish_reason"`
+	FunctionCall     *FunctionCall `json:"function_call,omitempty"`
+	Usage            Usage         `json:"usage"`
+	ErrorCode        int           `json:"error_code"`
+	ErrorMsg         string        `json:"error_msg"`
+}
+
+// apiError 返回响应中的错误，没有错误时返回 nil
+func (r *ChatResponse) apiError() *APIError {
+	if r.ErrorCode == 0 {
+		return nil
+	}
+	return &APIError{Code: r.ErrorCode, Message: r.ErrorMsg}
+}
+
+func (c *Client) do(ctx context.Context, path string, payloadBytes []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Client) decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	var apiErr APIError
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Code != 0 {
+		return fmt.Errorf("HTTP %d: %w", resp.StatusCode, &apiErr)
+	}
+	return fmt.Errorf("API错误 (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,199 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ernieServer 模拟千帆的鉴权和对话接口，chat 根据请求体返回响应
+type ernieServer struct {
+	*httptest.Server
+	tokens   atomic.Int32
+	paths    []string
+	requests []map[string]any
+}
+
+func newErnieServer(t *testing.T, chat func(w http.ResponseWriter, token string, body map[string]any)) *ernieServer {
+	s := &ernieServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/oauth/2.0/token" {
+			assert.Equal(t, "client_credentials", r.URL.Query().Get("grant_type"))
+			assert.Equal(t, "test-key", r.URL.Query().Get("client_id"))
+			assert.Equal(t, "test-secret", r.URL.Query().Get("client_secret"))
+			n := s.tokens.Add(1)
+			fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":2592000}`, n)
+			return
+		}
+
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &body))
+		s.paths = append(s.paths, r.URL.Path)
+		s.requests = append(s.requests, body)
+		chat(w, r.URL.Query().Get("access_token"), body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *ernieServer) newLLM(t *testing.T, model string) llms.Model {
+	llm, err := llmscn.CreateLLM(llmscn.ErnieLLM, map[string]interface{}{
+		"api_key":    "test-key",
+		"secret_key": "test-secret",
+		"base_url":   s.URL,
+		"model":      model,
+	})
+	require.NoError(t, err)
+	return llm
+}
+
+func TestErnieLLM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("换取访问令牌并复用", func(t *testing.T) {
+		server := newErnieServer(t, func(w http.ResponseWriter, token string, body map[string]any) {
+			assert.Equal(t, "token-1", token)
+			_, _ = w.Write([]byte(`{"id":"as-1","result":"你好！","finish_reason":"normal","usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`))
+		})
+		llm := server.newLLM(t, "ERNIE-4.0-8K")
+
+		messages := []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeSystem, "你是助手"),
+			llms.TextParts(llms.ChatMessageTypeHuman, "你好"),
+			llms.TextParts(llms.ChatMessageTypeHuman, "在吗"),
+		}
+		for i := 0; i < 2; i++ {
+			resp, err := llm.GenerateContent(ctx, messages, llms.WithJSONMode(), llmscn.WithRequestTags(map[string]string{"user": "tenant-a"}))
+			require.NoError(t, err)
+			assert.Equal(t, "你好！", resp.Choices[0].Content)
+			assertTokenUsage(t, resp.Choices[0].GenerationInfo, 3, 2, 5)
+		}
+
+		assert.Equal(t, int32(1), server.tokens.Load())
+		assert.Equal(t, "/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/completions_pro", server.paths[0])
+		body := server.requests[0]
+		assert.Equal(t, "你是助手", body["system"])
+		assert.Equal(t, []any{map[string]any{"role": "user", "content": "你好\n在吗"}}, body["messages"])
+		assert.Equal(t, "json_object", body["response_format"])
+		assert.Equal(t, "tenant-a", body["user_id"])
+	})
+
+	t.Run("访问令牌过期时重新获取", func(t *testing.T) {
+		server := newErnieServer(t, func(w http.ResponseWriter, token string, body map[string]any) {
+			if token == "token-1" {
+				_, _ = w.Write([]byte(`{"error_code":111,"error_msg":"Access token expired"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"as-2","result":"ok"}`))
+		})
+
+		text, err := server.newLLM(t, "").Call(ctx, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", text)
+		assert.Equal(t, int32(2), server.tokens.Load())
+		assert.Equal(t, "/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/ernie_speed", server.paths[0])
+	})
+
+	t.Run("业务错误", func(t *testing.T) {
+		server := newErnieServer(t, func(w http.ResponseWriter, token string, body map[string]any) {
+			_, _ = w.Write([]byte(`{"error_code":336003,"error_msg":"the first message role must be user"}`))
+		})
+
+		_, err := server.newLLM(t, "").Call(ctx, "你好")
+		assert.ErrorContains(t, err, "API错误 (336003)")
+	})
+
+	t.Run("流式输出", func(t *testing.T) {
+		server := newErnieServer(t, func(w http.ResponseWriter, token string, body map[string]any) {
+			assert.Equal(t, true, body["stream"])
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, chunk := range []string{
+				`{"id":"as-3","sentence_id":0,"is_end":false,"result":"你"}`,
+				`{"id":"as-3","sentence_id":1,"is_end":true,"result":"好","finish_reason":"normal","usage":{"prompt_tokens":2,"completion_tokens":2,"total_tokens":4}}`,
+			} {
+				fmt.Fprintf(w, "data: %s\n\n", chunk)
+			}
+		})
+
+		var chunks []string
+		resp, err := server.newLLM(t, "").GenerateContent(ctx,
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")},
+			llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+				chunks = append(chunks, string(chunk))
+				return nil
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"你", "好"}, chunks)
+		assert.Equal(t, "你好", resp.Choices[0].Content)
+		assert.Equal(t, "normal", resp.Choices[0].StopReason)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 2, 2, 4)
+	})
+
+	t.Run("工具调用", func(t *testing.T) {
+		server := newErnieServer(t, func(w http.ResponseWriter, token string, body map[string]any) {
+			if len(body["messages"].([]any)) == 1 {
+				_, _ = w.Write([]byte(`{"id":"as-4","result":"","function_call":{"name":"get_weather","arguments":"{\"city\":\"北京\"}","thoughts":"查询天气"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"as-5","result":"北京晴"}`))
+		})
+		llm := server.newLLM(t, "")
+
+		tools := []llms.Tool{{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:        "get_weather",
+				Description: "查询天气",
+				Parameters:  map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+			},
+		}}
+		messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "北京天气怎么样")}
+		resp, err := llm.GenerateContent(ctx, messages, llms.WithTools(tools),
+			llms.WithToolChoice(llms.ToolChoice{Type: "function", Function: &llms.FunctionReference{Name: "get_weather"}}))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices[0].ToolCalls, 1)
+		call := resp.Choices[0].ToolCalls[0]
+		assert.Equal(t, "get_weather", call.FunctionCall.Name)
+		assert.JSONEq(t, `{"city":"北京"}`, call.FunctionCall.Arguments)
+
+		functions := server.requests[0]["functions"].([]any)
+		assert.Equal(t, "get_weather", functions[0].(map[string]any)["name"])
+		assert.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}, server.requests[0]["tool_choice"])
+
+		messages = append(messages,
+			llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+			llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: call.ID, Name: call.FunctionCall.Name, Content: "晴"}}},
+		)
+		resp, err = llm.GenerateContent(ctx, messages, llms.WithTools(tools))
+		require.NoError(t, err)
+		assert.Equal(t, "北京晴", resp.Choices[0].Content)
+
+		history := server.requests[1]["messages"].([]any)
+		require.Len(t, history, 3)
+		assert.Equal(t, map[string]any{"role": "assistant", "content": "", "function_call": map[string]any{"name": "get_weather", "arguments": `{"city":"北京"}`}}, history[1])
+		assert.Equal(t, map[string]any{"role": "function", "name": "get_weather", "content": "晴"}, history[2])
+	})
+
+	t.Run("缺少鉴权信息", func(t *testing.T) {
+		t.Setenv("ERNIE_API_KEY", "")
+		t.Setenv("ERNIE_SECRET_KEY", "")
+		_, err := llmscn.CreateLLM(llmscn.ErnieLLM, map[string]interface{}{"api_key": "test-key"})
+		assert.ErrorContains(t, err, "ERNIE_SECRET_KEY")
+
+		_, err = llmscn.CreateLLM(llmscn.ErnieLLM, map[string]interface{}{"access_token": strings.Repeat("t", 8)})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,249 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// FallbackIndexKey 回复在 GenerationInfo 中记录实际响应的模型序号，0 为主模型，之后依次为备用模型
+const FallbackIndexKey = "fallback_index"
+
+// ErrAllModelsFailed 表示主模型和所有备用模型均调用失败
+var ErrAllModelsFailed = errors.New("所有模型均调用失败")
+
+// FallbackStats 单个模型的调用统计
+type FallbackStats struct {
+	// Index 模型序号，0 为主模型
+	Index int `json:"index"`
+	// Requests 发送给该模型的请求数
+	Requests int64 `json:"requests"`
+	// Failures 触发故障转移的失败次数
+	Failures int64 `json:"failures"`
+	// ConsecutiveFailures 当前连续失败次数
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// Open 熔断器是否处于打开状态
+	Open bool `json:"open"`
+}
+
+// FallbackModel 故障转移装饰器
+// 主模型返回错误或超时时，自动将同一请求依次发送给备用模型（如 DeepSeek 不可用时改用通义千问），
+// 直到某个模型成功。每个模型有独立的熔断器：连续失败达到阈值后在冷却期内跳过该模型，
+// 冷却期结束后放行一个请求试探，成功则恢复。所有模型都处于熔断状态时仍按顺序尝试。
+// 流式调用在已输出部分内容后失败时不再转移，直接返回错误
+type FallbackModel struct {
+	models []llms.Model
+
+	timeout          time.Duration
+	shouldFallback   func(err error) bool
+	failureThreshold int
+	cooldown         time.Duration
+
+	mu       sync.Mutex
+	breakers []fallbackBreaker
+}
+
+var _ llms.Model = (*FallbackModel)(nil)
+
+// fallbackBreaker 单个模型的熔断器与统计
+type fallbackBreaker struct {
+	requests            int64
+	failures            int64
+	consecutiveFailures int
+	openUntil           time.Time
+	probing             bool
+}
+
+// NewFallbackModel 创建故障转移装饰器，按 primary、fallbacks 的顺序尝试
+func NewFallbackModel(primary llms.Model, fallbacks ...llms.Model) *FallbackModel {
+	models := append([]llms.Model{primary}, fallbacks...)
+	return &FallbackModel{
+		models:           models,
+		failureThreshold: 5,
+		cooldown:         30 * time.Second,
+		breakers:         make([]fallbackBreaker, len(models)),
+	}
+}
+
+// WithTimeout 设置单个模型的超时时间，超时后转移到下一个模型；0表示不限制（默认）
+func (m *FallbackModel) WithTimeout(timeout time.Duration) *FallbackModel {
+	m.timeout = timeout
+	return m
+}
+
+// WithErrorClassifier 设置判断错误是否需要转移的函数，返回 false 的错误（如请求参数错误）直接返回给调用方，
+// 也不计入熔断。默认对调用方取消或超时以外的所有错误转移
+func (m *FallbackModel) WithErrorClassifier(shouldFallback func(err error) bool) *FallbackModel {
+	m.shouldFallback = shouldFallback
+	return m
+}
+
+// WithCircuitBreaker 设置熔断器，模型连续失败 threshold 次后在 cooldown 内被跳过，默认5次、30秒；
+// threshold 为0表示不熔断
+func (m *FallbackModel) WithCircuitBreaker(threshold int, cooldown time.Duration) *FallbackModel {
+	m.failureThreshold = threshold
+	m.cooldown = cooldown
+	return m
+}
+
+// Stats 返回各模型的调用统计，按模型序号排列
+func (m *FallbackModel) Stats() []FallbackStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]FallbackStats, len(m.breakers))
+	for i, b := range m.breakers {
+		stats[i] = FallbackStats{
+			Index:               i,
+			Requests:            b.requests,
+			Failures:            b.failures,
+			ConsecutiveFailures: b.consecutiveFailures,
+			Open:                now.Before(b.openUntil),
+		}
+	}
+	return stats
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *FallbackModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	// 记录是否已向调用方输出内容
+	streamed := false
+	if opts.StreamingFunc != nil {
+		streamingFunc := opts.StreamingFunc
+		options = append(options[:len(options):len(options)], llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return streamingFunc(ctx, chunk)
+		}))
+	}
+
+	var errs []error
+	indexes, force := m.candidates()
+	for _, index := range indexes {
+		if !m.acquire(index, force) {
+			continue
+		}
+		resp, err := m.generate(ctx, index, messages, options)
+		if err == nil {
+			m.record(index, true)
+			for _, choice := range resp.Choices {
+				if choice.GenerationInfo == nil {
+					choice.GenerationInfo = make(map[string]any)
+				}
+				choice.GenerationInfo[FallbackIndexKey] = index
+			}
+			return resp, nil
+		}
+		if streamed || !m.fallback(ctx, err) {
+			m.release(index)
+			return nil, err
+		}
+		m.record(index, false)
+		errs = append(errs, fmt.Errorf("模型 %d: %w", index, err))
+	}
+	if len(errs) == 0 {
+		// 冷却期已过的模型正由其他请求试探
+		return nil, fmt.Errorf("%w: 所有模型均处于熔断状态", ErrAllModelsFailed)
+	}
+	return nil, fmt.Errorf("%w: %w", ErrAllModelsFailed, errors.Join(errs...))
+}
+
+// Call 实现 llms.Model 接口
+func (m *FallbackModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// generate 使用指定模型生成，设置了超时时间时限制单个模型的调用时间
+func (m *FallbackModel) generate(ctx context.Context, index int, messages []llms.MessageContent, options []llms.CallOption) (*llms.ContentResponse, error) {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	return m.models[index].GenerateContent(ctx, messages, options...)
+}
+
+// fallback 判断错误是否需要转移到下一个模型
+func (m *FallbackModel) fallback(ctx context.Context, err error) bool {
+	// 调用方已放弃请求
+	if ctx.Err() != nil {
+		return false
+	}
+	if m.shouldFallback != nil {
+		return m.shouldFallback(err)
+	}
+	return true
+}
+
+// candidates 返回本次请求依次尝试的模型序号，跳过熔断中的模型；
+// 所有模型都处于熔断状态时返回全部模型，force 为 true
+func (m *FallbackModel) candidates() (indexes []int, force bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	indexes = make([]int, 0, len(m.models))
+	for i, b := range m.breakers {
+		if !now.Before(b.openUntil) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) > 0 {
+		return indexes, false
+	}
+	for i := range m.models {
+		indexes = append(indexes, i)
+	}
+	return indexes, true
+}
+
+// acquire 在调用模型前检查熔断器，冷却期已过的模型同一时间只放行一个试探请求
+func (m *FallbackModel) acquire(index int, force bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := &m.breakers[index]
+	if !force && !b.openUntil.IsZero() {
+		if time.Now().Before(b.openUntil) || b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	b.requests++
+	return true
+}
+
+// record 记录模型调用结果，连续失败达到阈值时打开熔断器
+func (m *FallbackModel) record(index int, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := &m.breakers[index]
+	b.probing = false
+	if success {
+		b.consecutiveFailures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	b.consecutiveFailures++
+	if m.failureThreshold > 0 && b.consecutiveFailures >= m.failureThreshold {
+		b.openUntil = time.Now().Add(m.cooldown)
+	}
+}
+
+// release 结束试探请求但不改变熔断状态，用于不计入熔断的错误
+func (m *FallbackModel) release(index int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breakers[index].probing = false
+}
@@ -0,0 +1,87 @@
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestFallbackModel(t *testing.T) {
+	ctx := context.Background()
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")}
+
+	t.Run("主模型失败时使用备用模型", func(t *testing.T) {
+		backup := &fakeModel{reply: "备用回复"}
+		model := llmscn.NewFallbackModel(outageModel{}, backup)
+
+		resp, err := model.GenerateContent(ctx, messages)
+		require.NoError(t, err)
+		assert.Equal(t, "备用回复", resp.Choices[0].Content)
+		assert.Equal(t, 1, resp.Choices[0].GenerationInfo[llmscn.FallbackIndexKey])
+
+		stats := model.Stats()
+		assert.Equal(t, int64(1), stats[0].Failures)
+		assert.Equal(t, int64(1), stats[1].Requests)
+	})
+
+	t.Run("主模型超时时使用备用模型", func(t *testing.T) {
+		slow := newDelayModel("slow", time.Second, nil)
+		model := llmscn.NewFallbackModel(slow, &fakeModel{reply: "fast"}).WithTimeout(20 * time.Millisecond)
+
+		text, err := model.Call(ctx, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "fast", text)
+	})
+
+	t.Run("错误分类", func(t *testing.T) {
+		backup := &fakeModel{reply: "备用回复"}
+		model := llmscn.NewFallbackModel(outageModel{}, backup).
+			WithErrorClassifier(func(err error) bool { return false })
+
+		_, err := model.GenerateContent(ctx, messages)
+		assert.EqualError(t, err, "503 service unavailable")
+		assert.Empty(t, backup.received)
+		assert.Zero(t, model.Stats()[0].Failures)
+	})
+
+	t.Run("熔断后跳过主模型，冷却期后试探恢复", func(t *testing.T) {
+		backup := &fakeModel{reply: "备用回复"}
+		model := llmscn.NewFallbackModel(outageModel{}, backup).WithCircuitBreaker(2, 30*time.Millisecond)
+
+		for i := 0; i < 3; i++ {
+			_, err := model.GenerateContent(ctx, messages)
+			require.NoError(t, err)
+		}
+		stats := model.Stats()
+		assert.True(t, stats[0].Open)
+		assert.Equal(t, int64(2), stats[0].Requests)
+
+		time.Sleep(40 * time.Millisecond)
+		_, err := model.GenerateContent(ctx, messages)
+		require.NoError(t, err)
+		stats = model.Stats()
+		assert.Equal(t, int64(3), stats[0].Requests)
+		assert.True(t, stats[0].Open, "试探失败后重新熔断")
+		assert.Len(t, backup.received, 4)
+	})
+
+	t.Run("不转移的情况", func(t *testing.T) {
+		// 已输出部分内容
+		backup := &fakeModel{reply: "备用回复"}
+		_, err := llmscn.NewFallbackModel(outageModel{partial: "你"}, backup).GenerateContent(ctx, messages,
+			llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error { return nil }))
+		assert.Error(t, err)
+		assert.Empty(t, backup.received)
+
+		// 所有模型均失败
+		_, err = llmscn.NewFallbackModel(outageModel{}, outageModel{}).GenerateContent(ctx, messages)
+		assert.True(t, errors.Is(err, llmscn.ErrAllModelsFailed))
+		assert.ErrorContains(t, err, "模型 1")
+	})
+}
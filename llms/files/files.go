@@ -0,0 +1,66 @@
+// Package files 提供跨服务商的文件/文档接口抽象
+//
+// 不同服务商的文档问答能力依赖各自的文件接口：Moonshot(Kimi) 通过 Files API
+// 上传并抽取文本，通义千问 qwen-long 通过 fileid:// 引用已上传文件，
+// 本地实现则直接解析文本。Store 接口统一了这些差异，ContextMessage
+// 可以为任何 Store 生成可直接放入对话的上下文消息。
+package files
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrExtractNotSupported 表示该存储不支持直接抽取文本
+var ErrExtractNotSupported = errors.New("该文件存储不支持文本抽取")
+
+// ErrFileNotFound 表示文件不存在
+var ErrFileNotFound = errors.New("文件不存在")
+
+// File 文件元信息
+type File struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	Bytes     int64     `json:"bytes"`
+	Purpose   string    `json:"purpose,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store 文件存储接口
+type Store interface {
+	// Upload 上传文件
+	Upload(ctx context.Context, filename string, r io.Reader) (*File, error)
+	// Retrieve 获取文件元信息
+	Retrieve(ctx context.Context, id string) (*File, error)
+	// Delete 删除文件
+	Delete(ctx context.Context, id string) error
+	// ExtractText 抽取文件文本内容
+	ExtractText(ctx context.Context, id string) (string, error)
+}
+
+// Referencer 由可以在对话中直接引用文件的存储实现（如 qwen-long 的 fileid://）
+type Referencer interface {
+	// Reference 返回在系统消息中引用文件的文本，不支持时返回空字符串
+	Reference(id string) string
+}
+
+// ContextMessage 为文件生成可放入对话的系统消息
+// 支持直接引用的存储返回引用文本，其他存储返回抽取出的文件内容
+func ContextMessage(ctx context.Context, store Store, id string) (llms.MessageContent, error) {
+	if ref, ok := store.(Referencer); ok {
+		if reference := ref.Reference(id); reference != "" {
+			return llms.TextParts(llms.ChatMessageTypeSystem, reference), nil
+		}
+	}
+
+	text, err := store.ExtractText(ctx, id)
+	if err != nil {
+		return llms.MessageContent{}, err
+	}
+	return llms.TextParts(llms.ChatMessageTypeSystem, text), nil
+}
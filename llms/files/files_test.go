@@ -0,0 +1,79 @@
+package files_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := files.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	file, err := store.Upload(ctx, "doc.html", strings.NewReader("<h1>标题</h1><p>正文 &amp; 内容</p><script>x()</script>"))
+	require.NoError(t, err)
+	assert.Equal(t, "doc.html", file.Filename)
+
+	text, err := store.ExtractText(ctx, file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "标题\n正文 & 内容", text)
+
+	msg, err := files.ContextMessage(ctx, store, file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, llms.ChatMessageTypeSystem, msg.Role)
+
+	require.NoError(t, store.Delete(ctx, file.ID))
+	_, err = store.Retrieve(ctx, file.ID)
+	assert.ErrorIs(t, err, files.ErrFileNotFound)
+}
+
+func TestRemoteStores(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			assert.Equal(t, files.PurposeFileExtract, r.FormValue("purpose"))
+			w.Write([]byte(`{"id":"file-1","filename":"a.txt","bytes":5,"purpose":"file-extract","created_at":1700000000}`))
+		case r.URL.Path == "/files/file-1/content":
+			w.Write([]byte(`{"content":"hello"}`))
+		case r.URL.Path == "/files/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	moonshot, err := files.NewMoonshotStore(files.WithAPIKey("test-key"), files.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	file, err := moonshot.Upload(ctx, "a.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "file-1", file.ID)
+
+	msg, err := files.ContextMessage(ctx, moonshot, file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, llms.TextParts(llms.ChatMessageTypeSystem, "hello"), msg)
+
+	_, err = moonshot.Retrieve(ctx, "missing")
+	assert.ErrorIs(t, err, files.ErrFileNotFound)
+
+	qwen, err := files.NewQwenStore(files.WithAPIKey("test-key"), files.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	_, err = qwen.ExtractText(ctx, file.ID)
+	assert.ErrorIs(t, err, files.ErrExtractNotSupported)
+
+	msg, err = files.ContextMessage(ctx, qwen, file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, llms.TextParts(llms.ChatMessageTypeSystem, "fileid://file-1"), msg)
+}
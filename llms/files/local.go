@@ -0,0 +1,233 @@
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalStore 基于本地目录的文件存储，文本抽取使用内置解析器完成
+// 支持纯文本类文件（txt、md、csv、json 等）、HTML 以及 docx
+type LocalStore struct {
+	dir  string
+	lock sync.RWMutex
+}
+
+// NewLocalStore 创建本地文件存储
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// Upload 实现 Store 接口
+func (s *LocalStore) Upload(ctx context.Context, filename string, r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件内容失败: %w", err)
+	}
+
+	id, err := newFileID()
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{
+		ID:        id,
+		Filename:  filepath.Base(filename),
+		Bytes:     int64(len(data)),
+		Purpose:   PurposeFileExtract,
+		Status:    "processed",
+		CreatedAt: time.Now(),
+	}
+	meta, err := json.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("序列化文件信息失败: %w", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := os.WriteFile(s.dataPath(id), data, 0644); err != nil {
+		return nil, fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.WriteFile(s.metaPath(id), meta, 0644); err != nil {
+		os.Remove(s.dataPath(id))
+		return nil, fmt.Errorf("写入文件信息失败: %w", err)
+	}
+
+	return file, nil
+}
+
+// Retrieve 实现 Store 接口
+func (s *LocalStore) Retrieve(ctx context.Context, id string) (*File, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	data, err := os.ReadFile(s.metaPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析文件信息失败: %w", err)
+	}
+	return &file, nil
+}
+
+// Delete 实现 Store 接口
+func (s *LocalStore) Delete(ctx context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err := os.Stat(s.metaPath(id)); os.IsNotExist(err) {
+		return ErrFileNotFound
+	}
+	if err := os.Remove(s.dataPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	if err := os.Remove(s.metaPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件信息失败: %w", err)
+	}
+	return nil
+}
+
+// ExtractText 实现 Store 接口
+func (s *LocalStore) ExtractText(ctx context.Context, id string) (string, error) {
+	file, err := s.Retrieve(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	s.lock.RLock()
+	data, err := os.ReadFile(s.dataPath(id))
+	s.lock.RUnlock()
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	return ExtractText(file.Filename, data)
+}
+
+// dataPath 返回文件内容路径
+func (s *LocalStore) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".data")
+}
+
+// metaPath 返回文件信息路径
+func (s *LocalStore) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// newFileID 生成随机文件ID
+func newFileID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成文件ID失败: %w", err)
+	}
+	return "file-" + hex.EncodeToString(b), nil
+}
+
+// ================================
+// 内置文本解析器
+// ================================
+
+var (
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr)[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]+>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// ExtractText 根据文件扩展名使用内置解析器抽取文本
+func ExtractText(filename string, data []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".txt", ".md", ".markdown", ".csv", ".tsv", ".json", ".xml", ".yaml", ".yml", ".log", "":
+		return string(data), nil
+	case ".html", ".htm":
+		return extractHTML(string(data)), nil
+	case ".docx":
+		return extractDocx(data)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrExtractNotSupported, filepath.Ext(filename))
+	}
+}
+
+// extractHTML 去除HTML标签，保留段落换行
+func extractHTML(content string) string {
+	content = htmlDropPattern.ReplaceAllString(content, "")
+	content = htmlBreakPattern.ReplaceAllString(content, "\n")
+	content = htmlTagPattern.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+	content = blankLinePattern.ReplaceAllString(content, "\n\n")
+	return strings.TrimSpace(content)
+}
+
+// extractDocx 从 docx 的 word/document.xml 中抽取段落文本
+func extractDocx(data []byte) (string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("解析docx失败: %w", err)
+	}
+
+	for _, f := range reader.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("解析docx失败: %w", err)
+		}
+		defer rc.Close()
+
+		var sb strings.Builder
+		decoder := xml.NewDecoder(rc)
+		inText := false
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("解析docx失败: %w", err)
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				inText = t.Name.Local == "t"
+				if t.Name.Local == "tab" {
+					sb.WriteString("\t")
+				}
+			case xml.EndElement:
+				if t.Name.Local == "p" {
+					sb.WriteString("\n")
+				}
+				inText = false
+			case xml.CharData:
+				if inText {
+					sb.Write(t)
+				}
+			}
+		}
+		return strings.TrimSpace(sb.String()), nil
+	}
+
+	return "", fmt.Errorf("解析docx失败: 缺少 word/document.xml")
+}
@@ -0,0 +1,266 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// MoonshotBaseURL Moonshot(Kimi) API基础URL
+	MoonshotBaseURL = "https://api.moonshot.cn/v1"
+	// QwenBaseURL 通义千问 OpenAI 兼容模式基础URL
+	QwenBaseURL = "https://dashscope.aliyuncs.com/compatible-mode/v1"
+
+	// PurposeFileExtract 用于文档抽取/问答的文件用途
+	PurposeFileExtract = "file-extract"
+)
+
+// Doer 执行HTTP请求
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Option 远程文件存储的配置选项
+type Option func(*options)
+
+// options 远程文件存储的配置
+type options struct {
+	apiKey     string
+	baseURL    string
+	purpose    string
+	httpClient Doer
+}
+
+// WithAPIKey 设置API密钥
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithBaseURL 设置API基础URL
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithPurpose 设置上传文件的用途，默认为 file-extract
+func WithPurpose(purpose string) Option {
+	return func(o *options) {
+		o.purpose = purpose
+	}
+}
+
+// WithHTTPClient 设置自定义HTTP客户端
+func WithHTTPClient(client Doer) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// RemoteStore 基于 OpenAI 兼容 Files API 的文件存储
+type RemoteStore struct {
+	apiKey     string
+	baseURL    string
+	purpose    string
+	httpClient Doer
+
+	// extractable 表示服务端支持通过 /files/{id}/content 抽取文本
+	extractable bool
+	// referencePrefix 非空时表示支持在对话中直接引用文件
+	referencePrefix string
+}
+
+// NewMoonshotStore 创建 Moonshot(Kimi) Files API 存储
+// 默认从环境变量 KIMI_API_KEY 读取API密钥
+func NewMoonshotStore(opts ...Option) (*RemoteStore, error) {
+	o := &options{
+		apiKey:  os.Getenv("KIMI_API_KEY"),
+		baseURL: MoonshotBaseURL,
+	}
+	return newRemoteStore(o, opts, true, "")
+}
+
+// NewQwenStore 创建通义千问(qwen-long) 文件存储
+// 上传后的文件通过 fileid:// 在系统消息中引用，默认从环境变量 QWEN_API_KEY 读取API密钥
+func NewQwenStore(opts ...Option) (*RemoteStore, error) {
+	o := &options{
+		apiKey:  os.Getenv("QWEN_API_KEY"),
+		baseURL: QwenBaseURL,
+	}
+	return newRemoteStore(o, opts, false, "fileid://")
+}
+
+// newRemoteStore 应用选项并创建远程存储
+func newRemoteStore(o *options, opts []Option, extractable bool, referencePrefix string) (*RemoteStore, error) {
+	o.purpose = PurposeFileExtract
+	o.httpClient = http.DefaultClient
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.apiKey == "" {
+		return nil, errors.New("API密钥不能为空")
+	}
+
+	return &RemoteStore{
+		apiKey:          o.apiKey,
+		baseURL:         strings.TrimSuffix(o.baseURL, "/"),
+		purpose:         o.purpose,
+		httpClient:      o.httpClient,
+		extractable:     extractable,
+		referencePrefix: referencePrefix,
+	}, nil
+}
+
+// remoteFile Files API返回的文件对象
+type remoteFile struct {
+	ID        string `json:"id"`
+	Filename  string `json:"filename"`
+	Bytes     int64  `json:"bytes"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// toFile 转换为通用文件元信息
+func (f *remoteFile) toFile() *File {
+	return &File{
+		ID:        f.ID,
+		Filename:  f.Filename,
+		Bytes:     f.Bytes,
+		Purpose:   f.Purpose,
+		Status:    f.Status,
+		CreatedAt: time.Unix(f.CreatedAt, 0),
+	}
+}
+
+// Upload 实现 Store 接口
+func (s *RemoteStore) Upload(ctx context.Context, filename string, r io.Reader) (*File, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.WriteField("purpose", s.purpose); err != nil {
+		return nil, fmt.Errorf("构建上传请求失败: %w", err)
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("构建上传请求失败: %w", err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("读取文件内容失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("构建上传请求失败: %w", err)
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, "/files", body, writer.FormDataContentType())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var file remoteFile
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return file.toFile(), nil
+}
+
+// Retrieve 实现 Store 接口
+func (s *RemoteStore) Retrieve(ctx context.Context, id string) (*File, error) {
+	resp, err := s.do(ctx, http.MethodGet, "/files/"+id, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var file remoteFile
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return file.toFile(), nil
+}
+
+// Delete 实现 Store 接口
+func (s *RemoteStore) Delete(ctx context.Context, id string) error {
+	resp, err := s.do(ctx, http.MethodDelete, "/files/"+id, nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ExtractText 实现 Store 接口
+func (s *RemoteStore) ExtractText(ctx context.Context, id string) (string, error) {
+	if !s.extractable {
+		return "", ErrExtractNotSupported
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, "/files/"+id+"/content", nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	// Moonshot 返回 {"content": "..."}，其他实现可能直接返回文本
+	var content struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &content); err == nil && content.Content != "" {
+		return content.Content, nil
+	}
+	return string(data), nil
+}
+
+// Reference 实现 Referencer 接口，仅在服务端支持文件引用时可用
+func (s *RemoteStore) Reference(id string) string {
+	if s.referencePrefix == "" {
+		return ""
+	}
+	return s.referencePrefix + id
+}
+
+// do 发送请求并检查响应状态
+func (s *RemoteStore) do(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrFileNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API错误 (%d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return resp, nil
+}
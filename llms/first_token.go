@@ -0,0 +1,142 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// FirstTokenTimeoutKey 是首个token超时时间在 CallOptions.Metadata 中的键
+const FirstTokenTimeoutKey = "first_token_timeout"
+
+// ErrFirstTokenTimeout 表示流式调用在规定时间内没有收到任何输出，请求已被取消
+// 可通过 errors.Is 判断，据此触发故障转移
+var ErrFirstTokenTimeout = errors.New("首个token超时")
+
+// WithFirstTokenTimeout 设置单次流式调用的首个token超时时间：在 d 内没有收到任何输出（包括推理内容）时取消请求，
+// 返回 ErrFirstTokenTimeout。与整体超时（context 的截止时间）相互独立，收到首个token后不再限制生成时长。
+// 由 FirstTokenTimeoutModel 执行，CreateLLM 创建的所有模型均已包装；非流式调用不受影响
+func WithFirstTokenTimeout(d time.Duration) llms.CallOption {
+	return func(o *llms.CallOptions) {
+		current := CallMetadata(*o)
+		metadata := make(map[string]interface{}, len(current)+1)
+		for k, v := range current {
+			metadata[k] = v
+		}
+		metadata[FirstTokenTimeoutKey] = d
+		SetCallMetadata(o, metadata)
+	}
+}
+
+// FirstTokenTimeout 返回调用选项中设置的首个token超时时间，未设置时返回0
+func FirstTokenTimeout(opts llms.CallOptions) time.Duration {
+	d, _ := CallMetadata(opts)[FirstTokenTimeoutKey].(time.Duration)
+	return d
+}
+
+// FirstTokenTimeoutModel 首个token超时装饰器
+// 流式调用在超时时间内没有回调任何输出时取消请求，服务商的请求随 context 一同取消
+type FirstTokenTimeoutModel struct {
+	model   llms.Model
+	timeout time.Duration
+}
+
+var _ llms.Model = (*FirstTokenTimeoutModel)(nil)
+
+// NewFirstTokenTimeoutModel 创建首个token超时装饰器，timeout 为调用未通过 WithFirstTokenTimeout 设置时的默认值，0 表示不限制
+func NewFirstTokenTimeoutModel(model llms.Model, timeout time.Duration) *FirstTokenTimeoutModel {
+	return &FirstTokenTimeoutModel{
+		model:   model,
+		timeout: timeout,
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *FirstTokenTimeoutModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	timeout := FirstTokenTimeout(opts)
+	if timeout <= 0 {
+		timeout = m.timeout
+	}
+	if timeout <= 0 || opts.StreamingFunc == nil && opts.StreamingReasoningFunc == nil {
+		return m.model.GenerateContent(ctx, messages, options...)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	timer := time.AfterFunc(timeout, func() {
+		cancel(ErrFirstTokenTimeout)
+	})
+	defer timer.Stop()
+
+	// 收到首个非空输出时停止计时；计时器已触发时拒绝迟到的输出
+	var once sync.Once
+	received := func(chunks ...[]byte) error {
+		for _, chunk := range chunks {
+			if len(chunk) == 0 {
+				continue
+			}
+			err := error(nil)
+			once.Do(func() {
+				if !timer.Stop() {
+					err = context.Cause(ctx)
+				}
+			})
+			return err
+		}
+		return nil
+	}
+
+	options = options[:len(options):len(options)]
+	if streamingFunc := opts.StreamingFunc; streamingFunc != nil {
+		options = append(options, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			if err := received(chunk); err != nil {
+				return err
+			}
+			return streamingFunc(ctx, chunk)
+		}))
+	}
+	if reasoningFunc := opts.StreamingReasoningFunc; reasoningFunc != nil {
+		options = append(options, llms.WithStreamingReasoningFunc(func(ctx context.Context, reasoningChunk, chunk []byte) error {
+			if err := received(reasoningChunk, chunk); err != nil {
+				return err
+			}
+			return reasoningFunc(ctx, reasoningChunk, chunk)
+		}))
+	}
+
+	resp, err := m.model.GenerateContent(ctx, messages, options...)
+	if err != nil && errors.Is(context.Cause(ctx), ErrFirstTokenTimeout) {
+		return nil, fmt.Errorf("%w（%s）: %w", ErrFirstTokenTimeout, timeout, err)
+	}
+	return resp, err
+}
+
+// Call 实现 llms.Model 接口
+func (m *FirstTokenTimeoutModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// durationParam 读取以毫秒为单位的数值参数，也接受 time.Duration
+func durationParam(params map[string]interface{}, key string) (time.Duration, error) {
+	switch v := params[key].(type) {
+	case nil:
+		return 0, nil
+	case time.Duration:
+		return v, nil
+	case int:
+		return time.Duration(v) * time.Millisecond, nil
+	case float64:
+		return time.Duration(v * float64(time.Millisecond)), nil
+	default:
+		return 0, fmt.Errorf("参数 %s 必须是毫秒数，实际为 %T", key, v)
+	}
+}
@@ -0,0 +1,86 @@
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// slowStreamModel 模拟流式输出，每个分片前等待对应的时间，等待期间响应 context 取消
+type slowStreamModel struct {
+	delays []time.Duration
+	chunks []string
+}
+
+func (m slowStreamModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	content := ""
+	for i, chunk := range m.chunks {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(m.delays[i]):
+		}
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+		content += chunk
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: content}}}, nil
+}
+
+func (m slowStreamModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestFirstTokenTimeout(t *testing.T) {
+	ctx := context.Background()
+	stream := llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error { return nil })
+
+	// 首个token迟迟不到，请求被取消
+	stuck := llmscn.NewFirstTokenTimeoutModel(slowStreamModel{
+		delays: []time.Duration{300 * time.Millisecond},
+		chunks: []string{"你好"},
+	}, 0)
+	start := time.Now()
+	_, err := stuck.Call(ctx, "你好", stream, llmscn.WithFirstTokenTimeout(20*time.Millisecond))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, llmscn.ErrFirstTokenTimeout))
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+
+	// 收到首个token后不再限制生成时长
+	slowTail := llmscn.NewFirstTokenTimeoutModel(slowStreamModel{
+		delays: []time.Duration{0, 50 * time.Millisecond},
+		chunks: []string{"你", "好"},
+	}, 20*time.Millisecond)
+	reply, err := slowTail.Call(ctx, "你好", stream)
+	require.NoError(t, err)
+	assert.Equal(t, "你好", reply)
+
+	// 非流式调用不受影响
+	reply, err = stuck.Call(ctx, "你好", llmscn.WithFirstTokenTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, "你好", reply)
+
+	// 调用方取消不视为首个token超时
+	cancelled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = stuck.Call(cancelled, "你好", stream, llmscn.WithFirstTokenTimeout(time.Minute))
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, llmscn.ErrFirstTokenTimeout))
+
+	// 参数必须是毫秒数
+	_, err = llmscn.CreateLLM(llmscn.DeepSeekLLM, map[string]interface{}{"api_key": "x", "first_token_timeout_ms": "5s"})
+	assert.ErrorContains(t, err, "first_token_timeout_ms")
+}
@@ -0,0 +1,218 @@
+// Package auth 提供服务商客户端可插拔的请求认证方式
+//
+// 默认情况下各服务商使用 Bearer 令牌认证；部分企业部署（如阿里云 VPC 内的通义千问、
+// 私有化部署的智谱）要求 AK/SK 签名或自定义认证头。认证方式在请求体改写完成后、
+// 发送之前对请求签名。本包为内部包，供 llms 根包与各服务商子包共用，对外接口见 llms.AuthProvider。
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Provider 请求认证方式
+type Provider interface {
+	// Sign 在请求发送前为其添加认证信息
+	Sign(req *http.Request) error
+}
+
+// Func 将函数适配为 Provider，适用于只需设置自定义认证头的场景
+type Func func(req *http.Request) error
+
+// Sign 实现 Provider 接口
+func (f Func) Sign(req *http.Request) error {
+	return f(req)
+}
+
+// Apply 移除客户端默认设置的 Bearer 认证头后使用 provider 签名，provider 为 nil 时不做处理
+func Apply(provider Provider, req *http.Request) error {
+	if provider == nil {
+		return nil
+	}
+	req.Header.Del("Authorization")
+	if err := provider.Sign(req); err != nil {
+		return fmt.Errorf("请求签名失败: %w", err)
+	}
+	return nil
+}
+
+// Bearer Bearer 令牌认证
+type Bearer struct {
+	Token string
+}
+
+// Sign 实现 Provider 接口
+func (b Bearer) Sign(req *http.Request) error {
+	if b.Token == "" {
+		return fmt.Errorf("bearer 令牌为空")
+	}
+	req.Header.Set("Authorization", "Bearer "+b.Token)
+	return nil
+}
+
+// AKSKAlgorithm AK/SK 签名算法名称
+const AKSKAlgorithm = "ACS3-HMAC-SHA256"
+
+// AKSK 阿里云 V3 风格的 AK/SK 签名（ACS3-HMAC-SHA256）
+//
+// 签名覆盖请求方法、路径、查询参数、host、content-type 与全部 x-acs-* 头以及请求体摘要，
+// 结果写入 Authorization 头：
+//
+//	ACS3-HMAC-SHA256 Credential=<AccessKeyID>,SignedHeaders=<headers>,Signature=<signature>
+type AKSK struct {
+	// AccessKeyID 访问密钥ID
+	AccessKeyID string
+	// AccessKeySecret 访问密钥
+	AccessKeySecret string
+	// SecurityToken STS 临时凭证的安全令牌，可选
+	SecurityToken string
+
+	// now 与 nonce 用于测试中固定签名结果
+	now   func() time.Time
+	nonce func() string
+}
+
+// Sign 实现 Provider 接口
+func (a *AKSK) Sign(req *http.Request) error {
+	if a.AccessKeyID == "" || a.AccessKeySecret == "" {
+		return fmt.Errorf("AccessKeyID 与 AccessKeySecret 不能为空")
+	}
+
+	payload, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256.Sum256(payload)
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	nonce := randomNonce
+	if a.nonce != nil {
+		nonce = a.nonce
+	}
+
+	req.Header.Set("x-acs-date", now().UTC().Format("2006-01-02T15:04:05Z"))
+	req.Header.Set("x-acs-signature-nonce", nonce())
+	req.Header.Set("x-acs-content-sha256", hex.EncodeToString(payloadHash[:]))
+	if a.SecurityToken != "" {
+		req.Header.Set("x-acs-security-token", a.SecurityToken)
+	}
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := AKSKAlgorithm + "\n" + hex.EncodeToString(requestHash[:])
+
+	mac := hmac.New(sha256.New, []byte(a.AccessKeySecret))
+	mac.Write([]byte(stringToSign))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s,SignedHeaders=%s,Signature=%s",
+		AKSKAlgorithm, a.AccessKeyID, signedHeaders, signature))
+	return nil
+}
+
+// readBody 读取请求体并恢复，以便签名后正常发送
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	return data, nil
+}
+
+// canonicalURI 返回规范化的请求路径
+func canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// canonicalQuery 返回按参数名排序并编码的查询字符串
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, percentEncode(key)+"="+percentEncode(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// percentEncode 按 RFC 3986 编码，空格编码为 %20
+func percentEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// canonicalHeaders 返回参与签名的头名称列表与规范化的头内容
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-acs-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// randomNonce 生成签名随机数，防止重放
+func randomNonce() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,223 @@
+// Package media 提供跨服务商共享的多模态内容编码
+//
+// 上游 llms.ContentPart 无法在包外扩展，OpenAI 兼容客户端也会丢弃未知的内容片段，
+// 因此音频以 audio/* MIME 类型的 llms.BinaryContent 表示，
+// 由各服务商在发送请求前改写为对应的请求格式（input_audio、audio_url 等）。
+// 本包为内部包，供 llms 根包与各服务商子包共用，对外接口见 llms.AudioContent。
+package media
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// urlParam 标记 BinaryContent.Data 中存放的是音频地址而不是音频数据
+const urlParam = "source"
+
+// ErrCapabilityNotSupported 表示模型不支持请求中的输入类型
+var ErrCapabilityNotSupported = errors.New("模型不支持该输入类型")
+
+// CapabilityError 模型能力错误，可与 ErrCapabilityNotSupported 匹配
+type CapabilityError struct {
+	// Provider 服务商名称
+	Provider string
+	// Model 模型名称
+	Model string
+	// Capability 缺少的能力，如 audio
+	Capability string
+}
+
+// Error 实现 error 接口
+func (e *CapabilityError) Error() string {
+	return fmt.Sprintf("%s 模型 %s 不支持 %s 输入", e.Provider, e.Model, e.Capability)
+}
+
+// Is 使能力错误可与 ErrCapabilityNotSupported 匹配
+func (e *CapabilityError) Is(target error) bool {
+	return target == ErrCapabilityNotSupported
+}
+
+// 音频请求格式
+const (
+	// StyleInputAudio OpenAI 风格：{"type":"input_audio","input_audio":{"data":...,"format":...}}
+	StyleInputAudio = "input_audio"
+	// StyleAudioURL {"type":"audio_url","audio_url":{"url":...}}
+	StyleAudioURL = "audio_url"
+)
+
+// Audio 音频输入
+type Audio struct {
+	// Format 音频格式，如 wav、mp3
+	Format string `json:"format"`
+	// Data 音频数据，与 URL 二选一
+	Data []byte `json:"data,omitempty"`
+	// URL 音频地址
+	URL string `json:"url,omitempty"`
+}
+
+// Source 返回音频地址，音频数据以 data URI 形式返回
+func (a Audio) Source() string {
+	if a.URL != "" {
+		return a.URL
+	}
+	return "data:audio/" + a.Format + ";base64," + base64.StdEncoding.EncodeToString(a.Data)
+}
+
+// NewPart 将音频编码为内容片段
+func NewPart(audio Audio) llms.BinaryContent {
+	mimeType := "audio/" + normalizeFormat(audio.Format)
+	if audio.URL != "" {
+		return llms.BinaryContent{
+			MIMEType: mime.FormatMediaType(mimeType, map[string]string{urlParam: "url"}),
+			Data:     []byte(audio.URL),
+		}
+	}
+	return llms.BinaryContent{MIMEType: mimeType, Data: audio.Data}
+}
+
+// FormatFromURL 根据地址的扩展名推断音频格式
+func FormatFromURL(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	return normalizeFormat(strings.TrimPrefix(path.Ext(url), "."))
+}
+
+// normalizeFormat 规范化音频格式名称
+func normalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "", "x-wav", "wave":
+		return "wav"
+	case "mpeg", "mpga":
+		return "mp3"
+	}
+	return format
+}
+
+// Parse 解析音频内容片段，不是音频时返回 false
+func Parse(part llms.ContentPart) (Audio, bool) {
+	binary, ok := part.(llms.BinaryContent)
+	if !ok {
+		return Audio{}, false
+	}
+	return parseBinary(binary.MIMEType, binary.Data)
+}
+
+// parseBinary 解析音频 MIME 类型及数据
+func parseBinary(mimeType string, data []byte) (Audio, bool) {
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return Audio{}, false
+	}
+	format, ok := strings.CutPrefix(mediaType, "audio/")
+	if !ok {
+		return Audio{}, false
+	}
+	audio := Audio{Format: normalizeFormat(format)}
+	if params[urlParam] == "url" {
+		audio.URL = string(data)
+	} else {
+		audio.Data = data
+	}
+	return audio, true
+}
+
+// HasAudio 判断消息中是否包含音频
+func HasAudio(messages []llms.MessageContent) bool {
+	for _, message := range messages {
+		for _, part := range message.Parts {
+			if _, ok := Parse(part); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RewriteAudioParts 将请求体中以 binary 片段发送的音频改写为指定格式
+// 请求体无法解析或不包含音频时原样返回
+func RewriteAudioParts(data []byte, style string) []byte {
+	if !strings.Contains(string(data), `"audio/`) {
+		return data
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return data
+	}
+	var messages []map[string]json.RawMessage
+	if err := json.Unmarshal(body["messages"], &messages); err != nil {
+		return data
+	}
+
+	rewritten := false
+	for _, message := range messages {
+		var parts []json.RawMessage
+		if err := json.Unmarshal(message["content"], &parts); err != nil {
+			continue // 纯文本内容
+		}
+		changed := false
+		for i, raw := range parts {
+			var part struct {
+				Type   string `json:"type"`
+				Binary struct {
+					MIMEType string `json:"mime_type"`
+					Data     []byte `json:"data"`
+				} `json:"binary"`
+			}
+			if err := json.Unmarshal(raw, &part); err != nil || part.Type != "binary" {
+				continue
+			}
+			audio, ok := parseBinary(part.Binary.MIMEType, part.Binary.Data)
+			if !ok {
+				continue
+			}
+			if encoded, err := json.Marshal(audioPart(audio, style)); err == nil {
+				parts[i] = encoded
+				changed = true
+			}
+		}
+		if changed {
+			if encoded, err := json.Marshal(parts); err == nil {
+				message["content"] = encoded
+				rewritten = true
+			}
+		}
+	}
+	if !rewritten {
+		return data
+	}
+
+	encoded, err := json.Marshal(messages)
+	if err != nil {
+		return data
+	}
+	body["messages"] = encoded
+	result, err := json.Marshal(body)
+	if err != nil {
+		return data
+	}
+	return result
+}
+
+// audioPart 按请求格式构造音频片段
+func audioPart(audio Audio, style string) map[string]interface{} {
+	if style == StyleAudioURL {
+		return map[string]interface{}{
+			"type":      StyleAudioURL,
+			"audio_url": map[string]string{"url": audio.Source()},
+		}
+	}
+	return map[string]interface{}{
+		"type":        StyleInputAudio,
+		"input_audio": map[string]string{"data": audio.Source(), "format": audio.Format},
+	}
+}
@@ -0,0 +1,53 @@
+package media
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestRewriteAudioParts(t *testing.T) {
+	// 与 OpenAI 兼容客户端序列化多模态消息的方式一致
+	body, err := json.Marshal(map[string]interface{}{
+		"model": "qwen-omni-turbo",
+		"messages": []map[string]interface{}{
+			{"role": "system", "content": "你是一个助手"},
+			{"role": "user", "content": []llms.ContentPart{
+				llms.TextContent{Text: "这段录音说了什么？"},
+				NewPart(Audio{Format: "mp3", Data: []byte("ID3")}),
+				NewPart(Audio{Format: "wav", URL: "https://example.com/a.wav"}),
+				llms.BinaryContent{MIMEType: "image/png", Data: []byte{1}},
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	var request struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(RewriteAudioParts(body, StyleInputAudio), &request))
+	assert.Equal(t, "qwen-omni-turbo", request.Model)
+	assert.JSONEq(t, `"你是一个助手"`, string(request.Messages[0].Content))
+	var parts []map[string]interface{}
+	require.NoError(t, json.Unmarshal(request.Messages[1].Content, &parts))
+	require.Len(t, parts, 4)
+	assert.Equal(t, "text", parts[0]["type"])
+	assert.Equal(t, map[string]interface{}{"type": "input_audio", "input_audio": map[string]interface{}{"data": "data:audio/mp3;base64,SUQz", "format": "mp3"}}, parts[1])
+	assert.Equal(t, map[string]interface{}{"type": "input_audio", "input_audio": map[string]interface{}{"data": "https://example.com/a.wav", "format": "wav"}}, parts[2])
+	assert.Equal(t, "binary", parts[3]["type"])
+
+	require.NoError(t, json.Unmarshal(RewriteAudioParts(body, StyleAudioURL), &request))
+	parts = nil
+	require.NoError(t, json.Unmarshal(request.Messages[1].Content, &parts))
+	assert.Equal(t, map[string]interface{}{"type": "audio_url", "audio_url": map[string]interface{}{"url": "https://example.com/a.wav"}}, parts[2])
+
+	// 不包含音频时原样返回
+	plain := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+	assert.Equal(t, plain, RewriteAudioParts(plain, StyleInputAudio))
+}
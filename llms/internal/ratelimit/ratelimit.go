@@ -0,0 +1,163 @@
+// Package ratelimit 提供可在多个服务商客户端之间共享的令牌桶限流器
+//
+// 同一个限流器可以传给多个客户端（如多个租户各自创建的通义千问客户端），
+// 共同限制对同一账号的请求速率，避免触发服务商的限流。
+// 本包为内部包，供 llms 根包与各服务商子包共用，对外接口见 llms.RateLimiter。
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited 表示请求因超出排队时长或排队数量上限被拒绝
+var ErrRateLimited = errors.New("请求超出限流，已被拒绝")
+
+// Options 限流器配置
+type Options struct {
+	// QPS 每秒允许的请求数，必须大于0
+	QPS float64
+	// Burst 允许的突发请求数，即令牌桶容量，默认为1
+	Burst int
+	// MaxWait 单个请求最长的排队时间，预计等待超过该时间的请求立即被拒绝，0表示不限制
+	MaxWait time.Duration
+	// MaxQueue 最多同时排队的请求数，超出时新请求立即被拒绝，0表示不限制
+	MaxQueue int
+}
+
+// Stats 限流统计
+type Stats struct {
+	// Allowed 已放行的请求数，包括排队后放行的请求
+	Allowed int64 `json:"allowed"`
+	// Queued 需要排队等待的请求数
+	Queued int64 `json:"queued"`
+	// Rejected 被拒绝的请求数，包括排队期间被取消的请求
+	Rejected int64 `json:"rejected"`
+	// Waiting 当前正在排队的请求数
+	Waiting int `json:"waiting"`
+	// TotalWait 放行的请求累计排队时间
+	TotalWait time.Duration `json:"total_wait"`
+}
+
+// Limiter 令牌桶限流器，可安全地被多个客户端并发使用
+type Limiter struct {
+	options Options
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	stats  Stats
+}
+
+// New 创建限流器，令牌桶初始为满
+func New(options Options) *Limiter {
+	if options.Burst <= 0 {
+		options.Burst = 1
+	}
+	return &Limiter{
+		options: options,
+		tokens:  float64(options.Burst),
+		last:    time.Now(),
+	}
+}
+
+// Wait 等待获取一个令牌
+// 预计等待时间超过 MaxWait 或排队数量达到 MaxQueue 时立即返回 ErrRateLimited，
+// 排队期间 ctx 被取消时返回 ctx 的错误
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l.options.QPS <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	if l.tokens >= 1 {
+		l.tokens--
+		l.stats.Allowed++
+		l.mu.Unlock()
+		return nil
+	}
+
+	// 预留令牌，按欠缺的令牌数计算等待时间，排在前面的请求先放行
+	wait := time.Duration((1 - l.tokens) / l.options.QPS * float64(time.Second))
+	if (l.options.MaxWait > 0 && wait > l.options.MaxWait) ||
+		(l.options.MaxQueue > 0 && l.stats.Waiting >= l.options.MaxQueue) {
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+	l.tokens--
+	l.stats.Queued++
+	l.stats.Waiting++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++ // 归还预留的令牌
+		l.stats.Waiting--
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		l.mu.Lock()
+		l.stats.Waiting--
+		l.stats.Allowed++
+		l.stats.TotalWait += wait
+		l.mu.Unlock()
+		return nil
+	}
+}
+
+// Stats 返回限流统计
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// refill 按经过的时间补充令牌，调用方需持有锁
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+	l.tokens = min(l.tokens+elapsed*l.options.QPS, float64(l.options.Burst))
+}
+
+// Doer 发送HTTP请求，与 http.Client 及各服务商客户端的 Doer 接口一致
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Wrap 返回发送请求前先获取令牌的 Doer，limiter 为 nil 时直接返回 next
+func Wrap(next Doer, limiter *Limiter) Doer {
+	if limiter == nil {
+		return next
+	}
+	return &client{next: next, limiter: limiter}
+}
+
+// client 限流的 Doer
+type client struct {
+	next    Doer
+	limiter *Limiter
+}
+
+// Do 实现 Doer 接口
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return c.next.Do(req)
+}
@@ -0,0 +1,152 @@
+// Package retry 为服务商的HTTP请求提供自动重试
+//
+// 请求遇到 429、5xx 或超时时按带抖动的指数退避重试，响应带有 Retry-After 时按其等待。
+// 重试只发生在收到响应头之前或响应状态表示失败时，流式响应开始输出后不会重试。
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultBackoff 首次重试前的默认等待时间
+	DefaultBackoff = 500 * time.Millisecond
+	// DefaultMaxBackoff 退避等待时间的上限，不限制 Retry-After
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// maxDrainBytes 重试前读取并丢弃的失败响应体的最大字节数，以便复用连接
+const maxDrainBytes = 64 << 10
+
+// Doer 发送HTTP请求，与 http.Client 及各服务商客户端的 Doer 接口一致
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Policy 重试策略
+type Policy struct {
+	// MaxRetries 最大重试次数，0 表示不重试
+	MaxRetries int
+	// Backoff 首次重试前的等待时间，之后每次翻倍，默认 DefaultBackoff
+	Backoff time.Duration
+	// MaxBackoff 退避等待时间的上限，默认 DefaultMaxBackoff
+	MaxBackoff time.Duration
+}
+
+// Wrap 返回按策略重试的 Doer，MaxRetries 不大于0时直接返回 next
+func Wrap(next Doer, policy Policy) Doer {
+	if policy.MaxRetries <= 0 {
+		return next
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = DefaultBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultMaxBackoff
+	}
+	return &client{next: next, policy: policy}
+}
+
+// client 按策略重试的 Doer
+type client struct {
+	next   Doer
+	policy Policy
+}
+
+// Do 实现 Doer 接口
+func (c *client) Do(req *http.Request) (*http.Response, error) {
+	// 缓存请求体，每次尝试重新发送
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		if body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			attemptReq.ContentLength = int64(len(body))
+		}
+
+		resp, err := c.next.Do(attemptReq)
+		if attempt >= c.policy.MaxRetries || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := c.policy.delay(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry 判断请求是否可以重试：限流、服务端错误或超时
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// delay 计算第 attempt 次失败后的等待时间
+// 响应带有 Retry-After 时按其等待，否则在指数退避时间的 50%～100% 之间随机取值
+func (p Policy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	backoff := p.MaxBackoff
+	if attempt < 32 {
+		if d := p.Backoff << attempt; d > 0 && d < p.MaxBackoff {
+			backoff = d
+		}
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+// retryAfter 解析 Retry-After 响应头，支持秒数和HTTP日期两种格式
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
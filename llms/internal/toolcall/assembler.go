@@ -0,0 +1,213 @@
+// Package toolcall 提供流式响应中工具调用增量的累积
+//
+// OpenAI 兼容接口在流式响应中将工具调用拆分为多个增量：首个增量携带 id、类型和函数名，
+// 后续增量只携带 index 与参数片段，多个并行调用的增量可能交错到达。
+// 本包为内部包，供 llms 根包与各服务商子包共用，对外接口见 llms.ToolCallAssembler。
+package toolcall
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Delta 工具调用增量
+type Delta struct {
+	// Index 调用在响应中的序号，为 nil 时按 ID 匹配，ID 也为空时视为上一个调用的后续片段
+	Index *int
+	// ID 调用ID，通常只在首个增量中出现
+	ID string
+	// Type 调用类型，默认为 function
+	Type string
+	// Name 函数名称
+	Name string
+	// Arguments 参数片段，按到达顺序拼接
+	Arguments string
+}
+
+// call 正在累积的工具调用
+type call struct {
+	index     int
+	id        string
+	typ       string
+	name      string
+	arguments []byte
+}
+
+// Assembler 将流式工具调用增量累积为完整的工具调用，可并发使用
+type Assembler struct {
+	mu    sync.Mutex
+	calls []*call
+	last  *call
+}
+
+// New 创建工具调用累积器
+func New() *Assembler {
+	return &Assembler{}
+}
+
+// Add 累积一个增量
+func (a *Assembler) Add(delta Delta) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c := a.find(delta)
+	if c == nil {
+		index := a.nextIndex()
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		c = &call{index: index}
+		a.calls = append(a.calls, c)
+	}
+	if delta.ID != "" {
+		c.id = delta.ID
+	}
+	if delta.Type != "" {
+		c.typ = delta.Type
+	}
+	if delta.Name != "" {
+		c.name = delta.Name
+	}
+	c.arguments = append(c.arguments, delta.Arguments...)
+	a.last = c
+}
+
+// find 查找增量所属的调用，新调用返回 nil
+func (a *Assembler) find(delta Delta) *call {
+	if delta.Index != nil {
+		for _, c := range a.calls {
+			if c.index == *delta.Index {
+				return c
+			}
+		}
+		return nil
+	}
+	if delta.ID != "" {
+		for _, c := range a.calls {
+			if c.id == delta.ID {
+				return c
+			}
+		}
+		return nil
+	}
+	return a.last
+}
+
+// nextIndex 返回未携带序号的新调用使用的序号
+func (a *Assembler) nextIndex() int {
+	next := 0
+	for _, c := range a.calls {
+		if c.index >= next {
+			next = c.index + 1
+		}
+	}
+	return next
+}
+
+// rawDelta OpenAI 兼容格式的工具调用增量
+type rawDelta struct {
+	Index    *int   `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// AddRaw 累积 OpenAI 兼容格式的 tool_calls 增量，value 可以是解码后的数组或单个对象、
+// 对应的 JSON 字节或 json.RawMessage；参数既可以是字符串片段，也可以是完整的JSON对象
+func (a *Assembler) AddRaw(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("序列化工具调用增量失败: %w", err)
+		}
+	}
+
+	var deltas []rawDelta
+	if err := json.Unmarshal(data, &deltas); err != nil {
+		var single rawDelta
+		if err := json.Unmarshal(data, &single); err != nil {
+			return fmt.Errorf("解析工具调用增量失败: %w", err)
+		}
+		deltas = []rawDelta{single}
+	}
+
+	for _, d := range deltas {
+		a.Add(Delta{
+			Index:     d.Index,
+			ID:        d.ID,
+			Type:      d.Type,
+			Name:      d.Function.Name,
+			Arguments: rawArguments(d.Function.Arguments),
+		})
+	}
+	return nil
+}
+
+// rawArguments 将参数转换为字符串：字符串片段原样返回，JSON对象返回其JSON文本
+func rawArguments(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var fragment string
+	if err := json.Unmarshal(raw, &fragment); err == nil {
+		return fragment
+	}
+	return string(raw)
+}
+
+// Len 返回已累积的工具调用数量
+func (a *Assembler) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.calls)
+}
+
+// ToolCalls 返回按序号排序的工具调用，类型默认为 function
+func (a *Assembler) ToolCalls() []llms.ToolCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	calls := append([]*call(nil), a.calls...)
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].index < calls[j].index })
+
+	result := make([]llms.ToolCall, len(calls))
+	for i, c := range calls {
+		typ := c.typ
+		if typ == "" {
+			typ = "function"
+		}
+		result[i] = llms.ToolCall{
+			ID:   c.id,
+			Type: typ,
+			FunctionCall: &llms.FunctionCall{
+				Name:      c.name,
+				Arguments: string(c.arguments),
+			},
+		}
+	}
+	return result
+}
+
+// Reset 清空已累积的工具调用
+func (a *Assembler) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls = nil
+	a.last = nil
+}
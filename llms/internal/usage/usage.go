@@ -0,0 +1,88 @@
+// Package usage 统一各服务商在 ContentChoice.GenerationInfo 中记录的token用量
+//
+// 用量同时以 prompt_tokens 等统一的键和 openai 客户端使用的 PromptTokens 等键写入，
+// 已有的读取方无需修改。本包为内部包，供 llms 根包与各服务商子包共用，对外接口见 llms.TokenUsage。
+package usage
+
+import "github.com/tmc/langchaingo/llms"
+
+// 统一的用量键
+const (
+	PromptTokensKey     = "prompt_tokens"
+	CompletionTokensKey = "completion_tokens"
+	TotalTokensKey      = "total_tokens"
+)
+
+// openai 客户端使用的用量键
+const (
+	legacyPromptTokensKey     = "PromptTokens"
+	legacyCompletionTokensKey = "CompletionTokens"
+	legacyTotalTokensKey      = "TotalTokens"
+)
+
+// Set 将用量写入 GenerationInfo，info 为 nil 时创建并返回新的映射
+// total 为 0 时按 prompt 与 completion 之和计算
+func Set(info map[string]any, prompt, completion, total int) map[string]any {
+	if info == nil {
+		info = make(map[string]any, 6)
+	}
+	if total == 0 {
+		total = prompt + completion
+	}
+	info[PromptTokensKey] = prompt
+	info[CompletionTokensKey] = completion
+	info[TotalTokensKey] = total
+	info[legacyPromptTokensKey] = prompt
+	info[legacyCompletionTokensKey] = completion
+	info[legacyTotalTokensKey] = total
+	return info
+}
+
+// SetAll 将同一次请求的用量写入响应的每个候选
+func SetAll(resp *llms.ContentResponse, prompt, completion, total int) {
+	if resp == nil {
+		return
+	}
+	for _, choice := range resp.Choices {
+		if choice != nil {
+			choice.GenerationInfo = Set(choice.GenerationInfo, prompt, completion, total)
+		}
+	}
+}
+
+// Normalize 为 openai 客户端返回的响应补充统一的用量键
+func Normalize(resp *llms.ContentResponse) {
+	if resp == nil {
+		return
+	}
+	for _, choice := range resp.Choices {
+		if choice == nil || choice.GenerationInfo == nil {
+			continue
+		}
+		prompt, completion, total := Get(choice.GenerationInfo)
+		Set(choice.GenerationInfo, prompt, completion, total)
+	}
+}
+
+// Get 读取 GenerationInfo 中的用量，优先使用统一的键
+func Get(info map[string]any) (prompt, completion, total int) {
+	prompt = lookup(info, PromptTokensKey, legacyPromptTokensKey)
+	completion = lookup(info, CompletionTokensKey, legacyCompletionTokensKey)
+	total = lookup(info, TotalTokensKey, legacyTotalTokensKey)
+	return prompt, completion, total
+}
+
+// lookup 按顺序读取第一个存在的整数值
+func lookup(info map[string]any, keys ...string) int {
+	for _, key := range keys {
+		switch v := info[key].(type) {
+		case int:
+			return v
+		case int64:
+			return int(v)
+		case float64:
+			return int(v)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,349 @@
+package llms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// jsonNumberPattern 匹配合法的JSON数字
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// JSONRepairStats 按模型统计的JSON解析情况
+type JSONRepairStats struct {
+	// Parsed 无需修复即可解析的次数
+	Parsed int64 `json:"parsed"`
+	// Repaired 修复后解析成功的次数
+	Repaired int64 `json:"repaired"`
+	// Failed 修复后仍无法解析的次数
+	Failed int64 `json:"failed"`
+}
+
+var (
+	jsonRepairMu    sync.Mutex
+	jsonRepairStats = make(map[string]*JSONRepairStats)
+)
+
+// JSONRepairMetrics 返回各模型的JSON修复统计，用于观察哪些模型经常输出不合法的JSON
+func JSONRepairMetrics() map[string]JSONRepairStats {
+	jsonRepairMu.Lock()
+	defer jsonRepairMu.Unlock()
+
+	metrics := make(map[string]JSONRepairStats, len(jsonRepairStats))
+	for model, stats := range jsonRepairStats {
+		metrics[model] = *stats
+	}
+	return metrics
+}
+
+// ResetJSONRepairMetrics 清空JSON修复统计
+func ResetJSONRepairMetrics() {
+	jsonRepairMu.Lock()
+	defer jsonRepairMu.Unlock()
+	jsonRepairStats = make(map[string]*JSONRepairStats)
+}
+
+// recordJSONRepair 记录一次JSON解析结果
+func recordJSONRepair(model string, record func(*JSONRepairStats)) {
+	jsonRepairMu.Lock()
+	defer jsonRepairMu.Unlock()
+
+	stats, ok := jsonRepairStats[model]
+	if !ok {
+		stats = &JSONRepairStats{}
+		jsonRepairStats[model] = stats
+	}
+	record(stats)
+}
+
+// ParseJSONOutput 将模型输出解析到 v，解析失败时先尝试 RepairJSON 修复再解析
+// model 用于按模型统计修复频率，可通过 JSONRepairMetrics 查看
+func ParseJSONOutput(model, output string, v interface{}) error {
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), v); err == nil {
+		recordJSONRepair(model, func(s *JSONRepairStats) { s.Parsed++ })
+		return nil
+	}
+
+	repaired, err := RepairJSON(output)
+	if err == nil {
+		err = json.Unmarshal([]byte(repaired), v)
+	}
+	if err != nil {
+		recordJSONRepair(model, func(s *JSONRepairStats) { s.Failed++ })
+		return fmt.Errorf("解析模型输出的JSON失败: %w", err)
+	}
+	recordJSONRepair(model, func(s *JSONRepairStats) { s.Repaired++ })
+	return nil
+}
+
+// RepairJSON 容错修复模型输出的不规范JSON
+// 支持：Markdown 代码块与前后说明文字、中文引号与标点、单引号字符串、未加引号的键、
+// 尾随逗号、注释、Python 风格的 True/False/None，以及被截断的字符串、对象和数组
+func RepairJSON(s string) (string, error) {
+	trimmed := strings.TrimSpace(s)
+	if json.Valid([]byte(trimmed)) {
+		return trimmed, nil
+	}
+
+	start := strings.IndexAny(trimmed, "{[")
+	if start < 0 {
+		return "", errors.New("未找到JSON对象或数组")
+	}
+
+	r := &jsonRepairer{runes: []rune(trimmed[start:])}
+	repaired := r.repair()
+	if !json.Valid([]byte(repaired)) {
+		return "", fmt.Errorf("无法修复JSON: %s", truncateForError(repaired))
+	}
+	return repaired, nil
+}
+
+// jsonRepairer 单遍扫描输入，输出修复后的JSON
+type jsonRepairer struct {
+	runes []rune
+	pos   int
+	out   strings.Builder
+	// stack 未闭合的括号对应的结束符
+	stack []rune
+	// expectKey 当前处于对象中且下一个值应为键
+	expectKey bool
+	// pendingKey 已读到键但还没有读到冒号
+	pendingKey bool
+}
+
+func (r *jsonRepairer) repair() string {
+	for r.pos < len(r.runes) {
+		c := r.runes[r.pos]
+		switch {
+		case unicode.IsSpace(c):
+			r.out.WriteRune(c)
+			r.pos++
+
+		case c == '{' || c == '[':
+			if c == '{' {
+				r.stack = append(r.stack, '}')
+			} else {
+				r.stack = append(r.stack, ']')
+			}
+			r.out.WriteRune(c)
+			r.pos++
+			r.expectKey = c == '{'
+
+		case c == '}' || c == ']':
+			r.pos++
+			if len(r.stack) == 0 {
+				continue
+			}
+			r.trimTrailingComma()
+			r.closeValue()
+			r.out.WriteRune(r.stack[len(r.stack)-1])
+			r.stack = r.stack[:len(r.stack)-1]
+			r.expectKey = false
+			if len(r.stack) == 0 {
+				// 根节点已闭合，忽略后面的说明文字或代码块结束符
+				return r.out.String()
+			}
+
+		case c == ',' || c == '，':
+			r.out.WriteRune(',')
+			r.pos++
+			r.expectKey = r.inObject()
+
+		case c == ':' || c == '：':
+			r.out.WriteRune(':')
+			r.pos++
+			r.pendingKey = false
+			r.expectKey = false
+
+		case c == '/' && r.peek(1) == '/':
+			for r.pos < len(r.runes) && r.runes[r.pos] != '\n' {
+				r.pos++
+			}
+
+		case c == '/' && r.peek(1) == '*':
+			r.pos += 2
+			for r.pos < len(r.runes) && !(r.runes[r.pos] == '*' && r.peek(1) == '/') {
+				r.pos++
+			}
+			r.pos += 2
+
+		case c == '"' || c == '\'' || c == '“' || c == '”' || c == '‘' || c == '’':
+			r.readString(c)
+			r.afterValue()
+
+		case c == '-' || c == '_' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			r.readWord()
+			r.afterValue()
+
+		default:
+			// 忽略无法识别的字符
+			r.pos++
+		}
+	}
+
+	// 输入被截断：补全未闭合的结构
+	r.trimTrailingComma()
+	r.closeValue()
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		r.out.WriteRune(r.stack[i])
+	}
+	return r.out.String()
+}
+
+func (r *jsonRepairer) peek(offset int) rune {
+	if r.pos+offset < len(r.runes) {
+		return r.runes[r.pos+offset]
+	}
+	return 0
+}
+
+func (r *jsonRepairer) inObject() bool {
+	return len(r.stack) > 0 && r.stack[len(r.stack)-1] == '}'
+}
+
+// afterValue 在读完字符串或裸值后更新键值状态
+func (r *jsonRepairer) afterValue() {
+	if r.expectKey {
+		r.pendingKey = true
+		r.expectKey = false
+	}
+}
+
+// closeValue 为缺少冒号或值的键补上 null
+func (r *jsonRepairer) closeValue() {
+	current := strings.TrimRightFunc(r.out.String(), unicode.IsSpace)
+	switch {
+	case r.pendingKey:
+		r.reset(current + ":null")
+	case strings.HasSuffix(current, ":"):
+		r.reset(current + "null")
+	}
+	r.pendingKey = false
+}
+
+// trimTrailingComma 删除结束符前多余的逗号
+func (r *jsonRepairer) trimTrailingComma() {
+	current := strings.TrimRightFunc(r.out.String(), unicode.IsSpace)
+	if strings.HasSuffix(current, ",") {
+		r.reset(strings.TrimSuffix(current, ","))
+	}
+}
+
+func (r *jsonRepairer) reset(s string) {
+	r.out.Reset()
+	r.out.WriteString(s)
+}
+
+// readString 读取一个字符串，统一输出为双引号字符串，截断时自动闭合
+func (r *jsonRepairer) readString(open rune) {
+	closers := map[rune]bool{open: true}
+	switch open {
+	case '“', '”':
+		closers = map[rune]bool{'“': true, '”': true}
+	case '‘', '’':
+		closers = map[rune]bool{'‘': true, '’': true}
+	}
+
+	r.out.WriteRune('"')
+	r.pos++
+	for r.pos < len(r.runes) {
+		c := r.runes[r.pos]
+		r.pos++
+		switch {
+		case c == '\\':
+			if r.pos < len(r.runes) {
+				next := r.runes[r.pos]
+				r.pos++
+				if next == '\'' {
+					// \' 在JSON中不是合法转义
+					r.out.WriteRune('\'')
+				} else {
+					r.out.WriteRune('\\')
+					r.out.WriteRune(next)
+				}
+			}
+		case closers[c]:
+			r.out.WriteRune('"')
+			return
+		case c == '"':
+			r.out.WriteString(`\"`)
+		case c == '\n':
+			r.out.WriteString(`\n`)
+		case c == '\r':
+			r.out.WriteString(`\r`)
+		case c == '\t':
+			r.out.WriteString(`\t`)
+		case c < 0x20:
+			fmt.Fprintf(&r.out, `\u%04x`, c)
+		default:
+			r.out.WriteRune(c)
+		}
+	}
+	// 字符串被截断
+	r.out.WriteRune('"')
+}
+
+// readWord 读取未加引号的键或值
+func (r *jsonRepairer) readWord() {
+	start := r.pos
+	for r.pos < len(r.runes) {
+		c := r.runes[r.pos]
+		if unicode.IsSpace(c) || strings.ContainsRune(",，:：{}[]\"'“”‘’", c) {
+			break
+		}
+		r.pos++
+	}
+	word := string(r.runes[start:r.pos])
+
+	if r.expectKey {
+		r.writeQuoted(word)
+		return
+	}
+
+	switch word {
+	case "true", "false", "null":
+		r.out.WriteString(word)
+		return
+	case "True":
+		r.out.WriteString("true")
+		return
+	case "False":
+		r.out.WriteString("false")
+		return
+	case "None", "undefined", "NaN", "nil":
+		r.out.WriteString("null")
+		return
+	}
+
+	if jsonNumberPattern.MatchString(word) {
+		r.out.WriteString(word)
+		return
+	}
+	// 截断的数字，如 "1." 或 "2e"
+	if r.pos == len(r.runes) {
+		if trimmed := strings.TrimRight(word, ".eE+-"); jsonNumberPattern.MatchString(trimmed) {
+			r.out.WriteString(trimmed)
+			return
+		}
+	}
+	r.writeQuoted(strings.TrimSpace(word))
+}
+
+func (r *jsonRepairer) writeQuoted(s string) {
+	encoded, _ := json.Marshal(s)
+	r.out.Write(encoded)
+}
+
+// truncateForError 截断过长的内容，避免错误信息过大
+func truncateForError(s string) string {
+	const limit = 200
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "..."
+}
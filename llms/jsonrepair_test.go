@@ -0,0 +1,56 @@
+package llms_test
+
+import (
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Valid", `{"a": 1}`, `{"a": 1}`},
+		{"TrailingComma", `{"a": [1, 2,], "b": 2,}`, `{"a": [1, 2], "b": 2}`},
+		{"UnquotedKeys", `{name: "张三", age: 18}`, `{"name": "张三", "age": 18}`},
+		{"ChineseQuotes", `{“name”：“张三”，“city”：“北京”}`, `{"name":"张三","city":"北京"}`},
+		{"SingleQuotes", `{'a': 'it\'s "ok"'}`, `{"a": "it's \"ok\""}`},
+		{"Python", `{"ok": True, "err": None}`, `{"ok": true, "err": null}`},
+		{"CodeFence", "好的，结果如下：\n```json\n{\"a\": 1}\n```\n希望有帮助", `{"a": 1}`},
+		{"Comments", "{\n  // 名称\n  \"a\": 1 /* 数量 */\n}", "{\n  \n  \"a\": 1 \n}"},
+		{"TruncatedString", `{"a": "hel`, `{"a": "hel"}`},
+		{"TruncatedNested", `{"items": [{"id": 1}, {"id": 2`, `{"items": [{"id": 1}, {"id": 2}]}`},
+		{"TruncatedAfterKey", `{"a": 1, "b"`, `{"a": 1, "b":null}`},
+		{"TruncatedAfterColon", `{"a": 1, "b":`, `{"a": 1, "b":null}`},
+		{"TruncatedNumber", `[1, 2.`, `[1, 2]`},
+		{"RawNewline", "{\"a\": \"line1\nline2\"}", `{"a": "line1\nline2"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llmscn.RepairJSON(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := llmscn.RepairJSON("没有JSON")
+	assert.Error(t, err)
+}
+
+func TestParseJSONOutput(t *testing.T) {
+	llmscn.ResetJSONRepairMetrics()
+
+	var v struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, llmscn.ParseJSONOutput("glm-4", `{"name": "a"}`, &v))
+	require.NoError(t, llmscn.ParseJSONOutput("glm-4", `{name: "b",}`, &v))
+	assert.Equal(t, "b", v.Name)
+	assert.Error(t, llmscn.ParseJSONOutput("glm-4", "抱歉", &v))
+
+	assert.Equal(t, llmscn.JSONRepairStats{Parsed: 1, Repaired: 1, Failed: 1}, llmscn.JSONRepairMetrics()["glm-4"])
+}
@@ -9,6 +9,8 @@ import (
 	"log"
 	"net/http"
 	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/toolcall"
 )
 
 // CreateChat 创建一个聊天请求
@@ -134,6 +136,7 @@ func parseStreamingChatResponse(ctx context.Context, r *http.Response, request *
 
 	// 处理流式响应
 	var lastResponse *ChatResponseChunk
+	assembler := toolcall.New()
 	for streamResponse := range responseChan {
 		if streamResponse.Err != nil {
 			return nil, streamResponse.Err
@@ -141,6 +144,9 @@ func parseStreamingChatResponse(ctx context.Context, r *http.Response, request *
 
 		// 更新响应
 		lastResponse = streamResponse.Response
+		if usage := streamResponse.Response.TokenUsage(); usage != nil {
+			response.Usage = *usage
+		}
 
 		// 处理delta
 		for _, choice := range streamResponse.Response.Choices {
@@ -163,8 +169,9 @@ func parseStreamingChatResponse(ctx context.Context, r *http.Response, request *
 
 			// 处理工具调用
 			if toolCalls, ok := choice.Delta["tool_calls"]; ok && toolCalls != nil {
-				// 这里可以添加工具调用的处理逻辑
-				// 由于工具调用的处理比较复杂，这里暂时不实现
+				if err := assembler.AddRaw(toolCalls); err != nil {
+					return nil, err
+				}
 			}
 
 			// 更新完成原因
@@ -174,6 +181,10 @@ func parseStreamingChatResponse(ctx context.Context, r *http.Response, request *
 		}
 	}
 
+	if assembler.Len() > 0 {
+		response.Choices[0].Message.ToolCalls = assembler.ToolCalls()
+	}
+
 	// 更新响应的其他字段
 	if lastResponse != nil {
 		response.ID = lastResponse.ID
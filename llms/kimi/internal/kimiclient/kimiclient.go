@@ -82,10 +82,17 @@ type ChatRequest struct {
 	Stream      bool          `json:"stream,omitempty"`
 	Tools       []Tool        `json:"tools,omitempty"`
 	ToolChoice  interface{}   `json:"tool_choice,omitempty"`
+	// ResponseFormat 设置为 json_object 时启用JSON模式
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 
 	StreamingFunc func(ctx context.Context, chunk []byte) error `json:"-"`
 }
 
+// ResponseFormat 是响应格式
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
 // ChatMessage 是聊天消息
 type ChatMessage struct {
 	Role      string      `json:"role"`
@@ -117,11 +124,14 @@ type ChatResponse struct {
 		Message      ChatMessage `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage Usage `json:"usage"`
+}
+
+// Usage 是token用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // ChatResponseChunk 是流式聊天响应的块
@@ -134,7 +144,23 @@ type ChatResponseChunk struct {
 		Index        int `json:"index"`
 		Delta        map[string]interface{} `json:"delta"`
 		FinishReason string `json:"finish_reason"`
+		Usage        *Usage `json:"usage,omitempty"`
 	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// TokenUsage 返回数据块中的用量，只有最后一个数据块携带用量
+// Moonshot 将用量放在 choices[0].usage 中，兼容放在顶层的格式
+func (c *ChatResponseChunk) TokenUsage() *Usage {
+	if c.Usage != nil {
+		return c.Usage
+	}
+	for _, choice := range c.Choices {
+		if choice.Usage != nil {
+			return choice.Usage
+		}
+	}
+	return nil
 }
 
 func (c *Client) setDefaults(payload *ChatRequest) {
@@ -8,8 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/toolcall"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
 	"github.com/sjzsdu/langchaingo-cn/llms/kimi/internal/kimiclient"
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
@@ -111,9 +116,12 @@ func New(opts ...Option) (*LLM, error) {
 		clientOpts = append(clientOpts, kimiclient.WithBaseURL(options.baseURL))
 	}
 
+	// 按限流器和重试策略包装HTTP客户端
+	var httpClient retry.Doer = http.DefaultClient
 	if options.httpClient != nil {
-		clientOpts = append(clientOpts, kimiclient.WithHTTPClient(options.httpClient))
+		httpClient = options.httpClient
 	}
+	clientOpts = append(clientOpts, kimiclient.WithHTTPClient(retry.Wrap(ratelimit.Wrap(httpClient, options.rateLimiter), options.retryPolicy)))
 
 	// 创建客户端
 	baseURL := options.baseURL
@@ -176,11 +184,7 @@ func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOptio
 		TopP:        o.config.TopP,
 		MaxTokens:   o.config.MaxTokens,
 	}
-
-	// 处理JSON模式
-	if llmOptions.JSONMode {
-		// Kimi可能不支持JSON模式，这里可以添加相关处理
-	}
+	applyCallOptions(&request, llmOptions)
 
 	// 发送请求
 	response, err := o.client.CreateChat(ctx, &request)
@@ -256,6 +260,7 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		Stream:        llmOptions.StreamingFunc != nil,
 		StreamingFunc: llmOptions.StreamingFunc,
 	}
+	applyCallOptions(&request, llmOptions)
 
 	// 处理工具调用
 	if llmOptions.Tools != nil && len(llmOptions.Tools) > 0 {
@@ -303,6 +308,11 @@ func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		contentResponse.Choices[0].Content = contentStr
 	}
 
+	// 处理用量
+	if u := response.Usage; u.PromptTokens+u.CompletionTokens+u.TotalTokens > 0 {
+		contentResponse.Choices[0].GenerationInfo = usage.Set(nil, u.PromptTokens, u.CompletionTokens, u.TotalTokens)
+	}
+
 	// 处理工具调用
 	if response.Choices[0].Message.ToolCalls != nil {
 		var toolCalls []interface{}
@@ -620,8 +630,9 @@ type streamingContentResponse struct {
 	errChan          <-chan error
 	callbacksHandler callbacks.Handler
 	text             strings.Builder
-	toolCalls        []llms.ToolCall
+	toolCalls        *toolcall.Assembler
 	currentChoice    *llms.ContentChoice
+	usage            *kimiclient.Usage
 }
 
 // GetChunk 获取下一个内容块
@@ -643,16 +654,24 @@ func (s *streamingContentResponse) GetChunk() (string, error) {
 					Choices: []*llms.ContentChoice{
 						{
 							Content:    s.text.String(),
-							ToolCalls:  s.toolCalls,
+							ToolCalls:  s.toolCalls.ToolCalls(),
 							StopReason: s.currentChoice.StopReason,
 						},
 					},
 				}
+				if s.usage != nil {
+					contentResponse.Choices[0].GenerationInfo = usage.Set(nil, s.usage.PromptTokens, s.usage.CompletionTokens, s.usage.TotalTokens)
+				}
 				s.callbacksHandler.HandleLLMGenerateContentEnd(s.ctx, contentResponse)
 			}
 			return "", io.EOF
 		}
 
+		// 最后一个数据块携带用量
+		if u := chunk.TokenUsage(); u != nil {
+			s.usage = u
+		}
+
 		// 提取内容
 		var content string
 		if len(chunk.Choices) > 0 {
@@ -667,102 +686,12 @@ func (s *streamingContentResponse) GetChunk() (string, error) {
 				s.text.WriteString(content)
 			}
 
-			// 处理工具调用
-			var toolCallsData []interface{}
-			switch tc := chunk.Choices[0].Delta["tool_calls"].(type) {
-			case []interface{}:
-				// 直接使用数组
-				toolCallsData = tc
-			case map[string]interface{}:
-				// 如果是单个工具调用，包装为数组
-				toolCallsData = []interface{}{tc}
-			default:
-				// 尝试通过JSON转换
-				if chunk.Choices[0].Delta["tool_calls"] != nil {
-					tcBytes, err := json.Marshal(chunk.Choices[0].Delta["tool_calls"])
-					if err == nil {
-						if err := json.Unmarshal(tcBytes, &toolCallsData); err != nil {
-							// 尝试作为单个对象解析
-							var singleToolCall map[string]interface{}
-							if err := json.Unmarshal(tcBytes, &singleToolCall); err == nil {
-								toolCallsData = []interface{}{singleToolCall}
-							}
-						}
-					}
-				}
-			}
-
-			if len(toolCallsData) > 0 {
-				// 初始化工具调用列表（如果需要）
-				if s.toolCalls == nil {
-					s.toolCalls = make([]llms.ToolCall, 0)
-				}
-
-				// 处理每个工具调用
-				for _, tc := range toolCallsData {
-					toolCall, ok := tc.(map[string]interface{})
-					if !ok {
-						continue
-					}
-
-					// 获取工具调用的索引
-					var idx int
-					if index, ok := toolCall["index"].(float64); ok {
-						idx = int(index)
-					} else {
-						// 如果没有索引，使用当前长度
-						idx = len(s.toolCalls)
-					}
-
-					// 确保工具调用列表有足够的空间
-					for len(s.toolCalls) <= idx {
-						s.toolCalls = append(s.toolCalls, llms.ToolCall{
-							Type:         "function",
-							FunctionCall: &llms.FunctionCall{},
-						})
-					}
-
-					// 更新ID
-					if id, ok := toolCall["id"].(string); ok {
-						s.toolCalls[idx].ID = id
-					}
-
-					// 更新类型
-					if typeStr, ok := toolCall["type"].(string); ok {
-						s.toolCalls[idx].Type = typeStr
-					}
-
-					// 处理函数调用
-					if function, ok := toolCall["function"].(map[string]interface{}); ok {
-						// 确保 FunctionCall 已初始化
-						if s.toolCalls[idx].FunctionCall == nil {
-							s.toolCalls[idx].FunctionCall = &llms.FunctionCall{}
-						}
-
-						// 更新函数名称
-						if name, ok := function["name"].(string); ok {
-							s.toolCalls[idx].FunctionCall.Name = name
-						}
-
-						// 更新函数参数
-						switch args := function["arguments"].(type) {
-						case string:
-							// 对于流式响应，可能需要累积参数
-							s.toolCalls[idx].FunctionCall.Arguments += args
-						default:
-							// 尝试将对象转换为JSON字符串
-							if function["arguments"] != nil {
-								argsBytes, err := json.Marshal(function["arguments"])
-								if err == nil {
-									s.toolCalls[idx].FunctionCall.Arguments += string(argsBytes)
-								}
-							}
-						}
-					}
+			// 处理工具调用，增量可能乱序、拆分或交错到达
+			if delta := chunk.Choices[0].Delta["tool_calls"]; delta != nil {
+				if err := s.toolCalls.AddRaw(delta); err != nil {
+					return "", err
 				}
-
-				// 更新当前选择的工具调用
-				s.currentChoice.ToolCalls = s.toolCalls
+				s.currentChoice.ToolCalls = s.toolCalls.ToolCalls()
 			}
 		}
 
@@ -799,6 +728,7 @@ func (o *LLM) StreamingCall(ctx context.Context, prompt string, options ...llms.
 		MaxTokens:   o.config.MaxTokens,
 		Stream:      true,
 	}
+	applyCallOptions(&request, llmOptions)
 
 	// 发送请求
 	chunkChan, errChan := o.client.CreateChatStream(ctx, &request)
@@ -843,6 +773,7 @@ func (o *LLM) StreamingGenerateContent(ctx context.Context, messages []llms.Mess
 		MaxTokens:   o.config.MaxTokens,
 		Stream:      true,
 	}
+	applyCallOptions(&request, llmOptions)
 
 	// 处理工具调用
 	if llmOptions.Tools != nil && len(llmOptions.Tools) > 0 {
@@ -872,7 +803,27 @@ func (o *LLM) StreamingGenerateContent(ctx context.Context, messages []llms.Mess
 		errChan:          errChan,
 		callbacksHandler: callbackHandler,
 		text:             strings.Builder{},
-		toolCalls:        []llms.ToolCall{},
+		toolCalls:        toolcall.New(),
 		currentChoice:    &llms.ContentChoice{},
 	}, nil
 }
+
+// applyCallOptions 用调用时传入的选项覆盖客户端默认的模型与采样参数
+func applyCallOptions(request *kimiclient.ChatRequest, options llms.CallOptions) {
+	if options.Model != "" {
+		request.Model = options.Model
+	}
+	if options.Temperature != 0 {
+		request.Temperature = options.Temperature
+	}
+	if options.TopP != 0 {
+		request.TopP = options.TopP
+	}
+	if options.MaxTokens != 0 {
+		request.MaxTokens = options.MaxTokens
+	}
+	// JSON模式，提示词中需要包含 JSON 字样
+	if options.JSONMode {
+		request.ResponseFormat = &kimiclient.ResponseFormat{Type: "json_object"}
+	}
+}
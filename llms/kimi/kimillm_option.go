@@ -3,7 +3,10 @@ package kimi
 import (
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
 	"github.com/tmc/langchaingo/callbacks"
 )
 
@@ -21,6 +24,12 @@ type options struct {
 	// httpClient 是自定义的HTTP客户端
 	httpClient *http.Client
 
+	// retryPolicy 是请求失败时的重试策略
+	retryPolicy retry.Policy
+
+	// rateLimiter 是可在多个客户端之间共享的限流器
+	rateLimiter *ratelimit.Limiter
+
 	// callbacksHandler 是回调处理器
 	callbacksHandler callbacks.Handler
 
@@ -77,6 +86,29 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
 // WithCallbacksHandler 设置回调处理器
 func WithCallbacksHandler(handler callbacks.Handler) Option {
 	return func(o *options) {
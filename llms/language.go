@@ -0,0 +1,207 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// 支持的回复语言
+const (
+	LanguageChinese = "zh"
+	LanguageEnglish = "en"
+)
+
+// ResponseLanguageKey 是回复语言在 CallOptions.Metadata 中的键
+const ResponseLanguageKey = "response_language"
+
+// TranslatedFromKey 回复经自动翻译后，原回复的语言记录在 GenerationInfo 的该键下
+const TranslatedFromKey = "translated_from"
+
+// ErrResponseLanguageMismatch 表示模型回复的语言与要求的语言不一致
+var ErrResponseLanguageMismatch = errors.New("回复语言不符合要求")
+
+// responseLanguageInstructions 各语言的系统指令，使用目标语言书写，国内模型对同语言的指令遵循得更稳定
+var responseLanguageInstructions = map[string]string{
+	LanguageChinese: "请始终使用简体中文回答。即使用户、上下文或工具返回的结果使用其他语言，也要用简体中文组织回答；代码、命令和专有名词可以保留原文。",
+	LanguageEnglish: "Always respond in English. Even if the user, the context or tool results use another language, write your answer in English; code, commands and proper nouns may stay as they are.",
+}
+
+// responseLanguageNames 翻译提示词中使用的语言名称
+var responseLanguageNames = map[string]string{
+	LanguageChinese: "Simplified Chinese",
+	LanguageEnglish: "English",
+}
+
+// WithResponseLanguage 要求单次调用使用指定语言（"zh" 或 "en"）回复
+// 由 LanguageEnforcedModel 注入系统指令并校验回复语言，优先于其默认语言
+func WithResponseLanguage(language string) llms.CallOption {
+	return func(o *llms.CallOptions) {
+		current := CallMetadata(*o)
+		metadata := make(map[string]interface{}, len(current)+1)
+		for k, v := range current {
+			metadata[k] = v
+		}
+		metadata[ResponseLanguageKey] = language
+		SetCallMetadata(o, metadata)
+	}
+}
+
+// ResponseLanguage 返回调用选项中要求的回复语言，未设置时返回空字符串
+func ResponseLanguage(opts llms.CallOptions) string {
+	language, _ := CallMetadata(opts)[ResponseLanguageKey].(string)
+	return language
+}
+
+// codeBlockPattern 匹配代码块与行内代码，检测语言时忽略
+var codeBlockPattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+
+// DetectResponseLanguage 检测回复的主要语言，返回 "zh"、"en"，文字过少无法判断时返回空字符串
+// 代码块与行内代码不参与检测，避免代码中的英文标识符影响中文回复的判断
+func DetectResponseLanguage(text string) string {
+	text = codeBlockPattern.ReplaceAllString(text, "")
+
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+
+	// 一个汉字承载的信息量大约相当于一个英文短词
+	switch {
+	case han+latin < 8:
+		return ""
+	case han*4 >= latin:
+		return LanguageChinese
+	default:
+		return LanguageEnglish
+	}
+}
+
+// LanguageEnforcedModel 回复语言装饰器
+// 国内模型在工具调用较多的对话中经常从中文漂移到英文（反之亦然）。该装饰器在请求中注入
+// 目标语言的系统指令，并检测回复的语言；不一致时可由翻译模型自动将回复翻译为目标语言，
+// 否则返回 ErrResponseLanguageMismatch。流式调用的输出已发送给调用方，只注入指令、不做校验
+type LanguageEnforcedModel struct {
+	model      llms.Model
+	language   string
+	translator llms.Model
+}
+
+var _ llms.Model = (*LanguageEnforcedModel)(nil)
+
+// NewLanguageEnforcedModel 创建回复语言装饰器，language 为默认回复语言，
+// 为空时仅对通过 WithResponseLanguage 指定语言的调用生效
+func NewLanguageEnforcedModel(model llms.Model, language string) *LanguageEnforcedModel {
+	return &LanguageEnforcedModel{
+		model:    model,
+		language: language,
+	}
+}
+
+// WithTranslation 回复语言不一致时使用 translator 自动翻译，translator 为 nil 时使用被包装的模型
+func (m *LanguageEnforcedModel) WithTranslation(translator llms.Model) *LanguageEnforcedModel {
+	if translator == nil {
+		translator = m.model
+	}
+	m.translator = translator
+	return m
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *LanguageEnforcedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	language := ResponseLanguage(opts)
+	if language == "" {
+		language = m.language
+	}
+	if language == "" {
+		return m.model.GenerateContent(ctx, messages, options...)
+	}
+	instruction, ok := responseLanguageInstructions[language]
+	if !ok {
+		return nil, fmt.Errorf("不支持的回复语言: %s", language)
+	}
+
+	resp, err := m.model.GenerateContent(ctx, withLanguageInstruction(messages, instruction), options...)
+	if err != nil || opts.StreamingFunc != nil || resp == nil {
+		return resp, err
+	}
+
+	for _, choice := range resp.Choices {
+		// 只包含工具调用的回复没有可检测的文本
+		detected := DetectResponseLanguage(choice.Content)
+		if detected == "" || detected == language {
+			continue
+		}
+		if m.translator == nil {
+			return nil, fmt.Errorf("%w: 期望 %s，实际 %s", ErrResponseLanguageMismatch, language, detected)
+		}
+
+		translated, err := m.translate(ctx, choice.Content, language)
+		if err != nil {
+			return nil, fmt.Errorf("翻译回复失败: %w", err)
+		}
+		choice.Content = translated
+		if choice.GenerationInfo == nil {
+			choice.GenerationInfo = make(map[string]any)
+		}
+		choice.GenerationInfo[TranslatedFromKey] = detected
+	}
+	return resp, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *LanguageEnforcedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// translate 将回复翻译为目标语言
+func (m *LanguageEnforcedModel) translate(ctx context.Context, text, language string) (string, error) {
+	prompt := fmt.Sprintf("Translate the following text into %s. Keep code, commands and Markdown formatting unchanged. Output only the translation.\n\n%s",
+		responseLanguageNames[language], text)
+	resp, err := m.translator.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Content) == "" {
+		return "", fmt.Errorf("翻译结果为空")
+	}
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+// withLanguageInstruction 将语言指令并入开头的系统消息，没有系统消息时在最前面插入一条
+// 智谱、通义千问等服务商只接受位于开头的单条系统消息，因此不追加新的系统消息
+func withLanguageInstruction(messages []llms.MessageContent, instruction string) []llms.MessageContent {
+	result := make([]llms.MessageContent, 0, len(messages)+1)
+	if len(messages) > 0 && messages[0].Role == llms.ChatMessageTypeSystem {
+		// 部分服务商只读取系统消息的第一段文本，因此合并为一段
+		var text strings.Builder
+		for _, part := range messages[0].Parts {
+			if t, ok := PartText(part); ok {
+				text.WriteString(t)
+			}
+		}
+		text.WriteString("\n\n" + instruction)
+		result = append(result, llms.TextParts(llms.ChatMessageTypeSystem, strings.TrimSpace(text.String())))
+		return append(result, messages[1:]...)
+	}
+
+	result = append(result, llms.TextParts(llms.ChatMessageTypeSystem, instruction))
+	return append(result, messages...)
+}
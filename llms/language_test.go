@@ -0,0 +1,101 @@
+package llms_test
+
+import (
+	"context"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// scriptedModel 依次返回预设的回复
+type scriptedModel struct {
+	replies  []string
+	received [][]llms.MessageContent
+}
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.received = append(m.received, messages)
+	reply := m.replies[0]
+	if len(m.replies) > 1 {
+		m.replies = m.replies[1:]
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: reply}}}, nil
+}
+
+func (m *scriptedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestDetectResponseLanguage(t *testing.T) {
+	assert.Equal(t, "zh", llmscn.DetectResponseLanguage("查询结果显示北京今天晴，气温二十五度。"))
+	assert.Equal(t, "en", llmscn.DetectResponseLanguage("The weather in Beijing is sunny today."))
+	// 代码不影响判断
+	assert.Equal(t, "zh", llmscn.DetectResponseLanguage("可以这样调用：\n```go\nclient := weather.NewClient(apiKey)\nresult, err := client.Query(ctx, \"Beijing\")\n```\n返回今天的天气。"))
+	assert.Equal(t, "", llmscn.DetectResponseLanguage("OK"))
+}
+
+func TestLanguageEnforcedModel(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("注入系统指令", func(t *testing.T) {
+		model := &scriptedModel{replies: []string{"北京今天晴，气温二十五度。"}}
+		enforced := llmscn.NewLanguageEnforcedModel(model, llmscn.LanguageChinese)
+
+		_, err := enforced.GenerateContent(ctx, []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeSystem, "你是天气助手。"),
+			llms.TextParts(llms.ChatMessageTypeHuman, "What's the weather?"),
+		})
+		require.NoError(t, err)
+
+		sent := model.received[0]
+		require.Len(t, sent, 2)
+		require.Len(t, sent[0].Parts, 1)
+		system := sent[0].Parts[0].(llms.TextContent).Text
+		assert.Contains(t, system, "你是天气助手。")
+		assert.Contains(t, system, "简体中文")
+
+		// 没有系统消息时在最前面插入
+		_, err = enforced.Call(ctx, "天气如何")
+		require.NoError(t, err)
+		assert.Equal(t, llms.ChatMessageTypeSystem, model.received[1][0].Role)
+		assert.Len(t, model.received[1], 2)
+	})
+
+	t.Run("语言不一致时返回错误", func(t *testing.T) {
+		model := &scriptedModel{replies: []string{"The weather in Beijing is sunny today."}}
+		_, err := llmscn.NewLanguageEnforcedModel(model, llmscn.LanguageChinese).Call(ctx, "天气如何")
+		assert.ErrorIs(t, err, llmscn.ErrResponseLanguageMismatch)
+	})
+
+	t.Run("自动翻译", func(t *testing.T) {
+		model := &scriptedModel{replies: []string{"The weather in Beijing is sunny today."}}
+		translator := &scriptedModel{replies: []string{"北京今天天气晴朗。"}}
+		enforced := llmscn.NewLanguageEnforcedModel(model, "").WithTranslation(translator)
+
+		// 未指定语言时不做处理
+		reply, err := enforced.Call(ctx, "天气如何")
+		require.NoError(t, err)
+		assert.Equal(t, "The weather in Beijing is sunny today.", reply)
+		assert.Len(t, model.received[0], 1)
+
+		resp, err := enforced.GenerateContent(ctx, []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "天气如何"),
+		}, llmscn.WithResponseLanguage(llmscn.LanguageChinese))
+		require.NoError(t, err)
+		assert.Equal(t, "北京今天天气晴朗。", resp.Choices[0].Content)
+		assert.Equal(t, "en", resp.Choices[0].GenerationInfo[llmscn.TranslatedFromKey])
+		assert.Contains(t, translator.received[0][0].Parts[0].(llms.TextContent).Text, "Simplified Chinese")
+	})
+
+	t.Run("不支持的语言", func(t *testing.T) {
+		model := &scriptedModel{replies: []string{"ok"}}
+		_, err := llmscn.NewLanguageEnforcedModel(model, "fr").Call(ctx, "hi")
+		assert.Error(t, err)
+
+		_, err = llmscn.CreateLLM(llmscn.OllamaLLM, map[string]interface{}{"response_language": "fr"})
+		assert.Error(t, err)
+	})
+}
@@ -4,10 +4,16 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/sjzsdu/langchaingo-cn/llms/baichuan"
 	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	"github.com/sjzsdu/langchaingo-cn/llms/doubao"
+	"github.com/sjzsdu/langchaingo-cn/llms/ernie"
 	"github.com/sjzsdu/langchaingo-cn/llms/kimi"
+	"github.com/sjzsdu/langchaingo-cn/llms/minimax"
 	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
 	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/sjzsdu/langchaingo-cn/llms/spark"
+	"github.com/sjzsdu/langchaingo-cn/llms/yi"
 	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/anthropic"
@@ -25,9 +31,16 @@ const (
 	QwenLLM         LLMType = "qwen"
 	ZhipuLLM        LLMType = "zhipu"
 	SiliconFlowLLM  LLMType = "siliconflow"
+	ErnieLLM        LLMType = "ernie"
+	SparkLLM        LLMType = "spark"
+	MiniMaxLLM      LLMType = "minimax"
+	DoubaoLLM       LLMType = "doubao"
+	YiLLM           LLMType = "yi"
+	BaichuanLLM     LLMType = "baichuan"
 	AnthropicLLM    LLMType = "anthropic"
 	OpenAILLM       LLMType = "openai"
 	OllamaLLM       LLMType = "ollama"
+	RemoteLLM       LLMType = "remote"
 )
 
 // ErrUnsupportedLLMType 表示不支持的LLM类型错误
@@ -46,10 +59,18 @@ var ErrMissingRequiredParam = errors.New("缺少必要参数")
 //
 // 可选参数：
 // - "model": 模型名称
-// - "base_url": API基础URL
+// - "base_url": API基础URL（remote 类型必需，指向任意OpenAI兼容端点）
+// - "secret_key": 千帆应用的 Secret Key（仅ERNIE需要，与 api_key 一起换取访问令牌）
+// - "access_token": 已有的千帆访问令牌（仅ERNIE支持，设置后不需要 api_key 和 secret_key）
+// - "app_id"、"api_secret": 讯飞开放平台应用的 APPID 和 APISecret（仅星火 WebSocket 协议需要，与 api_key 一起签名）
+// - "api_password": 讯飞星火 HTTP 接口的 APIPassword（设置后默认使用 HTTP 接口）
+// - "protocol": 星火的调用协议（"websocket" 或 "http"）
+// - "group_id": MiniMax 账号的 GroupId（仅语音合成接口需要）
+// - "endpoint_id": 豆包的推理接入点ID（ep- 开头），优先于 model
+// - "endpoints": 豆包的模型名称到推理接入点ID的映射（map[string]string），调用时按模型名称选择接入点
 // - "temperature": 温度参数
 // - "top_p": Top-P参数
-// - "top_k": Top-K参数（仅Qwen支持）
+// - "top_k": Top-K参数（仅Qwen和星火支持）
 // - "max_tokens": 最大生成令牌数
 // - "use_openai_compatible": 是否使用OpenAI兼容模式（仅Qwen支持）
 // - "organization": 组织ID（仅OpenAI支持）
@@ -57,7 +78,68 @@ var ErrMissingRequiredParam = errors.New("缺少必要参数")
 // - "api_version": API版本（仅OpenAI支持，默认为"2023-05-15"）
 // - "format": 输出格式（仅Ollama支持，可选值："json"）
 // - "system": 系统提示（仅Ollama支持）
+// - "profile": 命名配置（如 "creative"、"deterministic"、"cheap"），叠加在 SetDefaultProfile 设置的全局配置之上
+// - "response_language": 回复语言（"zh" 或 "en"），见 LanguageEnforcedModel
+// - "translate_response": 回复语言不一致时是否由同一模型自动翻译，默认返回 ErrResponseLanguageMismatch
+// - 调用选项 llms.WithN 请求多个候选时，不支持参数 n 的服务商通过并行采样模拟，见 CandidatesModel
+// - "usage_reporter": *UsageReporter，记录每次调用的token用量，见 UsageReportingModel
+// - "graceful_degradation": 服务商不可用时返回友好的降级回复而不是错误，见 DegradedModel
+// - "first_token_timeout_ms": 流式调用的默认首个token超时（毫秒），调用选项 WithFirstTokenTimeout 优先，见 FirstTokenTimeoutModel
+//
+// 创建参数中显式设置的 temperature、max_tokens 优先于配置
 func CreateLLM(llmType LLMType, params map[string]interface{}) (llms.Model, error) {
+	profileName, _ := params["profile"].(string)
+	profile, err := resolveProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	firstTokenTimeout, err := durationParam(params, "first_token_timeout_ms")
+	if err != nil {
+		return nil, err
+	}
+	model, err := createLLM(llmType, params)
+	if err != nil {
+		return nil, err
+	}
+	// 在最内层取消服务商请求，重试和故障转移等外层装饰器可据 ErrFirstTokenTimeout 处理
+	model = NewFirstTokenTimeoutModel(model, firstTokenTimeout)
+	model = NewCandidatesModel(model, nativeCandidates[llmType])
+	// 在内层记录用量，自动翻译等额外调用同样会被统计
+	if reporter, ok := params["usage_reporter"].(*UsageReporter); ok && reporter != nil {
+		modelName, _ := params["model"].(string)
+		model = NewUsageReportingModel(model, reporter, string(llmType), modelName)
+	}
+
+	if _, ok := params["temperature"]; ok {
+		profile.Temperature = nil
+	}
+	if _, ok := params["max_tokens"]; ok {
+		profile.MaxTokens = 0
+	}
+	model = WithProfile(model, profile)
+
+	if language, ok := params["response_language"].(string); ok && language != "" {
+		if _, ok := responseLanguageInstructions[language]; !ok {
+			return nil, fmt.Errorf("不支持的回复语言: %s", language)
+		}
+		enforced := NewLanguageEnforcedModel(model, language)
+		if translate, _ := params["translate_response"].(bool); translate {
+			enforced.WithTranslation(nil)
+		}
+		model = enforced
+	}
+
+	// 放在最外层，在重试和语言校验之后仍失败时才降级
+	if degrade, _ := params["graceful_degradation"].(bool); degrade {
+		language, _ := params["response_language"].(string)
+		model = NewDegradedModel(model, DegradationOptions{Language: language})
+	}
+	return model, nil
+}
+
+// createLLM 按类型创建LLM实例
+func createLLM(llmType LLMType, params map[string]interface{}) (llms.Model, error) {
 	switch llmType {
 	case DeepSeekLLM:
 		return createDeepSeekLLM(params)
@@ -69,12 +151,26 @@ func CreateLLM(llmType LLMType, params map[string]interface{}) (llms.Model, erro
 		return createZhipuLLM(params)
 	case SiliconFlowLLM:
 		return createSiliconFlowLLM(params)
+	case ErnieLLM:
+		return createErnieLLM(params)
+	case SparkLLM:
+		return createSparkLLM(params)
+	case MiniMaxLLM:
+		return createMiniMaxLLM(params)
+	case DoubaoLLM:
+		return createDoubaoLLM(params)
+	case YiLLM:
+		return createYiLLM(params)
+	case BaichuanLLM:
+		return createBaichuanLLM(params)
 	case AnthropicLLM:
 		return createAnthropicLLM(params)
 	case OpenAILLM:
 		return createOpenAILLM(params)
 	case OllamaLLM:
 		return createOllamaLLM(params)
+	case RemoteLLM:
+		return createRemoteLLM(params)
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLLMType, llmType)
 	}
@@ -227,10 +323,224 @@ func createSiliconFlowLLM(params map[string]interface{}) (*siliconflow.LLM, erro
 		opts = append(opts, siliconflow.WithEmbeddingModel(embeddingModel))
 	}
 
+	if tier, ok := params["tier"].(string); ok && tier != "" {
+		opts = append(opts, siliconflow.WithTier(siliconflow.Tier(tier)))
+	}
+
 	// 创建LLM实例
 	return siliconflow.New(opts...)
 }
 
+// createErnieLLM 创建文心一言 LLM实例
+func createErnieLLM(params map[string]interface{}) (*ernie.LLM, error) {
+	// 构建选项
+	opts := []ernie.Option{}
+
+	// 添加参数
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, ernie.WithAPIKey(apiKey))
+	}
+
+	if secretKey, ok := params["secret_key"].(string); ok && secretKey != "" {
+		opts = append(opts, ernie.WithSecretKey(secretKey))
+	}
+
+	if accessToken, ok := params["access_token"].(string); ok && accessToken != "" {
+		opts = append(opts, ernie.WithAccessToken(accessToken))
+	}
+
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, ernie.WithModel(model))
+	}
+
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, ernie.WithBaseURL(baseURL))
+	}
+
+	if temperature, ok := params["temperature"].(float64); ok {
+		opts = append(opts, ernie.WithTemperature(temperature))
+	}
+
+	if topP, ok := params["top_p"].(float64); ok {
+		opts = append(opts, ernie.WithTopP(topP))
+	}
+
+	if maxTokens, ok := params["max_tokens"].(int); ok {
+		opts = append(opts, ernie.WithMaxTokens(maxTokens))
+	}
+
+	// 创建LLM实例
+	return ernie.New(opts...)
+}
+
+// createSparkLLM 创建讯飞星火 LLM实例
+func createSparkLLM(params map[string]interface{}) (*spark.LLM, error) {
+	// 构建选项
+	opts := []spark.Option{}
+
+	// 添加参数
+	if protocol, ok := params["protocol"].(string); ok && protocol != "" {
+		opts = append(opts, spark.WithProtocol(spark.Protocol(protocol)))
+	}
+
+	if appID, ok := params["app_id"].(string); ok && appID != "" {
+		opts = append(opts, spark.WithAppID(appID))
+	}
+
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, spark.WithAPIKey(apiKey))
+	}
+
+	if apiSecret, ok := params["api_secret"].(string); ok && apiSecret != "" {
+		opts = append(opts, spark.WithAPISecret(apiSecret))
+	}
+
+	if apiPassword, ok := params["api_password"].(string); ok && apiPassword != "" {
+		opts = append(opts, spark.WithAPIPassword(apiPassword))
+	}
+
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, spark.WithModel(model))
+	}
+
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, spark.WithBaseURL(baseURL))
+	}
+
+	if temperature, ok := params["temperature"].(float64); ok {
+		opts = append(opts, spark.WithTemperature(temperature))
+	}
+
+	if topK, ok := params["top_k"].(int); ok {
+		opts = append(opts, spark.WithTopK(topK))
+	}
+
+	if maxTokens, ok := params["max_tokens"].(int); ok {
+		opts = append(opts, spark.WithMaxTokens(maxTokens))
+	}
+
+	// 创建LLM实例
+	return spark.New(opts...)
+}
+
+// createMiniMaxLLM 创建 MiniMax LLM实例
+func createMiniMaxLLM(params map[string]interface{}) (*minimax.LLM, error) {
+	// 构建选项
+	opts := []minimax.Option{}
+
+	// 添加参数
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, minimax.WithAPIKey(apiKey))
+	}
+
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, minimax.WithModel(model))
+	}
+
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, minimax.WithBaseURL(baseURL))
+	}
+
+	if groupID, ok := params["group_id"].(string); ok && groupID != "" {
+		opts = append(opts, minimax.WithGroupID(groupID))
+	}
+
+	// 创建LLM实例
+	return minimax.New(opts...)
+}
+
+// createDoubaoLLM 创建豆包 LLM实例
+func createDoubaoLLM(params map[string]interface{}) (*doubao.LLM, error) {
+	// 构建选项
+	opts := []doubao.Option{}
+
+	// 添加参数
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, doubao.WithAPIKey(apiKey))
+	}
+
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, doubao.WithModel(model))
+	}
+
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, doubao.WithBaseURL(baseURL))
+	}
+
+	if endpointID, ok := params["endpoint_id"].(string); ok && endpointID != "" {
+		opts = append(opts, doubao.WithEndpointID(endpointID))
+	}
+
+	if endpoints := stringMapParam(params, "endpoints"); len(endpoints) > 0 {
+		opts = append(opts, doubao.WithEndpoints(endpoints))
+	}
+
+	// 创建LLM实例
+	return doubao.New(opts...)
+}
+
+// createYiLLM 创建零一万物 Yi LLM实例
+func createYiLLM(params map[string]interface{}) (*yi.LLM, error) {
+	// 构建选项
+	opts := []yi.Option{}
+
+	// 添加参数
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, yi.WithAPIKey(apiKey))
+	}
+
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, yi.WithModel(model))
+	}
+
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, yi.WithBaseURL(baseURL))
+	}
+
+	// 创建LLM实例
+	return yi.New(opts...)
+}
+
+// createBaichuanLLM 创建百川 LLM实例
+func createBaichuanLLM(params map[string]interface{}) (*baichuan.LLM, error) {
+	// 构建选项
+	opts := []baichuan.Option{}
+
+	// 添加参数
+	if apiKey, ok := params["api_key"].(string); ok && apiKey != "" {
+		opts = append(opts, baichuan.WithAPIKey(apiKey))
+	}
+
+	if model, ok := params["model"].(string); ok && model != "" {
+		opts = append(opts, baichuan.WithModel(model))
+	}
+
+	if baseURL, ok := params["base_url"].(string); ok && baseURL != "" {
+		opts = append(opts, baichuan.WithBaseURL(baseURL))
+	}
+
+	// 创建LLM实例
+	return baichuan.New(opts...)
+}
+
+// stringMapParam 读取字符串映射参数，兼容从JSON配置解析得到的 map[string]interface{}
+func stringMapParam(params map[string]interface{}, key string) map[string]string {
+	switch v := params[key].(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		result := make(map[string]string, len(v))
+		for name, value := range v {
+			if s, ok := value.(string); ok {
+				result[name] = s
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
 // createOpenAILLM 创建OpenAI LLM实例
 func createOpenAILLM(params map[string]interface{}) (llms.Model, error) {
 	// 构建选项
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/sjzsdu/langchaingo-cn/llms/baichuan"
 	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
 	"github.com/sjzsdu/langchaingo-cn/llms/kimi"
 	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
 	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/sjzsdu/langchaingo-cn/llms/yi"
 	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
 	"github.com/tmc/langchaingo/embeddings"
 	"github.com/tmc/langchaingo/llms"
@@ -80,6 +82,26 @@ func InitTextModels(llm string) ([]llms.Model, []string, error) {
 		modelNames = append(modelNames, "SiliconFlow")
 	}
 
+	// 初始化零一万物客户端
+	if matchModelName(llm, "Yi") {
+		yiLLM, err := yi.New()
+		if err != nil {
+			return nil, nil, fmt.Errorf("初始化零一万物失败: %w", err)
+		}
+		models = append(models, yiLLM)
+		modelNames = append(modelNames, "Yi")
+	}
+
+	// 初始化百川客户端
+	if matchModelName(llm, "Baichuan") {
+		baichuanLLM, err := baichuan.New()
+		if err != nil {
+			return nil, nil, fmt.Errorf("初始化百川失败: %w", err)
+		}
+		models = append(models, baichuanLLM)
+		modelNames = append(modelNames, "Baichuan")
+	}
+
 	// 如果没有找到任何模型，返回错误
 	if len(models) == 0 {
 		return nil, nil, fmt.Errorf("未找到指定的模型: %s", llm)
@@ -0,0 +1,259 @@
+// Package minimax 提供了 MiniMax 大语言模型的Go语言客户端实现，对话接口使用兼容OpenAI的模式，另提供语音合成（T2A）接口
+package minimax
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+const (
+	// 环境变量名
+	TokenEnvVarName   = "MINIMAX_API_KEY"  //nolint:gosec
+	ModelEnvVarName   = "MINIMAX_MODEL"    //nolint:gosec
+	GroupIDEnvVarName = "MINIMAX_GROUP_ID" //nolint:gosec
+
+	// OpenAI兼容模式基础URL
+	OpenAICompatibleBaseURL = "https://api.minimax.chat/v1"
+	// 默认模型
+	DefaultModel = ModelABAB65S
+)
+
+const (
+	// ModelABAB65S 是 abab6.5s 通用对话模型，支持245K上下文
+	ModelABAB65S = "abab6.5s-chat"
+
+	// ModelABAB65G 是 abab6.5g 英文及多语种对话模型
+	ModelABAB65G = "abab6.5g-chat"
+
+	// ModelABAB65T 是 abab6.5t 中文人设对话模型
+	ModelABAB65T = "abab6.5t-chat"
+
+	// ModelABAB55S 是 abab5.5s 对话模型
+	ModelABAB55S = "abab5.5s-chat"
+
+	// ModelText01 是 MiniMax-Text-01 长上下文模型
+	ModelText01 = "MiniMax-Text-01"
+)
+
+// APIError 是 MiniMax 通过 base_resp 返回的错误，MiniMax 的业务错误通常以HTTP状态码200返回
+type APIError struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("MiniMax API错误 (%d): %s", e.StatusCode, e.StatusMsg)
+}
+
+// LLM 是 MiniMax 大语言模型的实现
+type LLM struct {
+	*openai.LLM // 匿名嵌入OpenAI LLM，自动继承其所有方法
+
+	apiKey  string
+	baseURL string
+	groupID string
+	client  retry.Doer // 语音合成等非对话接口使用的HTTP客户端
+}
+
+// Option 是LLM的配置选项函数类型
+type Option func(*options)
+
+// options 是LLM的配置选项
+type options struct {
+	apiKey      string
+	baseURL     string
+	model       string
+	groupID     string
+	httpClient  *http.Client
+	retryPolicy retry.Policy
+	rateLimiter *ratelimit.Limiter
+}
+
+// WithAPIKey 设置API密钥
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithBaseURL 设置API基础URL
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithModel 设置模型
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+// WithGroupID 设置账号的 GroupId，语音合成接口需要
+func WithGroupID(groupID string) Option {
+	return func(o *options) {
+		o.groupID = groupID
+	}
+}
+
+// WithHTTPClient 设置自定义的HTTP客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
+// defaultOptions 返回默认选项
+func defaultOptions() options {
+	return options{
+		apiKey:  os.Getenv(TokenEnvVarName),
+		baseURL: OpenAICompatibleBaseURL,
+		model:   getEnvOrDefault(ModelEnvVarName, DefaultModel),
+		groupID: os.Getenv(GroupIDEnvVarName),
+	}
+}
+
+// getEnvOrDefault 获取环境变量值，如果不存在则返回默认值
+func getEnvOrDefault(envVar, defaultValue string) string {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// New 创建一个新的 MiniMax LLM实例
+func New(opts ...Option) (*LLM, error) {
+	options := defaultOptions()
+
+	// 应用选项
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// 验证API密钥
+	if options.apiKey == "" {
+		return nil, errors.New("API密钥不能为空，请设置MINIMAX_API_KEY环境变量或使用WithAPIKey选项")
+	}
+
+	httpClient := options.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	client := retry.Wrap(ratelimit.Wrap(&baseRespClient{client: httpClient}, options.rateLimiter), options.retryPolicy)
+
+	// 创建OpenAI客户端
+	openaiLLM, err := openai.New(
+		openai.WithToken(options.apiKey),
+		openai.WithModel(options.model),
+		openai.WithBaseURL(options.baseURL),
+		openai.WithHTTPClient(client),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("创建OpenAI客户端失败: %w", err)
+	}
+
+	return &LLM{
+		LLM:     openaiLLM,
+		apiKey:  options.apiKey,
+		baseURL: options.baseURL,
+		groupID: options.groupID,
+		client:  client,
+	}, nil
+}
+
+// GetModels 返回 MiniMax 支持的对话模型列表
+func (m *LLM) GetModels() []string {
+	return []string{
+		ModelABAB65S,
+		ModelABAB65G,
+		ModelABAB65T,
+		ModelABAB55S,
+		ModelText01,
+	}
+}
+
+// GenerateContent 生成内容，支持流式输出和工具调用
+func (m *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	resp, err := m.LLM.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	// openai 客户端只写入 PromptTokens 等键，补充统一的用量键
+	usage.Normalize(resp)
+	return resp, nil
+}
+
+// baseRespClient 将响应体中 base_resp 携带的业务错误转换为 *APIError，
+// 否则 openai 客户端会把不含 choices 的错误响应当作空响应
+type baseRespClient struct {
+	client *http.Client
+}
+
+// Do 实现 openai 客户端的 Doer 接口
+func (c *baseRespClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return resp, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if apiErr := parseBaseResp(data); apiErr != nil {
+		return nil, apiErr
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+// parseBaseResp 返回响应体中的业务错误，没有错误时返回 nil
+func parseBaseResp(data []byte) *APIError {
+	var body struct {
+		BaseResp *APIError `json:"base_resp"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil || body.BaseResp == nil || body.BaseResp.StatusCode == 0 {
+		return nil
+	}
+	return body.BaseResp
+}
@@ -0,0 +1,242 @@
+package minimax
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// ModelSpeech01Turbo 是 speech-01-turbo 语音合成模型，速度快
+	ModelSpeech01Turbo = "speech-01-turbo"
+
+	// ModelSpeech01HD 是 speech-01-hd 高品质语音合成模型
+	ModelSpeech01HD = "speech-01-hd"
+
+	// ModelSpeech02HD 是 speech-02-hd 语音合成模型
+	ModelSpeech02HD = "speech-02-hd"
+
+	// DefaultSpeechModel 默认语音合成模型
+	DefaultSpeechModel = ModelSpeech01Turbo
+	// DefaultVoice 默认音色（青涩青年音色）
+	DefaultVoice = "male-qn-qingse"
+)
+
+// ErrMissingGroupID 表示语音合成缺少 GroupId
+var ErrMissingGroupID = errors.New("语音合成需要 GroupId，请设置MINIMAX_GROUP_ID环境变量或使用WithGroupID选项")
+
+// SpeechOption 是语音合成的选项
+type SpeechOption func(*speechOptions)
+
+// speechOptions 是语音合成的选项
+type speechOptions struct {
+	model      string
+	voice      string
+	speed      float64
+	volume     float64
+	pitch      int
+	emotion    string
+	format     string
+	sampleRate int
+}
+
+// WithSpeechModel 设置语音合成模型，默认 speech-01-turbo
+func WithSpeechModel(model string) SpeechOption {
+	return func(o *speechOptions) {
+		o.model = model
+	}
+}
+
+// WithVoice 设置音色ID，默认 male-qn-qingse
+func WithVoice(voice string) SpeechOption {
+	return func(o *speechOptions) {
+		o.voice = voice
+	}
+}
+
+// WithSpeed 设置语速，取值范围 [0.5, 2]，默认 1
+func WithSpeed(speed float64) SpeechOption {
+	return func(o *speechOptions) {
+		o.speed = speed
+	}
+}
+
+// WithVolume 设置音量，取值范围 (0, 10]，默认 1
+func WithVolume(volume float64) SpeechOption {
+	return func(o *speechOptions) {
+		o.volume = volume
+	}
+}
+
+// WithPitch 设置语调，取值范围 [-12, 12]，默认 0（原音色输出）
+func WithPitch(pitch int) SpeechOption {
+	return func(o *speechOptions) {
+		o.pitch = pitch
+	}
+}
+
+// WithEmotion 设置情绪，如 happy、sad、angry，未设置时由模型根据文本判断
+func WithEmotion(emotion string) SpeechOption {
+	return func(o *speechOptions) {
+		o.emotion = emotion
+	}
+}
+
+// WithAudioFormat 设置音频格式，可选 mp3、pcm、flac、wav，默认 mp3
+func WithAudioFormat(format string) SpeechOption {
+	return func(o *speechOptions) {
+		o.format = format
+	}
+}
+
+// WithSampleRate 设置采样率，默认 32000
+func WithSampleRate(sampleRate int) SpeechOption {
+	return func(o *speechOptions) {
+		o.sampleRate = sampleRate
+	}
+}
+
+// SpeechResult 语音合成结果
+type SpeechResult struct {
+	// Audio 音频数据
+	Audio []byte
+	// Format 音频格式
+	Format string
+	// Duration 音频时长
+	Duration time.Duration
+	// SampleRate 采样率
+	SampleRate int
+	// Characters 计费字符数
+	Characters int
+	// TraceID 请求ID，用于排查问题
+	TraceID string
+}
+
+// speechRequest MiniMax 语音合成请求
+type speechRequest struct {
+	Model        string `json:"model"`
+	Text         string `json:"text"`
+	Stream       bool   `json:"stream"`
+	VoiceSetting struct {
+		VoiceID string  `json:"voice_id"`
+		Speed   float64 `json:"speed"`
+		Vol     float64 `json:"vol"`
+		Pitch   int     `json:"pitch"`
+		Emotion string  `json:"emotion,omitempty"`
+	} `json:"voice_setting"`
+	AudioSetting struct {
+		SampleRate int    `json:"sample_rate"`
+		Format     string `json:"format"`
+		Channel    int    `json:"channel"`
+	} `json:"audio_setting"`
+}
+
+// speechResponse MiniMax 语音合成响应，音频为十六进制编码
+type speechResponse struct {
+	Data *struct {
+		Audio  string `json:"audio"`
+		Status int    `json:"status"`
+	} `json:"data"`
+	ExtraInfo struct {
+		AudioLength     int64  `json:"audio_length"`
+		AudioSampleRate int    `json:"audio_sample_rate"`
+		AudioFormat     string `json:"audio_format"`
+		UsageCharacters int    `json:"usage_characters"`
+	} `json:"extra_info"`
+	TraceID  string    `json:"trace_id"`
+	BaseResp *APIError `json:"base_resp"`
+}
+
+// TextToSpeech 调用语音合成（T2A）接口将文本转换为音频，单次请求的文本不超过1万字符
+// 该接口不属于 llms.Model，需要通过 *minimax.LLM 调用，并设置账号的 GroupId
+func (m *LLM) TextToSpeech(ctx context.Context, text string, opts ...SpeechOption) (*SpeechResult, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("合成文本不能为空")
+	}
+	if m.groupID == "" {
+		return nil, ErrMissingGroupID
+	}
+
+	options := speechOptions{
+		model:      DefaultSpeechModel,
+		voice:      DefaultVoice,
+		speed:      1,
+		volume:     1,
+		format:     "mp3",
+		sampleRate: 32000,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	request := speechRequest{Model: options.model, Text: text}
+	request.VoiceSetting.VoiceID = options.voice
+	request.VoiceSetting.Speed = options.speed
+	request.VoiceSetting.Vol = options.volume
+	request.VoiceSetting.Pitch = options.pitch
+	request.VoiceSetting.Emotion = options.emotion
+	request.AudioSetting.SampleRate = options.sampleRate
+	request.AudioSetting.Format = options.format
+	request.AudioSetting.Channel = 1
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("序列化语音合成请求失败: %w", err)
+	}
+	endpoint := m.baseURL + "/t2a_v2?GroupId=" + url.QueryEscape(m.groupID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建语音合成请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求语音合成接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取语音合成响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status code %d): %s", resp.StatusCode, string(data))
+	}
+	var result speechResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析语音合成响应失败: %w", err)
+	}
+	if result.BaseResp != nil && result.BaseResp.StatusCode != 0 {
+		return nil, result.BaseResp
+	}
+	if result.Data == nil || result.Data.Audio == "" {
+		return nil, errors.New("语音合成响应中没有音频数据")
+	}
+
+	audio, err := hex.DecodeString(result.Data.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("解码音频数据失败: %w", err)
+	}
+	format := result.ExtraInfo.AudioFormat
+	if format == "" {
+		format = options.format
+	}
+	return &SpeechResult{
+		Audio:      audio,
+		Format:     format,
+		Duration:   time.Duration(result.ExtraInfo.AudioLength) * time.Millisecond,
+		SampleRate: result.ExtraInfo.AudioSampleRate,
+		Characters: result.ExtraInfo.UsageCharacters,
+		TraceID:    result.TraceID,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/minimax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestMiniMaxLLM(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		requests []map[string]any
+		reply    string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		body["path"] = r.URL.Path
+		body["group_id"] = r.URL.Query().Get("GroupId")
+		requests = append(requests, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	newLLM := func(t *testing.T, params map[string]interface{}) llms.Model {
+		params["api_key"] = "test-key"
+		params["base_url"] = server.URL
+		llm, err := llmscn.CreateLLM(llmscn.MiniMaxLLM, params)
+		require.NoError(t, err)
+		return llm
+	}
+
+	t.Run("工具调用", func(t *testing.T) {
+		requests = nil
+		reply = `{"id":"m-1","object":"chat.completion","created":1,"model":"abab6.5s-chat","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"上海\"}"}}]}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15},"base_resp":{"status_code":0,"status_msg":""}}`
+
+		llm := newLLM(t, map[string]interface{}{})
+		resp, err := llm.GenerateContent(ctx,
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "上海天气怎么样")},
+			llms.WithTools([]llms.Tool{{
+				Type: "function",
+				Function: &llms.FunctionDefinition{
+					Name:       "get_weather",
+					Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+				},
+			}}))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices[0].ToolCalls, 1)
+		assert.Equal(t, "call_1", resp.Choices[0].ToolCalls[0].ID)
+		assert.JSONEq(t, `{"city":"上海"}`, resp.Choices[0].ToolCalls[0].FunctionCall.Arguments)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 10, 5, 15)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/chat/completions", requests[0]["path"])
+		assert.Equal(t, minimax.DefaultModel, requests[0]["model"])
+		assert.Len(t, requests[0]["tools"], 1)
+	})
+
+	t.Run("base_resp错误", func(t *testing.T) {
+		reply = `{"id":"m-2","choices":null,"base_resp":{"status_code":1008,"status_msg":"insufficient balance"}}`
+
+		_, err := newLLM(t, map[string]interface{}{}).Call(ctx, "你好")
+		var apiErr *minimax.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 1008, apiErr.StatusCode)
+		assert.Equal(t, "insufficient balance", apiErr.StatusMsg)
+	})
+
+	t.Run("语音合成", func(t *testing.T) {
+		requests = nil
+		reply = `{"data":{"audio":"` + hex.EncodeToString([]byte("mp3-data")) + `","status":2},"extra_info":{"audio_length":1500,"audio_sample_rate":32000,"audio_format":"mp3","usage_characters":4},"trace_id":"trace-1","base_resp":{"status_code":0,"status_msg":"success"}}`
+
+		llm, err := minimax.New(minimax.WithAPIKey("test-key"), minimax.WithBaseURL(server.URL), minimax.WithGroupID("g-1"))
+		require.NoError(t, err)
+		result, err := llm.TextToSpeech(ctx, "你好世界", minimax.WithVoice("female-shaonv"), minimax.WithSpeed(1.2))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("mp3-data"), result.Audio)
+		assert.Equal(t, "mp3", result.Format)
+		assert.Equal(t, 1500*time.Millisecond, result.Duration)
+		assert.Equal(t, 4, result.Characters)
+		assert.Equal(t, "trace-1", result.TraceID)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/t2a_v2", requests[0]["path"])
+		assert.Equal(t, "g-1", requests[0]["group_id"])
+		assert.Equal(t, minimax.DefaultSpeechModel, requests[0]["model"])
+		assert.Equal(t, "你好世界", requests[0]["text"])
+		assert.Equal(t, map[string]any{"voice_id": "female-shaonv", "speed": 1.2, "vol": float64(1), "pitch": float64(0)}, requests[0]["voice_setting"])
+	})
+
+	t.Run("语音合成缺少GroupId", func(t *testing.T) {
+		t.Setenv("MINIMAX_GROUP_ID", "")
+		llm, err := minimax.New(minimax.WithAPIKey("test-key"), minimax.WithBaseURL(server.URL))
+		require.NoError(t, err)
+		_, err = llm.TextToSpeech(ctx, "你好")
+		assert.ErrorIs(t, err, minimax.ErrMissingGroupID)
+	})
+}
@@ -0,0 +1,214 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// 内置的命名配置
+const (
+	ProfileCreative      = "creative"
+	ProfileDeterministic = "deterministic"
+	ProfileCheap         = "cheap"
+)
+
+// ErrUnknownProfile 表示未注册的命名配置
+var ErrUnknownProfile = errors.New("未知的默认配置")
+
+// Profile 模型默认选项配置
+// 通过 SetDefaultProfile 设置后，作用于 CreateLLM 创建的所有模型；
+// 创建参数或单次调用选项中显式设置的值优先于配置
+type Profile struct {
+	// Temperature 默认温度，nil 表示不设置
+	Temperature *float64 `json:"temperature,omitempty"`
+	// MaxTokens 默认最大生成token数，0 表示不设置
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// Retry 调用失败后的重试次数
+	Retry int `json:"retry,omitempty"`
+	// RetryBackoff 首次重试前的等待时间，之后每次翻倍，默认 500ms
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+	// Timeout 单次调用（含重试）的超时时间，0 表示不限制
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Tags 默认请求标签，见 WithRequestTags
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// IsZero 判断配置是否为空
+func (p Profile) IsZero() bool {
+	return p.Temperature == nil && p.MaxTokens == 0 && p.Retry == 0 && p.Timeout == 0 && len(p.Tags) == 0
+}
+
+// Merge 返回以 p 为基础、由 override 中已设置的字段覆盖后的配置
+func (p Profile) Merge(override Profile) Profile {
+	merged := p
+	if override.Temperature != nil {
+		merged.Temperature = override.Temperature
+	}
+	if override.MaxTokens != 0 {
+		merged.MaxTokens = override.MaxTokens
+	}
+	if override.Retry != 0 {
+		merged.Retry = override.Retry
+	}
+	if override.RetryBackoff != 0 {
+		merged.RetryBackoff = override.RetryBackoff
+	}
+	if override.Timeout != 0 {
+		merged.Timeout = override.Timeout
+	}
+	if len(override.Tags) > 0 {
+		merged.Tags = make(map[string]string, len(p.Tags)+len(override.Tags))
+		for k, v := range p.Tags {
+			merged.Tags[k] = v
+		}
+		for k, v := range override.Tags {
+			merged.Tags[k] = v
+		}
+	}
+	return merged
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+var (
+	profileMu      sync.RWMutex
+	defaultProfile Profile
+	namedProfiles  = map[string]Profile{
+		ProfileCreative:      {Temperature: floatPtr(0.9)},
+		ProfileDeterministic: {Temperature: floatPtr(0), Retry: 2},
+		ProfileCheap:         {Temperature: floatPtr(0.3), MaxTokens: 512},
+	}
+)
+
+// SetDefaultProfile 设置全局默认配置，作用于之后通过 CreateLLM 创建的模型
+// 传入空配置即可清除
+func SetDefaultProfile(profile Profile) {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	defaultProfile = profile
+}
+
+// DefaultProfile 返回当前的全局默认配置
+func DefaultProfile() Profile {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	return defaultProfile
+}
+
+// RegisterProfile 注册或覆盖命名配置
+func RegisterProfile(name string, profile Profile) {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	namedProfiles[name] = profile
+}
+
+// GetProfile 返回命名配置
+func GetProfile(name string) (Profile, error) {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	profile, ok := namedProfiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+	}
+	return profile, nil
+}
+
+// resolveProfile 计算创建模型时生效的配置：全局默认配置，叠加 name 指定的命名配置
+func resolveProfile(name string) (Profile, error) {
+	profile := DefaultProfile()
+	if name == "" {
+		return profile, nil
+	}
+	named, err := GetProfile(name)
+	if err != nil {
+		return Profile{}, err
+	}
+	return profile.Merge(named), nil
+}
+
+// ProfiledModel 默认配置装饰器
+// 为每次调用补充默认温度、最大token数和请求标签，并按配置处理超时与重试
+type ProfiledModel struct {
+	model   llms.Model
+	profile Profile
+}
+
+var _ llms.Model = (*ProfiledModel)(nil)
+
+// WithProfile 使用配置包装模型，配置为空时直接返回原模型
+func WithProfile(model llms.Model, profile Profile) llms.Model {
+	if profile.IsZero() {
+		return model
+	}
+	if len(profile.Tags) > 0 {
+		model = NewTaggedModel(model, profile.Tags)
+	}
+	return &ProfiledModel{model: model, profile: profile}
+}
+
+// Profile 返回模型使用的配置
+func (m *ProfiledModel) Profile() Profile {
+	return m.profile
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *ProfiledModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	// 默认选项放在最前面，调用方的选项可以覆盖
+	defaults := make([]llms.CallOption, 0, 2)
+	if m.profile.Temperature != nil {
+		defaults = append(defaults, llms.WithTemperature(*m.profile.Temperature))
+	}
+	if m.profile.MaxTokens > 0 {
+		defaults = append(defaults, llms.WithMaxTokens(m.profile.MaxTokens))
+	}
+	options = append(defaults, options...)
+
+	if m.profile.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.profile.Timeout)
+		defer cancel()
+	}
+
+	// 流式输出已经开始后不再重试，避免重复输出
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	streamed := false
+	if opts.StreamingFunc != nil {
+		streamingFunc := opts.StreamingFunc
+		options = append(options, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return streamingFunc(ctx, chunk)
+		}))
+	}
+
+	backoff := m.profile.RetryBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := m.model.GenerateContent(ctx, messages, options...)
+		if err == nil || attempt >= m.profile.Retry || streamed || ctx.Err() != nil {
+			return resp, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff << attempt):
+		}
+	}
+}
+
+// Call 实现 llms.Model 接口
+func (m *ProfiledModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
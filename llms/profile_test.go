@@ -0,0 +1,106 @@
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// flakyModel 前 failures 次调用返回错误，并记录最后一次调用的选项
+type flakyModel struct {
+	failures int
+	calls    int
+	options  llms.CallOptions
+	tags     map[string]string
+}
+
+func (f *flakyModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	f.calls++
+	f.options = llms.CallOptions{}
+	for _, opt := range options {
+		opt(&f.options)
+	}
+	f.tags = llmscn.RequestTagsFromContext(ctx)
+	if f.calls <= f.failures {
+		return nil, errors.New("temporary failure")
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "ok"}}}, nil
+}
+
+func (f *flakyModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func TestProfile(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("DefaultsAndOverrides", func(t *testing.T) {
+		model := &flakyModel{failures: 1}
+		profiled := llmscn.WithProfile(model, llmscn.Profile{
+			MaxTokens:    256,
+			Retry:        1,
+			RetryBackoff: time.Millisecond,
+			Tags:         map[string]string{"team": "search"},
+		}.Merge(mustProfile(t, llmscn.ProfileCreative)))
+
+		out, err := profiled.Call(ctx, "hi", llms.WithMaxTokens(64))
+		require.NoError(t, err)
+		assert.Equal(t, "ok", out)
+		assert.Equal(t, 2, model.calls)
+		assert.Equal(t, 0.9, model.options.Temperature)
+		assert.Equal(t, 64, model.options.MaxTokens)
+		assert.Equal(t, map[string]string{"team": "search"}, model.tags)
+	})
+
+	t.Run("NoRetryAfterStreaming", func(t *testing.T) {
+		model := &flakyModel{failures: 5}
+		streaming := &streamingFlakyModel{flakyModel: model}
+		profiled := llmscn.WithProfile(streaming, llmscn.Profile{Retry: 3, RetryBackoff: time.Millisecond})
+		_, err := profiled.Call(ctx, "hi", llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error { return nil }))
+		assert.Error(t, err)
+		assert.Equal(t, 1, model.calls)
+	})
+
+	t.Run("FactoryUsesDefaultProfile", func(t *testing.T) {
+		llmscn.SetDefaultProfile(llmscn.Profile{MaxTokens: 100})
+		defer llmscn.SetDefaultProfile(llmscn.Profile{})
+
+		model, err := llmscn.CreateLLM(llmscn.OllamaLLM, map[string]interface{}{"model": "qwen2", "profile": llmscn.ProfileCheap})
+		require.NoError(t, err)
+		profiled, ok := model.(*llmscn.ProfiledModel)
+		require.True(t, ok)
+		assert.Equal(t, 512, profiled.Profile().MaxTokens)
+
+		_, err = llmscn.CreateLLM(llmscn.OllamaLLM, map[string]interface{}{"model": "qwen2", "profile": "unknown"})
+		assert.ErrorIs(t, err, llmscn.ErrUnknownProfile)
+	})
+}
+
+// streamingFlakyModel 先输出一段内容再失败
+type streamingFlakyModel struct {
+	*flakyModel
+}
+
+func (s *streamingFlakyModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.StreamingFunc != nil {
+		_ = opts.StreamingFunc(ctx, []byte("partial"))
+	}
+	return s.flakyModel.GenerateContent(ctx, messages, options...)
+}
+
+func mustProfile(t *testing.T, name string) llmscn.Profile {
+	t.Helper()
+	profile, err := llmscn.GetProfile(name)
+	require.NoError(t, err)
+	return profile
+}
@@ -0,0 +1,220 @@
+package qwen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+const (
+	// NativeBaseURL DashScope 原生接口基础URL
+	NativeBaseURL = "https://dashscope.aliyuncs.com/api/v1"
+
+	// ModelTextEmbeddingV1 是通用文本向量模型 v1
+	ModelTextEmbeddingV1 = "text-embedding-v1"
+
+	// ModelTextEmbeddingV2 是通用文本向量模型 v2
+	ModelTextEmbeddingV2 = "text-embedding-v2"
+
+	// ModelTextEmbeddingV3 是通用文本向量模型 v3，支持选择向量维度
+	ModelTextEmbeddingV3 = "text-embedding-v3"
+
+	// DefaultEmbeddingBatchSize 单次请求的默认文本数量，text-embedding-v3 单次最多10条
+	DefaultEmbeddingBatchSize = 10
+)
+
+// 文本类型，检索场景下对查询和文档区分编码效果更好
+const (
+	textTypeQuery    = "query"
+	textTypeDocument = "document"
+)
+
+// embeddingPath DashScope 原生文本向量接口路径
+const embeddingPath = "/services/embeddings/text-embedding/text-embedding"
+
+// WithNativeBaseURL 设置 DashScope 原生接口基础URL，用于 NewEmbedder
+func WithNativeBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.nativeBaseURL = baseURL
+	}
+}
+
+// WithEmbeddingDimension 设置向量维度，仅 text-embedding-v3 及以上模型支持，
+// 可选 1024（默认）、768、512 等；0 表示使用模型默认维度
+func WithEmbeddingDimension(dimension int) Option {
+	return func(o *options) {
+		o.embeddingDimension = dimension
+	}
+}
+
+// WithEmbeddingBatchSize 设置单次请求的文本数量，默认 DefaultEmbeddingBatchSize
+func WithEmbeddingBatchSize(size int) Option {
+	return func(o *options) {
+		o.embeddingBatchSize = size
+	}
+}
+
+// Embedder 通过 DashScope 原生文本向量接口生成向量，实现 embeddings.Embedder 接口
+// 与通过 OpenAI 兼容接口的 CreateEmbedding 相比，支持选择向量维度并区分查询与文档的编码
+type Embedder struct {
+	client    retry.Doer
+	apiKey    string
+	signer    auth.Provider
+	baseURL   string
+	model     string
+	dimension int
+	batchSize int
+}
+
+var _ embeddings.Embedder = (*Embedder)(nil)
+
+// NewEmbedder 创建通义千问文本向量实例
+// 默认使用 text-embedding-v3，可通过 WithEmbeddingModel 或 QWEN_EMBEDDING_MODEL 环境变量指定其他模型
+func NewEmbedder(opts ...Option) (*Embedder, error) {
+	options := defaultOptions()
+	options.embeddingModel = getEnvOrDefault(EmbeddingModelEnvVarName, ModelTextEmbeddingV3)
+	options.nativeBaseURL = NativeBaseURL
+	options.embeddingBatchSize = DefaultEmbeddingBatchSize
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.apiKey == "" && options.authProvider == nil {
+		return nil, errors.New("API密钥不能为空，请设置QWEN_API_KEY环境变量或使用WithAPIKey选项")
+	}
+	if options.embeddingDimension < 0 {
+		return nil, fmt.Errorf("向量维度不能为负数: %d", options.embeddingDimension)
+	}
+	if options.embeddingBatchSize <= 0 {
+		return nil, fmt.Errorf("批量大小必须大于0: %d", options.embeddingBatchSize)
+	}
+
+	return &Embedder{
+		client:    retry.Wrap(ratelimit.Wrap(http.DefaultClient, options.rateLimiter), options.retryPolicy),
+		apiKey:    options.apiKey,
+		signer:    options.authProvider,
+		baseURL:   strings.TrimRight(options.nativeBaseURL, "/"),
+		model:     options.embeddingModel,
+		dimension: options.embeddingDimension,
+		batchSize: options.embeddingBatchSize,
+	}, nil
+}
+
+// EmbedDocuments 为文档生成向量，按批量大小分批请求
+func (e *Embedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.batchSize {
+		end := min(start+e.batchSize, len(texts))
+		batch, err := e.embed(ctx, texts[start:end], textTypeDocument)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// EmbedQuery 为检索查询生成向量
+func (e *Embedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, []string{text}, textTypeQuery)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// embeddingRequest DashScope 文本向量请求
+type embeddingRequest struct {
+	Model string `json:"model"`
+	Input struct {
+		Texts []string `json:"texts"`
+	} `json:"input"`
+	Parameters struct {
+		TextType  string `json:"text_type,omitempty"`
+		Dimension int    `json:"dimension,omitempty"`
+	} `json:"parameters"`
+}
+
+// embeddingResponse DashScope 文本向量响应，失败时只包含 code 和 message
+type embeddingResponse struct {
+	Output struct {
+		Embeddings []struct {
+			TextIndex int       `json:"text_index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"embeddings"`
+	} `json:"output"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+// embed 请求一批文本的向量，结果按输入顺序返回
+func (e *Embedder) embed(ctx context.Context, texts []string, textType string) ([][]float32, error) {
+	var payload embeddingRequest
+	payload.Model = e.model
+	payload.Input.Texts = texts
+	payload.Parameters.TextType = textType
+	payload.Parameters.Dimension = e.dimension
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化向量请求失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+embeddingPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建向量请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+	if err := auth.Apply(e.signer, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求向量接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取向量响应失败: %w", err)
+	}
+	var result embeddingResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("API error (status code %d): %s", resp.StatusCode, string(data))
+		}
+		return nil, fmt.Errorf("解析向量响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status code %d): %s - %s", resp.StatusCode, result.Code, result.Message)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Output.Embeddings {
+		if item.TextIndex < 0 || item.TextIndex >= len(texts) {
+			return nil, fmt.Errorf("向量响应中的文本序号越界: %d", item.TextIndex)
+		}
+		vectors[item.TextIndex] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("向量响应缺少第 %d 条文本的结果", i)
+		}
+	}
+	return vectors, nil
+}
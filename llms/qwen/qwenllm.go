@@ -1,10 +1,20 @@
 package qwen
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/media"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 )
 
@@ -38,11 +48,34 @@ const (
 
 	// ModelQWenVLMax 是通义千问视觉Max模型
 	ModelQWenVLMax = "qwen-vl-max"
+
+	// ModelQWenMTPlus 是通义千问翻译Plus模型
+	ModelQWenMTPlus = "qwen-mt-plus"
+
+	// ModelQWenMTTurbo 是通义千问翻译Turbo模型
+	ModelQWenMTTurbo = "qwen-mt-turbo"
+
+	// ModelQWenCoderPlus 是通义千问代码Plus模型
+	ModelQWenCoderPlus = "qwen-coder-plus"
+
+	// ModelQWenCoderTurbo 是通义千问代码Turbo模型
+	ModelQWenCoderTurbo = "qwen-coder-turbo"
+
+	// ModelQWenOmniTurbo 是通义千问全模态Turbo模型，支持音频输入，仅支持流式输出
+	ModelQWenOmniTurbo = "qwen-omni-turbo"
+
+	// ModelQWenAudioTurbo 是通义千问音频理解Turbo模型
+	ModelQWenAudioTurbo = "qwen-audio-turbo"
 )
 
+// audioModelPrefixes 支持音频输入的模型名称前缀
+var audioModelPrefixes = []string{"qwen-omni", "qwen2.5-omni", "qwen-audio", "qwen2-audio"}
+
 // LLM 是通义千问大语言模型的实现
 type LLM struct {
 	*openai.LLM // 匿名嵌入OpenAI LLM，自动继承其所有方法
+
+	model string // 默认模型，用于判断模型能力
 }
 
 // Option 是LLM的配置选项函数类型
@@ -54,6 +87,14 @@ type options struct {
 	baseURL        string
 	model          string
 	embeddingModel string
+	authProvider   auth.Provider
+	retryPolicy    retry.Policy
+	rateLimiter    *ratelimit.Limiter
+
+	// 原生文本向量接口配置，见 NewEmbedder
+	nativeBaseURL      string
+	embeddingDimension int
+	embeddingBatchSize int
 }
 
 // WithAPIKey 设置API密钥
@@ -70,6 +111,37 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithAuthProvider 设置自定义认证方式，如 AK/SK 签名或自定义认证头，
+// 设置后不再要求API密钥，请求在发送前由 provider 签名
+func WithAuthProvider(provider auth.Provider) Option {
+	return func(o *options) {
+		o.authProvider = provider
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
 // WithModel 设置模型
 func WithModel(model string) Option {
 	return func(o *options) {
@@ -88,6 +160,7 @@ func WithEmbeddingModel(model string) Option {
 func defaultOptions() options {
 	return options{
 		apiKey:         os.Getenv(TokenEnvVarName),
+		baseURL:        OpenAICompatibleBaseURL,
 		model:          getEnvOrDefault(ModelEnvVarName, DefaultModel),
 		embeddingModel: getEnvOrDefault(EmbeddingModelEnvVarName, DefaultEmbeddingModel),
 	}
@@ -112,16 +185,23 @@ func New(opts ...Option) (*LLM, error) {
 	}
 
 	// 验证API密钥
-	if options.apiKey == "" {
+	if options.apiKey == "" && options.authProvider == nil {
 		return nil, errors.New("API密钥不能为空，请设置QWEN_API_KEY环境变量或使用WithAPIKey选项")
 	}
 
+	// 使用自定义认证方式时令牌仅作为占位，发送前会被替换
+	token := options.apiKey
+	if token == "" {
+		token = "none"
+	}
+
 	// 创建OpenAI客户端
 	openaiOpts := []openai.Option{
-		openai.WithToken(options.apiKey),
+		openai.WithToken(token),
 		openai.WithModel(options.model),
-		openai.WithBaseURL(OpenAICompatibleBaseURL),
+		openai.WithBaseURL(options.baseURL),
 		openai.WithEmbeddingModel(options.embeddingModel),
+		openai.WithHTTPClient(retry.Wrap(ratelimit.Wrap(&extraBodyClient{client: http.DefaultClient, signer: options.authProvider}, options.rateLimiter), options.retryPolicy)),
 	}
 
 	openaiLLM, err := openai.New(openaiOpts...)
@@ -129,7 +209,7 @@ func New(opts ...Option) (*LLM, error) {
 		return nil, fmt.Errorf("创建OpenAI客户端失败: %w", err)
 	}
 
-	return &LLM{LLM: openaiLLM}, nil
+	return &LLM{LLM: openaiLLM, model: options.model}, nil
 }
 
 // GetModels 返回通义千问支持的模型列表
@@ -140,5 +220,43 @@ func (q *LLM) GetModels() []string {
 		ModelQWenMax,
 		ModelQWenVLPlus,
 		ModelQWenVLMax,
+		ModelQWenMTPlus,
+		ModelQWenMTTurbo,
+		ModelQWenCoderPlus,
+		ModelQWenCoderTurbo,
+		ModelQWenOmniTurbo,
+		ModelQWenAudioTurbo,
+	}
+}
+
+// SupportsAudio 判断模型是否支持音频输入
+func SupportsAudio(model string) bool {
+	model = strings.ToLower(model)
+	for _, prefix := range audioModelPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateContent 重写生成内容方法，消息包含音频时检查模型是否支持音频输入
+// 音频片段（见 llmscn.AudioContent）在发送前被转换为 input_audio 格式
+func (q *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if media.HasAudio(messages) {
+		opts := llms.CallOptions{Model: q.model}
+		for _, opt := range options {
+			opt(&opts)
+		}
+		if !SupportsAudio(opts.Model) {
+			return nil, &media.CapabilityError{Provider: "qwen", Model: opts.Model, Capability: "audio"}
+		}
+	}
+	resp, err := q.LLM.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
 	}
+	// openai 客户端只写入 PromptTokens 等键，补充统一的用量键
+	usage.Normalize(resp)
+	return resp, nil
 }
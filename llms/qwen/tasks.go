@@ -0,0 +1,204 @@
+package qwen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/media"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// extraBodyPrefix 是需要提升为请求体顶层字段的 Metadata 键前缀
+const extraBodyPrefix = "qwen:"
+
+// requestTagsKey 是请求标签在 CallOptions.Metadata 中的键，与 llmscn.RequestTagsKey 保持一致
+const requestTagsKey = "request_tags"
+
+// 任务推荐参数
+const (
+	// translateTemperature 翻译任务推荐温度
+	translateTemperature = 0.1
+	// codeTemperature 代码任务推荐温度
+	codeTemperature = 0.2
+)
+
+// codeBlockPattern 匹配 Markdown 代码块
+var codeBlockPattern = regexp.MustCompile("(?s)```[\\w+#.-]*\\n(.*?)```")
+
+// WithTranslationOptions 设置 qwen-mt 翻译模型的翻译参数
+// sourceLang 为空时自动识别源语言，语言名称使用英文全称（如 "Chinese"、"English"）
+func WithTranslationOptions(sourceLang, targetLang string) llms.CallOption {
+	if sourceLang == "" {
+		sourceLang = "auto"
+	}
+	return withExtraBody("translation_options", map[string]string{
+		"source_lang": sourceLang,
+		"target_lang": targetLang,
+	})
+}
+
+// Translate 使用 qwen-mt 翻译模型将文本翻译为目标语言
+// 默认使用 qwen-mt-turbo，可通过 WithModel 选项指定其他翻译模型
+func Translate(ctx context.Context, text, targetLang string, opts ...Option) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("待翻译文本不能为空")
+	}
+	if targetLang == "" {
+		return "", errors.New("目标语言不能为空")
+	}
+
+	llm, err := New(append([]Option{WithModel(ModelQWenMTTurbo)}, opts...)...)
+	if err != nil {
+		return "", err
+	}
+
+	// 翻译模型不支持 system 消息，仅发送待翻译文本
+	resp, err := llm.GenerateContent(ctx,
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, text)},
+		llms.WithTemperature(translateTemperature),
+		WithTranslationOptions("", targetLang),
+	)
+	if err != nil {
+		return "", fmt.Errorf("翻译失败: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("翻译失败: 响应为空")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+// CodeComplete 使用 qwen-coder 代码模型根据提示生成代码
+// 默认使用 qwen-coder-plus，返回结果中的 Markdown 代码块会被提取为纯代码
+func CodeComplete(ctx context.Context, prompt string, opts ...Option) (string, error) {
+	if strings.TrimSpace(prompt) == "" {
+		return "", errors.New("代码提示不能为空")
+	}
+
+	llm, err := New(append([]Option{WithModel(ModelQWenCoderPlus)}, opts...)...)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := llm.GenerateContent(ctx,
+		[]llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeSystem, "你是一个编程助手，只输出代码，不要解释。"),
+			llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+		},
+		llms.WithTemperature(codeTemperature),
+	)
+	if err != nil {
+		return "", fmt.Errorf("代码生成失败: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("代码生成失败: 响应为空")
+	}
+
+	return extractCode(resp.Choices[0].Content), nil
+}
+
+// extractCode 提取 Markdown 代码块中的代码，多个代码块按顺序拼接，没有代码块时返回原文
+func extractCode(content string) string {
+	matches := codeBlockPattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return strings.TrimSpace(content)
+	}
+
+	blocks := make([]string, 0, len(matches))
+	for _, match := range matches {
+		blocks = append(blocks, strings.TrimRight(match[1], "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// withExtraBody 通过 CallOptions.Metadata 传递需要写入请求体顶层的字段
+func withExtraBody(key string, value interface{}) llms.CallOption {
+	return func(o *llms.CallOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]interface{})
+		}
+		o.Metadata[extraBodyPrefix+key] = value
+	}
+}
+
+// extraBodyClient 将 Metadata 中带 qwen: 前缀的字段改写为 DashScope 要求的顶层字段，
+// 将请求标签中的 user 标签转发为 user 字段，并将音频片段转换为 input_audio 格式
+type extraBodyClient struct {
+	client *http.Client
+	signer auth.Provider
+}
+
+// Do 实现 openai 客户端的 Doer 接口
+func (c *extraBodyClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Method == http.MethodPost {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		data = media.RewriteAudioParts(rewriteExtraBody(data), media.StyleInputAudio)
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+	}
+	if err := auth.Apply(c.signer, req); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// rewriteExtraBody 将 metadata 中带前缀的字段移动到请求体顶层，并移除请求标签
+// 请求体无法解析或不包含此类字段时原样返回
+func rewriteExtraBody(data []byte) []byte {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return data
+	}
+
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal(body["metadata"], &metadata); err != nil {
+		return data
+	}
+
+	moved := false
+	for key, value := range metadata {
+		if field, ok := strings.CutPrefix(key, extraBodyPrefix); ok {
+			body[field] = value
+			delete(metadata, key)
+			moved = true
+		}
+	}
+	if rawTags, ok := metadata[requestTagsKey]; ok {
+		// 标签仅用于本地记录，user 标签转发为 OpenAI 兼容的 user 字段
+		var tags map[string]string
+		if err := json.Unmarshal(rawTags, &tags); err == nil && tags["user"] != "" {
+			if encoded, err := json.Marshal(tags["user"]); err == nil {
+				body["user"] = encoded
+			}
+		}
+		delete(metadata, requestTagsKey)
+		moved = true
+	}
+	if !moved {
+		return data
+	}
+
+	if len(metadata) == 0 {
+		delete(body, "metadata")
+	} else if encoded, err := json.Marshal(metadata); err == nil {
+		body["metadata"] = encoded
+	}
+
+	rewritten, err := json.Marshal(body)
+	if err != nil {
+		return data
+	}
+	return rewritten
+}
@@ -0,0 +1,99 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQwenTasks(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		requests []map[string]any
+		content  string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		reply, err := json.Marshal(map[string]any{
+			"id": "q-1", "object": "chat.completion", "created": 1, "model": body["model"],
+			"choices": []any{map[string]any{
+				"index": 0, "finish_reason": "stop",
+				"message": map[string]any{"role": "assistant", "content": content},
+			}},
+		})
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(reply)
+	}))
+	defer server.Close()
+
+	opts := []qwen.Option{qwen.WithAPIKey("test-key"), qwen.WithBaseURL(server.URL)}
+
+	t.Run("翻译使用qwen-mt模型和翻译参数", func(t *testing.T) {
+		requests = nil
+		content = " Hello, world \n"
+
+		text, err := qwen.Translate(ctx, "你好，世界", "English", opts...)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello, world", text)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, qwen.ModelQWenMTTurbo, requests[0]["model"])
+		assert.Equal(t, map[string]any{"source_lang": "auto", "target_lang": "English"}, requests[0]["translation_options"])
+		assert.NotContains(t, requests[0], "metadata")
+		assert.InDelta(t, 0.1, requests[0]["temperature"], 1e-9)
+		// 翻译模型不支持 system 消息
+		messages := requests[0]["messages"].([]any)
+		require.Len(t, messages, 1)
+		assert.Equal(t, "user", messages[0].(map[string]any)["role"])
+	})
+
+	t.Run("翻译可指定模型", func(t *testing.T) {
+		requests = nil
+		content = "Bonjour"
+
+		_, err := qwen.Translate(ctx, "你好", "French", append(opts, qwen.WithModel(qwen.ModelQWenMTPlus))...)
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+		assert.Equal(t, qwen.ModelQWenMTPlus, requests[0]["model"])
+	})
+
+	t.Run("代码生成使用qwen-coder模型并提取代码块", func(t *testing.T) {
+		requests = nil
+		content = "下面是实现：\n```go\nfunc add(a, b int) int { return a + b }\n```\n"
+
+		code, err := qwen.CodeComplete(ctx, "写一个加法函数", opts...)
+		require.NoError(t, err)
+		assert.Equal(t, "func add(a, b int) int { return a + b }", code)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, qwen.ModelQWenCoderPlus, requests[0]["model"])
+		assert.NotContains(t, requests[0], "translation_options")
+		assert.InDelta(t, 0.2, requests[0]["temperature"], 1e-9)
+		messages := requests[0]["messages"].([]any)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "system", messages[0].(map[string]any)["role"])
+	})
+
+	t.Run("参数错误不发送请求", func(t *testing.T) {
+		requests = nil
+
+		_, err := qwen.Translate(ctx, " ", "English", opts...)
+		assert.ErrorContains(t, err, "待翻译文本不能为空")
+		_, err = qwen.Translate(ctx, "你好", "", opts...)
+		assert.ErrorContains(t, err, "目标语言不能为空")
+		_, err = qwen.CodeComplete(ctx, "", opts...)
+		assert.ErrorContains(t, err, "代码提示不能为空")
+		assert.Empty(t, requests)
+	})
+}
@@ -0,0 +1,187 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// RaceMode 竞速策略
+type RaceMode string
+
+const (
+	// RaceFirstAcceptable 返回最先得到的可接受结果
+	RaceFirstAcceptable RaceMode = "first_acceptable"
+	// RacePreferAccurate 在截止时间内优先等待精确模型，超时后使用快速模型的结果
+	RacePreferAccurate RaceMode = "prefer_accurate"
+)
+
+// 竞速获胜方，写入 GenerationInfo 的 RaceWinnerKey 字段
+const (
+	RaceWinnerKey      = "race_winner"
+	RaceWinnerFast     = "fast"
+	RaceWinnerAccurate = "accurate"
+)
+
+// RacePolicy 竞速策略配置
+type RacePolicy struct {
+	// Mode 竞速模式，默认为 RaceFirstAcceptable
+	Mode RaceMode
+	// Deadline RacePreferAccurate 模式下等待精确模型的时间（从请求开始计时），0表示一直等待
+	Deadline time.Duration
+	// Acceptable 判断结果是否可接受，默认要求至少有一个候选且内容或工具调用不为空
+	Acceptable func(resp *llms.ContentResponse) bool
+}
+
+// RacingModel 双模型竞速装饰器
+// 将同一请求同时发送给快速（低成本）模型和较慢但效果更好的模型，
+// 按策略返回结果并取消落败的请求，用于降低交互场景的尾延迟。
+// 设置了流式回调时，内部请求以非流式方式发送，获胜结果会一次性回调给调用方。
+type RacingModel struct {
+	fast     llms.Model
+	accurate llms.Model
+	policy   RacePolicy
+}
+
+var _ llms.Model = (*RacingModel)(nil)
+
+// NewRacingModel 创建双模型竞速装饰器
+func NewRacingModel(fast, accurate llms.Model, policy RacePolicy) *RacingModel {
+	if policy.Mode == "" {
+		policy.Mode = RaceFirstAcceptable
+	}
+	if policy.Acceptable == nil {
+		policy.Acceptable = defaultAcceptable
+	}
+	return &RacingModel{
+		fast:     fast,
+		accurate: accurate,
+		policy:   policy,
+	}
+}
+
+// raceResult 单个模型的返回结果
+type raceResult struct {
+	resp     *llms.ContentResponse
+	err      error
+	accurate bool
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (r *RacingModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	streamingFunc := opts.StreamingFunc
+	// 覆盖调用方的流式回调，避免两个模型同时输出
+	innerOptions := append(options, llms.WithStreamingFunc(nil))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan raceResult, 2)
+	run := func(model llms.Model, accurate bool) {
+		resp, err := model.GenerateContent(ctx, messages, innerOptions...)
+		results <- raceResult{resp: resp, err: err, accurate: accurate}
+	}
+	go run(r.fast, false)
+	go run(r.accurate, true)
+
+	winner, err := r.pick(ctx, results)
+	if err != nil {
+		return nil, err
+	}
+
+	name := RaceWinnerFast
+	if winner.accurate {
+		name = RaceWinnerAccurate
+	}
+	for _, choice := range winner.resp.Choices {
+		if choice.GenerationInfo == nil {
+			choice.GenerationInfo = make(map[string]any)
+		}
+		choice.GenerationInfo[RaceWinnerKey] = name
+	}
+
+	if streamingFunc != nil && len(winner.resp.Choices) > 0 {
+		if err := streamingFunc(ctx, []byte(winner.resp.Choices[0].Content)); err != nil {
+			return nil, err
+		}
+	}
+	return winner.resp, nil
+}
+
+// pick 按策略选出获胜结果
+func (r *RacingModel) pick(ctx context.Context, results <-chan raceResult) (raceResult, error) {
+	var (
+		deadline      <-chan time.Time
+		deadlinePast  = r.policy.Mode == RaceFirstAcceptable
+		accurateAlive = true
+		fastResult    *raceResult
+		errs          []error
+	)
+	if r.policy.Mode == RacePreferAccurate && r.policy.Deadline > 0 {
+		timer := time.NewTimer(r.policy.Deadline)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for received := 0; received < 2; {
+		select {
+		case <-ctx.Done():
+			return raceResult{}, ctx.Err()
+		case <-deadline:
+			deadlinePast = true
+			if fastResult != nil {
+				return *fastResult, nil
+			}
+		case result := <-results:
+			received++
+			if !r.acceptable(result) {
+				if result.err == nil {
+					result.err = errors.New("结果不可接受")
+				}
+				errs = append(errs, result.err)
+				if result.accurate {
+					accurateAlive = false
+					if fastResult != nil {
+						return *fastResult, nil
+					}
+				}
+				continue
+			}
+			if result.accurate || deadlinePast || !accurateAlive {
+				return result, nil
+			}
+			fastResult = &result
+		}
+	}
+
+	if fastResult != nil {
+		return *fastResult, nil
+	}
+	return raceResult{}, fmt.Errorf("竞速模型均失败: %w", errors.Join(errs...))
+}
+
+// acceptable 判断结果是否可用
+func (r *RacingModel) acceptable(result raceResult) bool {
+	return result.err == nil && result.resp != nil && r.policy.Acceptable(result.resp)
+}
+
+// defaultAcceptable 默认的可接受判断
+func defaultAcceptable(resp *llms.ContentResponse) bool {
+	if len(resp.Choices) == 0 {
+		return false
+	}
+	choice := resp.Choices[0]
+	return choice.Content != "" || len(choice.ToolCalls) > 0
+}
+
+// Call 实现 llms.Model 接口
+func (r *RacingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, r, prompt, options...)
+}
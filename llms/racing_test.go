@@ -0,0 +1,92 @@
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// delayModel 延迟后返回固定回复，被取消时记录下来
+type delayModel struct {
+	reply     string
+	delay     time.Duration
+	err       error
+	cancelled chan struct{}
+}
+
+func newDelayModel(reply string, delay time.Duration, err error) *delayModel {
+	return &delayModel{reply: reply, delay: delay, err: err, cancelled: make(chan struct{}, 1)}
+}
+
+func (d *delayModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	select {
+	case <-time.After(d.delay):
+	case <-ctx.Done():
+		d.cancelled <- struct{}{}
+		return nil, ctx.Err()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: d.reply}}}, nil
+}
+
+func (d *delayModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, d, prompt, options...)
+}
+
+func TestRacingModel(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("first acceptable cancels loser", func(t *testing.T) {
+		fast := newDelayModel("fast", 5*time.Millisecond, nil)
+		accurate := newDelayModel("accurate", time.Second, nil)
+		text, err := llmscn.NewRacingModel(fast, accurate, llmscn.RacePolicy{}).Call(ctx, "hi")
+		require.NoError(t, err)
+		assert.Equal(t, "fast", text)
+
+		select {
+		case <-accurate.cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("accurate model was not cancelled")
+		}
+	})
+
+	t.Run("prefer accurate within deadline", func(t *testing.T) {
+		fast := newDelayModel("fast", time.Millisecond, nil)
+		accurate := newDelayModel("accurate", 20*time.Millisecond, nil)
+		resp, err := llmscn.NewRacingModel(fast, accurate, llmscn.RacePolicy{
+			Mode:     llmscn.RacePreferAccurate,
+			Deadline: 200 * time.Millisecond,
+		}).GenerateContent(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "accurate", resp.Choices[0].Content)
+		assert.Equal(t, llmscn.RaceWinnerAccurate, resp.Choices[0].GenerationInfo[llmscn.RaceWinnerKey])
+	})
+
+	t.Run("prefer accurate falls back after deadline", func(t *testing.T) {
+		fast := newDelayModel("fast", time.Millisecond, nil)
+		accurate := newDelayModel("accurate", time.Second, nil)
+		text, err := llmscn.NewRacingModel(fast, accurate, llmscn.RacePolicy{
+			Mode:     llmscn.RacePreferAccurate,
+			Deadline: 20 * time.Millisecond,
+		}).Call(ctx, "hi")
+		require.NoError(t, err)
+		assert.Equal(t, "fast", text)
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		fast := newDelayModel("", time.Millisecond, errors.New("fast down"))
+		accurate := newDelayModel("", time.Millisecond, errors.New("accurate down"))
+		_, err := llmscn.NewRacingModel(fast, accurate, llmscn.RacePolicy{}).Call(ctx, "hi")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fast down")
+		assert.Contains(t, err.Error(), "accurate down")
+	})
+}
@@ -0,0 +1,23 @@
+package llms
+
+import "github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+
+// RateLimiter 令牌桶限流器，通过各服务商的 WithRateLimiter 选项设置
+// （支持 deepseek、kimi、qwen、zhipu、siliconflow、ernie、spark、minimax、doubao、yi、baichuan）。同一个限流器可以传给多个客户端，
+// 多租户应用可据此限制每个服务商账号的总QPS，避免触发服务商的限流。
+// 每次HTTP请求（包括自动重试）消耗一个令牌，星火 WebSocket 协议每次建立连接消耗一个令牌
+type RateLimiter = ratelimit.Limiter
+
+// RateLimiterOptions 限流器配置
+type RateLimiterOptions = ratelimit.Options
+
+// RateLimiterStats 限流统计，包括排队和被拒绝的请求数
+type RateLimiterStats = ratelimit.Stats
+
+// ErrRateLimited 表示请求因超出排队时长或排队数量上限被拒绝
+var ErrRateLimited = ratelimit.ErrRateLimited
+
+// NewRateLimiter 创建限流器
+func NewRateLimiter(options RateLimiterOptions) *RateLimiter {
+	return ratelimit.New(options)
+}
@@ -0,0 +1,79 @@
+package llms_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("超出速率的请求排队等待", func(t *testing.T) {
+		limiter := llmscn.NewRateLimiter(llmscn.RateLimiterOptions{QPS: 50, Burst: 1})
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, limiter.Wait(context.Background()))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+		stats := limiter.Stats()
+		assert.Equal(t, int64(3), stats.Allowed)
+		assert.Equal(t, int64(2), stats.Queued)
+		assert.Zero(t, stats.Rejected)
+		assert.Zero(t, stats.Waiting)
+		assert.Positive(t, stats.TotalWait)
+	})
+
+	t.Run("超出排队时长或被取消时拒绝", func(t *testing.T) {
+		limiter := llmscn.NewRateLimiter(llmscn.RateLimiterOptions{QPS: 1, MaxWait: 10 * time.Millisecond})
+		require.NoError(t, limiter.Wait(context.Background()))
+		assert.ErrorIs(t, limiter.Wait(context.Background()), llmscn.ErrRateLimited)
+
+		unbounded := llmscn.NewRateLimiter(llmscn.RateLimiterOptions{QPS: 1})
+		require.NoError(t, unbounded.Wait(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, unbounded.Wait(ctx), context.DeadlineExceeded)
+
+		assert.Equal(t, int64(1), limiter.Stats().Rejected)
+		assert.Equal(t, int64(1), unbounded.Stats().Rejected)
+		assert.Equal(t, int64(1), unbounded.Stats().Queued)
+	})
+
+	t.Run("多个客户端共享限流器", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(retryTestCompletion))
+		}))
+		defer server.Close()
+
+		limiter := llmscn.NewRateLimiter(llmscn.RateLimiterOptions{QPS: 1, MaxWait: 10 * time.Millisecond})
+		qwenLLM, err := qwen.New(qwen.WithAPIKey("test-key"), qwen.WithBaseURL(server.URL), qwen.WithRateLimiter(limiter))
+		require.NoError(t, err)
+		deepseekLLM, err := deepseek.New(deepseek.WithAPIKey("test-key"), deepseek.WithBaseURL(server.URL), deepseek.WithRateLimiter(limiter))
+		require.NoError(t, err)
+
+		messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")}
+		_, err = qwenLLM.GenerateContent(context.Background(), messages)
+		require.NoError(t, err)
+		_, err = deepseekLLM.GenerateContent(context.Background(), messages)
+		assert.ErrorIs(t, err, llmscn.ErrRateLimited)
+		_, err = qwenLLM.GenerateContent(context.Background(), messages)
+		assert.ErrorIs(t, err, llmscn.ErrRateLimited)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, int64(2), limiter.Stats().Rejected)
+	})
+}
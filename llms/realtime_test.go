@@ -0,0 +1,200 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// realtimeServer 模拟智谱实时接口，记录客户端事件，handle 负责与客户端交互
+type realtimeServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	events []map[string]any
+}
+
+func newRealtimeServer(t *testing.T, handle func(ctx context.Context, s *realtimeServer, conn *websocket.Conn)) *realtimeServer {
+	s := &realtimeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		require.NoError(t, err)
+		defer conn.CloseNow()
+		handle(r.Context(), s, conn)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// read 读取一个客户端事件并记录
+func (s *realtimeServer) read(ctx context.Context, conn *websocket.Conn) (map[string]any, error) {
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var event map[string]any
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	s.mu.Unlock()
+	return event, nil
+}
+
+func (s *realtimeServer) received() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]any(nil), s.events...)
+}
+
+func (s *realtimeServer) dial(t *testing.T, config zhipu.RealtimeConfig) *zhipu.RealtimeSession {
+	config.APIKey = "test-key"
+	config.URL = "ws" + strings.TrimPrefix(s.URL, "http")
+	session, err := zhipu.DialRealtime(context.Background(), config)
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+// collectEvents 读取事件直到通道关闭
+func collectEvents(t *testing.T, session *zhipu.RealtimeSession) []zhipu.RealtimeEvent {
+	var events []zhipu.RealtimeEvent
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-session.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-timeout:
+			t.Fatal("等待事件通道关闭超时")
+			return nil
+		}
+	}
+}
+
+func TestZhipuRealtime(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("发送音频并接收文本与音频事件", func(t *testing.T) {
+		server := newRealtimeServer(t, func(ctx context.Context, s *realtimeServer, conn *websocket.Conn) {
+			// session.update、input_audio_buffer.append、commit、response.create
+			for range 4 {
+				_, err := s.read(ctx, conn)
+				require.NoError(t, err)
+			}
+			for _, event := range []string{
+				`{"type":"conversation.item.input_audio_transcription.completed","event_id":"e-1","transcript":"你好"}`,
+				`{"type":"response.text.delta","event_id":"e-2","delta":"你好，"}`,
+				`{"type":"response.audio.delta","event_id":"e-3","delta":"AQID"}`,
+				`{"type":"response.done","event_id":"e-4","response":{"status":"completed"}}`,
+			} {
+				require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte(event)))
+			}
+			conn.Close(websocket.StatusNormalClosure, "")
+		})
+
+		session := server.dial(t, zhipu.RealtimeConfig{Instructions: "你是客服", Voice: "tongtong", ClientVAD: true})
+		require.NoError(t, session.SendAudio(ctx, []byte{1, 2, 3}))
+		require.NoError(t, session.CommitAudio(ctx))
+
+		events := collectEvents(t, session)
+		require.Len(t, events, 4)
+		assert.Equal(t, zhipu.RealtimeEventInputTranscription, events[0].Type)
+		assert.Equal(t, "你好", events[0].Transcript)
+		assert.Equal(t, zhipu.RealtimeEventTextDelta, events[1].Type)
+		assert.Equal(t, "你好，", events[1].Delta)
+		assert.Equal(t, zhipu.RealtimeEventAudioDelta, events[2].Type)
+		audio, err := events[2].Audio()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3}, audio)
+		assert.Equal(t, zhipu.RealtimeEventResponseDone, events[3].Type)
+		assert.JSONEq(t, `{"type":"response.done","event_id":"e-4","response":{"status":"completed"}}`, string(events[3].Raw))
+		// 服务端正常关闭时没有错误
+		assert.NoError(t, session.Err())
+
+		received := server.received()
+		require.Len(t, received, 4)
+		assert.Equal(t, map[string]any{
+			"type": zhipu.RealtimeEventSessionUpdate,
+			"session": map[string]any{
+				"model":               zhipu.ModelGLMRealtime,
+				"instructions":        "你是客服",
+				"voice":               "tongtong",
+				"input_audio_format":  "wav",
+				"output_audio_format": "pcm",
+				"turn_detection":      map[string]any{"type": "client_vad"},
+			},
+		}, received[0])
+		assert.Equal(t, map[string]any{"type": zhipu.RealtimeEventAudioAppend, "audio": "AQID"}, received[1])
+		assert.Equal(t, zhipu.RealtimeEventAudioCommit, received[2]["type"])
+		assert.Equal(t, zhipu.RealtimeEventResponseCreate, received[3]["type"])
+	})
+
+	t.Run("错误事件与异常断开", func(t *testing.T) {
+		server := newRealtimeServer(t, func(ctx context.Context, s *realtimeServer, conn *websocket.Conn) {
+			_, err := s.read(ctx, conn)
+			require.NoError(t, err)
+			require.NoError(t, conn.Write(ctx, websocket.MessageText,
+				[]byte(`{"type":"error","error":{"type":"invalid_request_error","code":"1214","message":"音频格式错误"}}`)))
+			conn.Close(websocket.StatusInternalError, "boom")
+		})
+
+		session := server.dial(t, zhipu.RealtimeConfig{})
+		events := collectEvents(t, session)
+		require.Len(t, events, 1)
+		assert.Equal(t, zhipu.RealtimeEventError, events[0].Type)
+		require.NotNil(t, events[0].Error)
+		assert.Equal(t, "智谱实时接口错误 1214: 音频格式错误", events[0].Error.Error())
+
+		// 非正常关闭时通过 Err 返回断开原因
+		require.Error(t, session.Err())
+		assert.Equal(t, websocket.StatusInternalError, websocket.CloseStatus(session.Err()))
+		assert.Equal(t, "server_vad", server.received()[0]["session"].(map[string]any)["turn_detection"].(map[string]any)["type"])
+	})
+
+	t.Run("主动关闭", func(t *testing.T) {
+		closed := make(chan error, 1)
+		server := newRealtimeServer(t, func(ctx context.Context, s *realtimeServer, conn *websocket.Conn) {
+			_, err := s.read(ctx, conn)
+			require.NoError(t, err)
+			_, err = s.read(ctx, conn)
+			closed <- err
+		})
+
+		session := server.dial(t, zhipu.RealtimeConfig{})
+		require.NoError(t, session.Close())
+		assert.Empty(t, collectEvents(t, session))
+		assert.NoError(t, session.Err())
+		assert.Equal(t, websocket.StatusNormalClosure, websocket.CloseStatus(<-closed))
+
+		// 关闭后发送失败
+		assert.ErrorContains(t, session.SendAudio(ctx, []byte{1}), "发送 input_audio_buffer.append 事件失败")
+	})
+
+	t.Run("连接失败", func(t *testing.T) {
+		server := newRealtimeServer(t, func(context.Context, *realtimeServer, *websocket.Conn) {})
+
+		_, err := zhipu.DialRealtime(ctx, zhipu.RealtimeConfig{APIKey: "wrong-key", URL: "ws" + strings.TrimPrefix(server.URL, "http")})
+		assert.ErrorContains(t, err, "连接智谱实时接口失败")
+
+		t.Setenv("ZHIPU_API_KEY", "")
+		_, err = zhipu.DialRealtime(ctx, zhipu.RealtimeConfig{URL: "ws" + strings.TrimPrefix(server.URL, "http")})
+		assert.ErrorContains(t, err, "API密钥不能为空")
+	})
+}
@@ -0,0 +1,84 @@
+package llms
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// RemoteModel 通过OpenAI兼容接口访问远程部署的模型
+// 可指向任意OpenAI兼容端点（自建网关、第三方聚合平台、vLLM等），
+// 使图和Agent能以统一方式调用远程部署；直连各提供商时仍使用对应的本地客户端
+type RemoteModel struct {
+	*openai.LLM // 匿名嵌入OpenAI LLM，自动继承其所有方法
+
+	baseURL string
+	model   string
+}
+
+// NewRemoteModel 创建访问OpenAI兼容端点的远程模型
+// baseURL: 端点地址，如 "https://gateway.example.com/v1"
+// apiKey: API密钥，为空时不发送 Authorization 头（适用于无需鉴权的内网网关）
+// modelName: 远程端点上的模型名称
+// opts: 额外的 openai 选项，如 openai.WithEmbeddingModel、openai.WithHTTPClient
+func NewRemoteModel(baseURL, apiKey, modelName string, opts ...openai.Option) (*RemoteModel, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("%w: base_url", ErrMissingRequiredParam)
+	}
+	if modelName == "" {
+		return nil, fmt.Errorf("%w: model", ErrMissingRequiredParam)
+	}
+
+	openaiOpts := []openai.Option{
+		openai.WithBaseURL(baseURL),
+		openai.WithModel(modelName),
+	}
+	if apiKey != "" {
+		openaiOpts = append(openaiOpts, openai.WithToken(apiKey))
+	} else {
+		// openai 客户端要求非空密钥，使用占位密钥并在发送前移除鉴权头
+		openaiOpts = append(openaiOpts,
+			openai.WithToken("none"),
+			openai.WithHTTPClient(&noAuthClient{client: http.DefaultClient}),
+		)
+	}
+	openaiOpts = append(openaiOpts, opts...)
+
+	openaiLLM, err := openai.New(openaiOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建远程模型客户端失败: %w", err)
+	}
+	return &RemoteModel{LLM: openaiLLM, baseURL: baseURL, model: modelName}, nil
+}
+
+// Endpoint 返回远程端点地址，实现 Endpointer 接口以支持 Warmup
+func (r *RemoteModel) Endpoint() string {
+	return r.baseURL
+}
+
+// ModelName 返回远程端点上的模型名称
+func (r *RemoteModel) ModelName() string {
+	return r.model
+}
+
+// noAuthClient 移除 Authorization 头后转发请求
+type noAuthClient struct {
+	client *http.Client
+}
+
+// Do 实现 openai 客户端所需的 Doer 接口
+func (c *noAuthClient) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Del("Authorization")
+	return c.client.Do(req)
+}
+
+// createRemoteLLM 创建远程模型实例
+func createRemoteLLM(params map[string]interface{}) (*RemoteModel, error) {
+	baseURL, _ := params["base_url"].(string)
+	apiKey, _ := params["api_key"].(string)
+	model, _ := params["model"].(string)
+	return NewRemoteModel(baseURL, apiKey, model)
+}
@@ -0,0 +1,56 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestRemoteModel(t *testing.T) {
+	var auth, model, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		path = r.URL.Path
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"gateway-model",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	remote, err := llmscn.NewRemoteModel(server.URL+"/v1/", "secret", "gateway-model")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/v1", remote.Endpoint())
+
+	reply, err := llms.GenerateFromSinglePrompt(context.Background(), remote, "ping")
+	require.NoError(t, err)
+	assert.Equal(t, "pong", reply)
+	assert.Equal(t, "/v1/chat/completions", path)
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, "gateway-model", model)
+
+	// 未提供密钥时不发送鉴权头
+	model2, err := llmscn.CreateLLM(llmscn.RemoteLLM, map[string]interface{}{
+		"base_url": server.URL + "/v1",
+		"model":    "gateway-model",
+	})
+	require.NoError(t, err)
+	_, err = llms.GenerateFromSinglePrompt(context.Background(), model2, "ping")
+	require.NoError(t, err)
+	assert.Empty(t, auth)
+
+	_, err = llmscn.NewRemoteModel("", "", "gateway-model")
+	assert.True(t, errors.Is(err, llmscn.ErrMissingRequiredParam))
+}
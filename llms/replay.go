@@ -0,0 +1,317 @@
+package llms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
+)
+
+// RedactedValue 脱敏后的请求头和查询参数取值
+const RedactedValue = "[REDACTED]"
+
+// RequestPayload 记录的服务商请求，用于排查服务端行为差异和提交工单
+// 认证相关的请求头和查询参数已脱敏，可直接附在工单中
+type RequestPayload struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	// Body JSON 请求体原样保存，便于阅读和修改
+	Body json.RawMessage `json:"body,omitempty"`
+	// RawBody 非 JSON 请求体（如文件上传），序列化为 base64
+	RawBody    []byte    `json:"raw_body,omitempty"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// PayloadHandler 接收记录的请求，在请求发出前同步调用
+type PayloadHandler func(payload *RequestPayload)
+
+type payloadCaptureKey struct{}
+
+// WithPayloadCapture 返回开启请求记录的上下文，使用该上下文发出的服务商请求经过 CaptureTransport 时交给 handler
+// 只有按需开启的调用会被记录，未开启时 CaptureTransport 直接转发请求
+func WithPayloadCapture(ctx context.Context, handler PayloadHandler) context.Context {
+	return context.WithValue(ctx, payloadCaptureKey{}, handler)
+}
+
+// CaptureTransport 记录请求的 http.RoundTripper
+// 通过各服务商的 WithHTTPClient 选项或 openai.WithHTTPClient 使用；
+// 使用 http.DefaultClient 的服务商（qwen、zhipu、siliconflow）可调用 InstallPayloadCapture
+type CaptureTransport struct {
+	// Base 实际发送请求的 RoundTripper，为空时使用 http.DefaultTransport
+	Base http.RoundTripper
+}
+
+// NewCaptureTransport 创建记录请求的 RoundTripper
+func NewCaptureTransport(base http.RoundTripper) *CaptureTransport {
+	return &CaptureTransport{Base: base}
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *CaptureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	handler, _ := req.Context().Value(payloadCaptureKey{}).(PayloadHandler)
+	if handler == nil {
+		return base.RoundTrip(req)
+	}
+
+	payload := &RequestPayload{
+		Method:     req.Method,
+		URL:        redactURL(req.URL),
+		Header:     redactHeader(req.Header),
+		CapturedAt: time.Now(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// RoundTripper 不应修改原请求，使用副本重新设置请求体
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		if json.Valid(data) {
+			payload.Body = json.RawMessage(data)
+		} else {
+			payload.RawBody = data
+		}
+	}
+	handler(payload)
+
+	return base.RoundTrip(req)
+}
+
+var installCaptureOnce sync.Once
+
+// InstallPayloadCapture 为 http.DefaultClient 安装 CaptureTransport，重复调用无副作用
+// 安装后仍只记录通过 WithPayloadCapture 开启的调用
+func InstallPayloadCapture() {
+	installCaptureOnce.Do(func() {
+		http.DefaultClient.Transport = NewCaptureTransport(http.DefaultClient.Transport)
+	})
+}
+
+// SavePayload 将记录的请求保存为 JSON 文件
+func SavePayload(filename string, payload *RequestPayload) error {
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o600)
+}
+
+// LoadPayload 从 JSON 文件读取记录的请求
+func LoadPayload(filename string) (*RequestPayload, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var payload RequestPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("解析请求记录失败: %w", err)
+	}
+	return &payload, nil
+}
+
+// ReplayOptions 回放配置
+type ReplayOptions struct {
+	// Client 发送请求的HTTP客户端，默认为 http.DefaultClient
+	Client *http.Client
+	// Header 覆盖记录中的请求头，用于补充已脱敏的认证信息，如 Authorization
+	Header http.Header
+	// BaseURL 将请求发往其他地址（如测试环境），保留原请求的路径和查询参数
+	BaseURL string
+}
+
+// ReplayResult 回放得到的原始响应
+type ReplayResult struct {
+	StatusCode int
+	Header     http.Header
+	// Body 完整的响应体，流式请求为原始的 SSE 文本
+	Body     []byte
+	Duration time.Duration
+}
+
+// 内置服务商的API密钥环境变量，回放时用于补充已脱敏的认证头
+var replayTokenEnvVars = map[string]string{
+	"deepseek":    "DEEPSEEK_API_KEY",
+	"kimi":        "KIMI_API_KEY",
+	"qwen":        qwen.TokenEnvVarName,
+	"zhipu":       zhipu.TokenEnvVarName,
+	"siliconflow": siliconflow.TokenEnvVarName,
+	"openai":      "OPENAI_API_KEY",
+	"anthropic":   "ANTHROPIC_API_KEY",
+}
+
+// Replay 回放记录的服务商请求并返回原始响应
+// 已脱敏的认证头按请求地址从对应服务商的环境变量（如 QWEN_API_KEY）中补充
+func Replay(ctx context.Context, payloadFile string) (*ReplayResult, error) {
+	return ReplayWithOptions(ctx, payloadFile, ReplayOptions{})
+}
+
+// ReplayWithOptions 按配置回放记录的服务商请求
+func ReplayWithOptions(ctx context.Context, payloadFile string, opts ReplayOptions) (*ReplayResult, error) {
+	payload, err := LoadPayload(payloadFile)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := url.Parse(payload.URL)
+	if err != nil {
+		return nil, fmt.Errorf("请求记录中的地址无效: %w", err)
+	}
+	if opts.BaseURL != "" {
+		base, err := url.Parse(strings.TrimRight(opts.BaseURL, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("回放地址无效: %w", err)
+		}
+		target.Scheme, target.Host = base.Scheme, base.Host
+		target.Path = base.Path + target.Path
+	}
+	for key, values := range target.Query() {
+		if len(values) > 0 && values[0] == RedactedValue {
+			return nil, fmt.Errorf("%w: 查询参数 %s 已脱敏，无法回放", ErrMissingRequiredParam, key)
+		}
+	}
+
+	body := []byte(payload.Body)
+	if len(body) == 0 {
+		body = payload.RawBody
+	}
+	req, err := http.NewRequestWithContext(ctx, payload.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = payload.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	for key, values := range opts.Header {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	if err := restoreCredentials(req.Header, payload.URL); err != nil {
+		return nil, err
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取回放响应失败: %w", err)
+	}
+	return &ReplayResult{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       data,
+		Duration:   time.Since(start),
+	}, nil
+}
+
+// restoreCredentials 使用服务商的API密钥补充已脱敏的认证头，无法补充时返回错误
+func restoreCredentials(header http.Header, rawURL string) error {
+	var token string
+	for key, values := range header {
+		if len(values) == 0 || values[0] != RedactedValue {
+			continue
+		}
+		if token == "" {
+			if envVar := replayTokenEnvVar(rawURL); envVar != "" {
+				token = os.Getenv(envVar)
+			}
+			if token == "" {
+				return fmt.Errorf("%w: 请求头 %s 已脱敏，请通过 ReplayOptions.Header 或服务商的API密钥环境变量提供", ErrMissingRequiredParam, key)
+			}
+		}
+		if key == "Authorization" || key == "Proxy-Authorization" {
+			header.Set(key, "Bearer "+token)
+		} else {
+			header.Set(key, token)
+		}
+	}
+	return nil
+}
+
+// replayTokenEnvVar 按请求地址的主机名查找内置服务商的API密钥环境变量
+func replayTokenEnvVar(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	for provider, endpoint := range defaultEndpoints {
+		if e, err := url.Parse(endpoint); err == nil && e.Hostname() == u.Hostname() {
+			return replayTokenEnvVars[provider]
+		}
+	}
+	return ""
+}
+
+// isSensitiveName 判断请求头或查询参数是否包含认证信息
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "authorization", "proxy-authorization", "cookie":
+		return true
+	}
+	for _, word := range []string{"key", "token", "secret", "signature", "password"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeader 复制请求头并脱敏认证信息
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for key := range redacted {
+		if isSensitiveName(key) {
+			redacted[key] = []string{RedactedValue}
+		}
+	}
+	return redacted
+}
+
+// redactURL 返回脱敏查询参数后的地址
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	if len(query) == 0 {
+		return u.String()
+	}
+	changed := false
+	for key := range query {
+		if isSensitiveName(key) {
+			query.Set(key, RedactedValue)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
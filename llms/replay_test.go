@@ -0,0 +1,79 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+func TestReplay(t *testing.T) {
+	var auths, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		auths = append(auths, r.Header.Get("Authorization"))
+		bodies = append(bodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"m",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: llmscn.NewCaptureTransport(nil)}
+	remote, err := llmscn.NewRemoteModel(server.URL+"/v1", "secret", "m", openai.WithHTTPClient(client))
+	require.NoError(t, err)
+
+	// 未开启记录的调用不会被记录
+	var payloads []*llmscn.RequestPayload
+	_, err = llms.GenerateFromSinglePrompt(context.Background(), remote, "ping")
+	require.NoError(t, err)
+
+	ctx := llmscn.WithPayloadCapture(context.Background(), func(payload *llmscn.RequestPayload) {
+		payloads = append(payloads, payload)
+	})
+	reply, err := llms.GenerateFromSinglePrompt(ctx, remote, "ping")
+	require.NoError(t, err)
+	assert.Equal(t, "pong", reply)
+	require.Len(t, payloads, 1)
+
+	payload := payloads[0]
+	assert.Equal(t, http.MethodPost, payload.Method)
+	assert.Equal(t, server.URL+"/v1/chat/completions", payload.URL)
+	assert.Equal(t, llmscn.RedactedValue, payload.Header.Get("Authorization"))
+	assert.JSONEq(t, bodies[1], string(payload.Body))
+
+	file := filepath.Join(t.TempDir(), "payload.json")
+	require.NoError(t, llmscn.SavePayload(file, payload))
+
+	// 认证头已脱敏，本地地址无法从环境变量补充
+	_, err = llmscn.Replay(context.Background(), file)
+	assert.True(t, errors.Is(err, llmscn.ErrMissingRequiredParam))
+
+	result, err := llmscn.ReplayWithOptions(context.Background(), file, llmscn.ReplayOptions{
+		Header: http.Header{"Authorization": {"Bearer other"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "Bearer other", auths[2])
+	assert.JSONEq(t, bodies[1], bodies[2])
+
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	require.NoError(t, json.Unmarshal(result.Body, &resp))
+	assert.Equal(t, "pong", resp.Choices[0].Message.Content)
+}
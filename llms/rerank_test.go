@@ -0,0 +1,122 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestSiliconFlowRerank(t *testing.T) {
+	ctx := context.Background()
+
+	type request struct {
+		Model     string   `json:"model"`
+		Query     string   `json:"query"`
+		Documents []string `json:"documents"`
+		TopN      int      `json:"top_n"`
+	}
+	var (
+		requests []request
+		status   int
+		reply    string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var req request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	opts := []siliconflow.Option{siliconflow.WithAPIKey("test-key"), siliconflow.WithBaseURL(server.URL)}
+	documents := []string{"杭州是浙江省会", "西湖位于杭州", "北京是首都"}
+	// 结果未按得分排序，且忽略了 top_n
+	unsorted := `{"results":[{"index":2,"relevance_score":0.05},{"index":0,"relevance_score":0.6},{"index":1,"relevance_score":0.9}]}`
+
+	t.Run("按相关性得分排序", func(t *testing.T) {
+		requests = nil
+		status, reply = http.StatusOK, unsorted
+
+		results, err := siliconflow.Rerank(ctx, "西湖在哪里", documents, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, []siliconflow.RerankResult{
+			{Index: 1, Document: "西湖位于杭州", Score: 0.9},
+			{Index: 0, Document: "杭州是浙江省会", Score: 0.6},
+			{Index: 2, Document: "北京是首都", Score: 0.05},
+		}, results)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, siliconflow.DefaultRerankModel, requests[0].Model)
+		assert.Equal(t, "西湖在哪里", requests[0].Query)
+		assert.Equal(t, documents, requests[0].Documents)
+		assert.Zero(t, requests[0].TopN)
+	})
+
+	t.Run("按TopN截断", func(t *testing.T) {
+		requests = nil
+		status, reply = http.StatusOK, unsorted
+
+		results, err := siliconflow.Rerank(ctx, "西湖在哪里", documents,
+			append(opts, siliconflow.WithTopN(2), siliconflow.WithRerankModel(siliconflow.ModelBCEReranker))...)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, 1, results[0].Index)
+		assert.Equal(t, 0, results[1].Index)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, 2, requests[0].TopN)
+		assert.Equal(t, siliconflow.ModelBCEReranker, requests[0].Model)
+	})
+
+	t.Run("文档重排序保留元数据", func(t *testing.T) {
+		requests = nil
+		status, reply = http.StatusOK, unsorted
+
+		docs := make([]schema.Document, len(documents))
+		for i, content := range documents {
+			docs[i] = schema.Document{PageContent: content, Metadata: map[string]any{"id": i}, Score: 0.1}
+		}
+		reranked, err := siliconflow.RerankDocuments(ctx, "西湖在哪里", docs, opts...)
+		require.NoError(t, err)
+		require.Len(t, reranked, 3)
+		assert.Equal(t, "西湖位于杭州", reranked[0].PageContent)
+		assert.Equal(t, map[string]any{"id": 1}, reranked[0].Metadata)
+		assert.InDelta(t, 0.9, reranked[0].Score, 1e-6)
+		assert.Equal(t, map[string]any{"id": 2}, reranked[2].Metadata)
+		assert.InDelta(t, 0.05, reranked[2].Score, 1e-6)
+		// 输入文档不被修改
+		assert.InDelta(t, 0.1, docs[1].Score, 1e-6)
+	})
+
+	t.Run("空文档列表不发送请求", func(t *testing.T) {
+		requests = nil
+
+		results, err := siliconflow.Rerank(ctx, "西湖在哪里", nil, opts...)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		reranked, err := siliconflow.RerankDocuments(ctx, "西湖在哪里", nil, opts...)
+		require.NoError(t, err)
+		assert.Empty(t, reranked)
+		assert.Empty(t, requests)
+	})
+
+	t.Run("接口错误", func(t *testing.T) {
+		requests = nil
+		status, reply = http.StatusBadRequest, `{"code":20015,"message":"model does not exist"}`
+
+		_, err := siliconflow.Rerank(ctx, "西湖在哪里", documents, opts...)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status code 400")
+		assert.Contains(t, err.Error(), "20015 - model does not exist")
+	})
+}
@@ -0,0 +1,107 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	"github.com/sjzsdu/langchaingo-cn/llms/kimi"
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const retryTestCompletion = `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"好"},"finish_reason":"stop"}]}`
+
+// flakyServer 前 failures 次请求返回 status，之后返回正常的对话响应
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []json.RawMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Len(t, body.Messages, 1, "重试时应重新发送请求体")
+
+		if calls.Add(1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":{"type":"rate_limit","message":"busy"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(retryTestCompletion))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestProviderRetry(t *testing.T) {
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")}
+
+	t.Run("限流时按Retry-After重试", func(t *testing.T) {
+		server, calls := flakyServer(t, 2, http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}})
+
+		llm, err := deepseek.New(deepseek.WithAPIKey("test-key"), deepseek.WithBaseURL(server.URL), deepseek.WithMaxRetries(2))
+		require.NoError(t, err)
+
+		resp, err := llm.GenerateContent(context.Background(), messages)
+		require.NoError(t, err)
+		assert.Equal(t, "好", resp.Choices[0].Content)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("服务端错误时退避重试，次数用尽后返回错误", func(t *testing.T) {
+		server, calls := flakyServer(t, 3, http.StatusServiceUnavailable, nil)
+
+		llm, err := qwen.New(qwen.WithAPIKey("test-key"), qwen.WithBaseURL(server.URL),
+			qwen.WithMaxRetries(1), qwen.WithRetryBackoff(time.Millisecond))
+		require.NoError(t, err)
+
+		_, err = llm.GenerateContent(context.Background(), messages)
+		require.Error(t, err)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("客户端错误不重试", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusBadRequest, nil)
+
+		llm, err := qwen.New(qwen.WithAPIKey("test-key"), qwen.WithBaseURL(server.URL), qwen.WithMaxRetries(3))
+		require.NoError(t, err)
+
+		_, err = llm.GenerateContent(context.Background(), messages)
+		require.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("超时后重试", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				time.Sleep(200 * time.Millisecond)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(retryTestCompletion))
+		}))
+		defer server.Close()
+
+		llm, err := kimi.New(kimi.WithToken("test-key"), kimi.WithBaseURL(server.URL),
+			kimi.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}),
+			kimi.WithMaxRetries(1), kimi.WithRetryBackoff(time.Millisecond))
+		require.NoError(t, err)
+
+		resp, err := llm.GenerateContent(context.Background(), messages)
+		require.NoError(t, err)
+		assert.Equal(t, "好", resp.Choices[0].Content)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
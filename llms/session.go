@@ -0,0 +1,202 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"unicode"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrEmptyInput 表示会话输入为空
+var ErrEmptyInput = errors.New("输入内容不能为空")
+
+// Session 多轮对话会话
+// 会话绑定到一个固定的模型实例（provider pinning），负责维护消息历史、
+// 系统提示词、按token窗口裁剪历史以及会话级调用选项
+type Session struct {
+	model        llms.Model
+	systemPrompt string
+	history      []llms.MessageContent
+	callOptions  []llms.CallOption
+
+	maxMessages  int
+	maxTokens    int
+	tokenCounter func(text string) int
+
+	mu sync.Mutex
+}
+
+// SessionOption 会话配置选项
+type SessionOption func(*Session)
+
+// WithSystemPrompt 设置系统提示词，每次请求都会放在消息最前面
+func WithSystemPrompt(prompt string) SessionOption {
+	return func(s *Session) {
+		s.systemPrompt = prompt
+	}
+}
+
+// WithSessionTemperature 设置会话级温度参数
+func WithSessionTemperature(temperature float64) SessionOption {
+	return func(s *Session) {
+		s.callOptions = append(s.callOptions, llms.WithTemperature(temperature))
+	}
+}
+
+// WithSessionCallOptions 设置会话级调用选项，每次请求都会附带
+func WithSessionCallOptions(options ...llms.CallOption) SessionOption {
+	return func(s *Session) {
+		s.callOptions = append(s.callOptions, options...)
+	}
+}
+
+// WithMaxHistoryMessages 设置保留的最大历史消息数（不含系统提示词），0表示不限制
+func WithMaxHistoryMessages(n int) SessionOption {
+	return func(s *Session) {
+		s.maxMessages = n
+	}
+}
+
+// WithMaxHistoryTokens 设置历史消息的token窗口（不含系统提示词），0表示不限制
+func WithMaxHistoryTokens(n int) SessionOption {
+	return func(s *Session) {
+		s.maxTokens = n
+	}
+}
+
+// WithTokenCounter 设置自定义token计数函数，默认使用粗略估算
+func WithTokenCounter(counter func(text string) int) SessionOption {
+	return func(s *Session) {
+		s.tokenCounter = counter
+	}
+}
+
+// NewSession 创建绑定到指定模型的多轮对话会话
+func NewSession(model llms.Model, opts ...SessionOption) *Session {
+	s := &Session{
+		model:        model,
+		history:      make([]llms.MessageContent, 0),
+		tokenCounter: estimateTokens,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Send 发送一条用户消息并返回模型回复，回复会被追加到会话历史中
+func (s *Session) Send(ctx context.Context, text string, options ...llms.CallOption) (string, error) {
+	return s.send(ctx, text, options)
+}
+
+// Stream 以流式方式发送一条用户消息，每个分片都会回调 streamingFunc，
+// 返回完整回复并追加到会话历史中
+func (s *Session) Stream(ctx context.Context, text string, streamingFunc func(ctx context.Context, chunk []byte) error, options ...llms.CallOption) (string, error) {
+	options = append(options, llms.WithStreamingFunc(streamingFunc))
+	return s.send(ctx, text, options)
+}
+
+// send 执行一次对话轮次
+func (s *Session) send(ctx context.Context, text string, options []llms.CallOption) (string, error) {
+	if text == "" {
+		return "", ErrEmptyInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userMsg := llms.TextParts(llms.ChatMessageTypeHuman, text)
+	history := append(append([]llms.MessageContent{}, s.history...), userMsg)
+	history = s.trim(history)
+
+	messages := make([]llms.MessageContent, 0, len(history)+1)
+	if s.systemPrompt != "" {
+		messages = append(messages, llms.TextParts(llms.ChatMessageTypeSystem, s.systemPrompt))
+	}
+	messages = append(messages, history...)
+
+	callOptions := append(append([]llms.CallOption{}, s.callOptions...), options...)
+	resp, err := s.model.GenerateContent(ctx, messages, callOptions...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("模型未返回任何结果")
+	}
+
+	reply := resp.Choices[0].Content
+	s.history = append(history, llms.TextParts(llms.ChatMessageTypeAI, reply))
+
+	return reply, nil
+}
+
+// trim 按消息数和token窗口裁剪历史，总是保留最新的一条消息
+func (s *Session) trim(history []llms.MessageContent) []llms.MessageContent {
+	if s.maxMessages > 0 && len(history) > s.maxMessages {
+		history = history[len(history)-s.maxMessages:]
+	}
+
+	if s.maxTokens > 0 {
+		total := 0
+		start := len(history)
+		for i := len(history) - 1; i >= 0; i-- {
+			total += s.tokenCounter(messageText(history[i]))
+			if total > s.maxTokens && i < len(history)-1 {
+				break
+			}
+			start = i
+		}
+		history = history[start:]
+	}
+
+	return history
+}
+
+// History 返回当前会话历史的副本（不含系统提示词）
+func (s *Session) History() []llms.MessageContent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]llms.MessageContent, len(s.history))
+	copy(result, s.history)
+	return result
+}
+
+// Reset 清空会话历史，保留系统提示词和调用选项
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = make([]llms.MessageContent, 0)
+}
+
+// Model 返回会话绑定的模型
+func (s *Session) Model() llms.Model {
+	return s.model
+}
+
+// messageText 提取消息中的文本内容
+func messageText(msg llms.MessageContent) string {
+	var text string
+	for _, part := range msg.Parts {
+		if tc, ok := part.(llms.TextContent); ok {
+			text += tc.Text
+		}
+	}
+	return text
+}
+
+// estimateTokens 粗略估算token数：中日韩字符按1个token计，其余按每4个字符1个token计
+func estimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
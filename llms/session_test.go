@@ -0,0 +1,93 @@
+package llms_test
+
+import (
+	"context"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// fakeModel 记录收到的消息并返回固定回复
+type fakeModel struct {
+	reply    string
+	received [][]llms.MessageContent
+}
+
+func (f *fakeModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	f.received = append(f.received, messages)
+
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.StreamingFunc != nil {
+		if err := opts.StreamingFunc(ctx, []byte(f.reply)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: f.reply}}}, nil
+}
+
+func (f *fakeModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SendKeepsHistory", func(t *testing.T) {
+		model := &fakeModel{reply: "你好"}
+		session := llmscn.NewSession(model, llmscn.WithSystemPrompt("你是助手"))
+
+		reply, err := session.Send(ctx, "hi")
+		require.NoError(t, err)
+		assert.Equal(t, "你好", reply)
+
+		_, err = session.Send(ctx, "again")
+		require.NoError(t, err)
+
+		// 系统提示词 + 两轮历史 + 新消息
+		require.Len(t, model.received, 2)
+		assert.Len(t, model.received[1], 4)
+		assert.Equal(t, llms.ChatMessageTypeSystem, model.received[1][0].Role)
+		assert.Len(t, session.History(), 4)
+
+		session.Reset()
+		assert.Empty(t, session.History())
+	})
+
+	t.Run("TrimByMessages", func(t *testing.T) {
+		model := &fakeModel{reply: "ok"}
+		session := llmscn.NewSession(model, llmscn.WithMaxHistoryMessages(3))
+
+		for i := 0; i < 3; i++ {
+			_, err := session.Send(ctx, "msg")
+			require.NoError(t, err)
+		}
+		assert.Len(t, model.received[2], 3)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		model := &fakeModel{reply: "streamed"}
+		session := llmscn.NewSession(model)
+
+		var chunks []string
+		reply, err := session.Stream(ctx, "hi", func(ctx context.Context, chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "streamed", reply)
+		assert.Equal(t, []string{"streamed"}, chunks)
+	})
+
+	t.Run("EmptyInput", func(t *testing.T) {
+		session := llmscn.NewSession(&fakeModel{})
+		_, err := session.Send(ctx, "")
+		assert.ErrorIs(t, err, llmscn.ErrEmptyInput)
+	})
+}
@@ -0,0 +1,269 @@
+package llms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ShadowIDKey 写入主模型响应 GenerationInfo 的影子记录ID，可用于将线上反馈与对比记录关联
+const ShadowIDKey = "shadow_id"
+
+// ShadowSampler 决定请求是否同时发送给影子模型
+type ShadowSampler func(ctx context.Context, messages []llms.MessageContent) bool
+
+// SampleRate 按比例随机采样，rate 取值 0~1
+func SampleRate(rate float64) ShadowSampler {
+	return func(ctx context.Context, messages []llms.MessageContent) bool {
+		return rate > 0 && rand.Float64() < rate
+	}
+}
+
+// ShadowOutput 单个模型的输出
+type ShadowOutput struct {
+	Content          string          `json:"content"`
+	ToolCalls        []llms.ToolCall `json:"tool_calls,omitempty"`
+	StopReason       string          `json:"stop_reason,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	LatencyMs        int64           `json:"latency_ms"`
+	PromptTokens     int             `json:"prompt_tokens,omitempty"`
+	CompletionTokens int             `json:"completion_tokens,omitempty"`
+}
+
+// ShadowRecord 一次双写请求的对比记录
+type ShadowRecord struct {
+	ID       string                `json:"id"`
+	Time     time.Time             `json:"time"`
+	Tags     map[string]string     `json:"tags,omitempty"` // 请求标签，见 WithRequestTags
+	Messages []llms.MessageContent `json:"messages"`
+	Primary  ShadowOutput          `json:"primary"`
+	Shadow   ShadowOutput          `json:"shadow"`
+}
+
+// ShadowSink 对比记录的写入目标，在后台调用
+type ShadowSink interface {
+	WriteShadow(ctx context.Context, record ShadowRecord) error
+}
+
+// ShadowSinkFunc 将函数适配为 ShadowSink
+type ShadowSinkFunc func(ctx context.Context, record ShadowRecord) error
+
+// WriteShadow 实现 ShadowSink 接口
+func (f ShadowSinkFunc) WriteShadow(ctx context.Context, record ShadowRecord) error {
+	return f(ctx, record)
+}
+
+// FileShadowSink 将对比记录以 JSON Lines 格式追加到文件，每行一条记录
+type FileShadowSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ ShadowSink = (*FileShadowSink)(nil)
+
+// NewFileShadowSink 创建文件写入目标
+func NewFileShadowSink(path string) *FileShadowSink {
+	return &FileShadowSink{path: path}
+}
+
+// WriteShadow 实现 ShadowSink 接口
+func (s *FileShadowSink) WriteShadow(ctx context.Context, record ShadowRecord) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(record); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ShadowModel 影子流量装饰器，用于模型迁移
+// 所有请求都由主模型响应；被采样的请求同时异步发送给候选（影子）模型，
+// 两者的输出写入 ShadowSink 供离线对比，例如从 qwen-turbo 迁移到 qwen-plus 前评估效果。
+// 影子模型的失败、延迟和写入错误都不会影响主模型的响应。
+type ShadowModel struct {
+	primary llms.Model
+	shadow  llms.Model
+	sampler ShadowSampler
+	sink    ShadowSink
+
+	timeout     time.Duration
+	maxInFlight int64
+	onError     func(err error)
+
+	inFlight atomic.Int64
+	skipped  atomic.Int64
+	wg       sync.WaitGroup
+}
+
+var _ llms.Model = (*ShadowModel)(nil)
+
+// NewShadowModel 创建影子流量装饰器，sampler 为 nil 时所有请求都发送给影子模型
+func NewShadowModel(primary, shadow llms.Model, sampler ShadowSampler, sink ShadowSink) *ShadowModel {
+	if sampler == nil {
+		sampler = func(ctx context.Context, messages []llms.MessageContent) bool { return true }
+	}
+	return &ShadowModel{
+		primary:     primary,
+		shadow:      shadow,
+		sampler:     sampler,
+		sink:        sink,
+		timeout:     2 * time.Minute,
+		maxInFlight: 100,
+	}
+}
+
+// WithTimeout 设置影子请求（含写入记录）的超时时间，默认2分钟
+// 影子请求不随调用方的 context 取消，只受该超时限制
+func (m *ShadowModel) WithTimeout(timeout time.Duration) *ShadowModel {
+	m.timeout = timeout
+	return m
+}
+
+// WithMaxInFlight 设置同时进行的影子请求上限，默认100，超出时跳过采样；0表示不限制
+func (m *ShadowModel) WithMaxInFlight(n int) *ShadowModel {
+	m.maxInFlight = int64(n)
+	return m
+}
+
+// WithErrorHandler 设置对比记录写入失败时的回调，可用于记录日志
+func (m *ShadowModel) WithErrorHandler(onError func(err error)) *ShadowModel {
+	m.onError = onError
+	return m
+}
+
+// Skipped 返回因达到并发上限而跳过的采样请求数
+func (m *ShadowModel) Skipped() int64 {
+	return m.skipped.Load()
+}
+
+// Flush 等待进行中的影子请求完成并写入记录，或直到 ctx 结束
+func (m *ShadowModel) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shadowCall 一次模型调用的结果
+type shadowCall struct {
+	resp    *llms.ContentResponse
+	err     error
+	latency time.Duration
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *ShadowModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if !m.sampler(ctx, messages) {
+		return m.primary.GenerateContent(ctx, messages, options...)
+	}
+	if n := m.inFlight.Add(1); m.maxInFlight > 0 && n > m.maxInFlight {
+		m.inFlight.Add(-1)
+		m.skipped.Add(1)
+		return m.primary.GenerateContent(ctx, messages, options...)
+	}
+
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	tags := RequestTagsFromContext(ctx)
+	if requestTags := RequestTags(opts); requestTags != nil {
+		for k, v := range tags {
+			if _, ok := requestTags[k]; !ok {
+				requestTags[k] = v
+			}
+		}
+		tags = requestTags
+	}
+	record := ShadowRecord{
+		ID:       newUsageBatchID(),
+		Time:     time.Now(),
+		Tags:     tags,
+		Messages: messages,
+	}
+
+	// 影子请求与主请求同时发出，不继承调用方的取消，也不输出流式内容
+	primaryDone := make(chan ShadowOutput, 1)
+	shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.timeout)
+	shadowOptions := append(options[:len(options):len(options)], llms.WithStreamingFunc(nil))
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.inFlight.Add(-1)
+		defer cancel()
+
+		start := time.Now()
+		resp, err := m.shadow.GenerateContent(shadowCtx, messages, shadowOptions...)
+		record.Shadow = shadowOutput(shadowCall{resp: resp, err: err, latency: time.Since(start)})
+		record.Primary = <-primaryDone
+
+		if err := m.sink.WriteShadow(shadowCtx, record); err != nil && m.onError != nil {
+			m.onError(err)
+		}
+	}()
+
+	start := time.Now()
+	resp, err := m.primary.GenerateContent(ctx, messages, options...)
+	// 在写入 GenerationInfo 之前提取主模型输出，避免与后台协程并发访问
+	primaryDone <- shadowOutput(shadowCall{resp: resp, err: err, latency: time.Since(start)})
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	for _, choice := range resp.Choices {
+		if choice.GenerationInfo == nil {
+			choice.GenerationInfo = make(map[string]any)
+		}
+		choice.GenerationInfo[ShadowIDKey] = record.ID
+	}
+	return resp, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *ShadowModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// shadowOutput 提取调用结果中用于对比的字段，多候选时只取第一个
+func shadowOutput(call shadowCall) ShadowOutput {
+	output := ShadowOutput{LatencyMs: call.latency.Milliseconds()}
+	if call.err != nil {
+		output.Error = call.err.Error()
+		return output
+	}
+	if call.resp == nil || len(call.resp.Choices) == 0 {
+		return output
+	}
+	choice := call.resp.Choices[0]
+	output.Content = choice.Content
+	output.ToolCalls = choice.ToolCalls
+	output.StopReason = choice.StopReason
+	output.PromptTokens, output.CompletionTokens, _ = TokenUsage(choice.GenerationInfo)
+	return output
+}
@@ -0,0 +1,157 @@
+package llms_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// recordingShadowSink 收集写入的对比记录
+type recordingShadowSink struct {
+	mu      sync.Mutex
+	records []llmscn.ShadowRecord
+}
+
+func (s *recordingShadowSink) WriteShadow(ctx context.Context, record llmscn.ShadowRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *recordingShadowSink) all() []llmscn.ShadowRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]llmscn.ShadowRecord(nil), s.records...)
+}
+
+func TestShadowModel(t *testing.T) {
+	t.Run("记录主模型与影子模型的输出", func(t *testing.T) {
+		sink := &recordingShadowSink{}
+		model := llmscn.NewShadowModel(
+			&scriptedModel{replies: []string{"qwen-turbo 的回答"}},
+			&scriptedModel{replies: []string{"qwen-plus 的回答"}},
+			nil, sink)
+
+		resp, err := model.GenerateContent(context.Background(),
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "介绍一下杭州")},
+			llmscn.WithRequestTags(map[string]string{"feature": "travel"}))
+		require.NoError(t, err)
+		assert.Equal(t, "qwen-turbo 的回答", resp.Choices[0].Content)
+		require.NoError(t, model.Flush(context.Background()))
+
+		records := sink.all()
+		require.Len(t, records, 1)
+		record := records[0]
+		assert.Equal(t, record.ID, resp.Choices[0].GenerationInfo[llmscn.ShadowIDKey])
+		assert.Equal(t, "qwen-turbo 的回答", record.Primary.Content)
+		assert.Equal(t, "qwen-plus 的回答", record.Shadow.Content)
+		assert.Equal(t, "travel", record.Tags["feature"])
+		require.Len(t, record.Messages, 1)
+	})
+
+	t.Run("影子模型失败不影响响应且不随调用方取消", func(t *testing.T) {
+		sink := &recordingShadowSink{}
+		model := llmscn.NewShadowModel(
+			&scriptedModel{replies: []string{"primary"}},
+			newDelayModel("", 20*time.Millisecond, errors.New("rate limited")),
+			nil, sink)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		text, err := model.Call(ctx, "hi")
+		cancel()
+		require.NoError(t, err)
+		assert.Equal(t, "primary", text)
+		require.NoError(t, model.Flush(context.Background()))
+
+		records := sink.all()
+		require.Len(t, records, 1)
+		assert.Equal(t, "rate limited", records[0].Shadow.Error)
+		assert.GreaterOrEqual(t, records[0].Shadow.LatencyMs, int64(20))
+	})
+
+	t.Run("未采样的请求只发送给主模型", func(t *testing.T) {
+		sink := &recordingShadowSink{}
+		shadow := &scriptedModel{replies: []string{"shadow"}}
+		model := llmscn.NewShadowModel(&scriptedModel{replies: []string{"primary"}}, shadow, llmscn.SampleRate(0), sink)
+
+		for i := 0; i < 5; i++ {
+			_, err := model.Call(context.Background(), "hi")
+			require.NoError(t, err)
+		}
+		require.NoError(t, model.Flush(context.Background()))
+		assert.Empty(t, sink.all())
+		assert.Empty(t, shadow.received)
+	})
+
+	t.Run("达到并发上限时跳过采样", func(t *testing.T) {
+		sink := &recordingShadowSink{}
+		model := llmscn.NewShadowModel(
+			&scriptedModel{replies: []string{"primary"}},
+			newDelayModel("shadow", 50*time.Millisecond, nil),
+			nil, sink).WithMaxInFlight(1)
+
+		for i := 0; i < 3; i++ {
+			_, err := model.Call(context.Background(), "hi")
+			require.NoError(t, err)
+		}
+		require.NoError(t, model.Flush(context.Background()))
+		assert.Len(t, sink.all(), 1)
+		assert.Equal(t, int64(2), model.Skipped())
+	})
+
+	t.Run("写入失败时回调", func(t *testing.T) {
+		var sinkErr error
+		model := llmscn.NewShadowModel(
+			&scriptedModel{replies: []string{"primary"}},
+			&scriptedModel{replies: []string{"shadow"}},
+			nil, llmscn.ShadowSinkFunc(func(ctx context.Context, record llmscn.ShadowRecord) error {
+				return errors.New("disk full")
+			})).WithErrorHandler(func(err error) { sinkErr = err })
+
+		_, err := model.Call(context.Background(), "hi")
+		require.NoError(t, err)
+		require.NoError(t, model.Flush(context.Background()))
+		assert.EqualError(t, sinkErr, "disk full")
+	})
+}
+
+func TestFileShadowSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shadow.jsonl")
+	model := llmscn.NewShadowModel(
+		&scriptedModel{replies: []string{"primary"}},
+		newDelayModel("shadow", 0, nil),
+		nil, llmscn.NewFileShadowSink(path))
+
+	for i := 0; i < 2; i++ {
+		_, err := model.Call(context.Background(), "hi")
+		require.NoError(t, err)
+	}
+	require.NoError(t, model.Flush(context.Background()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record llmscn.ShadowRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Equal(t, "primary", record.Primary.Content)
+		assert.Equal(t, "shadow", record.Shadow.Content)
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
@@ -0,0 +1,181 @@
+package siliconflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	// RerankModelEnvVarName 重排序模型环境变量
+	RerankModelEnvVarName = "SILICONFLOW_RERANK_MODEL" //nolint:gosec
+
+	// DefaultRerankModel 默认重排序模型
+	DefaultRerankModel = ModelBGERerankerV2M3
+)
+
+const (
+	// ModelBGERerankerV2M3 是BGE-Reranker-v2-m3多语言重排序模型
+	ModelBGERerankerV2M3 = "BAAI/bge-reranker-v2-m3"
+
+	// ModelBCEReranker 是BCE-Reranker重排序模型
+	ModelBCEReranker = "netease-youdao/bce-reranker-base_v1"
+)
+
+// WithRerankModel 设置 Rerank 使用的重排序模型
+func WithRerankModel(model string) Option {
+	return func(o *options) {
+		o.rerankModel = model
+	}
+}
+
+// WithTopN 设置 Rerank 返回的文档数量，0 表示返回全部文档
+func WithTopN(n int) Option {
+	return func(o *options) {
+		o.topN = n
+	}
+}
+
+// RerankResult 重排序结果
+type RerankResult struct {
+	// Index 文档在输入中的序号
+	Index int
+	// Document 文档内容
+	Document string
+	// Score 与查询的相关性得分，越大越相关
+	Score float64
+}
+
+// rerankRequest 硅基流动重排序请求
+type rerankRequest struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n,omitempty"`
+	ReturnDocuments bool     `json:"return_documents"`
+}
+
+// rerankResponse 硅基流动重排序响应，失败时只包含 code 和 message
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Rerank 使用重排序模型计算文档与查询的相关性，按得分从高到低返回
+// 适合在向量召回之后做第二阶段排序，默认使用 BAAI/bge-reranker-v2-m3，
+// 可通过 WithRerankModel 或 SILICONFLOW_RERANK_MODEL 环境变量指定其他模型
+func Rerank(ctx context.Context, query string, documents []string, opts ...Option) ([]RerankResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("查询不能为空")
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	options := defaultOptions()
+	options.rerankModel = getEnvOrDefault(RerankModelEnvVarName, DefaultRerankModel)
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.apiKey == "" && options.authProvider == nil {
+		return nil, errors.New("API密钥不能为空，请设置SILICONFLOW_API_KEY环境变量或使用WithAPIKey选项")
+	}
+
+	body, err := json.Marshal(rerankRequest{
+		Model:     options.rerankModel,
+		Query:     query,
+		Documents: documents,
+		TopN:      options.topN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化重排序请求失败: %w", err)
+	}
+	url := strings.TrimRight(options.baseURL, "/") + "/rerank"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建重排序请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if options.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+options.apiKey)
+	}
+	if err := auth.Apply(options.authProvider, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := retry.Wrap(ratelimit.Wrap(http.DefaultClient, options.rateLimiter), options.retryPolicy).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求重排序接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取重排序响应失败: %w", err)
+	}
+	var result rerankResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("API error (status code %d): %s", resp.StatusCode, string(data))
+		}
+		return nil, fmt.Errorf("解析重排序响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status code %d): %d - %s", resp.StatusCode, result.Code, result.Message)
+	}
+
+	results := make([]RerankResult, 0, len(result.Results))
+	for _, item := range result.Results {
+		if item.Index < 0 || item.Index >= len(documents) {
+			return nil, fmt.Errorf("重排序响应中的文档序号越界: %d", item.Index)
+		}
+		results = append(results, RerankResult{
+			Index:    item.Index,
+			Document: documents[item.Index],
+			Score:    item.RelevanceScore,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	// 部分模型忽略 top_n 参数，按得分排序后在本地截断
+	if options.topN > 0 && len(results) > options.topN {
+		results = results[:options.topN]
+	}
+	return results, nil
+}
+
+// RerankDocuments 对检索得到的文档重排序，返回按相关性从高到低排列的文档，
+// 文档的 Score 被替换为重排序得分
+func RerankDocuments(ctx context.Context, query string, docs []schema.Document, opts ...Option) ([]schema.Document, error) {
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		contents[i] = doc.PageContent
+	}
+
+	results, err := Rerank(ctx, query, contents, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	reranked := make([]schema.Document, 0, len(results))
+	for _, result := range results {
+		doc := docs[result.Index]
+		doc.Score = float32(result.Score)
+		reranked = append(reranked, doc)
+	}
+	return reranked, nil
+}
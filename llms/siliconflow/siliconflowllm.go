@@ -1,11 +1,21 @@
 package siliconflow
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-
+	"strings"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/media"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 )
@@ -96,6 +106,10 @@ const (
 // LLM 是硅基流动大语言模型的实现
 type LLM struct {
 	*openai.LLM // 匿名嵌入OpenAI LLM，自动继承其所有方法
+
+	model      string   // 默认模型，用于判断模型能力
+	tier       Tier     // 模型档位，未设置时为空
+	tierModels []string // 档位候选模型，用于过载时自动切换
 }
 
 // Option 是LLM的配置选项函数类型
@@ -107,6 +121,14 @@ type options struct {
 	baseURL        string
 	model          string
 	embeddingModel string
+	tier           Tier
+	authProvider   auth.Provider
+	retryPolicy    retry.Policy
+	rateLimiter    *ratelimit.Limiter
+
+	// 重排序配置，见 Rerank
+	rerankModel string
+	topN        int
 }
 
 // WithAPIKey 设置API密钥
@@ -123,6 +145,37 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithAuthProvider 设置自定义认证方式，如 AK/SK 签名或自定义认证头，
+// 设置后不再要求API密钥，请求在发送前由 provider 签名
+func WithAuthProvider(provider auth.Provider) Option {
+	return func(o *options) {
+		o.authProvider = provider
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
 // WithModel 设置模型
 func WithModel(model string) Option {
 	return func(o *options) {
@@ -141,6 +194,7 @@ func WithEmbeddingModel(model string) Option {
 func defaultOptions() options {
 	return options{
 		apiKey:         os.Getenv(TokenEnvVarName),
+		baseURL:        OpenAICompatibleBaseURL,
 		model:          getEnvOrDefault(ModelEnvVarName, DefaultModel),
 		embeddingModel: getEnvOrDefault(EmbeddingModelEnvVarName, DefaultEmbeddingModel),
 	}
@@ -165,16 +219,33 @@ func New(opts ...Option) (*LLM, error) {
 	}
 
 	// 验证API密钥
-	if options.apiKey == "" {
+	if options.apiKey == "" && options.authProvider == nil {
 		return nil, errors.New("API密钥不能为空，请设置SILICONFLOW_API_KEY环境变量或使用WithAPIKey选项")
 	}
 
+	// 按档位选择首选模型
+	var models []string
+	if options.tier != "" {
+		models = TierModels(options.tier)
+		if len(models) == 0 {
+			return nil, fmt.Errorf("未知的模型档位: %s", options.tier)
+		}
+		options.model = models[0]
+	}
+
+	// 使用自定义认证方式时令牌仅作为占位，发送前会被替换
+	token := options.apiKey
+	if token == "" {
+		token = "none"
+	}
+
 	// 创建OpenAI客户端
 	openaiOpts := []openai.Option{
-		openai.WithToken(options.apiKey),
+		openai.WithToken(token),
 		openai.WithModel(options.model),
-		openai.WithBaseURL(OpenAICompatibleBaseURL),
+		openai.WithBaseURL(options.baseURL),
 		openai.WithEmbeddingModel(options.embeddingModel),
+		openai.WithHTTPClient(retry.Wrap(ratelimit.Wrap(&audioClient{client: http.DefaultClient, signer: options.authProvider}, options.rateLimiter), options.retryPolicy)),
 	}
 
 	openaiLLM, err := openai.New(openaiOpts...)
@@ -182,7 +253,7 @@ func New(opts ...Option) (*LLM, error) {
 		return nil, fmt.Errorf("创建OpenAI客户端失败: %w", err)
 	}
 
-	return &LLM{LLM: openaiLLM}, nil
+	return &LLM{LLM: openaiLLM, model: options.model, tier: options.tier, tierModels: models}, nil
 }
 
 // GetModels 返回硅基流动支持的模型列表
@@ -220,7 +291,66 @@ func (s *LLM) GetEmbeddingModels() []string {
 }
 
 // GenerateContent 重写生成内容方法，处理推理模型的特殊返回格式
+// 设置了档位且调用时未指定模型时，首选模型过载会自动切换到同档位的其他模型
+// 消息包含音频时检查模型是否支持音频输入，音频片段在发送前被转换为 audio_url 格式
 func (s *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if media.HasAudio(messages) {
+		opts := llms.CallOptions{Model: s.model}
+		for _, opt := range options {
+			opt(&opts)
+		}
+		if !SupportsAudio(opts.Model) {
+			return nil, &media.CapabilityError{Provider: "siliconflow", Model: opts.Model, Capability: "audio"}
+		}
+	}
+
+	if len(s.tierModels) > 0 {
+		opts := llms.CallOptions{}
+		for _, opt := range options {
+			opt(&opts)
+		}
+		if opts.Model == "" {
+			return s.generateWithFallback(ctx, messages, options...)
+		}
+	}
+
 	// 硅基流动完全兼容OpenAI接口，直接调用父类方法
-	return s.LLM.GenerateContent(ctx, messages, options...)
+	resp, err := s.LLM.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	// openai 客户端只写入 PromptTokens 等键，补充统一的用量键
+	usage.Normalize(resp)
+	return resp, nil
+}
+
+// SupportsAudio 判断模型是否支持音频输入
+// 硅基流动上的音频理解模型名称中均包含 audio 或 omni（如 Qwen2-Audio、Qwen2.5-Omni 系列）
+func SupportsAudio(model string) bool {
+	model = strings.ToLower(model)
+	return strings.Contains(model, "audio") || strings.Contains(model, "omni")
+}
+
+// audioClient 在发送请求前将音频片段转换为 audio_url 格式
+type audioClient struct {
+	client *http.Client
+	signer auth.Provider
+}
+
+// Do 实现 openai 客户端的 Doer 接口
+func (c *audioClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Method == http.MethodPost {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		data = media.RewriteAudioParts(data, media.StyleAudioURL)
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+	}
+	if err := auth.Apply(c.signer, req); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
 }
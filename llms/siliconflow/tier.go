@@ -0,0 +1,83 @@
+package siliconflow
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Tier 模型档位，按价格与性能划分
+type Tier string
+
+const (
+	// TierFast 低延迟、低成本的小模型
+	TierFast Tier = "fast"
+	// TierBalanced 兼顾效果与成本的中等模型
+	TierBalanced Tier = "balanced"
+	// TierQuality 效果优先的大模型
+	TierQuality Tier = "quality"
+)
+
+// tierModels 各档位的候选模型，按优先级排列
+// 平台模型名变化较频繁，应用只需指定档位，模型列表随本库更新
+var tierModels = map[Tier][]string{
+	TierFast:     {ModelQwen257B, ModelGLM49B, ModelMistral7B},
+	TierBalanced: {ModelQwen2532B, ModelQwen2514B, ModelInternLM25},
+	TierQuality:  {ModelDeepSeekV3, ModelQwen2572B, ModelDeepSeekV25},
+}
+
+// statusCodePattern 匹配 openai 客户端错误信息中的HTTP状态码
+var statusCodePattern = regexp.MustCompile(`status code: (\d+)`)
+
+// WithTier 按档位选择模型，首选模型过载或限流时自动切换到同档位的下一个模型
+// 设置后优先于 WithModel
+func WithTier(tier Tier) Option {
+	return func(o *options) {
+		o.tier = tier
+	}
+}
+
+// TierModels 返回档位的候选模型列表，未知档位返回 nil
+func TierModels(tier Tier) []string {
+	models, ok := tierModels[tier]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), models...)
+}
+
+// generateWithFallback 依次使用档位中的模型请求，遇到过载错误时切换到下一个模型
+func (s *LLM) generateWithFallback(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var lastErr error
+	for _, model := range s.tierModels {
+		resp, err := s.LLM.GenerateContent(ctx, messages, append(options[:len(options):len(options)], llms.WithModel(model))...)
+		if err == nil {
+			usage.Normalize(resp)
+			return resp, nil
+		}
+		if !isOverloaded(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = fmt.Errorf("模型 %s 过载: %w", model, err)
+	}
+	return nil, fmt.Errorf("档位 %s 的所有模型均不可用: %w", s.tier, lastErr)
+}
+
+// isOverloaded 判断错误是否表示模型过载或限流，此类错误换用其他模型通常可以成功
+// 硅基流动在模型过载时返回 503（错误码 50505），限流时返回 429
+func isOverloaded(err error) bool {
+	msg := strings.ToLower(err.Error())
+	if match := statusCodePattern.FindStringSubmatch(msg); match != nil {
+		code, _ := strconv.Atoi(match[1])
+		switch code {
+		case 429, 502, 503, 504:
+			return true
+		}
+	}
+	return strings.Contains(msg, "overloaded") || strings.Contains(msg, "50505")
+}
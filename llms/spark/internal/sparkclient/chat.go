@@ -0,0 +1,78 @@
+package sparkclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/coder/websocket"
+)
+
+// CreateChat 通过 WebSocket 发送一次聊天请求，逐帧读取响应直到最后一帧
+// 星火协议总是流式返回，设置了 StreamingFunc 时每帧的增量文本会回调给调用方
+func (c *Client) CreateChat(ctx context.Context, request *ChatRequest) (*ChatResponse, error) {
+	endpoint, err := c.signURL(Path(request.Model))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, resp, err := websocket.Dial(ctx, endpoint, &websocket.DialOptions{HTTPClient: c.httpClient})
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("连接星火接口失败 (%d): %w", resp.StatusCode, err)
+		}
+		return nil, fmt.Errorf("连接星火接口失败: %w", err)
+	}
+	defer conn.CloseNow()
+
+	payloadBytes, err := json.Marshal(c.newWireRequest(request))
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+	if err := conn.Write(ctx, websocket.MessageText, payloadBytes); err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+
+	response := &ChatResponse{}
+	var text strings.Builder
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("读取响应失败: %w", err)
+		}
+
+		var frame wireResponse
+		if err := json.Unmarshal(data, &frame); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+		if frame.Header.Code != 0 {
+			return nil, &APIError{Code: frame.Header.Code, Message: frame.Header.Message, SID: frame.Header.SID}
+		}
+
+		response.SID = frame.Header.SID
+		for _, choice := range frame.Payload.Choices.Text {
+			if choice.FunctionCall != nil {
+				response.FunctionCall = choice.FunctionCall
+			}
+			if choice.Content == "" {
+				continue
+			}
+			text.WriteString(choice.Content)
+			if request.StreamingFunc != nil {
+				if err := request.StreamingFunc(ctx, []byte(choice.Content)); err != nil {
+					return nil, fmt.Errorf("流式函数返回错误: %w", err)
+				}
+			}
+		}
+
+		if frame.Header.Status == 2 {
+			response.Usage = frame.Payload.Usage.Text
+			break
+		}
+	}
+
+	response.Content = text.String()
+	conn.Close(websocket.StatusNormalClosure, "")
+	return response, nil
+}
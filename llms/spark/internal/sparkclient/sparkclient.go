@@ -0,0 +1,231 @@
+package sparkclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL 是讯飞星火 WebSocket 协议的默认地址
+const DefaultBaseURL = "wss://spark-api.xf-yun.com"
+
+// modelPaths 模型（即请求中的 domain）到 WebSocket 接口路径的映射，未列出的模型使用 /<model>/chat
+var modelPaths = map[string]string{
+	"lite":        "/v1.1/chat",
+	"generalv3":   "/v3.1/chat",
+	"pro-128k":    "/chat/pro-128k",
+	"generalv3.5": "/v3.5/chat",
+	"max-32k":     "/chat/max-32k",
+	"4.0Ultra":    "/v4.0/chat",
+}
+
+// Path 返回模型对应的 WebSocket 接口路径
+func Path(model string) string {
+	if path, ok := modelPaths[model]; ok {
+		return path
+	}
+	return "/" + model + "/chat"
+}
+
+// APIError 是星火接口返回的错误，握手成功后错误通过响应帧头部的 code 返回
+type APIError struct {
+	Code    int
+	Message string
+	SID     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API错误 (%d): %s (sid: %s)", e.Code, e.Message, e.SID)
+}
+
+// Client 是星火 WebSocket 协议的客户端，每次请求建立一个新连接
+type Client struct {
+	appID     string
+	apiKey    string
+	apiSecret string
+	baseURL   string
+
+	httpClient *http.Client
+	// now 返回签名使用的时间，便于测试
+	now func() time.Time
+}
+
+// Option 是星火客户端的选项
+type Option func(*Client)
+
+// WithHTTPClient 设置建立 WebSocket 连接使用的HTTP客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithBaseURL 设置 WebSocket 地址，如 wss://spark-api.xf-yun.com
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// New 返回一个新的星火客户端
+func New(appID, apiKey, apiSecret string, opts ...Option) *Client {
+	c := &Client{
+		appID:     appID,
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		baseURL:   DefaultBaseURL,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// signURL 返回带鉴权参数的接口地址
+// 签名原文为 host、date 与请求行，使用 APISecret 做 HMAC-SHA256 后与 APIKey 组成 authorization 参数
+func (c *Client) signURL(path string) (string, error) {
+	u, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return "", fmt.Errorf("解析接口地址失败: %w", err)
+	}
+
+	date := c.now().UTC().Format(http.TimeFormat)
+	origin := fmt.Sprintf("host: %s\ndate: %s\nGET %s HTTP/1.1", u.Host, date, u.Path)
+	mac := hmac.New(sha256.New, []byte(c.apiSecret))
+	mac.Write([]byte(origin))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	authorization := fmt.Sprintf(`api_key="%s", algorithm="hmac-sha256", headers="host date request-line", signature="%s"`, c.apiKey, signature)
+	query := url.Values{}
+	query.Set("authorization", base64.StdEncoding.EncodeToString([]byte(authorization)))
+	query.Set("date", date)
+	query.Set("host", u.Host)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// ChatRequest 是创建聊天请求的结构体，发送前转换为星火的 header/parameter/payload 格式
+type ChatRequest struct {
+	// Model 是请求的 domain，同时用于选择接口路径
+	Model       string
+	Messages    []ChatMessage
+	Temperature float64
+	TopK        int
+	MaxTokens   int
+	Functions   []Function
+	// UserID 是终端用户标识，对应请求头部的 uid
+	UserID string
+
+	StreamingFunc func(ctx context.Context, chunk []byte) error
+}
+
+// ChatMessage 是聊天消息，角色为 system、user、assistant 或 tool
+type ChatMessage struct {
+	Role         string        `json:"role"`
+	Content      string        `json:"content"`
+	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+}
+
+// FunctionCall 是模型发起的函数调用
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Function 是函数定义
+type Function struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parameters  any    `json:"parameters"`
+}
+
+// Usage 是token用量
+type Usage struct {
+	QuestionTokens   int `json:"question_tokens"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatResponse 是拼接后的完整聊天响应
+type ChatResponse struct {
+	// SID 是本次会话的ID
+	SID          string
+	Content      string
+	FunctionCall *FunctionCall
+	Usage        Usage
+}
+
+// wireRequest 是星火协议的请求帧
+type wireRequest struct {
+	Header struct {
+		AppID string `json:"app_id"`
+		UID   string `json:"uid,omitempty"`
+	} `json:"header"`
+	Parameter struct {
+		Chat struct {
+			Domain      string  `json:"domain"`
+			Temperature float64 `json:"temperature,omitempty"`
+			TopK        int     `json:"top_k,omitempty"`
+			MaxTokens   int     `json:"max_tokens,omitempty"`
+		} `json:"chat"`
+	} `json:"parameter"`
+	Payload struct {
+		Message struct {
+			Text []ChatMessage `json:"text"`
+		} `json:"message"`
+		Functions *struct {
+			Text []Function `json:"text"`
+		} `json:"functions,omitempty"`
+	} `json:"payload"`
+}
+
+// wireResponse 是星火协议的响应帧，header.status 为 2 表示最后一帧
+type wireResponse struct {
+	Header struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		SID     string `json:"sid"`
+		Status  int    `json:"status"`
+	} `json:"header"`
+	Payload struct {
+		Choices struct {
+			Status int `json:"status"`
+			Seq    int `json:"seq"`
+			Text   []struct {
+				Content      string        `json:"content"`
+				Role         string        `json:"role"`
+				Index        int           `json:"index"`
+				FunctionCall *FunctionCall `json:"function_call,omitempty"`
+			} `json:"text"`
+		} `json:"choices"`
+		Usage struct {
+			Text Usage `json:"text"`
+		} `json:"usage"`
+	} `json:"payload"`
+}
+
+// newWireRequest 将聊天请求转换为星火协议的请求帧
+func (c *Client) newWireRequest(request *ChatRequest) *wireRequest {
+	w := &wireRequest{}
+	w.Header.AppID = c.appID
+	w.Header.UID = request.UserID
+	w.Parameter.Chat.Domain = request.Model
+	w.Parameter.Chat.Temperature = request.Temperature
+	w.Parameter.Chat.TopK = request.TopK
+	w.Parameter.Chat.MaxTokens = request.MaxTokens
+	w.Payload.Message.Text = request.Messages
+	if len(request.Functions) > 0 {
+		w.Payload.Functions = &struct {
+			Text []Function `json:"text"`
+		}{Text: request.Functions}
+	}
+	return w
+}
@@ -0,0 +1,407 @@
+// Package spark 提供了讯飞星火认知大模型的Go语言客户端实现，支持 WebSocket 协议与兼容OpenAI的 HTTP 接口
+package spark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/sjzsdu/langchaingo-cn/llms/spark/internal/sparkclient"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+const (
+	// 环境变量名
+	AppIDEnvVarName       = "SPARK_APP_ID"       //nolint:gosec
+	TokenEnvVarName       = "SPARK_API_KEY"      //nolint:gosec
+	APISecretEnvVarName   = "SPARK_API_SECRET"   //nolint:gosec
+	APIPasswordEnvVarName = "SPARK_API_PASSWORD" //nolint:gosec
+	ModelEnvVarName       = "SPARK_MODEL"        //nolint:gosec
+
+	// WebSocketBaseURL WebSocket 协议的接口地址
+	WebSocketBaseURL = sparkclient.DefaultBaseURL
+	// OpenAICompatibleBaseURL HTTP 接口（兼容OpenAI）的基础URL
+	OpenAICompatibleBaseURL = "https://spark-api-open.xf-yun.com/v1"
+	// DefaultModel 默认模型
+	DefaultModel = ModelMax
+)
+
+// 模型名称即请求中的 domain，两种协议通用
+const (
+	// ModelLite 是星火 Lite 轻量级模型
+	ModelLite = "lite"
+
+	// ModelPro 是星火 Pro 模型
+	ModelPro = "generalv3"
+
+	// ModelPro128K 是星火 Pro 128K 长上下文模型
+	ModelPro128K = "pro-128k"
+
+	// ModelMax 是星火 Max 模型
+	ModelMax = "generalv3.5"
+
+	// ModelMax32K 是星火 Max 32K 模型
+	ModelMax32K = "max-32k"
+
+	// Model4Ultra 是星火 4.0 Ultra 模型
+	Model4Ultra = "4.0Ultra"
+)
+
+// Protocol 是调用星火的协议
+type Protocol string
+
+const (
+	// ProtocolWebSocket 使用 APPID、APIKey、APISecret 签名的 WebSocket 协议
+	ProtocolWebSocket Protocol = "websocket"
+	// ProtocolHTTP 使用 APIPassword 鉴权的兼容OpenAI的 HTTP 接口
+	ProtocolHTTP Protocol = "http"
+)
+
+var (
+	// ErrEmptyResponse 表示API返回了空响应
+	ErrEmptyResponse = errors.New("空响应")
+
+	// ErrMissingCredentials 表示缺少当前协议所需的鉴权信息
+	ErrMissingCredentials = errors.New("缺少鉴权信息，WebSocket 协议需要设置 SPARK_APP_ID、SPARK_API_KEY、SPARK_API_SECRET，HTTP 接口需要设置 SPARK_API_PASSWORD")
+)
+
+// LLM 是讯飞星火大语言模型的客户端
+type LLM struct {
+	protocol Protocol
+
+	// httpLLM 是 HTTP 接口的客户端，仅 HTTP 协议使用
+	httpLLM *openai.LLM
+	// client 是 WebSocket 协议的客户端
+	client *sparkclient.Client
+
+	callbacksHandler callbacks.Handler
+
+	model       string
+	temperature float64
+	topK        int
+	maxTokens   int
+}
+
+var _ llms.Model = (*LLM)(nil)
+
+// New 创建一个新的讯飞星火 LLM客户端
+// 未通过 WithProtocol 指定协议时，设置了 APIPassword 则使用 HTTP 接口，否则使用 WebSocket 协议
+func New(opts ...Option) (*LLM, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.protocol == "" {
+		options.protocol = ProtocolWebSocket
+		if options.apiPassword != "" {
+			options.protocol = ProtocolHTTP
+		}
+	}
+	if options.model == "" {
+		options.model = DefaultModel
+	}
+
+	llm := &LLM{
+		protocol:         options.protocol,
+		callbacksHandler: options.callbacksHandler,
+		model:            options.model,
+		temperature:      options.temperature,
+		topK:             options.topK,
+		maxTokens:        options.maxTokens,
+	}
+
+	switch options.protocol {
+	case ProtocolHTTP:
+		if options.apiPassword == "" {
+			return nil, ErrMissingCredentials
+		}
+		baseURL := options.baseURL
+		if baseURL == "" {
+			baseURL = OpenAICompatibleBaseURL
+		}
+		var httpClient retry.Doer = http.DefaultClient
+		if options.httpClient != nil {
+			httpClient = options.httpClient
+		}
+		openaiOpts := []openai.Option{
+			openai.WithToken(options.apiPassword),
+			openai.WithModel(options.model),
+			openai.WithBaseURL(baseURL),
+			openai.WithHTTPClient(retry.Wrap(ratelimit.Wrap(httpClient, options.rateLimiter), options.retryPolicy)),
+		}
+		if options.callbacksHandler != nil {
+			openaiOpts = append(openaiOpts, openai.WithCallback(options.callbacksHandler))
+		}
+		httpLLM, err := openai.New(openaiOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("创建OpenAI客户端失败: %w", err)
+		}
+		llm.httpLLM = httpLLM
+	case ProtocolWebSocket:
+		if options.appID == "" || options.apiKey == "" || options.apiSecret == "" {
+			return nil, ErrMissingCredentials
+		}
+		var clientOpts []sparkclient.Option
+		if options.baseURL != "" {
+			clientOpts = append(clientOpts, sparkclient.WithBaseURL(options.baseURL))
+		}
+		// 握手请求与 HTTP 接口一样经过限流和重试，每次建立连接（包括重试）消耗一个令牌
+		clientOpts = append(clientOpts, sparkclient.WithHTTPClient(handshakeClient(options.httpClient, options.rateLimiter, options.retryPolicy)))
+		llm.client = sparkclient.New(options.appID, options.apiKey, options.apiSecret, clientOpts...)
+	default:
+		return nil, fmt.Errorf("不支持的协议: %s", options.protocol)
+	}
+
+	return llm, nil
+}
+
+// GetModels 返回讯飞星火支持的模型列表
+func (o *LLM) GetModels() []string {
+	return []string{
+		ModelLite,
+		ModelPro,
+		ModelPro128K,
+		ModelMax,
+		ModelMax32K,
+		Model4Ultra,
+	}
+}
+
+// Call 调用讯飞星火生成文本
+func (o *LLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, o, prompt, options...)
+}
+
+// GenerateContent 生成内容，支持流式输出和函数调用
+func (o *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if o.protocol == ProtocolHTTP {
+		// 客户端默认的采样参数放在最前面，调用时传入的选项优先
+		defaults := make([]llms.CallOption, 0, 2+len(options))
+		if o.temperature != 0 {
+			defaults = append(defaults, llms.WithTemperature(o.temperature))
+		}
+		if o.maxTokens != 0 {
+			defaults = append(defaults, llms.WithMaxTokens(o.maxTokens))
+		}
+		resp, err := o.httpLLM.GenerateContent(ctx, messages, append(defaults, options...)...)
+		if err != nil {
+			return nil, err
+		}
+		// openai 客户端只写入 PromptTokens 等键，补充统一的用量键
+		usage.Normalize(resp)
+		return resp, nil
+	}
+	return o.generateWebSocket(ctx, messages, options...)
+}
+
+// generateWebSocket 通过 WebSocket 协议生成内容
+func (o *LLM) generateWebSocket(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if o.callbacksHandler != nil {
+		o.callbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+
+	chatMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, fmt.Errorf("转换消息格式失败: %w", err)
+	}
+
+	request := &sparkclient.ChatRequest{
+		Model:         o.model,
+		Messages:      chatMessages,
+		Temperature:   o.temperature,
+		TopK:          o.topK,
+		MaxTokens:     o.maxTokens,
+		StreamingFunc: opts.StreamingFunc,
+	}
+	applyCallOptions(request, opts)
+
+	response, err := o.client.CreateChat(ctx, request)
+	if err != nil {
+		if o.callbacksHandler != nil {
+			o.callbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+	if response.Content == "" && response.FunctionCall == nil {
+		return nil, ErrEmptyResponse
+	}
+
+	choice := &llms.ContentChoice{Content: response.Content}
+	if u := response.Usage; u.PromptTokens+u.CompletionTokens+u.TotalTokens > 0 {
+		choice.GenerationInfo = usage.Set(nil, u.PromptTokens, u.CompletionTokens, u.TotalTokens)
+	}
+	if call := response.FunctionCall; call != nil {
+		// WebSocket 协议的函数调用没有ID，使用会话ID
+		toolCall := llms.ToolCall{
+			ID:   response.SID,
+			Type: "function",
+			FunctionCall: &llms.FunctionCall{
+				Name:      call.Name,
+				Arguments: call.Arguments,
+			},
+		}
+		choice.ToolCalls = []llms.ToolCall{toolCall}
+		choice.FuncCall = toolCall.FunctionCall
+		choice.StopReason = "function_call"
+	}
+
+	contentResponse := &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}
+	if o.callbacksHandler != nil {
+		o.callbacksHandler.HandleLLMGenerateContentEnd(ctx, contentResponse)
+	}
+	return contentResponse, nil
+}
+
+// handshakeClient 返回 WebSocket 握手使用的HTTP客户端，握手请求经过限流，
+// 遇到限流（429）、服务端错误（5xx）或超时时按重试策略重新握手；连接建立后不再重试
+func handshakeClient(client *http.Client, limiter *ratelimit.Limiter, policy retry.Policy) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = doerTransport{retry.Wrap(ratelimit.Wrap(roundTripDoer{transport}, limiter), policy)}
+	return &wrapped
+}
+
+// roundTripDoer 将 http.RoundTripper 适配为 retry.Doer
+type roundTripDoer struct {
+	transport http.RoundTripper
+}
+
+func (d roundTripDoer) Do(req *http.Request) (*http.Response, error) {
+	return d.transport.RoundTrip(req)
+}
+
+// doerTransport 将 retry.Doer 适配为 http.RoundTripper
+type doerTransport struct {
+	doer retry.Doer
+}
+
+func (t doerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.doer.Do(req)
+}
+
+// applyCallOptions 用调用时传入的选项覆盖客户端默认的模型与采样参数，并设置函数与用户标识
+func applyCallOptions(request *sparkclient.ChatRequest, options llms.CallOptions) {
+	if options.Model != "" {
+		request.Model = options.Model
+	}
+	if options.Temperature != 0 {
+		request.Temperature = options.Temperature
+	}
+	if options.TopK != 0 {
+		request.TopK = options.TopK
+	}
+	if options.MaxTokens != 0 {
+		request.MaxTokens = options.MaxTokens
+	}
+
+	// 请求标签中的 user 标签转发为请求头部的 uid
+	if tags, ok := options.Metadata["request_tags"].(map[string]string); ok && tags["user"] != "" {
+		request.UserID = tags["user"]
+	}
+
+	// WebSocket 协议不支持指定调用的函数，tool_choice 为 none 时不发送函数定义
+	if choice, ok := options.ToolChoice.(string); ok && choice == "none" {
+		return
+	}
+	for _, tool := range options.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		request.Functions = append(request.Functions, sparkclient.Function{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+		})
+	}
+}
+
+// convertMessages 将LangChain消息转换为星火消息，工具调用结果使用 tool 角色
+func convertMessages(messages []llms.MessageContent) ([]sparkclient.ChatMessage, error) {
+	result := make([]sparkclient.ChatMessage, 0, len(messages))
+
+	for _, message := range messages {
+		if message.Role == llms.ChatMessageTypeTool {
+			for _, part := range message.Parts {
+				response, ok := part.(llms.ToolCallResponse)
+				if !ok {
+					return nil, fmt.Errorf("工具消息只能包含工具调用结果，实际为 %T", part)
+				}
+				result = append(result, sparkclient.ChatMessage{Role: "tool", Content: response.Content})
+			}
+			continue
+		}
+
+		var role string
+		switch message.Role {
+		case llms.ChatMessageTypeSystem:
+			role = "system"
+		case llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric:
+			role = "user"
+		case llms.ChatMessageTypeAI:
+			role = "assistant"
+		default:
+			return nil, fmt.Errorf("不支持的消息类型: %s", message.Role)
+		}
+
+		// 助手发起的函数调用，星火每轮只返回一个函数调用
+		if call, ok := findToolCall(message); ok {
+			result = append(result, sparkclient.ChatMessage{
+				Role: role,
+				FunctionCall: &sparkclient.FunctionCall{
+					Name:      call.FunctionCall.Name,
+					Arguments: call.FunctionCall.Arguments,
+				},
+			})
+			continue
+		}
+
+		text, err := messageText(message)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sparkclient.ChatMessage{Role: role, Content: text})
+	}
+
+	return result, nil
+}
+
+// findToolCall 返回消息中的第一个工具调用
+func findToolCall(message llms.MessageContent) (llms.ToolCall, bool) {
+	for _, part := range message.Parts {
+		if call, ok := part.(llms.ToolCall); ok && call.FunctionCall != nil {
+			return call, true
+		}
+	}
+	return llms.ToolCall{}, false
+}
+
+// messageText 拼接消息中的文本，WebSocket 协议只支持文本输入
+func messageText(message llms.MessageContent) (string, error) {
+	var text string
+	for _, part := range message.Parts {
+		p, ok := part.(llms.TextContent)
+		if !ok {
+			return "", fmt.Errorf("不支持的内容类型: %T", part)
+		}
+		text += p.Text
+	}
+	return text, nil
+}
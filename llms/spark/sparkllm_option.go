@@ -0,0 +1,178 @@
+package spark
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/tmc/langchaingo/callbacks"
+)
+
+// options 包含创建讯飞星火 LLM客户端的选项
+type options struct {
+	// protocol 是调用协议，未设置时根据鉴权信息选择
+	protocol Protocol
+
+	// appID 是讯飞开放平台应用的 APPID，WebSocket 协议使用
+	appID string
+
+	// apiKey 是应用的 APIKey，WebSocket 协议使用
+	apiKey string
+
+	// apiSecret 是应用的 APISecret，WebSocket 协议使用
+	apiSecret string
+
+	// apiPassword 是 HTTP 接口的 APIPassword
+	apiPassword string
+
+	// model 是要使用的模型名称
+	model string
+
+	// baseURL 是当前协议的接口地址
+	baseURL string
+
+	// httpClient 是自定义的HTTP客户端
+	httpClient *http.Client
+
+	// retryPolicy 是请求失败时的重试策略
+	retryPolicy retry.Policy
+
+	// rateLimiter 是可在多个客户端之间共享的限流器
+	rateLimiter *ratelimit.Limiter
+
+	// callbacksHandler 是回调处理器
+	callbacksHandler callbacks.Handler
+
+	// temperature 控制随机性，取值范围 (0, 2]
+	temperature float64
+
+	// topK 从 k 个候选中随机选择
+	topK int
+
+	// maxTokens 是生成的最大令牌数
+	maxTokens int
+}
+
+// Option 是配置讯飞星火 LLM客户端的函数类型
+type Option func(*options)
+
+// defaultOptions 返回默认选项
+func defaultOptions() *options {
+	return &options{
+		appID:       os.Getenv(AppIDEnvVarName),
+		apiKey:      os.Getenv(TokenEnvVarName),
+		apiSecret:   os.Getenv(APISecretEnvVarName),
+		apiPassword: os.Getenv(APIPasswordEnvVarName),
+		model:       os.Getenv(ModelEnvVarName),
+	}
+}
+
+// WithProtocol 设置调用协议，默认在设置了 APIPassword 时使用 HTTP 接口，否则使用 WebSocket 协议
+func WithProtocol(protocol Protocol) Option {
+	return func(o *options) {
+		o.protocol = protocol
+	}
+}
+
+// WithAppID 设置应用的 APPID
+func WithAppID(appID string) Option {
+	return func(o *options) {
+		o.appID = appID
+	}
+}
+
+// WithAPIKey 设置应用的 APIKey
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithAPISecret 设置应用的 APISecret
+func WithAPISecret(apiSecret string) Option {
+	return func(o *options) {
+		o.apiSecret = apiSecret
+	}
+}
+
+// WithAPIPassword 设置 HTTP 接口的 APIPassword
+func WithAPIPassword(apiPassword string) Option {
+	return func(o *options) {
+		o.apiPassword = apiPassword
+	}
+}
+
+// WithModel 设置模型名称
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+// WithBaseURL 设置当前协议的接口地址，WebSocket 协议如 wss://spark-api.xf-yun.com，HTTP 接口如 https://spark-api-open.xf-yun.com/v1
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient 设置自定义的HTTP客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待；
+// WebSocket 协议只重试握手，连接建立后出错不重试
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（WebSocket 协议为每次建立连接）前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
+// WithCallbacksHandler 设置回调处理器
+func WithCallbacksHandler(handler callbacks.Handler) Option {
+	return func(o *options) {
+		o.callbacksHandler = handler
+	}
+}
+
+// WithTemperature 设置温度参数，取值范围 (0, 2]
+func WithTemperature(temperature float64) Option {
+	return func(o *options) {
+		o.temperature = temperature
+	}
+}
+
+// WithTopK 设置topK参数，取值范围 [1, 6]
+func WithTopK(topK int) Option {
+	return func(o *options) {
+		o.topK = topK
+	}
+}
+
+// WithMaxTokens 设置最大令牌数
+func WithMaxTokens(maxTokens int) Option {
+	return func(o *options) {
+		o.maxTokens = maxTokens
+	}
+}
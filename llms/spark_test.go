@@ -0,0 +1,236 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/spark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// sparkServer 模拟星火 WebSocket 接口，frames 根据请求帧返回响应帧
+type sparkServer struct {
+	*httptest.Server
+	paths    []string
+	requests []map[string]any
+	// rejects 是在接受连接之前以 503 拒绝的握手次数，handshakes 记录收到的握手请求数
+	rejects    int
+	handshakes int
+}
+
+func newSparkServer(t *testing.T, frames func(request map[string]any) []string) *sparkServer {
+	s := &sparkServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		authorization, err := base64.StdEncoding.DecodeString(query.Get("authorization"))
+		require.NoError(t, err)
+		assert.Contains(t, string(authorization), `api_key="test-key", algorithm="hmac-sha256", headers="host date request-line"`)
+		assert.NotEmpty(t, query.Get("date"))
+		assert.Equal(t, r.Host, query.Get("host"))
+
+		s.handshakes++
+		if s.rejects > 0 {
+			s.rejects--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		require.NoError(t, err)
+		defer conn.CloseNow()
+
+		_, data, err := conn.Read(r.Context())
+		require.NoError(t, err)
+		var request map[string]any
+		require.NoError(t, json.Unmarshal(data, &request))
+		s.paths = append(s.paths, r.URL.Path)
+		s.requests = append(s.requests, request)
+
+		for _, frame := range frames(request) {
+			require.NoError(t, conn.Write(r.Context(), websocket.MessageText, []byte(frame)))
+		}
+		conn.Close(websocket.StatusNormalClosure, "")
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *sparkServer) newLLM(t *testing.T, model string) llms.Model {
+	llm, err := llmscn.CreateLLM(llmscn.SparkLLM, map[string]interface{}{
+		"app_id":     "test-app",
+		"api_key":    "test-key",
+		"api_secret": "test-secret",
+		"base_url":   "ws" + strings.TrimPrefix(s.URL, "http"),
+		"model":      model,
+		"top_k":      4,
+	})
+	require.NoError(t, err)
+	return llm
+}
+
+func TestSparkLLM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("WebSocket协议流式输出", func(t *testing.T) {
+		server := newSparkServer(t, func(request map[string]any) []string {
+			return []string{
+				`{"header":{"code":0,"message":"Success","sid":"cht-1","status":0},"payload":{"choices":{"status":0,"seq":0,"text":[{"content":"你","role":"assistant","index":0}]}}}`,
+				`{"header":{"code":0,"message":"Success","sid":"cht-1","status":2},"payload":{"choices":{"status":2,"seq":1,"text":[{"content":"好","role":"assistant","index":0}]},"usage":{"text":{"question_tokens":2,"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}}}`,
+			}
+		})
+
+		var chunks []string
+		resp, err := server.newLLM(t, spark.Model4Ultra).GenerateContent(ctx,
+			[]llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, "你是助手"),
+				llms.TextParts(llms.ChatMessageTypeHuman, "你好"),
+			},
+			llmscn.WithRequestTags(map[string]string{"user": "tenant-a"}),
+			llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+				chunks = append(chunks, string(chunk))
+				return nil
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"你", "好"}, chunks)
+		assert.Equal(t, "你好", resp.Choices[0].Content)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 3, 2, 5)
+
+		assert.Equal(t, "/v4.0/chat", server.paths[0])
+		request := server.requests[0]
+		assert.Equal(t, map[string]any{"app_id": "test-app", "uid": "tenant-a"}, request["header"])
+		assert.Equal(t, map[string]any{"chat": map[string]any{"domain": "4.0Ultra", "top_k": float64(4)}}, request["parameter"])
+		assert.Equal(t, map[string]any{"message": map[string]any{"text": []any{
+			map[string]any{"role": "system", "content": "你是助手"},
+			map[string]any{"role": "user", "content": "你好"},
+		}}}, request["payload"])
+	})
+
+	t.Run("业务错误", func(t *testing.T) {
+		server := newSparkServer(t, func(request map[string]any) []string {
+			return []string{`{"header":{"code":10013,"message":"input content audit failed","sid":"cht-2","status":2}}`}
+		})
+
+		_, err := server.newLLM(t, "").Call(ctx, "你好")
+		assert.ErrorContains(t, err, "API错误 (10013)")
+		assert.Equal(t, "/v3.5/chat", server.paths[0])
+	})
+
+	t.Run("握手失败时重试", func(t *testing.T) {
+		server := newSparkServer(t, func(request map[string]any) []string {
+			return []string{`{"header":{"code":0,"sid":"cht-5","status":2},"payload":{"choices":{"status":2,"text":[{"content":"你好","role":"assistant","index":0}]}}}`}
+		})
+		server.rejects = 1
+
+		newLLM := func(maxRetries int) *spark.LLM {
+			llm, err := spark.New(
+				spark.WithAppID("test-app"),
+				spark.WithAPIKey("test-key"),
+				spark.WithAPISecret("test-secret"),
+				spark.WithBaseURL("ws"+strings.TrimPrefix(server.URL, "http")),
+				spark.WithMaxRetries(maxRetries),
+				spark.WithRetryBackoff(time.Millisecond),
+			)
+			require.NoError(t, err)
+			return llm
+		}
+
+		resp, err := newLLM(2).Call(ctx, "你好")
+		require.NoError(t, err)
+		assert.Equal(t, "你好", resp)
+		assert.Equal(t, 2, server.handshakes)
+
+		// 不设置重试时握手失败直接返回错误
+		server.rejects, server.handshakes = 1, 0
+		_, err = newLLM(0).Call(ctx, "你好")
+		assert.ErrorContains(t, err, "连接星火接口失败 (503)")
+		assert.Equal(t, 1, server.handshakes)
+	})
+
+	t.Run("函数调用", func(t *testing.T) {
+		server := newSparkServer(t, func(request map[string]any) []string {
+			text := request["payload"].(map[string]any)["message"].(map[string]any)["text"].([]any)
+			if len(text) == 1 {
+				return []string{`{"header":{"code":0,"sid":"cht-3","status":2},"payload":{"choices":{"status":2,"text":[{"content":"","role":"assistant","index":0,"function_call":{"name":"get_weather","arguments":"{\"city\":\"北京\"}"}}]}}}`}
+			}
+			return []string{`{"header":{"code":0,"sid":"cht-4","status":2},"payload":{"choices":{"status":2,"text":[{"content":"北京晴","role":"assistant","index":0}]}}}`}
+		})
+		llm := server.newLLM(t, "")
+
+		tools := []llms.Tool{{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:        "get_weather",
+				Description: "查询天气",
+				Parameters:  map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+			},
+		}}
+		messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "北京天气怎么样")}
+		resp, err := llm.GenerateContent(ctx, messages, llms.WithTools(tools))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices[0].ToolCalls, 1)
+		call := resp.Choices[0].ToolCalls[0]
+		assert.Equal(t, "cht-3", call.ID)
+		assert.Equal(t, "get_weather", call.FunctionCall.Name)
+		assert.JSONEq(t, `{"city":"北京"}`, call.FunctionCall.Arguments)
+
+		functions := server.requests[0]["payload"].(map[string]any)["functions"].(map[string]any)["text"].([]any)
+		assert.Equal(t, "get_weather", functions[0].(map[string]any)["name"])
+
+		messages = append(messages,
+			llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+			llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: call.ID, Name: call.FunctionCall.Name, Content: "晴"}}},
+		)
+		resp, err = llm.GenerateContent(ctx, messages, llms.WithTools(tools))
+		require.NoError(t, err)
+		assert.Equal(t, "北京晴", resp.Choices[0].Content)
+
+		history := server.requests[1]["payload"].(map[string]any)["message"].(map[string]any)["text"].([]any)
+		require.Len(t, history, 3)
+		assert.Equal(t, map[string]any{"role": "assistant", "content": "", "function_call": map[string]any{"name": "get_weather", "arguments": `{"city":"北京"}`}}, history[1])
+		assert.Equal(t, map[string]any{"role": "tool", "content": "晴"}, history[2])
+	})
+
+	t.Run("HTTP接口", func(t *testing.T) {
+		var body map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/chat/completions", r.URL.Path)
+			assert.Equal(t, "Bearer test-password", r.Header.Get("Authorization"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"cha-1","object":"chat.completion","created":1,"model":"lite","choices":[{"index":0,"message":{"role":"assistant","content":"你好"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`))
+		}))
+		defer server.Close()
+
+		llm, err := llmscn.CreateLLM(llmscn.SparkLLM, map[string]interface{}{
+			"api_password": "test-password",
+			"base_url":     server.URL,
+			"model":        spark.ModelLite,
+		})
+		require.NoError(t, err)
+		resp, err := llm.GenerateContent(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")})
+		require.NoError(t, err)
+		assert.Equal(t, "你好", resp.Choices[0].Content)
+		assert.Equal(t, "lite", body["model"])
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 1, 2, 3)
+	})
+
+	t.Run("缺少鉴权信息", func(t *testing.T) {
+		for _, env := range []string{"SPARK_APP_ID", "SPARK_API_KEY", "SPARK_API_SECRET", "SPARK_API_PASSWORD"} {
+			t.Setenv(env, "")
+		}
+		_, err := llmscn.CreateLLM(llmscn.SparkLLM, map[string]interface{}{"api_key": "test-key"})
+		assert.ErrorIs(t, err, spark.ErrMissingCredentials)
+
+		_, err = llmscn.CreateLLM(llmscn.SparkLLM, map[string]interface{}{"protocol": "http", "api_key": "test-key"})
+		assert.ErrorIs(t, err, spark.ErrMissingCredentials)
+	})
+}
@@ -0,0 +1,180 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// StopReasonPattern 表示因命中停止条件而提前结束流式输出
+const StopReasonPattern = "stop_pattern"
+
+// errStopPattern 在流式回调中返回，用于中断服务商的流式输出
+var errStopPattern = errors.New("命中停止条件")
+
+// StopCondition 停止条件，检查目前累积的输出
+// 命中时返回应保留的输出长度（字节）以及 true
+type StopCondition func(text string) (end int, ok bool)
+
+// StopAtString 在输出中出现指定字符串（如 "</answer>"）时停止，保留该字符串
+func StopAtString(s string) StopCondition {
+	return func(text string) (int, bool) {
+		idx := strings.Index(text, s)
+		if idx < 0 {
+			return 0, false
+		}
+		return idx + len(s), true
+	}
+}
+
+// StopAtRegexp 在输出匹配正则表达式时停止，保留到首个匹配结束处
+func StopAtRegexp(re *regexp.Regexp) StopCondition {
+	return func(text string) (int, bool) {
+		loc := re.FindStringIndex(text)
+		if loc == nil {
+			return 0, false
+		}
+		return loc[1], true
+	}
+}
+
+// StopAtJSONObject 在第一个完整的JSON对象或数组结束时停止
+// 仅做括号配对（忽略字符串内的括号），不校验JSON语法
+func StopAtJSONObject() StopCondition {
+	return func(text string) (int, bool) {
+		depth := 0
+		started := false
+		inString := false
+		escaped := false
+		for i := 0; i < len(text); i++ {
+			c := text[i]
+			if inString {
+				switch {
+				case escaped:
+					escaped = false
+				case c == '\\':
+					escaped = true
+				case c == '"':
+					inString = false
+				}
+				continue
+			}
+			switch c {
+			case '"':
+				if started {
+					inString = true
+				}
+			case '{', '[':
+				depth++
+				started = true
+			case '}', ']':
+				if started {
+					depth--
+					if depth == 0 {
+						return i + 1, true
+					}
+				}
+			}
+		}
+		return 0, false
+	}
+}
+
+// StopOnPattern 流式停止装饰器
+// 包装任意模型，在流式输出命中任一停止条件时立即取消服务商的流式请求，
+// 返回截断到停止位置的内容，避免模型在给出所需结构化输出后继续生成浪费token
+type StopOnPattern struct {
+	model      llms.Model
+	conditions []StopCondition
+}
+
+var _ llms.Model = (*StopOnPattern)(nil)
+
+// NewStopOnPattern 创建流式停止装饰器
+func NewStopOnPattern(model llms.Model, conditions ...StopCondition) *StopOnPattern {
+	return &StopOnPattern{
+		model:      model,
+		conditions: conditions,
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+// 即使调用方未设置流式回调，也会以流式方式请求以便提前停止
+func (s *StopOnPattern) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	userStreamingFunc := opts.StreamingFunc
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		text      strings.Builder
+		forwarded int
+		stopAt    = -1
+	)
+
+	streamingFunc := func(ctx context.Context, chunk []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if stopAt >= 0 {
+			return errStopPattern
+		}
+		text.Write(chunk)
+		current := text.String()
+
+		end := len(current)
+		for _, condition := range s.conditions {
+			if idx, ok := condition(current); ok {
+				stopAt = max(idx, forwarded)
+				end = stopAt
+				break
+			}
+		}
+
+		if userStreamingFunc != nil && end > forwarded {
+			if err := userStreamingFunc(ctx, []byte(current[forwarded:end])); err != nil {
+				return err
+			}
+		}
+		forwarded = end
+
+		if stopAt >= 0 {
+			cancel()
+			return errStopPattern
+		}
+		return nil
+	}
+
+	resp, err := s.model.GenerateContent(ctx, messages, append(options, llms.WithStreamingFunc(streamingFunc))...)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stopAt < 0 {
+		return resp, err
+	}
+
+	content := text.String()[:stopAt]
+	if resp == nil || len(resp.Choices) == 0 {
+		return &llms.ContentResponse{
+			Choices: []*llms.ContentChoice{{Content: content, StopReason: StopReasonPattern}},
+		}, nil
+	}
+	resp.Choices[0].Content = content
+	resp.Choices[0].StopReason = StopReasonPattern
+	return resp, nil
+}
+
+// Call 实现 llms.Model 接口
+func (s *StopOnPattern) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, s, prompt, options...)
+}
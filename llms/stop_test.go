@@ -0,0 +1,68 @@
+package llms_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// chunkModel 按块流式输出，回调返回错误时停止，并记录实际发送的块数
+type chunkModel struct {
+	chunks []string
+	sent   int
+}
+
+func (c *chunkModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	full := ""
+	for _, chunk := range c.chunks {
+		c.sent++
+		full += chunk
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: full}}}, nil
+}
+
+func (c *chunkModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, c, prompt, options...)
+}
+
+func TestStopOnPattern(t *testing.T) {
+	ctx := context.Background()
+
+	model := &chunkModel{chunks: []string{"<answer>42</ans", "wer> and then", " some rambling"}}
+	var streamed string
+	resp, err := llmscn.NewStopOnPattern(model, llmscn.StopAtString("</answer>")).
+		GenerateContent(ctx, nil, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed += string(chunk)
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, "<answer>42</answer>", resp.Choices[0].Content)
+	assert.Equal(t, llmscn.StopReasonPattern, resp.Choices[0].StopReason)
+	assert.Equal(t, "<answer>42</answer>", streamed)
+	assert.Equal(t, 2, model.sent)
+
+	model = &chunkModel{chunks: []string{`{"a": "}", "b": [1`, `, 2]} trailing`, "more"}}
+	text, err := llmscn.NewStopOnPattern(model, llmscn.StopAtJSONObject()).Call(ctx, "json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a": "}", "b": [1, 2]}`, text)
+
+	model = &chunkModel{chunks: []string{"no ", "match"}}
+	text, err = llmscn.NewStopOnPattern(model, llmscn.StopAtRegexp(regexp.MustCompile(`END\d`))).Call(ctx, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "no match", text)
+}
@@ -0,0 +1,456 @@
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrStructuredOutput 表示多次重试后模型输出仍无法解析或不符合 JSON Schema
+var ErrStructuredOutput = errors.New("模型未返回符合要求的结构化输出")
+
+// StructuredMode 结构化输出的请求方式
+type StructuredMode string
+
+const (
+	// StructuredModeJSON 使用服务商的JSON模式（response_format 为 json_object），并在系统消息中给出 JSON Schema
+	StructuredModeJSON StructuredMode = "json"
+	// StructuredModeTool 将 JSON Schema 作为工具参数，强制模型调用该工具，从工具参数中读取结果
+	StructuredModeTool StructuredMode = "tool"
+)
+
+// structuredOptions 结构化输出配置
+type structuredOptions struct {
+	mode        StructuredMode
+	maxRetries  int
+	name        string
+	callOptions []llms.CallOption
+}
+
+// StructuredOption 结构化输出配置选项
+type StructuredOption func(*structuredOptions)
+
+// WithStructuredMode 设置请求方式，默认 StructuredModeJSON
+func WithStructuredMode(mode StructuredMode) StructuredOption {
+	return func(o *structuredOptions) {
+		o.mode = mode
+	}
+}
+
+// WithStructuredRetries 设置输出无法解析或校验失败时的最大重试次数，默认2次
+func WithStructuredRetries(n int) StructuredOption {
+	return func(o *structuredOptions) {
+		o.maxRetries = n
+	}
+}
+
+// WithSchemaName 设置 Schema 名称，工具模式下作为工具名，默认 output
+func WithSchemaName(name string) StructuredOption {
+	return func(o *structuredOptions) {
+		o.name = name
+	}
+}
+
+// WithStructuredCallOptions 设置每次请求附带的调用选项
+func WithStructuredCallOptions(options ...llms.CallOption) StructuredOption {
+	return func(o *structuredOptions) {
+		o.callOptions = append(o.callOptions, options...)
+	}
+}
+
+// GenerateStructured 让模型按 JSON Schema 输出并解析为 T
+// schema 可以是 map、JSON 字符串、[]byte、json.RawMessage 或可序列化为 JSON Schema 的结构体，
+// 为 nil 时根据 T 的字段和 json 标签生成。模型输出先经 ParseJSONOutput 容错解析，
+// 再按 Schema 校验（type、properties、required、additionalProperties、items、enum、anyOf 及长度和取值范围），
+// 不符合要求时把错误原因发回模型重试；调用模型本身出错时直接返回
+func GenerateStructured[T any](ctx context.Context, model llms.Model, messages []llms.MessageContent, schema any, options ...StructuredOption) (T, error) {
+	var result T
+	o := structuredOptions{mode: StructuredModeJSON, maxRetries: 2, name: "output"}
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	s, err := structuredSchema(schema, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return result, err
+	}
+	schemaJSON, err := json.Marshal(s)
+	if err != nil {
+		return result, fmt.Errorf("序列化 JSON Schema 失败: %w", err)
+	}
+
+	callOptions := o.callOptions[:len(o.callOptions):len(o.callOptions)]
+	var instruction string
+	switch o.mode {
+	case StructuredModeJSON:
+		instruction = "请只输出一个符合以下 JSON Schema 的JSON，不要使用Markdown代码块，也不要输出任何解释文字。\nJSON Schema:\n" + string(schemaJSON)
+		// 服务商的JSON模式只支持根节点为对象，其他情况仅依靠提示词约束
+		if s["type"] == "object" {
+			callOptions = append(callOptions, llms.WithJSONMode())
+		}
+	case StructuredModeTool:
+		if s["type"] != "object" {
+			return result, errors.New("工具模式要求 JSON Schema 的根节点为 object")
+		}
+		instruction = fmt.Sprintf("请调用 %s 工具返回结果，参数必须符合工具的 JSON Schema。", o.name)
+		callOptions = append(callOptions,
+			llms.WithTools([]llms.Tool{{
+				Type: "function",
+				Function: &llms.FunctionDefinition{
+					Name:        o.name,
+					Description: "按指定的 JSON Schema 返回结构化结果",
+					Parameters:  s,
+				},
+			}}),
+			llms.WithToolChoice(llms.ToolChoice{Type: "function", Function: &llms.FunctionReference{Name: o.name}}),
+		)
+	default:
+		return result, fmt.Errorf("不支持的结构化输出方式: %s", o.mode)
+	}
+
+	conversation := withLanguageInstruction(messages, instruction)
+	modelName := fmt.Sprintf("%T", model)
+
+	var lastErr error
+	for attempt := 0; attempt <= o.maxRetries; attempt++ {
+		resp, err := model.GenerateContent(ctx, conversation, callOptions...)
+		if err != nil {
+			return result, err
+		}
+		if len(resp.Choices) == 0 {
+			return result, errors.New("模型未返回任何结果")
+		}
+
+		output := structuredContent(resp.Choices[0], o.mode)
+		value, err := decodeStructured[T](modelName, output, s)
+		if err == nil {
+			return value, nil
+		}
+		lastErr = err
+
+		conversation = append(conversation[:len(conversation):len(conversation)],
+			llms.TextParts(llms.ChatMessageTypeAI, output),
+			llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf("上面的输出不符合要求：%v。请修正后重新输出完整的JSON，不要包含其他内容。", err)),
+		)
+	}
+	return result, fmt.Errorf("%w: 重试 %d 次后仍失败: %w", ErrStructuredOutput, o.maxRetries, lastErr)
+}
+
+// structuredContent 取出模型的结构化输出，工具模式下优先读取工具调用参数
+func structuredContent(choice *llms.ContentChoice, mode StructuredMode) string {
+	if mode == StructuredModeTool {
+		for _, call := range choice.ToolCalls {
+			if call.FunctionCall != nil {
+				return call.FunctionCall.Arguments
+			}
+		}
+	}
+	return choice.Content
+}
+
+// decodeStructured 容错解析模型输出，按 Schema 校验后解码为 T
+func decodeStructured[T any](model, output string, schema map[string]any) (T, error) {
+	var result T
+	var raw any
+	if err := ParseJSONOutput(model, output, &raw); err != nil {
+		return result, err
+	}
+	if err := validateJSONSchema(raw, schema, "$"); err != nil {
+		return result, err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("解码为 %T 失败: %w", result, err)
+	}
+	return result, nil
+}
+
+// structuredSchema 将 schema 统一为经过 JSON 往返的 map，数值均为 float64，便于校验时比较
+func structuredSchema(schema any, t reflect.Type) (map[string]any, error) {
+	var data []byte
+	var err error
+	switch v := schema.(type) {
+	case nil:
+		data, err = json.Marshal(typeSchema(t, map[reflect.Type]bool{}))
+	case json.RawMessage:
+		data = v
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("序列化 JSON Schema 失败: %w", err)
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("JSON Schema 必须是JSON对象: %w", err)
+	}
+	return result, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// typeSchema 根据 Go 类型生成 JSON Schema，字段名取自 json 标签，
+// 带 omitempty 的字段和指针字段为可选，指针字段还允许为 null，递归引用的类型不再展开
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]any{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]any{}
+		required := []string{}
+		addStructFields(t, properties, &required, visiting)
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		// interface{} 等任意类型
+		return map[string]any{}
+	}
+}
+
+// addStructFields 收集结构体的导出字段，未加 json 标签的嵌入结构体字段展开到外层
+func addStructFields(t reflect.Type, properties map[string]any, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, properties, required, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := typeSchema(field.Type, visiting)
+		if field.Type.Kind() == reflect.Pointer {
+			// 指针字段可以为 null，模型常对缺省的可选字段返回 null
+			if typ, ok := schema["type"].(string); ok {
+				schema["type"] = []string{typ, "null"}
+			}
+		}
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// validateJSONSchema 按 JSON Schema 的常用关键字校验 json.Unmarshal 得到的值，path 为出错位置
+func validateJSONSchema(value any, schema map[string]any, path string) error {
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		actual := jsonType(value)
+		matched := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s 应为 %s 类型，实际为 %s", path, strings.Join(types, "/"), actual)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		matched := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s 的值 %v 不在可选值 %v 中", path, value, enum)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var errs []error
+		for _, candidate := range anyOf {
+			sub, _ := candidate.(map[string]any)
+			err := validateJSONSchema(value, sub, path)
+			if err == nil {
+				errs = nil
+				break
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s 不符合 anyOf 中的任何一个 Schema: %w", path, errors.Join(errs...))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		return validateJSONObject(v, schema, path)
+	case []any:
+		if n, ok := schema["minItems"].(float64); ok && float64(len(v)) < n {
+			return fmt.Errorf("%s 至少需要 %v 个元素，实际为 %d 个", path, n, len(v))
+		}
+		if n, ok := schema["maxItems"].(float64); ok && float64(len(v)) > n {
+			return fmt.Errorf("%s 最多允许 %v 个元素，实际为 %d 个", path, n, len(v))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateJSONSchema(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n, ok := schema["minLength"].(float64); ok && float64(length) < n {
+			return fmt.Errorf("%s 长度至少为 %v，实际为 %d", path, n, length)
+		}
+		if n, ok := schema["maxLength"].(float64); ok && float64(length) > n {
+			return fmt.Errorf("%s 长度最多为 %v，实际为 %d", path, n, length)
+		}
+	case float64:
+		if n, ok := schema["minimum"].(float64); ok && v < n {
+			return fmt.Errorf("%s 的值 %v 小于最小值 %v", path, v, n)
+		}
+		if n, ok := schema["maximum"].(float64); ok && v > n {
+			return fmt.Errorf("%s 的值 %v 大于最大值 %v", path, v, n)
+		}
+	}
+	return nil
+}
+
+// validateJSONObject 校验对象的必填字段、已声明的属性和额外属性
+func validateJSONObject(v map[string]any, schema map[string]any, path string) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, ok := v[key]; !ok {
+				return fmt.Errorf("%s 缺少必填字段 %s", path, key)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	// 按字段名排序，错误信息保持稳定
+	sort.Strings(keys)
+	for _, key := range keys {
+		fieldPath := path + "." + key
+		if sub, ok := properties[key].(map[string]any); ok {
+			if err := validateJSONSchema(v[key], sub, fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s 不允许出现未声明的字段", fieldPath)
+			}
+		case map[string]any:
+			if err := validateJSONSchema(v[key], additional, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// schemaTypes 读取 type 关键字，支持字符串和字符串数组
+func schemaTypes(t any) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// jsonType 返回 json.Unmarshal 得到的值对应的 JSON Schema 类型
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,162 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// structuredModel 依次返回预设的回复，并记录每次请求的消息和调用选项
+type structuredModel struct {
+	replies  []string
+	toolCall bool
+	received [][]llms.MessageContent
+	options  []llms.CallOptions
+}
+
+func (m *structuredModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.received = append(m.received, messages)
+	m.options = append(m.options, opts)
+
+	reply := m.replies[len(m.received)-1]
+	choice := &llms.ContentChoice{Content: reply}
+	if m.toolCall {
+		choice = &llms.ContentChoice{ToolCalls: []llms.ToolCall{{
+			ID:           "call_1",
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: "output", Arguments: reply},
+		}}}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}, nil
+}
+
+func (m *structuredModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+type structuredCity struct {
+	Name       string   `json:"name"`
+	Province   string   `json:"province"`
+	Population int      `json:"population"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+func TestGenerateStructured(t *testing.T) {
+	ctx := context.Background()
+	messages := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "你是地理助手"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "介绍一下杭州"),
+	}
+
+	t.Run("JSON模式并根据类型生成Schema", func(t *testing.T) {
+		model := &structuredModel{replies: []string{"```json\n{\"name\": \"杭州\", \"province\": \"浙江\", \"population\": 12000000,}\n```"}}
+
+		city, err := llmscn.GenerateStructured[structuredCity](ctx, model, messages, nil)
+		require.NoError(t, err)
+		assert.Equal(t, structuredCity{Name: "杭州", Province: "浙江", Population: 12000000}, city)
+
+		require.Len(t, model.options, 1)
+		assert.True(t, model.options[0].JSONMode)
+		require.Len(t, model.received[0], 2)
+		system := model.received[0][0].Parts[0].(llms.TextContent).Text
+		assert.Contains(t, system, "你是地理助手")
+		assert.Contains(t, system, `"required":["name","province","population"]`)
+	})
+
+	t.Run("校验失败时带上错误原因重试", func(t *testing.T) {
+		model := &structuredModel{replies: []string{
+			`{"name": "杭州", "province": "浙江"}`,
+			`{"name": "杭州", "province": "浙江", "population": "很多"}`,
+			`{"name": "杭州", "province": "浙江", "population": 12000000}`,
+		}}
+
+		city, err := llmscn.GenerateStructured[structuredCity](ctx, model, messages, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 12000000, city.Population)
+
+		require.Len(t, model.received, 3)
+		assert.Len(t, model.received[2], 6)
+		feedback := model.received[1][3].Parts[0].(llms.TextContent).Text
+		assert.Contains(t, feedback, "缺少必填字段 population")
+		feedback = model.received[2][5].Parts[0].(llms.TextContent).Text
+		assert.Contains(t, feedback, "$.population 应为 integer 类型")
+	})
+
+	t.Run("超过重试次数返回错误", func(t *testing.T) {
+		schema := `{"type": "object", "properties": {"level": {"type": "string", "enum": ["低", "中", "高"]}}, "required": ["level"], "additionalProperties": false}`
+		model := &structuredModel{replies: []string{`{"level": "极高"}`, `{"level": "高", "reason": "人口多"}`}}
+
+		_, err := llmscn.GenerateStructured[map[string]string](ctx, model, messages, schema, llmscn.WithStructuredRetries(1))
+		assert.ErrorIs(t, err, llmscn.ErrStructuredOutput)
+		assert.ErrorContains(t, err, "$.reason 不允许出现未声明的字段")
+		assert.Len(t, model.received, 2)
+	})
+
+	t.Run("工具模式", func(t *testing.T) {
+		model := &structuredModel{
+			toolCall: true,
+			replies:  []string{`{"name": "杭州", "province": "浙江", "population": 12000000, "tags": ["西湖"]}`},
+		}
+
+		city, err := llmscn.GenerateStructured[structuredCity](ctx, model, messages, nil,
+			llmscn.WithStructuredMode(llmscn.StructuredModeTool),
+			llmscn.WithStructuredCallOptions(llms.WithTemperature(0)))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"西湖"}, city.Tags)
+
+		opts := model.options[0]
+		assert.False(t, opts.JSONMode)
+		require.Len(t, opts.Tools, 1)
+		assert.Equal(t, "output", opts.Tools[0].Function.Name)
+		assert.Equal(t, llms.ToolChoice{Type: "function", Function: &llms.FunctionReference{Name: "output"}}, opts.ToolChoice)
+		assert.Equal(t, 0.0, opts.Temperature)
+	})
+
+	t.Run("指针字段允许为null", func(t *testing.T) {
+		type person struct {
+			Name string `json:"name"`
+			Age  *int   `json:"age"`
+		}
+		model := &structuredModel{replies: []string{`{"name": "a", "age": null}`}}
+
+		result, err := llmscn.GenerateStructured[person](ctx, model, messages, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "a", result.Name)
+		assert.Nil(t, result.Age)
+		assert.Len(t, model.received, 1)
+		system := model.received[0][0].Parts[0].(llms.TextContent).Text
+		assert.Contains(t, system, `"age":{"type":["integer","null"]}`)
+		assert.Contains(t, system, `"required":["name"]`)
+	})
+
+	t.Run("DeepSeek使用原生JSON模式", func(t *testing.T) {
+		var request map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &request)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":"{\"name\":\"杭州\",\"province\":\"浙江\",\"population\":1}"},"finish_reason":"stop"}]}`))
+		}))
+		defer server.Close()
+
+		llm, err := deepseek.New(deepseek.WithAPIKey("test-key"), deepseek.WithBaseURL(server.URL))
+		require.NoError(t, err)
+		city, err := llmscn.GenerateStructured[structuredCity](ctx, llm, messages, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "杭州", city.Name)
+		assert.Equal(t, map[string]any{"type": "json_object"}, request["response_format"])
+	})
+}
@@ -0,0 +1,112 @@
+package llms
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// RequestTagsKey 是请求标签在 CallOptions.Metadata 中的键
+// 支持的提供商（qwen、zhipu、ernie、spark）会将其中的 RequestTagUser 标签转发为请求体的用户字段，
+// 其余标签不会发送给服务商
+const RequestTagsKey = "request_tags"
+
+// RequestTagUser 标签会被转发为服务商的用户标识字段（qwen 的 user、zhipu 和 ernie 的 user_id、星火 WebSocket 协议的 uid），
+// 用于在服务商控制台按功能或租户统计用量
+const RequestTagUser = "user"
+
+// requestTagsContextKey 是请求标签在 context 中的键
+type requestTagsContextKey struct{}
+
+// WithRequestTags 为单次请求设置标签（如功能名、租户ID），可多次调用，后设置的同名标签覆盖先前的值
+func WithRequestTags(tags map[string]string) llms.CallOption {
+	return func(o *llms.CallOptions) {
+		merged := RequestTags(*o)
+		if merged == nil {
+			merged = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			merged[k] = v
+		}
+
+		// 复制 Metadata，避免修改调用方共享的 map
+		current := CallMetadata(*o)
+		metadata := make(map[string]interface{}, len(current)+1)
+		for k, v := range current {
+			metadata[k] = v
+		}
+		metadata[RequestTagsKey] = merged
+		SetCallMetadata(o, metadata)
+	}
+}
+
+// RequestTags 返回调用选项中设置的请求标签副本，未设置时返回 nil
+func RequestTags(opts llms.CallOptions) map[string]string {
+	tags, ok := CallMetadata(opts)[RequestTagsKey].(map[string]string)
+	if !ok {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return copied
+}
+
+// ContextWithRequestTags 将请求标签写入 context，供回调处理器读取
+func ContextWithRequestTags(ctx context.Context, tags map[string]string) context.Context {
+	return context.WithValue(ctx, requestTagsContextKey{}, tags)
+}
+
+// RequestTagsFromContext 读取 context 中的请求标签，未设置时返回 nil
+// 在 callbacks.Handler 中调用即可按标签记录用量
+func RequestTagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(requestTagsContextKey{}).(map[string]string)
+	return tags
+}
+
+// TaggedModel 请求标签装饰器
+// 合并默认标签与单次请求通过 WithRequestTags 设置的标签，写入调用选项与 context，
+// 使模型的回调处理器可通过 RequestTagsFromContext 记录每次请求的标签
+type TaggedModel struct {
+	model    llms.Model
+	defaults map[string]string
+}
+
+var _ llms.Model = (*TaggedModel)(nil)
+
+// NewTaggedModel 创建请求标签装饰器，defaults 为每次请求的默认标签，可为 nil
+func NewTaggedModel(model llms.Model, defaults map[string]string) *TaggedModel {
+	return &TaggedModel{
+		model:    model,
+		defaults: defaults,
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (t *TaggedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	tags := make(map[string]string, len(t.defaults))
+	for k, v := range t.defaults {
+		tags[k] = v
+	}
+	for k, v := range RequestTags(opts) {
+		tags[k] = v
+	}
+	if len(tags) == 0 {
+		return t.model.GenerateContent(ctx, messages, options...)
+	}
+
+	ctx = ContextWithRequestTags(ctx, tags)
+	options = append(options[:len(options):len(options)], WithRequestTags(tags))
+	return t.model.GenerateContent(ctx, messages, options...)
+}
+
+// Call 实现 llms.Model 接口
+func (t *TaggedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, t, prompt, options...)
+}
@@ -0,0 +1,64 @@
+package llms_test
+
+import (
+	"context"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// tagModel 记录收到的调用选项标签与 context 标签
+type tagModel struct {
+	fakeModel
+	optionTags  map[string]string
+	contextTags map[string]string
+}
+
+func (m *tagModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.optionTags = llmscn.RequestTags(opts)
+	m.contextTags = llmscn.RequestTagsFromContext(ctx)
+	return m.fakeModel.GenerateContent(ctx, messages, options...)
+}
+
+func TestRequestTags(t *testing.T) {
+	t.Run("MergeWithoutClobberingMetadata", func(t *testing.T) {
+		shared := map[string]interface{}{"other": 1}
+		opts := llms.CallOptions{}
+		llms.WithMetadata(shared)(&opts)
+		llmscn.WithRequestTags(map[string]string{"feature": "search", "user": "a"})(&opts)
+		llmscn.WithRequestTags(map[string]string{"user": "b"})(&opts)
+
+		assert.Equal(t, map[string]string{"feature": "search", "user": "b"}, llmscn.RequestTags(opts))
+		assert.Equal(t, 1, opts.Metadata["other"])
+		assert.NotContains(t, shared, llmscn.RequestTagsKey)
+	})
+
+	t.Run("TaggedModel", func(t *testing.T) {
+		model := &tagModel{fakeModel: fakeModel{reply: "ok"}}
+		tagged := llmscn.NewTaggedModel(model, map[string]string{"tenant": "acme", "feature": "default"})
+
+		_, err := tagged.GenerateContent(context.Background(), []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+		}, llmscn.WithRequestTags(map[string]string{"feature": "summary"}))
+		require.NoError(t, err)
+
+		want := map[string]string{"tenant": "acme", "feature": "summary"}
+		assert.Equal(t, want, model.optionTags)
+		assert.Equal(t, want, model.contextTags)
+	})
+
+	t.Run("NoTags", func(t *testing.T) {
+		model := &tagModel{fakeModel: fakeModel{reply: "ok"}}
+		_, err := llmscn.NewTaggedModel(model, nil).Call(context.Background(), "hi")
+		require.NoError(t, err)
+		assert.Nil(t, model.optionTags)
+		assert.Nil(t, model.contextTags)
+	})
+}
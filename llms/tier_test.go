@@ -0,0 +1,90 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestSiliconFlowTierFallback(t *testing.T) {
+	ctx := context.Background()
+	models := siliconflow.TierModels(siliconflow.TierFast)
+	require.GreaterOrEqual(t, len(models), 2)
+
+	var (
+		sent     []string
+		statuses map[string]int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		sent = append(sent, body.Model)
+		w.Header().Set("Content-Type", "application/json")
+		if status, ok := statuses[body.Model]; ok {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":{"message":"request failed","type":"error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"s-1","object":"chat.completion","created":1,"model":"` + body.Model + `","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"你好"}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+	}))
+	defer server.Close()
+
+	llm, err := siliconflow.New(
+		siliconflow.WithAPIKey("test-key"),
+		siliconflow.WithBaseURL(server.URL),
+		siliconflow.WithTier(siliconflow.TierFast),
+	)
+	require.NoError(t, err)
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")}
+
+	t.Run("首选模型过载时切换到下一个模型", func(t *testing.T) {
+		sent = nil
+		statuses = map[string]int{models[0]: http.StatusServiceUnavailable}
+
+		resp, err := llm.GenerateContent(ctx, messages)
+		require.NoError(t, err)
+		assert.Equal(t, "你好", resp.Choices[0].Content)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 3, 1, 4)
+		assert.Equal(t, models[:2], sent)
+	})
+
+	t.Run("请求错误不切换模型", func(t *testing.T) {
+		sent = nil
+		statuses = map[string]int{models[0]: http.StatusBadRequest}
+
+		_, err := llm.GenerateContent(ctx, messages)
+		require.Error(t, err)
+		assert.Equal(t, models[:1], sent)
+	})
+
+	t.Run("调用时指定模型不切换", func(t *testing.T) {
+		sent = nil
+		statuses = map[string]int{models[0]: http.StatusServiceUnavailable}
+
+		_, err := llm.GenerateContent(ctx, messages, llms.WithModel(models[0]))
+		require.Error(t, err)
+		assert.Equal(t, models[:1], sent)
+	})
+
+	t.Run("所有模型均过载", func(t *testing.T) {
+		sent = nil
+		statuses = map[string]int{}
+		for _, model := range models {
+			statuses[model] = http.StatusTooManyRequests
+		}
+
+		_, err := llm.GenerateContent(ctx, messages)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "档位 fast 的所有模型均不可用")
+		assert.Equal(t, models, sent)
+	})
+}
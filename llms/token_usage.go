@@ -0,0 +1,17 @@
+package llms
+
+import "github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+
+// GenerationInfo 中统一的token用量键
+// 所有服务商（包括流式调用的最终响应）都以这些键记录用量，值为 int；
+// 为兼容已有代码，同时保留 openai 客户端使用的 PromptTokens、CompletionTokens、TotalTokens 键
+const (
+	PromptTokensKey     = usage.PromptTokensKey
+	CompletionTokensKey = usage.CompletionTokensKey
+	TotalTokensKey      = usage.TotalTokensKey
+)
+
+// TokenUsage 读取 GenerationInfo 中的token用量，同时兼容统一的键与 openai 客户端的键
+func TokenUsage(info map[string]any) (prompt, completion, total int) {
+	return usage.Get(info)
+}
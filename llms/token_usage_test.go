@@ -0,0 +1,101 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	"github.com/sjzsdu/langchaingo-cn/llms/kimi"
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// assertTokenUsage 检查 GenerationInfo 中统一的用量键
+func assertTokenUsage(t *testing.T, info map[string]any, prompt, completion, total int) {
+	t.Helper()
+	assert.Equal(t, prompt, info[llmscn.PromptTokensKey])
+	assert.Equal(t, completion, info[llmscn.CompletionTokensKey])
+	assert.Equal(t, total, info[llmscn.TotalTokensKey])
+}
+
+func TestTokenUsage(t *testing.T) {
+	t.Run("兼容两种键", func(t *testing.T) {
+		prompt, completion, total := llmscn.TokenUsage(map[string]any{"PromptTokens": 3, "CompletionTokens": float64(4), "TotalTokens": 7})
+		assert.Equal(t, []int{3, 4, 7}, []int{prompt, completion, total})
+
+		prompt, completion, total = llmscn.TokenUsage(map[string]any{"prompt_tokens": 5, "completion_tokens": 6, "total_tokens": 11, "PromptTokens": 1})
+		assert.Equal(t, []int{5, 6, 11}, []int{prompt, completion, total})
+	})
+
+	t.Run("DeepSeek流式调用请求并返回用量", func(t *testing.T) {
+		var body struct {
+			StreamOptions struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"你好\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":8,\"completion_tokens\":2,\"total_tokens\":10}}\n\n" +
+				"data: [DONE]\n\n"))
+		}))
+		defer server.Close()
+
+		llm, err := deepseek.New(deepseek.WithAPIKey("test-key"), deepseek.WithBaseURL(server.URL))
+		require.NoError(t, err)
+
+		resp, err := llm.GenerateContent(context.Background(),
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")},
+			llms.WithStreamingFunc(func(context.Context, []byte) error { return nil }))
+		require.NoError(t, err)
+		assert.True(t, body.StreamOptions.IncludeUsage)
+		assert.Equal(t, "你好", resp.Choices[0].Content)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 8, 2, 10)
+	})
+
+	t.Run("Kimi流式调用读取最后数据块中的用量", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"好\"}}]}\n\n" +
+				"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\",\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}]}\n\n" +
+				"data: [DONE]\n\n"))
+		}))
+		defer server.Close()
+
+		llm, err := kimi.New(kimi.WithToken("test-key"), kimi.WithBaseURL(server.URL))
+		require.NoError(t, err)
+
+		resp, err := llm.GenerateContent(context.Background(),
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")},
+			llms.WithStreamingFunc(func(context.Context, []byte) error { return nil }))
+		require.NoError(t, err)
+		assertTokenUsage(t, resp.Choices[0].GenerationInfo, 5, 1, 6)
+	})
+
+	t.Run("OpenAI兼容服务商补充统一的键", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"好"},"finish_reason":"stop"}],` +
+				`"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`))
+		}))
+		defer server.Close()
+
+		llm, err := qwen.New(qwen.WithAPIKey("test-key"), qwen.WithBaseURL(server.URL))
+		require.NoError(t, err)
+
+		resp, err := llm.GenerateContent(context.Background(),
+			[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")})
+		require.NoError(t, err)
+		info := resp.Choices[0].GenerationInfo
+		assertTokenUsage(t, info, 9, 3, 12)
+		assert.Equal(t, 9, info["PromptTokens"])
+	})
+}
@@ -0,0 +1,15 @@
+package llms
+
+import "github.com/sjzsdu/langchaingo-cn/llms/internal/toolcall"
+
+// ToolCallAssembler 将流式响应中的工具调用增量累积为完整的 llms.ToolCall
+// 支持乱序到达的序号、拆分的参数片段和交错的并行调用，各服务商的流式路径共用该实现
+type ToolCallAssembler = toolcall.Assembler
+
+// ToolCallDelta 工具调用增量
+type ToolCallDelta = toolcall.Delta
+
+// NewToolCallAssembler 创建工具调用累积器
+func NewToolCallAssembler() *ToolCallAssembler {
+	return toolcall.New()
+}
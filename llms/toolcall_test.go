@@ -0,0 +1,64 @@
+package llms_test
+
+import (
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestToolCallAssembler(t *testing.T) {
+	t.Run("交错的并行调用与乱序序号", func(t *testing.T) {
+		a := llmscn.NewToolCallAssembler()
+		a.Add(llmscn.ToolCallDelta{Index: intPtr(1), ID: "call_b", Type: "function", Name: "get_time"})
+		a.Add(llmscn.ToolCallDelta{Index: intPtr(0), ID: "call_a", Type: "function", Name: "get_weather"})
+		a.Add(llmscn.ToolCallDelta{Index: intPtr(0), Arguments: `{"city":`})
+		a.Add(llmscn.ToolCallDelta{Index: intPtr(1), Arguments: `{"tz":"Asia/Shanghai"}`})
+		a.Add(llmscn.ToolCallDelta{Index: intPtr(0), Arguments: `"北京"}`})
+
+		calls := a.ToolCalls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, "call_a", calls[0].ID)
+		assert.Equal(t, "get_weather", calls[0].FunctionCall.Name)
+		assert.Equal(t, `{"city":"北京"}`, calls[0].FunctionCall.Arguments)
+		assert.Equal(t, "call_b", calls[1].ID)
+		assert.Equal(t, `{"tz":"Asia/Shanghai"}`, calls[1].FunctionCall.Arguments)
+	})
+
+	t.Run("无序号时按ID匹配并续接上一个调用", func(t *testing.T) {
+		a := llmscn.NewToolCallAssembler()
+		a.Add(llmscn.ToolCallDelta{ID: "call_a", Name: "search", Arguments: `{"q":`})
+		a.Add(llmscn.ToolCallDelta{Arguments: `"go"}`})
+		a.Add(llmscn.ToolCallDelta{ID: "call_b", Name: "fetch", Arguments: `{}`})
+		a.Add(llmscn.ToolCallDelta{ID: "call_a", Type: "function"})
+
+		calls := a.ToolCalls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, llms.ToolCall{ID: "call_a", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: `{"q":"go"}`}}, calls[0])
+		assert.Equal(t, "function", calls[1].Type)
+		assert.Equal(t, `{}`, calls[1].FunctionCall.Arguments)
+	})
+
+	t.Run("原始增量", func(t *testing.T) {
+		a := llmscn.NewToolCallAssembler()
+		require.NoError(t, a.AddRaw([]interface{}{
+			map[string]interface{}{"index": float64(0), "id": "call_a", "function": map[string]interface{}{"name": "add", "arguments": `{"a":1,`}},
+		}))
+		require.NoError(t, a.AddRaw(map[string]interface{}{"index": float64(0), "function": map[string]interface{}{"arguments": `"b":2}`}}))
+		// 参数为完整的JSON对象
+		require.NoError(t, a.AddRaw([]byte(`[{"index":1,"id":"call_b","function":{"name":"echo","arguments":{"text":"hi"}}}]`)))
+		assert.Error(t, a.AddRaw("not a tool call"))
+
+		calls := a.ToolCalls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, `{"a":1,"b":2}`, calls[0].FunctionCall.Arguments)
+		assert.Equal(t, `{"text":"hi"}`, calls[1].FunctionCall.Arguments)
+
+		a.Reset()
+		assert.Zero(t, a.Len())
+	})
+}
@@ -0,0 +1,571 @@
+package llms
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// UsageRecord 单次模型调用的用量
+type UsageRecord struct {
+	Provider         string            `json:"provider,omitempty"`
+	Model            string            `json:"model,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"` // 请求标签，见 WithRequestTags
+	PromptTokens     int               `json:"prompt_tokens"`
+	CompletionTokens int               `json:"completion_tokens"`
+	TotalTokens      int               `json:"total_tokens"`
+	Cost             float64           `json:"cost,omitempty"`
+	Time             time.Time         `json:"time"`
+}
+
+// UsageBatch 一次上报的用量批次
+// 批次在重试时保持相同的 ID，接收方可据此去重
+type UsageBatch struct {
+	ID      string        `json:"id"`
+	Records []UsageRecord `json:"records"`
+}
+
+// UsageSink 用量的上报目标
+// 返回错误的批次会在下次上报时重新发送，因此实现需要能够处理重复的批次
+type UsageSink interface {
+	WriteUsage(ctx context.Context, batch UsageBatch) error
+}
+
+// UsageReporterOptions 用量上报配置
+type UsageReporterOptions struct {
+	// FlushInterval 定期上报的间隔，默认1分钟
+	FlushInterval time.Duration
+	// MaxBatchSize 缓冲的记录数达到该值时立即上报，默认100
+	MaxBatchSize int
+	// SpillFile 上报失败的批次写入该文件（JSON Lines），在之后的上报及进程重启后重新发送；
+	// 为空时失败的批次只保留在内存中
+	SpillFile string
+	// Cost 计算单条记录的费用，记录中已设置 Cost 时不调用
+	Cost func(record UsageRecord) float64
+	// OnError 后台定期上报失败时调用，可用于记录日志
+	OnError func(err error)
+}
+
+// UsageReporter 用量上报器
+// 汇总各提供商的token与费用用量，按批次定期或在缓冲满时上报给 UsageSink。
+// 上报失败的批次会保留并按顺序重试，直到成功写入（至少一次投递），
+// 配置 SpillFile 后未投递的批次会落盘，进程重启后继续上报
+type UsageReporter struct {
+	sink UsageSink
+	opts UsageReporterOptions
+
+	mu     sync.Mutex
+	buffer []UsageRecord
+
+	// flushMu 串行化上报，pending 只在持有 flushMu 时访问
+	flushMu sync.Mutex
+	pending []UsageBatch
+
+	trigger   chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewUsageReporter 创建用量上报器并启动后台定期上报，使用完毕后需调用 Close
+// SpillFile 中存在上次未投递的批次时，会在首次上报时重新发送
+func NewUsageReporter(sink UsageSink, opts UsageReporterOptions) (*UsageReporter, error) {
+	if sink == nil {
+		return nil, fmt.Errorf("%w: sink", ErrMissingRequiredParam)
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Minute
+	}
+	if opts.MaxBatchSize <= 0 {
+		opts.MaxBatchSize = 100
+	}
+
+	r := &UsageReporter{
+		sink:    sink,
+		opts:    opts,
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if opts.SpillFile != "" {
+		pending, err := loadSpilledUsage(opts.SpillFile)
+		if err != nil {
+			return nil, err
+		}
+		r.pending = pending
+	}
+
+	go r.loop()
+	return r, nil
+}
+
+// Record 记录一次调用的用量，未设置时间时使用当前时间
+func (r *UsageReporter) Record(record UsageRecord) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if record.TotalTokens == 0 {
+		record.TotalTokens = record.PromptTokens + record.CompletionTokens
+	}
+	if record.Cost == 0 && r.opts.Cost != nil {
+		record.Cost = r.opts.Cost(record)
+	}
+
+	r.mu.Lock()
+	r.buffer = append(r.buffer, record)
+	full := len(r.buffer) >= r.opts.MaxBatchSize
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Pending 返回已打包但尚未投递成功的批次数
+func (r *UsageReporter) Pending() int {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	return len(r.pending)
+}
+
+// Flush 将缓冲的记录打包为批次，并按顺序上报所有未投递的批次
+// 遇到失败时停止，剩余批次保留到下次上报
+func (r *UsageReporter) Flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	if len(r.buffer) > 0 {
+		r.pending = append(r.pending, UsageBatch{ID: newUsageBatchID(), Records: r.buffer})
+		r.buffer = nil
+	}
+	r.mu.Unlock()
+
+	var err error
+	delivered := 0
+	for _, batch := range r.pending {
+		if err = r.sink.WriteUsage(ctx, batch); err != nil {
+			break
+		}
+		delivered++
+	}
+	r.pending = r.pending[delivered:]
+
+	spillErr := r.spill()
+	if err != nil {
+		return fmt.Errorf("上报用量失败，%d 个批次待重试: %w", len(r.pending), errors.Join(err, spillErr))
+	}
+	return spillErr
+}
+
+// Close 停止后台上报并上报剩余的记录
+// 上报失败时，配置了 SpillFile 的批次已落盘，可由下一个上报器继续发送
+func (r *UsageReporter) Close(ctx context.Context) error {
+	r.closeOnce.Do(func() {
+		close(r.done)
+	})
+	<-r.stopped
+	return r.Flush(ctx)
+}
+
+// loop 定期或在缓冲满时上报
+func (r *UsageReporter) loop() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.trigger:
+		case <-r.done:
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.opts.FlushInterval)
+		if err := r.Flush(ctx); err != nil && r.opts.OnError != nil {
+			r.opts.OnError(err)
+		}
+		cancel()
+	}
+}
+
+// spill 将未投递的批次写入 SpillFile，全部投递后删除文件
+func (r *UsageReporter) spill() error {
+	path := r.opts.SpillFile
+	if path == "" {
+		return nil
+	}
+	if len(r.pending) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除用量落盘文件失败: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, batch := range r.pending {
+		if err := encoder.Encode(batch); err != nil {
+			return fmt.Errorf("序列化用量批次失败: %w", err)
+		}
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建用量落盘目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入用量落盘文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入用量落盘文件失败: %w", err)
+	}
+	return nil
+}
+
+// loadSpilledUsage 读取落盘的批次，文件不存在时返回空
+func loadSpilledUsage(path string) ([]UsageBatch, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取用量落盘文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var batches []UsageBatch
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var batch UsageBatch
+		if err := json.Unmarshal(line, &batch); err != nil {
+			return nil, fmt.Errorf("解析用量落盘文件失败: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取用量落盘文件失败: %w", err)
+	}
+	return batches, nil
+}
+
+// newUsageBatchID 生成随机的批次ID
+func newUsageBatchID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// ================================
+// 上报目标
+// ================================
+
+// WebhookUsageSink 以JSON格式将批次 POST 到 Webhook
+// 请求头 Idempotency-Key 为批次ID，状态码不是 2xx 时视为失败
+type WebhookUsageSink struct {
+	URL     string
+	Headers map[string]string // 附加的请求头，如认证信息
+	Client  *http.Client      // 默认为 http.DefaultClient
+}
+
+var _ UsageSink = (*WebhookUsageSink)(nil)
+
+// NewWebhookUsageSink 创建Webhook上报目标
+func NewWebhookUsageSink(url string, headers map[string]string) *WebhookUsageSink {
+	return &WebhookUsageSink{
+		URL:     url,
+		Headers: headers,
+	}
+}
+
+// WriteUsage 实现 UsageSink 接口
+func (s *WebhookUsageSink) WriteUsage(ctx context.Context, batch UsageBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", batch.ID)
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook 返回状态码 %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// FileUsageSink 将记录以 JSON Lines 格式追加到文件，每行一条记录并附带批次ID
+type FileUsageSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ UsageSink = (*FileUsageSink)(nil)
+
+// NewFileUsageSink 创建文件上报目标
+func NewFileUsageSink(path string) *FileUsageSink {
+	return &FileUsageSink{path: path}
+}
+
+// fileUsageLine 文件中的一行
+type fileUsageLine struct {
+	BatchID string `json:"batch_id"`
+	UsageRecord
+}
+
+// WriteUsage 实现 UsageSink 接口
+func (s *FileUsageSink) WriteUsage(ctx context.Context, batch UsageBatch) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range batch.Records {
+		if err := encoder.Encode(fileUsageLine{BatchID: batch.ID, UsageRecord: record}); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	// 整批一次写入，避免与其他批次交错
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// SQLUsageSink 将记录写入数据库表，同一批次只写入一次
+// 使用 database/sql，需要调用方导入对应的驱动（如 _ "github.com/go-sql-driver/mysql"）
+type SQLUsageSink struct {
+	db    *sql.DB
+	table string
+	// dollar 表示驱动使用 $1 风格的占位符（PostgreSQL）
+	dollar bool
+
+	mu    sync.Mutex
+	ready bool
+}
+
+var _ UsageSink = (*SQLUsageSink)(nil)
+
+// NewSQLUsageSink 创建数据库上报目标，driver 为 sql.Open 使用的驱动名，table 为空时使用 llm_usage
+// 表不存在时会在首次写入时创建
+func NewSQLUsageSink(db *sql.DB, driver, table string) *SQLUsageSink {
+	if table == "" {
+		table = "llm_usage"
+	}
+	return &SQLUsageSink{
+		db:     db,
+		table:  table,
+		dollar: driver == "postgres" || driver == "pgx",
+	}
+}
+
+// WriteUsage 实现 UsageSink 接口
+func (s *SQLUsageSink) WriteUsage(ctx context.Context, batch UsageBatch) error {
+	if err := s.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 重试的批次已写入时跳过
+	var count int
+	row := tx.QueryRowContext(ctx, s.bind(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE batch_id = ?", s.table)), batch.ID)
+	if err := row.Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	query := s.bind(fmt.Sprintf(`INSERT INTO %s (batch_id, seq, provider, model, tags, prompt_tokens, completion_tokens, total_tokens, cost, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, s.table))
+	for i, record := range batch.Records {
+		tags := ""
+		if len(record.Tags) > 0 {
+			data, err := json.Marshal(record.Tags)
+			if err != nil {
+				return err
+			}
+			tags = string(data)
+		}
+		if _, err := tx.ExecContext(ctx, query, batch.ID, i, record.Provider, record.Model, tags,
+			record.PromptTokens, record.CompletionTokens, record.TotalTokens, record.Cost, record.Time); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ensureTable 创建用量表（如不存在）
+func (s *SQLUsageSink) ensureTable(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	batch_id VARCHAR(64) NOT NULL,
+	seq INTEGER NOT NULL,
+	provider VARCHAR(64) NOT NULL,
+	model VARCHAR(255) NOT NULL,
+	tags TEXT NOT NULL,
+	prompt_tokens INTEGER NOT NULL,
+	completion_tokens INTEGER NOT NULL,
+	total_tokens INTEGER NOT NULL,
+	cost DOUBLE PRECISION NOT NULL,
+	created_at TIMESTAMP NOT NULL
+)`, s.table)
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("创建用量表 %s 失败: %w", s.table, err)
+	}
+	s.ready = true
+	return nil
+}
+
+// bind 按驱动风格替换占位符
+func (s *SQLUsageSink) bind(query string) string {
+	if !s.dollar {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&sb, "$%d", n)
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// ================================
+// 模型装饰器
+// ================================
+
+// UsageReportingModel 用量上报装饰器
+// 从每次调用返回的 GenerationInfo 中读取token用量，连同请求标签记录到 UsageReporter
+type UsageReportingModel struct {
+	model     llms.Model
+	reporter  *UsageReporter
+	provider  string
+	modelName string
+}
+
+var _ llms.Model = (*UsageReportingModel)(nil)
+
+// NewUsageReportingModel 创建用量上报装饰器，provider 与 modelName 写入每条记录
+func NewUsageReportingModel(model llms.Model, reporter *UsageReporter, provider, modelName string) *UsageReportingModel {
+	return &UsageReportingModel{
+		model:     model,
+		reporter:  reporter,
+		provider:  provider,
+		modelName: modelName,
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *UsageReportingModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	resp, err := m.model.GenerateContent(ctx, messages, options...)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	tags := RequestTagsFromContext(ctx)
+	if requestTags := RequestTags(opts); requestTags != nil {
+		for k, v := range tags {
+			if _, ok := requestTags[k]; !ok {
+				requestTags[k] = v
+			}
+		}
+		tags = requestTags
+	}
+
+	// 多候选生成的用量已分摊到各候选，累加即为实际用量；
+	// 其余情况下多个候选重复返回同一次请求的用量，只记录一次
+	record := UsageRecord{
+		Provider: m.provider,
+		Model:    m.modelName,
+		Tags:     tags,
+	}
+	for _, choice := range resp.Choices {
+		prompt, completion, total := TokenUsage(choice.GenerationInfo)
+		record.PromptTokens += prompt
+		record.CompletionTokens += completion
+		record.TotalTokens += total
+		if _, ok := choice.GenerationInfo[CandidateIndexKey]; !ok && record.PromptTokens+record.CompletionTokens > 0 {
+			break
+		}
+	}
+	if record.PromptTokens+record.CompletionTokens > 0 {
+		m.reporter.Record(record)
+	}
+	return resp, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *UsageReportingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
@@ -0,0 +1,142 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// usageModel 返回带token用量的回复
+type usageModel struct{}
+
+func (usageModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content: "ok",
+		GenerationInfo: map[string]any{
+			"PromptTokens":     10,
+			"CompletionTokens": 5,
+			"TotalTokens":      15,
+		},
+	}}}, nil
+}
+
+func (m usageModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// usageWebhook 记录收到的批次，failures 次之前返回 503
+type usageWebhook struct {
+	mu       sync.Mutex
+	failures int
+	batches  []llmscn.UsageBatch
+	keys     []string
+}
+
+func (h *usageWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures > 0 {
+		h.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var batch llmscn.UsageBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.batches = append(h.batches, batch)
+	h.keys = append(h.keys, r.Header.Get("Idempotency-Key"))
+}
+
+func TestUsageReporter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("失败时落盘并在重启后重新发送", func(t *testing.T) {
+		hook := &usageWebhook{failures: 1}
+		server := httptest.NewServer(hook)
+		defer server.Close()
+
+		spill := filepath.Join(t.TempDir(), "usage.jsonl")
+		reporter, err := llmscn.NewUsageReporter(llmscn.NewWebhookUsageSink(server.URL, nil), llmscn.UsageReporterOptions{
+			FlushInterval: time.Hour,
+			SpillFile:     spill,
+			Cost: func(record llmscn.UsageRecord) float64 {
+				return float64(record.TotalTokens) * 0.001
+			},
+		})
+		require.NoError(t, err)
+
+		model := llmscn.NewUsageReportingModel(usageModel{}, reporter, "deepseek", "deepseek-chat")
+		_, err = model.Call(ctx, "hi", llmscn.WithRequestTags(map[string]string{"tenant": "t1"}))
+		require.NoError(t, err)
+
+		err = reporter.Close(ctx)
+		assert.ErrorContains(t, err, "503")
+		assert.Equal(t, 1, reporter.Pending())
+		_, err = os.Stat(spill)
+		require.NoError(t, err)
+
+		// 新的上报器读取落盘文件继续发送
+		reporter, err = llmscn.NewUsageReporter(llmscn.NewWebhookUsageSink(server.URL, nil), llmscn.UsageReporterOptions{
+			FlushInterval: time.Hour,
+			SpillFile:     spill,
+		})
+		require.NoError(t, err)
+		require.NoError(t, reporter.Close(ctx))
+
+		require.Len(t, hook.batches, 1)
+		batch := hook.batches[0]
+		assert.Equal(t, batch.ID, hook.keys[0])
+		require.Len(t, batch.Records, 1)
+		record := batch.Records[0]
+		assert.Equal(t, "deepseek", record.Provider)
+		assert.Equal(t, "deepseek-chat", record.Model)
+		assert.Equal(t, "t1", record.Tags["tenant"])
+		assert.Equal(t, 15, record.TotalTokens)
+		assert.InDelta(t, 0.015, record.Cost, 1e-9)
+
+		_, err = os.Stat(spill)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("缓冲满时立即上报", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "usage.log")
+		reporter, err := llmscn.NewUsageReporter(llmscn.NewFileUsageSink(path), llmscn.UsageReporterOptions{
+			FlushInterval: time.Hour,
+			MaxBatchSize:  2,
+		})
+		require.NoError(t, err)
+		defer reporter.Close(ctx)
+
+		reporter.Record(llmscn.UsageRecord{Provider: "qwen", PromptTokens: 3, CompletionTokens: 4})
+		reporter.Record(llmscn.UsageRecord{Provider: "qwen", PromptTokens: 1, CompletionTokens: 1})
+
+		assert.Eventually(t, func() bool {
+			data, err := os.ReadFile(path)
+			return err == nil && strings.Count(string(data), "\n") == 2
+		}, time.Second, 10*time.Millisecond)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var line struct {
+			BatchID     string `json:"batch_id"`
+			TotalTokens int    `json:"total_tokens"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(strings.SplitN(string(data), "\n", 2)[0]), &line))
+		assert.NotEmpty(t, line.BatchID)
+		assert.Equal(t, 7, line.TotalTokens)
+	})
+}
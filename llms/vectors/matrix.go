@@ -0,0 +1,153 @@
+package vectors
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// Match top-k 检索的结果
+type Match struct {
+	// Index 向量在矩阵中的行号
+	Index int
+	// Score 按度量方式计算的得分，越大越相似
+	Score float32
+}
+
+// Matrix 内存中的向量矩阵，按行连续存储以提高检索时的缓存命中率
+// 添加向量时预先计算范数，余弦检索无需重复计算。并发读取是安全的，
+// 与 Add 并发调用时需要由调用方加锁
+type Matrix struct {
+	dim   int
+	data  []float32
+	norms []float32
+}
+
+// NewMatrix 创建维度为 dim 的空矩阵，dim 为0时由第一个添加的向量决定
+func NewMatrix(dim int) *Matrix {
+	return &Matrix{dim: dim}
+}
+
+// NewMatrixFromRows 使用已有向量创建矩阵，所有向量的维度必须相同
+func NewMatrixFromRows(rows [][]float32) (*Matrix, error) {
+	m := NewMatrix(0)
+	for _, row := range rows {
+		if _, err := m.Add(row); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Dim 返回向量维度
+func (m *Matrix) Dim() int {
+	return m.dim
+}
+
+// Len 返回向量数量
+func (m *Matrix) Len() int {
+	return len(m.norms)
+}
+
+// Add 复制并添加一个向量，返回其行号
+func (m *Matrix) Add(vector []float32) (int, error) {
+	if m.dim == 0 {
+		if len(vector) == 0 {
+			return 0, fmt.Errorf("%w: 向量为空", ErrDimensionMismatch)
+		}
+		m.dim = len(vector)
+	}
+	if len(vector) != m.dim {
+		return 0, fmt.Errorf("%w: %d != %d", ErrDimensionMismatch, len(vector), m.dim)
+	}
+
+	m.data = append(m.data, vector...)
+	m.norms = append(m.norms, Norm(vector))
+	return len(m.norms) - 1, nil
+}
+
+// Row 返回第 i 行向量，返回的切片与矩阵共享内存，不应修改
+func (m *Matrix) Row(i int) []float32 {
+	return m.data[i*m.dim : (i+1)*m.dim : (i+1)*m.dim]
+}
+
+// TopK 返回与 query 最相似的 k 个向量，按得分从高到低排序，得分相同时行号小的在前
+func (m *Matrix) TopK(query []float32, k int, metric Metric) ([]Match, error) {
+	if len(query) != m.dim && m.Len() > 0 {
+		return nil, fmt.Errorf("%w: %d != %d", ErrDimensionMismatch, len(query), m.dim)
+	}
+	if k <= 0 || m.Len() == 0 {
+		return nil, nil
+	}
+
+	var score func(i int) float32
+	switch metric {
+	case MetricCosine, "":
+		queryNorm := Norm(query)
+		score = func(i int) float32 {
+			if queryNorm == 0 || m.norms[i] == 0 {
+				return 0
+			}
+			return Dot(query, m.Row(i)) / (queryNorm * m.norms[i])
+		}
+	case MetricDot:
+		score = func(i int) float32 {
+			return Dot(query, m.Row(i))
+		}
+	case MetricEuclidean:
+		// 比较距离的平方即可确定顺序，只对入选的结果开方
+		score = func(i int) float32 {
+			return -squaredDistance(query, m.Row(i))
+		}
+	default:
+		return nil, fmt.Errorf("不支持的相似度度量: %s", metric)
+	}
+
+	if k > m.Len() {
+		k = m.Len()
+	}
+	h := make(matchHeap, 0, k)
+	for i := 0; i < m.Len(); i++ {
+		s := score(i)
+		if len(h) < k {
+			heap.Push(&h, Match{Index: i, Score: s})
+		} else if s > h[0].Score {
+			h[0] = Match{Index: i, Score: s}
+			heap.Fix(&h, 0)
+		}
+	}
+
+	matches := []Match(h)
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Index < matches[j].Index
+	})
+	if metric == MetricEuclidean {
+		for i := range matches {
+			matches[i].Score = -sqrt32(-matches[i].Score)
+		}
+	}
+	return matches, nil
+}
+
+// matchHeap 按得分排列的小顶堆，堆顶是当前入选结果中得分最低的
+type matchHeap []Match
+
+func (h matchHeap) Len() int { return len(h) }
+func (h matchHeap) Less(i, j int) bool {
+	if h[i].Score != h[j].Score {
+		return h[i].Score < h[j].Score
+	}
+	return h[i].Index > h[j].Index
+}
+func (h matchHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x any)   { *h = append(*h, x.(Match)) }
+func (h *matchHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
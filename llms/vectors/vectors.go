@@ -0,0 +1,139 @@
+// Package vectors 提供向量相似度计算与内存中的 top-k 检索
+//
+// 语义缓存、评测和命令行检索等场景都需要比较 Embedding 向量，统一使用本包的实现，
+// 避免各处重复编写相似度代码。向量使用 float32，与 embeddings.Embedder 的返回值一致；
+// 内层循环按4路展开并使用独立的累加器，便于编译器消除边界检查并生成向量化指令。
+package vectors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrDimensionMismatch 表示向量维度不一致
+var ErrDimensionMismatch = errors.New("向量维度不一致")
+
+// Metric 相似度度量方式
+type Metric string
+
+const (
+	// MetricCosine 余弦相似度，取值范围 [-1, 1]
+	MetricCosine Metric = "cosine"
+	// MetricDot 点积，适用于已归一化的向量
+	MetricDot Metric = "dot"
+	// MetricEuclidean 欧氏距离，得分为距离的相反数，越大越相似
+	MetricEuclidean Metric = "euclidean"
+)
+
+// Dot 计算两个向量的点积，a 和 b 的长度必须相同
+func Dot(a, b []float32) float32 {
+	checkDimension(a, b)
+	b = b[:len(a)]
+
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// Norm 计算向量的L2范数
+func Norm(a []float32) float32 {
+	return sqrt32(Dot(a, a))
+}
+
+// Cosine 计算两个向量的余弦相似度，任一向量为零向量时返回0
+func Cosine(a, b []float32) float32 {
+	normA, normB := Norm(a), Norm(b)
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return Dot(a, b) / (normA * normB)
+}
+
+// Euclidean 计算两个向量的欧氏距离，a 和 b 的长度必须相同
+func Euclidean(a, b []float32) float32 {
+	return sqrt32(squaredDistance(a, b))
+}
+
+// Normalize 返回向量归一化后的副本，零向量原样复制
+func Normalize(a []float32) []float32 {
+	out := make([]float32, len(a))
+	copy(out, a)
+	NormalizeInPlace(out)
+	return out
+}
+
+// NormalizeInPlace 将向量原地归一化为单位向量，零向量保持不变
+func NormalizeInPlace(a []float32) {
+	norm := Norm(a)
+	if norm == 0 {
+		return
+	}
+	scale := 1 / norm
+	for i := range a {
+		a[i] *= scale
+	}
+}
+
+// Similarity 按度量方式计算两个向量的得分，得分越大越相似
+func Similarity(metric Metric, a, b []float32) (float32, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("%w: %d != %d", ErrDimensionMismatch, len(a), len(b))
+	}
+
+	switch metric {
+	case MetricCosine, "":
+		return Cosine(a, b), nil
+	case MetricDot:
+		return Dot(a, b), nil
+	case MetricEuclidean:
+		return -Euclidean(a, b), nil
+	default:
+		return 0, fmt.Errorf("不支持的相似度度量: %s", metric)
+	}
+}
+
+// squaredDistance 计算两个向量欧氏距离的平方
+func squaredDistance(a, b []float32) float32 {
+	checkDimension(a, b)
+	b = b[:len(a)]
+
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 := a[i] - b[i]
+		d1 := a[i+1] - b[i+1]
+		d2 := a[i+2] - b[i+2]
+		d3 := a[i+3] - b[i+3]
+		s0 += d0 * d0
+		s1 += d1 * d1
+		s2 += d2 * d2
+		s3 += d3 * d3
+	}
+	for ; i < len(a); i++ {
+		d := a[i] - b[i]
+		s0 += d * d
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// sqrt32 计算 float32 的平方根
+func sqrt32(x float32) float32 {
+	return float32(math.Sqrt(float64(x)))
+}
+
+// checkDimension 维度不一致时 panic，与切片越界的行为一致
+func checkDimension(a, b []float32) {
+	if len(a) != len(b) {
+		panic(fmt.Sprintf("vectors: %v: %d != %d", ErrDimensionMismatch, len(a), len(b)))
+	}
+}
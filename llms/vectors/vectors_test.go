@@ -0,0 +1,122 @@
+package vectors_test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/vectors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimilarity(t *testing.T) {
+	a := []float32{1, 2, 3, 4, 5}
+	b := []float32{5, 4, 3, 2, 1}
+
+	assert.Equal(t, float32(35), vectors.Dot(a, b))
+	assert.InDelta(t, 35.0/55.0, vectors.Cosine(a, b), 1e-6)
+	assert.InDelta(t, 6.324555, vectors.Euclidean(a, b), 1e-5)
+	assert.Equal(t, float32(0), vectors.Cosine(a, make([]float32, 5)))
+
+	normalized := vectors.Normalize(a)
+	assert.InDelta(t, 1, vectors.Norm(normalized), 1e-6)
+	assert.Equal(t, float32(1), a[0], "Normalize 不修改原向量")
+
+	score, err := vectors.Similarity(vectors.MetricEuclidean, a, a)
+	require.NoError(t, err)
+	assert.Equal(t, float32(0), score)
+
+	_, err = vectors.Similarity(vectors.MetricDot, a, b[:3])
+	assert.ErrorIs(t, err, vectors.ErrDimensionMismatch)
+	assert.Panics(t, func() { vectors.Dot(a, b[:3]) })
+}
+
+func TestMatrixTopK(t *testing.T) {
+	m, err := vectors.NewMatrixFromRows([][]float32{
+		{1, 0},
+		{0, 1},
+		{1, 1},
+		{-1, 0},
+		{2, 0},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Dim())
+	assert.Equal(t, 5, m.Len())
+
+	matches, err := m.TopK([]float32{1, 0}, 3, vectors.MetricCosine)
+	require.NoError(t, err)
+	require.Len(t, matches, 3)
+	// 同方向的向量得分相同，行号小的在前
+	assert.Equal(t, []int{0, 4, 2}, []int{matches[0].Index, matches[1].Index, matches[2].Index})
+	assert.InDelta(t, 1, matches[0].Score, 1e-6)
+
+	matches, err = m.TopK([]float32{1, 0}, 2, vectors.MetricEuclidean)
+	require.NoError(t, err)
+	assert.Equal(t, 0, matches[0].Index)
+	assert.Equal(t, float32(0), matches[0].Score)
+	assert.InDelta(t, -1, matches[1].Score, 1e-6)
+
+	matches, err = m.TopK([]float32{1, 0}, 10, vectors.MetricDot)
+	require.NoError(t, err)
+	assert.Len(t, matches, 5)
+	assert.Equal(t, 4, matches[0].Index)
+
+	_, err = m.TopK([]float32{1, 0, 0}, 1, vectors.MetricDot)
+	assert.ErrorIs(t, err, vectors.ErrDimensionMismatch)
+	_, err = m.Add([]float32{1})
+	assert.ErrorIs(t, err, vectors.ErrDimensionMismatch)
+}
+
+func TestMatrixTopKMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	rows := make([][]float32, 200)
+	for i := range rows {
+		rows[i] = make([]float32, 37)
+		for j := range rows[i] {
+			rows[i][j] = rng.Float32()*2 - 1
+		}
+	}
+	m, err := vectors.NewMatrixFromRows(rows)
+	require.NoError(t, err)
+	query := rows[17]
+
+	matches, err := m.TopK(query, 5, vectors.MetricCosine)
+	require.NoError(t, err)
+
+	// 与逐个计算后排序的结果一致
+	expected := make([]int, len(rows))
+	for i := range expected {
+		expected[i] = i
+	}
+	sort.SliceStable(expected, func(i, j int) bool {
+		return vectors.Cosine(query, rows[expected[i]]) > vectors.Cosine(query, rows[expected[j]])
+	})
+	require.Len(t, matches, 5)
+	for i, match := range matches {
+		assert.Equal(t, expected[i], match.Index)
+		assert.InDelta(t, vectors.Cosine(query, rows[match.Index]), match.Score, 1e-5)
+	}
+}
+
+func BenchmarkMatrixTopK(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	m := vectors.NewMatrix(1024)
+	for i := 0; i < 10000; i++ {
+		row := make([]float32, 1024)
+		for j := range row {
+			row[j] = rng.Float32()
+		}
+		if _, err := m.Add(row); err != nil {
+			b.Fatal(err)
+		}
+	}
+	query := m.Row(0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.TopK(query, 10, vectors.MetricCosine); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package llms
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// VisionCacheHitKey 命中缓存的回复在每个候选的 GenerationInfo 中记录为 true
+const VisionCacheHitKey = "vision_cache_hit"
+
+// VisionCacheOptions 图片理解结果缓存配置
+type VisionCacheOptions struct {
+	// TTL 缓存有效期，默认为24小时，小于0表示不过期
+	TTL time.Duration
+	// MaxEntries 最多缓存的结果数，默认为1000，超出时淘汰最久未使用的结果
+	MaxEntries int
+	// MaxBytes 缓存内容的总字节数上限，0表示不限制
+	MaxBytes int
+}
+
+// VisionCacheStats 缓存统计
+type VisionCacheStats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int   `json:"bytes"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+// VisionCacheModel 图片理解结果缓存装饰器
+// 请求中包含图片时，以图片内容的哈希、提示词和生成参数作为键缓存回复，
+// 重复分析相同的素材（如商品目录中的产品图片）不会再次计费。
+// 图片数据（BinaryContent 或 data URI）按解码后的内容计算哈希，同一张图片以不同形式传入时命中同一条缓存；
+// 远程图片无法获取内容，按地址计算哈希。请求标签等元数据不参与计算。
+// 不包含图片的请求和失败的请求不缓存。命中缓存的流式调用会一次性回调全部内容
+type VisionCacheModel struct {
+	model   llms.Model
+	options VisionCacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	bytes   int
+	stats   VisionCacheStats
+}
+
+var _ llms.Model = (*VisionCacheModel)(nil)
+
+// visionCacheEntry 缓存条目
+type visionCacheEntry struct {
+	key       string
+	resp      *llms.ContentResponse
+	size      int
+	expiresAt time.Time
+}
+
+// NewVisionCacheModel 创建图片理解结果缓存装饰器
+func NewVisionCacheModel(model llms.Model, options VisionCacheOptions) *VisionCacheModel {
+	if options.TTL == 0 {
+		options.TTL = 24 * time.Hour
+	}
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 1000
+	}
+	return &VisionCacheModel{
+		model:   model,
+		options: options,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// GenerateContent 实现 llms.Model 接口
+func (m *VisionCacheModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	key, ok := visionCacheKey(messages, opts)
+	if !ok {
+		return m.model.GenerateContent(ctx, messages, options...)
+	}
+
+	if resp, hit := m.get(key); hit {
+		if opts.StreamingFunc != nil && len(resp.Choices) > 0 {
+			if err := opts.StreamingFunc(ctx, []byte(resp.Choices[0].Content)); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+
+	resp, err := m.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	m.put(key, resp)
+	return resp, nil
+}
+
+// Call 实现 llms.Model 接口
+func (m *VisionCacheModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Endpoint 返回被装饰模型的服务地址，供预热使用
+func (m *VisionCacheModel) Endpoint() string {
+	return modelEndpoint(m.model)
+}
+
+// Stats 返回缓存统计
+func (m *VisionCacheModel) Stats() VisionCacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Entries = m.lru.Len()
+	stats.Bytes = m.bytes
+	return stats
+}
+
+// Purge 清空缓存
+func (m *VisionCacheModel) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]*list.Element)
+	m.lru.Init()
+	m.bytes = 0
+}
+
+// get 查找未过期的缓存，返回回复的副本
+func (m *VisionCacheModel) get(key string) (*llms.ContentResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if ok {
+		entry := element.Value.(*visionCacheEntry)
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			m.lru.MoveToFront(element)
+			m.stats.Hits++
+			return copyResponse(entry.resp, true), true
+		}
+		m.remove(element)
+	}
+	m.stats.Misses++
+	return nil, false
+}
+
+// put 缓存回复，超出容量时淘汰最久未使用的结果
+func (m *VisionCacheModel) put(key string, resp *llms.ContentResponse) {
+	size := responseSize(resp)
+	if m.options.MaxBytes > 0 && size > m.options.MaxBytes {
+		return
+	}
+
+	entry := &visionCacheEntry{
+		key:  key,
+		resp: copyResponse(resp, false),
+		size: size,
+	}
+	if m.options.TTL > 0 {
+		entry.expiresAt = time.Now().Add(m.options.TTL)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	m.entries[key] = m.lru.PushFront(entry)
+	m.bytes += size
+
+	for m.lru.Len() > m.options.MaxEntries || (m.options.MaxBytes > 0 && m.bytes > m.options.MaxBytes) {
+		m.remove(m.lru.Back())
+		m.stats.Evictions++
+	}
+}
+
+// remove 删除缓存条目，调用方需持有锁
+func (m *VisionCacheModel) remove(element *list.Element) {
+	entry := element.Value.(*visionCacheEntry)
+	m.lru.Remove(element)
+	delete(m.entries, entry.key)
+	m.bytes -= entry.size
+}
+
+// visionCacheKey 计算请求的缓存键，请求中不包含图片或参数无法序列化时返回 false
+func visionCacheKey(messages []llms.MessageContent, opts llms.CallOptions) (string, bool) {
+	hash := sha256.New()
+	hasImage := false
+	write := func(kind, value string) {
+		hash.Write([]byte(kind))
+		hash.Write([]byte{0})
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+
+	for _, message := range messages {
+		write("role", string(message.Role))
+		for _, part := range message.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				write("text", p.Text)
+			case llms.ImageURLContent:
+				hasImage = true
+				write("image", imageURLHash(p.URL))
+				write("detail", p.Detail)
+			case llms.BinaryContent:
+				if strings.HasPrefix(p.MIMEType, "image/") {
+					// 与 data URI 形式的同一张图片使用相同的键
+					hasImage = true
+					write("image", contentHash(p.Data))
+					write("detail", "")
+					continue
+				}
+				write("binary", p.MIMEType+":"+contentHash(p.Data))
+			default:
+				data, err := json.Marshal(part)
+				if err != nil {
+					return "", false
+				}
+				write("part", string(data))
+			}
+		}
+	}
+	if !hasImage {
+		return "", false
+	}
+
+	// 元数据中保存请求标签等与生成结果无关的信息，不参与计算
+	opts.Metadata = nil
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return "", false
+	}
+	write("options", string(data))
+
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// imageURLHash 计算图片地址的哈希，data URI 按解码后的图片内容计算
+func imageURLHash(url string) string {
+	if strings.HasPrefix(url, "data:") {
+		if i := strings.Index(url, ";base64,"); i >= 0 {
+			if data, err := base64.StdEncoding.DecodeString(url[i+len(";base64,"):]); err == nil {
+				return contentHash(data)
+			}
+		}
+	}
+	return "url:" + url
+}
+
+// contentHash 计算内容的 SHA-256 哈希
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseSize 估算回复占用的字节数
+func responseSize(resp *llms.ContentResponse) int {
+	size := 0
+	for _, choice := range resp.Choices {
+		size += len(choice.Content) + len(choice.ReasoningContent)
+		for _, call := range choice.ToolCalls {
+			if call.FunctionCall != nil {
+				size += len(call.FunctionCall.Name) + len(call.FunctionCall.Arguments)
+			}
+		}
+	}
+	return size
+}
+
+// copyResponse 复制回复，避免调用方修改缓存的内容
+func copyResponse(resp *llms.ContentResponse, hit bool) *llms.ContentResponse {
+	out := &llms.ContentResponse{Choices: make([]*llms.ContentChoice, len(resp.Choices))}
+	for i, choice := range resp.Choices {
+		c := *choice
+		c.ToolCalls = append([]llms.ToolCall(nil), choice.ToolCalls...)
+		c.GenerationInfo = make(map[string]any, len(choice.GenerationInfo)+1)
+		for k, v := range choice.GenerationInfo {
+			c.GenerationInfo[k] = v
+		}
+		if hit {
+			c.GenerationInfo[VisionCacheHitKey] = true
+		}
+		out.Choices[i] = &c
+	}
+	return out
+}
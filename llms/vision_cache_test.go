@@ -0,0 +1,125 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func imageMessage(image llms.ContentPart, prompt string) []llms.MessageContent {
+	return []llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{image, llms.TextContent{Text: prompt}},
+	}}
+}
+
+func TestVisionCacheModel(t *testing.T) {
+	ctx := context.Background()
+	photo := []byte("product-photo-bytes")
+	binary := llms.BinaryContent{MIMEType: "image/png", Data: photo}
+	dataURL := llms.ImageURLContent{URL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(photo)}
+
+	base := &paramsModel{}
+	model := llmscn.NewVisionCacheModel(base, llmscn.VisionCacheOptions{})
+
+	resp, err := model.GenerateContent(ctx, imageMessage(binary, "描述这件商品"))
+	require.NoError(t, err)
+	assert.Nil(t, resp.Choices[0].GenerationInfo[llmscn.VisionCacheHitKey])
+
+	// 同一张图片以 data URI 传入，请求标签不同，仍然命中缓存
+	var streamed string
+	resp, err = model.GenerateContent(ctx, imageMessage(dataURL, "描述这件商品"),
+		llmscn.WithRequestTags(map[string]string{"sku": "A-1"}),
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed += string(chunk)
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, true, resp.Choices[0].GenerationInfo[llmscn.VisionCacheHitKey])
+	assert.Equal(t, "ok", streamed)
+	assert.Len(t, base.calls, 1)
+
+	// 修改返回的回复不影响缓存
+	resp.Choices[0].Content = "changed"
+
+	// 提示词、生成参数或图片不同时不命中
+	_, err = model.GenerateContent(ctx, imageMessage(binary, "这件商品是什么颜色"))
+	require.NoError(t, err)
+	_, err = model.GenerateContent(ctx, imageMessage(binary, "描述这件商品"), llms.WithTemperature(0.9))
+	require.NoError(t, err)
+	_, err = model.GenerateContent(ctx, imageMessage(llms.BinaryContent{MIMEType: "image/png", Data: []byte("other")}, "描述这件商品"))
+	require.NoError(t, err)
+	assert.Len(t, base.calls, 4)
+
+	resp, err = model.GenerateContent(ctx, imageMessage(binary, "描述这件商品"))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Choices[0].Content)
+	assert.Len(t, base.calls, 4)
+
+	// 不包含图片的请求不缓存
+	_, err = model.Call(ctx, "你好")
+	require.NoError(t, err)
+	_, err = model.Call(ctx, "你好")
+	require.NoError(t, err)
+	assert.Len(t, base.calls, 6)
+
+	stats := model.Stats()
+	assert.Equal(t, 4, stats.Entries)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(4), stats.Misses)
+
+	model.Purge()
+	assert.Equal(t, 0, model.Stats().Entries)
+}
+
+func TestVisionCacheLimits(t *testing.T) {
+	ctx := context.Background()
+	image := func(name string) llms.ContentPart {
+		return llms.ImageURLContent{URL: "https://example.com/" + name + ".jpg"}
+	}
+
+	t.Run("max entries", func(t *testing.T) {
+		base := &paramsModel{}
+		model := llmscn.NewVisionCacheModel(base, llmscn.VisionCacheOptions{MaxEntries: 2})
+		for _, name := range []string{"a", "b", "a", "c", "a", "b"} {
+			_, err := model.GenerateContent(ctx, imageMessage(image(name), "描述"))
+			require.NoError(t, err)
+		}
+		// b 在加入 c 时被淘汰，a 最近被使用过而保留
+		assert.Len(t, base.calls, 4)
+		assert.Equal(t, int64(2), model.Stats().Evictions)
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		base := &paramsModel{}
+		model := llmscn.NewVisionCacheModel(base, llmscn.VisionCacheOptions{MaxBytes: 4})
+		for _, name := range []string{"a", "b", "c"} {
+			_, err := model.GenerateContent(ctx, imageMessage(image(name), "描述"))
+			require.NoError(t, err)
+		}
+		stats := model.Stats()
+		assert.Equal(t, 2, stats.Entries)
+		assert.Equal(t, 4, stats.Bytes)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		base := &paramsModel{}
+		model := llmscn.NewVisionCacheModel(base, llmscn.VisionCacheOptions{TTL: 20 * time.Millisecond})
+		_, err := model.GenerateContent(ctx, imageMessage(image("a"), "描述"))
+		require.NoError(t, err)
+		_, err = model.GenerateContent(ctx, imageMessage(image("a"), "描述"))
+		require.NoError(t, err)
+		assert.Len(t, base.calls, 1)
+
+		time.Sleep(30 * time.Millisecond)
+		_, err = model.GenerateContent(ctx, imageMessage(image("a"), "描述"))
+		require.NoError(t, err)
+		assert.Len(t, base.calls, 2)
+	})
+}
@@ -0,0 +1,176 @@
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/baichuan"
+	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	"github.com/sjzsdu/langchaingo-cn/llms/doubao"
+	"github.com/sjzsdu/langchaingo-cn/llms/ernie"
+	"github.com/sjzsdu/langchaingo-cn/llms/kimi"
+	"github.com/sjzsdu/langchaingo-cn/llms/minimax"
+	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/sjzsdu/langchaingo-cn/llms/yi"
+	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/anthropic"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// Endpointer 由能够报告API地址的模型实现，Warmup 据此预建连接
+type Endpointer interface {
+	Endpoint() string
+}
+
+// WarmupOptions 预热配置
+type WarmupOptions struct {
+	// Probe 为每个模型发送一次 max_tokens=1 的请求，完整预热鉴权与服务端链路（会产生少量费用）
+	Probe bool
+	// Endpoints 额外需要预建连接的API地址，用于自定义 base_url 的模型
+	Endpoints []string
+	// Client 发起预热请求的HTTP客户端，默认为 http.DefaultClient（各提供商默认使用的客户端）
+	Client *http.Client
+}
+
+// 内置提供商的默认API地址
+var defaultEndpoints = map[string]string{
+	"deepseek":    "https://api.deepseek.com",
+	"kimi":        "https://api.moonshot.cn/v1",
+	"qwen":        qwen.OpenAICompatibleBaseURL,
+	"zhipu":       zhipu.OpenAICompatibleBaseURL,
+	"siliconflow": siliconflow.OpenAICompatibleBaseURL,
+	"ernie":       ernie.DefaultBaseURL,
+	"minimax":     minimax.OpenAICompatibleBaseURL,
+	"doubao":      doubao.OpenAICompatibleBaseURL,
+	"yi":          yi.OpenAICompatibleBaseURL,
+	"baichuan":    baichuan.OpenAICompatibleBaseURL,
+	"openai":      "https://api.openai.com/v1",
+	"anthropic":   "https://api.anthropic.com/v1",
+}
+
+// Warmup 预热模型连接：预解析DNS并建立TLS连接放入连接池，降低部署后首个请求的延迟
+func Warmup(ctx context.Context, models ...llms.Model) error {
+	return WarmupWithOptions(ctx, WarmupOptions{}, models...)
+}
+
+// WarmupWithOptions 按配置预热模型连接，各地址与模型并发预热，返回所有失败的合并错误
+func WarmupWithOptions(ctx context.Context, opts WarmupOptions, models ...llms.Model) error {
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	endpoints := make(map[string]bool)
+	for _, endpoint := range opts.Endpoints {
+		endpoints[endpoint] = true
+	}
+	for _, model := range models {
+		if endpoint := modelEndpoint(model); endpoint != "" {
+			endpoints[endpoint] = true
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	for endpoint := range endpoints {
+		wg.Add(1)
+		go func(endpoint string) {
+			defer wg.Done()
+			if err := warmupEndpoint(ctx, client, endpoint); err != nil {
+				fail(fmt.Errorf("预热 %s 失败: %w", endpoint, err))
+			}
+		}(endpoint)
+	}
+
+	if opts.Probe {
+		for i, model := range models {
+			wg.Add(1)
+			go func(i int, model llms.Model) {
+				defer wg.Done()
+				_, err := model.GenerateContent(ctx, []llms.MessageContent{
+					llms.TextParts(llms.ChatMessageTypeHuman, "hi"),
+				}, llms.WithMaxTokens(1))
+				if err != nil {
+					fail(fmt.Errorf("预热模型 #%d 探测请求失败: %w", i, err))
+				}
+			}(i, model)
+		}
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// warmupEndpoint 解析域名并发送一次 HEAD 请求，使连接保留在客户端连接池中
+func warmupEndpoint(ctx context.Context, client *http.Client, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if _, err := net.DefaultResolver.LookupHost(ctx, u.Hostname()); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	// 读完并关闭响应体，连接才会被放回连接池复用；状态码无关紧要
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// modelEndpoint 返回模型的API地址，未知模型返回空字符串
+func modelEndpoint(model llms.Model) string {
+	switch m := model.(type) {
+	case Endpointer:
+		return m.Endpoint()
+	case *deepseek.LLM:
+		return defaultEndpoints["deepseek"]
+	case *kimi.LLM:
+		return defaultEndpoints["kimi"]
+	case *qwen.LLM:
+		return defaultEndpoints["qwen"]
+	case *zhipu.LLM:
+		return defaultEndpoints["zhipu"]
+	case *siliconflow.LLM:
+		return defaultEndpoints["siliconflow"]
+	case *ernie.LLM:
+		return defaultEndpoints["ernie"]
+	case *minimax.LLM:
+		return defaultEndpoints["minimax"]
+	case *doubao.LLM:
+		return defaultEndpoints["doubao"]
+	case *yi.LLM:
+		return defaultEndpoints["yi"]
+	case *baichuan.LLM:
+		return defaultEndpoints["baichuan"]
+	case *openai.LLM:
+		return defaultEndpoints["openai"]
+	case *anthropic.LLM:
+		return defaultEndpoints["anthropic"]
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,59 @@
+package llms_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// endpointModel 报告自定义API地址并记录探测请求的 max_tokens
+type endpointModel struct {
+	fakeModel
+	endpoint  string
+	maxTokens int
+}
+
+func (e *endpointModel) Endpoint() string { return e.endpoint }
+
+func (e *endpointModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	e.maxTokens = opts.MaxTokens
+	return e.fakeModel.GenerateContent(ctx, messages, options...)
+}
+
+func TestWarmup(t *testing.T) {
+	var heads atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	// 两个模型共用一个地址，只需预建一次连接
+	model := &endpointModel{fakeModel: fakeModel{reply: "ok"}, endpoint: server.URL}
+	other := &endpointModel{fakeModel: fakeModel{reply: "ok"}, endpoint: server.URL}
+	err := llmscn.WarmupWithOptions(context.Background(), llmscn.WarmupOptions{
+		Probe:  true,
+		Client: server.Client(),
+	}, model, other)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), heads.Load())
+	assert.Equal(t, 1, model.maxTokens)
+	assert.Equal(t, 1, other.maxTokens)
+
+	err = llmscn.WarmupWithOptions(context.Background(), llmscn.WarmupOptions{
+		Endpoints: []string{"http://127.0.0.1:1"},
+	})
+	assert.Error(t, err)
+}
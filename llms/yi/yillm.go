@@ -0,0 +1,199 @@
+// Package yi 提供了零一万物 Yi 大语言模型的Go语言客户端实现，使用兼容OpenAI的接口
+package yi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+const (
+	// 环境变量名
+	TokenEnvVarName = "YI_API_KEY" //nolint:gosec
+	ModelEnvVarName = "YI_MODEL"   //nolint:gosec
+
+	// OpenAI兼容模式基础URL
+	OpenAICompatibleBaseURL = "https://api.lingyiwanwu.com/v1"
+	// 默认模型
+	DefaultModel = ModelYiLightning
+)
+
+const (
+	// ModelYiLightning 是 yi-lightning 高性能模型，速度快、价格低
+	ModelYiLightning = "yi-lightning"
+
+	// ModelYiLarge 是 yi-large 千亿参数模型
+	ModelYiLarge = "yi-large"
+
+	// ModelYiLargeTurbo 是 yi-large-turbo 模型，兼顾效果与推理速度
+	ModelYiLargeTurbo = "yi-large-turbo"
+
+	// ModelYiLargeFC 是 yi-large-fc 模型，增强了工具调用能力
+	ModelYiLargeFC = "yi-large-fc"
+
+	// ModelYiMedium 是 yi-medium 中型模型
+	ModelYiMedium = "yi-medium"
+
+	// ModelYiMedium200K 是 yi-medium-200k 长上下文模型
+	ModelYiMedium200K = "yi-medium-200k"
+
+	// ModelYiSpark 是 yi-spark 轻量级模型
+	ModelYiSpark = "yi-spark"
+
+	// ModelYiVision 是 yi-vision 多模态模型，支持图片输入
+	ModelYiVision = "yi-vision"
+)
+
+// LLM 是零一万物 Yi 大语言模型的实现
+type LLM struct {
+	*openai.LLM // 匿名嵌入OpenAI LLM，自动继承其所有方法
+}
+
+// Option 是LLM的配置选项函数类型
+type Option func(*options)
+
+// options 是LLM的配置选项
+type options struct {
+	apiKey      string
+	baseURL     string
+	model       string
+	httpClient  *http.Client
+	retryPolicy retry.Policy
+	rateLimiter *ratelimit.Limiter
+}
+
+// WithAPIKey 设置API密钥
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithBaseURL 设置API基础URL
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithModel 设置模型
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+// WithHTTPClient 设置自定义的HTTP客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
+// defaultOptions 返回默认选项
+func defaultOptions() options {
+	return options{
+		apiKey:  os.Getenv(TokenEnvVarName),
+		baseURL: OpenAICompatibleBaseURL,
+		model:   getEnvOrDefault(ModelEnvVarName, DefaultModel),
+	}
+}
+
+// getEnvOrDefault 获取环境变量值，如果不存在则返回默认值
+func getEnvOrDefault(envVar, defaultValue string) string {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// New 创建一个新的零一万物 Yi LLM实例
+func New(opts ...Option) (*LLM, error) {
+	options := defaultOptions()
+
+	// 应用选项
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// 验证API密钥
+	if options.apiKey == "" {
+		return nil, errors.New("API密钥不能为空，请设置YI_API_KEY环境变量或使用WithAPIKey选项")
+	}
+
+	httpClient := options.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// 创建OpenAI客户端
+	openaiLLM, err := openai.New(
+		openai.WithToken(options.apiKey),
+		openai.WithModel(options.model),
+		openai.WithBaseURL(options.baseURL),
+		openai.WithHTTPClient(retry.Wrap(ratelimit.Wrap(httpClient, options.rateLimiter), options.retryPolicy)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("创建OpenAI客户端失败: %w", err)
+	}
+
+	return &LLM{LLM: openaiLLM}, nil
+}
+
+// GetModels 返回零一万物支持的模型列表
+func (y *LLM) GetModels() []string {
+	return []string{
+		ModelYiLightning,
+		ModelYiLarge,
+		ModelYiLargeTurbo,
+		ModelYiLargeFC,
+		ModelYiMedium,
+		ModelYiMedium200K,
+		ModelYiSpark,
+		ModelYiVision,
+	}
+}
+
+// GenerateContent 生成内容，支持流式输出、工具调用和图片输入（yi-vision）
+func (y *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	resp, err := y.LLM.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	// openai 客户端只写入 PromptTokens 等键，补充统一的用量键
+	usage.Normalize(resp)
+	return resp, nil
+}
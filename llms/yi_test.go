@@ -0,0 +1,47 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/yi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestYiLLM(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("YI_MODEL", "")
+
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"y-1","object":"chat.completion","created":1,"model":"yi-lightning","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"你好，我是Yi"}}],"usage":{"prompt_tokens":7,"completion_tokens":4,"total_tokens":11}}`))
+	}))
+	defer server.Close()
+
+	llm, err := llmscn.CreateLLM(llmscn.YiLLM, map[string]interface{}{"api_key": "test-key", "base_url": server.URL})
+	require.NoError(t, err)
+
+	resp, err := llm.GenerateContent(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")})
+	require.NoError(t, err)
+	assert.Equal(t, "你好，我是Yi", resp.Choices[0].Content)
+	assertTokenUsage(t, resp.Choices[0].GenerationInfo, 7, 4, 11)
+
+	_, err = llm.Call(ctx, "你好", llms.WithModel(yi.ModelYiLarge))
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, yi.DefaultModel, requests[0]["model"])
+	assert.Equal(t, yi.ModelYiLarge, requests[1]["model"])
+}
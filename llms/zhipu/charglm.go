@@ -0,0 +1,130 @@
+package zhipu
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// characterMetaKey 是角色扮演元信息在 CallOptions.Metadata 中的键
+const characterMetaKey = "zhipu:meta"
+
+// requestTagsKey 是请求标签在 CallOptions.Metadata 中的键，与 llmscn.RequestTagsKey 保持一致
+const requestTagsKey = "request_tags"
+
+// CharacterMeta 是 charglm 角色扮演模型的 meta 参数
+type CharacterMeta struct {
+	// UserInfo 用户信息描述
+	UserInfo string `json:"user_info"`
+	// BotInfo 角色信息描述，可在此描述角色的性格与情感基调
+	BotInfo string `json:"bot_info"`
+	// BotName 角色名称
+	BotName string `json:"bot_name"`
+	// UserName 用户名称
+	UserName string `json:"user_name"`
+}
+
+// WithCharacterMeta 设置 charglm 角色扮演模型的 meta 参数
+// 仅在使用 charglm 系列模型时生效
+func WithCharacterMeta(meta CharacterMeta) llms.CallOption {
+	return func(o *llms.CallOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]interface{})
+		}
+		o.Metadata[characterMetaKey] = meta
+	}
+}
+
+// metaClient 将 CallOptions 中的角色扮演元信息改写为智谱API要求的顶层 meta 字段，
+// 并将请求标签中的 user 标签转发为 user_id 字段；携带工具的流式请求会改写响应中的工具调用增量
+type metaClient struct {
+	client *http.Client
+	signer auth.Provider
+}
+
+// Do 实现 openai 客户端的 Doer 接口
+func (c *metaClient) Do(req *http.Request) (*http.Response, error) {
+	var toolStream bool
+	if req.Body != nil && req.Method == http.MethodPost {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		data = rewriteCharacterMeta(data)
+		toolStream = streamsTools(data)
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+	}
+	if err := auth.Apply(c.signer, req); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil || !toolStream || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	resp.Body = newToolCallStream(resp.Body)
+	return resp, nil
+}
+
+// rewriteCharacterMeta 将 metadata 中的角色扮演元信息移动到 meta 字段，非 charglm 模型直接丢弃，
+// 移除请求标签并将其中的 user 标签写入 user_id 字段
+// 请求体无法解析或不包含上述字段时原样返回
+func rewriteCharacterMeta(data []byte) []byte {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return data
+	}
+
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal(body["metadata"], &metadata); err != nil {
+		return data
+	}
+	meta, hasMeta := metadata[characterMetaKey]
+	rawTags, hasTags := metadata[requestTagsKey]
+	if !hasMeta && !hasTags {
+		return data
+	}
+
+	if hasMeta {
+		if isCharGLM(body["model"]) {
+			body["meta"] = meta
+		}
+		delete(metadata, characterMetaKey)
+	}
+	if hasTags {
+		// 智谱API不接受 metadata 字段，标签仅用于本地记录，user 标签转发为 user_id
+		var tags map[string]string
+		if err := json.Unmarshal(rawTags, &tags); err == nil && tags["user"] != "" {
+			if encoded, err := json.Marshal(tags["user"]); err == nil {
+				body["user_id"] = encoded
+			}
+		}
+		delete(metadata, requestTagsKey)
+	}
+	if len(metadata) == 0 {
+		delete(body, "metadata")
+	} else if encoded, err := json.Marshal(metadata); err == nil {
+		body["metadata"] = encoded
+	}
+
+	rewritten, err := json.Marshal(body)
+	if err != nil {
+		return data
+	}
+	return rewritten
+}
+
+// isCharGLM 判断请求体中的模型是否为 charglm 系列，只有该系列接受 meta 字段
+func isCharGLM(rawModel json.RawMessage) bool {
+	var model string
+	if err := json.Unmarshal(rawModel, &model); err != nil {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(model), "charglm")
+}
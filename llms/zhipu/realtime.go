@@ -0,0 +1,312 @@
+package zhipu
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	// RealtimeURL 实时音视频接口地址
+	RealtimeURL = "wss://open.bigmodel.cn/api/paas/v4/realtime"
+	// ModelGLMRealtime 是智谱GLM-Realtime实时语音模型
+	ModelGLMRealtime = "glm-realtime"
+)
+
+// 客户端事件类型
+const (
+	RealtimeEventSessionUpdate      = "session.update"
+	RealtimeEventAudioAppend        = "input_audio_buffer.append"
+	RealtimeEventAudioCommit        = "input_audio_buffer.commit"
+	RealtimeEventAudioClear         = "input_audio_buffer.clear"
+	RealtimeEventConversationCreate = "conversation.item.create"
+	RealtimeEventResponseCreate     = "response.create"
+	RealtimeEventResponseCancel     = "response.cancel"
+)
+
+// 服务端事件类型
+const (
+	RealtimeEventSessionCreated       = "session.created"
+	RealtimeEventSessionUpdated       = "session.updated"
+	RealtimeEventSpeechStarted        = "input_audio_buffer.speech_started"
+	RealtimeEventSpeechStopped        = "input_audio_buffer.speech_stopped"
+	RealtimeEventInputTranscription   = "conversation.item.input_audio_transcription.completed"
+	RealtimeEventAudioDelta           = "response.audio.delta"
+	RealtimeEventAudioTranscriptDelta = "response.audio_transcript.delta"
+	RealtimeEventTextDelta            = "response.text.delta"
+	RealtimeEventResponseDone         = "response.done"
+	RealtimeEventError                = "error"
+)
+
+// RealtimeConfig 实时语音会话配置
+type RealtimeConfig struct {
+	// APIKey API密钥，默认读取 ZHIPU_API_KEY 环境变量
+	APIKey string
+	// URL 接口地址，默认为 RealtimeURL
+	URL string
+	// Model 模型名称，默认为 ModelGLMRealtime
+	Model string
+	// Instructions 系统指令
+	Instructions string
+	// Voice 音色
+	Voice string
+	// InputAudioFormat 输入音频格式，默认 wav
+	InputAudioFormat string
+	// OutputAudioFormat 输出音频格式，默认 pcm
+	OutputAudioFormat string
+	// ClientVAD 为 true 时由客户端调用 CommitAudio 结束一轮发言，否则使用服务端语音活动检测
+	ClientVAD bool
+	// HTTPClient 建立 WebSocket 连接使用的HTTP客户端
+	HTTPClient *http.Client
+}
+
+// RealtimeEvent 服务端事件
+type RealtimeEvent struct {
+	// Type 事件类型
+	Type string `json:"type"`
+	// EventID 事件ID
+	EventID string `json:"event_id,omitempty"`
+	// Delta 文本或音频增量；音频为 base64 编码，可通过 Audio 方法解码
+	Delta string `json:"delta,omitempty"`
+	// Transcript 用户语音的识别结果（conversation.item.input_audio_transcription.completed）
+	Transcript string `json:"transcript,omitempty"`
+	// Error 错误信息（error 事件）
+	Error *RealtimeError `json:"error,omitempty"`
+	// Raw 原始事件内容，用于读取未解析的字段
+	Raw json.RawMessage `json:"-"`
+}
+
+// Audio 解码 response.audio.delta 事件中的音频数据
+func (e RealtimeEvent) Audio() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(e.Delta)
+}
+
+// RealtimeError 实时接口返回的错误
+type RealtimeError struct {
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error 实现 error 接口
+func (e *RealtimeError) Error() string {
+	return fmt.Sprintf("智谱实时接口错误 %s: %s", e.Code, e.Message)
+}
+
+// RealtimeSession 实时语音会话
+// 通过 WebSocket 双向流式传输：SendAudio 发送用户音频，Events 接收模型的音频、文本与识别结果。
+// 识别结果相当于语音识别（ASR），音频增量相当于语音合成（TTS），可直接接入电话或语音助手链路。
+type RealtimeSession struct {
+	conn      *websocket.Conn
+	events    chan RealtimeEvent
+	done      chan struct{}
+	closeOnce sync.Once
+	writeMu   sync.Mutex
+
+	errMu sync.Mutex
+	err   error
+}
+
+// DialRealtime 建立实时语音会话，需要账户已开通实时音视频接口
+func DialRealtime(ctx context.Context, config RealtimeConfig) (*RealtimeSession, error) {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv(TokenEnvVarName)
+	}
+	if config.APIKey == "" {
+		return nil, errors.New("API密钥不能为空，请设置ZHIPU_API_KEY环境变量或配置APIKey")
+	}
+	if config.URL == "" {
+		config.URL = RealtimeURL
+	}
+	if config.Model == "" {
+		config.Model = ModelGLMRealtime
+	}
+	if config.InputAudioFormat == "" {
+		config.InputAudioFormat = "wav"
+	}
+	if config.OutputAudioFormat == "" {
+		config.OutputAudioFormat = "pcm"
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+config.APIKey)
+	conn, _, err := websocket.Dial(ctx, config.URL, &websocket.DialOptions{
+		HTTPClient: config.HTTPClient,
+		HTTPHeader: header,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("连接智谱实时接口失败: %w", err)
+	}
+	// 音频增量事件可能较大
+	conn.SetReadLimit(16 << 20)
+
+	s := &RealtimeSession{
+		conn:   conn,
+		events: make(chan RealtimeEvent, 64),
+		done:   make(chan struct{}),
+	}
+	go s.readLoop()
+
+	session := map[string]interface{}{
+		"model":               config.Model,
+		"input_audio_format":  config.InputAudioFormat,
+		"output_audio_format": config.OutputAudioFormat,
+	}
+	if config.Instructions != "" {
+		session["instructions"] = config.Instructions
+	}
+	if config.Voice != "" {
+		session["voice"] = config.Voice
+	}
+	if config.ClientVAD {
+		session["turn_detection"] = map[string]interface{}{"type": "client_vad"}
+	} else {
+		session["turn_detection"] = map[string]interface{}{"type": "server_vad"}
+	}
+	if err := s.send(ctx, RealtimeEventSessionUpdate, map[string]interface{}{"session": session}); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Events 返回服务端事件通道，会话关闭或连接断开后通道关闭，可通过 Err 获取断开原因
+func (s *RealtimeSession) Events() <-chan RealtimeEvent {
+	return s.events
+}
+
+// Err 返回导致事件通道关闭的错误，正常关闭时返回 nil
+func (s *RealtimeSession) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// SendAudio 发送一段用户音频
+func (s *RealtimeSession) SendAudio(ctx context.Context, chunk []byte) error {
+	return s.send(ctx, RealtimeEventAudioAppend, map[string]interface{}{
+		"audio": base64.StdEncoding.EncodeToString(chunk),
+	})
+}
+
+// StreamAudio 从音频源（麦克风、电话线路等）持续读取并发送音频，直到读完或出错
+func (s *RealtimeSession) StreamAudio(ctx context.Context, r io.Reader, chunkSize int) error {
+	if chunkSize <= 0 {
+		chunkSize = 3200 // 16kHz 16bit 单声道 100ms
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if sendErr := s.SendAudio(ctx, buf[:n]); sendErr != nil {
+				return sendErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取音频失败: %w", err)
+		}
+	}
+}
+
+// CommitAudio 结束本轮用户发言并请求模型回复，仅在 ClientVAD 模式下需要调用
+func (s *RealtimeSession) CommitAudio(ctx context.Context) error {
+	if err := s.send(ctx, RealtimeEventAudioCommit, nil); err != nil {
+		return err
+	}
+	return s.send(ctx, RealtimeEventResponseCreate, nil)
+}
+
+// SendText 以文本形式发送一轮用户输入并请求模型回复
+func (s *RealtimeSession) SendText(ctx context.Context, text string) error {
+	err := s.send(ctx, RealtimeEventConversationCreate, map[string]interface{}{
+		"item": map[string]interface{}{
+			"type": "message",
+			"role": "user",
+			"content": []map[string]interface{}{
+				{"type": "input_text", "text": text},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, RealtimeEventResponseCreate, nil)
+}
+
+// CancelResponse 打断正在生成的回复，例如检测到用户插话时
+func (s *RealtimeSession) CancelResponse(ctx context.Context) error {
+	return s.send(ctx, RealtimeEventResponseCancel, nil)
+}
+
+// Close 关闭会话
+func (s *RealtimeSession) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.conn.Close(websocket.StatusNormalClosure, "")
+	})
+	return err
+}
+
+// send 发送客户端事件
+func (s *RealtimeSession) send(ctx context.Context, eventType string, fields map[string]interface{}) error {
+	event := map[string]interface{}{"type": eventType}
+	for k, v := range fields {
+		event[k] = v
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.Write(ctx, websocket.MessageText, data); err != nil {
+		return fmt.Errorf("发送 %s 事件失败: %w", eventType, err)
+	}
+	return nil
+}
+
+// readLoop 持续读取服务端事件，直到连接关闭
+func (s *RealtimeSession) readLoop() {
+	defer close(s.events)
+	for {
+		_, data, err := s.conn.Read(context.Background())
+		if err != nil {
+			select {
+			case <-s.done:
+				// 主动关闭
+				return
+			default:
+			}
+			if websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+				s.errMu.Lock()
+				s.err = err
+				s.errMu.Unlock()
+			}
+			return
+		}
+
+		var event RealtimeEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			continue
+		}
+		event.Raw = data
+		select {
+		case s.events <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package zhipu
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/toolcall"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// toolChoiceAuto 是智谱API唯一支持的 tool_choice 取值
+const toolChoiceAuto = "auto"
+
+// withGLMToolChoice 将工具选择调整为智谱API支持的形式，需放在调用选项的最后：
+// "none" 时不发送工具；指定函数时只发送该函数，由模型自行决定是否调用；
+// 其余取值（如 "required"）统一改为 "auto"
+func withGLMToolChoice() llms.CallOption {
+	return func(o *llms.CallOptions) {
+		if len(o.Tools) == 0 && len(o.Functions) == 0 {
+			o.ToolChoice = nil
+			return
+		}
+		if o.ToolChoice == nil {
+			return
+		}
+
+		mode, name := parseToolChoice(o.ToolChoice)
+		if mode == "none" {
+			o.Tools = nil
+			o.Functions = nil
+			o.ToolChoice = nil
+			return
+		}
+		if name != "" {
+			narrowTools(o, name)
+		}
+		o.ToolChoice = toolChoiceAuto
+	}
+}
+
+// parseToolChoice 解析工具选择，返回选择方式和指定的函数名称
+func parseToolChoice(choice interface{}) (mode string, name string) {
+	switch c := choice.(type) {
+	case string:
+		return c, ""
+	case llms.ToolChoice:
+		if c.Function != nil {
+			return c.Type, c.Function.Name
+		}
+		return c.Type, ""
+	case *llms.ToolChoice:
+		if c != nil {
+			return parseToolChoice(*c)
+		}
+	}
+	return "", ""
+}
+
+// narrowTools 只保留指定名称的工具和函数，没有匹配时保持不变
+func narrowTools(o *llms.CallOptions, name string) {
+	var tools []llms.Tool
+	for _, tool := range o.Tools {
+		if tool.Function != nil && tool.Function.Name == name {
+			tools = append(tools, tool)
+		}
+	}
+	var functions []llms.FunctionDefinition
+	for _, fn := range o.Functions {
+		if fn.Name == name {
+			functions = append(functions, fn)
+		}
+	}
+	if len(tools) == 0 && len(functions) == 0 {
+		return
+	}
+	o.Tools = tools
+	o.Functions = functions
+}
+
+// functionToToolMessage 将 function 角色的消息转换为 tool 消息，智谱API不支持 function 角色
+// 调用ID按函数名称从之前的工具调用中查找
+func functionToToolMessage(msg llms.MessageContent, callIDs map[string]string) llms.MessageContent {
+	if len(msg.Parts) != 1 {
+		return msg
+	}
+	resp, ok := msg.Parts[0].(llms.ToolCallResponse)
+	if !ok {
+		return msg
+	}
+	if resp.ToolCallID == "" {
+		resp.ToolCallID = callIDs[resp.Name]
+	}
+	return llms.MessageContent{
+		Role:  llms.ChatMessageTypeTool,
+		Parts: []llms.ContentPart{resp},
+	}
+}
+
+// ================================
+// 流式工具调用
+// ================================
+
+// streamsTools 判断请求体是否为携带工具的流式请求
+func streamsTools(data []byte) bool {
+	var body struct {
+		Stream bool              `json:"stream"`
+		Tools  []json.RawMessage `json:"tools"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return false
+	}
+	return body.Stream && len(body.Tools) > 0
+}
+
+// streamToolCall OpenAI 兼容格式的工具调用
+type streamToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// toolCallStream 改写流式响应中的工具调用增量
+// 智谱的工具调用增量可能带有 index、拆分参数或以JSON对象给出参数，openai 客户端只会按顺序拼接，
+// 因此先用 toolcall.Assembler 累积，在带有 finish_reason 的数据块中一次性给出完整的工具调用
+type toolCallStream struct {
+	body      io.ReadCloser
+	reader    *bufio.Reader
+	pending   bytes.Buffer
+	assembler *toolcall.Assembler
+	flushed   bool
+	eof       bool
+}
+
+// newToolCallStream 包装流式响应体
+func newToolCallStream(body io.ReadCloser) *toolCallStream {
+	return &toolCallStream{
+		body:      body,
+		reader:    bufio.NewReader(body),
+		assembler: toolcall.New(),
+	}
+}
+
+// Read 实现 io.Reader 接口
+func (s *toolCallStream) Read(p []byte) (int, error) {
+	for s.pending.Len() == 0 && !s.eof {
+		line, err := s.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			s.pending.Write(s.rewriteLine(line))
+		}
+		if errors.Is(err, io.EOF) {
+			s.eof = true
+			s.pending.Write(s.flush())
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	if s.pending.Len() == 0 {
+		return 0, io.EOF
+	}
+	return s.pending.Read(p)
+}
+
+// Close 实现 io.Closer 接口
+func (s *toolCallStream) Close() error {
+	return s.body.Close()
+}
+
+// rewriteLine 累积数据行中的工具调用增量，并在结束的数据块中写入完整的工具调用
+// 只携带工具调用增量的数据行被丢弃，无法解析的行原样返回
+func (s *toolCallStream) rewriteLine(line []byte) []byte {
+	data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+	if !ok {
+		return line
+	}
+	data = bytes.TrimSpace(data)
+	if string(data) == "[DONE]" {
+		return append(s.flush(), line...)
+	}
+
+	var chunk map[string]json.RawMessage
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return line
+	}
+	var choices []map[string]json.RawMessage
+	if err := json.Unmarshal(chunk["choices"], &choices); err != nil || len(choices) == 0 {
+		return line
+	}
+	var delta map[string]json.RawMessage
+	if err := json.Unmarshal(choices[0]["delta"], &delta); err != nil {
+		return line
+	}
+
+	raw, hasToolCalls := delta["tool_calls"]
+	if hasToolCalls {
+		if err := s.assembler.AddRaw(raw); err != nil {
+			return line
+		}
+		delete(delta, "tool_calls")
+	}
+
+	var finishReason string
+	_ = json.Unmarshal(choices[0]["finish_reason"], &finishReason)
+	if finishReason != "" && !s.flushed && s.assembler.Len() > 0 {
+		encoded, err := json.Marshal(s.toolCalls())
+		if err != nil {
+			return line
+		}
+		delta["tool_calls"] = encoded
+		s.flushed = true
+	} else if !hasToolCalls {
+		return line
+	}
+
+	if finishReason == "" && !hasContent(delta) && isNull(chunk["usage"]) {
+		return nil
+	}
+
+	encodedDelta, err := json.Marshal(delta)
+	if err != nil {
+		return line
+	}
+	choices[0]["delta"] = encodedDelta
+	encodedChoices, err := json.Marshal(choices)
+	if err != nil {
+		return line
+	}
+	chunk["choices"] = encodedChoices
+	rewritten, err := json.Marshal(chunk)
+	if err != nil {
+		return line
+	}
+	return append(append([]byte("data: "), rewritten...), '\n')
+}
+
+// flush 在流结束前未遇到 finish_reason 时，以单独的数据块给出累积的工具调用
+func (s *toolCallStream) flush() []byte {
+	if s.flushed || s.assembler.Len() == 0 {
+		return nil
+	}
+	s.flushed = true
+
+	chunk := map[string]interface{}{
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"delta":         map[string]interface{}{"tool_calls": s.toolCalls()},
+			"finish_reason": "tool_calls",
+		}},
+	}
+	encoded, err := json.Marshal(chunk)
+	if err != nil {
+		return nil
+	}
+	return append(append([]byte("data: "), encoded...), '\n', '\n')
+}
+
+// toolCalls 返回累积的工具调用
+func (s *toolCallStream) toolCalls() []streamToolCall {
+	calls := s.assembler.ToolCalls()
+	result := make([]streamToolCall, len(calls))
+	for i, call := range calls {
+		result[i].ID = call.ID
+		result[i].Type = call.Type
+		result[i].Function.Name = call.FunctionCall.Name
+		result[i].Function.Arguments = call.FunctionCall.Arguments
+	}
+	return result
+}
+
+// hasContent 判断增量中是否还有除角色外的非空字段
+func hasContent(delta map[string]json.RawMessage) bool {
+	for key, value := range delta {
+		if key == "role" || isNull(value) || string(value) == `""` {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// isNull 判断JSON值是否为空
+func isNull(value json.RawMessage) bool {
+	return len(value) == 0 || string(value) == "null"
+}
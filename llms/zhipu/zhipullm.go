@@ -4,8 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/auth"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/ratelimit"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/retry"
+	"github.com/sjzsdu/langchaingo-cn/llms/internal/usage"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 )
@@ -65,6 +71,9 @@ type options struct {
 	baseURL        string
 	model          string
 	embeddingModel string
+	authProvider   auth.Provider
+	retryPolicy    retry.Policy
+	rateLimiter    *ratelimit.Limiter
 }
 
 // WithAPIKey 设置API密钥
@@ -81,6 +90,37 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithAuthProvider 设置自定义认证方式，如 AK/SK 签名或自定义认证头，
+// 设置后不再要求API密钥，请求在发送前由 provider 签名
+func WithAuthProvider(provider auth.Provider) Option {
+	return func(o *options) {
+		o.authProvider = provider
+	}
+}
+
+// WithMaxRetries 设置请求遇到限流（429）、服务端错误（5xx）或超时时的最大重试次数，默认不重试
+// 重试按带抖动的指数退避等待，响应带有 Retry-After 时按其等待
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.retryPolicy.MaxRetries = n
+	}
+}
+
+// WithRetryBackoff 设置首次重试前的等待时间，之后每次翻倍，默认 500ms
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryPolicy.Backoff = backoff
+	}
+}
+
+// WithRateLimiter 设置限流器，可在多个客户端之间共享，见 llmscn.NewRateLimiter
+// 每次请求（包括重试）发送前先获取令牌，排队超限时返回 llmscn.ErrRateLimited
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+	}
+}
+
 // WithModel 设置模型
 func WithModel(model string) Option {
 	return func(o *options) {
@@ -99,6 +139,7 @@ func WithEmbeddingModel(model string) Option {
 func defaultOptions() options {
 	return options{
 		apiKey:         os.Getenv(TokenEnvVarName),
+		baseURL:        OpenAICompatibleBaseURL,
 		model:          getEnvOrDefault(ModelEnvVarName, DefaultModel),
 		embeddingModel: getEnvOrDefault(EmbeddingModelEnvVarName, DefaultEmbeddingModel),
 	}
@@ -123,16 +164,23 @@ func New(opts ...Option) (*LLM, error) {
 	}
 
 	// 验证API密钥
-	if options.apiKey == "" {
+	if options.apiKey == "" && options.authProvider == nil {
 		return nil, errors.New("API密钥不能为空，请设置ZHIPU_API_KEY环境变量或使用WithAPIKey选项")
 	}
 
+	// 使用自定义认证方式时令牌仅作为占位，发送前会被替换
+	token := options.apiKey
+	if token == "" {
+		token = "none"
+	}
+
 	// 创建OpenAI客户端
 	openaiOpts := []openai.Option{
-		openai.WithToken(options.apiKey),
+		openai.WithToken(token),
 		openai.WithModel(options.model),
-		openai.WithBaseURL(OpenAICompatibleBaseURL),
+		openai.WithBaseURL(options.baseURL),
 		openai.WithEmbeddingModel(options.embeddingModel),
+		openai.WithHTTPClient(retry.Wrap(ratelimit.Wrap(&metaClient{client: http.DefaultClient, signer: options.authProvider}, options.rateLimiter), options.retryPolicy)),
 	}
 
 	openaiLLM, err := openai.New(openaiOpts...)
@@ -157,13 +205,26 @@ func (z *LLM) GetModels() []string {
 	}
 }
 
-// GenerateContent 重写生成内容方法，自动处理system消息转换
+// GenerateContent 重写生成内容方法，自动处理system消息转换，
+// 并将工具调用相关的消息和选项调整为智谱API支持的形式
 func (z *LLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
 	// 转换system消息为user消息，因为智谱AI不支持system角色
 	convertedMessages := make([]llms.MessageContent, 0, len(messages))
+	// 按函数名称记录工具调用ID，用于转换 function 角色的消息
+	callIDs := make(map[string]string)
 
 	for _, msg := range messages {
-		if msg.Role == llms.ChatMessageTypeSystem {
+		if msg.Role == llms.ChatMessageTypeAI {
+			for _, part := range msg.Parts {
+				if call, ok := part.(llms.ToolCall); ok && call.FunctionCall != nil {
+					callIDs[call.FunctionCall.Name] = call.ID
+				}
+			}
+		}
+
+		if msg.Role == llms.ChatMessageTypeFunction {
+			convertedMessages = append(convertedMessages, functionToToolMessage(msg, callIDs))
+		} else if msg.Role == llms.ChatMessageTypeSystem {
 			// 将system消息转换为user消息，并在内容前添加提示
 			convertedMsg := llms.MessageContent{
 				Role:  llms.ChatMessageTypeHuman,
@@ -192,6 +253,13 @@ func (z *LLM) GenerateContent(ctx context.Context, messages []llms.MessageConten
 		}
 	}
 
-	// 调用父类方法
-	return z.LLM.GenerateContent(ctx, convertedMessages, options...)
+	// 调用父类方法，工具选择的调整需在其他选项之后应用
+	options = append(options[:len(options):len(options)], withGLMToolChoice())
+	resp, err := z.LLM.GenerateContent(ctx, convertedMessages, options...)
+	if err != nil {
+		return nil, err
+	}
+	// openai 客户端只写入 PromptTokens 等键，补充统一的用量键
+	usage.Normalize(resp)
+	return resp, nil
 }
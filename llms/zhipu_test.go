@@ -0,0 +1,221 @@
+package llms_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// zhipuServer 模拟智谱接口，记录请求体并返回预设的回复
+// 流式请求时 chunks 逐个作为 SSE 数据块写出
+type zhipuServer struct {
+	*httptest.Server
+	requests []map[string]any
+	reply    string
+	chunks   []string
+}
+
+func newZhipuServer(t *testing.T) *zhipuServer {
+	s := &zhipuServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		s.requests = append(s.requests, body)
+
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, chunk := range s.chunks {
+				fmt.Fprintf(w, "data: %s\n\n", chunk)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(s.reply))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *zhipuServer) newLLM(t *testing.T, model string) *zhipu.LLM {
+	llm, err := zhipu.New(zhipu.WithAPIKey("test-key"), zhipu.WithBaseURL(s.URL), zhipu.WithModel(model))
+	require.NoError(t, err)
+	return llm
+}
+
+var zhipuTools = []llms.Tool{
+	{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:       "get_weather",
+			Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		},
+	},
+	{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:       "get_time",
+			Parameters: map[string]any{"type": "object", "properties": map[string]any{"tz": map[string]any{"type": "string"}}},
+		},
+	},
+}
+
+func TestZhipuToolCalls(t *testing.T) {
+	ctx := context.Background()
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "北京天气和时间")}
+	streaming := llms.WithStreamingFunc(func(context.Context, []byte) error { return nil })
+
+	t.Run("流式参数分片且index交错", func(t *testing.T) {
+		server := newZhipuServer(t)
+		server.chunks = []string{
+			`{"id":"z-1","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get_weather","arguments":"{\"ci"}}]}}]}`,
+			`{"id":"z-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"get_time","arguments":"{\"tz\":"}}]}}]}`,
+			`{"id":"z-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ty\":\"北京\"}"}}]}}]}`,
+			`{"id":"z-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"\"Asia/Shanghai\"}"}}]}}]}`,
+			`{"id":"z-1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+			`[DONE]`,
+		}
+
+		resp, err := server.newLLM(t, zhipu.ModelGLM4).GenerateContent(ctx, messages, llms.WithTools(zhipuTools), streaming)
+		require.NoError(t, err)
+		calls := resp.Choices[0].ToolCalls
+		require.Len(t, calls, 2)
+		assert.Equal(t, "call_a", calls[0].ID)
+		assert.Equal(t, "get_weather", calls[0].FunctionCall.Name)
+		assert.JSONEq(t, `{"city":"北京"}`, calls[0].FunctionCall.Arguments)
+		assert.Equal(t, "call_b", calls[1].ID)
+		assert.Equal(t, "get_time", calls[1].FunctionCall.Name)
+		assert.JSONEq(t, `{"tz":"Asia/Shanghai"}`, calls[1].FunctionCall.Arguments)
+	})
+
+	t.Run("流式参数为JSON对象", func(t *testing.T) {
+		server := newZhipuServer(t)
+		server.chunks = []string{
+			`{"id":"z-2","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_c","type":"function","function":{"name":"get_weather","arguments":{"city":"上海"}}}]}}]}`,
+			`{"id":"z-2","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+			`[DONE]`,
+		}
+
+		resp, err := server.newLLM(t, zhipu.ModelGLM4).GenerateContent(ctx, messages, llms.WithTools(zhipuTools), streaming)
+		require.NoError(t, err)
+		calls := resp.Choices[0].ToolCalls
+		require.Len(t, calls, 1)
+		assert.Equal(t, "call_c", calls[0].ID)
+		assert.JSONEq(t, `{"city":"上海"}`, calls[0].FunctionCall.Arguments)
+	})
+
+	t.Run("流结束时没有finish_reason", func(t *testing.T) {
+		server := newZhipuServer(t)
+		server.chunks = []string{
+			`{"id":"z-3","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_d","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`,
+			`{"id":"z-3","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_e","type":"function","function":{"name":"get_time","arguments":{"tz":"Asia/Shanghai"}}}]}}]}`,
+			`{"id":"z-3","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"广州\"}"}}]}}]}`,
+		}
+
+		resp, err := server.newLLM(t, zhipu.ModelGLM4).GenerateContent(ctx, messages, llms.WithTools(zhipuTools), streaming)
+		require.NoError(t, err)
+		calls := resp.Choices[0].ToolCalls
+		require.Len(t, calls, 2)
+		assert.Equal(t, "call_d", calls[0].ID)
+		assert.JSONEq(t, `{"city":"广州"}`, calls[0].FunctionCall.Arguments)
+		assert.Equal(t, "call_e", calls[1].ID)
+		assert.JSONEq(t, `{"tz":"Asia/Shanghai"}`, calls[1].FunctionCall.Arguments)
+	})
+
+	t.Run("工具选择", func(t *testing.T) {
+		server := newZhipuServer(t)
+		server.reply = `{"id":"z-4","object":"chat.completion","created":1,"model":"glm-4","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"好的"}}]}`
+		llm := server.newLLM(t, zhipu.ModelGLM4)
+
+		_, err := llm.GenerateContent(ctx, messages, llms.WithTools(zhipuTools), llms.WithToolChoice("none"))
+		require.NoError(t, err)
+		_, err = llm.GenerateContent(ctx, messages, llms.WithTools(zhipuTools),
+			llms.WithToolChoice(llms.ToolChoice{Type: "function", Function: &llms.FunctionReference{Name: "get_time"}}))
+		require.NoError(t, err)
+		_, err = llm.GenerateContent(ctx, messages, llms.WithTools(zhipuTools), llms.WithToolChoice("required"))
+		require.NoError(t, err)
+
+		require.Len(t, server.requests, 3)
+		// none 时不发送工具
+		assert.NotContains(t, server.requests[0], "tools")
+		assert.NotContains(t, server.requests[0], "tool_choice")
+		// 指定函数时只发送该函数
+		tools := server.requests[1]["tools"].([]any)
+		require.Len(t, tools, 1)
+		assert.Equal(t, "get_time", tools[0].(map[string]any)["function"].(map[string]any)["name"])
+		assert.Equal(t, "auto", server.requests[1]["tool_choice"])
+		// 其余取值改为 auto
+		assert.Len(t, server.requests[2]["tools"], 2)
+		assert.Equal(t, "auto", server.requests[2]["tool_choice"])
+	})
+
+	t.Run("非流式工具调用往返", func(t *testing.T) {
+		server := newZhipuServer(t)
+		server.reply = `{"id":"z-5","object":"chat.completion","created":1,"model":"glm-4","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"北京\"}"}}]}}]}`
+		llm := server.newLLM(t, zhipu.ModelGLM4)
+
+		resp, err := llm.GenerateContent(ctx, messages, llms.WithTools(zhipuTools))
+		require.NoError(t, err)
+		require.Len(t, resp.Choices[0].ToolCalls, 1)
+		call := resp.Choices[0].ToolCalls[0]
+		assert.Equal(t, "call_1", call.ID)
+
+		// function 角色的回复没有调用ID，按函数名称从之前的工具调用中查找
+		server.reply = `{"id":"z-6","object":"chat.completion","created":1,"model":"glm-4","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"北京晴"}}]}`
+		history := append(messages,
+			llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+			llms.MessageContent{Role: llms.ChatMessageTypeFunction, Parts: []llms.ContentPart{llms.ToolCallResponse{Name: "get_weather", Content: "晴"}}},
+		)
+		resp, err = llm.GenerateContent(ctx, history, llms.WithTools(zhipuTools))
+		require.NoError(t, err)
+		assert.Equal(t, "北京晴", resp.Choices[0].Content)
+
+		require.Len(t, server.requests, 2)
+		sent := server.requests[1]["messages"].([]any)
+		require.Len(t, sent, 3)
+		toolMessage := sent[2].(map[string]any)
+		assert.Equal(t, "tool", toolMessage["role"])
+		assert.Equal(t, "call_1", toolMessage["tool_call_id"])
+		assert.Equal(t, "晴", toolMessage["content"])
+	})
+}
+
+func TestZhipuCharacterMeta(t *testing.T) {
+	ctx := context.Background()
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "你好")}
+	meta := zhipu.WithCharacterMeta(zhipu.CharacterMeta{
+		UserInfo: "我是一名学生",
+		BotInfo:  "苏梦远，温柔耐心的老师",
+		BotName:  "苏梦远",
+		UserName: "小明",
+	})
+
+	server := newZhipuServer(t)
+	server.reply = `{"id":"z-7","object":"chat.completion","created":1,"model":"charglm-3","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"你好呀"}}]}`
+
+	_, err := server.newLLM(t, zhipu.ModelCharGLM3).GenerateContent(ctx, messages, meta)
+	require.NoError(t, err)
+	_, err = server.newLLM(t, zhipu.ModelGLM4).GenerateContent(ctx, messages, meta)
+	require.NoError(t, err)
+
+	require.Len(t, server.requests, 2)
+	// charglm 模型发送顶层 meta 字段
+	sent, ok := server.requests[0]["meta"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "苏梦远", sent["bot_name"])
+	assert.Equal(t, "我是一名学生", sent["user_info"])
+	assert.Equal(t, "苏梦远，温柔耐心的老师", sent["bot_info"])
+	assert.Equal(t, "小明", sent["user_name"])
+	assert.NotContains(t, server.requests[0], "metadata")
+	// 其他模型不发送 meta
+	assert.NotContains(t, server.requests[1], "meta")
+	assert.NotContains(t, server.requests[1], "metadata")
+}
@@ -62,8 +62,11 @@ func (f *AgentFactory) createZeroShotReactAgent(config *AgentConfig, allConfigs
 		return nil, fmt.Errorf("failed to create LLM for agent: %w", err)
 	}
 
-	// 从Chain配置中获取工具(如果有)
-	tools := f.getToolsFromOptions(config.Options)
+	// 获取工具(如果有)
+	tools, err := f.getTools(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建零样本智能体
 	agent := agents.NewOneShotAgent(
@@ -73,8 +76,8 @@ func (f *AgentFactory) createZeroShotReactAgent(config *AgentConfig, allConfigs
 		agents.WithOutputKey(f.getOutputKey(config)),
 	)
 
-	// 创建执行器
-	executor := agents.NewExecutor(agent)
+	// 创建执行器，迭代次数由执行器控制
+	executor := agents.NewExecutor(agent, agents.WithMaxIterations(f.getMaxIterations(config)))
 
 	return executor, nil
 }
@@ -99,8 +102,11 @@ func (f *AgentFactory) createConversationalReactAgent(config *AgentConfig, allCo
 		return nil, fmt.Errorf("failed to create LLM for agent: %w", err)
 	}
 
-	// 从Chain配置中获取工具(如果有)
-	tools := f.getToolsFromOptions(config.Options)
+	// 获取工具(如果有)
+	tools, err := f.getTools(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建对话智能体
 	agent := agents.NewConversationalAgent(
@@ -110,12 +116,25 @@ func (f *AgentFactory) createConversationalReactAgent(config *AgentConfig, allCo
 		agents.WithOutputKey(f.getOutputKey(config)),
 	)
 
-	// 创建执行器
-	executor := agents.NewExecutor(agent)
+	// 创建执行器，迭代次数由执行器控制
+	executor := agents.NewExecutor(agent, agents.WithMaxIterations(f.getMaxIterations(config)))
 
 	return executor, nil
 }
 
+// getTools 汇总Options中的工具与从OpenAPI规范生成的工具
+func (f *AgentFactory) getTools(config *AgentConfig) ([]tools.Tool, error) {
+	result := f.getToolsFromOptions(config.Options)
+	for i, toolsConfig := range config.ToolsFromOpenAPI {
+		openAPITools, err := toolsConfig.Tools()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tools_from_openapi[%d]: %w", i, err)
+		}
+		result = append(result, openAPITools...)
+	}
+	return result, nil
+}
+
 // getToolsFromOptions 从Options中获取工具列表
 func (f *AgentFactory) getToolsFromOptions(options map[string]interface{}) []tools.Tool {
 	if options == nil {
@@ -129,9 +148,12 @@ func (f *AgentFactory) getToolsFromOptions(options map[string]interface{}) []too
 
 // getMaxIterations 获取最大迭代次数
 func (f *AgentFactory) getMaxIterations(config *AgentConfig) int {
-	// 可以从Options中获取，或使用默认值
-	if maxIter, ok := config.Options["max_iterations"].(int); ok {
+	// 可以从Options中获取，或使用默认值；从JSON解析的数字为 float64
+	switch maxIter := config.Options["max_iterations"].(type) {
+	case int:
 		return maxIter
+	case float64:
+		return int(maxIter)
 	}
 	return 5 // 默认值
 }
@@ -11,17 +11,19 @@ import (
 
 // ChainFactory Chain组件工厂
 type ChainFactory struct {
-	llmFactory    *LLMFactory
-	memoryFactory *MemoryFactory
-	promptFactory *PromptFactory
+	llmFactory       *LLMFactory
+	memoryFactory    *MemoryFactory
+	promptFactory    *PromptFactory
+	retrieverFactory *RetrieverFactory
 }
 
 // NewChainFactory 创建Chain工厂实例
 func NewChainFactory(llmFactory *LLMFactory, memoryFactory *MemoryFactory, promptFactory *PromptFactory) *ChainFactory {
 	return &ChainFactory{
-		llmFactory:    llmFactory,
-		memoryFactory: memoryFactory,
-		promptFactory: promptFactory,
+		llmFactory:       llmFactory,
+		memoryFactory:    memoryFactory,
+		promptFactory:    promptFactory,
+		retrieverFactory: NewRetrieverFactory(NewEmbeddingFactory()),
 	}
 }
 
@@ -47,6 +49,8 @@ func (f *ChainFactory) Create(config *ChainConfig, allConfigs *Config) (chains.C
 		return f.createStuffDocumentsChain(config, allConfigs)
 	case "map_reduce":
 		return f.createMapReduceChain(config, allConfigs)
+	case "retrieval_qa":
+		return f.createRetrievalQAChain(config, allConfigs)
 	default:
 		return nil, fmt.Errorf("unsupported Chain type: %s", config.Type)
 	}
@@ -229,6 +233,44 @@ func (f *ChainFactory) createMapReduceChain(config *ChainConfig, allConfigs *Con
 	return mapReduceChain, nil
 }
 
+// createRetrievalQAChain 创建检索问答链
+func (f *ChainFactory) createRetrievalQAChain(config *ChainConfig, allConfigs *Config) (chains.Chain, error) {
+	if config.LLMRef == "" {
+		return nil, fmt.Errorf("LLM reference is required for retrieval_qa chain")
+	}
+
+	llmConfig := allConfigs.LLMs[config.LLMRef]
+	llm, err := f.llmFactory.Create(llmConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM for chain: %w", err)
+	}
+
+	// 获取Prompt，需包含 context 和 question 变量
+	var prompt prompts.FormatPrompter
+	if config.PromptRef != "" {
+		promptConfig := allConfigs.Prompts[config.PromptRef]
+		prompt, err = f.promptFactory.Create(promptConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Prompt for chain: %w", err)
+		}
+	} else {
+		prompt = f.promptFactory.CreateSimpleTemplate(DefaultRetrievalQATemplate, []string{"context", "question"})
+	}
+
+	retriever, err := f.retrieverFactory.Create(allConfigs.Retrievers[config.RetrieverRef], allConfigs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Retriever for chain: %w", err)
+	}
+
+	chain := NewRetrievalQAChain(chains.NewLLMChain(llm, prompt), retriever)
+	if config.SourceKey != "" {
+		chain.SourceKey = config.SourceKey
+	}
+	chain.ReturnSourceDocuments = config.ReturnSourceDocuments
+
+	return chain, nil
+}
+
 // CreateWithComponents 使用现有组件创建Chain
 func (f *ChainFactory) CreateWithComponents(chainType string, llm llms.Model, memory schema.Memory, prompt prompts.FormatPrompter) (chains.Chain, error) {
 	switch chainType {
@@ -0,0 +1,723 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CodegenOptions 代码生成选项
+type CodegenOptions struct {
+	PackageName string // 生成代码的包名，默认为 main
+	FuncName    string // 构造函数名，默认为 NewApp
+	TypeName    string // 组件结构体名，默认为 App
+}
+
+// envVarPattern 匹配整个值为 ${VAR} 的环境变量引用
+var envVarPattern = regexp.MustCompile(`^\$\{(\w+)\}$`)
+
+// 生成代码中使用的包
+const (
+	pkgFmt         = "fmt"
+	pkgOS          = "os"
+	pkgLLMs        = "github.com/tmc/langchaingo/llms"
+	pkgMemory      = "github.com/tmc/langchaingo/memory"
+	pkgPrompts     = "github.com/tmc/langchaingo/prompts"
+	pkgChains      = "github.com/tmc/langchaingo/chains"
+	pkgAgents      = "github.com/tmc/langchaingo/agents"
+	pkgTools       = "github.com/tmc/langchaingo/tools"
+	pkgSchema      = "github.com/tmc/langchaingo/schema"
+	pkgSchemaCN    = "github.com/sjzsdu/langchaingo-cn/schema"
+	pkgOpenAI      = "github.com/tmc/langchaingo/llms/openai"
+	pkgAnthropic   = "github.com/tmc/langchaingo/llms/anthropic"
+	pkgOllama      = "github.com/tmc/langchaingo/llms/ollama"
+	pkgDeepSeek    = "github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	pkgKimi        = "github.com/sjzsdu/langchaingo-cn/llms/kimi"
+	pkgQwen        = "github.com/sjzsdu/langchaingo-cn/llms/qwen"
+	pkgZhipu       = "github.com/sjzsdu/langchaingo-cn/llms/zhipu"
+	pkgSiliconFlow = "github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	pkgErnie       = "github.com/sjzsdu/langchaingo-cn/llms/ernie"
+	pkgMiniMax     = "github.com/sjzsdu/langchaingo-cn/llms/minimax"
+	pkgDoubao      = "github.com/sjzsdu/langchaingo-cn/llms/doubao"
+	pkgYi          = "github.com/sjzsdu/langchaingo-cn/llms/yi"
+	pkgBaichuan    = "github.com/sjzsdu/langchaingo-cn/llms/baichuan"
+)
+
+// importAliases 需要别名的包
+var importAliases = map[string]string{
+	pkgSchemaCN: "schemacn",
+}
+
+// LoadCodegenConfigFromFile 加载用于代码生成的配置文件
+// 与 LoadConfigFromFile 不同，这里不展开环境变量，以便生成的代码在运行时读取环境变量而不是写入密钥。
+// 同时支持完整配置和 Executor 使用风格配置（顶层包含 agent 字段）。
+func LoadCodegenConfigFromFile(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if data, err = configFileData(filename, data); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if _, ok := probe["agent"]; ok {
+		var usage ExecutorUsageConfig
+		if err := json.Unmarshal(data, &usage); err != nil {
+			return nil, fmt.Errorf("failed to parse executor config: %w", err)
+		}
+		if err := usage.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid executor config: %w", err)
+		}
+		return usage.ToConfig()
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return &config, nil
+}
+
+// GenerateGoCode 根据配置生成强类型的Go构造代码
+// 生成的代码与 Factory 创建组件的方式一致，API密钥等 ${VAR} 引用会在运行时从环境变量读取。
+func GenerateGoCode(config *Config, opts CodegenOptions) ([]byte, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if cycles := detectCyclicReferences(config); len(cycles) > 0 {
+		return nil, fmt.Errorf("cyclic references: %s", strings.Join(cycles, ", "))
+	}
+
+	if opts.PackageName == "" {
+		opts.PackageName = "main"
+	}
+	if opts.FuncName == "" {
+		opts.FuncName = "NewApp"
+	}
+	if opts.TypeName == "" {
+		opts.TypeName = "App"
+	}
+
+	g := &codegen{
+		config:  config,
+		imports: map[string]bool{pkgFmt: true},
+		fields:  make(map[string]map[string]string),
+	}
+	return g.generate(opts)
+}
+
+// codegen 代码生成器状态
+type codegen struct {
+	config  *Config
+	imports map[string]bool
+	// fields 按组件类别记录配置名到结构体字段名的映射
+	fields map[string]map[string]string
+	body   bytes.Buffer
+}
+
+// generate 生成完整的Go源文件
+func (g *codegen) generate(opts CodegenOptions) ([]byte, error) {
+	var decl bytes.Buffer
+
+	// 结构体字段
+	g.declare(&decl, "llm", "LLM", sortedKeys(g.config.LLMs), "llms.Model", pkgLLMs)
+	g.declare(&decl, "memory", "Memory", sortedKeys(g.config.Memories), "schema.Memory", pkgSchema)
+	g.declare(&decl, "prompt", "Prompt", sortedKeys(g.config.Prompts), "prompts.FormatPrompter", pkgPrompts)
+	g.declare(&decl, "chain", "Chain", sortedKeys(g.config.Chains), "chains.Chain", pkgChains)
+	g.declare(&decl, "agent", "Agent", sortedKeys(g.config.Agents), "*agents.Executor", pkgAgents)
+	g.declare(&decl, "executor", "Executor", sortedKeys(g.config.Executors), "*agents.Executor", pkgAgents)
+
+	for _, name := range sortedKeys(g.config.LLMs) {
+		g.genLLM(name, g.config.LLMs[name])
+	}
+	for _, name := range sortedKeys(g.config.Memories) {
+		if err := g.genMemory(name, g.config.Memories[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(g.config.Prompts) {
+		if err := g.genPrompt(name, g.config.Prompts[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range g.chainOrder() {
+		if err := g.genChain(name, g.config.Chains[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(g.config.Agents) {
+		fmt.Fprintf(&g.body, "\n// Agent: %s\n", name)
+		if err := g.genAgent(g.fields["agent"][name], name, g.config.Agents[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(g.config.Executors) {
+		if err := g.genExecutor(name, g.config.Executors[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	hasAgents := len(g.config.Agents) > 0 || len(g.config.Executors) > 0
+	params := ""
+	if hasAgents {
+		g.imports[pkgTools] = true
+		params = "agentTools []tools.Tool"
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by langchaingo-cn config-gen codegen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", opts.PackageName)
+	src.WriteString(g.importBlock())
+	fmt.Fprintf(&src, "// %s 由配置生成的应用组件\n", opts.TypeName)
+	fmt.Fprintf(&src, "type %s struct {\n%s}\n\n", opts.TypeName, decl.String())
+	if len(g.config.Embeddings) > 0 {
+		fmt.Fprintf(&src, "// 注意: Embedding 组件 (%s) 暂不支持代码生成，请使用 schema.CreateEmbeddingFromConfig 创建\n",
+			strings.Join(sortedKeys(g.config.Embeddings), ", "))
+	}
+	fmt.Fprintf(&src, "// %s 创建配置中的所有组件\n", opts.FuncName)
+	if hasAgents {
+		src.WriteString("// agentTools 为所有 Agent 提供的工具\n")
+	}
+	fmt.Fprintf(&src, "func %s(%s) (*%s, error) {\n", opts.FuncName, params, opts.TypeName)
+	fmt.Fprintf(&src, "app := &%s{}\n", opts.TypeName)
+	if g.body.Len() > 0 {
+		src.WriteString("var err error\n")
+	}
+	src.Write(g.body.Bytes())
+	src.WriteString("return app, nil\n}\n")
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// declare 为一类组件生成结构体字段
+func (g *codegen) declare(decl *bytes.Buffer, kind, suffix string, names []string, typ, pkg string) {
+	if len(names) == 0 {
+		return
+	}
+	g.imports[pkg] = true
+	g.fields[kind] = make(map[string]string)
+	for _, name := range names {
+		field := fieldName(name, suffix)
+		g.fields[kind][name] = field
+		fmt.Fprintf(decl, "%s %s // %s: %s\n", field, typ, kind, name)
+	}
+}
+
+// genLLM 生成LLM构造代码，与 LLMFactory 保持一致
+func (g *codegen) genLLM(name string, config *LLMConfig) {
+	var (
+		pkg, alias, ctor string
+		opts             []string
+	)
+	option := func(fn, value string) {
+		opts = append(opts, fmt.Sprintf("%s.%s(%s)", alias, fn, value))
+	}
+	apiKey := func(fn string) {
+		if expr := g.apiKeyExpr(config); expr != "" {
+			option(fn, expr)
+		}
+	}
+	common := func(keyFn string) {
+		apiKey(keyFn)
+		option("WithModel", g.stringExpr(config.Model))
+		if config.BaseURL != "" {
+			option("WithBaseURL", g.stringExpr(config.BaseURL))
+		}
+	}
+	embeddingModel := func() {
+		if model, ok := config.Options["embedding_model"].(string); ok && model != "" {
+			option("WithEmbeddingModel", g.stringExpr(model))
+		}
+	}
+
+	ctor = "New"
+	switch config.Type {
+	case "openai":
+		pkg, alias = pkgOpenAI, "openai"
+		common("WithToken")
+		if org, ok := config.Options["organization"].(string); ok && org != "" {
+			option("WithOrganization", g.stringExpr(org))
+		}
+		if version, ok := config.Options["api_version"].(string); ok && version != "" {
+			option("WithAPIVersion", g.stringExpr(version))
+		}
+		embeddingModel()
+	case "deepseek":
+		pkg, alias = pkgDeepSeek, "deepseek"
+		common("WithAPIKey")
+	case "kimi":
+		pkg, alias = pkgKimi, "kimi"
+		common("WithToken")
+		if config.Temperature != nil {
+			option("WithTemperature", strconv.FormatFloat(*config.Temperature, 'g', -1, 64))
+		}
+		if config.MaxTokens != nil {
+			option("WithMaxTokens", strconv.Itoa(*config.MaxTokens))
+		}
+	case "qwen":
+		pkg, alias = pkgQwen, "qwen"
+		common("WithAPIKey")
+		embeddingModel()
+	case "zhipu":
+		pkg, alias = pkgZhipu, "zhipu"
+		common("WithAPIKey")
+		embeddingModel()
+	case "siliconflow":
+		pkg, alias = pkgSiliconFlow, "siliconflow"
+		common("WithAPIKey")
+		embeddingModel()
+		if tier, ok := config.Options["tier"].(string); ok && tier != "" {
+			option("WithTier", "siliconflow.Tier("+g.stringExpr(tier)+")")
+		}
+	case "ernie":
+		pkg, alias = pkgErnie, "ernie"
+		common("WithAPIKey")
+		// 明文的 Secret Key 不写入代码，由 ernie.New 读取环境变量 ERNIE_SECRET_KEY
+		if secretKey, ok := config.Options["secret_key"].(string); ok && secretKey != "" && !isLiteralSecret(secretKey) {
+			option("WithSecretKey", g.stringExpr(secretKey))
+		}
+		if config.Temperature != nil {
+			option("WithTemperature", strconv.FormatFloat(*config.Temperature, 'g', -1, 64))
+		}
+		if config.MaxTokens != nil {
+			option("WithMaxTokens", strconv.Itoa(*config.MaxTokens))
+		}
+	case "minimax":
+		pkg, alias = pkgMiniMax, "minimax"
+		common("WithAPIKey")
+		if groupID, ok := config.Options["group_id"].(string); ok && groupID != "" {
+			option("WithGroupID", g.stringExpr(groupID))
+		}
+	case "doubao":
+		pkg, alias = pkgDoubao, "doubao"
+		common("WithAPIKey")
+		if endpointID, ok := config.Options["endpoint_id"].(string); ok && endpointID != "" {
+			option("WithEndpointID", g.stringExpr(endpointID))
+		}
+	case "yi":
+		pkg, alias = pkgYi, "yi"
+		common("WithAPIKey")
+	case "baichuan":
+		pkg, alias = pkgBaichuan, "baichuan"
+		common("WithAPIKey")
+	case "anthropic":
+		pkg, alias = pkgAnthropic, "anthropic"
+		common("WithToken")
+	case "ollama":
+		pkg, alias = pkgOllama, "ollama"
+		option("WithModel", g.stringExpr(config.Model))
+		if config.BaseURL != "" {
+			option("WithServerURL", g.stringExpr(config.BaseURL))
+		}
+	}
+	g.imports[pkg] = true
+
+	field := g.fields["llm"][name]
+	fmt.Fprintf(&g.body, "\n// LLM: %s\n", name)
+	if isLiteralSecret(config.APIKey) {
+		g.body.WriteString("// 配置中的明文API密钥未写入代码，改为读取默认环境变量\n")
+	}
+	fmt.Fprintf(&g.body, "app.%s, err = %s.%s(%s)\n", field, alias, ctor, joinArgs(opts))
+	g.checkErr("LLM", name)
+}
+
+// genMemory 生成Memory构造代码，与 MemoryFactory 保持一致
+func (g *codegen) genMemory(name string, config *MemoryConfig) error {
+	field := g.fields["memory"][name]
+	fmt.Fprintf(&g.body, "\n// Memory: %s\n", name)
+
+	if config.Type == "simple" {
+		if config.Persistence != nil {
+			return fmt.Errorf("memory '%s': persistence is not supported for simple memory", name)
+		}
+		g.imports[pkgMemory] = true
+		fmt.Fprintf(&g.body, "app.%s = memory.NewSimple()\n", field)
+		return nil
+	}
+
+	var opts []string
+	if config.ReturnMessages != nil {
+		opts = append(opts, fmt.Sprintf("memory.WithReturnMessages(%t)", *config.ReturnMessages))
+	}
+	if config.Persistence != nil {
+		g.imports[pkgSchemaCN] = true
+		history := "history" + field
+		fmt.Fprintf(&g.body, "%s, err := schemacn.NewChatMessageHistory(%s)\n", history, g.persistenceExpr(config.Persistence))
+		g.checkErr("Memory", name)
+		opts = append(opts, fmt.Sprintf("memory.WithChatHistory(%s)", history))
+	}
+
+	g.imports[pkgMemory] = true
+	switch config.Type {
+	case "conversation_buffer":
+		if config.MaxMessages != nil {
+			fmt.Fprintf(&g.body, "app.%s = memory.NewConversationWindowBuffer(%d%s)\n", field, *config.MaxMessages, trailingArgs(opts))
+		} else {
+			fmt.Fprintf(&g.body, "app.%s = memory.NewConversationBuffer(%s)\n", field, joinArgs(opts))
+		}
+	case "conversation_token_buffer":
+		llmField, ok := g.fields["llm"][config.LLMRef]
+		if !ok {
+			return fmt.Errorf("memory '%s': referenced LLM '%s' not found", name, config.LLMRef)
+		}
+		maxTokenLimit := 2000
+		if config.MaxTokenLimit != nil {
+			maxTokenLimit = *config.MaxTokenLimit
+		}
+		fmt.Fprintf(&g.body, "app.%s = memory.NewConversationTokenBuffer(app.%s, %d%s)\n", field, llmField, maxTokenLimit, trailingArgs(opts))
+	default:
+		return fmt.Errorf("memory '%s': unsupported type for codegen: %s", name, config.Type)
+	}
+	return nil
+}
+
+// genPrompt 生成Prompt构造代码，与 PromptFactory 保持一致
+func (g *codegen) genPrompt(name string, config *PromptConfig) error {
+	field := g.fields["prompt"][name]
+	vars := stringSliceExpr(config.InputVariables)
+	fmt.Fprintf(&g.body, "\n// Prompt: %s\n", name)
+
+	switch config.Type {
+	case "prompt_template":
+		fmt.Fprintf(&g.body, "app.%s = prompts.NewPromptTemplate(%s, %s)\n", field, strconv.Quote(config.Template), vars)
+	case "chat_prompt_template":
+		fmt.Fprintf(&g.body, "app.%s = prompts.NewChatPromptTemplate([]prompts.MessageFormatter{\n", field)
+		for _, m := range config.Messages {
+			var ctor string
+			switch m.Role {
+			case "system":
+				ctor = "NewSystemMessagePromptTemplate"
+			case "human", "user":
+				ctor = "NewHumanMessagePromptTemplate"
+			case "ai", "assistant":
+				ctor = "NewAIMessagePromptTemplate"
+			default:
+				return fmt.Errorf("prompt '%s': unsupported message role: %s", name, m.Role)
+			}
+			fmt.Fprintf(&g.body, "prompts.%s(%s, %s),\n", ctor, strconv.Quote(m.Template), vars)
+		}
+		g.body.WriteString("})\n")
+	default:
+		return fmt.Errorf("prompt '%s': unsupported type for codegen: %s", name, config.Type)
+	}
+	return nil
+}
+
+// chainOrder 按依赖顺序返回Chain名称，子链先于引用它的链
+func (g *codegen) chainOrder() []string {
+	var order []string
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		if config, ok := g.config.Chains[name]; ok {
+			for _, sub := range config.Chains {
+				visit(sub)
+			}
+			order = append(order, name)
+		}
+	}
+	for _, name := range sortedKeys(g.config.Chains) {
+		visit(name)
+	}
+	return order
+}
+
+// genChain 生成Chain构造代码，与 ChainFactory 保持一致
+func (g *codegen) genChain(name string, config *ChainConfig) error {
+	field := g.fields["chain"][name]
+	fmt.Fprintf(&g.body, "\n// Chain: %s\n", name)
+
+	switch config.Type {
+	case "llm":
+		expr, err := g.llmChainExpr(name, config)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.body, "app.%s = %s\n", field, expr)
+	case "conversation":
+		llmField, ok := g.fields["llm"][config.LLMRef]
+		if !ok {
+			return fmt.Errorf("chain '%s': LLM reference is required for conversation chain", name)
+		}
+		mem := "memory.NewSimple()"
+		if config.MemoryRef != "" {
+			mem = "app." + g.fields["memory"][config.MemoryRef]
+		} else {
+			g.imports[pkgMemory] = true
+		}
+		fmt.Fprintf(&g.body, "app.%s = chains.NewConversation(app.%s, %s)\n", field, llmField, mem)
+	case "sequential":
+		subChains := make([]string, 0, len(config.Chains))
+		for _, sub := range config.Chains {
+			subChains = append(subChains, "app."+g.fields["chain"][sub])
+		}
+		list := fmt.Sprintf("[]chains.Chain{%s}", strings.Join(subChains, ", "))
+		if len(config.InputKeys) > 0 && len(config.OutputKeys) > 0 {
+			fmt.Fprintf(&g.body, "app.%s, err = chains.NewSequentialChain(%s, %s, %s)\n",
+				field, list, stringSliceExpr(config.InputKeys), stringSliceExpr(config.OutputKeys))
+		} else {
+			fmt.Fprintf(&g.body, "app.%s, err = chains.NewSimpleSequentialChain(%s)\n", field, list)
+		}
+		g.checkErr("Chain", name)
+	case "stuff_documents":
+		expr, err := g.llmChainExpr(name, config)
+		if err != nil {
+			return err
+		}
+		local := "stuff" + field
+		fmt.Fprintf(&g.body, "%s := chains.NewStuffDocuments(%s)\n", local, expr)
+		if config.Separator != "" {
+			fmt.Fprintf(&g.body, "%s.Separator = %s\n", local, strconv.Quote(config.Separator))
+		}
+		fmt.Fprintf(&g.body, "app.%s = %s\n", field, local)
+	case "map_reduce":
+		if len(config.Chains) < 2 {
+			return fmt.Errorf("chain '%s': map-reduce chain requires at least 2 sub-chains (map and reduce)", name)
+		}
+		mapExpr, err := g.llmChainExpr(config.Chains[0], g.config.Chains[config.Chains[0]])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.body, "app.%s = chains.NewMapReduceDocuments(%s, app.%s)\n",
+			field, mapExpr, g.fields["chain"][config.Chains[1]])
+	default:
+		return fmt.Errorf("chain '%s': unsupported type for codegen: %s", name, config.Type)
+	}
+	return nil
+}
+
+// llmChainExpr 返回创建LLM链的表达式
+func (g *codegen) llmChainExpr(name string, config *ChainConfig) (string, error) {
+	llmField, ok := g.fields["llm"][config.LLMRef]
+	if !ok {
+		return "", fmt.Errorf("chain '%s': LLM reference is required for llm chain", name)
+	}
+	prompt := `prompts.NewPromptTemplate("{{.input}}", []string{"input"})`
+	if config.PromptRef != "" {
+		prompt = "app." + g.fields["prompt"][config.PromptRef]
+	} else {
+		g.imports[pkgPrompts] = true
+	}
+	return fmt.Sprintf("chains.NewLLMChain(app.%s, %s)", llmField, prompt), nil
+}
+
+// genAgent 生成Agent构造代码并赋值给 field，与 AgentFactory 保持一致
+func (g *codegen) genAgent(field, name string, config *AgentConfig) error {
+	chainConfig, ok := g.config.Chains[config.ChainRef]
+	if !ok || chainConfig.LLMRef == "" {
+		return fmt.Errorf("agent '%s': LLM reference is required in chain", name)
+	}
+
+	ctor := "NewOneShotAgent"
+	if config.Type == "conversational_react" {
+		ctor = "NewConversationalAgent"
+	} else if config.Type != "zero_shot_react" {
+		return fmt.Errorf("agent '%s': unsupported type for codegen: %s", name, config.Type)
+	}
+
+	maxIterations := 5
+	if v, ok := config.Options["max_iterations"].(int); ok {
+		maxIterations = v
+	}
+	outputKey := config.OutputKey
+	if outputKey == "" {
+		outputKey = "output"
+	}
+
+	if len(config.ToolsFromOpenAPI) > 0 {
+		g.body.WriteString("// 注意: tools_from_openapi 暂不支持代码生成，请使用 schema.ToolsFromOpenAPI 加载后加入 agentTools\n")
+	}
+	fmt.Fprintf(&g.body, "app.%s = agents.NewExecutor(agents.%s(app.%s, agentTools, agents.WithMaxIterations(%d), agents.WithOutputKey(%s)))\n",
+		field, ctor, g.fields["llm"][chainConfig.LLMRef], maxIterations, strconv.Quote(outputKey))
+	return nil
+}
+
+// genExecutor 生成Executor构造代码，与 ExecutorUsageConfig.CreateExecutor 保持一致
+func (g *codegen) genExecutor(name string, config *ExecutorConfig) error {
+	field := g.fields["executor"][name]
+	fmt.Fprintf(&g.body, "\n// Executor: %s (agent: %s)\n", name, config.AgentRef)
+	if err := g.genAgent(field, config.AgentRef, g.config.Agents[config.AgentRef]); err != nil {
+		return err
+	}
+	if config.MemoryRef != "" {
+		fmt.Fprintf(&g.body, "app.%s.Memory = app.%s\n", field, g.fields["memory"][config.MemoryRef])
+	}
+	return nil
+}
+
+// checkErr 生成错误检查代码
+func (g *codegen) checkErr(kind, name string) {
+	fmt.Fprintf(&g.body, "if err != nil {\nreturn nil, fmt.Errorf(\"failed to create %s '%s': %%w\", err)\n}\n", kind, name)
+}
+
+// apiKeyExpr 返回API密钥表达式，明文密钥不会写入代码
+func (g *codegen) apiKeyExpr(config *LLMConfig) string {
+	key := config.APIKey
+	if key == "" || isLiteralSecret(key) {
+		key = (&ConfigGenerator{}).getDefaultAPIKeyEnv(config.Type)
+	}
+	if key == "" {
+		return ""
+	}
+	return g.stringExpr(key)
+}
+
+// stringExpr 返回字符串值的表达式，${VAR} 引用转换为运行时读取环境变量
+func (g *codegen) stringExpr(value string) string {
+	if m := envVarPattern.FindStringSubmatch(value); m != nil {
+		g.imports[pkgOS] = true
+		return fmt.Sprintf("os.Getenv(%q)", m[1])
+	}
+	if strings.Contains(value, "${") {
+		g.imports[pkgOS] = true
+		return fmt.Sprintf("os.ExpandEnv(%s)", strconv.Quote(value))
+	}
+	return strconv.Quote(value)
+}
+
+// persistenceExpr 返回持久化配置的字面量表达式
+func (g *codegen) persistenceExpr(p *PersistenceConfig) string {
+	var fields []string
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, fmt.Sprintf("%s: %s", name, g.stringExpr(value)))
+		}
+	}
+	add("Backend", p.Backend)
+	add("URL", p.URL)
+	add("Driver", p.Driver)
+	add("DSN", p.DSN)
+	add("TableName", p.TableName)
+	add("Namespace", p.Namespace)
+	add("SessionID", p.SessionID)
+	if p.TTLSeconds != nil {
+		fields = append(fields, fmt.Sprintf("TTLSeconds: func() *int { v := %d; return &v }()", *p.TTLSeconds))
+	}
+	return fmt.Sprintf("&schemacn.PersistenceConfig{%s}", strings.Join(fields, ", "))
+}
+
+// importBlock 生成排序后的import块
+func (g *codegen) importBlock() string {
+	var std, others []string
+	for pkg := range g.imports {
+		line := strconv.Quote(pkg)
+		if alias, ok := importAliases[pkg]; ok {
+			line = alias + " " + line
+		}
+		if strings.Contains(pkg, ".") {
+			others = append(others, line)
+		} else {
+			std = append(std, line)
+		}
+	}
+	sort.Strings(std)
+	sort.Slice(others, func(i, j int) bool { return importPath(others[i]) < importPath(others[j]) })
+
+	var sb strings.Builder
+	sb.WriteString("import (\n")
+	for _, line := range std {
+		sb.WriteString(line + "\n")
+	}
+	if len(std) > 0 && len(others) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, line := range others {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString(")\n\n")
+	return sb.String()
+}
+
+// importPath 返回import行中的包路径，用于排序
+func importPath(line string) string {
+	return line[strings.Index(line, `"`):]
+}
+
+// isLiteralSecret 判断API密钥是否为明文（非环境变量引用）
+func isLiteralSecret(key string) bool {
+	return key != "" && !strings.Contains(key, "$")
+}
+
+// fieldName 将配置名转换为导出的Go字段名，如 main_agent -> MainAgent，main-llm -> MainLLM，deepseek -> DeepseekLLM
+func fieldName(name, suffix string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	field := sb.String()
+	if field == "" || unicode.IsDigit([]rune(field)[0]) {
+		field = "C" + field
+	}
+	// 已以组件类别结尾时统一大小写，如 MainLlm -> MainLLM
+	if strings.HasSuffix(strings.ToLower(field), strings.ToLower(suffix)) {
+		field = field[:len(field)-len(suffix)]
+	}
+	return field + suffix
+}
+
+// stringSliceExpr 返回字符串切片的字面量表达式
+func stringSliceExpr(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return fmt.Sprintf("[]string{%s}", strings.Join(quoted, ", "))
+}
+
+// joinArgs 将参数列表格式化为多行调用参数
+func joinArgs(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(args, ",\n") + ",\n"
+}
+
+// trailingArgs 将参数列表格式化为跟在已有参数后的调用参数
+func trailingArgs(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(args, ", ")
+}
+
+// sortedKeys 返回排序后的map键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
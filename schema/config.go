@@ -13,14 +13,16 @@ type Config struct {
 	Memories   map[string]*MemoryConfig    `json:"memories,omitempty"`
 	Prompts    map[string]*PromptConfig    `json:"prompts,omitempty"`
 	Embeddings map[string]*EmbeddingConfig `json:"embeddings,omitempty"`
+	Retrievers map[string]*RetrieverConfig `json:"retrievers,omitempty"`
 	Chains     map[string]*ChainConfig     `json:"chains,omitempty"`
 	Agents     map[string]*AgentConfig     `json:"agents,omitempty"`
 	Executors  map[string]*ExecutorConfig  `json:"executors,omitempty"`
+	Graphs     map[string]*GraphConfig     `json:"graphs,omitempty"`
 }
 
 // LLMConfig LLM组件配置
 type LLMConfig struct {
-	Type        string                 `json:"type"`        // openai, deepseek, kimi, qwen, anthropic, ollama
+	Type        string                 `json:"type"`        // openai, deepseek, kimi, qwen, ernie, minimax, doubao, yi, baichuan, anthropic, ollama
 	Model       string                 `json:"model"`       // 模型名称
 	APIKey      string                 `json:"api_key"`     // API密钥，支持环境变量
 	BaseURL     string                 `json:"base_url"`    // 基础URL
@@ -31,14 +33,27 @@ type LLMConfig struct {
 
 // MemoryConfig Memory组件配置
 type MemoryConfig struct {
-	Type           string                 `json:"type"`            // conversation_buffer, conversation_summary, conversation_token_buffer
+	Type           string                 `json:"type"`            // conversation_buffer, conversation_token_buffer, simple
 	MaxTokenLimit  *int                   `json:"max_token_limit"` // token限制
 	MaxMessages    *int                   `json:"max_messages"`    // 消息数量限制
 	ReturnMessages *bool                  `json:"return_messages"` // 是否返回消息
 	LLMRef         string                 `json:"llm_ref"`         // 引用的LLM组件
+	Persistence    *PersistenceConfig     `json:"persistence"`     // 持久化存储配置，为空时仅保存在内存中
 	Options        map[string]interface{} `json:"options"`         // 其他选项
 }
 
+// PersistenceConfig Memory持久化存储配置
+type PersistenceConfig struct {
+	Backend    string `json:"backend"`               // redis, sql
+	URL        string `json:"url,omitempty"`         // Redis连接URL，如 redis://:password@localhost:6379/0
+	Driver     string `json:"driver,omitempty"`      // SQL驱动名称（需由调用方导入驱动），如 mysql, postgres, sqlite3
+	DSN        string `json:"dsn,omitempty"`         // SQL数据源
+	TableName  string `json:"table_name,omitempty"`  // SQL表名，默认为 langchaingo_messages
+	Namespace  string `json:"namespace,omitempty"`   // 命名空间，用于隔离不同应用的数据
+	SessionID  string `json:"session_id"`            // 会话ID
+	TTLSeconds *int   `json:"ttl_seconds,omitempty"` // 过期时间（秒），仅Redis支持
+}
+
 // PromptConfig Prompt组件配置
 type PromptConfig struct {
 	Type             string                 `json:"type"`              // prompt_template, chat_prompt_template
@@ -59,7 +74,7 @@ type ChatMessageConfig struct {
 
 // EmbeddingConfig Embedding组件配置
 type EmbeddingConfig struct {
-	Type      string                 `json:"type"`       // openai, voyage, cohere
+	Type      string                 `json:"type"`       // openai, voyage, huggingface, jina, qwen, zhipu, siliconflow
 	Model     string                 `json:"model"`      // 模型名称
 	APIKey    string                 `json:"api_key"`    // API密钥
 	BaseURL   string                 `json:"base_url"`   // 基础URL
@@ -67,9 +82,22 @@ type EmbeddingConfig struct {
 	Options   map[string]interface{} `json:"options"`    // 其他选项
 }
 
+// RetrieverConfig Retriever组件配置，从向量数据库检索相关文档
+type RetrieverConfig struct {
+	Type           string                 `json:"type"`                      // qdrant
+	EmbeddingRef   string                 `json:"embedding_ref"`             // 引用的Embedding组件，用于向量化查询
+	URL            string                 `json:"url"`                       // 向量数据库地址
+	APIKey         string                 `json:"api_key,omitempty"`         // API密钥，支持环境变量
+	Collection     string                 `json:"collection"`                // 集合名称
+	ContentKey     string                 `json:"content_key,omitempty"`     // 文档内容所在的载荷字段，默认为 content
+	TopK           *int                   `json:"top_k,omitempty"`           // 返回的文档数，默认为4
+	ScoreThreshold *float64               `json:"score_threshold,omitempty"` // 相似度阈值，低于该值的文档被过滤
+	Options        map[string]interface{} `json:"options,omitempty"`         // 其他选项
+}
+
 // ChainConfig Chain组件配置
 type ChainConfig struct {
-	Type           string                 `json:"type"`            // llm, conversation, sequential, stuff_documents, map_reduce
+	Type           string                 `json:"type"`            // llm, conversation, sequential, stuff_documents, map_reduce, retrieval_qa
 	LLMRef         string                 `json:"llm_ref"`         // 引用的LLM组件
 	MemoryRef      string                 `json:"memory_ref"`      // 引用的Memory组件
 	PromptRef      string                 `json:"prompt_ref"`      // 引用的Prompt组件
@@ -79,6 +107,10 @@ type ChainConfig struct {
 	Separator      string                 `json:"separator"`       // 分隔符（用于stuff_documents）
 	MaxConcurrency *int                   `json:"max_concurrency"` // 最大并发数
 	Options        map[string]interface{} `json:"options"`         // 其他选项
+
+	RetrieverRef          string `json:"retriever_ref,omitempty"`           // 引用的Retriever组件（用于retrieval_qa）
+	SourceKey             string `json:"source_key,omitempty"`              // 引用来源的文档元数据键，默认为 source（用于retrieval_qa）
+	ReturnSourceDocuments bool   `json:"return_source_documents,omitempty"` // 是否在输出中返回检索到的文档（用于retrieval_qa）
 }
 
 // AgentConfig Agent组件配置
@@ -87,6 +119,8 @@ type AgentConfig struct {
 	ChainRef  string                 `json:"chain_ref"`            // 引用的Chain组件
 	OutputKey string                 `json:"output_key,omitempty"` // 输出键，默认为"output"
 	Options   map[string]interface{} `json:"options,omitempty"`
+
+	ToolsFromOpenAPI []*OpenAPIToolsConfig `json:"tools_from_openapi,omitempty"` // 从OpenAPI规范生成的工具
 }
 
 // ExecutorConfig Executor组件配置
@@ -105,6 +139,23 @@ type ErrorHandlerConfig struct {
 	Options map[string]interface{} `json:"options"` // 其他选项
 }
 
+// supportedTypes 各组件类别支持的类型，与对应工厂的实现保持一致
+var supportedTypes = map[string][]string{
+	"llm":        {"openai", "deepseek", "kimi", "qwen", "zhipu", "siliconflow", "ernie", "minimax", "doubao", "yi", "baichuan", "anthropic", "ollama"},
+	"memory":     {"conversation_buffer", "conversation_token_buffer", "simple"},
+	"prompt":     {"prompt_template", "chat_prompt_template"},
+	"embedding":  {"openai", "voyage", "huggingface", "jina", "qwen", "zhipu", "siliconflow"},
+	"retriever":  {"qdrant"},
+	"chain":      {"llm", "conversation", "sequential", "stuff_documents", "map_reduce", "retrieval_qa"},
+	"agent":      {"zero_shot_react", "conversational_react"},
+	"middleware": {"logging", "metrics", "timeout", "retry", "circuit_breaker", "rate_limit"},
+}
+
+// SupportedTypes 返回组件类别（llm, memory, prompt, embedding, retriever, chain, agent, middleware）支持的类型
+func SupportedTypes(component string) []string {
+	return append([]string(nil), supportedTypes[component]...)
+}
+
 // LoadConfigFromFile 从文件加载配置
 func LoadConfigFromFile(filename string) (*Config, error) {
 	data, err := os.ReadFile(filename)
@@ -112,8 +163,11 @@ func LoadConfigFromFile(filename string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// 替换环境变量
-	data = []byte(expandEnvVars(string(data)))
+	// 替换环境变量，YAML文件转换为JSON
+	data, err = configFileData(filename, []byte(expandEnvVars(string(data))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
@@ -173,6 +227,13 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// 验证Retriever配置
+	for name, retrieverConfig := range c.Retrievers {
+		if err := retrieverConfig.ValidateReferences(c); err != nil {
+			return fmt.Errorf("invalid Retriever config '%s': %w", name, err)
+		}
+	}
+
 	// 验证Chain配置
 	for name, chainConfig := range c.Chains {
 		if err := chainConfig.ValidateReferences(c); err != nil {
@@ -194,6 +255,13 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// 验证Graph配置
+	for name, graphConfig := range c.Graphs {
+		if err := graphConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid Graph config '%s': %w", name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -203,9 +271,9 @@ func (l *LLMConfig) Validate() error {
 		return fmt.Errorf("type is required")
 	}
 
-	supportedTypes := []string{"openai", "deepseek", "kimi", "qwen", "zhipu", "siliconflow", "anthropic", "ollama"}
-	if !contains(supportedTypes, l.Type) {
-		return fmt.Errorf("unsupported type: %s, supported: %s", l.Type, strings.Join(supportedTypes, ", "))
+	supported := supportedTypes["llm"]
+	if !contains(supported, l.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", l.Type, strings.Join(supported, ", "))
 	}
 
 	if l.Model == "" {
@@ -221,9 +289,39 @@ func (m *MemoryConfig) Validate() error {
 		return fmt.Errorf("type is required")
 	}
 
-	supportedTypes := []string{"conversation_buffer", "conversation_summary", "conversation_token_buffer", "simple"}
-	if !contains(supportedTypes, m.Type) {
-		return fmt.Errorf("unsupported type: %s, supported: %s", m.Type, strings.Join(supportedTypes, ", "))
+	supported := supportedTypes["memory"]
+	if !contains(supported, m.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", m.Type, strings.Join(supported, ", "))
+	}
+
+	if m.Persistence != nil {
+		if err := m.Persistence.Validate(); err != nil {
+			return fmt.Errorf("invalid persistence config: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate 验证持久化存储配置
+func (p *PersistenceConfig) Validate() error {
+	switch p.Backend {
+	case "redis":
+		if p.URL == "" {
+			return fmt.Errorf("url is required for redis backend")
+		}
+	case "sql":
+		if p.Driver == "" || p.DSN == "" {
+			return fmt.Errorf("driver and dsn are required for sql backend")
+		}
+	case "":
+		return fmt.Errorf("backend is required")
+	default:
+		return fmt.Errorf("unsupported backend: %s, supported: redis, sql", p.Backend)
+	}
+
+	if p.SessionID == "" {
+		return fmt.Errorf("session_id is required")
 	}
 
 	return nil
@@ -235,9 +333,9 @@ func (p *PromptConfig) Validate() error {
 		return fmt.Errorf("type is required")
 	}
 
-	supportedTypes := []string{"prompt_template", "chat_prompt_template"}
-	if !contains(supportedTypes, p.Type) {
-		return fmt.Errorf("unsupported type: %s, supported: %s", p.Type, strings.Join(supportedTypes, ", "))
+	supported := supportedTypes["prompt"]
+	if !contains(supported, p.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", p.Type, strings.Join(supported, ", "))
 	}
 
 	if p.Type == "prompt_template" && p.Template == "" {
@@ -257,9 +355,9 @@ func (e *EmbeddingConfig) Validate() error {
 		return fmt.Errorf("type is required")
 	}
 
-	supportedTypes := []string{"openai", "voyage", "cohere"}
-	if !contains(supportedTypes, e.Type) {
-		return fmt.Errorf("unsupported type: %s, supported: %s", e.Type, strings.Join(supportedTypes, ", "))
+	supported := supportedTypes["embedding"]
+	if !contains(supported, e.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", e.Type, strings.Join(supported, ", "))
 	}
 
 	if e.Model == "" {
@@ -269,15 +367,49 @@ func (e *EmbeddingConfig) Validate() error {
 	return nil
 }
 
+// ValidateReferences 验证Retriever配置及其引用
+func (r *RetrieverConfig) ValidateReferences(config *Config) error {
+	if r.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+
+	supported := supportedTypes["retriever"]
+	if !contains(supported, r.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", r.Type, strings.Join(supported, ", "))
+	}
+
+	if r.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	if r.Collection == "" {
+		return fmt.Errorf("collection is required")
+	}
+
+	if r.TopK != nil && *r.TopK <= 0 {
+		return fmt.Errorf("top_k must be positive")
+	}
+
+	// 验证Embedding引用
+	if r.EmbeddingRef == "" {
+		return fmt.Errorf("embedding_ref is required")
+	}
+	if _, exists := config.Embeddings[r.EmbeddingRef]; !exists {
+		return fmt.Errorf("referenced Embedding '%s' not found", r.EmbeddingRef)
+	}
+
+	return nil
+}
+
 // ValidateReferences 验证Chain配置的引用
 func (c *ChainConfig) ValidateReferences(config *Config) error {
 	if c.Type == "" {
 		return fmt.Errorf("type is required")
 	}
 
-	supportedTypes := []string{"llm", "conversation", "sequential", "stuff_documents", "map_reduce"}
-	if !contains(supportedTypes, c.Type) {
-		return fmt.Errorf("unsupported type: %s, supported: %s", c.Type, strings.Join(supportedTypes, ", "))
+	supported := supportedTypes["chain"]
+	if !contains(supported, c.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", c.Type, strings.Join(supported, ", "))
 	}
 
 	// 验证LLM引用
@@ -301,6 +433,15 @@ func (c *ChainConfig) ValidateReferences(config *Config) error {
 		}
 	}
 
+	// 验证Retriever引用
+	if c.RetrieverRef != "" {
+		if _, exists := config.Retrievers[c.RetrieverRef]; !exists {
+			return fmt.Errorf("referenced Retriever '%s' not found", c.RetrieverRef)
+		}
+	} else if c.Type == "retrieval_qa" {
+		return fmt.Errorf("retriever_ref is required for retrieval_qa chain")
+	}
+
 	return nil
 }
 
@@ -310,9 +451,9 @@ func (a *AgentConfig) ValidateReferences(config *Config) error {
 		return fmt.Errorf("type is required")
 	}
 
-	supportedTypes := []string{"zero_shot_react", "conversational_react"}
-	if !contains(supportedTypes, a.Type) {
-		return fmt.Errorf("unsupported type: %s, supported: %s", a.Type, strings.Join(supportedTypes, ", "))
+	supported := supportedTypes["agent"]
+	if !contains(supported, a.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", a.Type, strings.Join(supported, ", "))
 	}
 
 	// 验证Chain引用
@@ -322,6 +463,16 @@ func (a *AgentConfig) ValidateReferences(config *Config) error {
 		}
 	}
 
+	// 验证OpenAPI工具配置
+	for i, toolsConfig := range a.ToolsFromOpenAPI {
+		if toolsConfig == nil {
+			return fmt.Errorf("tools_from_openapi[%d] is empty", i)
+		}
+		if err := toolsConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid tools_from_openapi[%d]: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -34,6 +34,7 @@ type MemoryUsageConfig struct {
 	MaxMessages    *int                   `json:"max_messages,omitempty"`    // 消息数量限制
 	ReturnMessages *bool                  `json:"return_messages,omitempty"` // 是否返回消息
 	LLM            *LLMConfig             `json:"llm,omitempty"`             // 直接嵌入LLM配置（用于summary类型）
+	Persistence    *PersistenceConfig     `json:"persistence,omitempty"`     // 持久化存储配置（redis/sql）
 	Options        map[string]interface{} `json:"options,omitempty"`         // 其他选项
 }
 
@@ -68,8 +69,11 @@ func LoadExecutorUsageConfigFromFile(filename string) (*ExecutorUsageConfig, err
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// 替换环境变量
-	data = []byte(expandEnvVars(string(data)))
+	// 替换环境变量，YAML文件转换为JSON
+	data, err = configFileData(filename, []byte(expandEnvVars(string(data))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
 
 	var config ExecutorUsageConfig
 	if err := json.Unmarshal(data, &config); err != nil {
@@ -99,8 +103,11 @@ func LoadChainUsageConfigFromFile(filename string) (*ChainUsageConfig, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// 替换环境变量
-	data = []byte(expandEnvVars(string(data)))
+	// 替换环境变量，YAML文件转换为JSON
+	data, err = configFileData(filename, []byte(expandEnvVars(string(data))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
 
 	var config ChainUsageConfig
 	if err := json.Unmarshal(data, &config); err != nil {
@@ -192,6 +199,12 @@ func (m *MemoryUsageConfig) Validate() error {
 		}
 	}
 
+	if m.Persistence != nil {
+		if err := m.Persistence.Validate(); err != nil {
+			return fmt.Errorf("invalid persistence config: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -291,6 +304,7 @@ func (c *ChainUsageConfig) toChainConfig(config *Config) (*ChainConfig, error) {
 			MaxTokenLimit:  c.Memory.MaxTokenLimit,
 			MaxMessages:    c.Memory.MaxMessages,
 			ReturnMessages: c.Memory.ReturnMessages,
+			Persistence:    c.Memory.Persistence,
 			Options:        c.Memory.Options,
 		}
 
@@ -383,6 +397,7 @@ func (e *ExecutorUsageConfig) ToConfig() (*Config, error) {
 				MaxTokenLimit:  e.Memory.MaxTokenLimit,
 				MaxMessages:    e.Memory.MaxMessages,
 				ReturnMessages: e.Memory.ReturnMessages,
+				Persistence:    e.Memory.Persistence,
 				Options:        e.Memory.Options,
 			}
 
@@ -446,6 +461,15 @@ func (e *ExecutorUsageConfig) CreateExecutor() (*agents.Executor, error) {
 		return nil, fmt.Errorf("failed to create executor: %w", err)
 	}
 
+	// Wire executor-level memory so that conversation context is loaded and saved on each call
+	if executorConfig.MemoryRef != "" {
+		mem, err := factory.CreateMemory(config.Memories[executorConfig.MemoryRef], config.LLMs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create executor memory: %w", err)
+		}
+		executor.Memory = mem
+	}
+
 	return executor, nil
 }
 
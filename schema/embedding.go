@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
 	"github.com/tmc/langchaingo/embeddings"
 	"github.com/tmc/langchaingo/embeddings/voyageai"
 	"github.com/tmc/langchaingo/embeddings/huggingface"
 	"github.com/tmc/langchaingo/embeddings/jina"
+	hfllm "github.com/tmc/langchaingo/llms/huggingface"
 	"github.com/tmc/langchaingo/llms/openai"
 )
 
@@ -45,6 +47,8 @@ func (f *EmbeddingFactory) Create(config *EmbeddingConfig) (embeddings.Embedder,
 		return f.createHuggingface(config, apiKey)
 	case "jina":
 		return f.createJina(config, apiKey)
+	case "qwen", "zhipu", "siliconflow":
+		return f.createChineseProvider(config, apiKey)
 	default:
 		return nil, fmt.Errorf("unsupported Embedding type: %s", config.Type)
 	}
@@ -128,6 +132,22 @@ func (f *EmbeddingFactory) createVoyageAI(config *EmbeddingConfig, apiKey string
 func (f *EmbeddingFactory) createHuggingface(config *EmbeddingConfig, apiKey string) (embeddings.Embedder, error) {
 	var opts []huggingface.Option
 
+	// 设置API密钥与基础URL，未设置时由客户端读取 HUGGINGFACEHUB_API_TOKEN
+	if apiKey != "" || config.BaseURL != "" {
+		var clientOpts []hfllm.Option
+		if apiKey != "" {
+			clientOpts = append(clientOpts, hfllm.WithToken(apiKey))
+		}
+		if config.BaseURL != "" {
+			clientOpts = append(clientOpts, hfllm.WithURL(config.BaseURL))
+		}
+		client, err := hfllm.New(clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Huggingface client: %w", err)
+		}
+		opts = append(opts, huggingface.WithClient(*client))
+	}
+
 	// 设置模型
 	if config.Model != "" {
 		opts = append(opts, huggingface.WithModel(config.Model))
@@ -180,20 +200,37 @@ func (f *EmbeddingFactory) createJina(config *EmbeddingConfig, apiKey string) (e
 	return embedder, nil
 }
 
+// createChineseProvider 通过 llmscn.CreateEmbedder 创建国内服务商的Embedding
+// Options 中的其他参数（如通义千问的 dimension、strip_new_lines）原样传递
+func (f *EmbeddingFactory) createChineseProvider(config *EmbeddingConfig, apiKey string) (embeddings.Embedder, error) {
+	params := make(map[string]interface{}, len(config.Options)+4)
+	for key, value := range config.Options {
+		params[key] = value
+	}
+	params["model"] = config.Model
+	if apiKey != "" {
+		params["api_key"] = apiKey
+	}
+	if config.BaseURL != "" {
+		params["base_url"] = config.BaseURL
+	}
+	if config.BatchSize != nil {
+		params["batch_size"] = *config.BatchSize
+	}
+
+	embedder, err := llmscn.CreateEmbedder(llmscn.EmbeddingType(config.Type), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s embedder: %w", config.Type, err)
+	}
+	return embedder, nil
+}
+
 // getDefaultAPIKey 获取默认API密钥
 func (f *EmbeddingFactory) getDefaultAPIKey(embeddingType string) string {
-	switch embeddingType {
-	case "openai":
-		return os.Getenv("OPENAI_API_KEY")
-	case "voyage":
-		return os.Getenv("VOYAGEAI_API_KEY")
-	case "huggingface":
-		return os.Getenv("HUGGINGFACE_API_KEY")
-	case "jina":
-		return os.Getenv("JINA_API_KEY")
-	default:
-		return ""
+	if env := embeddingAPIKeyEnv[embeddingType]; env != "" {
+		return os.Getenv(env)
 	}
+	return ""
 }
 
 // applyOpenAIEmbeddingOptions 应用OpenAI Embedding特定选项
@@ -0,0 +1,198 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrMissingEnv 表示配置依赖的环境变量未设置
+var ErrMissingEnv = errors.New("缺少必需的环境变量")
+
+// envRefPattern 匹配配置中的 ${VAR} 引用
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// llmAPIKeyEnv 各LLM类型未配置 api_key 时读取的环境变量
+var llmAPIKeyEnv = map[string]string{
+	"openai":      "OPENAI_API_KEY",
+	"deepseek":    "DEEPSEEK_API_KEY",
+	"kimi":        "KIMI_API_KEY",
+	"qwen":        "QWEN_API_KEY",
+	"zhipu":       "ZHIPU_API_KEY",
+	"siliconflow": "SILICONFLOW_API_KEY",
+	"ernie":       "ERNIE_API_KEY",
+	"minimax":     "MINIMAX_API_KEY",
+	"doubao":      "DOUBAO_API_KEY",
+	"yi":          "YI_API_KEY",
+	"baichuan":    "BAICHUAN_API_KEY",
+	"anthropic":   "ANTHROPIC_API_KEY",
+}
+
+// llmSecretKeyEnv 需要额外密钥的LLM类型未配置 options.secret_key 时读取的环境变量
+var llmSecretKeyEnv = map[string]string{
+	"ernie": "ERNIE_SECRET_KEY",
+}
+
+// LLMAPIKeyEnv 返回LLM类型未配置 api_key 时读取的环境变量名，不需要API密钥的类型（如 ollama）返回空字符串
+func LLMAPIKeyEnv(llmType string) string {
+	return llmAPIKeyEnv[llmType]
+}
+
+// embeddingAPIKeyEnv 各Embedding类型未配置 api_key 时读取的环境变量
+var embeddingAPIKeyEnv = map[string]string{
+	"openai":      "OPENAI_API_KEY",
+	"voyage":      "VOYAGEAI_API_KEY",
+	"huggingface": "HUGGINGFACE_API_KEY",
+	"jina":        "JINA_API_KEY",
+	"qwen":        "QWEN_API_KEY",
+	"zhipu":       "ZHIPU_API_KEY",
+	"siliconflow": "SILICONFLOW_API_KEY",
+}
+
+// EnvVar 配置依赖的环境变量
+type EnvVar struct {
+	Name    string   `json:"name"`
+	Paths   []string `json:"paths"`             // 引用该变量的配置路径，如 llms.main.api_key
+	Set     bool     `json:"set"`               // 当前进程中是否已设置为非空值
+	Comment string   `json:"comment,omitempty"` // 说明
+}
+
+// RequiredEnv 扫描配置中依赖的环境变量，按名称排序返回
+// 包括配置值中的 ${VAR} 引用（未设置时会被静默替换为空字符串，因此均视为必需），
+// 以及未配置 api_key 的LLM/Embedding组件隐式读取的API密钥环境变量。
+// 配置需在展开环境变量之前加载（如 LoadCodegenConfigFromFile），
+// 否则 ${VAR} 引用已被替换，只能识别隐式的API密钥变量。
+func RequiredEnv(config *Config) []EnvVar {
+	vars := make(map[string]*EnvVar)
+	add := func(name, path, comment string) {
+		v, ok := vars[name]
+		if !ok {
+			v = &EnvVar{Name: name, Set: os.Getenv(name) != ""}
+			vars[name] = v
+		}
+		v.Paths = append(v.Paths, path)
+		if v.Comment == "" {
+			v.Comment = comment
+		}
+	}
+
+	// 配置值中的 ${VAR} 引用
+	data, err := json.Marshal(config)
+	if err == nil {
+		var tree interface{}
+		if json.Unmarshal(data, &tree) == nil {
+			walkEnvRefs(tree, "", func(path, name string) {
+				add(name, path, "")
+			})
+		}
+	}
+
+	// 未配置 api_key 时隐式读取的API密钥
+	for name, llm := range config.LLMs {
+		if llm != nil && llm.APIKey == "" {
+			if env := llmAPIKeyEnv[llm.Type]; env != "" {
+				add(env, "llms."+name+".api_key", llm.Type+" API密钥")
+			}
+		}
+		if llm != nil && llm.Options["secret_key"] == nil && llm.Options["access_token"] == nil {
+			if env := llmSecretKeyEnv[llm.Type]; env != "" {
+				add(env, "llms."+name+".options.secret_key", llm.Type+" Secret Key")
+			}
+		}
+	}
+	for name, embedding := range config.Embeddings {
+		if embedding != nil && embedding.APIKey == "" {
+			if env := embeddingAPIKeyEnv[embedding.Type]; env != "" {
+				add(env, "embeddings."+name+".api_key", embedding.Type+" API密钥")
+			}
+		}
+	}
+
+	result := make([]EnvVar, 0, len(vars))
+	for _, v := range vars {
+		sort.Strings(v.Paths)
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// walkEnvRefs 遍历JSON树，对每个字符串值中的 ${VAR} 引用调用 fn
+func walkEnvRefs(node interface{}, path string, fn func(path, name string)) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			walkEnvRefs(v[key], child, fn)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkEnvRefs(item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case string:
+		for _, match := range envRefPattern.FindAllStringSubmatch(v, -1) {
+			fn(path, match[1])
+		}
+	}
+}
+
+// MissingEnv 返回未设置的环境变量
+func MissingEnv(vars []EnvVar) []EnvVar {
+	var missing []EnvVar
+	for _, v := range vars {
+		if !v.Set {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// CheckEnv 检查配置依赖的环境变量是否均已设置，缺失时返回可与 ErrMissingEnv 匹配的错误
+// 建议在启动时调用，避免在首次请求时才出现难以排查的服务商认证失败
+func CheckEnv(config *Config) error {
+	missing := MissingEnv(RequiredEnv(config))
+	if len(missing) == 0 {
+		return nil
+	}
+	details := make([]string, len(missing))
+	for i, v := range missing {
+		details[i] = fmt.Sprintf("%s (%s)", v.Name, strings.Join(v.Paths, ", "))
+	}
+	return NewConfigurationError("env", ErrMissingEnv.Error()+": "+strings.Join(details, "; "), ErrMissingEnv)
+}
+
+// CheckEnvFile 加载配置文件（不展开环境变量）并检查其依赖的环境变量是否均已设置
+func CheckEnvFile(filename string) error {
+	config, err := LoadCodegenConfigFromFile(filename)
+	if err != nil {
+		return err
+	}
+	return CheckEnv(config)
+}
+
+// EnvExample 生成 .env.example 内容，只列出变量名，不输出当前值以免泄露密钥
+func EnvExample(vars []EnvVar) string {
+	var b strings.Builder
+	b.WriteString("# Generated by langchaingo-cn config-gen env\n")
+	for _, v := range vars {
+		b.WriteString("\n")
+		if v.Comment != "" {
+			fmt.Fprintf(&b, "# %s\n", v.Comment)
+		}
+		fmt.Fprintf(&b, "# 用于: %s\n", strings.Join(v.Paths, ", "))
+		fmt.Fprintf(&b, "%s=\n", v.Name)
+	}
+	return b.String()
+}
@@ -208,6 +208,13 @@ func ValidateConfig(config *Config) *ValidationResult {
 		}
 	}
 
+	// 验证Retriever配置
+	for name, retrieverConfig := range config.Retrievers {
+		if err := retrieverConfig.ValidateReferences(config); err != nil {
+			result.AddError(NewValidationError(fmt.Sprintf("retrievers.%s", name), err.Error(), err))
+		}
+	}
+
 	// 验证Chain配置
 	for name, chainConfig := range config.Chains {
 		if err := chainConfig.ValidateReferences(config); err != nil {
@@ -222,6 +229,13 @@ func ValidateConfig(config *Config) *ValidationResult {
 		}
 	}
 
+	// 验证Graph配置
+	for name, graphConfig := range config.Graphs {
+		if err := graphConfig.Validate(); err != nil {
+			result.AddError(NewValidationError(fmt.Sprintf("graphs.%s", name), err.Error(), err))
+		}
+	}
+
 	// 检查循环引用
 	if cyclicRefs := detectCyclicReferences(config); len(cyclicRefs) > 0 {
 		for _, ref := range cyclicRefs {
@@ -243,6 +257,16 @@ func getDefaultAPIKeyForType(llmType string) string {
 		return "KIMI_API_KEY"
 	case "qwen":
 		return "QWEN_API_KEY"
+	case "ernie":
+		return "ERNIE_API_KEY"
+	case "minimax":
+		return "MINIMAX_API_KEY"
+	case "doubao":
+		return "DOUBAO_API_KEY"
+	case "yi":
+		return "YI_API_KEY"
+	case "baichuan":
+		return "BAICHUAN_API_KEY"
 	case "anthropic":
 		return "ANTHROPIC_API_KEY"
 	default:
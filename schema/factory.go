@@ -3,6 +3,7 @@ package schema
 import (
 	"fmt"
 
+	"github.com/sjzsdu/langchaingo-cn/graph"
 	"github.com/tmc/langchaingo/agents"
 	"github.com/tmc/langchaingo/chains"
 	"github.com/tmc/langchaingo/embeddings"
@@ -17,8 +18,10 @@ type Factory struct {
 	memoryFactory    *MemoryFactory
 	promptFactory    *PromptFactory
 	embeddingFactory *EmbeddingFactory
+	retrieverFactory *RetrieverFactory
 	chainFactory     *ChainFactory
 	agentFactory     *AgentFactory
+	graphFactory     *GraphFactory
 }
 
 // NewFactory 创建新的统一工厂管理器
@@ -35,8 +38,10 @@ func NewFactory() *Factory {
 		memoryFactory:    memoryFactory,
 		promptFactory:    promptFactory,
 		embeddingFactory: embeddingFactory,
+		retrieverFactory: NewRetrieverFactory(embeddingFactory),
 		chainFactory:     chainFactory,
 		agentFactory:     agentFactory,
+		graphFactory:     NewGraphFactory(),
 	}
 }
 
@@ -46,8 +51,10 @@ type Application struct {
 	Memories   map[string]schema.Memory
 	Prompts    map[string]prompts.FormatPrompter
 	Embeddings map[string]embeddings.Embedder
+	Retrievers map[string]schema.Retriever
 	Chains     map[string]chains.Chain
 	Agents     map[string]*agents.Executor
+	Graphs     map[string]*graph.GraphBuilder // 已配置中间件和状态管理器的图构建器，添加节点和边后构建
 }
 
 // CreateApplication 根据配置创建完整的应用程序
@@ -66,8 +73,10 @@ func (f *Factory) CreateApplication(config *Config) (*Application, error) {
 		Memories:   make(map[string]schema.Memory),
 		Prompts:    make(map[string]prompts.FormatPrompter),
 		Embeddings: make(map[string]embeddings.Embedder),
+		Retrievers: make(map[string]schema.Retriever),
 		Chains:     make(map[string]chains.Chain),
 		Agents:     make(map[string]*agents.Executor),
+		Graphs:     make(map[string]*graph.GraphBuilder),
 	}
 
 	// 创建LLM组件
@@ -106,6 +115,15 @@ func (f *Factory) CreateApplication(config *Config) (*Application, error) {
 		app.Embeddings[name] = embedding
 	}
 
+	// 创建Retriever组件
+	for name, retrieverConfig := range config.Retrievers {
+		retriever, err := f.retrieverFactory.Create(retrieverConfig, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Retriever '%s': %w", name, err)
+		}
+		app.Retrievers[name] = retriever
+	}
+
 	// 创建Chain组件
 	for name, chainConfig := range config.Chains {
 		chain, err := f.chainFactory.Create(chainConfig, config)
@@ -124,6 +142,15 @@ func (f *Factory) CreateApplication(config *Config) (*Application, error) {
 		app.Agents[name] = agent
 	}
 
+	// 创建Graph构建器
+	for name, graphConfig := range config.Graphs {
+		builder, err := f.graphFactory.Create(name, graphConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Graph '%s': %w", name, err)
+		}
+		app.Graphs[name] = builder
+	}
+
 	return app, nil
 }
 
@@ -152,6 +179,11 @@ func (f *Factory) CreateEmbedding(config *EmbeddingConfig) (embeddings.Embedder,
 	return f.embeddingFactory.Create(config)
 }
 
+// CreateRetriever 创建单个Retriever组件
+func (f *Factory) CreateRetriever(config *RetrieverConfig, allConfigs *Config) (schema.Retriever, error) {
+	return f.retrieverFactory.Create(config, allConfigs)
+}
+
 // CreateChain 创建单个Chain组件
 func (f *Factory) CreateChain(config *ChainConfig, allConfigs *Config) (chains.Chain, error) {
 	return f.chainFactory.Create(config, allConfigs)
@@ -162,6 +194,11 @@ func (f *Factory) CreateAgent(config *AgentConfig, allConfigs *Config) (*agents.
 	return f.agentFactory.Create(config, allConfigs)
 }
 
+// CreateGraph 创建单个Graph构建器
+func (f *Factory) CreateGraph(id string, config *GraphConfig) (*graph.GraphBuilder, error) {
+	return f.graphFactory.Create(id, config)
+}
+
 // GetLLMFactory 获取LLM工厂
 func (f *Factory) GetLLMFactory() *LLMFactory {
 	return f.llmFactory
@@ -182,6 +219,11 @@ func (f *Factory) GetEmbeddingFactory() *EmbeddingFactory {
 	return f.embeddingFactory
 }
 
+// GetRetrieverFactory 获取Retriever工厂
+func (f *Factory) GetRetrieverFactory() *RetrieverFactory {
+	return f.retrieverFactory
+}
+
 // GetChainFactory 获取Chain工厂
 func (f *Factory) GetChainFactory() *ChainFactory {
 	return f.chainFactory
@@ -192,6 +234,11 @@ func (f *Factory) GetAgentFactory() *AgentFactory {
 	return f.agentFactory
 }
 
+// GetGraphFactory 获取Graph工厂
+func (f *Factory) GetGraphFactory() *GraphFactory {
+	return f.graphFactory
+}
+
 // 全局工厂实例
 var globalFactory *Factory
 
@@ -245,6 +292,11 @@ func CreateEmbeddingFromConfig(config *EmbeddingConfig) (embeddings.Embedder, er
 	return globalFactory.CreateEmbedding(config)
 }
 
+// CreateRetrieverFromConfig 使用全局工厂创建Retriever
+func CreateRetrieverFromConfig(config *RetrieverConfig, allConfigs *Config) (schema.Retriever, error) {
+	return globalFactory.CreateRetriever(config, allConfigs)
+}
+
 // CreateChainFromConfig 使用全局工厂创建Chain
 func CreateChainFromConfig(config *ChainConfig, allConfigs *Config) (chains.Chain, error) {
 	return globalFactory.CreateChain(config, allConfigs)
@@ -255,6 +307,11 @@ func CreateAgentFromConfig(config *AgentConfig, allConfigs *Config) (*agents.Exe
 	return globalFactory.CreateAgent(config, allConfigs)
 }
 
+// CreateGraphFromConfig 使用全局工厂创建Graph构建器
+func CreateGraphFromConfig(id string, config *GraphConfig) (*graph.GraphBuilder, error) {
+	return globalFactory.CreateGraph(id, config)
+}
+
 // CreateExecutorFromUsageConfig 使用全局工厂从UsageConfig创建Executor
 func CreateExecutorFromUsageConfig(config *ExecutorUsageConfig) (*agents.Executor, error) {
 	return config.CreateExecutor()
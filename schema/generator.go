@@ -1,7 +1,6 @@
 package schema
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,7 +23,7 @@ func NewConfigGenerator(outputDir string) *ConfigGenerator {
 
 // LLMTemplate LLM配置模板参数
 type LLMTemplate struct {
-	Type        string  // 必需：LLM类型 (openai, deepseek, kimi, qwen, anthropic, ollama)
+	Type        string  // 必需：LLM类型 (openai, deepseek, kimi, qwen, ernie, minimax, doubao, yi, baichuan, anthropic, ollama)
 	Model       string  // 必需：模型名称
 	APIKey      string  // API密钥（默认使用环境变量）
 	BaseURL     string  // 可选：自定义API基础URL
@@ -47,6 +46,8 @@ type AgentTemplate struct {
 	LLMTemplate LLMTemplate // LLM配置
 	MemoryType  string      // 可选：Memory类型 (默认 conversation_buffer)
 	MaxSteps    int         // 可选：最大步数 (默认 5)
+
+	OpenAPITools []*OpenAPIToolsConfig // 可选：从OpenAPI规范生成的工具
 }
 
 // ExecutorTemplate Executor配置模板参数
@@ -211,8 +212,9 @@ func (g *ConfigGenerator) GenerateAgentConfig(template AgentTemplate, filename s
 		},
 		Agents: map[string]*AgentConfig{
 			"main_agent": {
-				Type:     template.Type,
-				ChainRef: "agent_chain",
+				Type:             template.Type,
+				ChainRef:         "agent_chain",
+				ToolsFromOpenAPI: template.OpenAPITools,
 			},
 		},
 	}
@@ -393,6 +395,35 @@ func (g *ConfigGenerator) GenerateSiliconFlowChatConfig(filename string) error {
 	}, filename)
 }
 
+// GenerateErnieChatConfig 生成文心一言聊天配置
+func (g *ConfigGenerator) GenerateErnieChatConfig(filename string) error {
+	return g.GenerateChainConfig(ChainTemplate{
+		Type: "conversation",
+		LLMTemplate: LLMTemplate{
+			Type:        "ernie",
+			Model:       "ernie-4.0-8k",
+			Temperature: 0.7,
+			MaxTokens:   2048,
+		},
+		MemoryType: "conversation_buffer",
+	}, filename)
+}
+
+// GenerateDoubaoChatConfig 生成豆包聊天配置
+// 使用推理接入点时将 model 替换为接入点ID（ep- 开头）
+func (g *ConfigGenerator) GenerateDoubaoChatConfig(filename string) error {
+	return g.GenerateChainConfig(ChainTemplate{
+		Type: "conversation",
+		LLMTemplate: LLMTemplate{
+			Type:        "doubao",
+			Model:       "doubao-1-5-pro-32k-250115",
+			Temperature: 0.7,
+			MaxTokens:   2048,
+		},
+		MemoryType: "conversation_buffer",
+	}, filename)
+}
+
 // GenerateReactAgentConfig 生成零样本ReAct智能体配置
 func (g *ConfigGenerator) GenerateReactAgentConfig(llmType, model, filename string) error {
 	return g.GenerateAgentConfig(AgentTemplate{
@@ -484,24 +515,10 @@ func (g *ConfigGenerator) GenerateExecutorWithSiliconFlow(filename string) error
 
 // getDefaultAPIKeyEnv 获取默认API密钥环境变量名
 func (g *ConfigGenerator) getDefaultAPIKeyEnv(llmType string) string {
-	switch llmType {
-	case "openai":
-		return "${OPENAI_API_KEY}"
-	case "deepseek":
-		return "${DEEPSEEK_API_KEY}"
-	case "kimi":
-		return "${KIMI_API_KEY}"
-	case "qwen":
-		return "${QWEN_API_KEY}"
-	case "zhipu":
-		return "${ZHIPU_API_KEY}"
-	case "siliconflow":
-		return "${SILICONFLOW_API_KEY}"
-	case "anthropic":
-		return "${ANTHROPIC_API_KEY}"
-	default:
-		return ""
+	if env := llmAPIKeyEnv[llmType]; env != "" {
+		return "${" + env + "}"
 	}
+	return ""
 }
 
 // writeConfigToFile 将配置写入文件
@@ -513,8 +530,8 @@ func (g *ConfigGenerator) writeConfigToFile(config *Config, filename string) err
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// 序列化为JSON（美化格式）
-	data, err := json.MarshalIndent(config, "", "  ")
+	// 按扩展名序列化为YAML或JSON（美化格式）
+	data, err := marshalConfigFile(filename, config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -537,8 +554,8 @@ func (g *ConfigGenerator) writeExecutorConfigToFile(config *ExecutorUsageConfig,
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// 序列化为JSON（美化格式）
-	data, err := json.MarshalIndent(config, "", "  ")
+	// 按扩展名序列化为YAML或JSON（美化格式）
+	data, err := marshalConfigFile(filename, config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -190,6 +191,36 @@ func TestConfigGenerator(t *testing.T) {
 		assert.True(t, exists)
 		assert.Equal(t, 5, int(maxSteps.(float64))) // JSON解析后数字变为float64
 	})
+
+	t.Run("生成YAML配置", func(t *testing.T) {
+		template := ChainTemplate{
+			Type:           "llm",
+			LLMTemplate:    LLMTemplate{Type: "zhipu", Model: "glm-4", Temperature: 0.2},
+			PromptTemplate: "你是一个助手。\n问题：{{.input}}",
+			InputVariables: []string{"input"},
+		}
+		require.NoError(t, generator.GenerateChainConfig(template, "chain.json"))
+		require.NoError(t, generator.GenerateChainConfig(template, "chain.yaml"))
+
+		data, err := os.ReadFile(filepath.Join(tempDir, "chain.yaml"))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), "llms:\n"))
+		assert.NotContains(t, string(data), "null")
+		assert.Contains(t, string(data), "template: |-")
+
+		// 两种格式加载后的配置一致
+		fromJSON, err := LoadCodegenConfigFromFile(filepath.Join(tempDir, "chain.json"))
+		require.NoError(t, err)
+		fromYAML, err := LoadCodegenConfigFromFile(filepath.Join(tempDir, "chain.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, fromJSON, fromYAML)
+
+		require.NoError(t, generator.GenerateExecutorWithDeepSeek("executor.yml"))
+		usage, err := LoadExecutorUsageConfigFromFile(filepath.Join(tempDir, "executor.yml"))
+		require.NoError(t, err)
+		assert.NoError(t, usage.Validate())
+		assert.Equal(t, "deepseek", usage.Agent.Chain.LLM.Type)
+	})
 }
 
 func TestConfigGeneratorPresets(t *testing.T) {
@@ -242,6 +273,17 @@ func TestConfigGeneratorPresets(t *testing.T) {
 		assert.FileExists(t, filePath)
 	})
 
+	t.Run("生成豆包聊天配置", func(t *testing.T) {
+		filename := "doubao_chat.json"
+		err := generator.GenerateDoubaoChatConfig(filename)
+		require.NoError(t, err)
+
+		config, err := LoadConfigFromFile(filepath.Join(tempDir, filename))
+		require.NoError(t, err)
+		assert.True(t, ValidateConfig(config).Valid)
+		assert.Equal(t, "doubao", config.LLMs["chain_llm"].Type)
+	})
+
 	t.Run("生成ReAct智能体配置", func(t *testing.T) {
 		filename := "react_agent.json"
 		err := generator.GenerateReactAgentConfig("deepseek", "deepseek-chat", filename)
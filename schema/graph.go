@@ -0,0 +1,425 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sjzsdu/langchaingo-cn/graph"
+)
+
+const (
+	// defaultStateKeyPrefix Redis状态管理器的默认键前缀
+	defaultStateKeyPrefix = "langchaingo:state:"
+	// defaultCounterKeyPrefix 跨副本共享的限流与断路器计数的默认键前缀
+	defaultCounterKeyPrefix = "langchaingo:counters:"
+)
+
+// GraphConfig 图的运行配置
+// 配置中声明图级的中间件栈和状态管理器，工厂据此创建预先配置好的 graph.GraphBuilder。
+// 节点和边可以由代码添加，也可以通过 definition 或 definition_file 声明拓扑，
+// 构建前调用 GraphBuilder.WithFunctions 绑定定义中引用的函数
+type GraphConfig struct {
+	Name           string              `json:"name,omitempty"`            // 图名称
+	Description    string              `json:"description,omitempty"`     // 图描述
+	TimeoutSeconds *int                `json:"timeout_seconds,omitempty"` // 单次执行超时（秒）
+	MaxConcurrency *int                `json:"max_concurrency,omitempty"` // 最大并发执行数
+	Middleware     []*MiddlewareConfig `json:"middleware,omitempty"`      // 中间件栈，按声明顺序由外向内执行
+	StateManager   *StateManagerConfig `json:"state_manager,omitempty"`   // 状态管理器，为空时不持久化状态
+	SLA            *SLAConfig          `json:"sla,omitempty"`             // 服务等级目标，在滑动窗口内评估并通过 Runnable.OnSLAViolation 通知
+
+	Definition     *graph.GraphDefinition `json:"definition,omitempty"`      // 图拓扑（节点、边、条件和入口点），格式与 graph.Graph.MarshalJSON 一致
+	DefinitionFile string                 `json:"definition_file,omitempty"` // 图拓扑文件（JSON或YAML），与 definition 二选一
+}
+
+// SLAConfig 图的服务等级目标配置，至少需要设置一个目标
+type SLAConfig struct {
+	MaxP95Ms      *int     `json:"max_p95_ms,omitempty"`     // 执行耗时P95上限（毫秒）
+	MaxErrorRate  *float64 `json:"max_error_rate,omitempty"` // 执行失败率上限，取值 (0, 1]
+	WindowSeconds *int     `json:"window_seconds,omitempty"` // 滑动窗口（秒），默认为300
+	MinSamples    *int     `json:"min_samples,omitempty"`    // 窗口内执行次数达到该值后才评估，默认为10
+}
+
+// MiddlewareConfig 图中间件配置
+type MiddlewareConfig struct {
+	Type                string   `json:"type"`                            // logging, metrics, timeout, retry, circuit_breaker, rate_limit
+	Level               string   `json:"level,omitempty"`                 // 日志级别（logging）：none, error, warn, info, debug，默认为 info
+	IncludeState        *bool    `json:"include_state,omitempty"`         // 日志中是否包含状态（logging）
+	TimeoutSeconds      *int     `json:"timeout_seconds,omitempty"`       // 节点默认超时（timeout）
+	MaxRetries          *int     `json:"max_retries,omitempty"`           // 最大重试次数（retry），默认为3
+	RetryDelayMs        *int     `json:"retry_delay_ms,omitempty"`        // 重试间隔毫秒数（retry），默认为1000
+	FailureThreshold    *int     `json:"failure_threshold,omitempty"`     // 触发断路的连续失败次数（circuit_breaker），默认为5
+	ResetTimeoutSeconds *int     `json:"reset_timeout_seconds,omitempty"` // 断路后尝试恢复的等待秒数（circuit_breaker），默认为30
+	Rate                *float64 `json:"rate,omitempty"`                  // 每秒允许的请求数（rate_limit）
+	Burst               *int     `json:"burst,omitempty"`                 // 突发请求数（rate_limit），默认与 rate 相同
+	SharedURL           string   `json:"shared_url,omitempty"`            // Redis连接URL，设置后限流与断路状态在副本间共享（rate_limit, circuit_breaker）
+	SharedKey           string   `json:"shared_key,omitempty"`            // 共享状态的键，默认为中间件类型
+
+	Labels         map[string]string `json:"labels,omitempty"`           // 指标标签名到状态变量名的映射（metrics），如 {"tenant": "tenant_id"}
+	MaxLabelValues *int              `json:"max_label_values,omitempty"` // 每个标签保留的不同取值数量（metrics），默认为100
+}
+
+// StateManagerConfig 图状态管理器配置
+type StateManagerConfig struct {
+	Backend    string `json:"backend"`               // memory, file, redis
+	MaxStates  *int   `json:"max_states,omitempty"`  // 内存中保存的最大状态数（memory），默认为1000
+	Dir        string `json:"dir,omitempty"`         // 状态文件目录（file）
+	URL        string `json:"url,omitempty"`         // Redis连接URL（redis）
+	KeyPrefix  string `json:"key_prefix,omitempty"`  // Redis键前缀（redis），默认为 langchaingo:state:
+	TTLSeconds *int   `json:"ttl_seconds,omitempty"` // 过期时间（秒），仅Redis支持
+	Compress   bool   `json:"compress,omitempty"`    // 是否使用gzip压缩存储的状态（redis）
+}
+
+// logLevels 日志级别名称与 graph.LogLevel 的对应关系
+var logLevels = map[string]graph.LogLevel{
+	"none":  graph.LogLevelNone,
+	"error": graph.LogLevelError,
+	"warn":  graph.LogLevelWarn,
+	"info":  graph.LogLevelInfo,
+	"debug": graph.LogLevelDebug,
+}
+
+// Validate 验证图配置
+func (g *GraphConfig) Validate() error {
+	if g.TimeoutSeconds != nil && *g.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeout_seconds must not be negative")
+	}
+	if g.MaxConcurrency != nil && *g.MaxConcurrency < 0 {
+		return fmt.Errorf("max_concurrency must not be negative")
+	}
+
+	for i, middlewareConfig := range g.Middleware {
+		if middlewareConfig == nil {
+			return fmt.Errorf("middleware[%d] is empty", i)
+		}
+		if err := middlewareConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid middleware[%d]: %w", i, err)
+		}
+	}
+
+	if g.StateManager != nil {
+		if err := g.StateManager.Validate(); err != nil {
+			return fmt.Errorf("invalid state_manager: %w", err)
+		}
+	}
+
+	if g.SLA != nil {
+		if err := g.SLA.Validate(); err != nil {
+			return fmt.Errorf("invalid sla: %w", err)
+		}
+	}
+
+	if g.Definition != nil && g.DefinitionFile != "" {
+		return fmt.Errorf("definition and definition_file cannot both be set")
+	}
+
+	return nil
+}
+
+// Validate 验证服务等级目标配置
+func (s *SLAConfig) Validate() error {
+	if s.MaxP95Ms == nil && s.MaxErrorRate == nil {
+		return fmt.Errorf("max_p95_ms or max_error_rate is required")
+	}
+	if s.MaxP95Ms != nil && *s.MaxP95Ms <= 0 {
+		return fmt.Errorf("max_p95_ms must be positive")
+	}
+	if s.MaxErrorRate != nil && (*s.MaxErrorRate <= 0 || *s.MaxErrorRate > 1) {
+		return fmt.Errorf("max_error_rate must be in (0, 1]")
+	}
+	if s.WindowSeconds != nil && *s.WindowSeconds <= 0 {
+		return fmt.Errorf("window_seconds must be positive")
+	}
+	if s.MinSamples != nil && *s.MinSamples <= 0 {
+		return fmt.Errorf("min_samples must be positive")
+	}
+	return nil
+}
+
+// Validate 验证中间件配置
+func (m *MiddlewareConfig) Validate() error {
+	if m.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+
+	supported := supportedTypes["middleware"]
+	if !contains(supported, m.Type) {
+		return fmt.Errorf("unsupported type: %s, supported: %s", m.Type, strings.Join(supported, ", "))
+	}
+
+	switch m.Type {
+	case "logging":
+		if _, ok := logLevels[m.Level]; m.Level != "" && !ok {
+			return fmt.Errorf("unsupported level: %s, supported: none, error, warn, info, debug", m.Level)
+		}
+	case "timeout":
+		if m.TimeoutSeconds == nil || *m.TimeoutSeconds <= 0 {
+			return fmt.Errorf("timeout_seconds is required for timeout middleware")
+		}
+	case "rate_limit":
+		if m.Rate == nil || *m.Rate <= 0 {
+			return fmt.Errorf("rate is required for rate_limit middleware")
+		}
+	}
+
+	if (len(m.Labels) > 0 || m.MaxLabelValues != nil) && m.Type != "metrics" {
+		return fmt.Errorf("labels are only supported by metrics middleware")
+	}
+	for name, variable := range m.Labels {
+		if name == "" || variable == "" {
+			return fmt.Errorf("label names and variables must not be empty")
+		}
+	}
+	if m.MaxLabelValues != nil && *m.MaxLabelValues <= 0 {
+		return fmt.Errorf("max_label_values must be positive")
+	}
+
+	if m.SharedURL != "" && m.Type != "rate_limit" && m.Type != "circuit_breaker" {
+		return fmt.Errorf("shared_url is only supported by rate_limit and circuit_breaker middleware")
+	}
+
+	return nil
+}
+
+// Validate 验证状态管理器配置
+func (s *StateManagerConfig) Validate() error {
+	switch s.Backend {
+	case "memory":
+	case "file":
+		if s.Dir == "" {
+			return fmt.Errorf("dir is required for file backend")
+		}
+	case "redis":
+		if s.URL == "" {
+			return fmt.Errorf("url is required for redis backend")
+		}
+	case "":
+		return fmt.Errorf("backend is required")
+	default:
+		return fmt.Errorf("unsupported backend: %s, supported: memory, file, redis", s.Backend)
+	}
+
+	return nil
+}
+
+// GraphFactory 图组件工厂
+type GraphFactory struct{}
+
+// NewGraphFactory 创建图工厂实例
+func NewGraphFactory() *GraphFactory {
+	return &GraphFactory{}
+}
+
+// Create 根据配置创建图构建器，调用方继续添加节点和边后构建图
+func (f *GraphFactory) Create(id string, config *GraphConfig) (*graph.GraphBuilder, error) {
+	if config == nil {
+		return nil, fmt.Errorf("Graph config is nil")
+	}
+
+	// 验证配置
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Graph config: %w", err)
+	}
+
+	builder := graph.NewGraph(id)
+	definition, err := f.LoadDefinition(config)
+	if err != nil {
+		return nil, err
+	}
+	if definition != nil {
+		builder.WithDefinition(definition)
+	}
+	if config.Name != "" {
+		builder.WithName(config.Name)
+	}
+	if config.Description != "" {
+		builder.WithDescription(config.Description)
+	}
+	if config.TimeoutSeconds != nil {
+		builder.WithTimeout(time.Duration(*config.TimeoutSeconds) * time.Second)
+	}
+	if config.MaxConcurrency != nil {
+		builder.WithMaxConcurrency(*config.MaxConcurrency)
+	}
+
+	for i, middlewareConfig := range config.Middleware {
+		middleware, err := f.CreateMiddleware(middlewareConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create middleware[%d]: %w", i, err)
+		}
+		builder.WithMiddleware(middleware)
+	}
+
+	if config.StateManager != nil {
+		manager, err := f.CreateStateManager(config.StateManager)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create state manager: %w", err)
+		}
+		builder.WithStateManager(manager)
+	}
+
+	if config.SLA != nil {
+		sla := graph.SLA{
+			Window:     time.Duration(intOrDefault(config.SLA.WindowSeconds, 0)) * time.Second,
+			MinSamples: intOrDefault(config.SLA.MinSamples, 0),
+		}
+		if config.SLA.MaxP95Ms != nil {
+			sla.MaxP95Duration = time.Duration(*config.SLA.MaxP95Ms) * time.Millisecond
+		}
+		if config.SLA.MaxErrorRate != nil {
+			sla.MaxErrorRate = *config.SLA.MaxErrorRate
+		}
+		builder.WithSLA(sla)
+	}
+
+	return builder, nil
+}
+
+// LoadDefinition 返回配置中声明的图拓扑，从 definition_file 读取时支持JSON和YAML，未声明时返回 nil
+func (f *GraphFactory) LoadDefinition(config *GraphConfig) (*graph.GraphDefinition, error) {
+	if config.DefinitionFile == "" {
+		return config.Definition, nil
+	}
+
+	data, err := os.ReadFile(config.DefinitionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph definition: %w", err)
+	}
+	data, err = configFileData(config.DefinitionFile, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse graph definition: %w", err)
+	}
+
+	var definition graph.GraphDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse graph definition: %w", err)
+	}
+	return &definition, nil
+}
+
+// CreateMiddleware 根据配置创建图中间件
+func (f *GraphFactory) CreateMiddleware(config *MiddlewareConfig) (graph.Middleware, error) {
+	if config == nil {
+		return nil, fmt.Errorf("Middleware config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch config.Type {
+	case "logging":
+		level := graph.LogLevelInfo
+		if config.Level != "" {
+			level = logLevels[config.Level]
+		}
+		middleware := graph.NewLoggingMiddleware(level)
+		if config.IncludeState != nil {
+			middleware.IncludeState = *config.IncludeState
+		}
+		return middleware, nil
+	case "metrics":
+		middleware := graph.NewMetricsMiddleware()
+		for _, name := range sortedKeys(config.Labels) {
+			middleware.WithVariableLabel(name, config.Labels[name])
+		}
+		if config.MaxLabelValues != nil {
+			middleware.WithMaxLabelValues(*config.MaxLabelValues)
+		}
+		return middleware, nil
+	case "timeout":
+		return graph.NewTimeoutMiddleware(time.Duration(*config.TimeoutSeconds) * time.Second), nil
+	case "retry":
+		maxRetries := intOrDefault(config.MaxRetries, 3)
+		retryDelay := time.Duration(intOrDefault(config.RetryDelayMs, 1000)) * time.Millisecond
+		return graph.NewRetryMiddleware(maxRetries, retryDelay), nil
+	case "circuit_breaker":
+		threshold := intOrDefault(config.FailureThreshold, 5)
+		resetTimeout := time.Duration(intOrDefault(config.ResetTimeoutSeconds, 30)) * time.Second
+		middleware := graph.NewCircuitBreakerMiddleware(threshold, resetTimeout)
+		if config.SharedURL != "" {
+			store, err := newCounterStore(config.SharedURL)
+			if err != nil {
+				return nil, err
+			}
+			middleware.WithStore(store, sharedKey(config))
+		}
+		return middleware, nil
+	case "rate_limit":
+		burst := int(*config.Rate)
+		if burst < 1 {
+			burst = 1
+		}
+		middleware := graph.NewRateLimitMiddleware(*config.Rate, intOrDefault(config.Burst, burst))
+		if config.SharedURL != "" {
+			store, err := newCounterStore(config.SharedURL)
+			if err != nil {
+				return nil, err
+			}
+			middleware.WithStore(store, sharedKey(config))
+		}
+		return middleware, nil
+	default:
+		return nil, fmt.Errorf("unsupported Middleware type: %s", config.Type)
+	}
+}
+
+// CreateStateManager 根据配置创建图状态管理器
+func (f *GraphFactory) CreateStateManager(config *StateManagerConfig) (graph.StateManager, error) {
+	if config == nil {
+		return nil, fmt.Errorf("StateManager config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch config.Backend {
+	case "memory":
+		return graph.NewMemoryStateManager(intOrDefault(config.MaxStates, 0)), nil
+	case "file":
+		return graph.NewFileStateManager(config.Dir)
+	case "redis":
+		opts, err := redis.ParseURL(config.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+		keyPrefix := config.KeyPrefix
+		if keyPrefix == "" {
+			keyPrefix = defaultStateKeyPrefix
+		}
+		var ttl time.Duration
+		if config.TTLSeconds != nil {
+			ttl = time.Duration(*config.TTLSeconds) * time.Second
+		}
+		return graph.NewRedisStateManager(redis.NewClient(opts), keyPrefix, ttl).WithCompression(config.Compress), nil
+	default:
+		return nil, fmt.Errorf("unsupported StateManager backend: %s", config.Backend)
+	}
+}
+
+// newCounterStore 创建跨副本共享计数的Redis存储
+func newCounterStore(url string) (graph.CounterStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shared_url: %w", err)
+	}
+	return graph.NewRedisCounterStore(redis.NewClient(opts), defaultCounterKeyPrefix), nil
+}
+
+// sharedKey 返回中间件共享状态的键
+func sharedKey(config *MiddlewareConfig) string {
+	if config.SharedKey != "" {
+		return config.SharedKey
+	}
+	return config.Type
+}
+
+// intOrDefault 返回指针指向的值，为空时返回默认值
+func intOrDefault(value *int, defaultValue int) int {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
@@ -0,0 +1,496 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ImportReport LangChain配置导入报告
+type ImportReport struct {
+	Unsupported []string // 无法转换的结构（路径: 说明）
+	Warnings    []string // 已转换但可能存在差异的项
+}
+
+// HasUnsupported 是否存在无法转换的结构
+func (r *ImportReport) HasUnsupported() bool {
+	return len(r.Unsupported) > 0
+}
+
+// langchainLLMTypes Python LangChain 类名/_type 到本包LLM类型的映射
+var langchainLLMTypes = map[string]string{
+	"ChatOpenAI":      "openai",
+	"OpenAI":          "openai",
+	"AzureChatOpenAI": "openai",
+	"openai":          "openai",
+	"openai-chat":     "openai",
+	"ChatAnthropic":   "anthropic",
+	"anthropic":       "anthropic",
+	"anthropic-chat":  "anthropic",
+	"ChatOllama":      "ollama",
+	"Ollama":          "ollama",
+	"ollama":          "ollama",
+	"ollama-llm":      "ollama",
+	"ChatTongyi":      "qwen",
+	"Tongyi":          "qwen",
+	"tongyi":          "qwen",
+	"MoonshotChat":    "kimi",
+	"Moonshot":        "kimi",
+	"moonshot":        "kimi",
+	"ChatZhipuAI":     "zhipu",
+	"zhipuai":         "zhipu",
+	"ChatDeepSeek":    "deepseek",
+	"deepseek":        "deepseek",
+
+	"QianfanChatEndpoint":    "ernie",
+	"QianfanLLMEndpoint":     "ernie",
+	"baidu-qianfan-chat":     "ernie",
+	"baidu-qianfan-endpoint": "ernie",
+
+	"MiniMaxChat": "minimax",
+	"Minimax":     "minimax",
+	"minimax":     "minimax",
+
+	"ChatYi":  "yi",
+	"YiLLM":   "yi",
+	"yi-chat": "yi",
+
+	"ChatBaichuan":  "baichuan",
+	"BaichuanLLM":   "baichuan",
+	"baichuan-chat": "baichuan",
+}
+
+// ImportLangChainConfig 将 Python LangChain 导出的 JSON（langchain.load.dumps 格式
+// 或旧版 _type 格式）转换为本包的 Config
+// 支持 LLM 参数、提示模板、LLMChain/ConversationChain/SequentialChain 以及
+// prompt | llm 形式的简单 LCEL 序列，无法转换的结构会记录在报告中
+func ImportLangChainConfig(data []byte) (*Config, *ImportReport, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse LangChain JSON: %w", err)
+	}
+
+	imp := &langchainImporter{
+		config: &Config{
+			LLMs:     make(map[string]*LLMConfig),
+			Memories: make(map[string]*MemoryConfig),
+			Prompts:  make(map[string]*PromptConfig),
+			Chains:   make(map[string]*ChainConfig),
+		},
+		report:   &ImportReport{},
+		counters: make(map[string]int),
+	}
+
+	// 顶层可以是单个对象，也可以是对象数组
+	items, ok := root.([]interface{})
+	if !ok {
+		items = []interface{}{root}
+	}
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			imp.unsupported(fmt.Sprintf("[%d]", i), "not a JSON object")
+			continue
+		}
+		imp.importObject(fmt.Sprintf("[%d]", i), obj)
+	}
+
+	if len(imp.config.LLMs)+len(imp.config.Prompts)+len(imp.config.Chains) == 0 {
+		return nil, imp.report, fmt.Errorf("no supported LangChain constructs found")
+	}
+
+	return imp.config, imp.report, nil
+}
+
+// langchainImporter 保存导入过程中的状态
+type langchainImporter struct {
+	config   *Config
+	report   *ImportReport
+	counters map[string]int
+}
+
+// importObject 根据对象类型分派导入，返回生成的组件类别和名称
+func (imp *langchainImporter) importObject(path string, obj map[string]interface{}) (kind, name string) {
+	className, kwargs := langchainClass(obj)
+	if className == "" {
+		imp.unsupported(path, "missing lc id or _type")
+		return "", ""
+	}
+
+	if llmType, ok := langchainLLMTypes[className]; ok {
+		return "llm", imp.importLLM(path, llmType, kwargs)
+	}
+
+	switch className {
+	case "PromptTemplate", "prompt":
+		return "prompt", imp.importPrompt(path, kwargs)
+	case "ChatPromptTemplate", "chat":
+		return "prompt", imp.importChatPrompt(path, kwargs)
+	case "LLMChain", "llm_chain":
+		return "chain", imp.importLLMChain(path, "llm", kwargs)
+	case "ConversationChain", "conversation_chain":
+		return "chain", imp.importLLMChain(path, "conversation", kwargs)
+	case "SequentialChain", "SimpleSequentialChain", "sequential_chain", "simple_sequential_chain":
+		return "chain", imp.importSequentialChain(path, kwargs)
+	case "RunnableSequence":
+		return "chain", imp.importRunnableSequence(path, kwargs)
+	case "ConversationBufferMemory", "ConversationBufferWindowMemory":
+		return "memory", imp.importMemory(className, kwargs)
+	default:
+		imp.unsupported(path, fmt.Sprintf("unsupported construct %s", className))
+		return "", ""
+	}
+}
+
+// importLLM 导入LLM配置
+func (imp *langchainImporter) importLLM(path, llmType string, kwargs map[string]interface{}) string {
+	cfg := &LLMConfig{
+		Type:    llmType,
+		Model:   firstString(kwargs, "model_name", "model"),
+		APIKey:  imp.secretValue(kwargs, "openai_api_key", "api_key", "anthropic_api_key", "dashscope_api_key", "moonshot_api_key", "zhipuai_api_key", "qianfan_ak", "minimax_api_key", "yi_api_key", "baichuan_api_key"),
+		BaseURL: firstString(kwargs, "openai_api_base", "base_url", "anthropic_api_url"),
+		Options: make(map[string]interface{}),
+	}
+
+	if v, ok := kwargs["temperature"].(float64); ok {
+		cfg.Temperature = &v
+	}
+	if v, ok := kwargs["max_tokens"].(float64); ok {
+		n := int(v)
+		cfg.MaxTokens = &n
+	}
+	for _, key := range []string{"top_p", "top_k", "streaming"} {
+		if v, ok := kwargs[key]; ok {
+			cfg.Options[key] = v
+		}
+	}
+	if secretKey := imp.secretValue(kwargs, "qianfan_sk"); secretKey != "" {
+		cfg.Options["secret_key"] = secretKey
+	}
+	if groupID := firstString(kwargs, "minimax_group_id"); groupID != "" {
+		cfg.Options["group_id"] = groupID
+	}
+	if cfg.Model == "" {
+		imp.warn(path, "model name not set")
+	}
+
+	name := imp.nextName("llm")
+	imp.config.LLMs[name] = cfg
+	return name
+}
+
+// importPrompt 导入普通提示模板
+func (imp *langchainImporter) importPrompt(path string, kwargs map[string]interface{}) string {
+	cfg := imp.convertPrompt(path, kwargs)
+	if cfg == nil {
+		return ""
+	}
+	name := imp.nextName("prompt")
+	imp.config.Prompts[name] = cfg
+	return name
+}
+
+// convertPrompt 将PromptTemplate参数转换为PromptConfig
+func (imp *langchainImporter) convertPrompt(path string, kwargs map[string]interface{}) *PromptConfig {
+	template, _ := kwargs["template"].(string)
+	if template == "" {
+		imp.unsupported(path, "prompt template is empty")
+		return nil
+	}
+
+	cfg := &PromptConfig{
+		Type:           "prompt_template",
+		Template:       imp.convertTemplate(path, template, kwargs),
+		InputVariables: stringSlice(kwargs["input_variables"]),
+	}
+	if partials, ok := kwargs["partial_variables"].(map[string]interface{}); ok && len(partials) > 0 {
+		cfg.PartialVariables = make(map[string]string, len(partials))
+		for k, v := range partials {
+			cfg.PartialVariables[k] = fmt.Sprint(v)
+		}
+	}
+	return cfg
+}
+
+// importChatPrompt 导入聊天提示模板
+func (imp *langchainImporter) importChatPrompt(path string, kwargs map[string]interface{}) string {
+	messages, _ := kwargs["messages"].([]interface{})
+	cfg := &PromptConfig{
+		Type:           "chat_prompt_template",
+		InputVariables: stringSlice(kwargs["input_variables"]),
+	}
+
+	for i, m := range messages {
+		msgPath := fmt.Sprintf("%s.messages[%d]", path, i)
+		obj, ok := m.(map[string]interface{})
+		if !ok {
+			imp.unsupported(msgPath, "not a JSON object")
+			continue
+		}
+
+		className, msgKwargs := langchainClass(obj)
+		var role string
+		switch className {
+		case "SystemMessagePromptTemplate":
+			role = "system"
+		case "HumanMessagePromptTemplate":
+			role = "human"
+		case "AIMessagePromptTemplate":
+			role = "ai"
+		default:
+			imp.unsupported(msgPath, fmt.Sprintf("unsupported message template %s", className))
+			continue
+		}
+
+		promptObj, _ := msgKwargs["prompt"].(map[string]interface{})
+		_, promptKwargs := langchainClass(promptObj)
+		template, _ := promptKwargs["template"].(string)
+		cfg.Messages = append(cfg.Messages, ChatMessageConfig{
+			Role:     role,
+			Template: imp.convertTemplate(msgPath, template, promptKwargs),
+		})
+	}
+
+	if len(cfg.Messages) == 0 {
+		imp.unsupported(path, "chat prompt has no convertible messages")
+		return ""
+	}
+
+	name := imp.nextName("prompt")
+	imp.config.Prompts[name] = cfg
+	return name
+}
+
+// importMemory 导入Memory配置
+func (imp *langchainImporter) importMemory(className string, kwargs map[string]interface{}) string {
+	cfg := &MemoryConfig{Type: "conversation_buffer"}
+	if v, ok := kwargs["return_messages"].(bool); ok {
+		cfg.ReturnMessages = &v
+	}
+	if className == "ConversationBufferWindowMemory" {
+		if k, ok := kwargs["k"].(float64); ok {
+			// 窗口大小k表示对话轮数，每轮包含两条消息
+			n := int(k) * 2
+			cfg.MaxMessages = &n
+		}
+	}
+
+	name := imp.nextName("memory")
+	imp.config.Memories[name] = cfg
+	return name
+}
+
+// importLLMChain 导入LLMChain/ConversationChain
+func (imp *langchainImporter) importLLMChain(path, chainType string, kwargs map[string]interface{}) string {
+	cfg := &ChainConfig{Type: chainType}
+
+	if llm, ok := kwargs["llm"].(map[string]interface{}); ok {
+		if kind, name := imp.importObject(path+".llm", llm); kind == "llm" {
+			cfg.LLMRef = name
+		}
+	}
+	if prompt, ok := kwargs["prompt"].(map[string]interface{}); ok {
+		if kind, name := imp.importObject(path+".prompt", prompt); kind == "prompt" {
+			cfg.PromptRef = name
+		}
+	}
+	if memory, ok := kwargs["memory"].(map[string]interface{}); ok {
+		if kind, name := imp.importObject(path+".memory", memory); kind == "memory" {
+			cfg.MemoryRef = name
+		}
+	}
+	if outputKey, ok := kwargs["output_key"].(string); ok && outputKey != "" {
+		cfg.OutputKeys = []string{outputKey}
+	}
+
+	if cfg.LLMRef == "" {
+		imp.unsupported(path, "chain has no convertible llm")
+		return ""
+	}
+
+	name := imp.nextName("chain")
+	imp.config.Chains[name] = cfg
+	return name
+}
+
+// importSequentialChain 导入顺序链
+func (imp *langchainImporter) importSequentialChain(path string, kwargs map[string]interface{}) string {
+	subChains, _ := kwargs["chains"].([]interface{})
+	cfg := &ChainConfig{
+		Type:       "sequential",
+		InputKeys:  stringSlice(kwargs["input_variables"]),
+		OutputKeys: stringSlice(kwargs["output_variables"]),
+	}
+
+	for i, sub := range subChains {
+		subPath := fmt.Sprintf("%s.chains[%d]", path, i)
+		obj, ok := sub.(map[string]interface{})
+		if !ok {
+			imp.unsupported(subPath, "not a JSON object")
+			continue
+		}
+		if kind, name := imp.importObject(subPath, obj); kind == "chain" && name != "" {
+			cfg.Chains = append(cfg.Chains, name)
+		}
+	}
+
+	if len(cfg.Chains) == 0 {
+		imp.unsupported(path, "sequential chain has no convertible sub-chains")
+		return ""
+	}
+
+	name := imp.nextName("chain")
+	imp.config.Chains[name] = cfg
+	return name
+}
+
+// importRunnableSequence 导入 prompt | llm [| parser] 形式的LCEL序列
+func (imp *langchainImporter) importRunnableSequence(path string, kwargs map[string]interface{}) string {
+	var steps []interface{}
+	if first, ok := kwargs["first"]; ok {
+		steps = append(steps, first)
+	}
+	if middle, ok := kwargs["middle"].([]interface{}); ok {
+		steps = append(steps, middle...)
+	}
+	if last, ok := kwargs["last"]; ok {
+		steps = append(steps, last)
+	}
+
+	cfg := &ChainConfig{Type: "llm"}
+	for i, step := range steps {
+		stepPath := fmt.Sprintf("%s.steps[%d]", path, i)
+		obj, ok := step.(map[string]interface{})
+		if !ok {
+			imp.unsupported(stepPath, "not a JSON object")
+			continue
+		}
+
+		className, _ := langchainClass(obj)
+		if className == "StrOutputParser" {
+			continue
+		}
+
+		kind, name := imp.importObject(stepPath, obj)
+		switch {
+		case kind == "prompt" && cfg.PromptRef == "" && cfg.LLMRef == "":
+			cfg.PromptRef = name
+		case kind == "llm" && cfg.LLMRef == "":
+			cfg.LLMRef = name
+		case kind != "":
+			imp.unsupported(stepPath, fmt.Sprintf("%s step cannot be expressed in an llm chain", className))
+		}
+	}
+
+	if cfg.LLMRef == "" {
+		imp.unsupported(path, "runnable sequence has no llm step")
+		return ""
+	}
+
+	name := imp.nextName("chain")
+	imp.config.Chains[name] = cfg
+	return name
+}
+
+// fstringVarPattern 匹配Python f-string模板变量
+var fstringVarPattern = regexp.MustCompile(`\{\{|\}\}|\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// convertTemplate 将Python模板转换为Go模板语法
+func (imp *langchainImporter) convertTemplate(path, template string, kwargs map[string]interface{}) string {
+	format, _ := kwargs["template_format"].(string)
+	switch format {
+	case "", "f-string":
+		return fstringVarPattern.ReplaceAllStringFunc(template, func(m string) string {
+			switch m {
+			case "{{":
+				return "{"
+			case "}}":
+				return "}"
+			default:
+				return "{{." + m[1:len(m)-1] + "}}"
+			}
+		})
+	case "mustache", "jinja2":
+		imp.warn(path, fmt.Sprintf("%s template kept as-is, review variable syntax", format))
+		return template
+	default:
+		imp.warn(path, fmt.Sprintf("unknown template format %s", format))
+		return template
+	}
+}
+
+// secretValue 读取API密钥，lc secret 引用会转换为环境变量占位符
+func (imp *langchainImporter) secretValue(kwargs map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := kwargs[key].(type) {
+		case string:
+			return v
+		case map[string]interface{}:
+			if ids := stringSlice(v["id"]); v["type"] == "secret" && len(ids) > 0 {
+				return "${" + ids[0] + "}"
+			}
+		}
+	}
+	return ""
+}
+
+// nextName 生成组件名称
+func (imp *langchainImporter) nextName(kind string) string {
+	imp.counters[kind]++
+	return fmt.Sprintf("imported_%s_%d", kind, imp.counters[kind])
+}
+
+// unsupported 记录无法转换的结构
+func (imp *langchainImporter) unsupported(path, message string) {
+	imp.report.Unsupported = append(imp.report.Unsupported, fmt.Sprintf("%s: %s", path, message))
+}
+
+// warn 记录转换警告
+func (imp *langchainImporter) warn(path, message string) {
+	imp.report.Warnings = append(imp.report.Warnings, fmt.Sprintf("%s: %s", path, message))
+}
+
+// langchainClass 返回对象的类名和参数
+// 支持 {"lc":1,"type":"constructor","id":[...],"kwargs":{...}} 与 {"_type":"..."} 两种格式
+func langchainClass(obj map[string]interface{}) (string, map[string]interface{}) {
+	if obj == nil {
+		return "", nil
+	}
+	if ids := stringSlice(obj["id"]); obj["lc"] != nil && len(ids) > 0 {
+		kwargs, _ := obj["kwargs"].(map[string]interface{})
+		if kwargs == nil {
+			kwargs = map[string]interface{}{}
+		}
+		return ids[len(ids)-1], kwargs
+	}
+	if t, ok := obj["_type"].(string); ok {
+		return t, obj
+	}
+	return "", nil
+}
+
+// firstString 返回第一个非空的字符串字段
+func firstString(m map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := m[key].(string); ok && strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// stringSlice 将JSON数组转换为字符串切片
+func stringSlice(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
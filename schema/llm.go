@@ -10,10 +10,16 @@ import (
 	"github.com/tmc/langchaingo/llms/openai"
 
 	// 本地LLM包
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+	"github.com/sjzsdu/langchaingo-cn/llms/baichuan"
 	"github.com/sjzsdu/langchaingo-cn/llms/deepseek"
+	"github.com/sjzsdu/langchaingo-cn/llms/doubao"
+	"github.com/sjzsdu/langchaingo-cn/llms/ernie"
 	"github.com/sjzsdu/langchaingo-cn/llms/kimi"
+	"github.com/sjzsdu/langchaingo-cn/llms/minimax"
 	"github.com/sjzsdu/langchaingo-cn/llms/qwen"
 	"github.com/sjzsdu/langchaingo-cn/llms/siliconflow"
+	"github.com/sjzsdu/langchaingo-cn/llms/yi"
 	"github.com/sjzsdu/langchaingo-cn/llms/zhipu"
 )
 
@@ -42,6 +48,35 @@ func (f *LLMFactory) Create(config *LLMConfig) (llms.Model, error) {
 		apiKey = f.getDefaultAPIKey(config.Type)
 	}
 
+	// 全局默认配置与 options.profile 指定的命名配置
+	profileName, _ := config.Options["profile"].(string)
+	profile := llmscn.DefaultProfile()
+	if profileName != "" {
+		named, err := llmscn.GetProfile(profileName)
+		if err != nil {
+			return nil, err
+		}
+		profile = profile.Merge(named)
+	}
+
+	model, err := f.create(config, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 配置中显式设置的值优先，作为调用时的默认选项传入，
+	// 使不支持在创建时设置温度与最大token数的服务商同样生效
+	if config.Temperature != nil {
+		profile.Temperature = config.Temperature
+	}
+	if config.MaxTokens != nil {
+		profile.MaxTokens = *config.MaxTokens
+	}
+	return llmscn.WithProfile(model, profile), nil
+}
+
+// create 按类型创建LLM实例
+func (f *LLMFactory) create(config *LLMConfig, apiKey string) (llms.Model, error) {
 	switch config.Type {
 	case "openai":
 		return f.createOpenAI(config, apiKey)
@@ -55,6 +90,16 @@ func (f *LLMFactory) Create(config *LLMConfig) (llms.Model, error) {
 		return f.createZhipu(config, apiKey)
 	case "siliconflow":
 		return f.createSiliconFlow(config, apiKey)
+	case "ernie":
+		return f.createErnie(config, apiKey)
+	case "minimax":
+		return f.createMiniMax(config, apiKey)
+	case "doubao":
+		return f.createDoubao(config, apiKey)
+	case "yi":
+		return f.createYi(config, apiKey)
+	case "baichuan":
+		return f.createBaichuan(config, apiKey)
 	case "anthropic":
 		return f.createAnthropic(config, apiKey)
 	case "ollama":
@@ -232,6 +277,152 @@ func (f *LLMFactory) createSiliconFlow(config *LLMConfig, apiKey string) (llms.M
 	return siliconflow.New(opts...)
 }
 
+// createErnie 创建文心一言 LLM
+// Secret Key 通过 options.secret_key 设置，未设置时读取环境变量 ERNIE_SECRET_KEY
+func (f *LLMFactory) createErnie(config *LLMConfig, apiKey string) (llms.Model, error) {
+	var opts []ernie.Option
+
+	// 设置API密钥
+	if apiKey != "" {
+		opts = append(opts, ernie.WithAPIKey(apiKey))
+	}
+
+	// 设置模型
+	if config.Model != "" {
+		opts = append(opts, ernie.WithModel(config.Model))
+	}
+
+	// 设置基础URL
+	if config.BaseURL != "" {
+		opts = append(opts, ernie.WithBaseURL(config.BaseURL))
+	}
+
+	// 设置温度
+	if config.Temperature != nil {
+		opts = append(opts, ernie.WithTemperature(*config.Temperature))
+	}
+
+	// 设置最大token数
+	if config.MaxTokens != nil {
+		opts = append(opts, ernie.WithMaxTokens(*config.MaxTokens))
+	}
+
+	// 处理其他选项
+	f.applyErnieOptions(&opts, config.Options)
+
+	return ernie.New(opts...)
+}
+
+// createMiniMax 创建 MiniMax LLM
+// 语音合成接口需要的 GroupId 通过 options.group_id 设置，未设置时读取环境变量 MINIMAX_GROUP_ID
+func (f *LLMFactory) createMiniMax(config *LLMConfig, apiKey string) (llms.Model, error) {
+	var opts []minimax.Option
+
+	// 设置API密钥
+	if apiKey != "" {
+		opts = append(opts, minimax.WithAPIKey(apiKey))
+	}
+
+	// 设置模型
+	if config.Model != "" {
+		opts = append(opts, minimax.WithModel(config.Model))
+	}
+
+	// 设置基础URL
+	if config.BaseURL != "" {
+		opts = append(opts, minimax.WithBaseURL(config.BaseURL))
+	}
+
+	// 设置GroupId
+	if groupID, ok := config.Options["group_id"].(string); ok && groupID != "" {
+		opts = append(opts, minimax.WithGroupID(groupID))
+	}
+
+	return minimax.New(opts...)
+}
+
+// createDoubao 创建豆包 LLM
+// 模型可以是方舟的模型ID或推理接入点ID，options.endpoint_id 指定默认接入点，
+// options.endpoints 设置模型名称到接入点ID的映射
+func (f *LLMFactory) createDoubao(config *LLMConfig, apiKey string) (llms.Model, error) {
+	var opts []doubao.Option
+
+	// 设置API密钥
+	if apiKey != "" {
+		opts = append(opts, doubao.WithAPIKey(apiKey))
+	}
+
+	// 设置模型
+	if config.Model != "" {
+		opts = append(opts, doubao.WithModel(config.Model))
+	}
+
+	// 设置基础URL
+	if config.BaseURL != "" {
+		opts = append(opts, doubao.WithBaseURL(config.BaseURL))
+	}
+
+	// 设置推理接入点
+	if endpointID, ok := config.Options["endpoint_id"].(string); ok && endpointID != "" {
+		opts = append(opts, doubao.WithEndpointID(endpointID))
+	}
+	if endpoints, ok := config.Options["endpoints"].(map[string]interface{}); ok && len(endpoints) > 0 {
+		mapping := make(map[string]string, len(endpoints))
+		for name, endpoint := range endpoints {
+			if s, ok := endpoint.(string); ok {
+				mapping[name] = s
+			}
+		}
+		opts = append(opts, doubao.WithEndpoints(mapping))
+	}
+
+	return doubao.New(opts...)
+}
+
+// createYi 创建零一万物 Yi LLM
+func (f *LLMFactory) createYi(config *LLMConfig, apiKey string) (llms.Model, error) {
+	var opts []yi.Option
+
+	// 设置API密钥
+	if apiKey != "" {
+		opts = append(opts, yi.WithAPIKey(apiKey))
+	}
+
+	// 设置模型
+	if config.Model != "" {
+		opts = append(opts, yi.WithModel(config.Model))
+	}
+
+	// 设置基础URL
+	if config.BaseURL != "" {
+		opts = append(opts, yi.WithBaseURL(config.BaseURL))
+	}
+
+	return yi.New(opts...)
+}
+
+// createBaichuan 创建百川 LLM
+func (f *LLMFactory) createBaichuan(config *LLMConfig, apiKey string) (llms.Model, error) {
+	var opts []baichuan.Option
+
+	// 设置API密钥
+	if apiKey != "" {
+		opts = append(opts, baichuan.WithAPIKey(apiKey))
+	}
+
+	// 设置模型
+	if config.Model != "" {
+		opts = append(opts, baichuan.WithModel(config.Model))
+	}
+
+	// 设置基础URL
+	if config.BaseURL != "" {
+		opts = append(opts, baichuan.WithBaseURL(config.BaseURL))
+	}
+
+	return baichuan.New(opts...)
+}
+
 // createAnthropic 创建Anthropic LLM
 func (f *LLMFactory) createAnthropic(config *LLMConfig, apiKey string) (llms.Model, error) {
 	var opts []anthropic.Option
@@ -273,24 +464,10 @@ func (f *LLMFactory) createOllama(config *LLMConfig) (llms.Model, error) {
 
 // getDefaultAPIKey 获取默认API密钥
 func (f *LLMFactory) getDefaultAPIKey(llmType string) string {
-	switch llmType {
-	case "openai":
-		return os.Getenv("OPENAI_API_KEY")
-	case "deepseek":
-		return os.Getenv("DEEPSEEK_API_KEY")
-	case "kimi":
-		return os.Getenv("KIMI_API_KEY")
-	case "qwen":
-		return os.Getenv("QWEN_API_KEY")
-	case "zhipu":
-		return os.Getenv("ZHIPU_API_KEY")
-	case "siliconflow":
-		return os.Getenv("SILICONFLOW_API_KEY")
-	case "anthropic":
-		return os.Getenv("ANTHROPIC_API_KEY")
-	default:
-		return ""
+	if env := llmAPIKeyEnv[llmType]; env != "" {
+		return os.Getenv(env)
 	}
+	return ""
 }
 
 // applyOpenAIOptions 应用OpenAI特定选项
@@ -393,4 +570,31 @@ func (f *LLMFactory) applySiliconFlowOptions(opts *[]siliconflow.Option, options
 	if embModel, ok := options["embedding_model"].(string); ok && embModel != "" {
 		*opts = append(*opts, siliconflow.WithEmbeddingModel(embModel))
 	}
+
+	// 处理模型档位
+	if tier, ok := options["tier"].(string); ok && tier != "" {
+		*opts = append(*opts, siliconflow.WithTier(siliconflow.Tier(tier)))
+	}
+}
+
+// applyErnieOptions 应用文心一言特定选项
+func (f *LLMFactory) applyErnieOptions(opts *[]ernie.Option, options map[string]interface{}) {
+	if options == nil {
+		return
+	}
+
+	// 处理Secret Key
+	if secretKey, ok := options["secret_key"].(string); ok && secretKey != "" {
+		*opts = append(*opts, ernie.WithSecretKey(secretKey))
+	}
+
+	// 处理访问令牌
+	if token, ok := options["access_token"].(string); ok && token != "" {
+		*opts = append(*opts, ernie.WithAccessToken(token))
+	}
+
+	// 处理topP
+	if topP, ok := options["top_p"].(float64); ok {
+		*opts = append(*opts, ernie.WithTopP(topP))
+	}
 }
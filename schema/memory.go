@@ -45,21 +45,39 @@ func (f *MemoryFactory) Create(config *MemoryConfig, llmConfigs map[string]*LLMC
 
 // createConversationBuffer 创建ConversationBuffer记忆
 func (f *MemoryFactory) createConversationBuffer(config *MemoryConfig) (schema.Memory, error) {
-	mem := memory.NewConversationBuffer()
+	opts, err := f.bufferOptions(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// 设置消息数量限制
 	if config.MaxMessages != nil {
-		// ConversationBuffer没有直接的消息数量限制功能
-		// 可以考虑使用ConversationWindowBuffer
-		return memory.NewConversationWindowBuffer(*config.MaxMessages), nil
+		// ConversationBuffer没有直接的消息数量限制功能，使用ConversationWindowBuffer
+		return memory.NewConversationWindowBuffer(*config.MaxMessages, opts...), nil
 	}
 
+	return memory.NewConversationBuffer(opts...), nil
+}
+
+// bufferOptions 根据配置生成ConversationBuffer选项，包括是否返回消息以及持久化存储
+func (f *MemoryFactory) bufferOptions(config *MemoryConfig) ([]memory.ConversationBufferOption, error) {
+	var opts []memory.ConversationBufferOption
+
 	// 设置是否返回消息
 	if config.ReturnMessages != nil {
-		mem.ReturnMessages = *config.ReturnMessages
+		opts = append(opts, memory.WithReturnMessages(*config.ReturnMessages))
 	}
 
-	return mem, nil
+	// 设置持久化存储
+	if config.Persistence != nil {
+		history, err := NewChatMessageHistory(config.Persistence)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create chat history: %w", err)
+		}
+		opts = append(opts, memory.WithChatHistory(history))
+	}
+
+	return opts, nil
 }
 
 // createConversationSummary 创建ConversationSummary记忆
@@ -88,18 +106,19 @@ func (f *MemoryFactory) createConversationTokenBuffer(config *MemoryConfig, llmC
 		maxTokenLimit = *config.MaxTokenLimit
 	}
 
-	mem := memory.NewConversationTokenBuffer(llm, maxTokenLimit)
-
-	// 设置是否返回消息
-	if config.ReturnMessages != nil {
-		mem.ReturnMessages = *config.ReturnMessages
+	opts, err := f.bufferOptions(config)
+	if err != nil {
+		return nil, err
 	}
 
-	return mem, nil
+	return memory.NewConversationTokenBuffer(llm, maxTokenLimit, opts...), nil
 }
 
 // createSimple 创建Simple记忆
 func (f *MemoryFactory) createSimple(config *MemoryConfig) (schema.Memory, error) {
+	if config.Persistence != nil {
+		return nil, fmt.Errorf("persistence is not supported for simple memory")
+	}
 	return memory.NewSimple(), nil
 }
 
@@ -139,12 +158,10 @@ func (f *MemoryFactory) createConversationTokenBufferWithLLM(config *MemoryConfi
 		maxTokenLimit = *config.MaxTokenLimit
 	}
 
-	mem := memory.NewConversationTokenBuffer(llm, maxTokenLimit)
-
-	// 设置是否返回消息
-	if config.ReturnMessages != nil {
-		mem.ReturnMessages = *config.ReturnMessages
+	opts, err := f.bufferOptions(config)
+	if err != nil {
+		return nil, err
 	}
 
-	return mem, nil
+	return memory.NewConversationTokenBuffer(llm, maxTokenLimit, opts...), nil
 }
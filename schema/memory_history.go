@@ -0,0 +1,325 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	// defaultMemoryNamespace 默认命名空间
+	defaultMemoryNamespace = "langchaingo"
+	// defaultMemoryTableName 默认SQL表名
+	defaultMemoryTableName = "langchaingo_messages"
+)
+
+// tableNamePattern 合法的SQL表名
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewChatMessageHistory 根据持久化配置创建聊天历史存储
+// sql 后端使用 database/sql，需要调用方导入对应的驱动（如 _ "github.com/go-sql-driver/mysql"）
+func NewChatMessageHistory(config *PersistenceConfig) (schema.ChatMessageHistory, error) {
+	if config == nil {
+		return nil, fmt.Errorf("persistence config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = defaultMemoryNamespace
+	}
+
+	switch config.Backend {
+	case "redis":
+		opts, err := redis.ParseURL(config.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+		var ttl time.Duration
+		if config.TTLSeconds != nil {
+			ttl = time.Duration(*config.TTLSeconds) * time.Second
+		}
+		return &redisChatHistory{
+			client: redis.NewClient(opts),
+			key:    namespace + ":memory:" + config.SessionID,
+			ttl:    ttl,
+		}, nil
+	case "sql":
+		tableName := config.TableName
+		if tableName == "" {
+			tableName = defaultMemoryTableName
+		}
+		if !tableNamePattern.MatchString(tableName) {
+			return nil, fmt.Errorf("invalid table name: %s", tableName)
+		}
+		db, err := sql.Open(config.Driver, config.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database (is the %s driver imported?): %w", config.Driver, err)
+		}
+		history := &sqlChatHistory{
+			db:        db,
+			table:     tableName,
+			namespace: namespace,
+			sessionID: config.SessionID,
+			dollar:    config.Driver == "postgres" || config.Driver == "pgx",
+		}
+		if err := history.ensureTable(context.Background()); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return history, nil
+	default:
+		return nil, fmt.Errorf("unsupported backend: %s", config.Backend)
+	}
+}
+
+// storedMessage 持久化的消息格式
+type storedMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// toChatMessage 将持久化的消息还原为 llms.ChatMessage
+func (m storedMessage) toChatMessage() llms.ChatMessage {
+	switch llms.ChatMessageType(m.Role) {
+	case llms.ChatMessageTypeHuman:
+		return llms.HumanChatMessage{Content: m.Content}
+	case llms.ChatMessageTypeAI:
+		return llms.AIChatMessage{Content: m.Content}
+	case llms.ChatMessageTypeSystem:
+		return llms.SystemChatMessage{Content: m.Content}
+	default:
+		return llms.GenericChatMessage{Role: m.Role, Content: m.Content}
+	}
+}
+
+// ================================
+// Redis 聊天历史
+// ================================
+
+// redisChatHistory 基于Redis列表的聊天历史
+type redisChatHistory struct {
+	client *redis.Client
+	key    string
+	ttl    time.Duration
+}
+
+var _ schema.ChatMessageHistory = (*redisChatHistory)(nil)
+
+// AddMessage 实现 schema.ChatMessageHistory 接口
+func (h *redisChatHistory) AddMessage(ctx context.Context, message llms.ChatMessage) error {
+	data, err := json.Marshal(storedMessage{Role: string(message.GetType()), Content: message.GetContent()})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	pipe := h.client.TxPipeline()
+	pipe.RPush(ctx, h.key, data)
+	if h.ttl > 0 {
+		pipe.Expire(ctx, h.key, h.ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// AddUserMessage 实现 schema.ChatMessageHistory 接口
+func (h *redisChatHistory) AddUserMessage(ctx context.Context, message string) error {
+	return h.AddMessage(ctx, llms.HumanChatMessage{Content: message})
+}
+
+// AddAIMessage 实现 schema.ChatMessageHistory 接口
+func (h *redisChatHistory) AddAIMessage(ctx context.Context, message string) error {
+	return h.AddMessage(ctx, llms.AIChatMessage{Content: message})
+}
+
+// Clear 实现 schema.ChatMessageHistory 接口
+func (h *redisChatHistory) Clear(ctx context.Context) error {
+	return h.client.Del(ctx, h.key).Err()
+}
+
+// Messages 实现 schema.ChatMessageHistory 接口
+func (h *redisChatHistory) Messages(ctx context.Context) ([]llms.ChatMessage, error) {
+	items, err := h.client.LRange(ctx, h.key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]llms.ChatMessage, 0, len(items))
+	for _, item := range items {
+		var stored storedMessage
+		if err := json.Unmarshal([]byte(item), &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode message: %w", err)
+		}
+		messages = append(messages, stored.toChatMessage())
+	}
+	return messages, nil
+}
+
+// SetMessages 实现 schema.ChatMessageHistory 接口
+func (h *redisChatHistory) SetMessages(ctx context.Context, messages []llms.ChatMessage) error {
+	pipe := h.client.TxPipeline()
+	pipe.Del(ctx, h.key)
+	for _, message := range messages {
+		data, err := json.Marshal(storedMessage{Role: string(message.GetType()), Content: message.GetContent()})
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+		pipe.RPush(ctx, h.key, data)
+	}
+	if h.ttl > 0 && len(messages) > 0 {
+		pipe.Expire(ctx, h.key, h.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ================================
+// SQL 聊天历史
+// ================================
+
+// sqlChatHistory 基于 database/sql 的聊天历史
+type sqlChatHistory struct {
+	db        *sql.DB
+	table     string
+	namespace string
+	sessionID string
+	// dollar 表示驱动使用 $1 风格的占位符（PostgreSQL）
+	dollar bool
+}
+
+var _ schema.ChatMessageHistory = (*sqlChatHistory)(nil)
+
+// ensureTable 创建消息表（如不存在）
+func (h *sqlChatHistory) ensureTable(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	namespace VARCHAR(255) NOT NULL,
+	session_id VARCHAR(255) NOT NULL,
+	seq INTEGER NOT NULL,
+	role VARCHAR(32) NOT NULL,
+	content TEXT NOT NULL
+)`, h.table)
+	if _, err := h.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", h.table, err)
+	}
+	return nil
+}
+
+// bind 按驱动风格替换占位符
+func (h *sqlChatHistory) bind(query string) string {
+	if !h.dollar {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&sb, "$%d", n)
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// AddMessage 实现 schema.ChatMessageHistory 接口
+func (h *sqlChatHistory) AddMessage(ctx context.Context, message llms.ChatMessage) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := h.insert(ctx, tx, []llms.ChatMessage{message}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insert 在事务中按顺序追加消息
+func (h *sqlChatHistory) insert(ctx context.Context, tx *sql.Tx, messages []llms.ChatMessage) error {
+	var seq int
+	row := tx.QueryRowContext(ctx,
+		h.bind(fmt.Sprintf("SELECT COALESCE(MAX(seq), 0) FROM %s WHERE namespace = ? AND session_id = ?", h.table)),
+		h.namespace, h.sessionID)
+	if err := row.Scan(&seq); err != nil {
+		return err
+	}
+
+	query := h.bind(fmt.Sprintf("INSERT INTO %s (namespace, session_id, seq, role, content) VALUES (?, ?, ?, ?, ?)", h.table))
+	for _, message := range messages {
+		seq++
+		if _, err := tx.ExecContext(ctx, query, h.namespace, h.sessionID, seq, string(message.GetType()), message.GetContent()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddUserMessage 实现 schema.ChatMessageHistory 接口
+func (h *sqlChatHistory) AddUserMessage(ctx context.Context, message string) error {
+	return h.AddMessage(ctx, llms.HumanChatMessage{Content: message})
+}
+
+// AddAIMessage 实现 schema.ChatMessageHistory 接口
+func (h *sqlChatHistory) AddAIMessage(ctx context.Context, message string) error {
+	return h.AddMessage(ctx, llms.AIChatMessage{Content: message})
+}
+
+// Clear 实现 schema.ChatMessageHistory 接口
+func (h *sqlChatHistory) Clear(ctx context.Context) error {
+	_, err := h.db.ExecContext(ctx,
+		h.bind(fmt.Sprintf("DELETE FROM %s WHERE namespace = ? AND session_id = ?", h.table)),
+		h.namespace, h.sessionID)
+	return err
+}
+
+// Messages 实现 schema.ChatMessageHistory 接口
+func (h *sqlChatHistory) Messages(ctx context.Context) ([]llms.ChatMessage, error) {
+	rows, err := h.db.QueryContext(ctx,
+		h.bind(fmt.Sprintf("SELECT role, content FROM %s WHERE namespace = ? AND session_id = ? ORDER BY seq", h.table)),
+		h.namespace, h.sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []llms.ChatMessage
+	for rows.Next() {
+		var stored storedMessage
+		if err := rows.Scan(&stored.Role, &stored.Content); err != nil {
+			return nil, err
+		}
+		messages = append(messages, stored.toChatMessage())
+	}
+	return messages, rows.Err()
+}
+
+// SetMessages 实现 schema.ChatMessageHistory 接口
+func (h *sqlChatHistory) SetMessages(ctx context.Context, messages []llms.ChatMessage) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		h.bind(fmt.Sprintf("DELETE FROM %s WHERE namespace = ? AND session_id = ?", h.table)),
+		h.namespace, h.sessionID); err != nil {
+		return err
+	}
+	if err := h.insert(ctx, tx, messages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,556 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/tools"
+	"gopkg.in/yaml.v3"
+
+	llmscn "github.com/sjzsdu/langchaingo-cn/llms"
+)
+
+// openAPIMethods OpenAPI中支持转换为工具的HTTP方法
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete"}
+
+// toolNamePattern 工具名称中不允许出现的字符
+var toolNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// maxOpenAPIResponseSize 工具返回的响应体最大长度
+const maxOpenAPIResponseSize = 1 << 20
+
+// OpenAPIFilter 选择需要转换为工具的OpenAPI操作，各条件均为空时转换全部操作
+type OpenAPIFilter struct {
+	Operations []string `json:"operations,omitempty"` // operationId 或 "GET /pets" 形式的操作列表
+	Tags       []string `json:"tags,omitempty"`       // 包含任一标签的操作
+	Methods    []string `json:"methods,omitempty"`    // 允许的HTTP方法，如 GET、POST
+}
+
+// OpenAPIToolsConfig 从OpenAPI规范生成Agent工具的配置
+type OpenAPIToolsConfig struct {
+	Spec           string            `json:"spec"`                      // 规范文件路径或URL，支持JSON和YAML
+	BaseURL        string            `json:"base_url,omitempty"`        // 接口地址，默认使用规范中的第一个 servers
+	Headers        map[string]string `json:"headers,omitempty"`         // 每个请求附加的请求头，如认证信息
+	TimeoutSeconds *int              `json:"timeout_seconds,omitempty"` // 请求超时（秒），默认30秒
+	OpenAPIFilter
+}
+
+// Validate 验证OpenAPI工具配置
+func (c *OpenAPIToolsConfig) Validate() error {
+	if c.Spec == "" {
+		return fmt.Errorf("spec is required")
+	}
+	for _, method := range c.Methods {
+		if !contains(openAPIMethods, strings.ToLower(method)) {
+			return fmt.Errorf("unsupported method: %s, supported: %s", method, strings.ToUpper(strings.Join(openAPIMethods, ", ")))
+		}
+	}
+	return nil
+}
+
+// ToolsFromOpenAPI 将OpenAPI规范中选中的操作转换为Agent工具
+// 每个工具根据参数和请求体生成JSON Schema，调用时发送对应的HTTP请求并返回响应内容
+func ToolsFromOpenAPI(specPath string, filters OpenAPIFilter) ([]tools.Tool, error) {
+	config := &OpenAPIToolsConfig{Spec: specPath, OpenAPIFilter: filters}
+	return config.Tools()
+}
+
+// Tools 根据配置加载OpenAPI规范并生成工具
+func (c *OpenAPIToolsConfig) Tools() ([]tools.Tool, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	doc, err := loadOpenAPISpec(c.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version in %s: only OpenAPI 3.x is supported", c.Spec)
+	}
+
+	baseURL, err := c.resolveBaseURL(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := 30 * time.Second
+	if c.TimeoutSeconds != nil {
+		timeout = time.Duration(*c.TimeoutSeconds) * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	var result []tools.Tool
+	for _, path := range sortedKeys(paths) {
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok || !c.matches(method, path, op) {
+				continue
+			}
+			tool, err := newOpenAPITool(doc, method, path, item, op)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert operation %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			tool.baseURL = baseURL
+			tool.headers = c.Headers
+			tool.client = client
+			result = append(result, tool)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no operations in %s match the filters", c.Spec)
+	}
+	return result, nil
+}
+
+// matches 判断操作是否满足过滤条件
+func (c *OpenAPIToolsConfig) matches(method, path string, op map[string]interface{}) bool {
+	if len(c.Methods) > 0 && !containsFold(c.Methods, method) {
+		return false
+	}
+	if len(c.Operations) > 0 {
+		operationID, _ := op["operationId"].(string)
+		if !contains(c.Operations, operationID) && !containsFold(c.Operations, method+" "+path) {
+			return false
+		}
+	}
+	if len(c.Tags) > 0 {
+		tags, _ := op["tags"].([]interface{})
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok && contains(c.Tags, s) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// resolveBaseURL 确定接口地址，相对地址基于规范的URL解析
+func (c *OpenAPIToolsConfig) resolveBaseURL(doc map[string]interface{}) (string, error) {
+	base := c.BaseURL
+	if base == "" {
+		if servers, ok := doc["servers"].([]interface{}); ok && len(servers) > 0 {
+			if server, ok := servers[0].(map[string]interface{}); ok {
+				base, _ = server["url"].(string)
+			}
+		}
+	}
+	if base == "" {
+		return "", fmt.Errorf("base_url is required when the spec has no servers")
+	}
+
+	parsed, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %s: %w", base, err)
+	}
+	if !parsed.IsAbs() {
+		specURL, err := url.Parse(c.Spec)
+		if err != nil || !specURL.IsAbs() {
+			return "", fmt.Errorf("relative server URL %s requires base_url", base)
+		}
+		parsed = specURL.ResolveReference(parsed)
+	}
+	return strings.TrimSuffix(parsed.String(), "/"), nil
+}
+
+// loadOpenAPISpec 从文件或URL读取OpenAPI规范
+func loadOpenAPISpec(specPath string) (map[string]interface{}, error) {
+	var data []byte
+	if strings.HasPrefix(specPath, "http://") || strings.HasPrefix(specPath, "https://") {
+		resp, err := http.Get(specPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch OpenAPI spec: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch OpenAPI spec: status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read OpenAPI spec: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(specPath); err != nil {
+			return nil, fmt.Errorf("failed to read OpenAPI spec: %w", err)
+		}
+	}
+
+	// YAML是JSON的超集，统一按YAML解析
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	doc, ok := normalizeYAML(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: root must be an object")
+	}
+	return doc, nil
+}
+
+// normalizeYAML 将YAML解析出的非字符串键（如响应码 200）转换为字符串键
+func normalizeYAML(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeYAML(item)
+		}
+		return val
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeYAML(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// openAPIParam 路径、查询或请求头参数
+type openAPIParam struct {
+	name     string
+	in       string
+	required bool
+}
+
+// OpenAPITool 由OpenAPI操作生成的Agent工具，实现 tools.Tool 接口
+type OpenAPITool struct {
+	name        string
+	description string
+	method      string
+	path        string
+	params      []openAPIParam
+	// bodyProperties 平铺到输入中的请求体字段；为 nil 时请求体通过 "body" 字段传入
+	bodyProperties map[string]bool
+	hasBody        bool
+	parameters     map[string]interface{}
+
+	baseURL string
+	headers map[string]string
+	client  *http.Client
+}
+
+var _ tools.Tool = (*OpenAPITool)(nil)
+
+// newOpenAPITool 根据操作定义生成工具
+func newOpenAPITool(doc map[string]interface{}, method, path string, item, op map[string]interface{}) (*OpenAPITool, error) {
+	tool := &OpenAPITool{
+		name:   openAPIToolName(method, path, op),
+		method: strings.ToUpper(method),
+		path:   path,
+	}
+
+	properties := map[string]interface{}{}
+	var required []string
+
+	// 路径级参数在前，操作级参数可覆盖同名参数
+	var rawParams []interface{}
+	if p, ok := item["parameters"].([]interface{}); ok {
+		rawParams = append(rawParams, p...)
+	}
+	if p, ok := op["parameters"].([]interface{}); ok {
+		rawParams = append(rawParams, p...)
+	}
+	seen := map[string]int{}
+	for _, raw := range rawParams {
+		param, _ := resolveOpenAPIRef(doc, raw, 0).(map[string]interface{})
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		if name == "" || in == "cookie" {
+			continue
+		}
+		p := openAPIParam{name: name, in: in, required: in == "path"}
+		if r, ok := param["required"].(bool); ok && r {
+			p.required = true
+		}
+		if i, ok := seen[name]; ok {
+			tool.params[i] = p
+		} else {
+			seen[name] = len(tool.params)
+			tool.params = append(tool.params, p)
+		}
+
+		schema, _ := resolveOpenAPIRef(doc, param["schema"], 0).(map[string]interface{})
+		prop := map[string]interface{}{"type": "string"}
+		for k, v := range schema {
+			prop[k] = v
+		}
+		if desc, ok := param["description"].(string); ok && desc != "" {
+			prop["description"] = desc
+		}
+		properties[name] = prop
+	}
+	for _, p := range tool.params {
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+
+	if body, ok := resolveOpenAPIRef(doc, op["requestBody"], 0).(map[string]interface{}); ok {
+		tool.hasBody = true
+		content, _ := body["content"].(map[string]interface{})
+		media, _ := content["application/json"].(map[string]interface{})
+		schema, _ := resolveOpenAPIRef(doc, media["schema"], 0).(map[string]interface{})
+		bodyRequired, _ := body["required"].(bool)
+
+		if props, ok := schema["properties"].(map[string]interface{}); ok && schema["type"] != "array" {
+			// 对象请求体的字段平铺到工具输入中
+			tool.bodyProperties = map[string]bool{}
+			for name, prop := range props {
+				if _, exists := properties[name]; exists {
+					continue
+				}
+				properties[name] = prop
+				tool.bodyProperties[name] = true
+			}
+			if req, ok := schema["required"].([]interface{}); ok {
+				for _, r := range req {
+					if s, ok := r.(string); ok && tool.bodyProperties[s] {
+						required = append(required, s)
+					}
+				}
+			}
+		} else {
+			if schema == nil {
+				schema = map[string]interface{}{}
+			}
+			properties["body"] = schema
+			if bodyRequired {
+				required = append(required, "body")
+			}
+		}
+	}
+
+	tool.parameters = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		tool.parameters["required"] = required
+	}
+
+	summary, _ := op["summary"].(string)
+	description, _ := op["description"].(string)
+	desc := strings.TrimSpace(strings.Join([]string{summary, description}, "\n"))
+	if desc == "" {
+		desc = fmt.Sprintf("调用接口 %s %s", tool.method, path)
+	}
+	schemaJSON, err := json.Marshal(tool.parameters)
+	if err != nil {
+		return nil, err
+	}
+	tool.description = fmt.Sprintf("%s\n输入为JSON对象，格式如下: %s", desc, schemaJSON)
+	return tool, nil
+}
+
+// openAPIToolName 生成工具名称，优先使用 operationId
+func openAPIToolName(method, path string, op map[string]interface{}) string {
+	name, _ := op["operationId"].(string)
+	if name == "" {
+		name = method + "_" + path
+	}
+	return strings.Trim(toolNamePattern.ReplaceAllString(name, "_"), "_")
+}
+
+// resolveOpenAPIRef 展开 $ref 引用（仅支持文档内引用），超过深度的循环引用保留为空对象
+func resolveOpenAPIRef(doc map[string]interface{}, v interface{}, depth int) interface{} {
+	if depth > 16 {
+		return map[string]interface{}{}
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := val["$ref"].(string); ok {
+			return resolveOpenAPIRef(doc, lookupOpenAPIPointer(doc, ref), depth+1)
+		}
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = resolveOpenAPIRef(doc, item, depth+1)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			items[i] = resolveOpenAPIRef(doc, item, depth+1)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// lookupOpenAPIPointer 按 JSON Pointer（如 #/components/schemas/Pet）查找文档节点
+func lookupOpenAPIPointer(doc map[string]interface{}, ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var current interface{} = doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return current
+}
+
+// Name 返回工具名称
+func (t *OpenAPITool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述，包含输入的JSON Schema
+func (t *OpenAPITool) Description() string {
+	return t.description
+}
+
+// Parameters 返回工具输入的JSON Schema，可用于函数调用
+func (t *OpenAPITool) Parameters() map[string]interface{} {
+	return t.parameters
+}
+
+// Call 将JSON输入转换为HTTP请求并返回响应内容
+func (t *OpenAPITool) Call(ctx context.Context, input string) (string, error) {
+	args := map[string]interface{}{}
+	if strings.TrimSpace(input) != "" {
+		if err := json.Unmarshal([]byte(input), &args); err != nil {
+			// Agent生成的输入常带有说明文字或不规范的JSON，先尝试修复
+			repaired, repairErr := llmscn.RepairJSON(input)
+			if repairErr != nil || json.Unmarshal([]byte(repaired), &args) != nil {
+				return "", fmt.Errorf("invalid input for tool %s: %w", t.name, err)
+			}
+		}
+	}
+
+	path := t.path
+	query := url.Values{}
+	header := http.Header{}
+	for _, p := range t.params {
+		value, ok := args[p.name]
+		if !ok {
+			if p.required {
+				return "", fmt.Errorf("missing required parameter: %s", p.name)
+			}
+			continue
+		}
+		s := formatOpenAPIValue(value)
+		switch p.in {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.name+"}", url.PathEscape(s))
+		case "query":
+			if items, ok := value.([]interface{}); ok {
+				for _, item := range items {
+					query.Add(p.name, formatOpenAPIValue(item))
+				}
+			} else {
+				query.Set(p.name, s)
+			}
+		case "header":
+			header.Set(p.name, s)
+		}
+	}
+
+	var body io.Reader
+	if t.hasBody {
+		var payload interface{}
+		if t.bodyProperties != nil {
+			fields := map[string]interface{}{}
+			for name, value := range args {
+				if t.bodyProperties[name] {
+					fields[name] = value
+				}
+			}
+			payload = fields
+		} else if value, ok := args["body"]; ok {
+			payload = value
+		}
+		if payload != nil {
+			data, err := json.Marshal(payload)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode request body: %w", err)
+			}
+			body = bytes.NewReader(data)
+			header.Set("Content-Type", "application/json")
+		}
+	}
+
+	target := t.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, t.method, target, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request %s %s failed: %w", t.method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOpenAPIResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("request %s %s returned status %d: %s", t.method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return string(data), nil
+}
+
+// formatOpenAPIValue 将参数值转换为字符串
+func formatOpenAPIValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// containsFold 忽略大小写检查切片是否包含指定元素
+func containsFold(slice []string, item string) bool {
+	for _, s := range slice {
+		if strings.EqualFold(s, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// DefaultRetrievalQATemplate retrieval_qa 链未配置 prompt_ref 时使用的提示模板
+// 自定义模板需包含 context（按编号排列的检索文档）和 question 两个变量
+const DefaultRetrievalQATemplate = `请根据以下参考资料回答问题，引用资料时用方括号标注编号，如 [1]。如果参考资料中没有答案，请直接说明不知道，不要编造。
+
+参考资料：
+{{.context}}
+
+问题：{{.question}}
+回答：`
+
+// DefaultCitationSourceKey 引用来源默认读取的文档元数据键
+const DefaultCitationSourceKey = "source"
+
+// retrievalQASourceDocumentsKey 检索文档在输出中的键
+const retrievalQASourceDocumentsKey = "source_documents"
+
+// RetrievalQAChain 检索问答链：检索相关文档，按编号注入提示模板的 context 变量，
+// 并在回答后附加编号对应的引用来源
+type RetrievalQAChain struct {
+	// LLMChain 生成回答的链，提示模板包含 context 和 question 变量
+	LLMChain *chains.LLMChain
+	// Retriever 检索相关文档
+	Retriever schema.Retriever
+	// InputKey 问题的输入键，默认为 query
+	InputKey string
+	// OutputKey 带引用的回答的输出键，默认为 text
+	OutputKey string
+	// SourceKey 引用来源的文档元数据键，默认为 DefaultCitationSourceKey
+	SourceKey string
+	// ReturnSourceDocuments 是否在输出的 source_documents 中返回检索到的文档
+	ReturnSourceDocuments bool
+}
+
+var _ chains.Chain = (*RetrievalQAChain)(nil)
+
+// NewRetrievalQAChain 创建检索问答链
+func NewRetrievalQAChain(llmChain *chains.LLMChain, retriever schema.Retriever) *RetrievalQAChain {
+	return &RetrievalQAChain{
+		LLMChain:  llmChain,
+		Retriever: retriever,
+		InputKey:  "query",
+		OutputKey: "text",
+		SourceKey: DefaultCitationSourceKey,
+	}
+}
+
+// Call 检索文档并生成带编号引用的回答
+func (c *RetrievalQAChain) Call(ctx context.Context, values map[string]any, options ...chains.ChainCallOption) (map[string]any, error) {
+	query, ok := values[c.InputKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: input %s must be a string", chains.ErrInvalidInputValues, c.InputKey)
+	}
+
+	docs, err := c.Retriever.GetRelevantDocuments(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve documents: %w", err)
+	}
+
+	result, err := chains.Call(ctx, c.LLMChain, map[string]any{
+		"context":  numberedContext(docs),
+		"question": query,
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	answer, ok := result[c.LLMChain.OutputKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: answer is not a string", chains.ErrInvalidOutputValues)
+	}
+
+	output := map[string]any{c.OutputKey: answer + c.citations(docs)}
+	if c.ReturnSourceDocuments {
+		output[retrievalQASourceDocumentsKey] = docs
+	}
+	return output, nil
+}
+
+// GetMemory 返回链的记忆，检索问答链不保存上下文
+func (c *RetrievalQAChain) GetMemory() schema.Memory {
+	return memory.NewSimple()
+}
+
+// GetInputKeys 返回链的输入键
+func (c *RetrievalQAChain) GetInputKeys() []string {
+	return []string{c.InputKey}
+}
+
+// GetOutputKeys 返回链的输出键
+func (c *RetrievalQAChain) GetOutputKeys() []string {
+	if c.ReturnSourceDocuments {
+		return []string{c.OutputKey, retrievalQASourceDocumentsKey}
+	}
+	return []string{c.OutputKey}
+}
+
+// numberedContext 将检索文档格式化为 [1] 内容 的编号列表
+func numberedContext(docs []schema.Document) string {
+	parts := make([]string, len(docs))
+	for i, doc := range docs {
+		parts[i] = fmt.Sprintf("[%d] %s", i+1, strings.TrimSpace(doc.PageContent))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// citations 返回附加在回答后的引用列表，编号与 numberedContext 一致
+// 来源取自 SourceKey 元数据，存在 title 元数据时一并显示，均缺失时使用内容摘要
+func (c *RetrievalQAChain) citations(docs []schema.Document) string {
+	if len(docs) == 0 {
+		return ""
+	}
+
+	sourceKey := c.SourceKey
+	if sourceKey == "" {
+		sourceKey = DefaultCitationSourceKey
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\n参考来源：")
+	for i, doc := range docs {
+		source, _ := doc.Metadata[sourceKey].(string)
+		title, _ := doc.Metadata["title"].(string)
+
+		label := source
+		switch {
+		case title != "" && source != "" && title != source:
+			label = fmt.Sprintf("%s (%s)", title, source)
+		case title != "":
+			label = title
+		case source == "":
+			label = excerpt(doc.PageContent, 30)
+		}
+		fmt.Fprintf(&sb, "\n[%d] %s", i+1, label)
+	}
+	return sb.String()
+}
+
+// excerpt 返回内容的前 n 个字符，超出部分以省略号表示
+func excerpt(content string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(content), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+	"github.com/tmc/langchaingo/vectorstores/qdrant"
+)
+
+// defaultRetrieverTopK 未配置 top_k 时返回的文档数
+const defaultRetrieverTopK = 4
+
+// RetrieverFactory Retriever组件工厂
+type RetrieverFactory struct {
+	embeddingFactory *EmbeddingFactory
+}
+
+// NewRetrieverFactory 创建Retriever工厂实例
+func NewRetrieverFactory(embeddingFactory *EmbeddingFactory) *RetrieverFactory {
+	return &RetrieverFactory{
+		embeddingFactory: embeddingFactory,
+	}
+}
+
+// Create 根据配置创建Retriever实例
+func (f *RetrieverFactory) Create(config *RetrieverConfig, allConfigs *Config) (schema.Retriever, error) {
+	if config == nil {
+		return nil, fmt.Errorf("Retriever config is nil")
+	}
+
+	// 验证配置
+	if err := config.ValidateReferences(allConfigs); err != nil {
+		return nil, fmt.Errorf("invalid Retriever config: %w", err)
+	}
+
+	switch config.Type {
+	case "qdrant":
+		return f.createQdrant(config, allConfigs)
+	default:
+		return nil, fmt.Errorf("unsupported Retriever type: %s", config.Type)
+	}
+}
+
+// createQdrant 创建基于Qdrant的检索器
+func (f *RetrieverFactory) createQdrant(config *RetrieverConfig, allConfigs *Config) (schema.Retriever, error) {
+	embedder, err := f.embeddingFactory.Create(allConfigs.Embeddings[config.EmbeddingRef])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Embedding for retriever: %w", err)
+	}
+
+	qdrantURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Qdrant url: %w", err)
+	}
+
+	opts := []qdrant.Option{
+		qdrant.WithURL(*qdrantURL),
+		qdrant.WithCollectionName(config.Collection),
+		qdrant.WithEmbedder(embedder),
+	}
+	if config.APIKey != "" {
+		opts = append(opts, qdrant.WithAPIKey(config.APIKey))
+	}
+	if config.ContentKey != "" {
+		opts = append(opts, qdrant.WithContentKey(config.ContentKey))
+	}
+
+	store, err := qdrant.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Qdrant store: %w", err)
+	}
+
+	topK := defaultRetrieverTopK
+	if config.TopK != nil {
+		topK = *config.TopK
+	}
+	var searchOpts []vectorstores.Option
+	if config.ScoreThreshold != nil {
+		searchOpts = append(searchOpts, vectorstores.WithScoreThreshold(float32(*config.ScoreThreshold)))
+	}
+
+	return vectorstores.ToRetriever(store, topK, searchOpts...), nil
+}
@@ -1,11 +1,23 @@
 package schema
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/sjzsdu/langchaingo-cn/graph"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/chains"
 )
 
 func TestLoadConfigFromJSON(t *testing.T) {
@@ -29,6 +41,86 @@ func TestLoadConfigFromJSON(t *testing.T) {
 	assert.Equal(t, "test-key", config.LLMs["test_llm"].APIKey)
 }
 
+func TestLoadConfigFromYAML(t *testing.T) {
+	os.Setenv("TEST_API_KEY", "secret-key-123")
+	defer os.Unsetenv("TEST_API_KEY")
+
+	yamlConfig := `
+# 主模型
+llms:
+  test_llm:
+    type: openai
+    model: gpt-3.5-turbo
+    api_key: ${TEST_API_KEY}
+    temperature: 0.3
+prompts:
+  qa:
+    type: prompt_template
+    # 多行模板无需转义换行
+    template: |
+      请回答问题：
+      {{.question}}
+    input_variables: [question]
+`
+
+	config, err := LoadConfigFromYAML(yamlConfig)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+	assert.Equal(t, "openai", config.LLMs["test_llm"].Type)
+	assert.Equal(t, "secret-key-123", config.LLMs["test_llm"].APIKey)
+	assert.Equal(t, 0.3, *config.LLMs["test_llm"].Temperature)
+	assert.Equal(t, "请回答问题：\n{{.question}}\n", config.Prompts["qa"].Template)
+	assert.Equal(t, []string{"question"}, config.Prompts["qa"].InputVariables)
+
+	// 按扩展名自动识别格式
+	dir := t.TempDir()
+	path := dir + "/config.yml"
+	require.NoError(t, os.WriteFile(path, []byte(yamlConfig), 0644))
+	fromFile, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, config, fromFile)
+
+	usagePath := dir + "/executor.yaml"
+	require.NoError(t, os.WriteFile(usagePath, []byte(`
+agent:
+  type: zero_shot_react
+  chain:
+    type: llm
+    llm:
+      type: deepseek
+      model: deepseek-chat
+      api_key: ${TEST_API_KEY}
+max_iterations: 3
+`), 0644))
+	usage, err := LoadExecutorUsageConfigFromFile(usagePath)
+	require.NoError(t, err)
+	assert.Equal(t, "deepseek-chat", usage.Agent.Chain.LLM.Model)
+	assert.Equal(t, 3, *usage.MaxIterations)
+
+	// 非字符串键与无效的YAML
+	_, err = LoadConfigFromYAML("llms: [")
+	assert.Error(t, err)
+	_, err = LoadConfigFromYAML("llms:\n  1:\n    type: openai\n")
+	require.NoError(t, err)
+
+	t.Run("marshal round trip", func(t *testing.T) {
+		data, err := MarshalYAML(config)
+		require.NoError(t, err)
+		text := string(data)
+		assert.Contains(t, text, "template: |")
+		assert.NotContains(t, text, "{\"")
+
+		roundTrip, err := LoadConfigFromYAML(text)
+		require.NoError(t, err)
+		assert.Equal(t, config, roundTrip)
+
+		// 会被解析为其他类型的字符串保留引号
+		data, err = MarshalYAML(map[string]string{"flag": "true", "port": "8080", "name": "qa"})
+		require.NoError(t, err)
+		assert.Equal(t, "flag: \"true\"\nname: qa\nport: \"8080\"\n", string(data))
+	})
+}
+
 func TestConfigValidation(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -200,6 +292,44 @@ func TestEmbeddingFactory(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, embedder)
 	})
+
+	t.Run("create qwen embedding with options", func(t *testing.T) {
+		var body struct {
+			Model      string `json:"model"`
+			Parameters struct {
+				Dimension int `json:"dimension"`
+			} `json:"parameters"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			_, _ = w.Write([]byte(`{"output":{"embeddings":[{"text_index":0,"embedding":[0.1,0.2]}]}}`))
+		}))
+		defer server.Close()
+
+		config, err := LoadConfigFromJSON(`{"embeddings": {"embed": {
+			"type": "qwen", "model": "text-embedding-v3", "api_key": "test-key",
+			"base_url": "` + server.URL + `", "options": {"dimension": 768}
+		}}}`)
+		require.NoError(t, err)
+
+		embedder, err := factory.Create(config.Embeddings["embed"])
+		require.NoError(t, err)
+		vector, err := embedder.EmbedQuery(context.Background(), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.1, 0.2}, vector)
+		assert.Equal(t, "text-embedding-v3", body.Model)
+		assert.Equal(t, 768, body.Parameters.Dimension)
+	})
+
+	t.Run("invalid provider options", func(t *testing.T) {
+		_, err := factory.Create(&EmbeddingConfig{
+			Type:    "zhipu",
+			Model:   "embedding-3",
+			APIKey:  "test-key",
+			Options: map[string]interface{}{"batch_size": "many"},
+		})
+		assert.ErrorContains(t, err, "failed to create zhipu embedder")
+	})
 }
 
 func TestCompleteApplicationCreation(t *testing.T) {
@@ -258,6 +388,70 @@ func TestCompleteApplicationCreation(t *testing.T) {
 	assert.NotNil(t, app.Chains["main_chain"])
 }
 
+func TestRetrievalQAChain(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],"model":"m"}`))
+		case "/collections/docs/points/search":
+			assert.Equal(t, "qdrant-key", r.Header.Get("api-key"))
+			assert.Contains(t, string(body), `"limit":2`)
+			w.Write([]byte(`{"result":[
+				{"score":0.9,"payload":{"content":"退货需在签收后7天内申请","source":"https://help.example.com/returns","title":"退货政策"}},
+				{"score":0.8,"payload":{"content":"运费由买家承担","source":"faq.md"}}]}`))
+		case "/v1/chat/completions":
+			prompt = string(body)
+			w.Write([]byte(`{"id":"1","object":"chat.completion","model":"m",` +
+				`"choices":[{"index":0,"message":{"role":"assistant","content":"签收后7天内可以退货 [1]，运费自理 [2]。"},"finish_reason":"stop"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config, err := LoadConfigFromJSON(fmt.Sprintf(`{
+		"llms": {"main": {"type": "openai", "model": "m", "api_key": "k", "base_url": "%[1]s/v1"}},
+		"embeddings": {"embed": {"type": "openai", "model": "m", "api_key": "k", "base_url": "%[1]s/v1"}},
+		"retrievers": {
+			"kb": {"type": "qdrant", "embedding_ref": "embed", "url": "%[1]s", "api_key": "qdrant-key", "collection": "docs", "top_k": 2}
+		},
+		"chains": {
+			"qa": {"type": "retrieval_qa", "llm_ref": "main", "retriever_ref": "kb", "return_source_documents": true}
+		}
+	}`, server.URL))
+	require.NoError(t, err)
+
+	app, err := NewFactory().CreateApplication(config)
+	require.NoError(t, err)
+	assert.NotNil(t, app.Retrievers["kb"])
+
+	chain := app.Chains["qa"]
+	result, err := chains.Call(context.Background(), chain, map[string]any{"query": "怎么退货？"})
+	require.NoError(t, err)
+
+	// 检索文档按编号注入上下文
+	assert.Contains(t, prompt, "[1] 退货需在签收后7天内申请")
+	assert.Contains(t, prompt, "[2] 运费由买家承担")
+	assert.Contains(t, prompt, "问题：怎么退货？")
+
+	assert.Equal(t, "签收后7天内可以退货 [1]，运费自理 [2]。\n\n参考来源：\n"+
+		"[1] 退货政策 (https://help.example.com/returns)\n[2] faq.md", result["text"])
+	assert.Len(t, result["source_documents"], 2)
+
+	t.Run("无效配置", func(t *testing.T) {
+		invalid := *config
+		invalid.Chains = map[string]*ChainConfig{"qa": {Type: "retrieval_qa", LLMRef: "main"}}
+		assert.ErrorContains(t, invalid.Validate(), "retriever_ref is required")
+
+		invalid.Chains = nil
+		invalid.Retrievers = map[string]*RetrieverConfig{"kb": {Type: "qdrant", URL: server.URL, Collection: "docs", EmbeddingRef: "missing"}}
+		assert.ErrorContains(t, invalid.Validate(), "referenced Embedding 'missing' not found")
+	})
+}
+
 func TestEnvironmentVariableExpansion(t *testing.T) {
 	os.Setenv("TEST_API_KEY", "secret-key-123")
 	defer os.Unsetenv("TEST_API_KEY")
@@ -310,3 +504,585 @@ func intPtr(i int) *int {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestImportLangChainConfig(t *testing.T) {
+	data := `{
+		"lc": 1,
+		"type": "constructor",
+		"id": ["langchain", "schema", "runnable", "RunnableSequence"],
+		"kwargs": {
+			"first": {
+				"lc": 1, "type": "constructor",
+				"id": ["langchain", "prompts", "prompt", "PromptTemplate"],
+				"kwargs": {"template": "Translate {text} into {{Chinese}}", "input_variables": ["text"], "template_format": "f-string"}
+			},
+			"middle": [{
+				"lc": 1, "type": "constructor",
+				"id": ["langchain", "chat_models", "openai", "ChatOpenAI"],
+				"kwargs": {"model_name": "gpt-4o", "temperature": 0.2, "openai_api_key": {"lc": 1, "type": "secret", "id": ["OPENAI_API_KEY"]}}
+			}],
+			"last": {"lc": 1, "type": "constructor", "id": ["langchain", "schema", "output_parser", "StrOutputParser"], "kwargs": {}}
+		}
+	}`
+
+	config, report, err := ImportLangChainConfig([]byte(data))
+	require.NoError(t, err)
+	assert.False(t, report.HasUnsupported())
+
+	require.Len(t, config.Chains, 1)
+	chain := config.Chains["imported_chain_1"]
+	require.NotNil(t, chain)
+	assert.Equal(t, "llm", chain.Type)
+
+	llm := config.LLMs[chain.LLMRef]
+	require.NotNil(t, llm)
+	assert.Equal(t, "openai", llm.Type)
+	assert.Equal(t, "gpt-4o", llm.Model)
+	assert.Equal(t, "${OPENAI_API_KEY}", llm.APIKey)
+	assert.Equal(t, 0.2, *llm.Temperature)
+
+	prompt := config.Prompts[chain.PromptRef]
+	require.NotNil(t, prompt)
+	assert.Equal(t, "Translate {{.text}} into {Chinese}", prompt.Template)
+	assert.True(t, ValidateConfig(config).Valid)
+}
+
+func TestImportLangChainConfigUnsupported(t *testing.T) {
+	data := `[
+		{"_type": "llm_chain", "llm": {"_type": "openai", "model_name": "gpt-3.5-turbo"}, "prompt": {"_type": "prompt", "template": "{q}", "input_variables": ["q"]}},
+		{"_type": "vector_db_qa"}
+	]`
+
+	config, report, err := ImportLangChainConfig([]byte(data))
+	require.NoError(t, err)
+	assert.Len(t, config.Chains, 1)
+	require.True(t, report.HasUnsupported())
+	assert.Contains(t, report.Unsupported[0], "vector_db_qa")
+
+	_, _, err = ImportLangChainConfig([]byte(`{"_type": "unknown"}`))
+	assert.Error(t, err)
+}
+
+func TestPersistentMemory(t *testing.T) {
+	server := miniredis.RunT(t)
+	ctx := context.Background()
+
+	config := &MemoryConfig{
+		Type: "conversation_buffer",
+		Persistence: &PersistenceConfig{
+			Backend:    "redis",
+			URL:        "redis://" + server.Addr(),
+			Namespace:  "app",
+			SessionID:  "user-1",
+			TTLSeconds: intPtr(60),
+		},
+	}
+
+	memoryFactory := NewMemoryFactory(NewLLMFactory())
+	first, err := memoryFactory.Create(config, nil)
+	require.NoError(t, err)
+	require.NoError(t, first.SaveContext(ctx, map[string]any{"input": "你好"}, map[string]any{"output": "你好！"}))
+	assert.True(t, server.Exists("app:memory:user-1"))
+
+	// 模拟重启：使用相同配置创建新的Memory实例
+	second, err := memoryFactory.Create(config, nil)
+	require.NoError(t, err)
+	vars, err := second.LoadMemoryVariables(ctx, map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, "Human: 你好\nAI: 你好！", vars["history"])
+
+	_, err = memoryFactory.Create(&MemoryConfig{Type: "simple", Persistence: config.Persistence}, nil)
+	assert.Error(t, err)
+	assert.Error(t, (&PersistenceConfig{Backend: "redis", URL: "redis://localhost"}).Validate())
+
+	executorConfig := &ExecutorUsageConfig{
+		Agent: &AgentUsageConfig{
+			Type: "conversational_react",
+			Chain: &ChainUsageConfig{
+				Type: "llm",
+				LLM:  &LLMConfig{Type: "openai", Model: "gpt-3.5-turbo", APIKey: "test-key"},
+			},
+		},
+		Memory: &MemoryUsageConfig{Type: "conversation_buffer", Persistence: config.Persistence},
+	}
+	executor, err := executorConfig.CreateExecutor()
+	require.NoError(t, err)
+	assert.NotNil(t, executor.Memory)
+}
+
+func TestGraphConfig(t *testing.T) {
+	server := miniredis.RunT(t)
+	ctx := context.Background()
+	rate := 0.001
+
+	config, err := LoadConfigFromJSON(fmt.Sprintf(`{
+		"graphs": {
+			"flaky": {
+				"timeout_seconds": 10,
+				"middleware": [
+					{"type": "rate_limit", "rate": %v, "burst": 1, "shared_url": "redis://%s", "shared_key": "flaky"},
+					{"type": "retry", "max_retries": 2, "retry_delay_ms": 1}
+				],
+				"state_manager": {"backend": "file", "dir": %q}
+			}
+		}
+	}`, rate, server.Addr(), t.TempDir()))
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	// 构建图并执行，节点前两次失败由重试中间件恢复
+	newRunnable := func() *graph.Runnable {
+		app, err := NewFactory().CreateApplication(config)
+		require.NoError(t, err)
+		attempts := 0
+		g, err := app.Graphs["flaky"].
+			AddNode(graph.NewNode("work").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				attempts++
+				if attempts < 3 {
+					return nil, fmt.Errorf("attempt %d failed", attempts)
+				}
+				return state, nil
+			}).Build()).
+			Connect("work", "END").
+			SetEntryPoint("work").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+
+	_, err = newRunnable().Invoke(ctx, graph.NewState("run"))
+	require.NoError(t, err)
+
+	// 限流状态通过Redis在副本间共享，另一个实例的令牌桶已耗尽
+	_, err = newRunnable().Invoke(ctx, graph.NewState("run"))
+	assert.ErrorContains(t, err, "rate limit exceeded")
+
+	manager, err := NewGraphFactory().CreateStateManager(config.Graphs["flaky"].StateManager)
+	require.NoError(t, err)
+	require.NoError(t, manager.Save(ctx, graph.NewState("saved")))
+	loaded, err := manager.Load(ctx, "saved")
+	require.NoError(t, err)
+	assert.Equal(t, "saved", loaded.ID)
+
+	t.Run("Redis状态管理器", func(t *testing.T) {
+		manager, err := NewGraphFactory().CreateStateManager(&StateManagerConfig{
+			Backend:    "redis",
+			URL:        "redis://" + server.Addr(),
+			TTLSeconds: intPtr(60),
+			Compress:   true,
+		})
+		require.NoError(t, err)
+		require.NoError(t, manager.Save(ctx, graph.NewState("saved")))
+		assert.True(t, server.Exists("langchaingo:state:saved"))
+		assert.Equal(t, time.Minute, server.TTL("langchaingo:state:saved"))
+
+		loaded, err := manager.Load(ctx, "saved")
+		require.NoError(t, err)
+		assert.Equal(t, "saved", loaded.ID)
+	})
+
+	t.Run("指标标签", func(t *testing.T) {
+		maxValues := 1
+		middleware, err := NewGraphFactory().CreateMiddleware(&MiddlewareConfig{
+			Type:           "metrics",
+			Labels:         map[string]string{"tenant": "tenant_id"},
+			MaxLabelValues: &maxValues,
+		})
+		require.NoError(t, err)
+		metrics := middleware.(*graph.MetricsMiddleware)
+
+		g, err := graph.NewGraph("labeled").
+			WithMiddleware(metrics).
+			AddNode(graph.NewNode("work").WithFunction(func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				return state, nil
+			}).Build()).
+			Connect("work", "END").
+			SetEntryPoint("work").
+			BuildE()
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		for _, tenant := range []string{"acme", "globex"} {
+			state := graph.NewState(tenant)
+			state.SetVariable("tenant_id", tenant)
+			_, err := runnable.Invoke(ctx, state)
+			require.NoError(t, err)
+		}
+
+		series := metrics.GetLabeledMetrics()
+		require.Len(t, series, 2)
+		tenants := []string{series[0].Labels["tenant"], series[1].Labels["tenant"]}
+		assert.ElementsMatch(t, []string{"acme", graph.OtherLabelValue}, tenants)
+	})
+
+	t.Run("服务等级目标", func(t *testing.T) {
+		graphConfig := &GraphConfig{SLA: &SLAConfig{MaxP95Ms: intPtr(2000), MaxErrorRate: floatPtr(0.05), WindowSeconds: intPtr(60)}}
+		builder, err := NewGraphFactory().Create("sla", graphConfig)
+		require.NoError(t, err)
+		g := builder.Build()
+		require.NotNil(t, g.Config.SLA)
+		assert.Equal(t, 2*time.Second, g.Config.SLA.MaxP95Duration)
+		assert.Equal(t, 0.05, g.Config.SLA.MaxErrorRate)
+		assert.Equal(t, time.Minute, g.Config.SLA.Window)
+		assert.Zero(t, g.Config.SLA.MinSamples)
+	})
+
+	t.Run("图拓扑定义", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "greeter.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(`
+id: greeter
+entry_point: greet
+nodes:
+  - id: greet
+  - id: score
+    expression: "length > 3 ? 'long' : 'short'"
+    output_key: size
+edges:
+  - from: greet
+    to: score
+  - from: score
+    to: END
+`), 0o644))
+
+		builder, err := NewGraphFactory().Create("greeter", &GraphConfig{TimeoutSeconds: intPtr(5), DefinitionFile: file})
+		require.NoError(t, err)
+		g, err := builder.
+			WithFunctions(graph.NewFunctionRegistry().Register("greet", func(ctx context.Context, state *graph.State) (*graph.State, error) {
+				name, _ := state.GetVariable("name")
+				state.SetVariable("greeting", fmt.Sprintf("hello, %s", name))
+				state.SetVariable("length", len(name.(string)))
+				return state, nil
+			})).
+			BuildE()
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, g.Config.Timeout)
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		state := graph.NewState("run")
+		state.SetVariable("name", "gopher")
+		result, err := runnable.Invoke(ctx, state)
+		require.NoError(t, err)
+		greeting, _ := result.GetVariable("greeting")
+		assert.Equal(t, "hello, gopher", greeting)
+		size, _ := result.GetVariable("size")
+		assert.Equal(t, "long", size)
+
+		// 缺少绑定的函数时构建失败
+		builder, err = NewGraphFactory().Create("greeter", &GraphConfig{DefinitionFile: file})
+		require.NoError(t, err)
+		_, err = builder.BuildE()
+		assert.ErrorContains(t, err, "no function registered as greet")
+	})
+
+	t.Run("无效配置", func(t *testing.T) {
+		invalid := []*GraphConfig{
+			{Definition: &graph.GraphDefinition{}, DefinitionFile: "graph.json"},
+			{Middleware: []*MiddlewareConfig{{Type: "unknown"}}},
+			{Middleware: []*MiddlewareConfig{{Type: "logging", Level: "verbose"}}},
+			{Middleware: []*MiddlewareConfig{{Type: "timeout"}}},
+			{Middleware: []*MiddlewareConfig{{Type: "rate_limit"}}},
+			{Middleware: []*MiddlewareConfig{{Type: "metrics", SharedURL: "redis://localhost"}}},
+			{Middleware: []*MiddlewareConfig{{Type: "logging", Labels: map[string]string{"tenant": "tenant"}}}},
+			{Middleware: []*MiddlewareConfig{{Type: "metrics", MaxLabelValues: new(int)}}},
+			{StateManager: &StateManagerConfig{Backend: "file"}},
+			{StateManager: &StateManagerConfig{Backend: "etcd"}},
+			{SLA: &SLAConfig{}},
+			{SLA: &SLAConfig{MaxErrorRate: floatPtr(1.5)}},
+			{SLA: &SLAConfig{MaxP95Ms: intPtr(100), MinSamples: intPtr(0)}},
+		}
+		for _, graphConfig := range invalid {
+			assert.Error(t, graphConfig.Validate())
+			_, err := NewGraphFactory().Create("g", graphConfig)
+			assert.Error(t, err)
+		}
+
+		result := ValidateConfig(&Config{Graphs: map[string]*GraphConfig{"bad": invalid[0]}})
+		assert.True(t, result.HasErrors())
+	})
+}
+
+func TestGenerateGoCode(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/config.json"
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"llms": {
+			"main-llm": {"type": "deepseek", "model": "deepseek-chat", "api_key": "${MY_DEEPSEEK_KEY}"},
+			"kimi": {"type": "kimi", "model": "moonshot-v1-8k", "api_key": "sk-plaintext", "temperature": 0.3}
+		},
+		"memories": {"chat": {"type": "conversation_buffer", "max_messages": 10}},
+		"prompts": {"qa": {"type": "prompt_template", "template": "Q: {{.question}}", "input_variables": ["question"]}},
+		"chains": {
+			"pipeline": {"type": "sequential", "chains": ["qa", "talk"]},
+			"qa": {"type": "llm", "llm_ref": "main-llm", "prompt_ref": "qa"},
+			"talk": {"type": "conversation", "llm_ref": "kimi", "memory_ref": "chat"}
+		},
+		"agents": {"react": {"type": "zero_shot_react", "chain_ref": "qa"}}
+	}`), 0644))
+
+	config, err := LoadCodegenConfigFromFile(path)
+	require.NoError(t, err)
+	code, err := GenerateGoCode(config, CodegenOptions{PackageName: "app"})
+	require.NoError(t, err)
+	src := string(code)
+
+	assert.Contains(t, src, "package app")
+	assert.Regexp(t, `MainLLM\s+llms.Model`, src)
+	assert.Contains(t, src, `deepseek.WithAPIKey(os.Getenv("MY_DEEPSEEK_KEY"))`)
+	assert.Contains(t, src, `kimi.WithToken(os.Getenv("KIMI_API_KEY"))`)
+	assert.NotContains(t, src, "sk-plaintext")
+	assert.Contains(t, src, "memory.NewConversationWindowBuffer(10)")
+	assert.Contains(t, src, "chains.NewSimpleSequentialChain([]chains.Chain{app.QaChain, app.TalkChain})")
+	assert.Contains(t, src, "func NewApp(agentTools []tools.Tool) (*App, error)")
+	// 子链先于顺序链创建
+	assert.Less(t, strings.Index(src, "app.TalkChain ="), strings.Index(src, "app.PipelineChain, err ="))
+
+	// 使用风格配置
+	usagePath := dir + "/executor.json"
+	require.NoError(t, os.WriteFile(usagePath, []byte(`{
+		"agent": {"type": "conversational_react", "chain": {"type": "llm", "llm": {"type": "qwen", "model": "qwen-plus"}}},
+		"memory": {"type": "conversation_buffer"}
+	}`), 0644))
+	config, err = LoadCodegenConfigFromFile(usagePath)
+	require.NoError(t, err)
+	code, err = GenerateGoCode(config, CodegenOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, string(code), "app.MainExecutor.Memory = app.ExecutorMemory")
+	assert.Contains(t, string(code), "agents.NewConversationalAgent(")
+}
+
+func TestToolsFromOpenAPI(t *testing.T) {
+	spec := `openapi: 3.0.0
+info:
+  title: Pets
+  version: "1.0"
+servers:
+  - url: /v1
+paths:
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        schema: {type: integer}
+    get:
+      operationId: getPet
+      summary: 查询宠物
+      tags: [pets]
+      parameters:
+        - name: fields
+          in: query
+          schema: {type: string}
+      responses:
+        200:
+          description: ok
+  /pets:
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        201:
+          description: created
+    delete:
+      operationId: deleteAll
+      tags: [admin]
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        age: {type: integer}
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/openapi.yaml":
+			w.Write([]byte(spec))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pets/7":
+			assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+			fmt.Fprintf(w, `{"id":7,"fields":%q}`, r.URL.Query().Get("fields"))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/pets":
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	toolList, err := ToolsFromOpenAPI(server.URL+"/openapi.yaml", OpenAPIFilter{Tags: []string{"pets"}})
+	require.NoError(t, err)
+	require.Len(t, toolList, 2)
+	assert.Equal(t, "createPet", toolList[0].Name())
+	assert.Equal(t, "getPet", toolList[1].Name())
+
+	create := toolList[0].(*OpenAPITool)
+	assert.Equal(t, []string{"name"}, create.Parameters()["required"])
+	assert.Contains(t, create.Description(), `"age"`)
+
+	output, err := create.Call(context.Background(), `{"name": "旺财", "age": 3}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"旺财","age":3}`, output)
+
+	// 通过配置加载，并附加请求头
+	config, err := LoadConfigFromJSON(fmt.Sprintf(`{
+		"llms": {"llm": {"type": "openai", "model": "gpt-4o", "api_key": "test"}},
+		"chains": {"chain": {"type": "llm", "llm_ref": "llm"}},
+		"agents": {"agent": {
+			"type": "zero_shot_react",
+			"chain_ref": "chain",
+			"tools_from_openapi": [{
+				"spec": "%s/openapi.yaml",
+				"headers": {"X-Api-Key": "secret"},
+				"operations": ["GET /pets/{id}"]
+			}]
+		}}
+	}`, server.URL))
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	toolsConfig := config.Agents["agent"].ToolsFromOpenAPI[0]
+	toolList, err = toolsConfig.Tools()
+	require.NoError(t, err)
+	require.Len(t, toolList, 1)
+
+	// 输入中带说明文字时自动修复
+	output, err = toolList[0].Call(context.Background(), "参数如下: {id: 7, fields: 'name'}")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":7,"fields":"name"}`, output)
+
+	_, err = toolList[0].Call(context.Background(), `{}`)
+	assert.ErrorContains(t, err, "missing required parameter: id")
+
+	_, err = ToolsFromOpenAPI(server.URL+"/openapi.yaml", OpenAPIFilter{Operations: []string{"unknown"}})
+	assert.Error(t, err)
+}
+
+func TestRequiredEnv(t *testing.T) {
+	t.Setenv("MY_DEEPSEEK_KEY", "sk-test")
+	t.Setenv("QWEN_API_KEY", "")
+	path := t.TempDir() + "/config.json"
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"llms": {
+			"main": {"type": "deepseek", "model": "deepseek-chat", "api_key": "${MY_DEEPSEEK_KEY}"},
+			"backup": {"type": "qwen", "model": "qwen-plus"},
+			"local": {"type": "ollama", "model": "qwen2"}
+		},
+		"memories": {"chat": {"type": "conversation_buffer", "persistence": {"backend": "redis", "url": "redis://${REDIS_HOST}:6379/0", "session_id": "s"}}}
+	}`), 0644))
+
+	config, err := LoadCodegenConfigFromFile(path)
+	require.NoError(t, err)
+	vars := RequiredEnv(config)
+	require.Len(t, vars, 3)
+	assert.Equal(t, EnvVar{Name: "MY_DEEPSEEK_KEY", Paths: []string{"llms.main.api_key"}, Set: true}, vars[0])
+	assert.Equal(t, EnvVar{Name: "QWEN_API_KEY", Paths: []string{"llms.backup.api_key"}, Comment: "qwen API密钥"}, vars[1])
+	assert.Equal(t, "REDIS_HOST", vars[2].Name)
+	assert.Equal(t, []string{"memories.chat.persistence.url"}, vars[2].Paths)
+
+	missing := MissingEnv(vars)
+	require.Len(t, missing, 2)
+	err = CheckEnvFile(path)
+	require.ErrorIs(t, err, ErrMissingEnv)
+	assert.Contains(t, err.Error(), "QWEN_API_KEY (llms.backup.api_key)")
+	assert.Contains(t, err.Error(), "REDIS_HOST (memories.chat.persistence.url)")
+
+	example := EnvExample(vars)
+	assert.Contains(t, example, "# qwen API密钥\n# 用于: llms.backup.api_key\nQWEN_API_KEY=\n")
+	assert.Contains(t, example, "MY_DEEPSEEK_KEY=\n")
+	assert.NotContains(t, example, "sk-test")
+
+	t.Setenv("QWEN_API_KEY", "sk-qwen")
+	t.Setenv("REDIS_HOST", "localhost")
+	assert.NoError(t, CheckEnvFile(path))
+}
+
+func TestRequiredEnvErnieSecretKey(t *testing.T) {
+	config, err := LoadConfigFromJSON(`{
+		"llms": {
+			"ernie": {"type": "ernie", "model": "ernie-4.0-8k"},
+			"ernie_token": {"type": "ernie", "model": "ernie-speed-8k", "api_key": "ak", "options": {"access_token": "token"}}
+		}
+	}`)
+	require.NoError(t, err)
+
+	vars := RequiredEnv(config)
+	require.Len(t, vars, 2)
+	assert.Equal(t, "ERNIE_API_KEY", vars[0].Name)
+	assert.Equal(t, EnvVar{Name: "ERNIE_SECRET_KEY", Paths: []string{"llms.ernie.options.secret_key"}, Comment: "ernie Secret Key"}, vars[1])
+
+	code, err := GenerateGoCode(config, CodegenOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, string(code), `ernie.WithAPIKey(os.Getenv("ERNIE_API_KEY"))`)
+	assert.Contains(t, string(code), `ernie.WithModel("ernie-4.0-8k")`)
+	// 明文的访问令牌和 Secret Key 不写入代码
+	assert.NotContains(t, string(code), "WithAccessToken")
+	assert.NotContains(t, string(code), "WithSecretKey")
+}
+
+func TestImportLangChainMiniMax(t *testing.T) {
+	data := `{
+		"lc": 1, "type": "constructor",
+		"id": ["langchain", "chat_models", "minimax", "MiniMaxChat"],
+		"kwargs": {"model": "abab6.5s-chat", "minimax_group_id": "g-1", "minimax_api_key": {"lc": 1, "type": "secret", "id": ["MINIMAX_API_KEY"]}}
+	}`
+
+	config, report, err := ImportLangChainConfig([]byte(data))
+	require.NoError(t, err)
+	assert.False(t, report.HasUnsupported())
+	require.Len(t, config.LLMs, 1)
+	for _, llm := range config.LLMs {
+		assert.Equal(t, "minimax", llm.Type)
+		assert.Equal(t, "${MINIMAX_API_KEY}", llm.APIKey)
+		assert.Equal(t, "g-1", llm.Options["group_id"])
+	}
+	assert.True(t, ValidateConfig(config).Valid)
+
+	code, err := GenerateGoCode(config, CodegenOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, string(code), `minimax.WithAPIKey(os.Getenv("MINIMAX_API_KEY"))`)
+	assert.Contains(t, string(code), `minimax.WithGroupID("g-1")`)
+}
+
+func TestImportLangChainYiBaichuan(t *testing.T) {
+	tests := []struct {
+		id      []string
+		kwargs  string
+		llmType string
+		envVar  string
+	}{
+		{[]string{"langchain", "chat_models", "yi", "ChatYi"}, `{"model": "yi-lightning", "yi_api_key": {"lc": 1, "type": "secret", "id": ["YI_API_KEY"]}}`, "yi", "YI_API_KEY"},
+		{[]string{"langchain", "chat_models", "baichuan", "ChatBaichuan"}, `{"model": "Baichuan4-Turbo", "baichuan_api_key": {"lc": 1, "type": "secret", "id": ["BAICHUAN_API_KEY"]}}`, "baichuan", "BAICHUAN_API_KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.llmType, func(t *testing.T) {
+			id, err := json.Marshal(tt.id)
+			require.NoError(t, err)
+			data := `{"lc": 1, "type": "constructor", "id": ` + string(id) + `, "kwargs": ` + tt.kwargs + `}`
+
+			config, report, err := ImportLangChainConfig([]byte(data))
+			require.NoError(t, err)
+			assert.False(t, report.HasUnsupported())
+			require.Len(t, config.LLMs, 1)
+			for _, llm := range config.LLMs {
+				assert.Equal(t, tt.llmType, llm.Type)
+				assert.Equal(t, "${"+tt.envVar+"}", llm.APIKey)
+			}
+			assert.True(t, ValidateConfig(config).Valid)
+
+			code, err := GenerateGoCode(config, CodegenOptions{})
+			require.NoError(t, err)
+			assert.Contains(t, string(code), tt.llmType+`.WithAPIKey(os.Getenv("`+tt.envVar+`"))`)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package schematest_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/schema"
+	"github.com/sjzsdu/langchaingo-cn/schema/schematest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+)
+
+// TestFixturesCoverSupportedTypes 每个支持的组件类型都必须有对应的样例
+func TestFixturesCoverSupportedTypes(t *testing.T) {
+	covered := make(map[string][]string)
+	for _, name := range schematest.Names() {
+		for component, types := range schematest.Types(schematest.MustLoad(t, name)) {
+			covered[component] = append(covered[component], types...)
+		}
+	}
+
+	for _, component := range []string{"llm", "memory", "prompt", "embedding", "retriever", "chain", "agent", "middleware"} {
+		supported := schema.SupportedTypes(component)
+		require.NotEmpty(t, supported, component)
+		for _, typ := range supported {
+			assert.Contains(t, covered[component], typ, "%s type '%s' has no fixture", component, typ)
+		}
+	}
+}
+
+// TestFixturesContract 样例可以加载、校验、往返序列化并创建完整的应用
+func TestFixturesContract(t *testing.T) {
+	factory := schema.NewFactory()
+
+	for _, name := range schematest.Names() {
+		t.Run(name, func(t *testing.T) {
+			config := schematest.MustLoad(t, name)
+			require.NoError(t, config.Validate())
+			assert.False(t, schema.ValidateConfig(config).HasErrors(), schema.ValidateConfig(config).String())
+
+			// 往返序列化不丢失字段
+			data, err := json.Marshal(config)
+			require.NoError(t, err)
+			roundTrip, err := schema.LoadConfigFromJSON(string(data))
+			require.NoError(t, err)
+			assert.Equal(t, config, roundTrip)
+
+			app, err := factory.CreateApplication(config)
+			require.NoError(t, err)
+			assert.Len(t, app.LLMs, len(config.LLMs))
+			assert.Len(t, app.Memories, len(config.Memories))
+			assert.Len(t, app.Prompts, len(config.Prompts))
+			assert.Len(t, app.Embeddings, len(config.Embeddings))
+			assert.Len(t, app.Retrievers, len(config.Retrievers))
+			assert.Len(t, app.Chains, len(config.Chains))
+			assert.Len(t, app.Agents, len(config.Agents))
+			assert.Len(t, app.Graphs, len(config.Graphs))
+		})
+	}
+
+	_, err := schematest.Load("missing")
+	assert.Error(t, err)
+}
+
+func TestLLMRefResolution(t *testing.T) {
+	factory := schema.NewFactory()
+
+	t.Run("缺失的引用在错误中给出名称", func(t *testing.T) {
+		config := schematest.MustLoad(t, schematest.Chains)
+		config.Chains["llm"].LLMRef = "unknown_llm"
+
+		_, err := factory.CreateApplication(config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown_llm")
+
+		_, err = factory.CreateChain(config.Chains["llm"], config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown_llm")
+	})
+
+	t.Run("记忆引用的LLM", func(t *testing.T) {
+		config := schematest.MustLoad(t, schematest.Memories)
+		config.Memories["token_buffer"].LLMRef = "unknown_llm"
+
+		_, err := factory.CreateMemory(config.Memories["token_buffer"], config.LLMs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown_llm")
+	})
+
+	t.Run("子链引用", func(t *testing.T) {
+		config := schematest.MustLoad(t, schematest.Chains)
+		config.Chains["sequential"].Chains = []string{"llm", "unknown_chain"}
+
+		_, err := factory.CreateChain(config.Chains["sequential"], config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown_chain")
+	})
+
+	t.Run("智能体引用的链", func(t *testing.T) {
+		config := schematest.MustLoad(t, schematest.Agents)
+		config.Agents["zero_shot_react"].ChainRef = "unknown_chain"
+
+		_, err := factory.CreateApplication(config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown_chain")
+	})
+}
+
+func TestMemoryWiring(t *testing.T) {
+	factory := schema.NewFactory()
+
+	config := schematest.MustLoad(t, schematest.Memories)
+	app, err := factory.CreateApplication(config)
+	require.NoError(t, err)
+
+	assert.IsType(t, &memory.ConversationBuffer{}, app.Memories["buffer"])
+	assert.IsType(t, &memory.ConversationWindowBuffer{}, app.Memories["window"])
+	assert.IsType(t, &memory.ConversationTokenBuffer{}, app.Memories["token_buffer"])
+	assert.IsType(t, memory.Simple{}, app.Memories["simple"])
+
+	window := app.Memories["window"].(*memory.ConversationWindowBuffer)
+	assert.Equal(t, 10, window.ConversationWindowSize)
+	assert.True(t, app.Memories["buffer"].(*memory.ConversationBuffer).ReturnMessages)
+
+	tokenBuffer := app.Memories["token_buffer"].(*memory.ConversationTokenBuffer)
+	assert.Equal(t, 1000, tokenBuffer.MaxTokenLimit)
+	assert.NotNil(t, tokenBuffer.LLM)
+
+	// 对话链使用 memory_ref 指定类型的记忆
+	chains := schematest.MustLoad(t, schematest.Chains)
+	chain, err := factory.CreateChain(chains.Chains["conversation"], chains)
+	require.NoError(t, err)
+	assert.IsType(t, &memory.ConversationTokenBuffer{}, chain.GetMemory())
+	assert.Equal(t, 500, chain.GetMemory().(*memory.ConversationTokenBuffer).MaxTokenLimit)
+}
+
+// recordingServer OpenAI兼容的测试服务，记录收到的请求
+type recordingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []map[string]interface{}
+	auth     []string
+}
+
+func newRecordingServer(t *testing.T) *recordingServer {
+	s := &recordingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]interface{}
+		_ = json.Unmarshal(body, &request)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, request)
+		s.auth = append(s.auth, r.Header.Get("Authorization"))
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","created":1,"model":"test","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *recordingServer) last() (map[string]interface{}, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil, ""
+	}
+	return s.requests[len(s.requests)-1], s.auth[len(s.auth)-1]
+}
+
+// maxTokens 返回请求中的最大token数，兼容 max_completion_tokens 字段
+func maxTokens(request map[string]interface{}) interface{} {
+	if v, ok := request["max_tokens"]; ok {
+		return v
+	}
+	return request["max_completion_tokens"]
+}
+
+// TestOptionPassthrough 配置中的模型、密钥、基础URL、温度与最大token数都会传到请求中
+func TestOptionPassthrough(t *testing.T) {
+	server := newRecordingServer(t)
+	config := schematest.MustLoad(t, schematest.LLMs)
+
+	for _, name := range []string{"openai", "deepseek", "kimi", "qwen", "zhipu", "siliconflow"} {
+		t.Run(name, func(t *testing.T) {
+			llmConfig := *config.LLMs[name]
+			llmConfig.BaseURL = server.URL
+			temperature := 0.25
+			maxTokenLimit := 64
+			llmConfig.Temperature = &temperature
+			llmConfig.MaxTokens = &maxTokenLimit
+
+			model, err := schema.CreateLLMFromConfig(&llmConfig)
+			require.NoError(t, err)
+
+			_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "你好")
+			require.NoError(t, err)
+
+			request, auth := server.last()
+			require.NotNil(t, request)
+			assert.Equal(t, llmConfig.Model, request["model"])
+			assert.Equal(t, "Bearer test-key", auth)
+			assert.InDelta(t, temperature, request["temperature"], 1e-9)
+			assert.EqualValues(t, maxTokenLimit, maxTokens(request))
+
+			// 调用方的选项优先于配置
+			_, err = llms.GenerateFromSinglePrompt(context.Background(), model, "你好", llms.WithTemperature(0.9))
+			require.NoError(t, err)
+			request, _ = server.last()
+			assert.InDelta(t, 0.9, request["temperature"], 1e-9)
+		})
+	}
+}
+
+func TestFixtureNames(t *testing.T) {
+	names := schematest.Names()
+	for _, name := range []string{schematest.LLMs, schematest.Memories, schematest.Prompts, schematest.Embeddings, schematest.Chains, schematest.Agents} {
+		assert.Contains(t, names, name)
+	}
+
+	data, err := schematest.JSON(schematest.LLMs)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(strings.TrimSpace(string(data)), "{"))
+}
@@ -0,0 +1,125 @@
+// Package schematest 提供各组件类型的标准配置样例，以及基于样例的工厂契约测试
+//
+// 每个样例都是一份完整的 schema.Config，覆盖对应组件类别支持的全部类型，API密钥使用
+// 固定的测试值，创建组件时不依赖环境变量或网络。新增配置字段或组件类型时，应同步更新样例，
+// 契约测试会确保已有配置仍能被加载、校验并创建。
+package schematest
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sjzsdu/langchaingo-cn/schema"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// 组件类别对应的样例名称
+const (
+	LLMs       = "llms"
+	Memories   = "memories"
+	Prompts    = "prompts"
+	Embeddings = "embeddings"
+	Chains     = "chains"
+	Agents     = "agents"
+	Graphs     = "graphs"
+)
+
+// Names 返回全部样例名称，按字母排序
+func Names() []string {
+	entries, err := fixtures.ReadDir("fixtures")
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSON 返回样例的原始JSON内容
+func JSON(name string) ([]byte, error) {
+	data, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("fixture '%s' not found, available: %s", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
+
+// Load 加载样例配置，每次调用返回新的实例，可以放心修改
+func Load(name string) (*schema.Config, error) {
+	data, err := JSON(name)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := schema.LoadConfigFromJSON(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fixture '%s': %w", name, err)
+	}
+	return config, nil
+}
+
+// MustLoad 在测试中加载样例，失败时终止测试
+func MustLoad(t testing.TB, name string) *schema.Config {
+	t.Helper()
+
+	config, err := Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// Types 返回样例中配置的组件类型，键为组件类别（llm, memory, prompt, embedding, chain, agent, middleware）
+func Types(config *schema.Config) map[string][]string {
+	types := make(map[string][]string)
+	add := func(component, typ string) {
+		for _, existing := range types[component] {
+			if existing == typ {
+				return
+			}
+		}
+		types[component] = append(types[component], typ)
+	}
+
+	for _, c := range config.LLMs {
+		add("llm", c.Type)
+	}
+	for _, c := range config.Memories {
+		add("memory", c.Type)
+	}
+	for _, c := range config.Prompts {
+		add("prompt", c.Type)
+	}
+	for _, c := range config.Embeddings {
+		add("embedding", c.Type)
+	}
+	for _, c := range config.Retrievers {
+		add("retriever", c.Type)
+	}
+	for _, c := range config.Chains {
+		add("chain", c.Type)
+	}
+	for _, c := range config.Agents {
+		add("agent", c.Type)
+	}
+	for _, c := range config.Graphs {
+		for _, m := range c.Middleware {
+			add("middleware", m.Type)
+		}
+	}
+
+	for _, list := range types {
+		sort.Strings(list)
+	}
+	return types
+}
@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsYAMLFile 根据扩展名（.yaml, .yml）判断配置文件是否为YAML格式，其余文件按JSON处理
+func IsYAMLFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// LoadConfigFromYAML 从YAML字符串加载配置
+func LoadConfigFromYAML(yamlStr string) (*Config, error) {
+	data, err := yamlToJSON([]byte(expandEnvVars(yamlStr)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+
+	return &config, nil
+}
+
+// LoadExecutorUsageConfigFromYAML 从YAML字符串加载Executor使用配置
+func LoadExecutorUsageConfigFromYAML(yamlStr string) (*ExecutorUsageConfig, error) {
+	data, err := yamlToJSON([]byte(expandEnvVars(yamlStr)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+
+	var config ExecutorUsageConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+
+	return &config, nil
+}
+
+// LoadChainUsageConfigFromYAML 从YAML字符串加载Chain使用配置
+func LoadChainUsageConfigFromYAML(yamlStr string) (*ChainUsageConfig, error) {
+	data, err := yamlToJSON([]byte(expandEnvVars(yamlStr)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+
+	var config ChainUsageConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+
+	return &config, nil
+}
+
+// MarshalYAML 将配置序列化为YAML
+// 字段名与JSON配置一致（使用 json 标签），字段顺序与结构体定义一致
+func MarshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON是YAML的子集，解析为节点树可以保留字段顺序，再转换为块格式输出
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// configFileData 将配置文件内容统一转换为JSON，YAML文件按扩展名识别
+func configFileData(filename string, data []byte) ([]byte, error) {
+	if !IsYAMLFile(filename) {
+		return data, nil
+	}
+	return yamlToJSON(data)
+}
+
+// marshalConfigFile 按文件扩展名将配置序列化为YAML或美化格式的JSON
+func marshalConfigFile(filename string, v interface{}) ([]byte, error) {
+	if IsYAMLFile(filename) {
+		return MarshalYAML(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// yamlToJSON 将YAML转换为JSON，使配置结构体的 json 标签和校验逻辑对两种格式一致生效
+func yamlToJSON(data []byte) ([]byte, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	return json.Marshal(normalizeYAML(raw))
+}
+
+// blockStyle 清除JSON解析出的流式风格和引号风格，使输出为常规的YAML块格式，
+// 并省略值为 null 的字段（与字段缺失等价）
+func blockStyle(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode {
+		node.Style = 0
+		if node.Kind == yaml.MappingNode {
+			content := node.Content[:0]
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i+1].Tag != "!!null" {
+					content = append(content, node.Content[i], node.Content[i+1])
+				}
+			}
+			node.Content = content
+		}
+		for _, child := range node.Content {
+			blockStyle(child)
+		}
+		return
+	}
+
+	if node.Tag != "!!str" {
+		node.Style = 0
+		return
+	}
+	// 多行文本（如提示模板）使用字面量风格，其余字符串仅在去掉引号后含义不变时去掉引号
+	if strings.Contains(node.Value, "\n") {
+		node.Style = yaml.LiteralStyle
+		return
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(node.Value), &value); err == nil && value == node.Value {
+		node.Style = 0
+	}
+}